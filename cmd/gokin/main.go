@@ -7,6 +7,7 @@ import (
 
 	"gokin/internal/app"
 	"gokin/internal/config"
+	"gokin/internal/security"
 	"gokin/internal/setup"
 
 	"github.com/spf13/cobra"
@@ -20,6 +21,11 @@ var (
 )
 
 func main() {
+	// Sandboxed bash commands re-execute this binary to set up isolation
+	if security.IsSandboxInit() {
+		security.RunSandboxInit()
+	}
+
 	rootCmd := &cobra.Command{
 		Use:   "gokin",
 		Short: "AI-powered CLI assistant for code",
@@ -46,7 +52,20 @@ and editing files, running commands, and more.`,
 	// Update command
 	rootCmd.AddCommand(newUpdateCmd())
 
+	// Headless run command
+	rootCmd.AddCommand(newRunCmd())
+
+	// MCP server command
+	rootCmd.AddCommand(newMCPCmd())
+
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			if exitErr.err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", exitErr.err)
+			}
+			os.Exit(exitErr.code)
+		}
 		os.Exit(1)
 	}
 }
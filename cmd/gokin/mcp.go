@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"gokin/internal/app"
+	"gokin/internal/commands"
+	"gokin/internal/config"
+	"gokin/internal/mcp"
+
+	"github.com/spf13/cobra"
+)
+
+func newMCPCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Model Context Protocol commands",
+	}
+	cmd.AddCommand(newMCPServeCmd())
+	cmd.AddCommand(newMCPLoginCmd())
+	cmd.AddCommand(newMCPLogoutCmd())
+	return cmd
+}
+
+func newMCPServeCmd() *cobra.Command {
+	var (
+		httpAddr       string
+		httpPath       string
+		token          string
+		allowedOrigins []string
+		toolNames      []string
+		permissionMode string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve gokin's tools to other agents and editors over MCP",
+		Long: `Expose gokin's tools (read, edit, grep, semantic_search, code_graph,
+run_tests, ...) as an MCP server for the project in the current directory.
+
+By default the server speaks MCP over stdio, for clients that start it as a
+subprocess. With --http it serves the streamable HTTP transport instead.
+
+Remote calls are subject to the same permission rules, project path
+confinement and audit logging as the model's own calls. Calls that would
+prompt for permission are answered by --permission-mode.`,
+		Example: `  gokin mcp serve
+  gokin mcp serve --tools read,grep,semantic_search,code_graph
+  gokin mcp serve --http 127.0.0.1:8765 --permission-mode accept-edits`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mode := app.PermissionMode(permissionMode)
+			switch mode {
+			case app.PermissionModeDeny, app.PermissionModeAcceptEdits, app.PermissionModeAllowAll:
+			default:
+				return fmt.Errorf("invalid --permission-mode %q (deny, accept-edits, allow-all)", permissionMode)
+			}
+
+			if token == "" {
+				token = os.Getenv("GOKIN_MCP_TOKEN")
+			}
+			if httpAddr != "" && token == "" && !isLoopbackAddr(httpAddr) {
+				return fmt.Errorf("refusing to serve on %s without a token (set --token or GOKIN_MCP_TOKEN)", httpAddr)
+			}
+
+			cfg, err := config.Load()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			cfg.Version = version
+			if model != "" {
+				cfg.Model.Name = model
+			}
+			if err := cfg.Validate(); err != nil {
+				if errors.Is(err, config.ErrMissingAuth) {
+					err = fmt.Errorf("%w (run 'gokin --setup' first)", err)
+				}
+				return err
+			}
+
+			workDir, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get working directory: %w", err)
+			}
+
+			application, err := app.NewHeadless(cfg, workDir)
+			if err != nil {
+				return fmt.Errorf("failed to create application: %w", err)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if httpAddr != "" {
+				fmt.Fprintf(os.Stderr, "gokin MCP server listening on http://%s%s\n", httpAddr, httpPath)
+			}
+			return application.ServeMCP(ctx, app.MCPServeOptions{
+				HTTPAddr: httpAddr,
+				HTTPPath: httpPath,
+				HTTP: mcp.HTTPOptions{
+					Token:          token,
+					AllowedOrigins: allowedOrigins,
+				},
+				Tools:          toolNames,
+				PermissionMode: mode,
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&httpAddr, "http", "", "serve streamable HTTP on this address (e.g. 127.0.0.1:8765) instead of stdio")
+	cmd.Flags().StringVar(&httpPath, "path", "/mcp", "URL path of the HTTP endpoint")
+	cmd.Flags().StringVar(&token, "token", "", "bearer token required from HTTP clients (default $GOKIN_MCP_TOKEN)")
+	cmd.Flags().StringSliceVar(&allowedOrigins, "allow-origin", nil, "browser origins allowed besides localhost")
+	cmd.Flags().StringSliceVar(&toolNames, "tools", nil, "comma-separated tools to expose (default: all non-interactive tools)")
+	cmd.Flags().StringVar(&permissionMode, "permission-mode", string(app.PermissionModeDeny), "how to answer permission prompts: deny, accept-edits, allow-all")
+
+	return cmd
+}
+
+func newMCPLoginCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "login <server>",
+		Short: "Authorize gokin with a remote MCP server via OAuth",
+		Long: `Run the OAuth authorization flow for an http MCP server from the config.
+A browser window opens for you to sign in; the tokens are stored in
+mcp_tokens.json in the gokin config directory and refreshed automatically.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			err = app.MCPLogin(ctx, cfg, args[0], mcp.LoginOptions{
+				OpenBrowser: commands.OpenBrowser,
+				Status:      func(msg string) { fmt.Fprintln(os.Stderr, msg) },
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Authorized with %s.\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newMCPLogoutCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:          "logout <server>",
+		Short:        "Remove the stored OAuth tokens of an MCP server",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			removed, err := app.MCPLogout(args[0])
+			if err != nil {
+				return err
+			}
+			if !removed {
+				fmt.Printf("No tokens stored for %s.\n", args[0])
+				return nil
+			}
+			fmt.Printf("Logged out of %s.\n", args[0])
+			return nil
+		},
+	}
+}
+
+// isLoopbackAddr reports whether a listen address only accepts local connections.
+func isLoopbackAddr(addr string) bool {
+	host := addr
+	if i := strings.LastIndex(addr, ":"); i >= 0 {
+		host = strings.Trim(addr[:i], "[]")
+	}
+	switch host {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return strings.HasPrefix(host, "127.")
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"gokin/internal/app"
+	"gokin/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// exitCodeError carries a specific process exit code out of a command.
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	if e.err != nil {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("exit code %d", e.code)
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+func newRunCmd() *cobra.Command {
+	var (
+		prompt         string
+		outputFormat   string
+		permissionMode string
+		maxTurns       int
+		maxTokens      int
+		recordPath     string
+		replayPath     string
+		mockPath       string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run a single prompt non-interactively",
+		Long: `Run a single prompt through the agent without the interactive UI.
+
+The prompt is taken from --prompt and/or standard input. Progress and the
+final result are written to stdout in the selected output format.
+
+Exit codes:
+  0  success
+  1  error
+  2  a tool call failed
+  3  a tool call was denied by the permission policy
+  4  the turn or token budget was exhausted
+
+--record saves every model request and response stream to a cassette file;
+--replay answers from such a cassette and --mock from a YAML script of model
+turns, both without network access, for deterministic end-to-end tests.`,
+		Example: `  gokin run -p "summarize the changes in this branch"
+  git diff | gokin run -p "review this diff" --output-format json
+  gokin run -p "fix the failing test" --permission-mode accept-edits --max-turns 20
+  gokin run -p "rename Foo to Bar" --record testdata/rename.yaml
+  gokin run -p "rename Foo to Bar" --replay testdata/rename.yaml --permission-mode allow-all`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format := app.OutputFormat(outputFormat)
+			switch format {
+			case app.OutputText, app.OutputJSON, app.OutputStreamJSON:
+			default:
+				return &exitCodeError{code: app.ExitError, err: fmt.Errorf("invalid --output-format %q (text, json, stream-json)", outputFormat)}
+			}
+
+			mode := app.PermissionMode(permissionMode)
+			switch mode {
+			case app.PermissionModeDeny, app.PermissionModeAcceptEdits, app.PermissionModeAllowAll:
+			default:
+				return &exitCodeError{code: app.ExitError, err: fmt.Errorf("invalid --permission-mode %q (deny, accept-edits, allow-all)", permissionMode)}
+			}
+
+			fullPrompt, err := readRunPrompt(prompt)
+			if err != nil {
+				return &exitCodeError{code: app.ExitError, err: err}
+			}
+
+			cfg, err := config.Load()
+			if err != nil {
+				return &exitCodeError{code: app.ExitError, err: fmt.Errorf("failed to load config: %w", err)}
+			}
+			cfg.Version = version
+			if model != "" {
+				cfg.Model.Name = model
+			}
+			if recordPath != "" {
+				cfg.Cassette.Record = recordPath
+			}
+			if replayPath != "" {
+				cfg.Cassette.Replay = replayPath
+			}
+			if mockPath != "" {
+				cfg.Cassette.Mock = mockPath
+			}
+
+			// No setup wizard here: there is nobody to answer it.
+			if err := cfg.Validate(); err != nil {
+				if errors.Is(err, config.ErrMissingAuth) {
+					err = fmt.Errorf("%w (run 'gokin --setup' first)", err)
+				}
+				return &exitCodeError{code: app.ExitError, err: err}
+			}
+
+			workDir, err := os.Getwd()
+			if err != nil {
+				return &exitCodeError{code: app.ExitError, err: fmt.Errorf("failed to get working directory: %w", err)}
+			}
+
+			application, err := app.NewHeadless(cfg, workDir)
+			if err != nil {
+				return &exitCodeError{code: app.ExitError, err: fmt.Errorf("failed to create application: %w", err)}
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			result := application.RunHeadless(ctx, app.HeadlessOptions{
+				Prompt:         fullPrompt,
+				OutputFormat:   format,
+				PermissionMode: mode,
+				MaxTurns:       maxTurns,
+				MaxTokens:      maxTokens,
+				Output:         os.Stdout,
+			})
+
+			if result.ExitCode != app.ExitSuccess {
+				var runErr error
+				if result.Error != "" && format == app.OutputText {
+					runErr = errors.New(result.Error)
+				}
+				return &exitCodeError{code: result.ExitCode, err: runErr}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&prompt, "prompt", "p", "", "prompt to run (stdin is appended when piped)")
+	cmd.Flags().StringVar(&outputFormat, "output-format", string(app.OutputText), "output format: text, json, stream-json")
+	cmd.Flags().StringVar(&permissionMode, "permission-mode", string(app.PermissionModeDeny), "how to answer permission prompts: deny, accept-edits, allow-all")
+	cmd.Flags().IntVar(&maxTurns, "max-turns", 0, "maximum number of model requests (0 = default)")
+	cmd.Flags().IntVar(&maxTokens, "max-tokens", 0, "maximum total tokens to spend (0 = unlimited)")
+	cmd.Flags().StringVar(&recordPath, "record", "", "record model traffic to this cassette file")
+	cmd.Flags().StringVar(&replayPath, "replay", "", "answer from a recorded cassette instead of the model")
+	cmd.Flags().StringVar(&mockPath, "mock", "", "answer from a YAML script of model turns instead of the model")
+
+	return cmd
+}
+
+// readRunPrompt combines the --prompt flag with piped standard input.
+func readRunPrompt(prompt string) (string, error) {
+	var stdinText string
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read stdin: %w", err)
+		}
+		stdinText = strings.TrimSpace(string(data))
+	}
+
+	prompt = strings.TrimSpace(prompt)
+	switch {
+	case prompt != "" && stdinText != "":
+		return prompt + "\n\n" + stdinText, nil
+	case prompt != "":
+		return prompt, nil
+	case stdinText != "":
+		return stdinText, nil
+	default:
+		return "", errors.New("no prompt given: use --prompt or pipe text to stdin")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"gokin/internal/client"
 	"gokin/internal/config"
 	ctxmgr "gokin/internal/context"
+	"gokin/internal/git"
 	"gokin/internal/logging"
 	"gokin/internal/memory"
 	"gokin/internal/permission"
@@ -116,6 +117,9 @@ type Agent struct {
 	autoCheckpoint     bool // Enable auto-checkpoint every N turns
 	checkpointInterval int  // Number of turns between auto-checkpoints
 	lastCheckpointTurn int  // Last turn when checkpoint was saved
+
+	// Git worktree the agent edits instead of the main tree, if isolated
+	worktree *git.Worktree
 }
 
 // NewAgent creates a new agent with the specified type and filtered tools.
@@ -168,10 +172,13 @@ func NewAgent(agentType AgentType, c client.Client, baseRegistry tools.ToolRegis
 		}
 	}
 
-	// Wire up HistorySearch tool (Custom Improvement)
+	// Wire up HistorySearch tool (Custom Improvement). The tool is shared
+	// with the base registry, so the agent gets a copy searching its own
+	// history.
 	if ht, ok := agent.registry.Get("history_search"); ok {
 		if htt, ok := ht.(*tools.HistorySearchTool); ok {
-			htt.SetHistoryGetter(func() []*genai.Content { return agent.history })
+			agent.registry.Unregister(htt.Name())
+			_ = agent.registry.Register(htt.WithHistoryGetter(func() []*genai.Content { return agent.history }))
 		}
 	}
 
@@ -266,10 +273,13 @@ func NewAgentWithDynamicType(dynType *DynamicAgentType, c client.Client, baseReg
 		}
 	}
 
-	// Wire up HistorySearch tool (Custom Improvement)
+	// Wire up HistorySearch tool (Custom Improvement). The tool is shared
+	// with the base registry, so the agent gets a copy searching its own
+	// history.
 	if ht, ok := agent.registry.Get("history_search"); ok {
 		if htt, ok := ht.(*tools.HistorySearchTool); ok {
-			htt.SetHistoryGetter(func() []*genai.Content { return agent.history })
+			agent.registry.Unregister(htt.Name())
+			_ = agent.registry.Register(htt.WithHistoryGetter(func() []*genai.Content { return agent.history }))
 		}
 	}
 
@@ -2,12 +2,15 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"gokin/internal/client"
 	"gokin/internal/config"
+	"gokin/internal/git"
+	"gokin/internal/hooks"
 	"gokin/internal/logging"
 	"gokin/internal/memory"
 	"gokin/internal/permission"
@@ -93,6 +96,15 @@ type Runner struct {
 	// Sub-agent activity callback for UI updates
 	onSubAgentActivity func(agentID, agentType, toolName string, args map[string]any, status string)
 
+	// Hooks run when a sub-agent finishes (subagent_stop)
+	hooks *hooks.Manager
+
+	// Git worktree isolation: active worktrees by agent ID
+	worktrees              map[string]*git.Worktree
+	worktreeMerger         WorktreeMerger
+	worktreeToolConfigurer func(registry tools.ToolRegistry, workDir string)
+	mergeMu                sync.Mutex // Serializes merging worktrees back
+
 	mu sync.RWMutex
 }
 
@@ -359,6 +371,53 @@ func (r *Runner) SetOnSubAgentActivity(fn func(agentID, agentType, toolName stri
 	r.mu.Unlock()
 }
 
+// SetHooks sets the hooks manager for subagent_stop hooks.
+func (r *Runner) SetHooks(hm *hooks.Manager) {
+	r.mu.Lock()
+	r.hooks = hm
+	r.mu.Unlock()
+}
+
+// runAgent runs an agent and then its subagent_stop hooks. An isolated
+// agent's worktree is merged back first. Hook context is appended to the
+// output, and a deny marks a successful result as failed so the caller sees
+// the gate fail.
+func (r *Runner) runAgent(ctx context.Context, agent *Agent, prompt string) (*AgentResult, error) {
+	if agent.worktree != nil {
+		prompt = worktreePrompt(agent.worktree, prompt)
+	}
+	result, err := agent.Run(ctx, prompt)
+	if agent.worktree != nil {
+		r.finishWorktree(ctx, agent, result)
+	}
+
+	r.mu.RLock()
+	hm := r.hooks
+	r.mu.RUnlock()
+	if hm == nil || result == nil {
+		return result, err
+	}
+
+	outcome := hm.RunSubagentStop(ctx, hooks.AgentInfo{
+		ID:         result.AgentID,
+		Type:       string(result.Type),
+		Status:     string(result.Status),
+		Output:     result.Output,
+		Error:      result.Error,
+		DurationMs: result.Duration.Milliseconds(),
+	})
+	if notes := outcome.Notes(); notes != "" {
+		result.Output += "\n\n" + notes
+	}
+	if outcome.Denied && err == nil {
+		result.Status = AgentStatusFailed
+		result.Completed = false
+		result.Error = fmt.Sprintf("blocked by hook %q: %s", outcome.DeniedBy, outcome.Reason)
+		err = errors.New(result.Error)
+	}
+	return result, err
+}
+
 // GetPromptOptimizer returns the prompt optimizer.
 func (r *Runner) GetPromptOptimizer() *PromptOptimizer {
 	r.mu.RLock()
@@ -472,18 +531,24 @@ func (r *Runner) Spawn(ctx context.Context, agentType string, prompt string, max
 
 	// Check for dynamic type first
 	var agent *Agent
+	var wt *git.Worktree
 	if typeRegistry != nil {
 		if dynType, ok := typeRegistry.GetDynamic(agentType); ok {
 			// Create agent with dynamic type configuration
-			agent = NewAgentWithDynamicType(dynType, r.client, r.baseRegistry, r.workDir, maxTurns, model, r.permissions, ctxCfg)
+			var registry tools.ToolRegistry
+			registry, wt = r.isolate(ctx, dynType.AllowedTools)
+			agent = NewAgentWithDynamicType(dynType, r.client, registry, r.workDir, maxTurns, model, r.permissions, ctxCfg)
 		}
 	}
 
 	// Fall back to built-in types
 	if agent == nil {
 		at := ParseAgentType(agentType)
-		agent = NewAgent(at, r.client, r.baseRegistry, r.workDir, maxTurns, model, r.permissions, ctxCfg)
+		var registry tools.ToolRegistry
+		registry, wt = r.isolate(ctx, at.AllowedTools())
+		agent = NewAgent(at, r.client, registry, r.workDir, maxTurns, model, r.permissions, ctxCfg)
 	}
+	r.attachWorktree(agent, wt)
 
 	// Set input callback
 	if onInput != nil {
@@ -543,7 +608,7 @@ func (r *Runner) Spawn(ctx context.Context, agentType string, prompt string, max
 
 	// Run agent synchronously
 	startTime := time.Now()
-	result, err := agent.Run(ctx, prompt)
+	result, err := r.runAgent(ctx, agent, prompt)
 	duration := time.Since(startTime)
 
 	// Report activity after completion
@@ -601,7 +666,9 @@ func (r *Runner) SpawnWithContext(
 	if !skipPermissions {
 		perms = r.permissions
 	}
-	agent := NewAgent(at, r.client, r.baseRegistry, r.workDir, maxTurns, model, perms, ctxCfg)
+	registry, wt := r.isolate(ctx, at.AllowedTools())
+	agent := NewAgent(at, r.client, registry, r.workDir, maxTurns, model, perms, ctxCfg)
+	r.attachWorktree(agent, wt)
 
 	// Set input callback
 	if onInput != nil {
@@ -649,7 +716,7 @@ func (r *Runner) SpawnWithContext(
 
 	r.reportActivity()
 
-	result, err := agent.Run(ctx, prompt)
+	result, err := r.runAgent(ctx, agent, prompt)
 
 	r.reportActivity()
 	r.saveAgentState(agent)
@@ -670,7 +737,9 @@ func (r *Runner) SpawnAsync(ctx context.Context, agentType string, prompt string
 	errorStore := r.errorStore
 	predictor := r.predictor
 	r.mu.RUnlock()
-	agent := NewAgent(at, r.client, r.baseRegistry, r.workDir, maxTurns, model, r.permissions, ctxCfg)
+	registry, wt := r.isolate(ctx, at.AllowedTools())
+	agent := NewAgent(at, r.client, registry, r.workDir, maxTurns, model, r.permissions, ctxCfg)
+	r.attachWorktree(agent, wt)
 
 	// Set up messenger for inter-agent communication
 	if r.messengerFactory != nil {
@@ -738,7 +807,7 @@ func (r *Runner) SpawnAsync(ctx context.Context, agentType string, prompt string
 		default:
 		}
 
-		result, err := agent.Run(ctx, prompt)
+		result, err := r.runAgent(ctx, agent, prompt)
 
 		// Ensure result is never nil
 		if result == nil {
@@ -795,7 +864,9 @@ func (r *Runner) SpawnAsyncWithStreaming(
 	promptOpt := r.promptOptimizer
 	r.mu.RUnlock()
 
-	agent := NewAgent(at, r.client, r.baseRegistry, r.workDir, maxTurns, model, r.permissions, ctxCfg)
+	registry, wt := r.isolate(ctx, at.AllowedTools())
+	agent := NewAgent(at, r.client, registry, r.workDir, maxTurns, model, r.permissions, ctxCfg)
+	r.attachWorktree(agent, wt)
 
 	// Set up streaming callback
 	if onText != nil {
@@ -923,7 +994,7 @@ func (r *Runner) SpawnAsyncWithStreaming(
 		}
 
 		startTime := time.Now()
-		result, err := agent.Run(ctx, prompt)
+		result, err := r.runAgent(ctx, agent, prompt)
 		duration := time.Since(startTime)
 
 		// Ensure result is never nil
@@ -996,7 +1067,9 @@ func (r *Runner) SpawnMultiple(ctx context.Context, tasks []AgentTask) ([]string
 			r.mu.RLock()
 			ctxCfg := r.ctxCfg
 			r.mu.RUnlock()
-			agent := NewAgent(t.Type, r.client, r.baseRegistry, r.workDir, t.MaxTurns, t.Model, r.permissions, ctxCfg)
+			registry, wt := r.isolate(ctx, t.Type.AllowedTools())
+			agent := NewAgent(t.Type, r.client, registry, r.workDir, t.MaxTurns, t.Model, r.permissions, ctxCfg)
+			r.attachWorktree(agent, wt)
 
 			// Set up messenger for inter-agent communication
 			if r.messengerFactory != nil {
@@ -1008,7 +1081,7 @@ func (r *Runner) SpawnMultiple(ctx context.Context, tasks []AgentTask) ([]string
 			r.agents[agent.ID] = agent
 			r.mu.Unlock()
 
-			result, err := agent.Run(ctx, t.Prompt)
+			result, err := r.runAgent(ctx, agent, t.Prompt)
 
 			mu.Lock()
 			ids[idx] = agent.ID
@@ -1270,7 +1343,7 @@ func (r *Runner) Resume(ctx context.Context, agentID string, prompt string) (str
 	r.mu.Unlock()
 
 	// Run agent with the new prompt (continuing from previous context)
-	result, err := agent.Run(ctx, prompt)
+	result, err := r.runAgent(ctx, agent, prompt)
 
 	// Save updated state
 	if r.store != nil {
@@ -1367,7 +1440,7 @@ func (r *Runner) ResumeAsync(ctx context.Context, agentID string, prompt string)
 		default:
 		}
 
-		result, err := agent.Run(ctx, prompt)
+		result, err := r.runAgent(ctx, agent, prompt)
 
 		// Ensure result is never nil
 		if result == nil {
@@ -1417,8 +1490,11 @@ func (r *Runner) saveAgentState(agent *Agent) {
 	}
 }
 
-// Close flushes all agent data (project learning) to prevent data loss on shutdown.
+// Close flushes all agent data (project learning) to prevent data loss on
+// shutdown and removes the worktrees of agents still running.
 func (r *Runner) Close() {
+	r.closeWorktrees()
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
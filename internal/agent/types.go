@@ -49,6 +49,7 @@ func (t AgentType) AllowedTools() []string {
 			// Planning tools
 			"enter_plan_mode", "exit_plan_mode",
 			"update_plan_progress", "get_plan_status",
+			"contract_propose", "contract_status",
 		}
 	case AgentTypeGuide:
 		// Documentation/search focused
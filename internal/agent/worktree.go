@@ -0,0 +1,211 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gokin/internal/git"
+	"gokin/internal/logging"
+	"gokin/internal/tools"
+)
+
+// worktreeTools are the tools bound to a work dir. Isolated agents get
+// copies of them rooted in their worktree.
+var worktreeTools = []string{
+	"read", "write", "edit", "bash", "glob", "grep", "list_dir", "tree",
+	"batch", "refactor", "copy", "move", "delete", "mkdir", "run_tests",
+	"git_log", "git_blame", "git_diff", "git_status", "git_add",
+	"git_commit", "git_branch", "git_pr",
+}
+
+// fileChangingTools are the tools that let an agent change files. Agents
+// without any of them gain nothing from a worktree.
+var fileChangingTools = []string{
+	"write", "edit", "bash", "batch", "refactor", "copy", "move", "delete", "mkdir",
+}
+
+// WorktreeMerger merges the changes an isolated agent made in its worktree
+// back into the main working tree. It returns a summary for the agent's
+// output and whether the worktree branch must be kept, e.g. because some
+// changes could not be merged.
+type WorktreeMerger func(ctx context.Context, agentID string, wt *git.Worktree) (summary string, keepBranch bool, err error)
+
+// SetWorktreeMerger sets how isolated agents' changes are merged back.
+// Without a merger their changes are left on the worktree branch.
+func (r *Runner) SetWorktreeMerger(merger WorktreeMerger) {
+	r.mu.Lock()
+	r.worktreeMerger = merger
+	r.mu.Unlock()
+}
+
+// SetWorktreeToolConfigurer sets a function that applies the app's tool
+// settings (sandbox, allowed dirs, ...) to the tools created for a worktree
+// rooted at workDir.
+func (r *Runner) SetWorktreeToolConfigurer(configure func(registry tools.ToolRegistry, workDir string)) {
+	r.mu.Lock()
+	r.worktreeToolConfigurer = configure
+	r.mu.Unlock()
+}
+
+// isolate returns the tool registry for a new agent. If ctx requests
+// worktree isolation and the agent can change files, the agent gets a git
+// worktree and tools rooted in it; otherwise the base registry is returned
+// and the worktree is nil. Isolation falls back to the main tree when the
+// worktree cannot be created.
+func (r *Runner) isolate(ctx context.Context, allowedTools []string) (tools.ToolRegistry, *git.Worktree) {
+	if !tools.WantsWorktreeIsolation(ctx) || !changesFiles(allowedTools) {
+		return r.baseRegistry, nil
+	}
+
+	wt, err := git.CreateWorktree(ctx, r.workDir, "agent-"+generateAgentID())
+	if err != nil {
+		logging.Warn("worktree isolation unavailable, using the main tree", "error", err)
+		return r.baseRegistry, nil
+	}
+
+	local := tools.DefaultRegistry(wt.Dir())
+	bound := make(map[string]bool, len(worktreeTools))
+	for _, name := range worktreeTools {
+		bound[name] = true
+	}
+
+	registry := tools.NewRegistry()
+	for _, tool := range r.baseRegistry.List() {
+		name := tool.Name()
+		// Agents spawned from the worktree would edit the main tree, and
+		// language servers serve the main tree
+		if name == "task" || strings.HasPrefix(name, "lsp_") {
+			continue
+		}
+		if bound[name] {
+			if t, ok := local.Get(name); ok {
+				tool = t
+			}
+		}
+		_ = registry.Register(tool)
+	}
+
+	r.mu.Lock()
+	configure := r.worktreeToolConfigurer
+	r.mu.Unlock()
+	if configure != nil {
+		configure(registry, wt.Dir())
+	}
+	return registry, wt
+}
+
+// changesFiles reports whether an agent with the allowed tools (nil means
+// all) can change files.
+func changesFiles(allowedTools []string) bool {
+	if allowedTools == nil {
+		return true
+	}
+	for _, name := range allowedTools {
+		for _, t := range fileChangingTools {
+			if name == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// attachWorktree binds an agent to its worktree so runAgent merges and
+// removes it when the agent finishes.
+func (r *Runner) attachWorktree(agent *Agent, wt *git.Worktree) {
+	if wt == nil {
+		return
+	}
+	agent.worktree = wt
+
+	r.mu.Lock()
+	if r.worktrees == nil {
+		r.worktrees = make(map[string]*git.Worktree)
+	}
+	r.worktrees[agent.ID] = wt
+	r.mu.Unlock()
+}
+
+// worktreePrompt tells an isolated agent where it works.
+func worktreePrompt(wt *git.Worktree, prompt string) string {
+	return fmt.Sprintf("You are working in an isolated git worktree at %s. "+
+		"Read and edit files there, not in the main project directory; "+
+		"your changes are merged back into the project when you finish.\n\n%s", wt.Dir(), prompt)
+}
+
+// finishWorktree merges an isolated agent's changes back and removes its
+// worktree, adding the outcome to the result. Merges are serialized so
+// concurrent agents are merged one at a time against the latest tree.
+// Changes of cancelled agents are not merged but kept on their branch.
+func (r *Runner) finishWorktree(ctx context.Context, agent *Agent, result *AgentResult) {
+	wt := agent.worktree
+	agent.worktree = nil
+
+	r.mu.Lock()
+	delete(r.worktrees, agent.ID)
+	merger := r.worktreeMerger
+	r.mu.Unlock()
+
+	cancelled := ctx.Err() != nil
+	// Finish merging and cleaning up even if the agent was cancelled
+	ctx = context.WithoutCancel(ctx)
+
+	var summary string
+	keepBranch := true
+	if merger != nil && !cancelled {
+		r.mergeMu.Lock()
+		var err error
+		summary, keepBranch, err = merger(ctx, agent.ID, wt)
+		r.mergeMu.Unlock()
+		if err != nil {
+			logging.Warn("failed to merge agent worktree", "agent_id", agent.ID, "error", err)
+			summary = fmt.Sprintf("Failed to merge the worktree: %v", err)
+			keepBranch = true
+		}
+	}
+
+	var err error
+	if keepBranch {
+		var kept bool
+		kept, err = wt.Abandon(ctx, fmt.Sprintf("gokin: changes from agent %s", agent.ID))
+		if kept {
+			summary = joinSummary(summary, fmt.Sprintf("The agent's changes are on branch %s.", wt.Branch))
+		}
+	} else {
+		err = wt.Remove(ctx, false)
+	}
+	if err != nil {
+		logging.Warn("failed to remove agent worktree", "agent_id", agent.ID, "path", wt.Path, "error", err)
+	}
+
+	if result != nil && summary != "" {
+		result.Output = joinSummary(result.Output, "## Worktree\n"+summary)
+	}
+}
+
+// joinSummary appends a paragraph to text.
+func joinSummary(text, paragraph string) string {
+	if text == "" {
+		return paragraph
+	}
+	return text + "\n\n" + paragraph
+}
+
+// closeWorktrees removes the worktrees of agents still running on exit,
+// keeping any changes on their branches.
+func (r *Runner) closeWorktrees() {
+	r.mu.Lock()
+	worktrees := r.worktrees
+	r.worktrees = nil
+	r.mu.Unlock()
+
+	for id, wt := range worktrees {
+		kept, err := wt.Abandon(context.Background(), fmt.Sprintf("gokin: changes from interrupted agent %s", id))
+		if err != nil {
+			logging.Warn("failed to remove agent worktree", "agent_id", id, "path", wt.Path, "error", err)
+		} else if kept {
+			logging.Info("kept changes of interrupted agent", "agent_id", id, "branch", wt.Branch)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,14 +15,18 @@ import (
 	"gokin/internal/audit"
 	"gokin/internal/cache"
 	"gokin/internal/chat"
+	"gokin/internal/checkpoint"
 	"gokin/internal/client"
 	"gokin/internal/commands"
 	"gokin/internal/config"
+	"gokin/internal/contract"
 	"gokin/internal/git"
 	appcontext "gokin/internal/context"
 	"gokin/internal/hooks"
 	"gokin/internal/logging"
+	"gokin/internal/lsp"
 	"gokin/internal/mcp"
+	"gokin/internal/mention"
 	"gokin/internal/permission"
 	"gokin/internal/plan"
 	"gokin/internal/ratelimit"
@@ -102,6 +107,7 @@ type App struct {
 	session  *chat.Session
 	tui      *ui.Model
 	program  *tea.Program
+	headless bool // Running via gokin run (no TUI)
 
 	// Application context for cancellation
 	ctx    context.Context
@@ -121,12 +127,18 @@ type App struct {
 	questionResponseChan chan string
 
 	// Diff preview handling
-	diffResponseChan chan ui.DiffDecision
+	diffResponseChan      chan ui.DiffResult
+	multiDiffResponseChan chan map[string]ui.DiffResult
 
 	// Plan management
 	planManager      *plan.Manager
 	planApprovalChan chan plan.ApprovalDecision
 
+	// Contract management (nil when disabled). Contract approval reuses the
+	// plan approval dialog; contractApprovalPending routes its feedback.
+	contractManager         *contract.Manager
+	contractApprovalPending atomic.Bool
+
 	// Hooks management
 	hooksManager *hooks.Manager
 
@@ -146,12 +158,16 @@ type App struct {
 	totalInputTokens  int
 	totalOutputTokens int
 
+	// API usage summed over every model request, for /cost
+	apiUsage commands.APIUsage
+
 	// Response metadata tracking
 	responseStartTime time.Time
 	responseToolsUsed []string
 
 	// Session persistence
 	sessionManager *chat.SessionManager
+	sessionArchive *chat.Archive
 
 	// New feature integrations
 	searchCache     *cache.SearchCache
@@ -185,6 +201,18 @@ type App struct {
 	// MCP (Model Context Protocol)
 	mcpManager *mcp.Manager
 
+	// @file, @dir, @symbol and @url mentions in prompts
+	mentionResolver *mention.Resolver
+
+	// Working tree checkpoints taken at every user turn
+	checkpointStore   *checkpoint.Store
+	checkpointMu      sync.Mutex
+	checkpointHistory map[string][]*genai.Content // Conversation before each turn of this session
+	checkpointOrder   []string
+
+	// Language servers for navigation tools and post-edit diagnostics
+	lspManager *lsp.Manager
+
 	// Streaming token estimation
 	streamedChars int // Accumulated chars during current streaming session
 
@@ -209,6 +237,7 @@ type App struct {
 
 	// Pending message queue
 	pendingMessage string
+	pendingScope   *commands.PromptOptions // Options of a pending prompt submitted by a command
 	pendingMu      sync.Mutex
 }
 
@@ -226,17 +255,7 @@ func (a *App) Run() error {
 	// before tool creation, so PathValidator gets correct directories.
 
 	// Configure logging to file to avoid TUI interference
-	configDir, err := appcontext.GetConfigDir()
-	if err == nil && a.config.Logging.Level != "" {
-		level := logging.ParseLevel(a.config.Logging.Level)
-		if err := logging.EnableFileLogging(configDir, level); err != nil {
-			// Silently continue with logging disabled
-			logging.DisableLogging()
-		}
-	} else {
-		// Disable logging if no config dir or level not set
-		logging.DisableLogging()
-	}
+	a.configureLogging()
 
 	// === Task 5.7: Detect project context once at startup ===
 	a.detectedProjectContext = a.detectProjectContext()
@@ -282,6 +301,13 @@ func (a *App) Run() error {
 		}
 	}
 
+	// A contract left open by an earlier session waits for approval again
+	if a.contractManager != nil {
+		if c := a.contractManager.Restored(); c != nil {
+			a.tui.AddSystemMessage(fmt.Sprintf("Contract %q from an earlier session needs approval before its checks run again: /contract to review, /contract approve to resume it", c.Name))
+		}
+	}
+
 	// Build model-specific enhancement
 	modelEnhancement := a.buildModelEnhancement()
 
@@ -433,8 +459,30 @@ func (a *App) Run() error {
 	return runErr
 }
 
+// configureLogging routes logs to a file in the config dir so they never
+// interleave with TUI or headless output.
+func (a *App) configureLogging() {
+	configDir, err := appcontext.GetConfigDir()
+	if err == nil && a.config.Logging.Level != "" {
+		level := logging.ParseLevel(a.config.Logging.Level)
+		if err := logging.EnableFileLogging(configDir, level); err != nil {
+			// Silently continue with logging disabled
+			logging.DisableLogging()
+		}
+	} else {
+		// Disable logging if no config dir or level not set
+		logging.DisableLogging()
+	}
+}
+
 // handleSubmit handles user message submission.
 func (a *App) handleSubmit(message string) {
+	a.submit(message, nil)
+}
+
+// submit processes a message, or queues it while another one is processed.
+// scope holds the options of a prompt submitted by a command, if any.
+func (a *App) submit(message string, scope *commands.PromptOptions) {
 	a.mu.Lock()
 	if a.processing {
 		// Copy program reference while holding lock
@@ -444,6 +492,7 @@ func (a *App) handleSubmit(message string) {
 		// Save as pending message instead of discarding
 		a.pendingMu.Lock()
 		a.pendingMessage = message
+		a.pendingScope = scope
 		a.pendingMu.Unlock()
 
 		// Notify user that message is queued (using copied reference)
@@ -470,6 +519,9 @@ func (a *App) handleSubmit(message string) {
 	ctx, cancel := context.WithCancel(a.ctx)
 	a.processingCancel = cancel
 	a.processingMu.Unlock()
+	if scope != nil {
+		ctx = withPromptScope(ctx, scope)
+	}
 
 	// Process message normally (coordinator is now integrated in agent system)
 	go func() {
@@ -488,6 +540,16 @@ func (a *App) executeCommand(name string, args []string) {
 		a.mu.Lock()
 		a.processing = false
 		a.mu.Unlock()
+
+		// Run a prompt submitted by the command (e.g. an MCP prompt)
+		a.pendingMu.Lock()
+		pending, scope := a.pendingMessage, a.pendingScope
+		a.pendingMessage, a.pendingScope = "", nil
+		a.pendingMu.Unlock()
+
+		if pending != "" {
+			go a.submit(pending, scope)
+		}
 	}()
 
 	ctx := a.ctx
@@ -509,6 +571,26 @@ func (a *App) executeCommand(name string, args []string) {
 	}
 }
 
+// SubmitPrompt queues a prompt for the model; it runs once the current
+// command or request completes. @mentions in the prompt are not attached,
+// since its text does not come from the user.
+func (a *App) SubmitPrompt(prompt string) {
+	a.pendingMu.Lock()
+	a.pendingMessage = prompt
+	a.pendingScope = &commands.PromptOptions{}
+	a.pendingMu.Unlock()
+}
+
+// SubmitScopedPrompt queues a prompt like SubmitPrompt; while it runs, the
+// model may only call opts.AllowedTools and is switched to opts.Model.
+// Only the @mentions of opts.MentionText are attached.
+func (a *App) SubmitScopedPrompt(prompt string, opts commands.PromptOptions) {
+	a.pendingMu.Lock()
+	a.pendingMessage = prompt
+	a.pendingScope = &opts
+	a.pendingMu.Unlock()
+}
+
 // handleQuit handles quit request.
 func (a *App) handleQuit() {
 	// Use graceful shutdown with timeout
@@ -551,6 +633,12 @@ func (a *App) GetHistoryManager() (*chat.HistoryManager, error) {
 	return chat.NewHistoryManager()
 }
 
+// GetSessionArchive returns the archive of saved sessions, or nil when it
+// is disabled.
+func (a *App) GetSessionArchive() *chat.Archive {
+	return a.sessionArchive
+}
+
 // GetContextManager returns the context manager.
 func (a *App) GetContextManager() *appcontext.ContextManager {
 	return a.contextManager
@@ -571,6 +659,12 @@ func (a *App) safeSendToProgram(msg tea.Msg) {
 // sendTokenUsageUpdate sends a token usage update to the UI.
 // This can be called from any goroutine safely.
 func (a *App) sendTokenUsageUpdate() {
+	a.sendPendingTokenUsage(0)
+}
+
+// sendPendingTokenUsage sends the token usage plus pending tokens that are
+// about to be sent but are not in the history yet, such as attached mentions.
+func (a *App) sendPendingTokenUsage(pending int) {
 	if a.contextManager == nil || !a.config.UI.ShowTokenUsage {
 		return
 	}
@@ -587,6 +681,13 @@ func (a *App) sendTokenUsageUpdate() {
 	if usage == nil {
 		return
 	}
+	if pending > 0 {
+		if counter := a.contextManager.GetTokenCounter(); counter != nil {
+			withPending := counter.GetUsage(usage.InputTokens + pending)
+			withPending.IsEstimate = usage.IsEstimate
+			usage = &withPending
+		}
+	}
 	program.Send(ui.TokenUsageMsg{
 		Tokens:      usage.InputTokens,
 		MaxTokens:   usage.MaxTokens,
@@ -614,6 +715,17 @@ func (a *App) GetUndoManager() *undo.Manager {
 	return a.undoManager
 }
 
+// GetPermissionManager returns the permission manager.
+func (a *App) GetPermissionManager() *permission.Manager {
+	return a.permManager
+}
+
+// GetContractManager returns the contract manager, or nil when contracts
+// are disabled.
+func (a *App) GetContractManager() *contract.Manager {
+	return a.contractManager
+}
+
 // GetWorkDir returns the working directory.
 func (a *App) GetWorkDir() string {
 	return a.workDir
@@ -677,9 +789,21 @@ func (a *App) GetTokenStats() commands.TokenStats {
 		InputTokens:  a.totalInputTokens,
 		OutputTokens: a.totalOutputTokens,
 		TotalTokens:  a.totalInputTokens + a.totalOutputTokens,
+		API:          a.apiUsage,
 	}
 }
 
+// recordAPIUsage adds the usage reported for one model response to the session totals.
+func (a *App) recordAPIUsage(resp *client.Response) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.apiUsage.Requests++
+	a.apiUsage.InputTokens += resp.InputTokens
+	a.apiUsage.OutputTokens += resp.OutputTokens
+	a.apiUsage.CacheCreationTokens += resp.CacheCreationInputTokens
+	a.apiUsage.CacheReadTokens += resp.CacheReadInputTokens
+}
+
 // GetModelSetter returns the client for model switching.
 func (a *App) GetModelSetter() commands.ModelSetter {
 	return a.client
@@ -975,6 +1099,15 @@ func (a *App) ApplyConfig(cfg *config.Config) error {
 		a.contextManager.SetClient(newClient)
 	}
 
+	// Update the semantic search reranker
+	if a.registry != nil {
+		if t, ok := a.registry.Get("semantic_search"); ok {
+			if sst, ok := t.(*tools.SemanticSearchTool); ok {
+				sst.SetClient(newClient)
+			}
+		}
+	}
+
 	// 7. Update rate limiter
 	if a.config.RateLimit.Enabled {
 		if a.rateLimiter == nil {
@@ -1001,6 +1134,7 @@ func (a *App) ApplyConfig(cfg *config.Config) error {
 		if bashTool, ok := a.registry.Get("bash"); ok {
 			if bt, ok := bashTool.(*tools.BashTool); ok {
 				bt.SetSandboxEnabled(a.config.Tools.Bash.Sandbox)
+				bt.SetSandboxNetworkDisabled(a.config.Tools.Bash.SandboxDisableNetwork)
 			}
 		}
 	}
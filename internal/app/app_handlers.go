@@ -3,12 +3,15 @@ package app
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"gokin/internal/config"
+	"gokin/internal/contract"
 	"gokin/internal/logging"
 	"gokin/internal/permission"
 	"gokin/internal/plan"
+	"gokin/internal/tools"
 	"gokin/internal/ui"
 )
 
@@ -28,6 +31,7 @@ func (a *App) promptPermission(ctx context.Context, req *permission.Request) (pe
 		Args:      req.Args,
 		RiskLevel: req.RiskLevel.String(),
 		Reason:    req.Reason,
+		Scope:     req.Scope,
 	})
 
 	// Warning timer - remind user after 30 seconds, then every 60 seconds
@@ -149,6 +153,13 @@ func (a *App) promptPlanApproval(ctx context.Context, p *plan.Plan) (plan.Approv
 		Steps:       steps,
 	}
 
+	// Show the contract drafted together with the plan
+	if p.ContractID != "" && a.contractManager != nil {
+		if c, err := a.contractManager.Get(p.ContractID); err == nil {
+			setContractFields(&msg, c)
+		}
+	}
+
 	// Send plan approval request to TUI
 	a.program.Send(msg)
 
@@ -164,6 +175,68 @@ func (a *App) promptPlanApproval(ctx context.Context, p *plan.Plan) (plan.Approv
 	}
 }
 
+// promptContractApproval is called by the contract manager to get user approval
+// for a contract proposed on its own. It reuses the plan approval dialog.
+// Without a UI nobody can review the clause commands, so the contract is
+// rejected.
+func (a *App) promptContractApproval(ctx context.Context, c *contract.Contract) (contract.ApprovalDecision, error) {
+	if a.program == nil {
+		return contract.ApprovalRejected, nil
+	}
+
+	msg := ui.PlanApprovalRequestMsg{
+		Title: "Contract: " + c.Name,
+	}
+	setContractFields(&msg, c)
+
+	a.contractApprovalPending.Store(true)
+	defer a.contractApprovalPending.Store(false)
+
+	a.program.Send(msg)
+
+	select {
+	case decision := <-a.planApprovalChan:
+		switch decision {
+		case plan.ApprovalApproved:
+			return contract.ApprovalApproved, nil
+		case plan.ApprovalModified:
+			return contract.ApprovalModified, nil
+		default:
+			return contract.ApprovalRejected, nil
+		}
+	case <-ctx.Done():
+		return contract.ApprovalRejected, ctx.Err()
+	case <-time.After(PlanApprovalTimeout):
+		logging.Warn("contract approval prompt timed out")
+		return contract.ApprovalRejected, fmt.Errorf("contract approval prompt timed out after %v", PlanApprovalTimeout)
+	}
+}
+
+// setContractFields fills the contract section of an approval request.
+func setContractFields(msg *ui.PlanApprovalRequestMsg, c *contract.Contract) {
+	msg.ContractName = c.Name
+	msg.Intent = c.Intent
+	for _, b := range c.Boundaries {
+		line := strings.TrimSpace(b.Type + " " + b.Name)
+		if b.Description != "" {
+			line += ": " + b.Description
+		}
+		msg.Boundaries = append(msg.Boundaries, line)
+	}
+	for _, clause := range c.Clauses {
+		switch clause.Kind {
+		case contract.KindPrecondition:
+			msg.Preconditions = append(msg.Preconditions, clause.Summary())
+		case contract.KindPostcondition:
+			msg.Postconditions = append(msg.Postconditions, clause.Summary())
+		case contract.KindInvariant:
+			msg.Invariants = append(msg.Invariants, clause.Summary())
+		case contract.KindExample:
+			msg.Examples = append(msg.Examples, clause.Summary())
+		}
+	}
+}
+
 // handlePlanApproval is called by the TUI when the user makes a plan approval decision.
 func (a *App) handlePlanApproval(decision ui.PlanApprovalDecision) {
 	a.handlePlanApprovalWithFeedback(decision, "")
@@ -171,6 +244,12 @@ func (a *App) handlePlanApproval(decision ui.PlanApprovalDecision) {
 
 // handlePlanApprovalWithFeedback is called by the TUI when the user makes a plan approval decision with feedback.
 func (a *App) handlePlanApprovalWithFeedback(decision ui.PlanApprovalDecision, feedback string) {
+	// Contract approvals share the dialog but have no plan to save
+	if a.contractApprovalPending.Load() {
+		a.handleContractApprovalWithFeedback(decision, feedback)
+		return
+	}
+
 	// Convert UI decision to plan.ApprovalDecision
 	var planDecision plan.ApprovalDecision
 	switch decision {
@@ -206,6 +285,29 @@ func (a *App) handlePlanApprovalWithFeedback(decision ui.PlanApprovalDecision, f
 	}
 }
 
+// handleContractApprovalWithFeedback forwards a contract approval decision.
+func (a *App) handleContractApprovalWithFeedback(decision ui.PlanApprovalDecision, feedback string) {
+	var planDecision plan.ApprovalDecision
+	switch decision {
+	case ui.PlanApproved:
+		planDecision = plan.ApprovalApproved
+	case ui.PlanModifyRequested:
+		planDecision = plan.ApprovalModified
+		if feedback != "" {
+			feedbackMsg := fmt.Sprintf("Please modify the contract according to this feedback:\n\n%s", feedback)
+			go a.handleSubmit(feedbackMsg)
+		}
+	default:
+		planDecision = plan.ApprovalRejected
+	}
+
+	select {
+	case a.planApprovalChan <- planDecision:
+	case <-time.After(30 * time.Second):
+		logging.Warn("contract approval response channel timeout - no listener")
+	}
+}
+
 // handlePlanProgressUpdate is called when plan execution progress is made.
 func (a *App) handlePlanProgressUpdate(progress *plan.ProgressUpdate) {
 	if a.program == nil {
@@ -246,9 +348,9 @@ const DiffDecisionTimeout = 5 * time.Minute
 
 // promptDiffDecision is called by tools to request user approval for file changes.
 // It sends a request to the TUI and waits for a response with timeout.
-func (a *App) promptDiffDecision(ctx context.Context, filePath, oldContent, newContent, toolName string, isNewFile bool) (ui.DiffDecision, error) {
+func (a *App) promptDiffDecision(ctx context.Context, filePath, oldContent, newContent, toolName string, isNewFile bool) (ui.DiffResult, error) {
 	if a.program == nil {
-		return ui.DiffApply, nil
+		return ui.DiffResult{Decision: ui.DiffApply, Content: newContent}, nil
 	}
 
 	// Send diff preview request to TUI
@@ -262,21 +364,54 @@ func (a *App) promptDiffDecision(ctx context.Context, filePath, oldContent, newC
 
 	// Wait for response from TUI with timeout to prevent deadlock
 	select {
-	case decision := <-a.diffResponseChan:
-		return decision, nil
+	case result := <-a.diffResponseChan:
+		return result, nil
 	case <-ctx.Done():
-		return ui.DiffReject, ctx.Err()
+		return ui.DiffResult{Decision: ui.DiffReject}, ctx.Err()
 	case <-time.After(DiffDecisionTimeout):
 		logging.Warn("diff decision prompt timed out", "file", filePath)
-		return ui.DiffReject, fmt.Errorf("diff decision prompt timed out after %v", DiffDecisionTimeout)
+		return ui.DiffResult{Decision: ui.DiffReject}, fmt.Errorf("diff decision prompt timed out after %v", DiffDecisionTimeout)
+	}
+}
+
+// promptMultiDiffDecision requests approval for changes to several files in one
+// preview and waits for the per-file results.
+func (a *App) promptMultiDiffDecision(ctx context.Context, files []ui.DiffFile) (map[string]ui.DiffResult, error) {
+	results := make(map[string]ui.DiffResult, len(files))
+	if a.program == nil {
+		for _, f := range files {
+			results[f.FilePath] = ui.DiffResult{Decision: ui.DiffApply, Content: f.NewContent}
+		}
+		return results, nil
+	}
+
+	a.program.Send(ui.MultiDiffPreviewRequestMsg{Files: files})
+
+	select {
+	case results = <-a.multiDiffResponseChan:
+		return results, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(DiffDecisionTimeout):
+		logging.Warn("multi-file diff decision prompt timed out", "files", len(files))
+		return nil, fmt.Errorf("diff decision prompt timed out after %v", DiffDecisionTimeout)
+	}
+}
+
+// handleMultiDiffDecision is called by the TUI when the user completes a multi-file diff preview.
+func (a *App) handleMultiDiffDecision(results map[string]ui.DiffResult) {
+	select {
+	case a.multiDiffResponseChan <- results:
+	case <-time.After(30 * time.Second):
+		logging.Warn("multi-file diff response channel timeout - no listener")
 	}
 }
 
 // handleDiffDecision is called by the TUI when the user makes a diff preview decision.
-func (a *App) handleDiffDecision(decision ui.DiffDecision) {
+func (a *App) handleDiffDecision(result ui.DiffResult) {
 	// Send decision to the waiting promptDiffDecision call with timeout
 	select {
-	case a.diffResponseChan <- decision:
+	case a.diffResponseChan <- result:
 	case <-time.After(30 * time.Second):
 		logging.Warn("diff response channel timeout - no listener")
 	}
@@ -324,10 +459,75 @@ type diffHandlerAdapter struct {
 	app *App
 }
 
+// PromptDiff approves the change only if it is applied in full.
 func (d *diffHandlerAdapter) PromptDiff(ctx context.Context, filePath, oldContent, newContent, toolName string, isNewFile bool) (bool, error) {
-	decision, err := d.app.promptDiffDecision(ctx, filePath, oldContent, newContent, toolName, isNewFile)
+	result, err := d.app.promptDiffDecision(ctx, filePath, oldContent, newContent, toolName, isNewFile)
 	if err != nil {
 		return false, err
 	}
-	return decision == ui.DiffApply, nil
+	return result.Decision == ui.DiffApply && !result.Partial(), nil
+}
+
+func (d *diffHandlerAdapter) PromptMultiDiff(ctx context.Context, files []tools.FileDiff, toolName string) (bool, error) {
+	results, err := d.app.promptMultiDiffDecision(ctx, toDiffFiles(files))
+	if err != nil {
+		return false, err
+	}
+	for _, f := range files {
+		if result := results[f.FilePath]; result.Decision != ui.DiffApply || result.Partial() {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (d *diffHandlerAdapter) ReviewDiff(ctx context.Context, filePath, oldContent, newContent, toolName string, isNewFile bool) (*tools.DiffReview, error) {
+	result, err := d.app.promptDiffDecision(ctx, filePath, oldContent, newContent, toolName, isNewFile)
+	if err != nil {
+		return nil, err
+	}
+	return toDiffReview(filePath, result), nil
+}
+
+func (d *diffHandlerAdapter) ReviewMultiDiff(ctx context.Context, files []tools.FileDiff, toolName string) ([]*tools.DiffReview, error) {
+	results, err := d.app.promptMultiDiffDecision(ctx, toDiffFiles(files))
+	if err != nil {
+		return nil, err
+	}
+	reviews := make([]*tools.DiffReview, len(files))
+	for i, f := range files {
+		reviews[i] = toDiffReview(f.FilePath, results[f.FilePath])
+	}
+	return reviews, nil
+}
+
+func toDiffFiles(files []tools.FileDiff) []ui.DiffFile {
+	diffFiles := make([]ui.DiffFile, len(files))
+	for i, f := range files {
+		diffFiles[i] = ui.DiffFile{
+			FilePath:   f.FilePath,
+			OldContent: f.OldContent,
+			NewContent: f.NewContent,
+			IsNewFile:  f.IsNewFile,
+		}
+	}
+	return diffFiles
+}
+
+// toDiffReview converts a diff preview result into the review reported to tools.
+func toDiffReview(filePath string, result ui.DiffResult) *tools.DiffReview {
+	review := &tools.DiffReview{
+		FilePath: filePath,
+		Approved: result.Decision == ui.DiffApply,
+		Content:  result.Content,
+	}
+	for _, h := range result.Hunks {
+		switch h.Decision {
+		case ui.HunkRejected:
+			review.Rejected = append(review.Rejected, h.Patch())
+		case ui.HunkEdited:
+			review.Edited = append(review.Edited, h.Patch())
+		}
+	}
+	return review
 }
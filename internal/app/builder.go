@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -13,15 +14,19 @@ import (
 	"gokin/internal/audit"
 	"gokin/internal/cache"
 	"gokin/internal/chat"
+	"gokin/internal/checkpoint"
 	"gokin/internal/client"
 	"gokin/internal/commands"
 	"gokin/internal/config"
 	appcontext "gokin/internal/context"
+	"gokin/internal/contract"
 	"gokin/internal/git"
 	"gokin/internal/hooks"
 	"gokin/internal/logging"
+	"gokin/internal/lsp"
 	"gokin/internal/mcp"
 	"gokin/internal/memory"
+	"gokin/internal/mention"
 	"gokin/internal/permission"
 	"gokin/internal/plan"
 	"gokin/internal/ratelimit"
@@ -58,9 +63,12 @@ type Builder struct {
 	contextManager   *appcontext.ContextManager
 	permManager      *permission.Manager
 	planManager      *plan.Manager
+	contractManager  *contract.Manager
 	hooksManager     *hooks.Manager
 	taskManager      *tasks.Manager
 	undoManager      *undo.Manager
+	checkpointStore  *checkpoint.Store
+	lspManager       *lsp.Manager
 	agentRunner      *agent.Runner
 	commandHandler   *commands.Handler
 	searchCache      *cache.SearchCache
@@ -96,6 +104,7 @@ type Builder struct {
 
 	// Session persistence
 	sessionManager *chat.SessionManager
+	sessionArchive *chat.Archive
 
 	// MCP (Model Context Protocol)
 	mcpManager   *mcp.Manager
@@ -104,6 +113,9 @@ type Builder struct {
 	// Context Predictor (predictive file loading)
 	contextPredictor *appcontext.ContextPredictor
 
+	// Headless mode: no TUI, no interactive prompts on stdin
+	headless bool
+
 	// For error collection during build
 	buildErrors []error
 	mu          sync.Mutex
@@ -125,6 +137,13 @@ func NewBuilder(cfg *config.Config, workDir string) *Builder {
 	}
 }
 
+// WithHeadless configures the builder for non-interactive use (gokin run):
+// first-run stdin prompts are skipped and missing models are reported as errors.
+func (b *Builder) WithHeadless() *Builder {
+	b.headless = true
+	return b
+}
+
 // Build constructs the App instance, returning any errors encountered.
 func (b *Builder) Build() (*App, error) {
 	// Initialize core components
@@ -133,8 +152,10 @@ func (b *Builder) Build() (*App, error) {
 	}
 	// Check allowed directories BEFORE creating tools and validators
 	// This ensures permissions are loaded before PathValidator is created
-	if err := b.checkAllowedDirs(); err != nil {
-		b.addError(err)
+	if !b.headless {
+		if err := b.checkAllowedDirs(); err != nil {
+			b.addError(err)
+		}
 	}
 	if err := b.initClient(); err != nil {
 		b.addError(err)
@@ -297,6 +318,10 @@ func (b *Builder) validateOllamaModel() error {
 
 // promptModelPull asks user to download a missing model.
 func (b *Builder) promptModelPull(c *client.OllamaClient, modelName string) error {
+	if b.headless {
+		return fmt.Errorf("model '%s' is not available. Run: ollama pull %s", modelName, modelName)
+	}
+
 	fmt.Printf("\nModel '%s' is not installed.\n\n", modelName)
 	fmt.Printf("Would you like to download it now? [Y/n] ")
 
@@ -348,6 +373,8 @@ func (b *Builder) initClient() error {
 		logging.Debug("client created", "type", "Gemini", "model", b.cfg.Model.Name)
 	} else if _, ok := b.geminiClient.(*client.AnthropicClient); ok {
 		logging.Debug("client created", "type", "Anthropic (GLM-4.7)", "model", b.cfg.Model.Name)
+	} else if _, ok := b.geminiClient.(*client.OpenAIClient); ok {
+		logging.Debug("client created", "type", "OpenAI-compatible", "model", b.geminiClient.GetModel())
 	} else {
 		logging.Debug("client created", "type", "Unknown", "model", b.cfg.Model.Name)
 	}
@@ -458,15 +485,106 @@ func (b *Builder) initSession() error {
 		}
 	}
 
+	// Create the searchable archive of saved sessions
+	if b.sessionManager != nil && b.cfg.Session.Archive {
+		archive, err := chat.NewArchive()
+		if err != nil {
+			logging.Warn("session archive disabled", "error", err)
+		} else {
+			b.sessionArchive = archive
+			if b.cfg.Session.ArchiveEmbeddings {
+				if embedder, err := b.newEmbedder(); err != nil {
+					logging.Warn("session archive embeddings disabled", "error", err)
+				} else {
+					archive.SetEmbedder(embedder)
+				}
+			}
+		}
+	}
+
+	// Wire up history search: the main conversation's history and the archive
+	if historyTool, ok := b.registry.Get("history_search"); ok {
+		if ht, ok := historyTool.(*tools.HistorySearchTool); ok {
+			ht.SetHistoryGetter(b.session.GetHistory)
+			ht.SetWorkDir(b.workDir)
+			if b.sessionArchive != nil {
+				ht.SetArchive(b.sessionArchive)
+			}
+		}
+	}
+
 	return nil
 }
 
+// permissionRules builds the permission rules from the user's config and
+// the project's .gokin/config.yaml, each rule labeled with its layer.
+func permissionRules(cfg config.PermissionConfig) *permission.Rules {
+	rules := permission.NewRulesFromConfig(cfg.DefaultPolicy, cfg.Rules)
+
+	// Project commands were merged into the user's map when loading
+	commands := cfg.Commands
+	if cfg.Project != nil {
+		commands = make(map[string]string, len(cfg.Commands))
+		for pattern, policy := range cfg.Commands {
+			if _, ok := cfg.Project.Commands[pattern]; !ok {
+				commands[pattern] = policy
+			}
+		}
+	}
+	addPermissionLayer(rules, cfg, commands, permission.SourceUser)
+	if cfg.Project != nil {
+		addPermissionLayer(rules, *cfg.Project, cfg.Project.Commands, permission.SourceProject)
+	}
+	return rules
+}
+
+// addPermissionLayer adds the allow, ask and deny rules and the command
+// policies of one config layer.
+func addPermissionLayer(rules *permission.Rules, cfg config.PermissionConfig, commands map[string]string, source string) {
+	rules.AddCommandPolicies(commands, source)
+	for level, specs := range map[permission.Level][]string{
+		permission.LevelAllow: cfg.Allow,
+		permission.LevelAsk:   cfg.Ask,
+		permission.LevelDeny:  cfg.Deny,
+	} {
+		for _, err := range rules.AddRules(specs, level, source) {
+			logging.Warn("skipping permission rule", "error", err)
+		}
+	}
+}
+
 // initManagers creates various manager components.
 func (b *Builder) initManagers() error {
 	// Permission manager
 	if b.cfg.Permission.Enabled {
-		rules := permission.NewRulesFromConfig(b.cfg.Permission.DefaultPolicy, b.cfg.Permission.Rules)
+		rules := permissionRules(b.cfg.Permission)
+
+		// "Always allow" answers are saved as narrow rules in a grants file
+		// of this project in the user's config directory, not in the
+		// project's config, which is often committed, nor in the user's,
+		// which applies to every project.
+		grantsPath := config.ProjectGrantsPath(b.workDir)
+		if grantsPath != "" {
+			grants, err := config.LoadProjectGrants(grantsPath)
+			if err != nil {
+				logging.Warn("failed to load permission grants", "error", err)
+			}
+			for _, err := range rules.AddRules(grants, permission.LevelAllow, permission.SourceGrant) {
+				logging.Warn("skipping permission rule", "error", err)
+			}
+		}
+
 		b.permManager = permission.NewManager(rules, true)
+		b.permManager.SetWorkDir(b.workDir)
+		b.permManager.SetGrantHandler(func(rule permission.Rule) {
+			if grantsPath == "" {
+				return
+			}
+			spec := rule.String()
+			if err := config.AddPermissionRule(grantsPath, "allow", spec); err != nil {
+				logging.Warn("failed to save permission rule", "rule", spec, "error", err)
+			}
+		})
 	} else {
 		b.permManager = permission.NewManager(nil, false)
 	}
@@ -506,6 +624,45 @@ func (b *Builder) initManagers() error {
 		}
 	}
 
+	// Contract manager (contracts are stored per project)
+	if b.cfg.Contract.Enabled {
+		b.contractManager = contract.NewManager(b.cfg.Contract, b.workDir)
+		// Clause commands get the bash tool's validator and sandbox
+		if bashTool, ok := b.registry.Get("bash"); ok {
+			if bt, ok := bashTool.(*tools.BashTool); ok {
+				b.contractManager.SetShell(bt)
+			}
+		}
+		b.planManager.SetContractProvider(b.contractManager)
+		b.registry.Register(tools.NewContractProposeTool(b.contractManager))
+		b.registry.Register(tools.NewContractVerifyTool(b.contractManager))
+		b.registry.Register(tools.NewContractStatusTool(b.contractManager))
+	}
+
+	// Language servers start on first use of a file they serve
+	if b.cfg.LSP.Enabled {
+		b.lspManager = lsp.NewManager(b.workDir, lspServers(b.cfg.LSP.Servers))
+		b.lspManager.SetDiagnosticsTimeout(b.cfg.LSP.DiagnosticsTimeout)
+		b.registry.Register(tools.NewLSPDefinitionTool(b.lspManager, b.workDir))
+		b.registry.Register(tools.NewLSPReferencesTool(b.lspManager, b.workDir))
+		b.registry.Register(tools.NewLSPHoverTool(b.lspManager, b.workDir))
+		b.registry.Register(tools.NewLSPSymbolsTool(b.lspManager, b.workDir))
+		b.registry.Register(tools.NewLSPRenameTool(b.lspManager, b.workDir))
+		if b.cfg.LSP.Diagnostics {
+			if writeTool, ok := b.registry.Get("write"); ok {
+				if wt, ok := writeTool.(*tools.WriteTool); ok {
+					wt.SetLSPManager(b.lspManager)
+				}
+			}
+			if editTool, ok := b.registry.Get("edit"); ok {
+				if et, ok := editTool.(*tools.EditTool); ok {
+					et.SetLSPManager(b.lspManager)
+				}
+			}
+		}
+	}
+	b.promptBuilder.SetPlanManager(b.planManager)
+
 	// Hooks manager
 	b.hooksManager = hooks.NewManager(b.cfg.Hooks.Enabled, b.workDir)
 	for _, hookCfg := range b.cfg.Hooks.Hooks {
@@ -518,9 +675,12 @@ func (b *Builder) initManagers() error {
 			Condition:   hooks.Condition(hookCfg.Condition),
 			FailOnError: hookCfg.FailOnError,
 			DependsOn:   hookCfg.DependsOn,
+			MatchArgs:   hookCfg.MatchArgs,
 		})
 	}
 	b.executor.SetHooks(b.hooksManager)
+	b.contextManager.SetHooks(b.hooksManager)
+	b.planManager.SetHooks(b.hooksManager)
 
 	// Task manager
 	b.taskManager = tasks.NewManager(b.workDir)
@@ -528,13 +688,27 @@ func (b *Builder) initManagers() error {
 	// Undo manager
 	b.undoManager = undo.NewManager()
 
+	// Working tree checkpoints, kept in a shadow repository under the config dir
+	if b.cfg.Checkpoint.Enabled && b.configDirErr == nil {
+		store, err := checkpoint.NewStore(b.configDir, b.workDir, b.cfg.Checkpoint.MaxCheckpoints)
+		if err != nil {
+			logging.Warn("checkpoints disabled", "error", err)
+		} else {
+			b.checkpointStore = store
+		}
+	}
+
 	// Agent runner
 	b.agentRunner = agent.NewRunner(b.geminiClient, b.registry, b.workDir)
 	b.agentRunner.SetPermissions(b.permManager)
 	b.agentRunner.SetContextConfig(&b.cfg.Context)
+	b.agentRunner.SetHooks(b.hooksManager)
 
 	// Command handler
 	b.commandHandler = commands.NewHandler()
+	for _, err := range b.commandHandler.RegisterCustomCommands(b.workDir) {
+		logging.Warn("failed to load custom command", "error", err)
+	}
 
 	// Initialize task router
 	routerCfg := &router.RouterConfig{
@@ -695,6 +869,7 @@ func (b *Builder) initIntegrations() error {
 		if bt, ok := bashTool.(*tools.BashTool); ok {
 			bt.SetTaskManager(b.taskManager)
 			bt.SetSandboxEnabled(b.cfg.Tools.Bash.Sandbox)
+			bt.SetSandboxNetworkDisabled(b.cfg.Tools.Bash.SandboxDisableNetwork)
 			// Set unrestricted mode for bash tool (skip command validation)
 			sandboxOff := !b.cfg.Tools.Bash.Sandbox
 			permissionOff := !b.cfg.Permission.Enabled
@@ -721,6 +896,11 @@ func (b *Builder) initIntegrations() error {
 			et.SetWorkDir(b.workDir)
 		}
 	}
+	if refactorTool, ok := b.registry.Get("refactor"); ok {
+		if rt, ok := refactorTool.(*tools.RefactorTool); ok {
+			rt.SetWorkDir(b.workDir)
+		}
+	}
 
 	// Set additional allowed directories from config
 	if len(b.cfg.Tools.AllowedDirs) > 0 {
@@ -739,6 +919,11 @@ func (b *Builder) initIntegrations() error {
 				wt.SetAllowedDirs(b.cfg.Tools.AllowedDirs)
 			}
 		}
+		if renameTool, ok := b.registry.Get("lsp_rename"); ok {
+			if rt, ok := renameTool.(*tools.LSPRenameTool); ok {
+				rt.SetAllowedDirs(b.cfg.Tools.AllowedDirs)
+			}
+		}
 		if listDirTool, ok := b.registry.Get("list_dir"); ok {
 			if lt, ok := listDirTool.(*tools.ListDirTool); ok {
 				lt.SetAllowedDirs(b.cfg.Tools.AllowedDirs)
@@ -780,6 +965,12 @@ func (b *Builder) initIntegrations() error {
 				mt.SetAllowedDirs(b.cfg.Tools.AllowedDirs)
 			}
 		}
+		// Allowed directories stay writable inside the bash sandbox
+		if bashTool, ok := b.registry.Get("bash"); ok {
+			if bt, ok := bashTool.(*tools.BashTool); ok {
+				bt.SetAllowedDirs(b.cfg.Tools.AllowedDirs)
+			}
+		}
 	}
 
 	// Wire up undo manager
@@ -798,6 +989,16 @@ func (b *Builder) initIntegrations() error {
 			bt.SetUndoManager(b.undoManager)
 		}
 	}
+	if refactorTool, ok := b.registry.Get("refactor"); ok {
+		if rt, ok := refactorTool.(*tools.RefactorTool); ok {
+			rt.SetUndoManager(b.undoManager)
+		}
+	}
+	if renameTool, ok := b.registry.Get("lsp_rename"); ok {
+		if rt, ok := renameTool.(*tools.LSPRenameTool); ok {
+			rt.SetUndoManager(b.undoManager)
+		}
+	}
 	// Wire up undo manager for file operation tools
 	if copyTool, ok := b.registry.Get("copy"); ok {
 		if ct, ok := copyTool.(*tools.CopyTool); ok {
@@ -953,6 +1154,7 @@ func (b *Builder) initIntegrations() error {
 		}
 	}
 	b.executor.SetSessionID(b.session.ID)
+	b.hooksManager.SetSessionID(b.session.ID)
 
 	// Initialize file watcher
 	if b.cfg.Watcher.Enabled {
@@ -972,17 +1174,12 @@ func (b *Builder) initIntegrations() error {
 
 	// Initialize semantic search
 	if b.cfg.Semantic.Enabled && b.configDirErr == nil {
-		// Always create a separate Gemini client for semantic search embeddings
-		// This works regardless of which chat model is selected (Gemini, GLM-4.7, Claude)
-		genaiClient, err := genai.NewClient(b.ctx, &genai.ClientConfig{
-			APIKey:  b.cfg.API.APIKey,
-			Backend: genai.BackendGeminiAPI,
-		})
+		// The embedder is independent of the chat provider (Gemini, Ollama or offline)
+		embedder, err := b.newEmbedder()
 		if err != nil {
-			logging.Error("failed to create Gemini client for semantic search", "error", err)
+			logging.Error("failed to create embedder for semantic search", "error", err)
 			// Continue without semantic search
 		} else {
-			embedder := semantic.NewEmbedder(genaiClient, b.cfg.Semantic.Model)
 			// Use per-project cache (each project gets its own cache file)
 			semanticCache := semantic.NewEmbeddingCache(b.configDir, b.workDir, b.cfg.Semantic.CacheTTL)
 			// Store project path in cache for metadata
@@ -996,6 +1193,13 @@ func (b *Builder) initIntegrations() error {
 				b.cfg.Semantic.MaxFileSize,
 				b.configDir,
 			)
+			if annCfg := b.cfg.Semantic.ANN; annCfg.Enabled {
+				b.semanticIdx.EnableANN(semantic.HNSWConfig{
+					M:              annCfg.M,
+					EfConstruction: annCfg.EfConstruction,
+					EfSearch:       annCfg.EfSearch,
+				}, annCfg.MinChunks)
+			}
 
 			// Create incremental indexer wrapping enhanced indexer
 			bgConfig := semantic.DefaultBackgroundIndexerConfig()
@@ -1016,10 +1220,14 @@ func (b *Builder) initIntegrations() error {
 				logging.Warn("background semantic indexing error", "error", err)
 			})
 
-			b.registry.Register(tools.NewSemanticSearchTool(b.semanticIdx, b.workDir, b.cfg.Semantic.TopK))
+			semanticTool := tools.NewSemanticSearchTool(b.semanticIdx, b.workDir, b.cfg.Semantic.TopK)
+			semanticTool.SetRetrieval(b.cfg.Semantic.Hybrid, b.cfg.Semantic.Rerank, b.cfg.Semantic.RerankTopN)
+			semanticTool.SetClient(b.geminiClient)
+			b.registry.Register(semanticTool)
 
 			logging.Debug("semantic search initialized with per-project storage",
 				"project", b.workDir,
+				"embedder", semantic.EmbedderNamespace(embedder),
 				"cache_ttl", b.cfg.Semantic.CacheTTL)
 		}
 	}
@@ -1028,6 +1236,9 @@ func (b *Builder) initIntegrations() error {
 	if enterPlanTool, ok := b.registry.Get("enter_plan_mode"); ok {
 		if ept, ok := enterPlanTool.(*tools.EnterPlanModeTool); ok {
 			ept.SetManager(b.planManager)
+			if b.contractManager != nil && b.cfg.Contract.AutoDetect {
+				ept.SetContractManager(b.contractManager)
+			}
 		}
 	}
 	if updateProgressTool, ok := b.registry.Get("update_plan_progress"); ok {
@@ -1063,23 +1274,27 @@ func (b *Builder) initIntegrations() error {
 		// Convert config to MCP server configs
 		mcpConfigs := make([]*mcp.ServerConfig, 0, len(b.cfg.MCP.Servers))
 		for _, s := range b.cfg.MCP.Servers {
-			mcpConfigs = append(mcpConfigs, &mcp.ServerConfig{
-				Name:        s.Name,
-				Transport:   s.Transport,
-				Command:     s.Command,
-				Args:        s.Args,
-				Env:         s.Env,
-				URL:         s.URL,
-				Headers:     s.Headers,
-				AutoConnect: s.AutoConnect,
-				Timeout:     s.Timeout,
-				MaxRetries:  s.MaxRetries,
-				RetryDelay:  s.RetryDelay,
-				ToolPrefix:  s.ToolPrefix,
-			})
+			mcpConfigs = append(mcpConfigs, newMCPServerConfig(s))
 		}
 
 		b.mcpManager = mcp.NewManager(mcpConfigs)
+		if b.configDirErr == nil {
+			b.mcpManager.SetTokenStore(mcp.NewTokenStore(mcpTokenPath(b.configDir)))
+		}
+
+		// Servers may ask for completions and announce tool changes while
+		// connected; both are answered by the app once it is assembled.
+		b.mcpManager.SetSamplingHandler(func(ctx context.Context, server string, params *mcp.CreateMessageParams) (*mcp.CreateMessageResult, error) {
+			if b.cachedApp == nil {
+				return nil, fmt.Errorf("sampling is not available yet")
+			}
+			return b.cachedApp.handleMCPSampling(ctx, server, params)
+		})
+		b.mcpManager.SetToolsChangedHandler(func(server string, removed, added []tools.Tool) {
+			if b.cachedApp != nil {
+				b.cachedApp.onMCPToolsChanged(server, removed, added)
+			}
+		})
 
 		// Connect to auto-connect servers
 		if err := b.mcpManager.ConnectAll(b.ctx); err != nil {
@@ -1098,6 +1313,19 @@ func (b *Builder) initIntegrations() error {
 		// Refresh tools on client
 		b.geminiClient.SetTools(b.registry.GeminiTools())
 
+		// Offer MCP prompts as /server:prompt commands
+		if b.commandHandler != nil {
+			serverPrompts, err := b.mcpManager.ListPrompts(b.ctx)
+			if err != nil {
+				logging.Warn("failed to list MCP prompts", "error", err)
+			}
+			for _, sp := range serverPrompts {
+				for _, p := range sp.Prompts {
+					b.commandHandler.Register(commands.NewMCPPromptCommand(b.mcpManager, sp.Server, p))
+				}
+			}
+		}
+
 		logging.Debug("MCP initialized",
 			"servers", len(b.cfg.MCP.Servers),
 			"tools", len(b.mcpManager.GetTools()))
@@ -1106,6 +1334,94 @@ func (b *Builder) initIntegrations() error {
 	return nil
 }
 
+// lspServers returns the built-in language servers merged with the
+// configured ones. A configured server replaces the built-in server for its
+// language, inheriting the extensions and root markers it leaves empty.
+func lspServers(configured []config.LSPServerConfig) []lsp.ServerConfig {
+	servers := lsp.DefaultServers()
+	for _, c := range configured {
+		server := lsp.ServerConfig{
+			Language:              c.Language,
+			Command:               c.Command,
+			Args:                  c.Args,
+			Extensions:            c.Extensions,
+			RootMarkers:           c.RootMarkers,
+			InitializationOptions: c.InitializationOptions,
+		}
+		idx := slices.IndexFunc(servers, func(s lsp.ServerConfig) bool { return s.Language == c.Language })
+		switch {
+		case idx >= 0 && c.Disabled:
+			servers = slices.Delete(servers, idx, idx+1)
+		case c.Disabled || c.Command == "":
+			// Nothing to run
+		case idx >= 0:
+			if len(server.Extensions) == 0 {
+				server.Extensions = servers[idx].Extensions
+			}
+			if len(server.RootMarkers) == 0 {
+				server.RootMarkers = servers[idx].RootMarkers
+			}
+			servers[idx] = server
+		default:
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// newEmbedder creates the embedder selected by semantic.provider.
+// "auto" uses Gemini when a Gemini key is configured, Ollama when it is the
+// active provider, and the offline local embedder otherwise.
+func (b *Builder) newEmbedder() (semantic.Embedder, error) {
+	cfg := b.cfg.Semantic
+	geminiKey := b.cfg.API.GeminiKey
+	if geminiKey == "" && b.cfg.API.GetActiveProvider() == "gemini" {
+		// The legacy api_key belongs to the active provider
+		geminiKey = b.cfg.API.APIKey
+	}
+
+	provider := strings.ToLower(cfg.Provider)
+	if provider == "" || provider == "auto" {
+		switch {
+		case geminiKey != "":
+			provider = semantic.ProviderGemini
+		case b.cfg.API.GetActiveProvider() == "ollama":
+			provider = semantic.ProviderOllama
+		default:
+			provider = semantic.ProviderLocal
+		}
+	}
+
+	switch provider {
+	case semantic.ProviderGemini:
+		if geminiKey == "" {
+			return nil, fmt.Errorf("semantic provider gemini requires a Gemini API key")
+		}
+		genaiClient, err := genai.NewClient(b.ctx, &genai.ClientConfig{
+			APIKey:  geminiKey,
+			Backend: genai.BackendGeminiAPI,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Gemini client: %w", err)
+		}
+		return semantic.NewGeminiEmbedder(genaiClient, cfg.Model, cfg.Dimensions), nil
+
+	case semantic.ProviderOllama:
+		model := cfg.Model
+		// The default model is a Gemini one; fall back to Ollama's default
+		if model == "text-embedding-004" {
+			model = ""
+		}
+		return semantic.NewOllamaEmbedder(b.cfg.API.OllamaBaseURL, b.cfg.API.OllamaKey, model, cfg.Dimensions), nil
+
+	case semantic.ProviderLocal:
+		return semantic.NewLocalEmbedder(cfg.Dimensions), nil
+
+	default:
+		return nil, fmt.Errorf("unknown semantic provider: %s", cfg.Provider)
+	}
+}
+
 // initUI creates and configures the TUI model.
 func (b *Builder) initUI() error {
 	b.tuiModel = ui.NewModel()
@@ -1166,6 +1482,9 @@ func (b *Builder) wireDependencies() error {
 	if oc, ok := b.geminiClient.(*client.OllamaClient); ok {
 		oc.SetStatusCallback(statusCb)
 	}
+	if oc, ok := b.geminiClient.(*client.OpenAIClient); ok {
+		oc.SetStatusCallback(statusCb)
+	}
 
 	// Set up executor handler
 	b.executor.SetHandler(&tools.ExecutionHandler{
@@ -1185,6 +1504,7 @@ func (b *Builder) wireDependencies() error {
 				app.sendTokenUsageUpdate()
 			}
 		},
+		OnUsage: app.recordAPIUsage,
 		OnThinking: func(text string) {
 			// Thinking content keeps the UI alive — send a tick
 			// so the status bar continues to animate and show elapsed time.
@@ -1234,6 +1554,7 @@ func (b *Builder) wireDependencies() error {
 	b.tuiModel.SetPlanApprovalCallback(app.handlePlanApproval)
 	b.tuiModel.SetModelSelectCallback(app.handleModelSelect)
 	b.tuiModel.SetDiffDecisionCallback(app.handleDiffDecision)
+	b.tuiModel.SetMultiDiffDecisionCallback(app.handleMultiDiffDecision)
 
 	// Set up cancel callback for ESC interrupt
 	b.tuiModel.SetCancelCallback(app.CancelProcessing)
@@ -1257,11 +1578,16 @@ func (b *Builder) wireDependencies() error {
 	paletteCtx := commands.NewPaletteContext(b.workDir, hasAuth)
 	paletteProvider := commands.NewPaletteProvider(b.commandHandler, paletteCtx)
 	b.tuiModel.SetPaletteProvider(paletteProvider)
+	b.tuiModel.SetMentionProvider(mentionProvider{resolver: app.mentionResolver})
 	b.tuiModel.RegisterPaletteActions()
 
 	// Set up plan approval callback for context compaction
 	b.agentRunner.SetOnPlanApproved(app.CompactContextWithPlan)
 
+	// Set up git worktree isolation for sub-agents
+	b.agentRunner.SetWorktreeToolConfigurer(app.configureWorktreeTools)
+	b.agentRunner.SetWorktreeMerger(app.mergeWorktree)
+
 	// Set up background task tracking callbacks for UI
 	b.agentRunner.SetOnAgentStart(func(id, agentType, description string) {
 		if app.program != nil {
@@ -1340,6 +1666,11 @@ func (b *Builder) wireDependencies() error {
 	// Set up plan approval
 	b.planManager.SetApprovalHandler(app.promptPlanApproval)
 
+	// Set up contract approval
+	if b.contractManager != nil {
+		b.contractManager.SetApprovalHandler(app.promptContractApproval)
+	}
+
 	// Set up plan progress updates
 	b.planManager.SetProgressUpdateHandler(app.handlePlanProgressUpdate)
 
@@ -1358,6 +1689,18 @@ func (b *Builder) wireDependencies() error {
 				et.SetDiffEnabled(true)
 			}
 		}
+		if refactorTool, ok := b.registry.Get("refactor"); ok {
+			if rt, ok := refactorTool.(*tools.RefactorTool); ok {
+				rt.SetDiffHandler(diffAdapter)
+				rt.SetDiffEnabled(true)
+			}
+		}
+		if renameTool, ok := b.registry.Get("lsp_rename"); ok {
+			if rt, ok := renameTool.(*tools.LSPRenameTool); ok {
+				rt.SetDiffHandler(diffAdapter)
+				rt.SetDiffEnabled(true)
+			}
+		}
 	}
 
 	// === PHASE 4: Initialize UI Auto-Update System ===
@@ -1383,39 +1726,43 @@ func (b *Builder) assembleApp() *App {
 	}
 
 	b.cachedApp = &App{
-		config:               b.cfg,
-		workDir:              b.workDir,
-		client:               b.geminiClient,
-		registry:             b.registry,
-		executor:             b.executor,
-		session:              b.session,
-		tui:                  b.tuiModel,
-		ctx:                  b.ctx,
-		cancel:               b.cancel, // Use the saved cancel function
-		projectInfo:          b.projectInfo,
-		contextManager:       b.contextManager,
-		promptBuilder:        b.promptBuilder,
-		contextAgent:         b.contextAgent,
-		permManager:          b.permManager,
-		permResponseChan:     make(chan permission.Decision, 2),
-		questionResponseChan: make(chan string, 1),
-		diffResponseChan:     make(chan ui.DiffDecision, 1),
-		planManager:          b.planManager,
-		planApprovalChan:     make(chan plan.ApprovalDecision, 1),
-		hooksManager:         b.hooksManager,
-		taskManager:          b.taskManager,
-		undoManager:          b.undoManager,
-		agentRunner:          b.agentRunner,
-		commandHandler:       b.commandHandler,
-		sessionManager:       b.sessionManager,
-		searchCache:          b.searchCache,
-		rateLimiter:          b.rateLimiter,
-		auditLogger:          b.auditLogger,
-		fileWatcher:          b.fileWatcher,
-		semanticIndexer:      b.semanticIdx,
-		backgroundIndexer:    b.backgroundIdx,
-		taskRouter:           b.taskRouter,
-		orchestrator:         b.taskOrchestrator,
+		config:                b.cfg,
+		workDir:               b.workDir,
+		client:                b.geminiClient,
+		registry:              b.registry,
+		executor:              b.executor,
+		session:               b.session,
+		tui:                   b.tuiModel,
+		headless:              b.headless,
+		ctx:                   b.ctx,
+		cancel:                b.cancel, // Use the saved cancel function
+		projectInfo:           b.projectInfo,
+		contextManager:        b.contextManager,
+		promptBuilder:         b.promptBuilder,
+		contextAgent:          b.contextAgent,
+		permManager:           b.permManager,
+		permResponseChan:      make(chan permission.Decision, 2),
+		questionResponseChan:  make(chan string, 1),
+		diffResponseChan:      make(chan ui.DiffResult, 1),
+		multiDiffResponseChan: make(chan map[string]ui.DiffResult, 1),
+		planManager:           b.planManager,
+		contractManager:       b.contractManager,
+		planApprovalChan:      make(chan plan.ApprovalDecision, 1),
+		hooksManager:          b.hooksManager,
+		taskManager:           b.taskManager,
+		undoManager:           b.undoManager,
+		agentRunner:           b.agentRunner,
+		commandHandler:        b.commandHandler,
+		sessionManager:        b.sessionManager,
+		sessionArchive:        b.sessionArchive,
+		searchCache:           b.searchCache,
+		rateLimiter:           b.rateLimiter,
+		auditLogger:           b.auditLogger,
+		fileWatcher:           b.fileWatcher,
+		semanticIndexer:       b.semanticIdx,
+		backgroundIndexer:     b.backgroundIdx,
+		taskRouter:            b.taskRouter,
+		orchestrator:          b.taskOrchestrator,
 		// Phase 4: UI Auto-Update System (initialized separately)
 		uiUpdateManager: nil, // Will be set after assembly
 		// Phase 5: Agent System Improvements
@@ -1426,9 +1773,16 @@ func (b *Builder) assembleApp() *App {
 		// Phase 6: Tree Planner
 		treePlanner: b.treePlanner,
 		// MCP (Model Context Protocol)
-		mcpManager: b.mcpManager,
+		mcpManager:      b.mcpManager,
+		mentionResolver: mention.NewResolver(b.workDir),
+		checkpointStore: b.checkpointStore,
+		lspManager:      b.lspManager,
 	}
 
+	// @symbol mentions come from the semantic index, @url ones from web_fetch
+	b.cachedApp.mentionResolver.SetSymbolLookup(b.cachedApp.lookupSymbols)
+	b.cachedApp.mentionResolver.SetFetcher(b.cachedApp.fetchMention)
+
 	// Wire up user input callback for agents
 	if b.agentRunner != nil {
 		b.agentRunner.SetOnInput(func(prompt string) (string, error) {
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/genai"
+
+	"gokin/internal/checkpoint"
+	"gokin/internal/logging"
+	"gokin/internal/ui"
+)
+
+// checkpointTimeout bounds snapshotting the working tree before a turn.
+const checkpointTimeout = 30 * time.Second
+
+// createCheckpoint snapshots the working tree before the turn for prompt
+// and remembers the conversation so far, so /rewind can return to it.
+// Checkpoints are turned off for the session if snapshotting times out,
+// e.g. in a huge directory without a .gitignore.
+func (a *App) createCheckpoint(ctx context.Context, prompt string) {
+	a.checkpointMu.Lock()
+	store := a.checkpointStore
+	a.checkpointMu.Unlock()
+	if store == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkpointTimeout)
+	defer cancel()
+	cp, err := store.Create(ctx, prompt)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		a.checkpointMu.Lock()
+		a.checkpointStore = nil
+		a.checkpointMu.Unlock()
+		logging.Warn("checkpoints disabled: snapshotting the working tree timed out", "timeout", checkpointTimeout)
+		a.safeSendToProgram(ui.StreamTextMsg("⚠ Checkpoints disabled for this session: snapshotting the working tree took too long.\n\n"))
+		return
+	}
+	if cp == nil {
+		logging.Warn("failed to create checkpoint", "error", err)
+		return
+	}
+	if err != nil {
+		logging.Debug("checkpoint created with warning", "id", cp.ID, "error", err)
+	}
+
+	history := a.session.GetHistory()
+	a.checkpointMu.Lock()
+	defer a.checkpointMu.Unlock()
+	if a.checkpointHistory == nil {
+		a.checkpointHistory = make(map[string][]*genai.Content)
+	}
+	a.checkpointHistory[cp.ID] = history
+	a.checkpointOrder = append(a.checkpointOrder, cp.ID)
+	if max := a.config.Checkpoint.MaxCheckpoints; max > 0 && len(a.checkpointOrder) > max {
+		for _, id := range a.checkpointOrder[:len(a.checkpointOrder)-max] {
+			delete(a.checkpointHistory, id)
+		}
+		a.checkpointOrder = append([]string(nil), a.checkpointOrder[len(a.checkpointOrder)-max:]...)
+	}
+}
+
+// ListCheckpoints returns the working tree checkpoints, newest first.
+func (a *App) ListCheckpoints(ctx context.Context) ([]checkpoint.Checkpoint, error) {
+	a.checkpointMu.Lock()
+	store := a.checkpointStore
+	a.checkpointMu.Unlock()
+	if store == nil {
+		return nil, fmt.Errorf("checkpoints are disabled")
+	}
+	return store.List(ctx)
+}
+
+// CanRewindConversation reports whether the conversation can be rewound to
+// checkpoint id, which is only the case for checkpoints of this session.
+func (a *App) CanRewindConversation(id string) bool {
+	a.checkpointMu.Lock()
+	defer a.checkpointMu.Unlock()
+	_, ok := a.checkpointHistory[id]
+	return ok
+}
+
+// Rewind restores the working tree to checkpoint id and, if conversation
+// is set, the conversation to where it was before that turn.
+func (a *App) Rewind(ctx context.Context, id string, conversation bool) (*checkpoint.RestoreResult, error) {
+	a.checkpointMu.Lock()
+	store := a.checkpointStore
+	history, hasHistory := a.checkpointHistory[id]
+	a.checkpointMu.Unlock()
+	if store == nil {
+		return nil, fmt.Errorf("checkpoints are disabled")
+	}
+	if conversation && !hasHistory {
+		return nil, fmt.Errorf("the conversation of checkpoint %.8s is not available: it was created in another session", id)
+	}
+
+	result, err := store.Restore(ctx, id)
+	if result == nil {
+		return nil, err
+	}
+	if err != nil {
+		logging.Warn("checkpoint restored with warning", "id", id, "error", err)
+	}
+
+	if conversation {
+		a.session.SetHistory(history)
+		a.sendTokenUsageUpdate()
+	}
+	logging.Info("rewound to checkpoint", "id", id,
+		"restored", len(result.Restored), "removed", len(result.Removed), "conversation", conversation)
+	return result, nil
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"gokin/internal/logging"
+	"gokin/internal/ui"
+)
+
+// contractWriteTools are the tools whose use can change whether a contract holds.
+var contractWriteTools = map[string]bool{
+	"write":      true,
+	"edit":       true,
+	"batch":      true,
+	"refactor":   true,
+	"lsp_rename": true,
+	"move":       true,
+	"copy":       true,
+	"delete":     true,
+}
+
+// shouldAutoVerifyContract reports whether a turn that used toolsUsed modified
+// files without verifying the active contract itself.
+func (a *App) shouldAutoVerifyContract(toolsUsed []string) bool {
+	if a.contractManager == nil || !a.contractManager.AutoVerify() || a.contractManager.Active() == nil {
+		return false
+	}
+
+	modified := false
+	for _, name := range toolsUsed {
+		if name == "contract_verify" {
+			return false
+		}
+		if contractWriteTools[name] {
+			modified = true
+		}
+	}
+	return modified
+}
+
+// autoVerifyContract runs the verification commands of the active contract
+// and reports the result per clause. Failures stay in the prompt context
+// until the contract passes.
+func (a *App) autoVerifyContract(ctx context.Context) {
+	if a.contractManager == nil || !a.contractManager.AutoVerify() {
+		return
+	}
+	active := a.contractManager.Active()
+	if active == nil || active.VerifiableCount() == 0 {
+		return
+	}
+
+	a.safeSendToProgram(ui.StreamTextMsg(fmt.Sprintf("\n━━━ Verifying contract: %s ━━━\n", active.Name)))
+
+	c, report, err := a.contractManager.Verify(ctx)
+	if err != nil {
+		logging.Warn("contract verification failed", "contract", active.ID, "error", err)
+		a.safeSendToProgram(ui.StreamTextMsg(fmt.Sprintf("Contract verification failed: %s\n", err)))
+		return
+	}
+
+	a.safeSendToProgram(ui.StreamTextMsg(report.Format()))
+	if report.Passed() {
+		a.safeSendToProgram(ui.StreamTextMsg(fmt.Sprintf("Contract %s verified.\n", c.Name)))
+	} else {
+		a.safeSendToProgram(ui.StreamTextMsg(fmt.Sprintf(
+			"Contract %s not satisfied; the failing clauses will be included in the next request.\n", c.Name)))
+	}
+	logging.Debug("contract verified", "contract", c.ID, "status", c.Status)
+}
@@ -0,0 +1,376 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"gokin/internal/client"
+	"gokin/internal/config"
+	"gokin/internal/logging"
+	"gokin/internal/permission"
+	"gokin/internal/tools"
+
+	"google.golang.org/genai"
+)
+
+// OutputFormat selects how a headless run reports progress and its result.
+type OutputFormat string
+
+const (
+	// OutputText streams the model's text to the output as plain text.
+	OutputText OutputFormat = "text"
+	// OutputJSON prints a single JSON result object when the run finishes.
+	OutputJSON OutputFormat = "json"
+	// OutputStreamJSON prints newline-delimited JSON events followed by the result.
+	OutputStreamJSON OutputFormat = "stream-json"
+)
+
+// PermissionMode decides how permission prompts are resolved when no user is present.
+// Rules that already allow or deny a tool are applied as usual; the mode only
+// answers the prompts that would otherwise be shown in the TUI.
+type PermissionMode string
+
+const (
+	// PermissionModeDeny denies every tool call that would require a prompt.
+	PermissionModeDeny PermissionMode = "deny"
+	// PermissionModeAcceptEdits allows file modifications but denies high-risk tools (bash, delete, ...).
+	PermissionModeAcceptEdits PermissionMode = "accept-edits"
+	// PermissionModeAllowAll allows every tool call that would require a prompt.
+	PermissionModeAllowAll PermissionMode = "allow-all"
+)
+
+// Exit codes for headless runs.
+const (
+	ExitSuccess          = 0
+	ExitError            = 1
+	ExitToolFailure      = 2
+	ExitPermissionDenied = 3
+	ExitBudgetExhausted  = 4
+)
+
+// Result statuses for headless runs (mirrors the exit codes).
+const (
+	HeadlessStatusSuccess          = "success"
+	HeadlessStatusError            = "error"
+	HeadlessStatusToolFailure      = "tool_failure"
+	HeadlessStatusPermissionDenied = "permission_denied"
+	HeadlessStatusBudgetExhausted  = "budget_exhausted"
+)
+
+// HeadlessOptions configures a non-interactive run.
+type HeadlessOptions struct {
+	Prompt         string
+	OutputFormat   OutputFormat
+	PermissionMode PermissionMode
+	MaxTurns       int       // Max model requests (0 = executor default)
+	MaxTokens      int       // Max total tokens (0 = unlimited)
+	Output         io.Writer // Destination for text/events/result
+}
+
+// HeadlessUsage is the token usage accumulated over a headless run.
+type HeadlessUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+}
+
+// HeadlessResult is the final outcome of a headless run.
+type HeadlessResult struct {
+	Type              string        `json:"type"` // always "result"
+	Status            string        `json:"status"`
+	Result            string        `json:"result"`
+	Error             string        `json:"error,omitempty"`
+	SessionID         string        `json:"session_id"`
+	Model             string        `json:"model"`
+	DurationMs        int64         `json:"duration_ms"`
+	NumTurns          int           `json:"num_turns"`
+	ToolCalls         int           `json:"tool_calls"`
+	ToolFailures      int           `json:"tool_failures"`
+	PermissionDenials []string      `json:"permission_denials,omitempty"`
+	Usage             HeadlessUsage `json:"usage"`
+	ExitCode          int           `json:"exit_code"`
+}
+
+// HeadlessEvent is a single line of stream-json output.
+type HeadlessEvent struct {
+	Type         string         `json:"type"` // text, tool_call, tool_result, tool_denied, hook, usage, error
+	Text         string         `json:"text,omitempty"`
+	Tool         string         `json:"tool,omitempty"`
+	Hook         string         `json:"hook,omitempty"`
+	Args         map[string]any `json:"args,omitempty"`
+	Success      *bool          `json:"success,omitempty"`
+	Content      string         `json:"content,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	InputTokens  int            `json:"input_tokens,omitempty"`
+	OutputTokens int            `json:"output_tokens,omitempty"`
+
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+}
+
+// headlessReporter serializes output and accumulates run statistics.
+// Handlers are invoked from parallel tool goroutines, so all access is locked.
+type headlessReporter struct {
+	format OutputFormat
+	out    io.Writer
+	mu     sync.Mutex
+
+	wroteText    bool
+	endsNewline  bool
+	turns        int
+	toolCalls    int
+	toolFailures int
+	denials      []string
+	usage        HeadlessUsage
+}
+
+func (r *headlessReporter) emit(ev HeadlessEvent) {
+	if r.format != OutputStreamJSON {
+		return
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		logging.Debug("failed to encode headless event", "error", err)
+		return
+	}
+	r.out.Write(append(data, '\n'))
+}
+
+func (r *headlessReporter) onText(text string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch r.format {
+	case OutputText:
+		io.WriteString(r.out, text)
+		r.wroteText = true
+		r.endsNewline = len(text) > 0 && text[len(text)-1] == '\n'
+	case OutputStreamJSON:
+		r.emit(HeadlessEvent{Type: "text", Text: text})
+	}
+}
+
+func (r *headlessReporter) onToolStart(name string, args map[string]any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.toolCalls++
+	r.emit(HeadlessEvent{Type: "tool_call", Tool: name, Args: args})
+}
+
+func (r *headlessReporter) onToolEnd(name string, result tools.ToolResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !result.Success {
+		r.toolFailures++
+	}
+	success := result.Success
+	r.emit(HeadlessEvent{Type: "tool_result", Tool: name, Success: &success, Content: result.Content, Error: result.Error})
+}
+
+func (r *headlessReporter) onToolDenied(name, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.denials = append(r.denials, fmt.Sprintf("%s: %s", name, reason))
+	r.emit(HeadlessEvent{Type: "tool_denied", Tool: name, Error: reason})
+}
+
+func (r *headlessReporter) onHook(name, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.emit(HeadlessEvent{Type: "hook", Hook: name, Text: reason})
+}
+
+func (r *headlessReporter) onUsage(resp *client.Response) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.turns++
+	r.usage.InputTokens += resp.InputTokens
+	r.usage.OutputTokens += resp.OutputTokens
+	r.usage.CacheCreationInputTokens += resp.CacheCreationInputTokens
+	r.usage.CacheReadInputTokens += resp.CacheReadInputTokens
+	r.emit(HeadlessEvent{
+		Type:                     "usage",
+		InputTokens:              resp.InputTokens,
+		OutputTokens:             resp.OutputTokens,
+		CacheCreationInputTokens: resp.CacheCreationInputTokens,
+		CacheReadInputTokens:     resp.CacheReadInputTokens,
+	})
+}
+
+// finish writes the final result in the configured format.
+func (r *headlessReporter) finish(res *HeadlessResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch r.format {
+	case OutputText:
+		if r.wroteText && !r.endsNewline {
+			io.WriteString(r.out, "\n")
+		}
+	default:
+		data, err := json.Marshal(res)
+		if err != nil {
+			logging.Debug("failed to encode headless result", "error", err)
+			return
+		}
+		r.out.Write(append(data, '\n'))
+	}
+}
+
+// headlessPermissionHandler answers permission prompts according to the mode.
+func headlessPermissionHandler(mode PermissionMode) permission.PromptHandler {
+	return func(ctx context.Context, req *permission.Request) (permission.Decision, error) {
+		switch mode {
+		case PermissionModeAllowAll:
+			return permission.DecisionAllow, nil
+		case PermissionModeAcceptEdits:
+			if req.RiskLevel <= permission.RiskMedium {
+				return permission.DecisionAllow, nil
+			}
+		}
+		logging.Debug("headless permission denied", "tool", req.ToolName, "mode", mode)
+		return permission.DecisionDeny, nil
+	}
+}
+
+// RunHeadless executes a single prompt through the full agent loop without the TUI
+// and reports progress to opts.Output. The App is shut down when the run completes,
+// so it must not be reused afterwards.
+func (a *App) RunHeadless(ctx context.Context, opts HeadlessOptions) *HeadlessResult {
+	start := time.Now()
+	a.configureLogging()
+
+	reporter := &headlessReporter{format: opts.OutputFormat, out: opts.Output}
+	result := &HeadlessResult{
+		Type:      "result",
+		SessionID: a.session.ID,
+		Model:     a.config.Model.Name,
+	}
+
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), GracefulShutdownTimeout)
+		defer cancel()
+		a.gracefulShutdown(shutdownCtx)
+	}()
+
+	// Cancel the run when the app context is cancelled (signals, shutdown).
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-a.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	a.detectedProjectContext = a.detectProjectContext()
+	if a.detectedProjectContext != "" && a.promptBuilder != nil {
+		a.promptBuilder.SetDetectedContext(a.detectedProjectContext)
+	}
+
+	if a.hooksManager != nil {
+		a.hooksManager.RunOnStart(ctx)
+	}
+
+	systemPrompt := a.promptBuilder.Build() + a.buildModelEnhancement()
+	a.client.SetSystemInstruction(systemPrompt)
+	a.session.SystemInstruction = systemPrompt
+
+	// No user is present: resolve prompts from the selected policy.
+	if a.permManager != nil {
+		a.permManager.SetPromptHandler(headlessPermissionHandler(opts.PermissionMode))
+	}
+	if askUserTool, ok := a.registry.Get("ask_user"); ok {
+		if aut, ok := askUserTool.(*tools.AskUserTool); ok {
+			aut.SetHandler(func(ctx context.Context, question string, options []string, defaultOpt string) (string, error) {
+				if defaultOpt != "" {
+					return defaultOpt, nil
+				}
+				return "", fmt.Errorf("no interactive user in headless mode; decide without asking")
+			})
+		}
+	}
+
+	a.executor.SetBudget(opts.MaxTurns, opts.MaxTokens)
+	a.executor.SetHandler(&tools.ExecutionHandler{
+		OnText:       reporter.onText,
+		OnToolStart:  reporter.onToolStart,
+		OnToolEnd:    reporter.onToolEnd,
+		OnToolDenied: reporter.onToolDenied,
+		OnUsage:      reporter.onUsage,
+		OnError: func(err error) {
+			reporter.mu.Lock()
+			reporter.emit(HeadlessEvent{Type: "error", Error: err.Error()})
+			reporter.mu.Unlock()
+		},
+	})
+
+	// Diffs cannot be reviewed interactively; permission rules still apply.
+	ctx = tools.ContextWithSkipDiff(ctx)
+
+	var newHistory []*genai.Content
+	var response string
+	prompt, err := a.runPromptHooks(ctx, opts.Prompt)
+	if err == nil {
+		prompt = a.expandMentions(ctx, prompt, opts.Prompt)
+		newHistory, response, err = a.executeTurn(ctx, a.session.GetHistory(), prompt)
+	}
+	if newHistory != nil {
+		a.session.SetHistory(newHistory)
+	}
+	if err == nil {
+		_, response, err = a.runStopHooks(ctx, newHistory, response, reporter.onHook)
+	}
+
+	// An approved plan that requested a clean context is executed right away.
+	if err == nil && a.planManager != nil && a.planManager.IsContextClearRequested() {
+		approvedPlan := a.planManager.ConsumeContextClearRequest()
+		if approvedPlan != nil && a.config.Plan.ClearContext {
+			a.executePlanWithClearContext(ctx, approvedPlan)
+		}
+	}
+
+	reporter.mu.Lock()
+	result.Result = response
+	result.DurationMs = time.Since(start).Milliseconds()
+	result.NumTurns = reporter.turns
+	result.ToolCalls = reporter.toolCalls
+	result.ToolFailures = reporter.toolFailures
+	result.PermissionDenials = reporter.denials
+	result.Usage = reporter.usage
+	reporter.mu.Unlock()
+
+	switch {
+	case err != nil && errors.Is(err, tools.ErrBudgetExhausted):
+		result.Status, result.ExitCode = HeadlessStatusBudgetExhausted, ExitBudgetExhausted
+		result.Error = err.Error()
+	case err != nil:
+		result.Status, result.ExitCode = HeadlessStatusError, ExitError
+		result.Error = err.Error()
+	case len(result.PermissionDenials) > 0:
+		result.Status, result.ExitCode = HeadlessStatusPermissionDenied, ExitPermissionDenied
+	case result.ToolFailures > 0:
+		result.Status, result.ExitCode = HeadlessStatusToolFailure, ExitToolFailure
+	default:
+		result.Status, result.ExitCode = HeadlessStatusSuccess, ExitSuccess
+	}
+
+	logging.Info("headless run finished",
+		"status", result.Status,
+		"turns", result.NumTurns,
+		"tool_calls", result.ToolCalls,
+		"duration_ms", result.DurationMs)
+
+	reporter.finish(result)
+	return result
+}
+
+// NewHeadless creates an application instance for non-interactive runs.
+func NewHeadless(cfg *config.Config, workDir string) (*App, error) {
+	return NewBuilder(cfg, workDir).WithHeadless().Build()
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"gokin/internal/logging"
+
+	"google.golang.org/genai"
+)
+
+// maxStopHookContinuations caps how often stop hooks can make the agent
+// continue a single turn, so a hook that always blocks cannot loop forever.
+const maxStopHookContinuations = 5
+
+// runPromptHooks runs user_prompt_submit hooks. It returns the prompt with any
+// hook context appended, or an error if a hook blocked it.
+func (a *App) runPromptHooks(ctx context.Context, prompt string) (string, error) {
+	if a.hooksManager == nil {
+		return prompt, nil
+	}
+
+	outcome := a.hooksManager.RunUserPromptSubmit(ctx, prompt)
+	if outcome.Denied {
+		return "", fmt.Errorf("prompt blocked by hook %q: %s", outcome.DeniedBy, outcome.Reason)
+	}
+	if notes := outcome.Notes(); notes != "" {
+		prompt += "\n\n" + notes
+	}
+	return prompt, nil
+}
+
+// executeTurn runs a message through the task router, or the executor when
+// routing is disabled.
+func (a *App) executeTurn(ctx context.Context, history []*genai.Content, message string) ([]*genai.Content, string, error) {
+	if a.taskRouter != nil {
+		return a.taskRouter.Execute(ctx, history, message)
+	}
+	return a.executor.Execute(ctx, history, message)
+}
+
+// runStopHooks runs stop hooks after a turn. While a hook blocks the stop, the
+// conversation continues with the hook's reason as the next message; notify
+// is called with the hook name and reason before each continuation. The
+// session is updated after every continued turn, and the history and response
+// of the last completed turn are returned.
+func (a *App) runStopHooks(ctx context.Context, history []*genai.Content, response string, notify func(hook, reason string)) ([]*genai.Content, string, error) {
+	if a.hooksManager == nil {
+		return history, response, nil
+	}
+
+	for i := 0; ; i++ {
+		outcome := a.hooksManager.RunStop(ctx, response, i > 0)
+		if !outcome.Denied || ctx.Err() != nil {
+			return history, response, nil
+		}
+		if i >= maxStopHookContinuations {
+			logging.Warn("stop hook continuation limit reached", "hook", outcome.DeniedBy, "limit", maxStopHookContinuations)
+			return history, response, nil
+		}
+
+		logging.Info("stop hook blocked turn end", "hook", outcome.DeniedBy, "reason", outcome.Reason)
+		if notify != nil {
+			notify(outcome.DeniedBy, outcome.Reason)
+		}
+
+		message := fmt.Sprintf("[Stop hook %q] %s", outcome.DeniedBy, outcome.Reason)
+		if notes := outcome.Notes(); notes != "" {
+			message += "\n\n" + notes
+		}
+
+		newHistory, newResponse, err := a.executeTurn(ctx, history, message)
+		if err != nil {
+			return history, response, err
+		}
+		history, response = newHistory, newResponse
+		a.session.SetHistory(history)
+	}
+}
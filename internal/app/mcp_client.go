@@ -0,0 +1,279 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"gokin/internal/client"
+	"gokin/internal/config"
+	appcontext "gokin/internal/context"
+	"gokin/internal/logging"
+	"gokin/internal/mcp"
+	"gokin/internal/tools"
+	"gokin/internal/ui"
+
+	"google.golang.org/genai"
+)
+
+// maxSamplingPreview limits how much of a sampling request is shown for approval.
+const maxSamplingPreview = 600
+
+// newMCPServerConfig converts a configured server to the MCP package's form.
+func newMCPServerConfig(s config.MCPServerConfig) *mcp.ServerConfig {
+	cfg := &mcp.ServerConfig{
+		Name:        s.Name,
+		Transport:   s.Transport,
+		Command:     s.Command,
+		Args:        s.Args,
+		Env:         s.Env,
+		URL:         s.URL,
+		Headers:     s.Headers,
+		AutoConnect: s.AutoConnect,
+		Timeout:     s.Timeout,
+		MaxRetries:  s.MaxRetries,
+		RetryDelay:  s.RetryDelay,
+		ToolPrefix:  s.ToolPrefix,
+	}
+	if s.OAuth != nil {
+		cfg.OAuth = &mcp.OAuthConfig{
+			ClientID:     s.OAuth.ClientID,
+			ClientSecret: s.OAuth.ClientSecret,
+			Scopes:       s.OAuth.Scopes,
+			CallbackPort: s.OAuth.CallbackPort,
+		}
+	}
+	return cfg
+}
+
+// mcpTokenPath returns the file holding OAuth tokens of MCP servers.
+func mcpTokenPath(configDir string) string {
+	return filepath.Join(configDir, "mcp_tokens.json")
+}
+
+// findMCPServer returns the configured MCP server with the given name.
+func findMCPServer(cfg *config.Config, name string) (*mcp.ServerConfig, error) {
+	for _, s := range cfg.MCP.Servers {
+		if s.Name == name {
+			return newMCPServerConfig(s), nil
+		}
+	}
+	return nil, fmt.Errorf("no MCP server named %q in the config", name)
+}
+
+// MCPLogin authorizes gokin with an HTTP MCP server through OAuth and stores
+// the tokens for later sessions.
+func MCPLogin(ctx context.Context, cfg *config.Config, server string, opts mcp.LoginOptions) error {
+	serverCfg, err := findMCPServer(cfg, server)
+	if err != nil {
+		return err
+	}
+	configDir, err := appcontext.GetConfigDir()
+	if err != nil {
+		return fmt.Errorf("failed to get config dir: %w", err)
+	}
+	return mcp.Login(ctx, serverCfg, mcp.NewTokenStore(mcpTokenPath(configDir)), opts)
+}
+
+// MCPLogout removes the stored OAuth tokens of an MCP server. It reports
+// whether tokens were stored.
+func MCPLogout(server string) (bool, error) {
+	configDir, err := appcontext.GetConfigDir()
+	if err != nil {
+		return false, fmt.Errorf("failed to get config dir: %w", err)
+	}
+	return mcp.NewTokenStore(mcpTokenPath(configDir)).Delete(server)
+}
+
+// onMCPToolsChanged swaps a server's tools in the registry after the server
+// announced that its tool list changed.
+func (a *App) onMCPToolsChanged(server string, removed, added []tools.Tool) {
+	for _, t := range removed {
+		a.registry.Unregister(t.Name())
+	}
+	for _, t := range added {
+		if err := a.registry.Register(t); err != nil {
+			logging.Warn("failed to register MCP tool", "tool", t.Name(), "error", err)
+		}
+	}
+
+	a.mu.Lock()
+	c := a.client
+	a.mu.Unlock()
+	if c != nil {
+		c.SetTools(a.registry.GeminiTools())
+	}
+
+	logging.Info("MCP tools updated", "server", server, "removed", len(removed), "added", len(added))
+}
+
+// handleMCPSampling answers a server's sampling/createMessage request with the
+// active model after the permission manager approved it. "Always" approves
+// further requests from the same server for the rest of the session.
+func (a *App) handleMCPSampling(ctx context.Context, server string, params *mcp.CreateMessageParams) (*mcp.CreateMessageResult, error) {
+	if len(params.Messages) == 0 {
+		return nil, errors.New("sampling request has no messages")
+	}
+
+	a.mu.Lock()
+	c := a.client
+	a.mu.Unlock()
+	if c == nil {
+		return nil, errors.New("no model client available for sampling")
+	}
+	if a.permManager == nil {
+		return nil, errors.New("sampling requires permission checks")
+	}
+
+	preview := samplingPreview(params)
+	a.safeSendToProgram(ui.StreamTextMsg(fmt.Sprintf("\nMCP server %q asks to use the model (max %d tokens):\n%s\n", server, params.MaxTokens, preview)))
+
+	resp, err := a.permManager.Check(ctx, "mcp_sampling", map[string]any{"server": server, "prompt": preview})
+	if err != nil {
+		return nil, fmt.Errorf("sampling not approved: %w", err)
+	}
+	if !resp.Allowed {
+		return nil, fmt.Errorf("sampling not approved: %s", resp.Reason)
+	}
+
+	// A separate client keeps the server's system prompt and the missing
+	// tools from leaking into the main conversation.
+	sc := c.WithModel(c.GetModel())
+	sc.SetTools(nil)
+	sc.SetSystemInstruction(params.SystemPrompt)
+
+	var history []*genai.Content
+	for _, msg := range params.Messages[:len(params.Messages)-1] {
+		var role genai.Role = genai.RoleUser
+		if msg.Role == "assistant" {
+			role = genai.RoleModel
+		}
+		history = append(history, genai.NewContentFromText(samplingText(msg), role))
+	}
+	last := samplingText(params.Messages[len(params.Messages)-1])
+
+	text, stopReason, err := sampleText(ctx, sc, history, last, params.MaxTokens)
+	if err != nil {
+		return nil, err
+	}
+	for _, stop := range params.StopSequences {
+		if i := strings.Index(text, stop); i >= 0 {
+			text = text[:i]
+			stopReason = "stopSequence"
+		}
+	}
+
+	logging.Info("MCP sampling request answered", "server", server, "model", sc.GetModel(), "chars", len(text))
+
+	return &mcp.CreateMessageResult{
+		Role:       "assistant",
+		Content:    &mcp.ContentBlock{Type: "text", Text: text},
+		Model:      sc.GetModel(),
+		StopReason: stopReason,
+	}, nil
+}
+
+// sampleText streams a completion and stops it once its estimated size
+// reaches maxTokens, since the clients have no per-request output limit.
+// A maxTokens of zero or less means no limit.
+func sampleText(ctx context.Context, c client.Client, history []*genai.Content, message string, maxTokens int) (string, string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.SendMessageWithHistory(ctx, history, message)
+	if err != nil {
+		return "", "", err
+	}
+
+	var sb strings.Builder
+	for chunk := range stream.Chunks {
+		if chunk.Error != nil {
+			return "", "", chunk.Error
+		}
+		sb.WriteString(chunk.Text)
+		if maxTokens <= 0 {
+			continue
+		}
+		text := sb.String()
+		if estimated := appcontext.EstimateTokens(text); estimated >= maxTokens {
+			cancel()
+			go func() {
+				for range stream.Chunks {
+				}
+			}()
+			if estimated > maxTokens {
+				text = truncateUTF8(text, len(text)*maxTokens/estimated)
+			}
+			return text, "maxTokens", nil
+		}
+	}
+	return sb.String(), "endTurn", nil
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// samplingText returns the text of a sampling message; other content types
+// are described because they cannot be forwarded as text.
+func samplingText(msg *mcp.SamplingMessage) string {
+	if msg.Content == nil {
+		return ""
+	}
+	if msg.Content.Type == "text" {
+		return msg.Content.Text
+	}
+	return fmt.Sprintf("[%s content: %s]", msg.Content.Type, msg.Content.MIMEType)
+}
+
+// samplingPreview summarizes a sampling request for the approval prompt.
+func samplingPreview(params *mcp.CreateMessageParams) string {
+	var sb strings.Builder
+	if params.SystemPrompt != "" {
+		sb.WriteString("System: " + params.SystemPrompt + "\n")
+	}
+	for _, msg := range params.Messages {
+		sb.WriteString(msg.Role + ": " + samplingText(msg) + "\n")
+	}
+	preview := strings.TrimSpace(sb.String())
+	if len(preview) > maxSamplingPreview {
+		preview = preview[:maxSamplingPreview] + "..."
+	}
+	return preview
+}
+
+// expandMCPResources attaches to message the contents of the @server:uri
+// mentions in source, the part of it the user wrote. Mentions that cannot
+// be read are left as written and reported.
+func (a *App) expandMCPResources(ctx context.Context, message, source string) string {
+	if a.mcpManager == nil {
+		return message
+	}
+	mentions := mcp.ParseResourceMentions(source, a.mcpManager.GetConnectedServers())
+	if len(mentions) == 0 {
+		return message
+	}
+
+	var sb strings.Builder
+	sb.WriteString(message)
+	for _, m := range mentions {
+		contents, err := a.mcpManager.ReadResource(ctx, m.Server, m.URI)
+		if err != nil {
+			logging.Warn("failed to read MCP resource", "server", m.Server, "uri", m.URI, "error", err)
+			a.safeSendToProgram(ui.StreamTextMsg(fmt.Sprintf("Could not attach %s: %v\n", m.Text, err)))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n\n<resource server=%q uri=%q>\n%s\n</resource>", m.Server, m.URI, mcp.FormatResourceContents(contents)))
+	}
+	return sb.String()
+}
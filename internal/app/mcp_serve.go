@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"gokin/internal/logging"
+	"gokin/internal/mcp"
+	"gokin/internal/tools"
+)
+
+// mcpServeExcluded are tools that only make sense inside an interactive agent
+// session (they drive the UI, the plan, sub-agents or the tool registry itself)
+// and are never exposed over MCP.
+var mcpServeExcluded = map[string]bool{
+	"ask_user":             true,
+	"tools_list":           true,
+	"request_tool":         true,
+	"todo":                 true,
+	"enter_plan_mode":      true,
+	"update_plan_progress": true,
+	"get_plan_status":      true,
+	"exit_plan_mode":       true,
+	"undo_plan":            true,
+	"redo_plan":            true,
+	"contract_propose":     true,
+	"contract_verify":      true,
+	"contract_status":      true,
+	"task":                 true,
+	"task_output":          true,
+	"task_stop":            true,
+	"ask_agent":            true,
+	"coordinate":           true,
+	"shared_memory":        true,
+	"update_scratchpad":    true,
+}
+
+// MCPServeOptions configures `gokin mcp serve`.
+type MCPServeOptions struct {
+	// HTTPAddr serves the streamable HTTP transport at this address; empty serves stdio.
+	HTTPAddr string
+	HTTPPath string
+	HTTP     mcp.HTTPOptions
+
+	// Tools restricts the exposed tools to these names (empty exposes the default set).
+	Tools []string
+
+	// PermissionMode answers permission prompts for remote calls; rules that
+	// already allow or deny a tool apply as usual.
+	PermissionMode PermissionMode
+}
+
+// ServeMCP exposes the tool registry to MCP clients until ctx is cancelled or,
+// for stdio, the client disconnects. Remote calls go through the executor, so
+// permission rules, path confinement, hooks and audit logging apply to them as
+// to calls made by the model. The App is shut down afterwards.
+func (a *App) ServeMCP(ctx context.Context, opts MCPServeOptions) error {
+	a.configureLogging()
+
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), GracefulShutdownTimeout)
+		defer cancel()
+		a.gracefulShutdown(shutdownCtx)
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-a.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	exposed, err := a.mcpExposedTools(opts.Tools)
+	if err != nil {
+		return err
+	}
+
+	// No user is present to answer prompts or review diffs.
+	if a.permManager != nil {
+		a.permManager.SetPromptHandler(headlessPermissionHandler(opts.PermissionMode))
+	}
+	a.executor.SetHandler(&tools.ExecutionHandler{})
+	ctx = tools.ContextWithSkipDiff(ctx)
+
+	server := mcp.NewServer(mcp.ServerOptions{
+		Name:    "gokin",
+		Version: a.config.Version,
+		Instructions: fmt.Sprintf("Gokin code tools for the project at %s. Relative paths are resolved against it; "+
+			"paths outside the project are rejected.", a.workDir),
+		Allow: func(name string) bool { return exposed[name] },
+	}, a.registry, a.executor.ExecuteCall)
+
+	if opts.HTTPAddr != "" {
+		logging.Info("serving MCP over HTTP", "addr", opts.HTTPAddr, "path", opts.HTTPPath, "tools", len(exposed))
+		return server.ListenAndServeHTTP(ctx, opts.HTTPAddr, opts.HTTPPath, opts.HTTP)
+	}
+	logging.Info("serving MCP over stdio", "tools", len(exposed))
+	return server.ServeStdio(ctx, os.Stdin, os.Stdout)
+}
+
+// mcpExposedTools returns the set of tools served over MCP.
+func (a *App) mcpExposedTools(only []string) (map[string]bool, error) {
+	exposed := make(map[string]bool)
+	if len(only) == 0 {
+		for _, name := range a.registry.Names() {
+			if !mcpServeExcluded[name] {
+				exposed[name] = true
+			}
+		}
+		return exposed, nil
+	}
+
+	for _, name := range only {
+		if _, ok := a.registry.Get(name); !ok {
+			return nil, fmt.Errorf("unknown tool %q", name)
+		}
+		if mcpServeExcluded[name] {
+			return nil, fmt.Errorf("tool %q requires an interactive session and cannot be served", name)
+		}
+		exposed[name] = true
+	}
+	return exposed, nil
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gokin/internal/logging"
+	"gokin/internal/mention"
+	"gokin/internal/ui"
+)
+
+// mentionProvider completes @mentions in the input box.
+type mentionProvider struct {
+	resolver *mention.Resolver
+}
+
+func (p mentionProvider) CompleteMentions(query string, limit int) []ui.MentionSuggestion {
+	completions := p.resolver.Complete(query, limit)
+	suggestions := make([]ui.MentionSuggestion, len(completions))
+	for i, c := range completions {
+		suggestions[i] = ui.MentionSuggestion{Value: c.Value, Kind: string(c.Kind), Detail: c.Detail}
+	}
+	return suggestions
+}
+
+// lookupSymbols finds @symbol mentions in the semantic index.
+func (a *App) lookupSymbols(query string, limit int) []mention.Symbol {
+	indexer, err := a.GetSemanticIndexer()
+	if err != nil {
+		return nil
+	}
+
+	infos := indexer.Symbols(query, limit)
+	symbols := make([]mention.Symbol, len(infos))
+	for i, s := range infos {
+		symbols[i] = mention.Symbol{
+			Name:      s.Name,
+			Kind:      s.Kind,
+			FilePath:  s.FilePath,
+			LineStart: s.LineStart,
+			LineEnd:   s.LineEnd,
+			Content:   s.Content,
+		}
+	}
+	return symbols
+}
+
+// fetchMention fetches an @url mention with the web_fetch tool. The call
+// goes through the executor, so permission rules, hooks and the audit log
+// apply as for the model's own calls.
+func (a *App) fetchMention(ctx context.Context, url string) (string, error) {
+	if a.executor == nil {
+		return "", fmt.Errorf("web_fetch tool is not available")
+	}
+	result := a.executor.ExecuteCall(ctx, "web_fetch", map[string]any{"url": url})
+	if !result.Success {
+		return "", errors.New(result.Error)
+	}
+	return result.Content, nil
+}
+
+// expandMentions attaches to message the files, directories, symbols and
+// URLs mentioned in source, the part of it the user wrote, and counts them
+// in the token usage display.
+func (a *App) expandMentions(ctx context.Context, message, source string) string {
+	if a.mentionResolver == nil {
+		return message
+	}
+	expanded, attachments := a.mentionResolver.Expand(ctx, source)
+	if len(attachments) == 0 {
+		return message
+	}
+
+	var attached []string
+	tokens := 0
+	for _, att := range attachments {
+		if att.Err != nil {
+			logging.Warn("failed to attach mention", "mention", att.Mention, "error", att.Err)
+			a.safeSendToProgram(ui.StreamTextMsg(fmt.Sprintf("Could not attach @%s: %v\n", att.Mention, att.Err)))
+			continue
+		}
+		tokens += att.Tokens
+		attached = append(attached, fmt.Sprintf("%s (~%d tokens)", att.Title, att.Tokens))
+	}
+	if len(attached) > 0 {
+		a.safeSendToProgram(ui.StreamTextMsg(fmt.Sprintf("📎 Attached %s\n\n", strings.Join(attached, ", "))))
+		a.sendPendingTokenUsage(tokens)
+	}
+	return message + strings.TrimPrefix(expanded, source)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"gokin/internal/agent"
@@ -26,18 +27,33 @@ func (a *App) processMessageWithContext(ctx context.Context, message string) {
 
 		// Check for pending message and process it
 		a.pendingMu.Lock()
-		pending := a.pendingMessage
-		a.pendingMessage = ""
+		pending, scope := a.pendingMessage, a.pendingScope
+		a.pendingMessage, a.pendingScope = "", nil
 		a.pendingMu.Unlock()
 
 		if pending != "" {
 			// Notify user that we're processing pending message
 			a.safeSendToProgram(ui.StreamTextMsg("\n📤 Processing queued message...\n"))
 			// Recursively handle the pending message
-			go a.handleSubmit(pending)
+			go a.submit(pending, scope)
 		}
 	}()
 
+	// Prompts of custom commands may restrict tools or switch the model
+	ctx, restoreScope := a.applyPromptScope(ctx)
+	defer restoreScope()
+
+	// user_prompt_submit hooks can block the prompt or add context to it
+	prompt := message
+	message, err := a.runPromptHooks(ctx, message)
+	if err != nil {
+		a.safeSendToProgram(ui.ErrorMsg(err))
+		return
+	}
+
+	// Snapshot the working tree so /rewind can undo this turn
+	a.createCheckpoint(ctx, prompt)
+
 	// Track response start time and reset tools used
 	a.mu.Lock()
 	a.responseStartTime = time.Now()
@@ -67,19 +83,21 @@ func (a *App) processMessageWithContext(ctx context.Context, message string) {
 		a.sendTokenUsageUpdate()
 	}
 
+	// Attach @file, @dir, @symbol and @url mentions and @server:uri MCP
+	// resources. Only the typed text is scanned, so hook context and
+	// command output cannot pull in files or URLs.
+	mentionText := promptMentionText(ctx, prompt)
+	message = a.expandMentions(ctx, message, mentionText)
+	message = a.expandMCPResources(ctx, message, mentionText)
+
 	// Get current history
 	history := a.session.GetHistory()
 
 	// === IMPROVEMENT 1: Use Task Router for intelligent routing ===
-	var newHistory []*genai.Content
-	var response string
-	var err error
+	newHistory, response, err := a.executeTurn(ctx, history, message)
 
+	// Log routing decision for debugging
 	if a.taskRouter != nil {
-		// Route the task intelligently
-		newHistory, response, err = a.taskRouter.Execute(ctx, history, message)
-
-		// Log routing decision for debugging
 		if analysis := a.taskRouter.GetAnalysis(message); analysis != nil {
 			logging.Debug("task routed",
 				"complexity", analysis.Score,
@@ -87,9 +105,6 @@ func (a *App) processMessageWithContext(ctx context.Context, message string) {
 				"strategy", analysis.Strategy,
 				"reasoning", analysis.Reasoning)
 		}
-	} else {
-		// Fallback to standard executor
-		newHistory, response, err = a.executor.Execute(ctx, history, message)
 	}
 
 	if err != nil {
@@ -100,6 +115,15 @@ func (a *App) processMessageWithContext(ctx context.Context, message string) {
 	// Update session history
 	a.session.SetHistory(newHistory)
 
+	// stop hooks can keep the agent working (e.g. "tests still failing")
+	newHistory, response, err = a.runStopHooks(ctx, newHistory, response, func(hook, reason string) {
+		a.safeSendToProgram(ui.StreamTextMsg(fmt.Sprintf("\n🪝 Hook %q: %s — continuing\n\n", hook, reason)))
+	})
+	if err != nil {
+		a.safeSendToProgram(ui.ErrorMsg(err))
+		return
+	}
+
 	// Check for context-clear request after plan approval
 	if a.planManager != nil && a.planManager.IsContextClearRequested() {
 		approvedPlan := a.planManager.ConsumeContextClearRequest()
@@ -157,6 +181,11 @@ func (a *App) processMessageWithContext(ctx context.Context, message string) {
 	outputTokens := a.totalOutputTokens
 	a.mu.Unlock()
 
+	// Verify the active contract after the model changed files
+	if a.shouldAutoVerifyContract(toolsUsed) {
+		a.autoVerifyContract(ctx)
+	}
+
 	if program != nil {
 		program.Send(ui.ResponseDoneMsg{})
 
@@ -444,7 +473,16 @@ func (a *App) executePlanDirectly(ctx context.Context, approvedPlan *plan.Plan)
 		if len(output) > 2000 {
 			output = output[:2000] + "..."
 		}
-		a.planManager.CompleteStep(step.ID, output)
+		if err := a.planManager.CompleteStep(step.ID, output); err != nil {
+			a.safeSendToProgram(ui.StreamTextMsg(
+				fmt.Sprintf("\n  Step %d failed: %s\n", step.ID, err)))
+
+			if a.config.Plan.AbortOnStepFailure {
+				a.safeSendToProgram(ui.StreamTextMsg("Aborting plan due to step failure.\n"))
+				break
+			}
+			continue
+		}
 
 		// Store step result in SharedMemory for inter-step communication
 		if sharedMem != nil {
@@ -518,6 +556,11 @@ func (a *App) executePlanDirectly(ctx context.Context, approvedPlan *plan.Plan)
 		Status:     statusText,
 	})
 
+	// Verify the contract approved with the plan
+	if planFinished {
+		a.autoVerifyContract(ctx)
+	}
+
 	// Send response metadata so UI shows duration and token usage
 	a.mu.Lock()
 	inputTokens := a.totalInputTokens
@@ -638,7 +681,33 @@ func (a *App) executePlanDelegated(ctx context.Context, approvedPlan *plan.Plan)
 	a.safeSendToProgram(ui.StreamTextMsg(
 		fmt.Sprintf("\n━━━ Executing plan: %s (%d steps) ━━━\n\n", approvedPlan.Title, totalSteps)))
 
-	for _, step := range approvedPlan.Steps {
+	// buildPrompt builds a step's prompt with full plan context
+	buildPrompt := func(step *plan.Step) string {
+		prevSummary := a.planManager.GetPreviousStepsSummary(step.ID, 2000)
+
+		// Get SharedMemory context for this sub-agent
+		sharedMemCtx := ""
+		if sharedMem != nil {
+			sharedMemCtx = sharedMem.GetForContext(fmt.Sprintf("plan_step_%d", step.ID), 20)
+		}
+
+		return buildStepPrompt(&StepPromptContext{
+			Step:            step,
+			PrevSummary:     prevSummary,
+			PlanTitle:       approvedPlan.Title,
+			PlanDescription: approvedPlan.Description,
+			PlanRequest:     approvedPlan.Request,
+			ContextSnapshot: contextSnapshot,
+			SharedMemoryCtx: sharedMemCtx,
+			TotalSteps:      totalSteps,
+			CompletedCount:  approvedPlan.CompletedCount(),
+		})
+	}
+
+	// Outcomes of steps already run in a parallel batch, by step ID
+	parallelOutcomes := make(map[int]stepOutcome)
+
+	for i, step := range approvedPlan.Steps {
 		select {
 		case <-ctx.Done():
 			return
@@ -650,6 +719,12 @@ func (a *App) executePlanDelegated(ctx context.Context, approvedPlan *plan.Plan)
 			continue
 		}
 
+		// Run this and the following parallel steps concurrently, each in
+		// its own worktree; their outcomes are then handled in order.
+		if _, ran := parallelOutcomes[step.ID]; !ran && step.Parallel && a.config.Plan.ParallelWorktrees {
+			a.runParallelSteps(ctx, approvedPlan.Steps[i:], buildPrompt, projectCtx, parallelOutcomes)
+		}
+
 		// Mark step as started and track current step ID
 		a.planManager.StartStep(step.ID)
 		a.planManager.SetCurrentStepID(step.ID)
@@ -669,61 +744,21 @@ func (a *App) executePlanDelegated(ctx context.Context, approvedPlan *plan.Plan)
 		header := fmt.Sprintf("──── Step %d/%d: %s ────\n", step.ID, totalSteps, step.Title)
 		a.safeSendToProgram(ui.StreamTextMsg(header))
 
-		// Build step prompt with full plan context
-		prevSummary := a.planManager.GetPreviousStepsSummary(step.ID, 2000)
-
-		// Get SharedMemory context for this sub-agent
-		sharedMemCtx := ""
-		if sharedMem != nil {
-			sharedMemCtx = sharedMem.GetForContext(fmt.Sprintf("plan_step_%d", step.ID), 20)
-		}
-
-		stepPrompt := buildStepPrompt(&StepPromptContext{
-			Step:            step,
-			PrevSummary:     prevSummary,
-			PlanTitle:       approvedPlan.Title,
-			PlanDescription: approvedPlan.Description,
-			PlanRequest:     approvedPlan.Request,
-			ContextSnapshot: contextSnapshot,
-			SharedMemoryCtx: sharedMemCtx,
-			TotalSteps:      totalSteps,
-			CompletedCount:  approvedPlan.CompletedCount(),
-		})
-
-		// Stream sub-agent text to TUI
-		onText := func(text string) {
-			a.safeSendToProgram(ui.StreamTextMsg(text))
-		}
-
-		// Spawn sub-agent for this step with retry on retryable errors
 		var result *agent.AgentResult
 		var err error
-		const maxRetries = 3
-		backoffDurations := []time.Duration{5 * time.Second, 15 * time.Second, 30 * time.Second}
-
-		for attempt := 0; attempt < maxRetries; attempt++ {
-			_, result, err = a.agentRunner.SpawnWithContext(
-				ctx, "general", stepPrompt, 30, "", projectCtx, onText, true)
-
-			// Retry on retryable errors (timeout, network, rate limit, etc.)
-			if err != nil && isRetryableError(err) && attempt < maxRetries-1 {
-				backoff := backoffDurations[attempt]
-				logging.Warn("sub-agent error, retrying step",
-					"step_id", step.ID, "attempt", attempt+1, "error", err.Error(), "backoff", backoff)
-				a.safeSendToProgram(ui.StreamTextMsg(
-					fmt.Sprintf("\n⚠️ Step %d failed (attempt %d/%d): %s\nRetrying in %v...\n",
-						step.ID, attempt+1, maxRetries, err.Error(), backoff)))
-
-				// Wait with backoff, but respect context cancellation
-				select {
-				case <-time.After(backoff):
-					continue
-				case <-ctx.Done():
-					err = ctx.Err()
-					break
-				}
+		if outcome, ran := parallelOutcomes[step.ID]; ran {
+			// Ran in a parallel batch without streaming: show its output now
+			result, err = outcome.result, outcome.err
+			if result != nil && result.Output != "" {
+				a.safeSendToProgram(ui.StreamTextMsg(result.Output + "\n"))
 			}
-			break
+		} else {
+			// Stream sub-agent text to TUI
+			onText := func(text string) {
+				a.safeSendToProgram(ui.StreamTextMsg(text))
+			}
+			// Spawn sub-agent for this step with retry on retryable errors
+			result, err = a.spawnPlanStep(ctx, step.ID, buildPrompt(step), projectCtx, onText)
 		}
 
 		if err != nil || result == nil || result.Status == agent.AgentStatusFailed {
@@ -742,7 +777,7 @@ func (a *App) executePlanDelegated(ctx context.Context, approvedPlan *plan.Plan)
 				a.safeSendToProgram(ui.StreamTextMsg(
 					fmt.Sprintf("\n⏸ Step %d paused after %d attempts: %s\n"+
 						"Use /resume-plan to continue when ready.\n",
-						step.ID, maxRetries, errMsg)))
+						step.ID, maxStepAttempts, errMsg)))
 				a.safeSendToProgram(ui.PlanProgressMsg{
 					PlanID:        approvedPlan.ID,
 					CurrentStepID: step.ID,
@@ -783,7 +818,16 @@ func (a *App) executePlanDelegated(ctx context.Context, approvedPlan *plan.Plan)
 		if len(output) > 2000 {
 			output = output[:2000] + "..."
 		}
-		a.planManager.CompleteStep(step.ID, output)
+		if err := a.planManager.CompleteStep(step.ID, output); err != nil {
+			a.safeSendToProgram(ui.StreamTextMsg(
+				fmt.Sprintf("\n  Step %d failed: %s\n", step.ID, err)))
+
+			if a.config.Plan.AbortOnStepFailure {
+				a.safeSendToProgram(ui.StreamTextMsg("Aborting plan due to step failure.\n"))
+				break
+			}
+			continue
+		}
 
 		// Store step result in SharedMemory for inter-step communication
 		if sharedMem != nil {
@@ -837,6 +881,11 @@ func (a *App) executePlanDelegated(ctx context.Context, approvedPlan *plan.Plan)
 		Status:     statusText,
 	})
 
+	// Verify the contract approved with the plan
+	if planFinished {
+		a.autoVerifyContract(ctx)
+	}
+
 	// Send response metadata so UI shows duration
 	a.safeSendToProgram(ui.ResponseMetadataMsg{
 		Model:    a.config.Model.Name,
@@ -859,6 +908,99 @@ func (a *App) executePlanDelegated(ctx context.Context, approvedPlan *plan.Plan)
 	}
 }
 
+// stepOutcome is the result of running a plan step's sub-agent.
+type stepOutcome struct {
+	result *agent.AgentResult
+	err    error
+}
+
+const (
+	// maxStepAttempts is how many times a plan step is tried on retryable errors.
+	maxStepAttempts = 3
+
+	// maxParallelSteps bounds the plan steps run concurrently.
+	maxParallelSteps = 4
+
+	// parallelStepTimeout bounds a plan step run in a parallel batch.
+	parallelStepTimeout = 30 * time.Minute
+)
+
+// spawnPlanStep runs a plan step's sub-agent, retrying retryable errors
+// with backoff.
+func (a *App) spawnPlanStep(ctx context.Context, stepID int, stepPrompt, projectCtx string, onText func(string)) (*agent.AgentResult, error) {
+	var result *agent.AgentResult
+	var err error
+	backoffDurations := []time.Duration{5 * time.Second, 15 * time.Second, 30 * time.Second}
+
+	for attempt := 0; attempt < maxStepAttempts; attempt++ {
+		_, result, err = a.agentRunner.SpawnWithContext(
+			ctx, "general", stepPrompt, 30, "", projectCtx, onText, true)
+
+		// Retry on retryable errors (timeout, network, rate limit, etc.)
+		if err != nil && isRetryableError(err) && attempt < maxStepAttempts-1 {
+			backoff := backoffDurations[attempt]
+			logging.Warn("sub-agent error, retrying step",
+				"step_id", stepID, "attempt", attempt+1, "error", err.Error(), "backoff", backoff)
+			a.safeSendToProgram(ui.StreamTextMsg(
+				fmt.Sprintf("\n⚠️ Step %d failed (attempt %d/%d): %s\nRetrying in %v...\n",
+					stepID, attempt+1, maxStepAttempts, err.Error(), backoff)))
+
+			// Wait with backoff, but respect context cancellation
+			select {
+			case <-time.After(backoff):
+				continue
+			case <-ctx.Done():
+				err = ctx.Err()
+				break
+			}
+		}
+		break
+	}
+	return result, err
+}
+
+// runParallelSteps runs the leading run of pending parallel steps in steps
+// concurrently, each sub-agent in its own git worktree that is merged back
+// when it finishes, and records their outcomes. A lone parallel step is
+// left to run as usual.
+func (a *App) runParallelSteps(ctx context.Context, steps []*plan.Step, buildPrompt func(*plan.Step) string, projectCtx string, outcomes map[int]stepOutcome) {
+	var batch []*plan.Step
+	for _, step := range steps {
+		if step.Status != plan.StatusPending || !step.Parallel {
+			break
+		}
+		batch = append(batch, step)
+	}
+	if len(batch) < 2 {
+		return
+	}
+
+	ids := make([]string, len(batch))
+	tasks := make([]*Task, len(batch))
+	var mu sync.Mutex
+	for i, step := range batch {
+		ids[i] = fmt.Sprintf("%d", step.ID)
+		stepPrompt := buildPrompt(step)
+		tasks[i] = &Task{
+			ID:   ids[i],
+			Name: step.Title,
+			Execute: func(ctx context.Context) error {
+				result, err := a.spawnPlanStep(ctx, step.ID, stepPrompt, projectCtx, nil)
+				mu.Lock()
+				outcomes[step.ID] = stepOutcome{result: result, err: err}
+				mu.Unlock()
+				return err
+			},
+		}
+	}
+
+	a.safeSendToProgram(ui.StreamTextMsg(
+		fmt.Sprintf("──── Running steps %s in parallel ────\n\n", strings.Join(ids, ", "))))
+
+	executor := NewParallelExecutor(maxParallelSteps, parallelStepTimeout)
+	_, _ = executor.ExecuteTasks(tools.ContextWithWorktreeIsolation(ctx), tasks)
+}
+
 // isRetryableError checks if an error is retryable (network, timeout, rate limit).
 func isRetryableError(err error) bool {
 	return client.IsRetryableError(err)
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gokin/internal/commands"
+	"gokin/internal/logging"
+	"gokin/internal/tools"
+)
+
+// promptScopeKey is the context key of the options of a prompt submitted by
+// a command.
+type promptScopeKey struct{}
+
+func withPromptScope(ctx context.Context, scope *commands.PromptOptions) context.Context {
+	return context.WithValue(ctx, promptScopeKey{}, scope)
+}
+
+// promptMentionText returns the text whose @mentions may be attached to a
+// prompt: what the user typed, or for a prompt submitted by a command the
+// text the command vouches for.
+func promptMentionText(ctx context.Context, typed string) string {
+	if scope, _ := ctx.Value(promptScopeKey{}).(*commands.PromptOptions); scope != nil {
+		return scope.MentionText
+	}
+	return typed
+}
+
+// applyPromptScope applies the prompt options carried by ctx: tool calls are
+// limited to the allowed tools and the model is switched for the turn. The
+// returned function restores the previous model.
+func (a *App) applyPromptScope(ctx context.Context) (context.Context, func()) {
+	scope, _ := ctx.Value(promptScopeKey{}).(*commands.PromptOptions)
+	if scope == nil {
+		return ctx, func() {}
+	}
+
+	if len(scope.AllowedTools) > 0 {
+		ctx = tools.ContextWithAllowedTools(ctx, scope.AllowedTools)
+	}
+
+	if scope.Model == "" || a.client == nil || a.client.GetModel() == scope.Model {
+		return ctx, func() {}
+	}
+	previous := a.client.GetModel()
+	a.client.SetModel(scope.Model)
+	logging.Debug("switched model for command prompt", "model", scope.Model, "previous", previous)
+	return ctx, func() {
+		a.client.SetModel(previous)
+	}
+}
+
+// RunShell runs a command of a custom command with the bash tool. The call
+// goes through the executor, so permission rules, command validation, the
+// sandbox and hooks apply as for the model's own commands.
+func (a *App) RunShell(ctx context.Context, command, description string) (string, error) {
+	if a.executor == nil {
+		return "", fmt.Errorf("bash tool is not available")
+	}
+	result := a.executor.ExecuteCall(ctx, "bash", map[string]any{
+		"command":     command,
+		"description": description,
+	})
+	out := strings.TrimRight(result.Content, "\n")
+	if !result.Success {
+		return out, errors.New(result.Error)
+	}
+	return out, nil
+}
@@ -179,6 +179,14 @@ func (a *App) gracefulShutdown(ctx context.Context) {
 		a.backgroundIndexer.Stop()
 	}
 
+	// 6c. Shut down language servers
+	if a.lspManager != nil {
+		logging.Debug("shutting down language servers")
+		if err := a.lspManager.Close(); err != nil {
+			logging.Debug("error shutting down language servers", "error", err)
+		}
+	}
+
 	// 7. Save semantic search cache
 	if a.semanticIndexer != nil {
 		logging.Debug("saving semantic cache")
@@ -208,8 +216,8 @@ func (a *App) gracefulShutdown(ctx context.Context) {
 		a.hooksManager.RunOnExit(ctx)
 	}
 
-	// 11. Save input history
-	if a.tui != nil {
+	// 11. Save input history (never loaded in headless runs)
+	if a.tui != nil && !a.headless {
 		if err := a.tui.SaveInputHistory(); err != nil {
 			logging.Debug("failed to save input history", "error", err)
 		}
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gokin/internal/git"
+	"gokin/internal/tools"
+	"gokin/internal/ui"
+	"gokin/internal/undo"
+)
+
+// conflictMarker starts a conflicting hunk in a merged file.
+const conflictMarker = "<<<<<<< "
+
+// mergeWorktree merges the changes an isolated agent made in its worktree
+// into the main working tree. Files the main tree has not changed since
+// the worktree was created are fast-forwarded, other files are merged
+// three-way, and conflicting merges are presented in the multi-diff preview
+// for the user to resolve. The branch is kept if any change was not
+// applied. The merge is one undoable change.
+func (a *App) mergeWorktree(ctx context.Context, agentID string, wt *git.Worktree) (string, bool, error) {
+	changes, err := wt.Changes(ctx)
+	if err != nil {
+		return "", true, err
+	}
+	if len(changes) == 0 {
+		return "No files were changed.", false, nil
+	}
+
+	var applied, skipped []string
+	var undoChanges []undo.FileChange
+	var conflicts []ui.DiffFile
+
+	write := func(path string, old, content []byte, existed bool) error {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return err
+		}
+		undoChanges = append(undoChanges, *undo.NewFileChange(path, "merge", old, content, !existed))
+		return nil
+	}
+
+	for _, c := range changes {
+		path := filepath.Join(wt.RepoDir, filepath.FromSlash(c.Path))
+		current, err := os.ReadFile(path)
+		existed := err == nil
+		if err != nil && !os.IsNotExist(err) {
+			return "", true, err
+		}
+		unchanged := (c.Base == nil && !existed) || (c.Base != nil && existed && bytes.Equal(current, c.Base))
+
+		switch {
+		case c.Content == nil:
+			// Deleted by the agent
+			if !existed {
+				continue
+			}
+			if !unchanged {
+				skipped = append(skipped, c.Path+" (deleted by the agent, changed in the main tree)")
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				return "", true, err
+			}
+			undoChanges = append(undoChanges, *undo.NewFileChange(path, "merge", current, nil, false))
+			applied = append(applied, c.Path+" (deleted)")
+
+		case existed && bytes.Equal(current, c.Content):
+			// The main tree already has the agent's version
+
+		case unchanged:
+			if err := write(path, current, c.Content, existed); err != nil {
+				return "", true, err
+			}
+			applied = append(applied, c.Path)
+
+		default:
+			merged, conflicted, err := git.MergeFile(ctx, current, c.Base, c.Content, "main", "agent "+agentID)
+			if err != nil {
+				return "", true, err
+			}
+			if !conflicted {
+				if err := write(path, current, merged, existed); err != nil {
+					return "", true, err
+				}
+				applied = append(applied, c.Path+" (merged)")
+				continue
+			}
+			conflicts = append(conflicts, ui.DiffFile{
+				FilePath:   path,
+				OldContent: string(current),
+				NewContent: string(merged),
+				IsNewFile:  !existed,
+			})
+		}
+	}
+
+	// Conflicts need the user: without a UI the main tree's version stays
+	if len(conflicts) > 0 && a.program != nil {
+		results, err := a.promptMultiDiffDecision(ctx, conflicts)
+		if err != nil {
+			results = nil
+		}
+		var remaining []ui.DiffFile
+		for _, f := range conflicts {
+			rel, _ := filepath.Rel(wt.RepoDir, f.FilePath)
+			result, ok := results[f.FilePath]
+			if !ok || result.Decision != ui.DiffApply {
+				remaining = append(remaining, f)
+				continue
+			}
+			if err := write(f.FilePath, []byte(f.OldContent), []byte(result.Content), !f.IsNewFile); err != nil {
+				return "", true, err
+			}
+			if strings.Contains(result.Content, conflictMarker) {
+				applied = append(applied, filepath.ToSlash(rel)+" (with conflict markers)")
+			} else {
+				applied = append(applied, filepath.ToSlash(rel)+" (resolved)")
+			}
+		}
+		conflicts = remaining
+	}
+	for _, f := range conflicts {
+		rel, _ := filepath.Rel(wt.RepoDir, f.FilePath)
+		skipped = append(skipped, filepath.ToSlash(rel)+" (conflict)")
+	}
+
+	if len(undoChanges) > 0 && a.undoManager != nil {
+		a.undoManager.Record(*undo.NewTransaction("merge", undoChanges))
+	}
+
+	var sb strings.Builder
+	if len(applied) > 0 {
+		fmt.Fprintf(&sb, "Merged into the working tree: %s.", strings.Join(applied, ", "))
+	} else {
+		sb.WriteString("No changes were merged into the working tree.")
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(&sb, "\nNot merged: %s.", strings.Join(skipped, ", "))
+	}
+	return sb.String(), len(skipped) > 0, nil
+}
+
+// configureWorktreeTools applies the tool settings of the main tree to the
+// tools created for an agent's worktree rooted at workDir.
+func (a *App) configureWorktreeTools(registry tools.ToolRegistry, workDir string) {
+	cfg := a.config
+	diffEnabled := cfg.DiffPreview.Enabled && cfg.Permission.Enabled
+	diffAdapter := &diffHandlerAdapter{app: a}
+
+	for _, tool := range registry.List() {
+		if len(cfg.Tools.AllowedDirs) > 0 {
+			if dt, ok := tool.(interface{ SetAllowedDirs([]string) }); ok {
+				dt.SetAllowedDirs(cfg.Tools.AllowedDirs)
+			}
+		}
+
+		switch t := tool.(type) {
+		case *tools.BashTool:
+			t.SetTaskManager(a.taskManager)
+			t.SetSandboxEnabled(cfg.Tools.Bash.Sandbox)
+			t.SetSandboxNetworkDisabled(cfg.Tools.Bash.SandboxDisableNetwork)
+			t.SetUnrestrictedMode(!cfg.Tools.Bash.Sandbox && !cfg.Permission.Enabled)
+		case *tools.ReadTool:
+			t.SetWorkDir(workDir)
+		case *tools.EditTool:
+			t.SetWorkDir(workDir)
+			if diffEnabled {
+				t.SetDiffHandler(diffAdapter)
+				t.SetDiffEnabled(true)
+			}
+		case *tools.WriteTool:
+			if diffEnabled {
+				t.SetDiffHandler(diffAdapter)
+				t.SetDiffEnabled(true)
+			}
+		case *tools.RefactorTool:
+			t.SetWorkDir(workDir)
+			if diffEnabled {
+				t.SetDiffHandler(diffAdapter)
+				t.SetDiffEnabled(true)
+			}
+		}
+	}
+}
@@ -20,6 +20,7 @@ type Entry struct {
 	Error     string         `json:"error,omitempty"`
 	Duration  time.Duration  `json:"duration_ms"`
 	SessionID string         `json:"session_id"`
+	Caller    string         `json:"caller,omitempty"` // Remote client for calls not made by the model
 }
 
 // NewEntry creates a new audit entry with a generated ID and timestamp.
@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 )
@@ -37,15 +38,19 @@ func (s *CallbackServer) Start() error {
 		ReadHeaderTimeout: 10 * time.Second,
 	}
 
-	// Start server in goroutine
+	// Listen before returning so that a busy port is reported to the caller
+	ln, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return fmt.Errorf("callback server error: %w", err)
+	}
+
+	// Serve in goroutine
 	go func() {
-		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := s.server.Serve(ln); err != nil && err != http.ErrServerClosed {
 			s.errChan <- fmt.Errorf("callback server error: %w", err)
 		}
 	}()
 
-	// Give server time to start
-	time.Sleep(100 * time.Millisecond)
 	return nil
 }
 
@@ -61,6 +61,9 @@ func (t *OAuthToken) IsExpired() bool {
 	if t == nil {
 		return true
 	}
+	if t.ExpiresAt.IsZero() {
+		return false // No expiry given by the server
+	}
 	return time.Now().After(t.ExpiresAt.Add(-TokenRefreshBuffer))
 }
 
@@ -11,13 +11,22 @@ import (
 	"time"
 )
 
-// OAuthManager handles the OAuth 2.0 flow for Gemini authentication
+// OAuthManager handles the OAuth 2.0 authorization code flow with PKCE
 type OAuthManager struct {
 	clientID     string
 	clientSecret string
 	redirectURI  string
 	scopes       []string
 
+	// Provider endpoints
+	authURL     string
+	tokenURL    string
+	userInfoURL string
+
+	// Extra parameters for the authorization and token requests
+	authParams url.Values
+	resource   string
+
 	// PKCE state (set during GenerateAuthURL)
 	codeVerifier  string
 	codeChallenge string
@@ -26,21 +35,62 @@ type OAuthManager struct {
 	httpClient *http.Client
 }
 
+// OAuthConfig configures an OAuthManager for an arbitrary provider.
+type OAuthConfig struct {
+	ClientID     string
+	ClientSecret string // Empty for public clients
+	RedirectURI  string
+	Scopes       []string
+
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string // Optional; used to look up the account email
+
+	// AuthParams are added to the authorization URL
+	AuthParams url.Values
+
+	// Resource is the RFC 8707 resource indicator sent with authorization
+	// and token requests
+	Resource string
+}
+
+// NewOAuthManager creates an OAuthManager for the given provider.
+func NewOAuthManager(cfg OAuthConfig) *OAuthManager {
+	return &OAuthManager{
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		redirectURI:  cfg.RedirectURI,
+		scopes:       cfg.Scopes,
+		authURL:      cfg.AuthURL,
+		tokenURL:     cfg.TokenURL,
+		userInfoURL:  cfg.UserInfoURL,
+		authParams:   cfg.AuthParams,
+		resource:     cfg.Resource,
+		httpClient: &http.Client{
+			Timeout: OAuthHTTPTimeout,
+		},
+	}
+}
+
 // NewGeminiOAuthManager creates an OAuthManager with Gemini Code Assist credentials
 func NewGeminiOAuthManager() *OAuthManager {
-	return &OAuthManager{
-		clientID:     GeminiOAuthClientID,
-		clientSecret: GeminiOAuthClientSecret,
-		redirectURI:  GeminiOAuthRedirectURI,
-		scopes: []string{
+	return NewOAuthManager(OAuthConfig{
+		ClientID:     GeminiOAuthClientID,
+		ClientSecret: GeminiOAuthClientSecret,
+		RedirectURI:  GeminiOAuthRedirectURI,
+		Scopes: []string{
 			ScopeCloudPlatform,
 			ScopeUserInfoEmail,
 			ScopeUserInfoProfile,
 		},
-		httpClient: &http.Client{
-			Timeout: OAuthHTTPTimeout,
+		AuthURL:     GoogleAuthURL,
+		TokenURL:    GoogleTokenURL,
+		UserInfoURL: GoogleUserInfo,
+		AuthParams: url.Values{
+			"access_type": {"offline"},
+			"prompt":      {"consent"},
 		},
-	}
+	})
 }
 
 // GenerateAuthURL creates the authorization URL with PKCE parameters.
@@ -62,16 +112,27 @@ func (m *OAuthManager) GenerateAuthURL() (string, error) {
 		"client_id":             {m.clientID},
 		"redirect_uri":          {m.redirectURI},
 		"response_type":         {"code"},
-		"scope":                 {strings.Join(m.scopes, " ")},
-		"access_type":           {"offline"},
-		"prompt":                {"consent"},
 		"code_challenge":        {m.codeChallenge},
 		"code_challenge_method": {"S256"},
 		"state":                 {m.state},
 	}
+	if len(m.scopes) > 0 {
+		params.Set("scope", strings.Join(m.scopes, " "))
+	}
+	if m.resource != "" {
+		params.Set("resource", m.resource)
+	}
+	for k, v := range m.authParams {
+		params[k] = v
+	}
+
+	sep := "?"
+	if strings.Contains(m.authURL, "?") {
+		sep = "&"
+	}
 
 	// Add # at the end to prevent trailing parameters from being added
-	return GoogleAuthURL + "?" + params.Encode() + "#", nil
+	return m.authURL + sep + params.Encode() + "#", nil
 }
 
 // GetState returns the current state parameter for validation
@@ -83,14 +144,14 @@ func (m *OAuthManager) GetState() string {
 func (m *OAuthManager) ExchangeCode(ctx context.Context, code string) (*OAuthToken, error) {
 	data := url.Values{
 		"client_id":     {m.clientID},
-		"client_secret": {m.clientSecret},
 		"code":          {code},
 		"code_verifier": {m.codeVerifier},
 		"grant_type":    {"authorization_code"},
 		"redirect_uri":  {m.redirectURI},
 	}
+	m.addClientParams(data)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", GoogleTokenURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", m.tokenURL, strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create token request: %w", err)
 	}
@@ -120,16 +181,18 @@ func (m *OAuthManager) ExchangeCode(ctx context.Context, code string) (*OAuthTok
 		AccessToken:  tokenResp.AccessToken,
 		RefreshToken: tokenResp.RefreshToken,
 		TokenType:    tokenResp.TokenType,
-		ExpiresAt:    time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+		ExpiresAt:    expiresAt(tokenResp.ExpiresIn),
 	}
 
 	// Fetch user email
-	email, err := m.fetchUserEmail(ctx, token.AccessToken)
-	if err != nil {
-		// Non-fatal - continue without email
-		email = ""
+	if m.userInfoURL != "" {
+		email, err := m.fetchUserEmail(ctx, token.AccessToken)
+		if err != nil {
+			// Non-fatal - continue without email
+			email = ""
+		}
+		token.Email = email
 	}
-	token.Email = email
 
 	return token, nil
 }
@@ -138,12 +201,12 @@ func (m *OAuthManager) ExchangeCode(ctx context.Context, code string) (*OAuthTok
 func (m *OAuthManager) RefreshToken(ctx context.Context, refreshToken string) (*OAuthToken, error) {
 	data := url.Values{
 		"client_id":     {m.clientID},
-		"client_secret": {m.clientSecret},
 		"refresh_token": {refreshToken},
 		"grant_type":    {"refresh_token"},
 	}
+	m.addClientParams(data)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", GoogleTokenURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", m.tokenURL, strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create refresh request: %w", err)
 	}
@@ -173,7 +236,7 @@ func (m *OAuthManager) RefreshToken(ctx context.Context, refreshToken string) (*
 		AccessToken:  tokenResp.AccessToken,
 		RefreshToken: refreshToken, // Refresh token doesn't change
 		TokenType:    tokenResp.TokenType,
-		ExpiresAt:    time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+		ExpiresAt:    expiresAt(tokenResp.ExpiresIn),
 	}
 
 	// If a new refresh token was provided, use it
@@ -184,9 +247,28 @@ func (m *OAuthManager) RefreshToken(ctx context.Context, refreshToken string) (*
 	return token, nil
 }
 
+// addClientParams adds the client secret and resource indicator to a token request.
+func (m *OAuthManager) addClientParams(data url.Values) {
+	if m.clientSecret != "" {
+		data.Set("client_secret", m.clientSecret)
+	}
+	if m.resource != "" {
+		data.Set("resource", m.resource)
+	}
+}
+
+// expiresAt converts expires_in to an absolute time; zero means the token
+// does not expire.
+func expiresAt(expiresIn int) time.Time {
+	if expiresIn <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(expiresIn) * time.Second)
+}
+
 // fetchUserEmail retrieves the user's email from Google userinfo endpoint
 func (m *OAuthManager) fetchUserEmail(ctx context.Context, accessToken string) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", GoogleUserInfo, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", m.userInfoURL, nil)
 	if err != nil {
 		return "", err
 	}
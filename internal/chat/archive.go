@@ -0,0 +1,763 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"gokin/internal/logging"
+	"gokin/internal/semantic"
+)
+
+// Archive limits.
+const (
+	maxArchivedSessions = 1000  // Pruned sessions beyond this are dropped, oldest first
+	maxDetachedTurns    = 500   // Turns kept per session after they left its file
+	maxTurnText         = 16000 // Indexed characters per turn
+	maxResultText       = 2000  // Indexed characters per tool result
+	maxTurnTools        = 20    // Tool calls listed per turn
+	maxEmbedPerSync     = 256   // Turns embedded per sync; the rest wait for the next one
+	embedBatchSize      = 32
+	archiveRRFK         = 60 // Reciprocal rank fusion constant
+	archiveVersion      = 1
+)
+
+// ArchivedTurn is a turn of a saved session: a user prompt and everything
+// the model did in response to it.
+type ArchivedTurn struct {
+	Turn      int       `json:"turn"` // 1-based turn in the session file; 0 once compaction or trimming dropped it
+	Prompt    string    `json:"prompt"`
+	Text      string    `json:"text"` // Prompt, replies, tool calls and tool results
+	Tools     []string  `json:"tools,omitempty"`
+	Files     []string  `json:"files,omitempty"`
+	Embedding []float32 `json:"embedding,omitempty"`
+}
+
+// ArchivedSession is the indexed content of a saved session.
+type ArchivedSession struct {
+	ID         string         `json:"id"`
+	WorkDir    string         `json:"work_dir,omitempty"`
+	Summary    string         `json:"summary,omitempty"`
+	StartTime  time.Time      `json:"start_time"`
+	LastActive time.Time      `json:"last_active"`
+	ModTime    time.Time      `json:"mod_time"`         // Of the session file when indexed
+	Pruned     bool           `json:"pruned,omitempty"` // The session file was deleted; searchable but not resumable
+	Turns      []ArchivedTurn `json:"turns"`
+}
+
+// archiveFile is the on-disk format of the archive.
+type archiveFile struct {
+	Version  int                `json:"version"`
+	Embedder string             `json:"embedder,omitempty"` // Namespace of the embeddings
+	Sessions []*ArchivedSession `json:"sessions"`
+}
+
+// ArchiveQuery is a search of the archive.
+type ArchiveQuery struct {
+	Text    string
+	Since   time.Time // Only sessions active since then; zero for all
+	WorkDir string    // Only sessions of this directory; empty for all
+	Limit   int       // Maximum hits (default: 10)
+}
+
+// ArchiveHit is a turn matching an archive search.
+type ArchiveHit struct {
+	SessionID  string
+	WorkDir    string
+	LastActive time.Time
+	Turn       int // 0 when the turn is no longer in the session file
+	Prompt     string
+	Snippet    string
+	Tools      []string
+	Files      []string
+	Pruned     bool
+	Score      float64
+}
+
+// Resumable reports whether /resume can jump to the hit's turn.
+func (h ArchiveHit) Resumable() bool {
+	return !h.Pruned && h.Turn > 0
+}
+
+// Archive is a persistent full-text index over the turns of every saved
+// session, kept in sync with the sessions directory when searched. Sessions
+// removed by cleanup and turns dropped by compaction stay searchable.
+// With an embedder, turns are also embedded and searches fuse both
+// rankings.
+type Archive struct {
+	sessionsDir string
+	path        string
+	embedder    semantic.Embedder
+
+	sessions map[string]*ArchivedSession
+	index    *semantic.BM25Index
+	loaded   bool
+	mu       sync.Mutex
+}
+
+// NewArchive creates the archive of the saved sessions.
+func NewArchive() (*Archive, error) {
+	sessionsDir, err := getSessionsDir()
+	if err != nil {
+		return nil, err
+	}
+	return &Archive{
+		sessionsDir: sessionsDir,
+		path:        filepath.Join(filepath.Dir(sessionsDir), "archive.json"),
+		sessions:    make(map[string]*ArchivedSession),
+		index:       semantic.NewBM25Index(),
+	}, nil
+}
+
+// SetEmbedder enables embedding search with the given embedder. It must
+// be called before the first sync.
+func (a *Archive) SetEmbedder(embedder semantic.Embedder) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.embedder = embedder
+}
+
+// Path returns the path of the archive file.
+func (a *Archive) Path() string {
+	return a.path
+}
+
+// Stats returns the number of archived sessions and turns.
+func (a *Archive) Stats(ctx context.Context) (sessions, turns int, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	err = a.syncLocked(ctx)
+	for _, s := range a.sessions {
+		turns += len(s.Turns)
+	}
+	return len(a.sessions), turns, err
+}
+
+// Sync indexes the sessions saved or changed since the last sync and marks
+// deleted ones as pruned.
+func (a *Archive) Sync(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.syncLocked(ctx)
+}
+
+// syncLocked implements Sync. Callers must hold a.mu.
+func (a *Archive) syncLocked(ctx context.Context) error {
+	changed := false
+	if !a.loaded {
+		if err := a.loadLocked(); err != nil {
+			logging.Warn("failed to read session archive, rebuilding it", "path", a.path, "error", err)
+			changed = true
+		}
+		a.loaded = true
+	}
+
+	entries, err := os.ReadDir(a.sessionsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	present := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		id := strings.TrimSuffix(entry.Name(), ".json")
+		present[id] = true
+
+		old := a.sessions[id]
+		if old != nil && !old.Pruned && old.ModTime.Equal(info.ModTime()) {
+			continue
+		}
+		state, err := readSessionState(filepath.Join(a.sessionsDir, entry.Name()))
+		if err != nil {
+			logging.Debug("skipping unreadable session", "session_id", id, "error", err)
+			continue
+		}
+
+		session := &ArchivedSession{
+			ID:         id,
+			WorkDir:    state.WorkDir,
+			Summary:    state.Summary,
+			StartTime:  state.StartTime,
+			LastActive: state.LastActive,
+			ModTime:    info.ModTime(),
+			Turns:      archiveTurns(state.History),
+		}
+		if old != nil {
+			mergeTurns(session, old)
+		}
+		a.sessions[id] = session
+		a.indexSessionLocked(session)
+		changed = true
+	}
+
+	for id, s := range a.sessions {
+		if !present[id] && !s.Pruned {
+			s.Pruned = true
+			changed = true
+		}
+	}
+
+	if a.trimLocked() {
+		changed = true
+	}
+	if a.embedder != nil && a.embedLocked(ctx) {
+		changed = true
+	}
+	if changed {
+		return a.saveLocked()
+	}
+	return nil
+}
+
+// readSessionState reads a saved session file.
+func readSessionState(path string) (*SessionState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var state SessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// mergeTurns keeps the embeddings of unchanged turns and the turns that
+// are no longer in the session file. A turn that grew, like the last turn
+// of a session in progress, is superseded rather than kept.
+func mergeTurns(session, old *ArchivedSession) {
+	byText := make(map[string]*ArchivedTurn, len(session.Turns))
+	for i := range session.Turns {
+		byText[session.Turns[i].Text] = &session.Turns[i]
+	}
+
+	var detached []ArchivedTurn
+	for _, turn := range old.Turns {
+		if current, ok := byText[turn.Text]; ok {
+			if current.Embedding == nil {
+				current.Embedding = turn.Embedding
+			}
+			continue
+		}
+		superseded := false
+		for _, current := range session.Turns {
+			if strings.HasPrefix(current.Text, turn.Text) {
+				superseded = true
+				break
+			}
+		}
+		if !superseded {
+			turn.Turn = 0
+			detached = append(detached, turn)
+		}
+	}
+	if len(detached) > maxDetachedTurns {
+		detached = detached[len(detached)-maxDetachedTurns:]
+	}
+	session.Turns = append(detached, session.Turns...)
+}
+
+// indexSessionLocked replaces the indexed turns of a session. Callers must
+// hold a.mu.
+func (a *Archive) indexSessionLocked(s *ArchivedSession) {
+	chunks := make([]semantic.ChunkInfo, len(s.Turns))
+	for i, turn := range s.Turns {
+		chunks[i] = semantic.ChunkInfo{FilePath: s.ID, LineStart: i, Content: turn.Text}
+	}
+	a.index.ReplaceFile(s.ID, chunks)
+}
+
+// trimLocked drops the oldest pruned sessions beyond maxArchivedSessions.
+// Callers must hold a.mu.
+func (a *Archive) trimLocked() bool {
+	excess := len(a.sessions) - maxArchivedSessions
+	if excess <= 0 {
+		return false
+	}
+	var pruned []*ArchivedSession
+	for _, s := range a.sessions {
+		if s.Pruned {
+			pruned = append(pruned, s)
+		}
+	}
+	sort.Slice(pruned, func(i, j int) bool {
+		return pruned[i].LastActive.Before(pruned[j].LastActive)
+	})
+	if excess > len(pruned) {
+		excess = len(pruned)
+	}
+	for _, s := range pruned[:excess] {
+		delete(a.sessions, s.ID)
+		a.index.RemoveFile(s.ID)
+	}
+	return excess > 0
+}
+
+// embedLocked embeds up to maxEmbedPerSync turns that have no embedding
+// yet. Callers must hold a.mu.
+func (a *Archive) embedLocked(ctx context.Context) bool {
+	var pending []*ArchivedTurn
+	for _, s := range a.sessions {
+		for i := range s.Turns {
+			if s.Turns[i].Embedding == nil && s.Turns[i].Text != "" {
+				pending = append(pending, &s.Turns[i])
+			}
+		}
+	}
+	if len(pending) > maxEmbedPerSync {
+		pending = pending[:maxEmbedPerSync]
+	}
+
+	embedded := false
+	for start := 0; start < len(pending); start += embedBatchSize {
+		batch := pending[start:min(start+embedBatchSize, len(pending))]
+		texts := make([]string, len(batch))
+		for i, turn := range batch {
+			texts[i] = truncateText(turn.Text, maxResultText)
+		}
+		vectors, err := a.embedder.EmbedBatch(ctx, texts)
+		if err != nil {
+			logging.Debug("failed to embed archived turns", "error", err)
+			break
+		}
+		for i, vector := range vectors {
+			if i < len(batch) {
+				batch[i].Embedding = vector
+			}
+		}
+		embedded = true
+	}
+	return embedded
+}
+
+// loadLocked reads the archive file. Embeddings of another embedder are
+// dropped. Callers must hold a.mu.
+func (a *Archive) loadLocked() error {
+	data, err := os.ReadFile(a.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var file archiveFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	if file.Version != archiveVersion {
+		return fmt.Errorf("unsupported archive version %d", file.Version)
+	}
+
+	namespace := ""
+	if a.embedder != nil {
+		namespace = semantic.EmbedderNamespace(a.embedder)
+	}
+	for _, s := range file.Sessions {
+		if file.Embedder != namespace {
+			for i := range s.Turns {
+				s.Turns[i].Embedding = nil
+			}
+		}
+		a.sessions[s.ID] = s
+		a.indexSessionLocked(s)
+	}
+	return nil
+}
+
+// saveLocked writes the archive file atomically. Callers must hold a.mu.
+func (a *Archive) saveLocked() error {
+	file := archiveFile{Version: archiveVersion, Sessions: make([]*ArchivedSession, 0, len(a.sessions))}
+	if a.embedder != nil {
+		file.Embedder = semantic.EmbedderNamespace(a.embedder)
+	}
+	for _, s := range a.sessions {
+		file.Sessions = append(file.Sessions, s)
+	}
+	sort.Slice(file.Sessions, func(i, j int) bool {
+		return file.Sessions[i].ID < file.Sessions[j].ID
+	})
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	// 0700/0600: only the owner can read session content
+	if err := os.MkdirAll(filepath.Dir(a.path), 0700); err != nil {
+		return err
+	}
+	tmp := a.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, a.path)
+}
+
+// archiveKey identifies a turn in the index.
+type archiveKey struct {
+	session string
+	index   int
+}
+
+// Search syncs the archive and returns the turns best matching the query.
+func (a *Archive) Search(ctx context.Context, q ArchiveQuery) ([]ArchiveHit, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.syncLocked(ctx); err != nil {
+		logging.Warn("session archive sync failed", "error", err)
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	candidates := max(limit*3, 50)
+	keep := func(sessionID, _ string) bool {
+		s := a.sessions[sessionID]
+		if s == nil {
+			return false
+		}
+		if !q.Since.IsZero() && s.LastActive.Before(q.Since) {
+			return false
+		}
+		return q.WorkDir == "" || s.WorkDir == q.WorkDir
+	}
+
+	scores := make(map[archiveKey]float64)
+	terms := make(map[archiveKey][]string)
+	var order []archiveKey
+	add := func(key archiveKey, score float64) {
+		if _, ok := scores[key]; !ok {
+			order = append(order, key)
+		}
+		scores[key] += score
+	}
+
+	lexical := a.index.Search(q.Text, candidates, keep)
+	vector := a.vectorRanking(ctx, q.Text, candidates, keep)
+	for rank, m := range lexical {
+		key := archiveKey{m.FilePath, m.LineStart}
+		terms[key] = m.Terms
+		if len(vector) == 0 {
+			add(key, m.Score)
+		} else {
+			add(key, 1/float64(archiveRRFK+rank+1))
+		}
+	}
+	for rank, key := range vector {
+		add(key, 1/float64(archiveRRFK+rank+1))
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+	if len(order) > limit {
+		order = order[:limit]
+	}
+
+	hits := make([]ArchiveHit, 0, len(order))
+	for _, key := range order {
+		s := a.sessions[key.session]
+		turn := s.Turns[key.index]
+		hits = append(hits, ArchiveHit{
+			SessionID:  s.ID,
+			WorkDir:    s.WorkDir,
+			LastActive: s.LastActive,
+			Turn:       turn.Turn,
+			Prompt:     turn.Prompt,
+			Snippet:    snippet(turn.Text, terms[key]),
+			Tools:      turn.Tools,
+			Files:      turn.Files,
+			Pruned:     s.Pruned,
+			Score:      scores[key],
+		})
+	}
+	return hits, nil
+}
+
+// vectorRanking ranks the embedded turns by similarity to the query, or
+// returns nil without an embedder. Callers must hold a.mu.
+func (a *Archive) vectorRanking(ctx context.Context, query string, n int, keep func(sessionID, kind string) bool) []archiveKey {
+	if a.embedder == nil {
+		return nil
+	}
+	queryVec, err := a.embedder.Embed(ctx, query)
+	if err != nil {
+		logging.Debug("failed to embed archive query", "error", err)
+		return nil
+	}
+
+	type scored struct {
+		key   archiveKey
+		score float32
+	}
+	var ranked []scored
+	for id, s := range a.sessions {
+		if !keep(id, "") {
+			continue
+		}
+		for i, turn := range s.Turns {
+			if len(turn.Embedding) == len(queryVec) {
+				ranked = append(ranked, scored{archiveKey{id, i}, semantic.CosineSimilarity(queryVec, turn.Embedding)})
+			}
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	keys := make([]archiveKey, len(ranked))
+	for i, r := range ranked {
+		keys[i] = r.key
+	}
+	return keys
+}
+
+// TurnStarts returns the index of the first message of each turn. A turn
+// starts with a user message carrying text, as opposed to the function
+// responses also sent with the user role; messages before the first one
+// belong to the first turn.
+func TurnStarts(history []SerializedContent) []int {
+	var starts []int
+	for i, content := range history {
+		if i == 0 {
+			starts = append(starts, 0)
+			continue
+		}
+		if content.Role != "user" {
+			continue
+		}
+		for _, part := range content.Parts {
+			if part.Text != "" && !part.Thought {
+				starts = append(starts, i)
+				break
+			}
+		}
+	}
+	return starts
+}
+
+// TruncateToTurn drops the messages after a 1-based turn, so that the
+// restored session continues from it.
+func (s *SessionState) TruncateToTurn(turn int) error {
+	starts := TurnStarts(s.History)
+	if turn < 1 || turn > len(starts) {
+		return fmt.Errorf("turn %d out of range: the session has %d turns", turn, len(starts))
+	}
+	if turn == len(starts) {
+		return nil
+	}
+
+	end := starts[turn]
+	s.History = s.History[:end]
+	if len(s.TokenCounts) > end {
+		s.TokenCounts = s.TokenCounts[:end]
+		s.TotalTokens = 0
+		for _, n := range s.TokenCounts {
+			s.TotalTokens += n
+		}
+	}
+	s.Summary = s.GenerateSummary()
+	return nil
+}
+
+// archiveTurns extracts the turns of a session history.
+func archiveTurns(history []SerializedContent) []ArchivedTurn {
+	starts := TurnStarts(history)
+	turns := make([]ArchivedTurn, 0, len(starts))
+	for n, start := range starts {
+		end := len(history)
+		if n+1 < len(starts) {
+			end = starts[n+1]
+		}
+
+		turn := ArchivedTurn{Turn: n + 1}
+		var text strings.Builder
+		files := make(map[string]bool)
+		for _, content := range history[start:end] {
+			for _, part := range content.Parts {
+				switch {
+				case part.Thought:
+				case part.FunctionCall != nil:
+					call := describeCall(part.FunctionCall)
+					if len(turn.Tools) < maxTurnTools {
+						turn.Tools = append(turn.Tools, call)
+					}
+					text.WriteString(call + "\n")
+					for _, key := range []string{"file_path", "path", "source", "destination"} {
+						if p, ok := part.FunctionCall.Args[key].(string); ok && p != "" {
+							files[p] = true
+						}
+					}
+				case part.FunctionResp != nil:
+					text.WriteString(truncateText(resultText(part.FunctionResp.Response), maxResultText) + "\n")
+				case part.Text != "":
+					if content.Role == "user" && turn.Prompt == "" {
+						turn.Prompt = truncateText(strings.Join(strings.Fields(part.Text), " "), 200)
+					}
+					text.WriteString(part.Text + "\n")
+				}
+			}
+		}
+
+		turn.Text = truncateText(text.String(), maxTurnText)
+		for f := range files {
+			turn.Files = append(turn.Files, f)
+		}
+		sort.Strings(turn.Files)
+		turns = append(turns, turn)
+	}
+	return turns
+}
+
+// describeCall returns a tool call with its main argument, e.g.
+// "bash: go test ./...".
+func describeCall(call *SerializedFunc) string {
+	for _, key := range []string{"command", "file_path", "path", "pattern", "query", "url"} {
+		if v, ok := call.Args[key].(string); ok && v != "" {
+			return truncateText(call.Name+": "+strings.Join(strings.Fields(v), " "), 200)
+		}
+	}
+	return call.Name
+}
+
+// resultText returns the text of a tool result.
+func resultText(response map[string]any) string {
+	var parts []string
+	for _, key := range []string{"error", "content", "output", "result"} {
+		if v, ok := response[key].(string); ok && v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// truncateText shortens text to at most n bytes on a rune boundary.
+func truncateText(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n]
+}
+
+// snippet returns the part of a turn's text around the first occurrence of
+// the longest matched term, on one line.
+func snippet(text string, terms []string) string {
+	const before, after = 80, 160
+
+	sorted := append([]string(nil), terms...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	// Offsets in the lowered text only hold when lowering kept its length
+	lower := strings.ToLower(text)
+	pos := -1
+	for _, term := range sorted {
+		if i := strings.Index(lower, term); i >= 0 && len(lower) == len(text) {
+			pos = i
+			break
+		}
+	}
+
+	start, end := 0, len(text)
+	if pos > before {
+		start = pos - before
+	}
+	if start+before+after < end {
+		end = start + before + after
+	}
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+
+	out := strings.Join(strings.Fields(text[start:end]), " ")
+	if start > 0 {
+		out = "..." + out
+	}
+	if end < len(text) {
+		out += "..."
+	}
+	return out
+}
+
+// FormatArchiveHits renders search hits with the command resuming each.
+func FormatArchiveHits(hits []ArchiveHit) string {
+	var sb strings.Builder
+	for i, hit := range hits {
+		location := fmt.Sprintf("session %s", hit.SessionID)
+		if hit.Turn > 0 {
+			location += fmt.Sprintf(", turn %d", hit.Turn)
+		}
+		location += ", " + hit.LastActive.Format("2006-01-02 15:04")
+		if hit.WorkDir != "" {
+			location += ", " + hit.WorkDir
+		}
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, location)
+		if hit.Prompt != "" {
+			fmt.Fprintf(&sb, "   Prompt: %s\n", hit.Prompt)
+		}
+		if hit.Snippet != "" {
+			fmt.Fprintf(&sb, "   Match: %s\n", hit.Snippet)
+		}
+		if len(hit.Files) > 0 {
+			files := hit.Files
+			if len(files) > 5 {
+				files = append(files[:5:5], fmt.Sprintf("(+%d more)", len(hit.Files)-5))
+			}
+			fmt.Fprintf(&sb, "   Files: %s\n", strings.Join(files, ", "))
+		}
+		switch {
+		case hit.Resumable():
+			fmt.Fprintf(&sb, "   Resume: /resume %s %d\n", hit.SessionID, hit.Turn)
+		case hit.Pruned:
+			sb.WriteString("   Resume: unavailable, the session was cleaned up\n")
+		default:
+			fmt.Fprintf(&sb, "   Resume: /resume %s (this turn was compacted away)\n", hit.SessionID)
+		}
+	}
+	return sb.String()
+}
+
+// ParseSince parses a relative age like "7d", "2w" or "24h", or a date
+// like "2025-01-31", into the time it refers to.
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, nil
+	}
+	if n := len(s); n > 1 && (s[n-1] == 'd' || s[n-1] == 'w') {
+		count, err := strconv.Atoi(s[:n-1])
+		if err == nil && count >= 0 {
+			days := count
+			if s[n-1] == 'w' {
+				days *= 7
+			}
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use 7d, 2w, 24h or YYYY-MM-DD", s)
+}
@@ -0,0 +1,311 @@
+// Package checkpoint snapshots the working tree into a shadow git
+// repository so files changed by any means, including shell commands, can
+// be restored to the state they had before a turn.
+package checkpoint
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// refPrefix namespaces checkpoint refs in the shadow repository.
+	refPrefix = "refs/checkpoints/"
+
+	// DefaultMaxCheckpoints is the number of checkpoints kept per project
+	// when no limit is configured.
+	DefaultMaxCheckpoints = 50
+)
+
+// Checkpoint is a snapshot of the working tree taken before a turn.
+type Checkpoint struct {
+	ID     string // Commit hash in the shadow repository
+	Prompt string // The prompt of the turn that followed
+	Time   time.Time
+}
+
+// ShortID returns an abbreviated checkpoint ID.
+func (c Checkpoint) ShortID() string {
+	if len(c.ID) > 8 {
+		return c.ID[:8]
+	}
+	return c.ID
+}
+
+// RestoreResult lists the files a restore changed, relative to the work
+// dir and slash separated.
+type RestoreResult struct {
+	Restored []string // Files written back with their checkpoint content
+	Removed  []string // Files deleted because they did not exist then
+}
+
+// Store keeps checkpoints of one working tree in a shadow git repository
+// outside of it. The shadow repository has its own index, so the project's
+// repository and index are never touched; .gitignore files in the tree are
+// honored, so ignored files are neither saved nor restored.
+type Store struct {
+	gitDir  string
+	workDir string
+	max     int
+
+	mu sync.Mutex
+}
+
+// NewStore opens or creates the shadow repository for workDir under
+// configDir. Only the maxCheckpoints most recent checkpoints are kept.
+func NewStore(configDir, workDir string, maxCheckpoints int) (*Store, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git is required for checkpoints: %w", err)
+	}
+	workDir, err := filepath.Abs(workDir)
+	if err != nil {
+		return nil, err
+	}
+	if maxCheckpoints <= 0 {
+		maxCheckpoints = DefaultMaxCheckpoints
+	}
+
+	s := &Store{
+		gitDir:  filepath.Join(configDir, "checkpoints", projectID(workDir)),
+		workDir: workDir,
+		max:     maxCheckpoints,
+	}
+	if _, err := os.Stat(filepath.Join(s.gitDir, "HEAD")); os.IsNotExist(err) {
+		if err := os.MkdirAll(s.gitDir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create checkpoint repository: %w", err)
+		}
+		cmd := exec.Command("git", "init", "--bare", "--quiet", s.gitDir)
+		cmd.Env = gitEnv()
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to create checkpoint repository: %w: %s", err, strings.TrimSpace(string(out)))
+		}
+		// Snapshots must match the files byte for byte
+		_, _ = s.git(context.Background(), nil, "config", "core.autocrlf", "false")
+		_, _ = s.git(context.Background(), nil, "config", "gc.pruneExpire", "1.hour.ago")
+	}
+	return s, nil
+}
+
+// projectID derives the shadow repository name from the work dir.
+func projectID(workDir string) string {
+	hash := sha256.Sum256([]byte(filepath.Clean(workDir)))
+	return hex.EncodeToString(hash[:8])
+}
+
+// Create snapshots the working tree as a checkpoint for prompt and drops
+// the oldest checkpoints beyond the limit.
+func (s *Store) Create(ctx context.Context, prompt string) (*Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cp, err := s.create(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.prune(ctx); err != nil {
+		return cp, fmt.Errorf("failed to prune checkpoints: %w", err)
+	}
+	return cp, nil
+}
+
+func (s *Store) create(ctx context.Context, prompt string) (*Checkpoint, error) {
+	tree, err := s.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	message := strings.TrimSpace(prompt)
+	if message == "" {
+		message = "(empty prompt)"
+	}
+	id, err := s.git(ctx, strings.NewReader(message), "commit-tree", tree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint: %w", err)
+	}
+	// Nanosecond ref names sort in creation order
+	ref := fmt.Sprintf("%s%019d", refPrefix, now.UnixNano())
+	if _, err := s.git(ctx, nil, "update-ref", ref, id); err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint: %w", err)
+	}
+	return &Checkpoint{ID: id, Prompt: message, Time: now}, nil
+}
+
+// snapshot stages the whole working tree in the shadow index and returns
+// its tree hash.
+func (s *Store) snapshot(ctx context.Context) (string, error) {
+	if _, err := s.git(ctx, nil, "add", "--all", "--ignore-errors", "."); err != nil {
+		return "", fmt.Errorf("failed to snapshot the working tree: %w", err)
+	}
+	tree, err := s.git(ctx, nil, "write-tree")
+	if err != nil {
+		return "", fmt.Errorf("failed to snapshot the working tree: %w", err)
+	}
+	return tree, nil
+}
+
+// prune deletes the refs of checkpoints beyond the limit and lets git
+// collect their objects.
+func (s *Store) prune(ctx context.Context) error {
+	refs, err := s.refs(ctx)
+	if err != nil || len(refs) <= s.max {
+		return err
+	}
+	for _, ref := range refs[s.max:] {
+		if _, err := s.git(ctx, nil, "update-ref", "-d", ref); err != nil {
+			return err
+		}
+	}
+	_, _ = s.git(ctx, nil, "gc", "--auto", "--quiet")
+	return nil
+}
+
+// refs returns the checkpoint refs, newest first.
+func (s *Store) refs(ctx context.Context) ([]string, error) {
+	out, err := s.git(ctx, nil, "for-each-ref", "--sort=-refname", "--format=%(refname)", refPrefix)
+	if err != nil || out == "" {
+		return nil, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// List returns the checkpoints, newest first.
+func (s *Store) List(ctx context.Context) ([]Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list(ctx)
+}
+
+func (s *Store) list(ctx context.Context) ([]Checkpoint, error) {
+	out, err := s.git(ctx, nil, "for-each-ref", "--sort=-refname",
+		"--format=%(objectname)%00%(committerdate:unix)%00%(contents)%01", refPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var checkpoints []Checkpoint
+	for _, record := range strings.Split(out, "\x01") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		unix, _ := strconv.ParseInt(fields[1], 10, 64)
+		checkpoints = append(checkpoints, Checkpoint{
+			ID:     fields[0],
+			Prompt: strings.TrimSpace(fields[2]),
+			Time:   time.Unix(unix, 0),
+		})
+	}
+	return checkpoints, nil
+}
+
+// Restore returns the working tree to checkpoint id: files changed or
+// deleted since are written back and files created since are removed.
+// The current state is saved as a new checkpoint first, so a restore can
+// itself be rewound.
+func (s *Store) Restore(ctx context.Context, id string) (*RestoreResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Snapshots the tree into the shadow index as well
+	if _, err := s.create(ctx, fmt.Sprintf("Before rewinding to checkpoint %.8s", id)); err != nil {
+		return nil, err
+	}
+
+	out, err := s.git(ctx, nil, "diff-index", "--cached", "--name-status", "-z", "--no-renames", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare with checkpoint: %w", err)
+	}
+
+	result := &RestoreResult{}
+	fields := strings.Split(strings.TrimRight(out, "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i] == "A" {
+			result.Removed = append(result.Removed, fields[i+1])
+		} else {
+			result.Restored = append(result.Restored, fields[i+1])
+		}
+	}
+
+	if len(result.Restored) > 0 {
+		paths := strings.Join(result.Restored, "\x00")
+		if _, err := s.git(ctx, strings.NewReader(paths),
+			"checkout", id, "--pathspec-from-file=-", "--pathspec-file-nul"); err != nil {
+			return nil, fmt.Errorf("failed to restore files: %w", err)
+		}
+	}
+	for _, rel := range result.Removed {
+		path := filepath.Join(s.workDir, filepath.FromSlash(rel))
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove %s: %w", rel, err)
+		}
+		s.removeEmptyDirs(filepath.Dir(path))
+	}
+
+	if err := s.prune(ctx); err != nil {
+		return result, fmt.Errorf("failed to prune checkpoints: %w", err)
+	}
+	return result, nil
+}
+
+// removeEmptyDirs removes dir and its parents up to the work dir while
+// they are empty.
+func (s *Store) removeEmptyDirs(dir string) {
+	for dir != s.workDir && strings.HasPrefix(dir, s.workDir+string(filepath.Separator)) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// git runs git against the shadow repository and the work dir and returns
+// its trimmed output. Errors include git's stderr.
+func (s *Store) git(ctx context.Context, stdin *strings.Reader, args ...string) (string, error) {
+	args = append([]string{
+		"--git-dir=" + s.gitDir,
+		"--work-tree=" + s.workDir,
+		"--literal-pathspecs",
+		"-c", "core.quotepath=false",
+		"-c", "user.name=gokin",
+		"-c", "user.email=gokin@localhost",
+	}, args...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = s.workDir
+	cmd.Env = gitEnv()
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// gitEnv returns the environment without git variables that would point
+// commands at the project's repository or index.
+func gitEnv() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "GIT_") {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
@@ -55,6 +55,8 @@ type AnthropicConfig struct {
 	// Extended Thinking
 	EnableThinking bool  // Enable extended thinking mode
 	ThinkingBudget int32 // Max tokens for thinking (0 = disabled)
+	// Prompt caching
+	PromptCaching bool // Add cache_control breakpoints to system, tools and history
 }
 
 // AnthropicClient implements Client interface for Anthropic-compatible APIs (including GLM-4.7).
@@ -195,6 +197,8 @@ func (c *AnthropicClient) SendMessageWithHistory(ctx context.Context, history []
 		}
 	}
 
+	c.applyPromptCaching(requestBody)
+
 	return c.streamRequest(ctx, requestBody)
 }
 
@@ -243,9 +247,61 @@ func (c *AnthropicClient) SendFunctionResponse(ctx context.Context, history []*g
 		requestBody["tools"] = c.convertToolsToAnthropic()
 	}
 
+	c.applyPromptCaching(requestBody)
+
 	return c.streamRequest(ctx, requestBody)
 }
 
+// applyPromptCaching adds cache_control breakpoints to the request. The API
+// caches the prompt prefix in the order tools, system, messages and allows four
+// breakpoints: the tool list, the system prompt, the previous user turn (read
+// back from the last request) and the last message (written for the next one).
+func (c *AnthropicClient) applyPromptCaching(requestBody map[string]interface{}) {
+	c.mu.RLock()
+	enabled := c.config.PromptCaching
+	c.mu.RUnlock()
+	if !enabled {
+		return
+	}
+
+	if tools, ok := requestBody["tools"].([]map[string]interface{}); ok && len(tools) > 0 {
+		tools[len(tools)-1]["cache_control"] = ephemeralCacheControl()
+	}
+
+	if system, ok := requestBody["system"].(string); ok && system != "" {
+		requestBody["system"] = []map[string]interface{}{
+			{"type": "text", "text": system, "cache_control": ephemeralCacheControl()},
+		}
+	}
+
+	messages, ok := requestBody["messages"].([]map[string]interface{})
+	if !ok || len(messages) == 0 {
+		return
+	}
+	last := len(messages) - 1
+	markLastBlock(messages[last])
+	for i := last - 1; i >= 0; i-- {
+		if role, _ := messages[i]["role"].(string); role == "user" {
+			markLastBlock(messages[i])
+			break
+		}
+	}
+}
+
+// ephemeralCacheControl returns the cache_control value for a breakpoint.
+func ephemeralCacheControl() map[string]interface{} {
+	return map[string]interface{}{"type": "ephemeral"}
+}
+
+// markLastBlock sets a cache breakpoint on the last content block of a message.
+func markLastBlock(message map[string]interface{}) {
+	blocks, ok := message["content"].([]map[string]interface{})
+	if !ok || len(blocks) == 0 {
+		return
+	}
+	blocks[len(blocks)-1]["cache_control"] = ephemeralCacheControl()
+}
+
 // SetSystemInstruction sets the system-level instruction for the model.
 func (c *AnthropicClient) SetSystemInstruction(instruction string) {
 	c.mu.Lock()
@@ -376,6 +432,35 @@ type toolCallAccumulator struct {
 	// Thinking block tracking
 	currentBlockType string          // "thinking", "text", or "tool_use"
 	thinkingBuilder  strings.Builder // Accumulates thinking content
+	// Usage from message_start and message_delta events
+	inputTokens         int
+	outputTokens        int
+	cacheCreationTokens int
+	cacheReadTokens     int
+}
+
+// recordUsage stores the counts present in a usage object. message_start
+// carries the input counts, message_delta the final output count.
+func (acc *toolCallAccumulator) recordUsage(usage map[string]interface{}) {
+	count := func(key string, dst *int) {
+		if v, ok := usage[key].(float64); ok && v > 0 {
+			*dst = int(v)
+		}
+	}
+	count("input_tokens", &acc.inputTokens)
+	count("output_tokens", &acc.outputTokens)
+	count("cache_creation_input_tokens", &acc.cacheCreationTokens)
+	count("cache_read_input_tokens", &acc.cacheReadTokens)
+}
+
+// applyUsage adds the recorded usage to the final chunk. The API reports
+// input_tokens without the cached parts, so they are added back to get the
+// full prompt size.
+func (acc *toolCallAccumulator) applyUsage(chunk *ResponseChunk) {
+	chunk.InputTokens = acc.inputTokens + acc.cacheCreationTokens + acc.cacheReadTokens
+	chunk.OutputTokens = acc.outputTokens
+	chunk.CacheCreationInputTokens = acc.cacheCreationTokens
+	chunk.CacheReadInputTokens = acc.cacheReadTokens
 }
 
 // isRetryableError returns true if the error should trigger a retry.
@@ -714,17 +799,14 @@ func (c *AnthropicClient) doStreamRequest(ctx context.Context, requestBody map[s
 					// Skip "[DONE]" marker
 					if data == "[DONE]" {
 						// Send any accumulated tool calls before marking done
+						final := ResponseChunk{Done: true}
 						if len(accumulator.completedCalls) > 0 {
-							chunks <- ResponseChunk{
-								FunctionCalls: accumulator.completedCalls,
-								Done:          true,
-							}
-						} else {
-							select {
-							case chunks <- ResponseChunk{Done: true}:
-							case <-ctx.Done():
-								return
-							}
+							final.FunctionCalls = accumulator.completedCalls
+						}
+						accumulator.applyUsage(&final)
+						select {
+						case chunks <- final:
+						case <-ctx.Done():
 						}
 						return
 					}
@@ -908,10 +990,18 @@ func (c *AnthropicClient) processStreamEvent(event map[string]interface{}, acc *
 		acc.currentBlockType = ""
 
 	case "message_start":
-		// Message starting - no action needed
+		// Input usage, including prompt cache reads and writes
+		if message, ok := event["message"].(map[string]interface{}); ok {
+			if usage, ok := message["usage"].(map[string]interface{}); ok {
+				acc.recordUsage(usage)
+			}
+		}
 
 	case "message_delta":
 		// Message metadata (usage, stop_reason, etc.)
+		if usage, ok := event["usage"].(map[string]interface{}); ok {
+			acc.recordUsage(usage)
+		}
 		if delta, ok := event["delta"].(map[string]interface{}); ok {
 			if stopReason, ok := delta["stop_reason"].(string); ok {
 				chunk.Done = true
@@ -938,6 +1028,10 @@ func (c *AnthropicClient) processStreamEvent(event map[string]interface{}, acc *
 		}
 	}
 
+	if chunk.Done && chunk.Error == nil {
+		acc.applyUsage(&chunk)
+	}
+
 	return chunk
 }
 
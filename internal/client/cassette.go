@@ -0,0 +1,274 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/genai"
+	"gopkg.in/yaml.v3"
+)
+
+// cassetteVersion is the current cassette file format version.
+const cassetteVersion = 1
+
+// Request kinds recorded in a cassette, one per Client send method.
+const (
+	RequestMessage          = "message"
+	RequestHistory          = "history"
+	RequestFunctionResponse = "function_response"
+)
+
+// Cassette is a recorded sequence of model requests and their response streams.
+// It is written by RecordingClient and played back by ReplayClient.
+type Cassette struct {
+	Version      int           `yaml:"version"`
+	Model        string        `yaml:"model,omitempty"`
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+// Interaction is one request and the chunks the model streamed back.
+type Interaction struct {
+	Request CassetteRequest `yaml:"request"`
+	// Error is set when the send call itself failed before streaming.
+	Error  string          `yaml:"error,omitempty"`
+	Chunks []CassetteChunk `yaml:"chunks,omitempty"`
+}
+
+// CassetteRequest identifies a request. Replay matches on Kind, Message and
+// FunctionResponses; the other fields are informational.
+type CassetteRequest struct {
+	Kind              string   `yaml:"kind"`
+	Model             string   `yaml:"model,omitempty"`
+	Message           string   `yaml:"message,omitempty"`
+	FunctionResponses []string `yaml:"function_responses,omitempty"`
+	HistoryLength     int      `yaml:"history_length,omitempty"`
+}
+
+// CassetteChunk is the serialized form of a ResponseChunk.
+type CassetteChunk struct {
+	Text                     string                 `yaml:"text,omitempty"`
+	Thinking                 string                 `yaml:"thinking,omitempty"`
+	FunctionCalls            []CassetteFunctionCall `yaml:"function_calls,omitempty"`
+	Parts                    []CassettePart         `yaml:"parts,omitempty"`
+	Error                    string                 `yaml:"error,omitempty"`
+	Done                     bool                   `yaml:"done,omitempty"`
+	FinishReason             string                 `yaml:"finish_reason,omitempty"`
+	InputTokens              int                    `yaml:"input_tokens,omitempty"`
+	OutputTokens             int                    `yaml:"output_tokens,omitempty"`
+	CacheCreationInputTokens int                    `yaml:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int                    `yaml:"cache_read_input_tokens,omitempty"`
+}
+
+// CassetteFunctionCall is the serialized form of a genai.FunctionCall.
+type CassetteFunctionCall struct {
+	ID   string         `yaml:"id,omitempty"`
+	Name string         `yaml:"name"`
+	Args map[string]any `yaml:"args,omitempty"`
+}
+
+// CassettePart keeps the fields of a genai.Part that providers round-trip
+// through history (Gemini thought signatures in particular).
+type CassettePart struct {
+	Text             string                `yaml:"text,omitempty"`
+	Thought          bool                  `yaml:"thought,omitempty"`
+	ThoughtSignature []byte                `yaml:"thought_signature,omitempty"`
+	FunctionCall     *CassetteFunctionCall `yaml:"function_call,omitempty"`
+}
+
+// LoadCassette reads a cassette file.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var c Cassette
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	if c.Version > cassetteVersion {
+		return nil, fmt.Errorf("cassette %s has unsupported version %d", path, c.Version)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette atomically.
+func (c *Cassette) Save(path string) error {
+	if c.Version == 0 {
+		c.Version = cassetteVersion
+	}
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create cassette directory: %w", err)
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// newCassetteRequest describes a send call for recording and matching.
+func newCassetteRequest(kind, model string, history []*genai.Content, message string, results []*genai.FunctionResponse) CassetteRequest {
+	req := CassetteRequest{
+		Kind:          kind,
+		Model:         model,
+		Message:       message,
+		HistoryLength: len(history),
+	}
+	for _, r := range results {
+		req.FunctionResponses = append(req.FunctionResponses, r.Name)
+	}
+	return req
+}
+
+// matches reports whether a recorded request answers the given one.
+func (r CassetteRequest) matches(other CassetteRequest) bool {
+	if r.Kind != other.Kind || r.Message != other.Message || len(r.FunctionResponses) != len(other.FunctionResponses) {
+		return false
+	}
+	for i := range r.FunctionResponses {
+		if r.FunctionResponses[i] != other.FunctionResponses[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// String describes the request for mismatch errors.
+func (r CassetteRequest) String() string {
+	switch r.Kind {
+	case RequestFunctionResponse:
+		return fmt.Sprintf("%s %v", r.Kind, r.FunctionResponses)
+	default:
+		return fmt.Sprintf("%s %q", r.Kind, truncateString(r.Message, 60))
+	}
+}
+
+// newCassetteChunk converts a streamed chunk for recording.
+func newCassetteChunk(chunk ResponseChunk) CassetteChunk {
+	cc := CassetteChunk{
+		Text:                     chunk.Text,
+		Thinking:                 chunk.Thinking,
+		Done:                     chunk.Done,
+		FinishReason:             string(chunk.FinishReason),
+		InputTokens:              chunk.InputTokens,
+		OutputTokens:             chunk.OutputTokens,
+		CacheCreationInputTokens: chunk.CacheCreationInputTokens,
+		CacheReadInputTokens:     chunk.CacheReadInputTokens,
+	}
+	if chunk.Error != nil {
+		cc.Error = chunk.Error.Error()
+	}
+	for _, fc := range chunk.FunctionCalls {
+		cc.FunctionCalls = append(cc.FunctionCalls, newCassetteFunctionCall(fc))
+	}
+	for _, p := range chunk.Parts {
+		if p == nil {
+			continue
+		}
+		cp := CassettePart{Text: p.Text, Thought: p.Thought, ThoughtSignature: p.ThoughtSignature}
+		if p.FunctionCall != nil {
+			fc := newCassetteFunctionCall(p.FunctionCall)
+			cp.FunctionCall = &fc
+		}
+		cc.Parts = append(cc.Parts, cp)
+	}
+	return cc
+}
+
+// toChunk converts a recorded chunk back into a ResponseChunk. Errors are
+// replayed as plain errors; their original types are not preserved.
+func (cc CassetteChunk) toChunk() ResponseChunk {
+	chunk := ResponseChunk{
+		Text:                     cc.Text,
+		Thinking:                 cc.Thinking,
+		Done:                     cc.Done,
+		FinishReason:             genai.FinishReason(cc.FinishReason),
+		InputTokens:              cc.InputTokens,
+		OutputTokens:             cc.OutputTokens,
+		CacheCreationInputTokens: cc.CacheCreationInputTokens,
+		CacheReadInputTokens:     cc.CacheReadInputTokens,
+	}
+	if cc.Error != "" {
+		chunk.Error = errors.New(cc.Error)
+	}
+	for _, fc := range cc.FunctionCalls {
+		chunk.FunctionCalls = append(chunk.FunctionCalls, fc.toFunctionCall())
+	}
+	for _, cp := range cc.Parts {
+		p := &genai.Part{Text: cp.Text, Thought: cp.Thought, ThoughtSignature: cp.ThoughtSignature}
+		if cp.FunctionCall != nil {
+			p.FunctionCall = cp.FunctionCall.toFunctionCall()
+		}
+		chunk.Parts = append(chunk.Parts, p)
+	}
+	return chunk
+}
+
+func newCassetteFunctionCall(fc *genai.FunctionCall) CassetteFunctionCall {
+	return CassetteFunctionCall{ID: fc.ID, Name: fc.Name, Args: fc.Args}
+}
+
+// toFunctionCall rebuilds a function call. Arguments go through a JSON round
+// trip so numbers decode as float64, exactly as they do from a live API.
+func (c CassetteFunctionCall) toFunctionCall() *genai.FunctionCall {
+	fc := &genai.FunctionCall{ID: c.ID, Name: c.Name, Args: map[string]any{}}
+	if len(c.Args) == 0 {
+		return fc
+	}
+	data, err := json.Marshal(c.Args)
+	if err != nil {
+		fc.Args = c.Args
+		return fc
+	}
+	if err := json.Unmarshal(data, &fc.Args); err != nil {
+		fc.Args = c.Args
+	}
+	return fc
+}
+
+// streamChunks plays chunks on a new StreamingResponse, stopping early if ctx
+// is cancelled. A final Done chunk is added when the chunks lack one.
+func streamChunks(ctx context.Context, chunks []ResponseChunk) *StreamingResponse {
+	out := make(chan ResponseChunk, len(chunks)+1)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		defer close(out)
+
+		finished := false
+		for _, chunk := range chunks {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				out <- ResponseChunk{Error: ctx.Err(), Done: true}
+				return
+			}
+			if chunk.Done || chunk.Error != nil {
+				finished = true
+				break
+			}
+		}
+		if !finished {
+			out <- ResponseChunk{Done: true, FinishReason: genai.FinishReasonStop}
+		}
+	}()
+
+	return &StreamingResponse{Chunks: out, Done: done}
+}
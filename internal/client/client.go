@@ -194,6 +194,12 @@ type ResponseChunk struct {
 
 	// OutputTokens from API usage metadata (if available).
 	OutputTokens int
+
+	// CacheCreationInputTokens is the part of InputTokens written to the prompt cache.
+	CacheCreationInputTokens int
+
+	// CacheReadInputTokens is the part of InputTokens served from the prompt cache.
+	CacheReadInputTokens int
 }
 
 // Response represents a complete response from the model.
@@ -218,6 +224,12 @@ type Response struct {
 
 	// OutputTokens from API usage metadata (completion tokens, if available).
 	OutputTokens int
+
+	// CacheCreationInputTokens is the part of InputTokens written to the prompt cache.
+	CacheCreationInputTokens int
+
+	// CacheReadInputTokens is the part of InputTokens served from the prompt cache.
+	CacheReadInputTokens int
 }
 
 // Collect collects all chunks from a streaming response into a single Response.
@@ -245,6 +257,12 @@ func (sr *StreamingResponse) Collect() (*Response, error) {
 		if chunk.OutputTokens > 0 {
 			resp.OutputTokens += chunk.OutputTokens
 		}
+		if chunk.CacheCreationInputTokens > 0 {
+			resp.CacheCreationInputTokens = chunk.CacheCreationInputTokens
+		}
+		if chunk.CacheReadInputTokens > 0 {
+			resp.CacheReadInputTokens = chunk.CacheReadInputTokens
+		}
 	}
 
 	return resp, nil
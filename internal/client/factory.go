@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 
 	"gokin/internal/config"
@@ -63,6 +64,10 @@ func NewClient(ctx context.Context, cfg *config.Config, modelID string) (Client,
 		provider = cfg.API.Backend
 	}
 
+	if cfg.Cassette != (config.CassetteConfig{}) {
+		return newCassetteClient(ctx, cfg, provider, modelID)
+	}
+
 	// If fallback providers are configured, build a FallbackClient
 	if len(cfg.Model.FallbackProviders) > 0 {
 		return newFallbackClientFromConfig(ctx, cfg, provider, modelID)
@@ -72,6 +77,60 @@ func NewClient(ctx context.Context, cfg *config.Config, modelID string) (Client,
 	return getOrCreateClient(ctx, cfg, provider, modelID)
 }
 
+// newCassetteClient creates a scripted, replaying or recording client as
+// selected by cfg.Cassette.
+func newCassetteClient(ctx context.Context, cfg *config.Config, provider, modelID string) (Client, error) {
+	cc := cfg.Cassette
+	set := 0
+	for _, path := range []string{cc.Record, cc.Replay, cc.Mock} {
+		if path != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, fmt.Errorf("only one of record, replay and mock can be used at a time")
+	}
+
+	switch {
+	case cc.Mock != "":
+		script, err := LoadMockScript(cc.Mock)
+		if err != nil {
+			return nil, err
+		}
+		if script.Model == "" {
+			script.Model = modelID
+		}
+		logging.Debug("using mock client", "script", cc.Mock)
+		return NewMockClient(script), nil
+
+	case cc.Replay != "":
+		c, err := NewReplayClient(cc.Replay, "")
+		if err != nil {
+			return nil, err
+		}
+		if c.GetModel() == "" {
+			c.SetModel(modelID)
+		}
+		logging.Debug("replaying cassette", "path", cc.Replay, "model", c.GetModel())
+		return c, nil
+	}
+
+	var (
+		inner Client
+		err   error
+	)
+	if len(cfg.Model.FallbackProviders) > 0 {
+		inner, err = newFallbackClientFromConfig(ctx, cfg, provider, modelID)
+	} else {
+		inner, err = getOrCreateClient(ctx, cfg, provider, modelID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	logging.Debug("recording cassette", "path", cc.Record, "model", inner.GetModel())
+	return NewRecordingClient(inner, cc.Record), nil
+}
+
 // newFallbackClientFromConfig creates a FallbackClient with the primary provider
 // and each configured fallback provider.
 func newFallbackClientFromConfig(ctx context.Context, cfg *config.Config, primaryProvider, modelID string) (Client, error) {
@@ -94,7 +153,13 @@ func newFallbackClientFromConfig(ctx context.Context, cfg *config.Config, primar
 			continue
 		}
 
-		fbClient, fbErr := getOrCreateClient(ctx, cfg, fbProvider, modelID)
+		// OpenAI-compatible endpoints serve their own model rather than the primary's
+		fbModel := modelID
+		if pc, ok := cfg.API.OpenAIProvider(fbProvider); ok && pc.Model != "" {
+			fbModel = pc.Model
+		}
+
+		fbClient, fbErr := getOrCreateClient(ctx, cfg, fbProvider, fbModel)
 		if fbErr != nil {
 			logging.Warn("failed to create fallback client",
 				"provider", fbProvider,
@@ -151,6 +216,10 @@ func createClientForProvider(ctx context.Context, cfg *config.Config, provider,
 	case "ollama":
 		return newOllamaClient(cfg, modelID)
 	default:
+		// Named OpenAI-compatible endpoints from api.openai_compatible
+		if pc, ok := cfg.API.OpenAIProvider(provider); ok {
+			return newOpenAICompatibleClient(cfg, provider, pc, modelID)
+		}
 		// Fallback to auto-detection from model name
 		return autoDetectClient(ctx, cfg, modelID)
 	}
@@ -230,6 +299,7 @@ func newGLMClient(cfg *config.Config, modelID string) (Client, error) {
 		StreamEnabled:  true,
 		EnableThinking: cfg.Model.EnableThinking,
 		ThinkingBudget: cfg.Model.ThinkingBudget,
+		PromptCaching:  cfg.Model.PromptCaching,
 		// Retry configuration from config
 		MaxRetries:  cfg.API.Retry.MaxRetries,
 		RetryDelay:  cfg.API.Retry.RetryDelay,
@@ -272,6 +342,7 @@ func newAnthropicClientForModel(cfg *config.Config, modelInfo ModelInfo) (Client
 		StreamEnabled:  true,
 		EnableThinking: cfg.Model.EnableThinking,
 		ThinkingBudget: cfg.Model.ThinkingBudget,
+		PromptCaching:  cfg.Model.PromptCaching,
 		// Retry configuration from config
 		MaxRetries:  cfg.API.Retry.MaxRetries,
 		RetryDelay:  cfg.API.Retry.RetryDelay,
@@ -339,6 +410,7 @@ func newDeepSeekClient(cfg *config.Config, modelID string) (Client, error) {
 		StreamEnabled:  true,
 		EnableThinking: cfg.Model.EnableThinking,
 		ThinkingBudget: cfg.Model.ThinkingBudget,
+		PromptCaching:  cfg.Model.PromptCaching,
 		// Retry configuration from config
 		MaxRetries:  cfg.API.Retry.MaxRetries,
 		RetryDelay:  cfg.API.Retry.RetryDelay,
@@ -379,3 +451,45 @@ func newOllamaClient(cfg *config.Config, modelID string) (Client, error) {
 
 	return NewOllamaClient(ollamaConfig)
 }
+
+// newOpenAICompatibleClient creates a client for a named OpenAI-compatible
+// endpoint (vLLM, llama.cpp server, LM Studio, OpenRouter).
+func newOpenAICompatibleClient(cfg *config.Config, name string, pc config.OpenAICompatibleConfig, modelID string) (Client, error) {
+	// Built-in model names (e.g. the default Gemini model) are never served
+	// by these endpoints, so use the provider's own model instead
+	if pc.Model != "" && (modelID == "" || IsValidModel(modelID)) {
+		modelID = pc.Model
+	}
+	if modelID == "" {
+		return nil, fmt.Errorf("model required for provider %s (set model.name or api.openai_compatible.%s.model)", name, name)
+	}
+
+	// Load optional API key
+	loadedKey := security.GetOpenAICompatibleKey(name, pc.APIKeyEnv, pc.APIKey)
+	if loadedKey.IsSet() {
+		logging.Debug("loaded OpenAI-compatible API key",
+			"provider", name,
+			"source", loadedKey.Source,
+			"model", modelID)
+	}
+
+	headers := make(map[string]string, len(pc.Headers))
+	for k, v := range pc.Headers {
+		headers[k] = os.ExpandEnv(v)
+	}
+
+	openAIConfig := OpenAIConfig{
+		Provider:    name,
+		BaseURL:     pc.BaseURL,
+		APIKey:      loadedKey.Value,
+		Headers:     headers,
+		Model:       modelID,
+		MaxTokens:   cfg.Model.MaxOutputTokens,
+		Temperature: cfg.Model.Temperature,
+		MaxRetries:  cfg.API.Retry.MaxRetries,
+		RetryDelay:  cfg.API.Retry.RetryDelay,
+		HTTPTimeout: cfg.API.Retry.HTTPTimeout,
+	}
+
+	return NewOpenAIClient(openAIConfig)
+}
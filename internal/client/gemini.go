@@ -493,6 +493,7 @@ func processResponse(resp *genai.GenerateContentResponse) ResponseChunk {
 	if resp.UsageMetadata != nil {
 		chunk.InputTokens = int(resp.UsageMetadata.PromptTokenCount)
 		chunk.OutputTokens = int(resp.UsageMetadata.CandidatesTokenCount)
+		chunk.CacheReadInputTokens = int(resp.UsageMetadata.CachedContentTokenCount)
 	}
 
 	if len(resp.Candidates) == 0 {
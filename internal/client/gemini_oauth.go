@@ -662,8 +662,9 @@ func (c *GeminiOAuthClient) parseSSEData(data string) (ResponseChunk, error) {
 				FinishReason string `json:"finishReason,omitempty"`
 			} `json:"candidates"`
 			UsageMetadata *struct {
-				PromptTokenCount     int `json:"promptTokenCount"`
-				CandidatesTokenCount int `json:"candidatesTokenCount"`
+				PromptTokenCount        int `json:"promptTokenCount"`
+				CandidatesTokenCount    int `json:"candidatesTokenCount"`
+				CachedContentTokenCount int `json:"cachedContentTokenCount"`
 			} `json:"usageMetadata,omitempty"`
 		} `json:"response"`
 	}
@@ -694,8 +695,9 @@ func (c *GeminiOAuthClient) parseSSEData(data string) (ResponseChunk, error) {
 				FinishReason string `json:"finishReason,omitempty"`
 			} `json:"candidates"`
 			UsageMetadata *struct {
-				PromptTokenCount     int `json:"promptTokenCount"`
-				CandidatesTokenCount int `json:"candidatesTokenCount"`
+				PromptTokenCount        int `json:"promptTokenCount"`
+				CandidatesTokenCount    int `json:"candidatesTokenCount"`
+				CachedContentTokenCount int `json:"cachedContentTokenCount"`
 			} `json:"usageMetadata,omitempty"`
 		}
 		if err := json.Unmarshal([]byte(data), &direct); err == nil && len(direct.Candidates) > 0 {
@@ -703,6 +705,7 @@ func (c *GeminiOAuthClient) parseSSEData(data string) (ResponseChunk, error) {
 			if direct.UsageMetadata != nil {
 				chunk.InputTokens = direct.UsageMetadata.PromptTokenCount
 				chunk.OutputTokens = direct.UsageMetadata.CandidatesTokenCount
+				chunk.CacheReadInputTokens = direct.UsageMetadata.CachedContentTokenCount
 			}
 
 			candidate := direct.Candidates[0]
@@ -739,6 +742,7 @@ func (c *GeminiOAuthClient) parseSSEData(data string) (ResponseChunk, error) {
 	if resp.UsageMetadata != nil {
 		chunk.InputTokens = resp.UsageMetadata.PromptTokenCount
 		chunk.OutputTokens = resp.UsageMetadata.CandidatesTokenCount
+		chunk.CacheReadInputTokens = resp.UsageMetadata.CachedContentTokenCount
 	}
 
 	if len(resp.Candidates) == 0 {
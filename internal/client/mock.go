@@ -0,0 +1,218 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"google.golang.org/genai"
+	"gopkg.in/yaml.v3"
+)
+
+// MockScript is a scripted conversation for MockClient:
+//
+//	model: mock
+//	turns:
+//	  - text: "Let me look at the file."
+//	    function_calls:
+//	      - name: read
+//	        args: {file_path: main.go}
+//	  - expect: {function_responses: [read]}
+//	    text: "main.go defines the entry point."
+type MockScript struct {
+	Model string     `yaml:"model,omitempty"`
+	Turns []MockTurn `yaml:"turns"`
+}
+
+// MockTurn is one scripted model response.
+type MockTurn struct {
+	// Expect optionally checks the request this turn answers.
+	Expect *MockExpect `yaml:"expect,omitempty"`
+
+	Thinking      string                 `yaml:"thinking,omitempty"`
+	Text          string                 `yaml:"text,omitempty"`
+	FunctionCalls []CassetteFunctionCall `yaml:"function_calls,omitempty"`
+	FinishReason  string                 `yaml:"finish_reason,omitempty"`
+	InputTokens   int                    `yaml:"input_tokens,omitempty"`
+	OutputTokens  int                    `yaml:"output_tokens,omitempty"`
+
+	// Error fails the request with this message instead of responding.
+	Error string `yaml:"error,omitempty"`
+}
+
+// MockExpect describes the request a turn expects. Empty fields match anything.
+type MockExpect struct {
+	Kind              string   `yaml:"kind,omitempty"`
+	MessageContains   string   `yaml:"message_contains,omitempty"`
+	FunctionResponses []string `yaml:"function_responses,omitempty"`
+}
+
+// LoadMockScript reads a mock script file.
+func LoadMockScript(path string) (*MockScript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mock script: %w", err)
+	}
+	script, err := ParseMockScript(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return script, nil
+}
+
+// ParseMockScript parses a YAML mock script.
+func ParseMockScript(data []byte) (*MockScript, error) {
+	var script MockScript
+	if err := yaml.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("failed to parse mock script: %w", err)
+	}
+	if len(script.Turns) == 0 {
+		return nil, fmt.Errorf("mock script has no turns")
+	}
+	for i, turn := range script.Turns {
+		for _, fc := range turn.FunctionCalls {
+			if fc.Name == "" {
+				return nil, fmt.Errorf("turn %d: function call without a name", i+1)
+			}
+		}
+	}
+	return &script, nil
+}
+
+// mockState is the script position shared by a MockClient and the clients
+// derived from it with WithModel.
+type mockState struct {
+	mu       sync.Mutex
+	script   *MockScript
+	next     int
+	requests []CassetteRequest
+}
+
+// MockClient plays the turns of a MockScript in order, one per request.
+type MockClient struct {
+	offlineClient
+	state *mockState
+}
+
+// NewMockClient creates a client that answers requests from script.
+func NewMockClient(script *MockScript) *MockClient {
+	model := script.Model
+	if model == "" {
+		model = "mock"
+	}
+	return &MockClient{
+		offlineClient: offlineClient{model: model},
+		state:         &mockState{script: script},
+	}
+}
+
+// SendMessage answers a message with the next scripted turn.
+func (c *MockClient) SendMessage(ctx context.Context, message string) (*StreamingResponse, error) {
+	return c.respond(ctx, newCassetteRequest(RequestMessage, c.GetModel(), nil, message, nil))
+}
+
+// SendMessageWithHistory answers a message with the next scripted turn.
+func (c *MockClient) SendMessageWithHistory(ctx context.Context, history []*genai.Content, message string) (*StreamingResponse, error) {
+	return c.respond(ctx, newCassetteRequest(RequestHistory, c.GetModel(), history, message, nil))
+}
+
+// SendFunctionResponse answers function results with the next scripted turn.
+func (c *MockClient) SendFunctionResponse(ctx context.Context, history []*genai.Content, results []*genai.FunctionResponse) (*StreamingResponse, error) {
+	return c.respond(ctx, newCassetteRequest(RequestFunctionResponse, c.GetModel(), history, "", results))
+}
+
+func (c *MockClient) respond(ctx context.Context, req CassetteRequest) (*StreamingResponse, error) {
+	c.state.mu.Lock()
+	c.state.requests = append(c.state.requests, req)
+	if c.state.next >= len(c.state.script.Turns) {
+		n := len(c.state.script.Turns)
+		c.state.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s (mock script exhausted after %d turns)", ErrCassetteMismatch, req, n)
+	}
+	index := c.state.next
+	turn := c.state.script.Turns[index]
+	c.state.next++
+	c.state.mu.Unlock()
+
+	if err := turn.Expect.check(req); err != nil {
+		return nil, fmt.Errorf("%w: turn %d: %v", ErrCassetteMismatch, index+1, err)
+	}
+	if turn.Error != "" {
+		return nil, errors.New(turn.Error)
+	}
+	return streamChunks(ctx, turn.chunks(index)), nil
+}
+
+// check reports how req differs from the expectation.
+func (e *MockExpect) check(req CassetteRequest) error {
+	if e == nil {
+		return nil
+	}
+	if e.Kind != "" && e.Kind != req.Kind {
+		return fmt.Errorf("expected %s request, got %s", e.Kind, req)
+	}
+	if e.MessageContains != "" && !strings.Contains(req.Message, e.MessageContains) {
+		return fmt.Errorf("expected message containing %q, got %s", e.MessageContains, req)
+	}
+	if len(e.FunctionResponses) > 0 && !slices.Equal(e.FunctionResponses, req.FunctionResponses) {
+		return fmt.Errorf("expected function responses %v, got %s", e.FunctionResponses, req)
+	}
+	return nil
+}
+
+// chunks converts the turn into a response stream. Function calls without an
+// ID get a deterministic one.
+func (t MockTurn) chunks(index int) []ResponseChunk {
+	var chunks []ResponseChunk
+	if t.Thinking != "" {
+		chunks = append(chunks, ResponseChunk{Thinking: t.Thinking})
+	}
+	if t.Text != "" {
+		chunks = append(chunks, ResponseChunk{Text: t.Text})
+	}
+	if len(t.FunctionCalls) > 0 {
+		calls := make([]*genai.FunctionCall, len(t.FunctionCalls))
+		for i, fc := range t.FunctionCalls {
+			calls[i] = fc.toFunctionCall()
+			if calls[i].ID == "" {
+				calls[i].ID = fmt.Sprintf("call_%d_%d", index+1, i+1)
+			}
+		}
+		chunks = append(chunks, ResponseChunk{FunctionCalls: calls})
+	}
+
+	finish := genai.FinishReasonStop
+	if t.FinishReason != "" {
+		finish = genai.FinishReason(t.FinishReason)
+	}
+	return append(chunks, ResponseChunk{
+		Done:         true,
+		FinishReason: finish,
+		InputTokens:  t.InputTokens,
+		OutputTokens: t.OutputTokens,
+	})
+}
+
+// Requests returns the requests received so far, for assertions in tests.
+func (c *MockClient) Requests() []CassetteRequest {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	return slices.Clone(c.state.requests)
+}
+
+// Remaining returns the number of scripted turns not yet played.
+func (c *MockClient) Remaining() int {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	return len(c.state.script.Turns) - c.state.next
+}
+
+// WithModel returns a client for the specified model that continues the
+// same script.
+func (c *MockClient) WithModel(modelName string) Client {
+	return &MockClient{offlineClient: offlineClient{model: modelName}, state: c.state}
+}
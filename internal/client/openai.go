@@ -0,0 +1,909 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gokin/internal/logging"
+
+	"google.golang.org/genai"
+)
+
+// OpenAIConfig holds configuration for OpenAI-compatible Chat Completions APIs
+// (vLLM, llama.cpp server, LM Studio, OpenRouter).
+type OpenAIConfig struct {
+	Provider    string            // Provider name from config, used in messages
+	BaseURL     string            // e.g. "http://localhost:8000/v1"
+	APIKey      string            // Optional, local servers usually run without one
+	Headers     map[string]string // Extra request headers
+	Model       string
+	MaxTokens   int32
+	Temperature float32
+	// Retry configuration
+	MaxRetries  int           // Maximum number of retry attempts
+	RetryDelay  time.Duration // Initial delay between retries
+	HTTPTimeout time.Duration // HTTP request timeout
+}
+
+// OpenAIClient implements Client interface for OpenAI-compatible Chat Completions APIs.
+type OpenAIClient struct {
+	config            OpenAIConfig
+	httpClient        *http.Client
+	tools             []*genai.Tool
+	rateLimiter       RateLimiter
+	statusCallback    StatusCallback
+	systemInstruction string
+	mu                sync.RWMutex
+}
+
+// NewOpenAIClient creates a new OpenAI-compatible client.
+func NewOpenAIClient(config OpenAIConfig) (*OpenAIClient, error) {
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("base URL is required")
+	}
+	baseURL, err := url.Parse(config.BaseURL)
+	if err != nil || (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+		return nil, fmt.Errorf("invalid BaseURL %q: must be an http:// or https:// URL", config.BaseURL)
+	}
+	if config.Model == "" {
+		return nil, fmt.Errorf("model name is required")
+	}
+	if config.Provider == "" {
+		config.Provider = "openai"
+	}
+
+	// Set defaults
+	if config.MaxTokens == 0 {
+		config.MaxTokens = 8192
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	}
+	if config.RetryDelay == 0 {
+		config.RetryDelay = 1 * time.Second
+	}
+	if config.HTTPTimeout == 0 {
+		config.HTTPTimeout = 120 * time.Second
+	}
+
+	// Warn if a key would be sent unencrypted to a non-localhost host
+	if baseURL.Scheme == "http" && config.APIKey != "" {
+		host := baseURL.Hostname()
+		if host != "localhost" && host != "127.0.0.1" && host != "::1" {
+			logging.Warn("OpenAI-compatible provider uses unencrypted HTTP to remote host",
+				"provider", config.Provider,
+				"host", host,
+				"recommendation", "use HTTPS when sending an API key")
+		}
+	}
+
+	return &OpenAIClient{
+		config:     config,
+		httpClient: &http.Client{Timeout: config.HTTPTimeout},
+		tools:      make([]*genai.Tool, 0),
+	}, nil
+}
+
+// SendMessage sends a message and returns a streaming response.
+func (c *OpenAIClient) SendMessage(ctx context.Context, message string) (*StreamingResponse, error) {
+	return c.SendMessageWithHistory(ctx, nil, message)
+}
+
+// SendMessageWithHistory sends a message with conversation history.
+func (c *OpenAIClient) SendMessageWithHistory(ctx context.Context, history []*genai.Content, message string) (*StreamingResponse, error) {
+	messages := c.convertHistoryToMessages(history)
+	if message != "" {
+		messages = append(messages, map[string]interface{}{"role": "user", "content": message})
+	}
+	return c.streamRequest(ctx, c.buildRequest(messages))
+}
+
+// SendFunctionResponse sends function call results back to the model.
+func (c *OpenAIClient) SendFunctionResponse(ctx context.Context, history []*genai.Content, results []*genai.FunctionResponse) (*StreamingResponse, error) {
+	messages := c.convertHistoryToMessages(history)
+	for _, result := range results {
+		messages = append(messages, map[string]interface{}{
+			"role":         "tool",
+			"tool_call_id": toolCallID(result.ID, result.Name),
+			"content":      functionResponseText(result.Response),
+		})
+	}
+	return c.streamRequest(ctx, c.buildRequest(messages))
+}
+
+// buildRequest assembles the chat completion request body.
+func (c *OpenAIClient) buildRequest(messages []map[string]interface{}) map[string]interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	requestBody := map[string]interface{}{
+		"model":      c.config.Model,
+		"messages":   messages,
+		"max_tokens": c.config.MaxTokens,
+		"stream":     true,
+		// Ask for a final usage chunk; servers that don't know the option ignore it
+		"stream_options": map[string]interface{}{"include_usage": true},
+	}
+	if c.config.Temperature > 0 {
+		requestBody["temperature"] = c.config.Temperature
+	}
+	if tools := c.convertToolsToOpenAI(); len(tools) > 0 {
+		requestBody["tools"] = tools
+	}
+	return requestBody
+}
+
+// SetSystemInstruction sets the system-level instruction for the model.
+func (c *OpenAIClient) SetSystemInstruction(instruction string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.systemInstruction = instruction
+}
+
+// SetThinkingBudget is a no-op: reasoning models served through the Chat
+// Completions API decide on their own and stream reasoning_content.
+func (c *OpenAIClient) SetThinkingBudget(budget int32) {}
+
+// SetTools sets the tools available for function calling.
+func (c *OpenAIClient) SetTools(tools []*genai.Tool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tools = tools
+}
+
+// SetRateLimiter sets the rate limiter for API calls.
+func (c *OpenAIClient) SetRateLimiter(limiter interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if rl, ok := limiter.(RateLimiter); ok {
+		c.rateLimiter = rl
+	}
+}
+
+// SetStatusCallback sets the callback for status updates during operations.
+func (c *OpenAIClient) SetStatusCallback(cb StatusCallback) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.statusCallback = cb
+}
+
+// CountTokens estimates tokens for the given contents.
+// The Chat Completions API has no counting endpoint; exact usage is reported with each response.
+func (c *OpenAIClient) CountTokens(ctx context.Context, contents []*genai.Content) (*genai.CountTokensResponse, error) {
+	totalChars := 0
+	for _, content := range contents {
+		totalChars += 4 * 4 // role overhead (~4 tokens)
+		for _, part := range content.Parts {
+			if part.Text != "" {
+				totalChars += len(part.Text)
+			}
+			if part.FunctionCall != nil {
+				totalChars += len(part.FunctionCall.Name) + 40
+				if argsJSON, err := json.Marshal(part.FunctionCall.Args); err == nil {
+					totalChars += len(argsJSON)
+				}
+			}
+			if part.FunctionResponse != nil {
+				totalChars += len(part.FunctionResponse.Name) + 40
+				if respJSON, err := json.Marshal(part.FunctionResponse.Response); err == nil {
+					totalChars += len(respJSON)
+				}
+			}
+		}
+	}
+
+	return &genai.CountTokensResponse{
+		TotalTokens: int32(totalChars / 4),
+	}, nil
+}
+
+// GetModel returns the model name.
+func (c *OpenAIClient) GetModel() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.config.Model
+}
+
+// SetModel changes the model for this client.
+func (c *OpenAIClient) SetModel(modelName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.config.Model = modelName
+}
+
+// WithModel returns a new client configured for the specified model.
+func (c *OpenAIClient) WithModel(modelName string) Client {
+	c.mu.RLock()
+	newConfig := c.config
+	tools := c.tools
+	c.mu.RUnlock()
+
+	newConfig.Model = modelName
+	newClient, err := NewOpenAIClient(newConfig)
+	if err != nil {
+		logging.Error("failed to create OpenAI-compatible client with new model", "model", modelName, "error", err)
+		return c
+	}
+	newClient.SetTools(tools)
+	return newClient
+}
+
+// GetRawClient returns the underlying HTTP client.
+func (c *OpenAIClient) GetRawClient() interface{} {
+	return c.httpClient
+}
+
+// Close releases idle connections.
+func (c *OpenAIClient) Close() error {
+	c.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// endpoint returns the chat completions URL. A base URL without a path gets
+// the conventional /v1 prefix.
+func (c *OpenAIClient) endpoint() string {
+	base := strings.TrimSuffix(c.config.BaseURL, "/")
+	if strings.HasSuffix(base, "/chat/completions") {
+		return base
+	}
+	if u, err := url.Parse(base); err == nil && u.Path == "" {
+		base += "/v1"
+	}
+	return base + "/chat/completions"
+}
+
+// streamRequest performs a streaming request with retry logic.
+func (c *OpenAIClient) streamRequest(ctx context.Context, requestBody map[string]interface{}) (*StreamingResponse, error) {
+	var estimatedTokens int64 = 500
+	c.mu.RLock()
+	rateLimiter := c.rateLimiter
+	c.mu.RUnlock()
+
+	if rateLimiter != nil {
+		if err := rateLimiter.AcquireWithContext(ctx, estimatedTokens); err != nil {
+			return nil, fmt.Errorf("rate limit: %w", err)
+		}
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	logging.Debug("OpenAI-compatible request",
+		"provider", c.config.Provider,
+		"url", c.endpoint(),
+		"body", truncateString(string(jsonData), 2000))
+
+	var lastErr error
+	var lastStatusCode int
+	maxDelay := 30 * time.Second
+
+	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			delay := calculateBackoffWithJitter(c.config.RetryDelay, attempt-1, maxDelay)
+			logging.Info("retrying OpenAI-compatible request", "provider", c.config.Provider, "attempt", attempt, "delay", delay)
+
+			c.mu.RLock()
+			cb := c.statusCallback
+			c.mu.RUnlock()
+			if cb != nil {
+				reason := "API error"
+				if lastErr != nil {
+					reason = lastErr.Error()
+					if lastStatusCode == 429 {
+						reason = "rate limit"
+					} else if strings.Contains(reason, "connection") {
+						reason = "connection error"
+					} else if strings.Contains(reason, "timeout") {
+						reason = "timeout"
+					} else if len(reason) > 50 {
+						reason = reason[:47] + "..."
+					}
+				}
+				cb.OnRetry(attempt, c.config.MaxRetries, delay, reason)
+			}
+
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		response, err := c.doStreamRequest(ctx, jsonData)
+		if err == nil {
+			return response, nil
+		}
+		lastErr = err
+
+		lastStatusCode = 0
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) {
+			lastStatusCode = httpErr.StatusCode
+		}
+
+		if ctx.Err() != nil || !c.isRetryableError(err, lastStatusCode) {
+			if rateLimiter != nil {
+				rateLimiter.ReturnTokens(1, estimatedTokens)
+			}
+			return nil, c.wrapError(err)
+		}
+
+		logging.Warn("OpenAI-compatible request failed, will retry", "attempt", attempt, "error", err, "status", lastStatusCode)
+	}
+
+	if rateLimiter != nil {
+		rateLimiter.ReturnTokens(1, estimatedTokens)
+	}
+	return nil, fmt.Errorf("max retries (%d) exceeded: %w", c.config.MaxRetries, c.wrapError(lastErr))
+}
+
+// doStreamRequest performs a single streaming request attempt.
+func (c *OpenAIClient) doStreamRequest(ctx context.Context, body []byte) (*StreamingResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+	for k, v := range c.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if err != nil {
+			respBody = []byte("(failed to read response body)")
+		}
+		resp.Body.Close()
+		logging.Warn("OpenAI-compatible API error", "provider", c.config.Provider, "status", resp.StatusCode, "body", string(respBody))
+		return nil, &HTTPError{
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("API error (status %d): %s", resp.StatusCode, openAIErrorMessage(respBody)),
+		}
+	}
+
+	chunks := make(chan ResponseChunk, 10)
+	done := make(chan struct{})
+
+	// Stream idle timeout (30 seconds between chunks), warning at 15 seconds.
+	// Local servers may sit silently while processing a long prompt, so this
+	// matches the other streaming clients rather than being stricter.
+	const streamIdleTimeout = 30 * time.Second
+	const streamIdleWarning = 15 * time.Second
+
+	c.mu.RLock()
+	statusCb := c.statusCallback
+	c.mu.RUnlock()
+
+	// Force-close the body on cancellation to unblock the scanner
+	go func() {
+		select {
+		case <-ctx.Done():
+			resp.Body.Close()
+		case <-done:
+		}
+	}()
+
+	go func() {
+		defer close(chunks)
+		defer close(done)
+		defer resp.Body.Close()
+
+		type scanResult struct {
+			line string
+			ok   bool
+			err  error
+		}
+		scanCh := make(chan scanResult)
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 8*1024*1024)
+		go func() {
+			defer close(scanCh)
+			for {
+				ok := scanner.Scan()
+				select {
+				case scanCh <- scanResult{line: scanner.Text(), ok: ok, err: scanner.Err()}:
+					if !ok {
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		send := func(chunk ResponseChunk) bool {
+			select {
+			case chunks <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		acc := newOpenAIStreamAccumulator()
+		idleTimer := time.NewTimer(streamIdleTimeout)
+		defer idleTimer.Stop()
+		warningTimer := time.NewTimer(streamIdleWarning)
+		defer warningTimer.Stop()
+		lastWarningAt := time.Duration(0)
+
+		for {
+			select {
+			case <-ctx.Done():
+				select {
+				case chunks <- ResponseChunk{Error: ctx.Err(), Done: true}:
+				default:
+				}
+				return
+
+			case <-warningTimer.C:
+				lastWarningAt += streamIdleWarning
+				if statusCb != nil {
+					statusCb.OnStreamIdle(lastWarningAt)
+				}
+				warningTimer.Reset(10 * time.Second)
+
+			case <-idleTimer.C:
+				logging.Warn("stream idle timeout exceeded", "provider", c.config.Provider, "timeout", streamIdleTimeout)
+				send(ResponseChunk{
+					Error: fmt.Errorf("stream idle timeout: no data received for %v", streamIdleTimeout),
+					Done:  true,
+				})
+				return
+
+			case result, ok := <-scanCh:
+				if lastWarningAt > 0 && statusCb != nil {
+					statusCb.OnStreamResume()
+				}
+				lastWarningAt = 0
+				resetTimer(idleTimer, streamIdleTimeout)
+				resetTimer(warningTimer, streamIdleWarning)
+
+				if !ok || !result.ok {
+					if ok && result.err != nil {
+						logging.Warn("SSE scanner error", "error", result.err)
+						send(ResponseChunk{Error: result.err, Done: true})
+						return
+					}
+					// Some servers close the stream without a [DONE] marker
+					send(acc.finish())
+					return
+				}
+
+				data, isData := sseData(result.line)
+				if !isData {
+					continue
+				}
+				if data == "[DONE]" {
+					send(acc.finish())
+					return
+				}
+
+				var event openAIStreamChunk
+				if err := json.Unmarshal([]byte(data), &event); err != nil {
+					logging.Warn("failed to parse SSE event", "error", err, "data", truncateString(data, 100))
+					if statusCb != nil {
+						statusCb.OnError(fmt.Errorf("incomplete SSE data: %w", err), true)
+					}
+					continue
+				}
+				if event.Error != nil {
+					send(ResponseChunk{Error: fmt.Errorf("API error: %s", event.Error.String()), Done: true})
+					return
+				}
+
+				chunk := acc.add(&event)
+				if chunk.Text != "" || chunk.Thinking != "" {
+					if !send(chunk) {
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return &StreamingResponse{
+		Chunks: chunks,
+		Done:   done,
+	}, nil
+}
+
+// sseData extracts the payload of an SSE "data:" line.
+func sseData(line string) (string, bool) {
+	data, ok := strings.CutPrefix(line, "data:")
+	if !ok {
+		return "", false
+	}
+	return strings.TrimPrefix(data, " "), true
+}
+
+// openAIStreamChunk is a single chat.completion.chunk event.
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content          string `json:"content"`
+			ReasoningContent string `json:"reasoning_content"` // vLLM, llama.cpp, DeepSeek
+			Reasoning        string `json:"reasoning"`         // OpenRouter
+			ToolCalls        []struct {
+				Index    int    `json:"index"`
+				ID       string `json:"id"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens        int `json:"prompt_tokens"`
+		CompletionTokens    int `json:"completion_tokens"`
+		PromptTokensDetails *struct {
+			CachedTokens int `json:"cached_tokens"`
+		} `json:"prompt_tokens_details"`
+	} `json:"usage"`
+	Error *openAIError `json:"error"`
+}
+
+// openAIError is the error object returned in error responses and stream events.
+type openAIError struct {
+	Message string      `json:"message"`
+	Type    string      `json:"type"`
+	Code    interface{} `json:"code"`
+}
+
+func (e *openAIError) String() string {
+	if e.Type != "" {
+		return e.Type + " - " + e.Message
+	}
+	return e.Message
+}
+
+// openAIErrorMessage extracts the message from an error response body.
+func openAIErrorMessage(body []byte) string {
+	var resp struct {
+		Error *openAIError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Error != nil && resp.Error.Message != "" {
+		return resp.Error.String()
+	}
+	return string(body)
+}
+
+// openAIToolCall accumulates the streamed fragments of one tool call.
+type openAIToolCall struct {
+	id   string
+	name string
+	args strings.Builder
+}
+
+// openAIStreamAccumulator collects tool calls, finish reason and usage until
+// the stream ends. Tool calls arrive interleaved by index when the model
+// calls several tools in parallel.
+type openAIStreamAccumulator struct {
+	calls        map[int]*openAIToolCall
+	finishReason string
+	inputTokens  int
+	outputTokens int
+	cachedTokens int
+}
+
+func newOpenAIStreamAccumulator() *openAIStreamAccumulator {
+	return &openAIStreamAccumulator{calls: make(map[int]*openAIToolCall)}
+}
+
+// add records an event and returns the text and reasoning it carries.
+func (a *openAIStreamAccumulator) add(event *openAIStreamChunk) ResponseChunk {
+	var chunk ResponseChunk
+	for _, choice := range event.Choices {
+		delta := choice.Delta
+		chunk.Text += delta.Content
+		if delta.ReasoningContent != "" {
+			chunk.Thinking += delta.ReasoningContent
+		} else {
+			chunk.Thinking += delta.Reasoning
+		}
+		for _, tc := range delta.ToolCalls {
+			call, ok := a.calls[tc.Index]
+			if !ok {
+				call = &openAIToolCall{}
+				a.calls[tc.Index] = call
+			}
+			if tc.ID != "" {
+				call.id = tc.ID
+			}
+			if tc.Function.Name != "" {
+				call.name = tc.Function.Name
+			}
+			call.args.WriteString(tc.Function.Arguments)
+		}
+		if choice.FinishReason != "" {
+			a.finishReason = choice.FinishReason
+		}
+	}
+	if event.Usage != nil {
+		a.inputTokens = event.Usage.PromptTokens
+		a.outputTokens = event.Usage.CompletionTokens
+		if event.Usage.PromptTokensDetails != nil {
+			a.cachedTokens = event.Usage.PromptTokensDetails.CachedTokens
+		}
+	}
+	return chunk
+}
+
+// finish builds the final chunk with the completed tool calls and usage.
+func (a *openAIStreamAccumulator) finish() ResponseChunk {
+	chunk := ResponseChunk{
+		Done:                 true,
+		FinishReason:         genai.FinishReasonStop,
+		InputTokens:          a.inputTokens,
+		OutputTokens:         a.outputTokens,
+		CacheReadInputTokens: a.cachedTokens,
+	}
+	switch a.finishReason {
+	case "length":
+		chunk.FinishReason = genai.FinishReasonMaxTokens
+	case "content_filter":
+		chunk.FinishReason = genai.FinishReasonSafety
+	}
+
+	indexes := make([]int, 0, len(a.calls))
+	for i := range a.calls {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	for _, i := range indexes {
+		call := a.calls[i]
+		if call.name == "" {
+			logging.Warn("dropping streamed tool call without a name", "index", i)
+			continue
+		}
+		args := make(map[string]interface{})
+		if raw := strings.TrimSpace(call.args.String()); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &args); err != nil {
+				logging.Error("tool args JSON unmarshal failed",
+					"error", err,
+					"tool", call.name,
+					"json", raw)
+				args = make(map[string]interface{})
+			}
+		}
+		id := call.id
+		if id == "" {
+			id = randomID()
+		}
+		chunk.FunctionCalls = append(chunk.FunctionCalls, &genai.FunctionCall{
+			ID:   id,
+			Name: call.name,
+			Args: args,
+		})
+	}
+	return chunk
+}
+
+// convertHistoryToMessages converts Gemini history to Chat Completions messages.
+func (c *OpenAIClient) convertHistoryToMessages(history []*genai.Content) []map[string]interface{} {
+	messages := make([]map[string]interface{}, 0, len(history)+2)
+
+	c.mu.RLock()
+	sysInstruction := c.systemInstruction
+	c.mu.RUnlock()
+	if sysInstruction != "" {
+		messages = append(messages, map[string]interface{}{"role": "system", "content": sysInstruction})
+	}
+
+	for _, content := range history {
+		switch content.Role {
+		case genai.RoleModel:
+			if msg := buildOpenAIAssistantMessage(content.Parts); msg != nil {
+				messages = append(messages, msg)
+			}
+		default:
+			messages = append(messages, buildOpenAIUserMessages(content.Parts)...)
+		}
+	}
+	return messages
+}
+
+// buildOpenAIAssistantMessage converts model parts to an assistant message.
+// Returns nil when the parts carry neither text nor tool calls.
+func buildOpenAIAssistantMessage(parts []*genai.Part) map[string]interface{} {
+	var text []string
+	var toolCalls []map[string]interface{}
+	for _, part := range parts {
+		if part.Thought {
+			continue
+		}
+		if part.Text != "" {
+			text = append(text, part.Text)
+		}
+		if part.FunctionCall != nil {
+			args, err := json.Marshal(part.FunctionCall.Args)
+			if err != nil || part.FunctionCall.Args == nil {
+				args = []byte("{}")
+			}
+			toolCalls = append(toolCalls, map[string]interface{}{
+				"id":   toolCallID(part.FunctionCall.ID, part.FunctionCall.Name),
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":      part.FunctionCall.Name,
+					"arguments": string(args),
+				},
+			})
+		}
+	}
+	if len(text) == 0 && len(toolCalls) == 0 {
+		return nil
+	}
+
+	msg := map[string]interface{}{"role": "assistant"}
+	if len(text) > 0 {
+		msg["content"] = strings.Join(text, "\n")
+	} else {
+		msg["content"] = nil
+	}
+	if len(toolCalls) > 0 {
+		msg["tool_calls"] = toolCalls
+	}
+	return msg
+}
+
+// buildOpenAIUserMessages converts user parts to messages. Tool results become
+// tool messages, which must directly follow the assistant's tool calls; text
+// and images (including images returned by tools) follow in one user message.
+func buildOpenAIUserMessages(parts []*genai.Part) []map[string]interface{} {
+	var messages []map[string]interface{}
+	var text []string
+	var images []map[string]interface{}
+
+	for _, part := range parts {
+		if part.FunctionResponse != nil {
+			messages = append(messages, map[string]interface{}{
+				"role":         "tool",
+				"tool_call_id": toolCallID(part.FunctionResponse.ID, part.FunctionResponse.Name),
+				"content":      functionResponseText(part.FunctionResponse.Response),
+			})
+		}
+		if part.Text != "" {
+			text = append(text, part.Text)
+		}
+		if part.InlineData != nil {
+			images = append(images, map[string]interface{}{
+				"type": "image_url",
+				"image_url": map[string]interface{}{
+					"url": "data:" + part.InlineData.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(part.InlineData.Data),
+				},
+			})
+		}
+	}
+
+	switch {
+	case len(images) > 0:
+		content := make([]map[string]interface{}, 0, len(images)+1)
+		if len(text) > 0 {
+			content = append(content, map[string]interface{}{"type": "text", "text": strings.Join(text, "\n")})
+		}
+		content = append(content, images...)
+		messages = append(messages, map[string]interface{}{"role": "user", "content": content})
+	case len(text) > 0:
+		messages = append(messages, map[string]interface{}{"role": "user", "content": strings.Join(text, "\n")})
+	}
+	return messages
+}
+
+// toolCallID returns the call ID, falling back to the tool name for old
+// sessions that didn't save IDs (used consistently for calls and results).
+func toolCallID(id, name string) string {
+	if id != "" {
+		return id
+	}
+	return name
+}
+
+// functionResponseText extracts the text sent back to the model for a tool result.
+func functionResponseText(resp map[string]interface{}) string {
+	var contentStr string
+	if resp != nil {
+		if val, ok := resp["content"].(string); ok {
+			contentStr = val
+		} else if data, ok := resp["data"]; ok {
+			if jsonBytes, err := json.Marshal(data); err == nil {
+				contentStr = string(jsonBytes)
+			}
+		}
+		if errStr, ok := resp["error"].(string); ok && errStr != "" {
+			contentStr = "Error: " + errStr
+		}
+	}
+	if contentStr == "" {
+		contentStr = "Operation completed"
+	}
+	return contentStr
+}
+
+// convertToolsToOpenAI converts Gemini tools to Chat Completions function tools.
+// Must be called with c.mu held.
+func (c *OpenAIClient) convertToolsToOpenAI() []map[string]interface{} {
+	tools := make([]map[string]interface{}, 0)
+	for _, tool := range c.tools {
+		for _, decl := range tool.FunctionDeclarations {
+			params := convertSchemaToJSON(decl.Parameters)
+			if params == nil {
+				params = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+			}
+			tools = append(tools, map[string]interface{}{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":        decl.Name,
+					"description": decl.Description,
+					"parameters":  params,
+				},
+			})
+		}
+	}
+	return tools
+}
+
+// isRetryableError returns true if the error should trigger a retry.
+func (c *OpenAIClient) isRetryableError(err error, statusCode int) bool {
+	switch statusCode {
+	case 429, 500, 502, 503, 504:
+		return true
+	}
+	if err != nil && statusCode == 0 {
+		errStr := err.Error()
+		if strings.Contains(errStr, "timeout") ||
+			strings.Contains(errStr, "connection refused") ||
+			strings.Contains(errStr, "no such host") ||
+			strings.Contains(errStr, "connection reset") ||
+			strings.Contains(errStr, "EOF") {
+			return true
+		}
+	}
+	return false
+}
+
+// wrapError adds the provider and endpoint to connection and model errors.
+func (c *OpenAIClient) wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	errStr := err.Error()
+
+	if strings.Contains(errStr, "connection refused") || strings.Contains(errStr, "no such host") {
+		return fmt.Errorf("%s server at %s is not reachable (is it running?): %w", c.config.Provider, c.config.BaseURL, err)
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case 401, 403:
+			return fmt.Errorf("%s rejected the API key (set api_key or api_key_env for this provider): %w", c.config.Provider, err)
+		case 404:
+			return fmt.Errorf("%s: model %q or endpoint %s not found: %w", c.config.Provider, c.config.Model, c.endpoint(), err)
+		}
+	}
+	return err
+}
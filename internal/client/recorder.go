@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"gokin/internal/logging"
+
+	"google.golang.org/genai"
+)
+
+// cassetteRecorder appends interactions to a cassette file. It is shared by
+// every client that records to the same path, so sub-agents and client
+// re-initialization write into one cassette.
+type cassetteRecorder struct {
+	path     string
+	mu       sync.Mutex
+	cassette *Cassette
+}
+
+var (
+	recordersMu sync.Mutex
+	recorders   = make(map[string]*cassetteRecorder)
+)
+
+// getRecorder returns the recorder for path, starting a new cassette the
+// first time the path is used in this process.
+func getRecorder(path, model string) *cassetteRecorder {
+	recordersMu.Lock()
+	defer recordersMu.Unlock()
+
+	if r, ok := recorders[path]; ok {
+		return r
+	}
+	r := &cassetteRecorder{
+		path:     path,
+		cassette: &Cassette{Version: cassetteVersion, Model: model},
+	}
+	recorders[path] = r
+	return r
+}
+
+// add appends an interaction and rewrites the cassette file.
+func (r *cassetteRecorder) add(interaction Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	if err := r.cassette.Save(r.path); err != nil {
+		logging.Warn("failed to save cassette", "path", r.path, "error", err)
+	}
+}
+
+// RecordingClient wraps a Client and records every request and its response
+// stream to a cassette file for later replay.
+type RecordingClient struct {
+	inner    Client
+	recorder *cassetteRecorder
+}
+
+// NewRecordingClient creates a client that records inner's traffic to path.
+func NewRecordingClient(inner Client, path string) *RecordingClient {
+	return &RecordingClient{
+		inner:    inner,
+		recorder: getRecorder(path, inner.GetModel()),
+	}
+}
+
+// SendMessage sends a message and records the exchange.
+func (c *RecordingClient) SendMessage(ctx context.Context, message string) (*StreamingResponse, error) {
+	req := newCassetteRequest(RequestMessage, c.inner.GetModel(), nil, message, nil)
+	sr, err := c.inner.SendMessage(ctx, message)
+	return c.record(ctx, req, sr, err)
+}
+
+// SendMessageWithHistory sends a message with history and records the exchange.
+func (c *RecordingClient) SendMessageWithHistory(ctx context.Context, history []*genai.Content, message string) (*StreamingResponse, error) {
+	req := newCassetteRequest(RequestHistory, c.inner.GetModel(), history, message, nil)
+	sr, err := c.inner.SendMessageWithHistory(ctx, history, message)
+	return c.record(ctx, req, sr, err)
+}
+
+// SendFunctionResponse sends function results and records the exchange.
+func (c *RecordingClient) SendFunctionResponse(ctx context.Context, history []*genai.Content, results []*genai.FunctionResponse) (*StreamingResponse, error) {
+	req := newCassetteRequest(RequestFunctionResponse, c.inner.GetModel(), history, "", results)
+	sr, err := c.inner.SendFunctionResponse(ctx, history, results)
+	return c.record(ctx, req, sr, err)
+}
+
+// record tees the response stream into the cassette. The interaction is
+// written once the stream ends, so concurrent requests do not interleave.
+func (c *RecordingClient) record(ctx context.Context, req CassetteRequest, sr *StreamingResponse, err error) (*StreamingResponse, error) {
+	if err != nil {
+		c.recorder.add(Interaction{Request: req, Error: err.Error()})
+		return nil, err
+	}
+
+	out := make(chan ResponseChunk, 10)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		defer close(out)
+
+		interaction := Interaction{Request: req}
+		defer func() { c.recorder.add(interaction) }()
+
+		for chunk := range sr.Chunks {
+			interaction.Chunks = append(interaction.Chunks, newCassetteChunk(chunk))
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return &StreamingResponse{Chunks: out, Done: done}, nil
+}
+
+// SetTools sets the tools available for the model to use.
+func (c *RecordingClient) SetTools(tools []*genai.Tool) {
+	c.inner.SetTools(tools)
+}
+
+// SetRateLimiter sets the rate limiter for API calls.
+func (c *RecordingClient) SetRateLimiter(limiter interface{}) {
+	c.inner.SetRateLimiter(limiter)
+}
+
+// CountTokens counts tokens for the given contents.
+func (c *RecordingClient) CountTokens(ctx context.Context, contents []*genai.Content) (*genai.CountTokensResponse, error) {
+	return c.inner.CountTokens(ctx, contents)
+}
+
+// GetModel returns the model name.
+func (c *RecordingClient) GetModel() string {
+	return c.inner.GetModel()
+}
+
+// SetModel changes the model for this client.
+func (c *RecordingClient) SetModel(modelName string) {
+	c.inner.SetModel(modelName)
+}
+
+// WithModel returns a recording client for the specified model that writes
+// to the same cassette.
+func (c *RecordingClient) WithModel(modelName string) Client {
+	return &RecordingClient{inner: c.inner.WithModel(modelName), recorder: c.recorder}
+}
+
+// GetRawClient returns the underlying client for direct API access.
+func (c *RecordingClient) GetRawClient() interface{} {
+	return c.inner.GetRawClient()
+}
+
+// SetSystemInstruction sets the system-level instruction for the model.
+func (c *RecordingClient) SetSystemInstruction(instruction string) {
+	c.inner.SetSystemInstruction(instruction)
+}
+
+// SetThinkingBudget configures the thinking budget for the next request.
+func (c *RecordingClient) SetThinkingBudget(budget int32) {
+	c.inner.SetThinkingBudget(budget)
+}
+
+// Close closes the wrapped client.
+func (c *RecordingClient) Close() error {
+	return c.inner.Close()
+}
@@ -0,0 +1,182 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"google.golang.org/genai"
+)
+
+// ErrCassetteMismatch is returned when a replayed or scripted client receives
+// a request it has no answer for.
+var ErrCassetteMismatch = errors.New("no recorded response for request")
+
+// offlineClient implements the configuration methods of Client for clients
+// that never talk to a provider.
+type offlineClient struct {
+	mu    sync.RWMutex
+	model string
+}
+
+// SetTools is a no-op; responses are predetermined.
+func (c *offlineClient) SetTools(tools []*genai.Tool) {}
+
+// SetRateLimiter is a no-op; no API calls are made.
+func (c *offlineClient) SetRateLimiter(limiter interface{}) {}
+
+// CountTokens estimates tokens at roughly four characters per token.
+func (c *offlineClient) CountTokens(ctx context.Context, contents []*genai.Content) (*genai.CountTokensResponse, error) {
+	totalChars := 0
+	for _, content := range contents {
+		totalChars += 4 * 4 // role overhead (~4 tokens)
+		for _, part := range content.Parts {
+			totalChars += len(part.Text)
+			if part.FunctionCall != nil {
+				if argsJSON, err := json.Marshal(part.FunctionCall.Args); err == nil {
+					totalChars += len(part.FunctionCall.Name) + len(argsJSON)
+				}
+			}
+			if part.FunctionResponse != nil {
+				if respJSON, err := json.Marshal(part.FunctionResponse.Response); err == nil {
+					totalChars += len(part.FunctionResponse.Name) + len(respJSON)
+				}
+			}
+		}
+	}
+	return &genai.CountTokensResponse{TotalTokens: int32(totalChars / 4)}, nil
+}
+
+// GetModel returns the model name.
+func (c *offlineClient) GetModel() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.model
+}
+
+// SetModel changes the reported model name.
+func (c *offlineClient) SetModel(modelName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.model = modelName
+}
+
+// GetRawClient returns nil; there is no underlying API client.
+func (c *offlineClient) GetRawClient() interface{} {
+	return nil
+}
+
+// SetSystemInstruction is a no-op; responses are predetermined.
+func (c *offlineClient) SetSystemInstruction(instruction string) {}
+
+// SetThinkingBudget is a no-op; responses are predetermined.
+func (c *offlineClient) SetThinkingBudget(budget int32) {}
+
+// Close is a no-op.
+func (c *offlineClient) Close() error {
+	return nil
+}
+
+// replayState is the cassette position shared by a ReplayClient and the
+// clients derived from it with WithModel.
+type replayState struct {
+	mu       sync.Mutex
+	path     string
+	cassette *Cassette
+	used     []bool
+}
+
+// ReplayClient answers requests from a recorded cassette without network
+// access. Each request is answered by the first unused interaction that
+// matches it, so interleaved sub-agent traffic replays correctly.
+type ReplayClient struct {
+	offlineClient
+	state *replayState
+}
+
+// NewReplayClient loads a cassette for replay. If model is empty the model
+// recorded in the cassette is reported.
+func NewReplayClient(path, model string) (*ReplayClient, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	if model == "" {
+		model = cassette.Model
+	}
+	return &ReplayClient{
+		offlineClient: offlineClient{model: model},
+		state: &replayState{
+			path:     path,
+			cassette: cassette,
+			used:     make([]bool, len(cassette.Interactions)),
+		},
+	}, nil
+}
+
+// SendMessage replays the response to a message.
+func (c *ReplayClient) SendMessage(ctx context.Context, message string) (*StreamingResponse, error) {
+	return c.replay(ctx, newCassetteRequest(RequestMessage, c.GetModel(), nil, message, nil))
+}
+
+// SendMessageWithHistory replays the response to a message with history.
+func (c *ReplayClient) SendMessageWithHistory(ctx context.Context, history []*genai.Content, message string) (*StreamingResponse, error) {
+	return c.replay(ctx, newCassetteRequest(RequestHistory, c.GetModel(), history, message, nil))
+}
+
+// SendFunctionResponse replays the response to function results.
+func (c *ReplayClient) SendFunctionResponse(ctx context.Context, history []*genai.Content, results []*genai.FunctionResponse) (*StreamingResponse, error) {
+	return c.replay(ctx, newCassetteRequest(RequestFunctionResponse, c.GetModel(), history, "", results))
+}
+
+func (c *ReplayClient) replay(ctx context.Context, req CassetteRequest) (*StreamingResponse, error) {
+	interaction, err := c.state.next(req)
+	if err != nil {
+		return nil, err
+	}
+	if interaction.Error != "" {
+		return nil, errors.New(interaction.Error)
+	}
+
+	chunks := make([]ResponseChunk, len(interaction.Chunks))
+	for i, cc := range interaction.Chunks {
+		chunks[i] = cc.toChunk()
+	}
+	return streamChunks(ctx, chunks), nil
+}
+
+// next claims the first unused interaction matching req.
+func (s *replayState) next(req CassetteRequest) (Interaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, interaction := range s.cassette.Interactions {
+		if !s.used[i] && interaction.Request.matches(req) {
+			s.used[i] = true
+			return interaction, nil
+		}
+	}
+	return Interaction{}, fmt.Errorf("%w: %s (cassette %s)", ErrCassetteMismatch, req, s.path)
+}
+
+// Remaining returns the number of recorded interactions not yet replayed.
+func (c *ReplayClient) Remaining() int {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	n := 0
+	for _, used := range c.state.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
+
+// WithModel returns a client for the specified model that replays from the
+// same cassette.
+func (c *ReplayClient) WithModel(modelName string) Client {
+	return &ReplayClient{offlineClient: offlineClient{model: modelName}, state: c.state}
+}
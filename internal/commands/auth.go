@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"os/exec"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
 	"gokin/internal/auth"
 	"gokin/internal/config"
+	"gokin/internal/secrets"
+	"gokin/internal/security"
 )
 
 // LoginCommand sets the API key.
@@ -106,13 +109,24 @@ Get your GLM API key from your provider.`, nil
 		providerName = "GLM"
 	}
 
-	return fmt.Sprintf(`%s API key saved!
+	saved := "saved"
+	if store := secrets.Default(); store != nil {
+		saved = "saved to " + store.Name()
+		if store.Get(provider+"_key") != apiKey {
+			saved = "set for this session only: " + store.Name() + " could not be written"
+			if _, ok := store.Backend().(*secrets.FileBackend); ok {
+				saved += " (set " + secrets.PassphraseEnv + ")"
+			}
+		}
+	}
+
+	return fmt.Sprintf(`%s API key %s!
 
 Active provider: %s
 Model: %s
 
 Use /provider to switch providers
-Use /model to switch models`, providerName, providerName, cfg.Model.Name), nil
+Use /model to switch models`, providerName, saved, providerName, cfg.Model.Name), nil
 }
 
 func (c *LoginCommand) showStatus(cfg *config.Config) string {
@@ -356,6 +370,22 @@ func (c *ProviderCommand) Execute(ctx context.Context, args []string, app AppInt
 		{"ollama", "llama3.2", "Ollama (local)"},
 	}
 
+	// Named OpenAI-compatible endpoints from config
+	var compatible []string
+	for name, p := range cfg.API.OpenAICompatible {
+		if p.BaseURL != "" {
+			compatible = append(compatible, name)
+		}
+	}
+	sort.Strings(compatible)
+	for _, name := range compatible {
+		providerInfo = append(providerInfo, struct {
+			name        string
+			model       string
+			description string
+		}{name, cfg.API.OpenAICompatible[name].Model, "OpenAI-compatible"})
+	}
+
 	providerModels := make(map[string]string)
 	validProviders := make(map[string]bool)
 	for _, p := range providerInfo {
@@ -392,7 +422,8 @@ func (c *ProviderCommand) Execute(ctx context.Context, args []string, app AppInt
 	newProvider := strings.ToLower(args[0])
 
 	if !validProviders[newProvider] {
-		return fmt.Sprintf("Unknown provider: %s\n\nAvailable: gemini, deepseek, glm, ollama", newProvider), nil
+		available := []string{"gemini", "deepseek", "glm", "ollama"}
+		return fmt.Sprintf("Unknown provider: %s\n\nAvailable: %s", newProvider, strings.Join(append(available, compatible...), ", ")), nil
 	}
 
 	if newProvider == currentProvider {
@@ -407,7 +438,9 @@ func (c *ProviderCommand) Execute(ctx context.Context, args []string, app AppInt
 	// Switch provider
 	cfg.API.ActiveProvider = newProvider
 	cfg.Model.Provider = newProvider
-	cfg.Model.Name = providerModels[newProvider]
+	if model := providerModels[newProvider]; model != "" {
+		cfg.Model.Name = model
+	}
 
 	if err := app.ApplyConfig(cfg); err != nil {
 		return fmt.Sprintf("Failed to save: %v", err), nil
@@ -478,6 +511,97 @@ func (c *StatusCommand) Execute(ctx context.Context, args []string, app AppInter
 	return sb.String(), nil
 }
 
+// AuthStatusCommand shows where each credential is loaded from.
+type AuthStatusCommand struct{}
+
+func (c *AuthStatusCommand) Name() string        { return "auth-status" }
+func (c *AuthStatusCommand) Description() string { return "Show authentication status" }
+func (c *AuthStatusCommand) Usage() string       { return "/auth-status" }
+func (c *AuthStatusCommand) GetMetadata() CommandMetadata {
+	return CommandMetadata{
+		Category: CategoryAuthSetup,
+		Icon:     "key",
+		Priority: 35,
+	}
+}
+
+func (c *AuthStatusCommand) Execute(ctx context.Context, args []string, app AppInterface) (string, error) {
+	cfg := app.GetConfig()
+	if cfg == nil {
+		return "Failed to get configuration.", nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Authentication Status\n")
+	sb.WriteString("=====================\n\n")
+
+	store := secrets.Default()
+	if store != nil {
+		sb.WriteString(fmt.Sprintf("Secret store: %s\n\n", store.Name()))
+	} else {
+		sb.WriteString("Secret store: none (keys are kept in the config file)\n\n")
+	}
+
+	keys := []struct {
+		name string
+		key  *security.LoadedKey
+	}{
+		{"gemini", security.GetGeminiKey(cfg.API.GeminiKey, cfg.API.APIKey)},
+		{"glm", security.GetGLMKey(cfg.API.GLMKey, cfg.API.APIKey)},
+		{"deepseek", security.GetDeepSeekKey(cfg.API.DeepSeekKey, cfg.API.APIKey)},
+		{"ollama", security.GetOllamaKey(cfg.API.OllamaKey)},
+	}
+	names := make([]string, 0, len(cfg.API.OpenAICompatible))
+	for name := range cfg.API.OpenAICompatible {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := cfg.API.OpenAICompatible[name]
+		keys = append(keys, struct {
+			name string
+			key  *security.LoadedKey
+		}{name, security.GetOpenAICompatibleKey(name, p.APIKeyEnv, p.APIKey)})
+	}
+
+	active := cfg.API.GetActiveProvider()
+	sb.WriteString("API Keys:\n")
+	for _, k := range keys {
+		marker := "  "
+		if k.name == active {
+			marker = "> "
+		}
+		if !k.key.IsSet() {
+			sb.WriteString(fmt.Sprintf("%s%-10s not set\n", marker, k.name))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%s%-10s %s  (%s)\n", marker, k.name, security.MaskKey(k.key.Value), keySourceLabel(k.key.Source, store)))
+	}
+
+	if cfg.API.HasOAuthToken("gemini") {
+		source := security.KeySourceConfig
+		if store != nil && store.Get("gemini_oauth.refresh_token") == cfg.API.GeminiOAuth.RefreshToken {
+			source = security.KeySourceKeyring
+		}
+		sb.WriteString(fmt.Sprintf("\nGemini OAuth: %s  (%s)\n", cfg.API.GeminiOAuth.Email, keySourceLabel(source, store)))
+	}
+
+	sb.WriteString(fmt.Sprintf("\nConfig: %s\n", config.GetConfigPath()))
+	return sb.String(), nil
+}
+
+// keySourceLabel describes where a key was loaded from.
+func keySourceLabel(source security.KeySource, store *secrets.Store) string {
+	switch source {
+	case security.KeySourceKeyring:
+		return "keyring: " + store.Name()
+	case security.KeySourceConfig:
+		return "config file, plain text"
+	default:
+		return string(source)
+	}
+}
+
 // OAuthLoginCommand handles /oauth-login for Google Account authentication
 type OAuthLoginCommand struct{}
 
@@ -526,7 +650,7 @@ func (c *OAuthLoginCommand) Execute(ctx context.Context, args []string, app AppI
 	defer server.Stop()
 
 	// Try to open browser
-	browserOpened := openBrowser(authURL)
+	browserOpened := OpenBrowser(authURL)
 
 	var sb strings.Builder
 	sb.WriteString("Opening browser for Google authentication...\n\n")
@@ -642,8 +766,8 @@ func (c *OAuthLogoutCommand) Execute(ctx context.Context, args []string, app App
 	return sb.String(), nil
 }
 
-// openBrowser opens a URL in the default browser
-func openBrowser(url string) bool {
+// OpenBrowser opens a URL in the default browser
+func OpenBrowser(url string) bool {
 	var cmd *exec.Cmd
 
 	switch runtime.GOOS {
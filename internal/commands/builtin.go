@@ -7,9 +7,14 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"gokin/internal/chat"
 	"gokin/internal/config"
+	"gokin/internal/permission"
+	"gokin/internal/security"
 )
 
 const (
@@ -47,9 +52,13 @@ func (c *HelpCommand) Execute(ctx context.Context, args []string, app AppInterfa
 		if !exists {
 			return fmt.Sprintf("%sUnknown command: /%s%s\nUse /help to see all commands.", colorRed, args[0], colorReset), nil
 		}
-		return fmt.Sprintf("%s/%s%s - %s\n\n%sUsage:%s %s\n\n%sDescription:%s %s",
+		help := fmt.Sprintf("%s/%s%s - %s\n\n%sUsage:%s %s\n\n%sDescription:%s %s",
 			colorGreen, cmd.Name(), colorReset, colorBold, cmd.Description(), colorReset,
-			cmd.Usage(), colorCyan, colorReset, cmd.Description()), nil
+			cmd.Usage(), colorCyan, colorReset, cmd.Description())
+		if custom, ok := cmd.(*CustomCommand); ok {
+			help += fmt.Sprintf("\n\n%sDefined in:%s %s", colorCyan, colorReset, custom.Path())
+		}
+		return help, nil
 	}
 
 	var sb strings.Builder
@@ -83,8 +92,8 @@ func (c *HelpCommand) Execute(ctx context.Context, args []string, app AppInterfa
 		commands []string
 	}{
 		{"Getting Started", []string{"help", "quickstart"}},
-		{"Session", []string{"model", "clear", "compact", "save", "resume", "sessions", "stats", "undo", "instructions"}},
-		{"Auth & Setup", []string{"login", "logout", "oauth-login", "oauth-logout", "provider", "status", "doctor", "config", "update"}},
+		{"Session", []string{"model", "clear", "compact", "save", "resume", "sessions", "history", "rewind", "stats", "undo", "instructions"}},
+		{"Auth & Setup", []string{"login", "logout", "oauth-login", "oauth-logout", "provider", "status", "auth-status", "doctor", "config", "update"}},
 		{"Git", []string{"init", "commit", "pr"}},
 		{"Planning", []string{"plan", "resume-plan", "tree-stats"}},
 		{"Tools", []string{"browse", "open", "copy", "paste", "clear-todos", "ql", "permissions", "sandbox", "theme",
@@ -117,6 +126,24 @@ func (c *HelpCommand) Execute(ctx context.Context, args []string, app AppInterfa
 		}
 	}
 
+	// Custom commands from .gokin/commands and the user config dir
+	var custom []Command
+	for name, cmd := range cmdMap {
+		if _, ok := cmd.(*CustomCommand); ok {
+			custom = append(custom, cmd)
+			delete(cmdMap, name)
+		}
+	}
+	if len(custom) > 0 {
+		sort.Slice(custom, func(i, j int) bool {
+			return custom[i].Name() < custom[j].Name()
+		})
+		sb.WriteString(fmt.Sprintf("\n  %sCustom%s\n", colorBold, colorReset))
+		for _, cmd := range custom {
+			sb.WriteString(fmt.Sprintf("    %s/%-22s%s %s%s%s\n", colorGreen, cmd.Name(), colorReset, colorCyan, cmd.Description(), colorReset))
+		}
+	}
+
 	// Show any uncategorized commands
 	if len(cmdMap) > 0 {
 		sb.WriteString(fmt.Sprintf("\n  %sOther%s\n", colorBold, colorReset))
@@ -266,20 +293,20 @@ type ResumeCommand struct{}
 
 func (c *ResumeCommand) Name() string        { return "resume" }
 func (c *ResumeCommand) Description() string { return "Resume a saved session" }
-func (c *ResumeCommand) Usage() string       { return "/resume <session_id>" }
+func (c *ResumeCommand) Usage() string       { return "/resume <session_id> [turn]" }
 func (c *ResumeCommand) GetMetadata() CommandMetadata {
 	return CommandMetadata{
 		Category: CategorySession,
 		Icon:     "resume",
 		Priority: 40,
 		HasArgs:  true,
-		ArgHint:  "<id>",
+		ArgHint:  "<id> [turn]",
 	}
 }
 
 func (c *ResumeCommand) Execute(ctx context.Context, args []string, app AppInterface) (string, error) {
 	if len(args) == 0 {
-		return "Usage: /resume <session_id>\nUse /sessions to list available sessions.", nil
+		return "Usage: /resume <session_id> [turn]\nUse /sessions to list available sessions, /history to find a turn.", nil
 	}
 
 	hm, err := app.GetHistoryManager()
@@ -287,11 +314,29 @@ func (c *ResumeCommand) Execute(ctx context.Context, args []string, app AppInter
 		return fmt.Sprintf("Failed to get history manager: %v", err), nil
 	}
 
-	sessionID := args[0]
+	// The turn is a second argument, or appended as <id>#<turn>
+	sessionID, turnArg := args[0], ""
+	if len(args) > 1 {
+		turnArg = args[1]
+	} else if i := strings.LastIndexByte(sessionID, '#'); i > 0 {
+		sessionID, turnArg = sessionID[:i], sessionID[i+1:]
+	}
+	turn := 0
+	if turnArg != "" {
+		if turn, err = strconv.Atoi(turnArg); err != nil || turn < 1 {
+			return fmt.Sprintf("Invalid turn: %s", turnArg), nil
+		}
+	}
+
 	state, err := hm.LoadFull(sessionID)
 	if err != nil {
 		return fmt.Sprintf("Failed to load session '%s': %v", sessionID, err), nil
 	}
+	if turn > 0 {
+		if err := state.TruncateToTurn(turn); err != nil {
+			return fmt.Sprintf("Failed to resume session '%s': %v", sessionID, err), nil
+		}
+	}
 
 	session := app.GetSession()
 	if session == nil {
@@ -303,6 +348,9 @@ func (c *ResumeCommand) Execute(ctx context.Context, args []string, app AppInter
 		return fmt.Sprintf("Failed to restore session: %v", err), nil
 	}
 
+	if turn > 0 {
+		return fmt.Sprintf("Session '%s' restored up to turn %d. %d messages loaded.", sessionID, turn, len(state.History)), nil
+	}
 	return fmt.Sprintf("Session '%s' restored. %d messages loaded.", sessionID, len(state.History)), nil
 }
 
@@ -351,6 +399,80 @@ func (c *SessionsCommand) Execute(ctx context.Context, args []string, app AppInt
 	return sb.String(), nil
 }
 
+// SessionArchiver is implemented by apps with a searchable archive of
+// saved sessions.
+type SessionArchiver interface {
+	GetSessionArchive() *chat.Archive
+}
+
+// HistoryCommand searches all saved sessions.
+type HistoryCommand struct{}
+
+func (c *HistoryCommand) Name() string        { return "history" }
+func (c *HistoryCommand) Description() string { return "Search all saved sessions" }
+func (c *HistoryCommand) Usage() string {
+	return `/history <query>             - Search prompts, replies, tool calls and files of all sessions
+/history <query> --since 7d  - Only sessions active in the last 7 days (also 2w, 24h, YYYY-MM-DD)
+/history <query> --here      - Only sessions of this project
+/history                     - Show archive size`
+}
+func (c *HistoryCommand) GetMetadata() CommandMetadata {
+	return CommandMetadata{
+		Category: CategorySession,
+		Icon:     "search",
+		Priority: 55,
+		HasArgs:  true,
+		ArgHint:  "<query>",
+	}
+}
+
+func (c *HistoryCommand) Execute(ctx context.Context, args []string, app AppInterface) (string, error) {
+	archiver, ok := app.(SessionArchiver)
+	if !ok || archiver.GetSessionArchive() == nil {
+		return "Session archive is not available. Enable session.enabled and session.archive in the config.", nil
+	}
+	archive := archiver.GetSessionArchive()
+
+	q := chat.ArchiveQuery{Limit: 10}
+	var words []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--here":
+			q.WorkDir = app.GetWorkDir()
+		case "--since":
+			if i+1 >= len(args) {
+				return "Usage: /history <query> --since <7d|2w|24h|YYYY-MM-DD>", nil
+			}
+			i++
+			since, err := chat.ParseSince(args[i], time.Now())
+			if err != nil {
+				return err.Error(), nil
+			}
+			q.Since = since
+		default:
+			words = append(words, args[i])
+		}
+	}
+	q.Text = strings.Join(words, " ")
+
+	if q.Text == "" {
+		sessions, turns, err := archive.Stats(ctx)
+		if err != nil {
+			return fmt.Sprintf("Failed to update session archive: %v", err), nil
+		}
+		return fmt.Sprintf("Session archive: %d sessions, %d turns (%s)\n\n%s", sessions, turns, archive.Path(), c.Usage()), nil
+	}
+
+	hits, err := archive.Search(ctx, q)
+	if err != nil {
+		return fmt.Sprintf("Search failed: %v", err), nil
+	}
+	if len(hits) == 0 {
+		return fmt.Sprintf("No past turns match %q.", q.Text), nil
+	}
+	return fmt.Sprintf("Past turns matching %q:\n\n%s", q.Text, chat.FormatArchiveHits(hits)), nil
+}
+
 // InitCommand initializes GOKIN.md for the project.
 type InitCommand struct{}
 
@@ -485,6 +607,20 @@ func (c *DoctorCommand) Execute(ctx context.Context, args []string, app AppInter
 		sb.WriteString(fmt.Sprintf("  %s○%s GOKIN.md not found (use /init to create)\n", colorYellow, colorReset))
 	}
 
+	// Bash sandbox
+	sb.WriteString(fmt.Sprintf("\n%s─── Sandbox ───%s\n", colorCyan, colorReset))
+	if cfg != nil && cfg.Tools.Bash.Sandbox {
+		status := security.GetSandboxStatus(sandboxConfigFrom(cfg))
+		sb.WriteString(fmt.Sprintf("  Platform: %s (%d layers active)\n", status.Platform, status.ActiveCount()))
+		sb.WriteString(formatSandboxLayers(status))
+		if status.ActiveCount() == 0 {
+			issues = append(issues, "Bash sandbox enabled but no isolation layer is active")
+			solutions = append(solutions, "Enable unprivileged user namespaces or use a kernel with Landlock/seccomp support")
+		}
+	} else {
+		sb.WriteString(fmt.Sprintf("  %s○%s Sandbox disabled (use /sandbox on)\n", colorYellow, colorReset))
+	}
+
 	// Data directories
 	dataDir, _ := getDataDir()
 	sb.WriteString(fmt.Sprintf("\n%s─── Directories ───%s\n", colorCyan, colorReset))
@@ -586,13 +722,19 @@ func (c *ConfigCommand) Execute(ctx context.Context, args []string, app AppInter
 	return sb.String(), nil
 }
 
-// PermissionsCommand toggles permission prompts.
+// PermissionInspector is implemented by apps that expose their permission
+// rules and recent decisions.
+type PermissionInspector interface {
+	GetPermissionManager() *permission.Manager
+}
+
+// PermissionsCommand toggles permission prompts and shows the rules.
 type PermissionsCommand struct{}
 
 func (c *PermissionsCommand) Name() string        { return "permissions" }
 func (c *PermissionsCommand) Description() string { return "Toggle permission prompts" }
 func (c *PermissionsCommand) Usage() string {
-	return `/permissions      - Show status
+	return `/permissions      - Show status, rules and recent decisions
 /permissions on   - Enable prompts
 /permissions off  - YOLO mode`
 }
@@ -614,10 +756,14 @@ func (c *PermissionsCommand) Execute(ctx context.Context, args []string, app App
 
 	// No args - show current status
 	if len(args) == 0 {
-		if cfg.Permission.Enabled {
+		if !cfg.Permission.Enabled {
+			return "permissions: off (YOLO)", nil
+		}
+		inspector, ok := app.(PermissionInspector)
+		if !ok || inspector.GetPermissionManager() == nil {
 			return "permissions: on", nil
 		}
-		return "permissions: off (YOLO)", nil
+		return formatPermissions(inspector.GetPermissionManager()), nil
 	}
 
 	// Toggle based on argument
@@ -641,13 +787,56 @@ func (c *PermissionsCommand) Execute(ctx context.Context, args []string, app App
 	}
 }
 
+// formatPermissions lists the argument-pattern rules by precedence and
+// the recent decisions with the rule or policy behind each.
+func formatPermissions(m *permission.Manager) string {
+	var sb strings.Builder
+	sb.WriteString("permissions: on\n")
+
+	rules := m.GetRules().List()
+	order := map[permission.Level]int{permission.LevelDeny: 0, permission.LevelAsk: 1, permission.LevelAllow: 2}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return order[rules[i].Level] < order[rules[j].Level]
+	})
+	sb.WriteString("\nRules:\n")
+	if len(rules) == 0 {
+		sb.WriteString("  (none — tool policies apply)\n")
+	}
+	for _, rule := range rules {
+		sb.WriteString(fmt.Sprintf("  %-5s %-40s %s\n", rule.Level, rule, rule.Source))
+	}
+
+	decisions := m.RecentDecisions()
+	if len(decisions) > 0 {
+		sb.WriteString("\nRecent decisions:\n")
+	}
+	for i := len(decisions) - 1; i >= 0; i-- {
+		d := decisions[i]
+		mark := colorGreen + "✓" + colorReset
+		if !d.Allowed {
+			mark = colorRed + "✗" + colorReset
+		}
+		subject := d.Subject
+		if len(subject) > 40 {
+			subject = subject[:37] + "..."
+		}
+		basis := d.Basis
+		if d.Asked {
+			basis = "asked; " + basis
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s %-10s %-40s %s\n", d.Time.Format("15:04:05"), mark, d.Tool, subject, basis))
+	}
+
+	return sb.String()
+}
+
 // SandboxCommand toggles bash sandbox mode.
 type SandboxCommand struct{}
 
 func (c *SandboxCommand) Name() string        { return "sandbox" }
 func (c *SandboxCommand) Description() string { return "Toggle bash sandbox mode" }
 func (c *SandboxCommand) Usage() string {
-	return `/sandbox      - Show status
+	return `/sandbox      - Show status and active isolation layers
 /sandbox on   - Safe mode
 /sandbox off  - Unrestricted`
 }
@@ -671,7 +860,7 @@ func (c *SandboxCommand) Execute(ctx context.Context, args []string, app AppInte
 	// No args - show current status
 	if len(args) == 0 {
 		if cfg.Tools.Bash.Sandbox {
-			return "sandbox: on", nil
+			return "sandbox: on\n" + formatSandboxLayers(security.GetSandboxStatus(sandboxConfigFrom(cfg))), nil
 		}
 		return "sandbox: off (!SANDBOX)", nil
 	}
@@ -697,6 +886,28 @@ func (c *SandboxCommand) Execute(ctx context.Context, args []string, app AppInte
 	}
 }
 
+// sandboxConfigFrom builds the bash sandbox configuration from the app config.
+func sandboxConfigFrom(cfg *config.Config) security.SandboxConfig {
+	sandboxCfg := security.DefaultSandboxConfig()
+	sandboxCfg.Enabled = cfg.Tools.Bash.Sandbox
+	sandboxCfg.WritableDirs = cfg.Tools.AllowedDirs
+	sandboxCfg.DisableNetwork = cfg.Tools.Bash.SandboxDisableNetwork
+	return sandboxCfg
+}
+
+// formatSandboxLayers renders one line per isolation layer.
+func formatSandboxLayers(status security.SandboxStatus) string {
+	var sb strings.Builder
+	for _, layer := range status.Layers {
+		mark := colorGreen + "✓" + colorReset
+		if !layer.Active {
+			mark = colorYellow + "○" + colorReset
+		}
+		sb.WriteString(fmt.Sprintf("  %s %-18s %s\n", mark, layer.Name, layer.Detail))
+	}
+	return sb.String()
+}
+
 // ClearTodosCommand clears all todo items.
 type ClearTodosCommand struct{}
 
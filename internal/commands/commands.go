@@ -20,6 +20,18 @@ type TokenStats struct {
 	InputTokens  int
 	OutputTokens int
 	TotalTokens  int
+	API          APIUsage // Usage reported by the API, summed over all requests
+}
+
+// APIUsage is token usage summed over model requests. InputTokens counts the
+// whole prompt of every request, including the parts served from or written
+// to the prompt cache.
+type APIUsage struct {
+	Requests            int
+	InputTokens         int
+	OutputTokens        int
+	CacheCreationTokens int
+	CacheReadTokens     int
 }
 
 // Command represents a slash command.
@@ -78,6 +90,8 @@ func NewHandler() *Handler {
 	h.Register(&SaveCommand{})
 	h.Register(&ResumeCommand{})
 	h.Register(&SessionsCommand{})
+	h.Register(&HistoryCommand{})
+	h.Register(&RewindCommand{})
 	// Register git commands
 	h.Register(&CommitCommand{})
 	h.Register(&PRCommand{})
@@ -92,6 +106,7 @@ func NewHandler() *Handler {
 	h.Register(&OAuthLogoutCommand{})
 	h.Register(&ProviderCommand{})
 	h.Register(&StatusCommand{})
+	h.Register(&AuthStatusCommand{})
 	h.Register(&ModelCommand{})
 	h.Register(&PermissionsCommand{})
 	h.Register(&SandboxCommand{})
@@ -112,6 +127,7 @@ func NewHandler() *Handler {
 
 	// Register stats command
 	h.Register(&StatsCommand{})
+	h.Register(&CostCommand{})
 
 	// Register theme command
 	h.Register(&ThemeCommand{})
@@ -119,6 +135,7 @@ func NewHandler() *Handler {
 	// Register planning mode command
 	h.Register(&PlanCommand{})
 	h.Register(&ResumePlanCommand{})
+	h.Register(&ContractCommand{})
 
 	// Register tree planner command
 	h.Register(&TreeStatsCommand{})
@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gokin/internal/contract"
+)
+
+// ContractProvider is implemented by apps that manage contracts.
+type ContractProvider interface {
+	GetContractManager() *contract.Manager
+}
+
+// ContractCommand shows contracts and approves or rejects proposed ones,
+// such as a contract left open by an earlier session.
+type ContractCommand struct{}
+
+func (c *ContractCommand) Name() string        { return "contract" }
+func (c *ContractCommand) Description() string { return "Show, approve or reject contracts" }
+func (c *ContractCommand) Usage() string {
+	return `/contract               - Show the active and the proposed contract
+/contract approve [id]  - Approve a proposed contract (default: the latest)
+/contract reject [id]   - Reject a proposed contract (default: the latest)`
+}
+func (c *ContractCommand) GetMetadata() CommandMetadata {
+	return CommandMetadata{
+		Category: CategoryPlanning,
+		Icon:     "check",
+		Priority: 30,
+		HasArgs:  true,
+		ArgHint:  "approve|reject [id]",
+	}
+}
+
+func (c *ContractCommand) Execute(ctx context.Context, args []string, app AppInterface) (string, error) {
+	provider, ok := app.(ContractProvider)
+	if !ok || provider.GetContractManager() == nil {
+		return "Contracts are not enabled. Set contract.enabled in the config.", nil
+	}
+	m := provider.GetContractManager()
+
+	id := ""
+	if len(args) > 1 {
+		id = args[1]
+	}
+
+	if len(args) == 0 {
+		var sb strings.Builder
+		if active := m.Active(); active != nil {
+			sb.WriteString(active.Format())
+		} else {
+			sb.WriteString("No active contract.\n")
+		}
+		if pending := m.Pending(); pending != nil {
+			sb.WriteString(fmt.Sprintf("\nAwaiting approval (%s):\n", pending.ID))
+			sb.WriteString(pending.Format())
+			sb.WriteString("\nIts verification commands only run after /contract approve.\n")
+		}
+		return sb.String(), nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "approve":
+		approved, err := m.Approve(id)
+		if err != nil {
+			return fmt.Sprintf("Cannot approve: %v", err), nil
+		}
+		return fmt.Sprintf("Approved contract %s.\n\n%s", approved.ID, approved.Format()), nil
+	case "reject":
+		rejected, err := m.RejectProposed(id)
+		if err != nil {
+			return fmt.Sprintf("Cannot reject: %v", err), nil
+		}
+		return fmt.Sprintf("Rejected contract %s (%s).", rejected.ID, rejected.Name), nil
+	default:
+		return c.Usage(), nil
+	}
+}
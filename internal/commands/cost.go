@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appcontext "gokin/internal/context"
+)
+
+// CostCommand shows API token usage and estimated cost, with prompt-cache
+// reads and writes itemized separately from uncached input.
+type CostCommand struct{}
+
+func (c *CostCommand) Name() string        { return "cost" }
+func (c *CostCommand) Description() string { return "Show token usage and estimated costs" }
+func (c *CostCommand) Usage() string       { return "/cost" }
+func (c *CostCommand) GetMetadata() CommandMetadata {
+	return CommandMetadata{
+		Category:    CategorySession,
+		Icon:        "stats",
+		Priority:    61,
+		RequiresAPI: true,
+	}
+}
+
+func (c *CostCommand) Execute(ctx context.Context, args []string, app AppInterface) (string, error) {
+	usage := app.GetTokenStats().API
+	if usage.Requests == 0 {
+		return "No API usage recorded in this session yet.", nil
+	}
+
+	cm := app.GetContextManager()
+	if cm == nil || cm.GetTokenCounter() == nil {
+		return "", fmt.Errorf("context manager not available")
+	}
+	counter := cm.GetTokenCounter()
+	cost := counter.CalculateCost(usage.InputTokens, usage.OutputTokens, usage.CacheCreationTokens, usage.CacheReadTokens)
+	pricing := counter.GetPricing()
+
+	var sb strings.Builder
+	sb.WriteString("💰 Session Cost\n")
+	sb.WriteString(strings.Repeat("─", 50))
+	sb.WriteString("\n\n")
+
+	sb.WriteString(fmt.Sprintf("  Model:            %s\n", app.GetConfig().Model.Name))
+	sb.WriteString(fmt.Sprintf("  API Requests:     %d\n\n", usage.Requests))
+
+	sb.WriteString("  Input\n")
+	sb.WriteString(fmt.Sprintf("    Uncached:       %12s  %s\n", formatNumber(int64(cost.UncachedInputTokens)), appcontext.FormatCost(cost.UncachedInputCost)))
+	sb.WriteString(fmt.Sprintf("    Cache write:    %12s  %s\n", formatNumber(int64(cost.CacheWriteTokens)), appcontext.FormatCost(cost.CacheWriteCost)))
+	sb.WriteString(fmt.Sprintf("    Cache read:     %12s  %s\n", formatNumber(int64(cost.CacheReadTokens)), appcontext.FormatCost(cost.CacheReadCost)))
+	sb.WriteString(fmt.Sprintf("  Output:           %12s  %s\n", formatNumber(int64(cost.OutputTokens)), appcontext.FormatCost(cost.OutputCost)))
+	sb.WriteString(fmt.Sprintf("  Total:            %12s  %s\n\n", formatNumber(int64(usage.InputTokens+usage.OutputTokens)), appcontext.FormatCost(cost.Total)))
+
+	if usage.InputTokens > 0 {
+		hitRate := float64(usage.CacheReadTokens) / float64(usage.InputTokens) * 100
+		sb.WriteString(fmt.Sprintf("  Cache hit rate:   %.1f%% of input tokens\n", hitRate))
+	}
+	if usage.CacheReadTokens > 0 {
+		saved := float64(usage.CacheReadTokens) / 1_000_000 * (pricing.InputCostPer1M - pricing.CacheReadCostPer1M)
+		sb.WriteString(fmt.Sprintf("  Saved by caching: %s\n", appcontext.FormatCost(saved)))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n  Pricing per 1M tokens: input $%.2f, cache write $%.2f, cache read $%.3f, output $%.2f\n",
+		pricing.InputCostPer1M, pricing.CacheWriteCostPer1M, pricing.CacheReadCostPer1M, pricing.OutputCostPer1M))
+	sb.WriteString("  Costs are estimates based on published list prices.")
+
+	return sb.String(), nil
+}
@@ -0,0 +1,347 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	appcontext "gokin/internal/context"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CommandScope tells where a custom command is defined.
+type CommandScope string
+
+const (
+	ScopeProject CommandScope = "project" // .gokin/commands in the project
+	ScopeUser    CommandScope = "user"    // commands in the user config dir
+)
+
+var (
+	argPattern         = regexp.MustCompile(`\$(ARGUMENTS|[1-9])`)
+	inlineShellPattern = regexp.MustCompile("!`([^`]+)`")
+)
+
+// PromptOptions scopes a prompt submitted by a command.
+type PromptOptions struct {
+	AllowedTools []string // Tools the model may call (names or globs); empty allows all
+	Model        string   // Model for this prompt; empty keeps the current one
+	MentionText  string   // Text whose @mentions are attached; the prompt itself is not scanned
+}
+
+// ScopedPromptSubmitter is implemented by apps that can run a submitted
+// prompt with a restricted tool set or another model.
+type ScopedPromptSubmitter interface {
+	SubmitScopedPrompt(prompt string, opts PromptOptions)
+}
+
+// ShellRunner is implemented by apps that run a command with the bash tool,
+// so permission rules, command validation, the sandbox and hooks apply.
+type ShellRunner interface {
+	RunShell(ctx context.Context, command, description string) (string, error)
+}
+
+// customCommandMeta is the YAML front matter of a custom command file.
+type customCommandMeta struct {
+	Description  string     `yaml:"description"`
+	ArgumentHint string     `yaml:"argument-hint"`
+	AllowedTools stringList `yaml:"allowed-tools"`
+	Model        string     `yaml:"model"`
+}
+
+// stringList accepts a YAML sequence or a comma-separated string.
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.SequenceNode {
+		var items []string
+		if err := value.Decode(&items); err != nil {
+			return err
+		}
+		*l = items
+		return nil
+	}
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// CustomCommand is a slash command defined by a markdown file. The file's
+// body is the prompt sent to the model; optional YAML front matter sets the
+// description, argument hint, allowed tools and model.
+type CustomCommand struct {
+	name  string
+	scope CommandScope
+	path  string
+	meta  customCommandMeta
+	body  string
+}
+
+// ParseCustomCommand parses a custom command file.
+func ParseCustomCommand(name string, scope CommandScope, filePath string, data []byte) (*CustomCommand, error) {
+	cmd := &CustomCommand{name: name, scope: scope, path: filePath}
+
+	body := string(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n")))
+	if rest, ok := strings.CutPrefix(body, "---\n"); ok {
+		front, after, found := strings.Cut(rest, "\n---")
+		if !found {
+			return nil, fmt.Errorf("%s: unterminated front matter", filePath)
+		}
+		if err := yaml.Unmarshal([]byte(front), &cmd.meta); err != nil {
+			return nil, fmt.Errorf("%s: invalid front matter: %w", filePath, err)
+		}
+		// Drop the rest of the closing "---" line
+		if _, after, found = strings.Cut(after, "\n"); !found {
+			after = ""
+		}
+		body = after
+	}
+
+	for _, tool := range cmd.meta.AllowedTools {
+		if strings.ContainsAny(tool, "()") {
+			return nil, fmt.Errorf("%s: argument patterns in allowed-tools are not supported: %q", filePath, tool)
+		}
+		if _, err := path.Match(tool, ""); err != nil {
+			return nil, fmt.Errorf("%s: invalid allowed-tools pattern %q: %w", filePath, tool, err)
+		}
+	}
+
+	cmd.body = strings.TrimSpace(body)
+	if cmd.body == "" {
+		return nil, fmt.Errorf("%s: empty command", filePath)
+	}
+	return cmd, nil
+}
+
+func (c *CustomCommand) Name() string { return c.name }
+
+func (c *CustomCommand) Description() string {
+	desc := c.meta.Description
+	if desc == "" {
+		// Fall back to the first line of the prompt
+		desc, _, _ = strings.Cut(c.body, "\n")
+		desc = strings.TrimSpace(strings.TrimLeft(desc, "# "))
+		if len(desc) > 60 {
+			desc = desc[:57] + "..."
+		}
+	}
+	return fmt.Sprintf("%s (%s)", desc, c.scope)
+}
+
+func (c *CustomCommand) Usage() string {
+	if c.meta.ArgumentHint != "" {
+		return "/" + c.name + " " + c.meta.ArgumentHint
+	}
+	return "/" + c.name
+}
+
+func (c *CustomCommand) GetMetadata() CommandMetadata {
+	return CommandMetadata{
+		Category: CategoryCustom,
+		Icon:     "command",
+		Priority: 50,
+		HasArgs:  c.meta.ArgumentHint != "" || strings.Contains(c.body, "$"),
+		ArgHint:  c.meta.ArgumentHint,
+	}
+}
+
+// Scope returns where the command is defined.
+func (c *CustomCommand) Scope() CommandScope { return c.scope }
+
+// Path returns the command's markdown file.
+func (c *CustomCommand) Path() string { return c.path }
+
+func (c *CustomCommand) Execute(ctx context.Context, args []string, app AppInterface) (string, error) {
+	opts := PromptOptions{
+		AllowedTools: c.meta.AllowedTools,
+		Model:        c.meta.Model,
+		MentionText:  c.MentionText(args),
+	}
+	scoped, canScope := app.(ScopedPromptSubmitter)
+	submitter, canSubmit := app.(PromptSubmitter)
+	if !canScope && (!canSubmit || len(opts.AllowedTools) > 0 || opts.Model != "") {
+		return "", fmt.Errorf("custom commands are not supported in this mode")
+	}
+
+	var run func(ctx context.Context, command string) (string, error)
+	if runner, ok := app.(ShellRunner); ok {
+		run = func(ctx context.Context, command string) (string, error) {
+			return runner.RunShell(ctx, command, fmt.Sprintf("/%s custom command", c.name))
+		}
+	} else if inlineShellPattern.MatchString(c.body) {
+		return "", fmt.Errorf("custom commands that run shell commands are not supported in this mode")
+	}
+
+	prompt, err := c.Expand(ctx, args, run)
+	if err != nil {
+		return "", err
+	}
+
+	if canScope {
+		scoped.SubmitScopedPrompt(prompt, opts)
+	} else {
+		submitter.SubmitPrompt(prompt)
+	}
+	return fmt.Sprintf("Running /%s\n", c.name), nil
+}
+
+// Expand builds the prompt: each !`command` of the body is run by run and
+// replaced by its output, and arguments are substituted for $ARGUMENTS and $1..$9. Commands
+// come from the body alone, and arguments inside them are shell-quoted, so
+// argument text is never run. @mentions are attached when the prompt is
+// sent; see MentionText.
+func (c *CustomCommand) Expand(ctx context.Context, args []string, run func(ctx context.Context, command string) (string, error)) (string, error) {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range inlineShellPattern.FindAllStringSubmatchIndex(c.body, -1) {
+		sb.WriteString(substituteArgs(c.body[last:loc[0]], args, nil))
+		last = loc[1]
+
+		command := substituteArgs(c.body[loc[2]:loc[3]], args, shellQuote)
+		out, err := run(ctx, command)
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if err != nil {
+			out = fmt.Sprintf("%s\n(command `%s` failed: %s)", out, command, err)
+		}
+		sb.WriteString(out)
+	}
+	sb.WriteString(substituteArgs(c.body[last:], args, nil))
+	return sb.String(), nil
+}
+
+// substituteArgs replaces $ARGUMENTS and $1..$9 in text in a single pass,
+// so argument text is not substituted again. Arguments are passed through
+// quote when it is set; missing ones become empty.
+func substituteArgs(text string, args []string, quote func(string) string) string {
+	if quote == nil {
+		quote = func(s string) string { return s }
+	}
+	return argPattern.ReplaceAllStringFunc(text, func(m string) string {
+		if m == "$ARGUMENTS" {
+			quoted := make([]string, len(args))
+			for i, arg := range args {
+				quoted[i] = quote(arg)
+			}
+			return strings.Join(quoted, " ")
+		}
+		i, _ := strconv.Atoi(m[1:])
+		if i <= len(args) {
+			return quote(args[i-1])
+		}
+		return ""
+	})
+}
+
+// shellQuote quotes s as a single word for the bash tool.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// MentionText returns the text whose @mentions are attached to the prompt:
+// the body with its arguments but without command output, which must not
+// pull in files or URLs.
+func (c *CustomCommand) MentionText(args []string) string {
+	return substituteArgs(inlineShellPattern.ReplaceAllString(c.body, ""), args, nil)
+}
+
+// CustomCommandDirs returns the project and user directories searched for
+// custom commands.
+func CustomCommandDirs(workDir string) (project, user string) {
+	project = filepath.Join(workDir, ".gokin", "commands")
+	if configDir, err := appcontext.GetConfigDir(); err == nil {
+		user = filepath.Join(configDir, "commands")
+	}
+	return project, user
+}
+
+// LoadCustomCommands reads the markdown commands in dir. Commands in
+// subdirectories are named dir:name. Files that fail to parse are reported
+// and skipped; a missing dir yields no commands.
+func LoadCustomCommands(dir string, scope CommandScope) ([]*CustomCommand, []error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	var cmds []*CustomCommand
+	var errs []error
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == dir && os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			errs = append(errs, err)
+			return nil
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(p), ".md") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return nil
+		}
+		name := strings.TrimSuffix(filepath.ToSlash(rel), filepath.Ext(rel))
+		name = strings.ReplaceAll(strings.ReplaceAll(name, "/", ":"), " ", "-")
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		cmd, err := ParseCustomCommand(name, scope, p, data)
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		cmds = append(cmds, cmd)
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].name < cmds[j].name })
+	return cmds, errs
+}
+
+// RegisterCustomCommands loads the project's and the user's custom commands.
+// A command keeps its plain name unless a built-in or a project command of
+// the same name exists; it is then registered as scope:name. Project
+// commands take precedence over user commands. Errors are returned for the
+// caller to report; valid commands are registered regardless.
+func (h *Handler) RegisterCustomCommands(workDir string) []error {
+	projectDir, userDir := CustomCommandDirs(workDir)
+	project, errs := LoadCustomCommands(projectDir, ScopeProject)
+	user, userErrs := LoadCustomCommands(userDir, ScopeUser)
+	errs = append(errs, userErrs...)
+
+	for _, cmd := range append(project, user...) {
+		if _, taken := h.commands[cmd.name]; taken {
+			cmd.name = string(cmd.scope) + ":" + cmd.name
+			if _, taken := h.commands[cmd.name]; taken {
+				errs = append(errs, fmt.Errorf("%s: command /%s already exists", cmd.path, cmd.name))
+				continue
+			}
+		}
+		h.Register(cmd)
+	}
+	return errs
+}
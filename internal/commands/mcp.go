@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gokin/internal/mcp"
+)
+
+// PromptSubmitter is implemented by apps that can send a prompt to the model
+// after a command finishes.
+type PromptSubmitter interface {
+	SubmitPrompt(prompt string)
+}
+
+// MCPPromptCommand exposes a prompt template of an MCP server as /server:prompt.
+type MCPPromptCommand struct {
+	manager *mcp.Manager
+	server  string
+	prompt  *mcp.Prompt
+}
+
+// NewMCPPromptCommand creates a slash command for an MCP prompt.
+func NewMCPPromptCommand(manager *mcp.Manager, server string, prompt *mcp.Prompt) *MCPPromptCommand {
+	return &MCPPromptCommand{manager: manager, server: server, prompt: prompt}
+}
+
+func (c *MCPPromptCommand) Name() string {
+	return c.server + ":" + strings.ReplaceAll(c.prompt.Name, " ", "-")
+}
+
+func (c *MCPPromptCommand) Description() string {
+	if c.prompt.Description != "" {
+		return c.prompt.Description
+	}
+	return fmt.Sprintf("MCP prompt from %s", c.server)
+}
+
+func (c *MCPPromptCommand) Usage() string {
+	usage := "/" + c.Name()
+	if hint := c.argHint(); hint != "" {
+		usage += " " + hint
+	}
+	return usage
+}
+
+func (c *MCPPromptCommand) GetMetadata() CommandMetadata {
+	return CommandMetadata{
+		Category: CategoryTools,
+		Icon:     "command",
+		Priority: 60,
+		HasArgs:  len(c.prompt.Arguments) > 0,
+		ArgHint:  c.argHint(),
+	}
+}
+
+// argHint lists the prompt's arguments, optional ones in brackets.
+func (c *MCPPromptCommand) argHint() string {
+	var parts []string
+	for _, arg := range c.prompt.Arguments {
+		if arg.Required {
+			parts = append(parts, "<"+arg.Name+">")
+		} else {
+			parts = append(parts, "["+arg.Name+"]")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func (c *MCPPromptCommand) Execute(ctx context.Context, args []string, app AppInterface) (string, error) {
+	submitter, ok := app.(PromptSubmitter)
+	if !ok {
+		return "", fmt.Errorf("prompts are not supported in this mode")
+	}
+
+	values, err := c.parseArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := c.manager.GetPrompt(ctx, c.server, c.prompt.Name, values)
+	if err != nil {
+		return "", fmt.Errorf("failed to get prompt: %w", err)
+	}
+
+	text := mcp.FormatPromptMessages(result)
+	if strings.TrimSpace(text) == "" {
+		return "", fmt.Errorf("prompt %s returned no content", c.Name())
+	}
+
+	submitter.SubmitPrompt(text)
+	return fmt.Sprintf("Running MCP prompt /%s\n", c.Name()), nil
+}
+
+// parseArgs maps command arguments to prompt arguments. Arguments are either
+// name=value pairs or positional in declaration order; the last positional
+// argument takes the rest of the line.
+func (c *MCPPromptCommand) parseArgs(args []string) (map[string]string, error) {
+	values := make(map[string]string)
+	known := make(map[string]bool, len(c.prompt.Arguments))
+	for _, arg := range c.prompt.Arguments {
+		known[arg.Name] = true
+	}
+
+	var positional []string
+	for _, a := range args {
+		if name, value, ok := strings.Cut(a, "="); ok && known[name] {
+			values[name] = value
+			continue
+		}
+		positional = append(positional, a)
+	}
+
+	var free []string
+	for _, arg := range c.prompt.Arguments {
+		if _, ok := values[arg.Name]; !ok {
+			free = append(free, arg.Name)
+		}
+	}
+	for i, name := range free {
+		if len(positional) == 0 {
+			break
+		}
+		if i == len(free)-1 {
+			values[name] = strings.Join(positional, " ")
+			positional = nil
+			break
+		}
+		values[name] = positional[0]
+		positional = positional[1:]
+	}
+	if len(positional) > 0 {
+		return nil, fmt.Errorf("too many arguments\nUsage: %s", c.Usage())
+	}
+
+	for _, arg := range c.prompt.Arguments {
+		if arg.Required && values[arg.Name] == "" {
+			return nil, fmt.Errorf("missing argument %q\nUsage: %s", arg.Name, c.Usage())
+		}
+	}
+	return values, nil
+}
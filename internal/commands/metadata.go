@@ -12,6 +12,7 @@ const (
 	CategoryGit            CommandCategory = "git"
 	CategoryPlanning       CommandCategory = "planning"
 	CategoryTools          CommandCategory = "tools"
+	CategoryCustom         CommandCategory = "custom" // Markdown commands from .gokin/commands
 )
 
 // CategoryInfo contains display information for a category.
@@ -40,6 +41,7 @@ func GetAllCategories() []CategoryInfo {
 		categoryInfoMap[CategoryGit],
 		categoryInfoMap[CategoryPlanning],
 		categoryInfoMap[CategoryTools],
+		categoryInfoMap[CategoryCustom],
 	}
 }
 
@@ -50,6 +52,7 @@ var categoryInfoMap = map[CommandCategory]CategoryInfo{
 	CategoryGit:            {ID: CategoryGit, Name: "Git", Icon: "git", Priority: 3},
 	CategoryPlanning:       {ID: CategoryPlanning, Name: "Planning", Icon: "tree", Priority: 4},
 	CategoryTools:          {ID: CategoryTools, Name: "Tools", Icon: "gear", Priority: 5},
+	CategoryCustom:         {ID: CategoryCustom, Name: "Custom", Icon: "command", Priority: 6},
 }
 
 // CommandMetadata contains extended information about a command.
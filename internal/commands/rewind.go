@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gokin/internal/checkpoint"
+)
+
+// Rewinder is implemented by apps that checkpoint the working tree at every
+// user turn.
+type Rewinder interface {
+	ListCheckpoints(ctx context.Context) ([]checkpoint.Checkpoint, error)
+	CanRewindConversation(id string) bool
+	Rewind(ctx context.Context, id string, conversation bool) (*checkpoint.RestoreResult, error)
+}
+
+// maxRewindListed caps the checkpoints listed by /rewind.
+const maxRewindListed = 20
+
+// RewindCommand lists working tree checkpoints and restores files, and
+// optionally the conversation, to one of them.
+type RewindCommand struct{}
+
+func (c *RewindCommand) Name() string { return "rewind" }
+func (c *RewindCommand) Description() string {
+	return "Restore files (and the conversation) to a checkpoint"
+}
+func (c *RewindCommand) Usage() string { return "/rewind [n|id] [--conversation]" }
+func (c *RewindCommand) GetMetadata() CommandMetadata {
+	return CommandMetadata{
+		Category: CategorySession,
+		Icon:     "undo",
+		Priority: 25,
+		HasArgs:  true,
+		ArgHint:  "[n] [--conversation]",
+	}
+}
+
+func (c *RewindCommand) Execute(ctx context.Context, args []string, app AppInterface) (string, error) {
+	rewinder, ok := app.(Rewinder)
+	if !ok {
+		return "Checkpoints are not available in this context.", nil
+	}
+
+	checkpoints, err := rewinder.ListCheckpoints(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(checkpoints) == 0 {
+		return "No checkpoints yet. A checkpoint of the working tree is taken at every prompt.", nil
+	}
+
+	var target string
+	conversation := false
+	for _, arg := range args {
+		switch {
+		case arg == "--conversation" || arg == "-c":
+			conversation = true
+		case target == "":
+			target = arg
+		default:
+			return "", fmt.Errorf("usage: %s", c.Usage())
+		}
+	}
+	if target == "" {
+		return c.list(checkpoints, rewinder), nil
+	}
+
+	cp, err := findCheckpoint(checkpoints, target)
+	if err != nil {
+		return "", err
+	}
+	result, err := rewinder.Rewind(ctx, cp.ID, conversation)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "⏪ Rewound to checkpoint %s (%s)\n", cp.ShortID(), cp.Time.Format("Jan 2 15:04"))
+	fmt.Fprintf(&sb, "   Prompt: %s\n\n", firstLine(cp.Prompt, 70))
+	if len(result.Restored) == 0 && len(result.Removed) == 0 {
+		sb.WriteString("No files differed from the checkpoint.\n")
+	}
+	for _, path := range result.Restored {
+		fmt.Fprintf(&sb, "  restored  %s\n", path)
+	}
+	for _, path := range result.Removed {
+		fmt.Fprintf(&sb, "  removed   %s\n", path)
+	}
+	if conversation {
+		sb.WriteString("\nThe conversation was rewound to before this prompt.")
+	}
+	sb.WriteString("\nThe previous state was saved as a new checkpoint; /rewind 1 undoes this.")
+	return sb.String(), nil
+}
+
+// list formats the most recent checkpoints, newest first.
+func (c *RewindCommand) list(checkpoints []checkpoint.Checkpoint, rewinder Rewinder) string {
+	var sb strings.Builder
+	sb.WriteString("⏪ Checkpoints (newest first)\n")
+	sb.WriteString(strings.Repeat("─", 50))
+	sb.WriteString("\n\n")
+
+	for i, cp := range checkpoints {
+		if i == maxRewindListed {
+			fmt.Fprintf(&sb, "  ... %d older checkpoints\n", len(checkpoints)-i)
+			break
+		}
+		marker := " "
+		if rewinder.CanRewindConversation(cp.ID) {
+			marker = "💬"
+		}
+		fmt.Fprintf(&sb, "  %2d. %s  %s %s  %s\n", i+1, cp.ShortID(), cp.Time.Format("Jan 2 15:04"), marker, firstLine(cp.Prompt, 60))
+	}
+
+	sb.WriteString("\nEach checkpoint is the state of the files before its prompt.\n")
+	sb.WriteString("💬 marks checkpoints whose conversation can be rewound too.\n\n")
+	sb.WriteString("Usage: /rewind 3                  restore files\n")
+	sb.WriteString("       /rewind 3 --conversation   restore files and the conversation")
+	return sb.String()
+}
+
+// findCheckpoint looks up a checkpoint by its number in the list or by ID
+// prefix.
+func findCheckpoint(checkpoints []checkpoint.Checkpoint, target string) (*checkpoint.Checkpoint, error) {
+	if n, err := strconv.Atoi(target); err == nil && len(target) < 4 {
+		if n < 1 || n > len(checkpoints) {
+			return nil, fmt.Errorf("no checkpoint %d; there are %d", n, len(checkpoints))
+		}
+		return &checkpoints[n-1], nil
+	}
+
+	var found *checkpoint.Checkpoint
+	for i := range checkpoints {
+		if strings.HasPrefix(checkpoints[i].ID, target) {
+			if found != nil {
+				return nil, fmt.Errorf("checkpoint ID %q is ambiguous", target)
+			}
+			found = &checkpoints[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("checkpoint not found: %s", target)
+	}
+	return found, nil
+}
+
+// firstLine returns the first line of s, truncated to max runes.
+func firstLine(s string, max int) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i] + " …"
+	}
+	if runes := []rune(s); len(runes) > max {
+		s = string(runes[:max-1]) + "…"
+	}
+	return s
+}
@@ -55,6 +55,47 @@ func (c *SemanticStatsCommand) Execute(ctx context.Context, args []string, app A
 	sb.WriteString(fmt.Sprintf("  Cache Size:      %s\n", formatBytes(int64(stats.CacheSizeBytes))))
 	sb.WriteString(fmt.Sprintf("  Index Size:      %s\n\n", formatBytes(int64(stats.IndexSizeBytes))))
 
+	// Approximate nearest-neighbour index
+	sb.WriteString("🧭 ANN Index (HNSW)\n")
+	if !stats.ANNEnabled {
+		sb.WriteString("  Status:          disabled (full scan)\n\n")
+	} else {
+		status := "full scan"
+		if stats.ANNActive {
+			status = "active"
+		}
+		sb.WriteString(fmt.Sprintf("  Status:          %s (used from %d chunks)\n", status, stats.ANNMinChunks))
+		sb.WriteString(fmt.Sprintf("  Vectors:         %d (%d deleted, %d layers)\n", stats.ANNNodes, stats.ANNDeleted, stats.ANNLevels))
+		mapped := ""
+		if stats.ANNMapped {
+			mapped = " (memory-mapped)"
+		}
+		sb.WriteString(fmt.Sprintf("  Index Size:      %s%s\n", formatBytes(stats.ANNSizeBytes), mapped))
+		sb.WriteString(fmt.Sprintf("  Recall Settings: m=%d ef_construction=%d ef_search=%d\n\n",
+			stats.ANNM, stats.ANNEfConstruction, stats.ANNEfSearch))
+	}
+
+	// Query latency
+	sb.WriteString("⏱️  Query Latency\n")
+	if stats.Queries.Count == 0 {
+		sb.WriteString("  Queries:         none yet\n\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("  Queries:         %d (%d via ANN)\n", stats.Queries.Count, stats.Queries.ANNCount))
+		sb.WriteString(fmt.Sprintf("  Average:         %s\n", formatLatency(stats.Queries.AvgLatency())))
+		sb.WriteString(fmt.Sprintf("  Last:            %s\n", formatLatency(stats.Queries.LastLatency)))
+		sb.WriteString(fmt.Sprintf("  Max:             %s\n\n", formatLatency(stats.Queries.MaxLatency)))
+	}
+
+	// Embeddings
+	sb.WriteString("🧬 Embeddings\n")
+	sb.WriteString(fmt.Sprintf("  Provider:        %s\n", stats.EmbeddingProvider))
+	sb.WriteString(fmt.Sprintf("  Model:           %s\n", stats.EmbeddingModel))
+	if stats.EmbeddingDimensions > 0 {
+		sb.WriteString(fmt.Sprintf("  Dimensions:      %d\n\n", stats.EmbeddingDimensions))
+	} else {
+		sb.WriteString("  Dimensions:      model default\n\n")
+	}
+
 	// Cache Performance
 	sb.WriteString("⚡ Cache Performance\n")
 	sb.WriteString(fmt.Sprintf("  Embeddings:      %d cached\n", stats.EmbeddingsCached))
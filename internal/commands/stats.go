@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	appcontext "gokin/internal/context"
 )
 
 // StatsCommand shows detailed session statistics.
@@ -45,13 +47,14 @@ func (c *StatsCommand) Execute(ctx context.Context, args []string, app AppInterf
 	sb.WriteString(fmt.Sprintf("  Output Tokens:    %s\n", formatNumber(int64(tokenStats.OutputTokens))))
 	sb.WriteString(fmt.Sprintf("  Total Tokens:     %s\n", formatNumber(int64(tokenStats.TotalTokens))))
 
-	// Calculate cost (rough estimation)
-	// Gemini 2.5 Flash: $0.075/1M input, $0.30/1M output
-	inputCost := float64(tokenStats.InputTokens) / 1_000_000 * 0.075
-	outputCost := float64(tokenStats.OutputTokens) / 1_000_000 * 0.30
-	totalCost := inputCost + outputCost
-
-	sb.WriteString(fmt.Sprintf("  Est. Cost:       $%.4f USD\n\n", totalCost))
+	// Estimated cost from API-reported usage, cache-aware
+	if cm := app.GetContextManager(); cm != nil && cm.GetTokenCounter() != nil {
+		api := tokenStats.API
+		cost := cm.GetTokenCounter().CalculateCost(api.InputTokens, api.OutputTokens, api.CacheCreationTokens, api.CacheReadTokens)
+		sb.WriteString(fmt.Sprintf("  Est. Cost:       %s USD\n\n", appcontext.FormatCost(cost.Total)))
+	} else {
+		sb.WriteString("\n")
+	}
 
 	// Model Info
 	sb.WriteString("🤖 Model\n")
@@ -99,7 +102,7 @@ func (c *StatsCommand) Execute(ctx context.Context, args []string, app AppInterf
 	// Footer
 	sb.WriteString(strings.Repeat("─", 50))
 	sb.WriteString("\n")
-	sb.WriteString("💡 Tip: Use /cost to see real-time token usage")
+	sb.WriteString("💡 Tip: Use /cost for a breakdown of cached and uncached input")
 
 	return sb.String(), nil
 }
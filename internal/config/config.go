@@ -21,13 +21,19 @@ type Config struct {
 	Cache   CacheConfig   `yaml:"cache"`
 	Watcher WatcherConfig `yaml:"watcher"`
 	DiffPreview   DiffPreviewConfig   `yaml:"diff_preview"`
+	Checkpoint    CheckpointConfig    `yaml:"checkpoint"`
+	LSP           LSPConfig           `yaml:"lsp"`
 	Semantic      SemanticConfig      `yaml:"semantic"`
 	Contract      ContractConfig      `yaml:"contract"`
 	MCP           MCPConfig           `yaml:"mcp"`
 	Update        UpdateConfig        `yaml:"update"`
+	Secrets       SecretsConfig       `yaml:"secrets"`
 
 	// Runtime version information
 	Version string `yaml:"-"`
+
+	// Runtime record/replay of model traffic (from environment or flags, never saved)
+	Cassette CassetteConfig `yaml:"-"`
 }
 
 // APIConfig holds API-related settings.
@@ -47,6 +53,10 @@ type APIConfig struct {
 	// Ollama server URL (default: http://localhost:11434)
 	OllamaBaseURL string `yaml:"ollama_base_url,omitempty"`
 
+	// OpenAI-compatible Chat Completions endpoints (vLLM, llama.cpp server,
+	// LM Studio, OpenRouter), keyed by provider name
+	OpenAICompatible map[string]OpenAICompatibleConfig `yaml:"openai_compatible,omitempty"`
+
 	// Active provider: gemini, glm, ollama (default: gemini)
 	ActiveProvider string `yaml:"active_provider"`
 
@@ -57,6 +67,15 @@ type APIConfig struct {
 	Retry RetryConfig `yaml:"retry"`
 }
 
+// OpenAICompatibleConfig describes a named provider speaking the OpenAI Chat Completions API.
+type OpenAICompatibleConfig struct {
+	BaseURL   string            `yaml:"base_url"`              // e.g. http://localhost:8000/v1
+	APIKey    string            `yaml:"api_key,omitempty"`     // Optional for local servers
+	APIKeyEnv string            `yaml:"api_key_env,omitempty"` // Environment variable holding the key
+	Headers   map[string]string `yaml:"headers,omitempty"`     // Extra request headers ($VARS are expanded)
+	Model     string            `yaml:"model,omitempty"`       // Model served when no other model is selected
+}
+
 // OAuthTokenConfig stores OAuth tokens in config
 type OAuthTokenConfig struct {
 	AccessToken  string `yaml:"access_token"`
@@ -101,6 +120,10 @@ func (c *APIConfig) GetActiveKey() string {
 	case "ollama":
 		// Ollama key is optional (local server doesn't need it)
 		return c.OllamaKey
+	default:
+		if p, ok := c.OpenAIProvider(provider); ok {
+			return p.APIKey
+		}
 	}
 
 	// Fallback to legacy APIKey field
@@ -132,7 +155,18 @@ func (c *APIConfig) HasProvider(provider string) bool {
 		// Ollama is always "available" since it doesn't require an API key
 		return true
 	}
-	return false
+	// OpenAI-compatible providers are available once their endpoint is configured
+	_, ok := c.OpenAIProvider(provider)
+	return ok
+}
+
+// OpenAIProvider returns the OpenAI-compatible provider with the given name.
+func (c *APIConfig) OpenAIProvider(name string) (OpenAICompatibleConfig, bool) {
+	p, ok := c.OpenAICompatible[name]
+	if !ok || p.BaseURL == "" {
+		return OpenAICompatibleConfig{}, false
+	}
+	return p, true
 }
 
 // SetProviderKey sets the API key for a specific provider.
@@ -146,6 +180,11 @@ func (c *APIConfig) SetProviderKey(provider, key string) {
 		c.DeepSeekKey = key
 	case "ollama":
 		c.OllamaKey = key
+	default:
+		if p, ok := c.OpenAICompatible[provider]; ok {
+			p.APIKey = key
+			c.OpenAICompatible[provider] = p
+		}
 	}
 }
 
@@ -174,6 +213,9 @@ type ModelConfig struct {
 	EnableThinking bool  `yaml:"enable_thinking"` // Enable extended thinking mode
 	ThinkingBudget int32 `yaml:"thinking_budget"` // Max tokens for thinking (0 = disabled)
 
+	// Prompt caching for Anthropic-compatible APIs (cache_control breakpoints)
+	PromptCaching bool `yaml:"prompt_caching"`
+
 	// Fallback providers to try when the primary provider fails
 	FallbackProviders []string `yaml:"fallback_providers"`
 
@@ -190,8 +232,9 @@ type ToolsConfig struct {
 
 // BashConfig holds bash tool settings.
 type BashConfig struct {
-	Sandbox         bool     `yaml:"sandbox"`
-	BlockedCommands []string `yaml:"blocked_commands"`
+	Sandbox               bool     `yaml:"sandbox"`
+	SandboxDisableNetwork bool     `yaml:"sandbox_disable_network"` // Run sandboxed commands in an empty network namespace (Linux)
+	BlockedCommands       []string `yaml:"blocked_commands"`
 }
 
 // UIConfig holds UI-related settings.
@@ -222,6 +265,16 @@ type PermissionConfig struct {
 	Enabled       bool              `yaml:"enabled"`        // Enable/disable permission system
 	DefaultPolicy string            `yaml:"default_policy"` // Default policy: "allow", "ask", "deny"
 	Rules         map[string]string `yaml:"rules"`          // Per-tool rules
+	Commands      map[string]string `yaml:"commands"`       // Per-command bash rules, e.g. "go test *": allow
+	Allow         []string          `yaml:"allow"`          // Rules run without asking, e.g. "bash(git diff:*)", "edit(src/**)"
+	Ask           []string          `yaml:"ask"`            // Rules that always ask
+	Deny          []string          `yaml:"deny"`           // Rules never run; they take precedence over all others
+
+	// Project holds the permission settings of the project's
+	// .gokin/config.yaml. Its rules are layered over the user's instead
+	// of replacing them.
+	Project     *PermissionConfig `yaml:"-"`
+	ProjectFile string            `yaml:"-"` // Path of the project config
 }
 
 // PlanConfig holds plan mode settings.
@@ -232,6 +285,7 @@ type PlanConfig struct {
 	ClearContext       bool          `yaml:"clear_context"`         // Clear context before plan execution
 	DelegateSteps      bool          `yaml:"delegate_steps"`        // Run each step in isolated sub-agent
 	AbortOnStepFailure bool          `yaml:"abort_on_step_failure"` // Stop plan on step failure
+	ParallelWorktrees  bool          `yaml:"parallel_worktrees"`    // Run parallel steps concurrently in git worktrees
 	PlanningTimeout    time.Duration `yaml:"planning_timeout"`      // Timeout for LLM plan generation
 	UseLLMExpansion    bool          `yaml:"use_llm_expansion"`     // Use LLM for dynamic plan expansion
 	Algorithm          string        `yaml:"algorithm"`             // Tree search algorithm: beam, mcts, astar
@@ -246,13 +300,16 @@ type HooksConfig struct {
 // HookConfig represents a single hook configuration.
 type HookConfig struct {
 	Name        string `yaml:"name"`          // Human-readable name
-	Type        string `yaml:"type"`          // Hook type: pre_tool, post_tool, on_error, on_start, on_exit
-	ToolName    string `yaml:"tool_name"`     // Tool to trigger on (empty = all)
+	Type        string `yaml:"type"`          // Hook type: pre_tool, post_tool, on_error, on_start, on_exit, user_prompt_submit, pre_compact, stop, subagent_stop, plan_step
+	ToolName    string `yaml:"tool_name"`     // Tool to trigger on: name, glob or /regex/ (empty = all)
 	Command     string `yaml:"command"`       // Shell command to execute
 	Enabled     bool   `yaml:"enabled"`       // Whether hook is active
 	Condition   string `yaml:"condition"`     // Condition: always, if_previous_success, if_previous_failure
 	FailOnError bool   `yaml:"fail_on_error"` // When true and hook fails, cancel tool execution
 	DependsOn   string `yaml:"depends_on"`    // Name of another hook that must complete first
+
+	// Argument patterns (glob or /regex/) keyed by argument name
+	MatchArgs map[string]string `yaml:"match_args,omitempty"`
 }
 
 // WebConfig holds web tool settings.
@@ -264,9 +321,11 @@ type WebConfig struct {
 
 // SessionConfig holds session persistence settings.
 type SessionConfig struct {
-	Enabled      bool          `yaml:"enabled"`       // Enable session persistence
-	SaveInterval time.Duration `yaml:"save_interval"` // Auto-save interval (default: 2m)
-	AutoLoad     bool          `yaml:"auto_load"`     // Auto-load last session on startup
+	Enabled           bool          `yaml:"enabled"`            // Enable session persistence
+	SaveInterval      time.Duration `yaml:"save_interval"`      // Auto-save interval (default: 2m)
+	AutoLoad          bool          `yaml:"auto_load"`          // Auto-load last session on startup
+	Archive           bool          `yaml:"archive"`            // Index saved sessions for /history and history_search
+	ArchiveEmbeddings bool          `yaml:"archive_embeddings"` // Also embed archived turns with the semantic search embedder
 }
 
 // MemoryConfig holds memory system settings.
@@ -316,19 +375,66 @@ type DiffPreviewConfig struct {
 	Enabled bool `yaml:"enabled"` // Enable/disable diff preview for write/edit operations
 }
 
+// CheckpointConfig holds working tree checkpoint settings.
+type CheckpointConfig struct {
+	Enabled        bool `yaml:"enabled"`         // Snapshot the working tree at every user turn
+	MaxCheckpoints int  `yaml:"max_checkpoints"` // Checkpoints kept per project
+}
+
+// SecretsConfig selects where API keys and OAuth tokens are kept.
+type SecretsConfig struct {
+	Backend string `yaml:"backend"` // auto, keyring, file or none (plain text in this file)
+}
+
+// LSPConfig holds language server settings.
+type LSPConfig struct {
+	Enabled            bool              `yaml:"enabled"`             // Enable language server tools
+	Diagnostics        bool              `yaml:"diagnostics"`         // Report new diagnostics after write/edit
+	DiagnosticsTimeout time.Duration     `yaml:"diagnostics_timeout"` // How long to wait for diagnostics after an edit
+	Servers            []LSPServerConfig `yaml:"servers,omitempty"`   // Overrides and additions to the built-in servers
+}
+
+// LSPServerConfig configures a language server. An entry with the language
+// of a built-in server replaces it; empty extensions and root markers are
+// taken from the built-in server.
+type LSPServerConfig struct {
+	Language              string         `yaml:"language"`                         // e.g. go, python, rust, typescript
+	Command               string         `yaml:"command"`                          // Server executable
+	Args                  []string       `yaml:"args,omitempty"`                   // Server arguments
+	Extensions            []string       `yaml:"extensions,omitempty"`             // File extensions handled, e.g. .go
+	RootMarkers           []string       `yaml:"root_markers,omitempty"`           // Files marking a project of this language
+	InitializationOptions map[string]any `yaml:"initialization_options,omitempty"` // Sent with initialize
+	Disabled              bool           `yaml:"disabled,omitempty"`               // Turn a built-in server off
+}
+
 // SemanticConfig holds semantic search settings.
 type SemanticConfig struct {
 	Enabled         bool          `yaml:"enabled"`          // Enable/disable semantic search
-	Model           string        `yaml:"model"`            // Embedding model (e.g., text-embedding-004)
+	Provider        string        `yaml:"provider"`         // Embedding provider: auto, gemini, ollama, local
+	Model           string        `yaml:"model"`            // Embedding model (e.g., text-embedding-004, nomic-embed-text)
+	Dimensions      int           `yaml:"dimensions"`       // Embedding size (0 = model default)
 	IndexOnStart    bool          `yaml:"index_on_start"`   // Index workspace on startup
 	MaxFileSize     int64         `yaml:"max_file_size"`    // Max file size to index (bytes)
 	CacheTTL        time.Duration `yaml:"cache_ttl"`        // Cache TTL for embeddings
 	TopK            int           `yaml:"top_k"`            // Default number of results
+	Hybrid          bool          `yaml:"hybrid"`           // Fuse BM25 keyword ranking with vector ranking
+	Rerank          bool          `yaml:"rerank"`           // Rerank results with the active LLM by default
+	RerankTopN      int           `yaml:"rerank_top_n"`     // Number of fused results the LLM reranks
 	ChunkSize       int           `yaml:"chunk_size"`       // Chunk size (characters)
 	ChunkOverlap    int           `yaml:"chunk_overlap"`    // Overlap between chunks
 	AutoCleanup     bool          `yaml:"auto_cleanup"`     // Auto-cleanup old projects
 	IndexPatterns   []string      `yaml:"index_patterns"`   // File patterns to index
 	ExcludePatterns []string      `yaml:"exclude_patterns"` // Patterns to exclude
+	ANN             ANNConfig     `yaml:"ann"`              // Approximate nearest-neighbour index
+}
+
+// ANNConfig holds settings of the on-disk HNSW index used by semantic search.
+type ANNConfig struct {
+	Enabled        bool `yaml:"enabled"`         // Use the HNSW index instead of a full scan
+	MinChunks      int  `yaml:"min_chunks"`      // Scan exactly below this many chunks
+	M              int  `yaml:"m"`               // Links per node (higher = better recall, more memory)
+	EfConstruction int  `yaml:"ef_construction"` // Candidate list size while building
+	EfSearch       int  `yaml:"ef_search"`       // Candidate list size while searching (recall vs latency)
 }
 
 // ContractConfig holds contract-driven development settings.
@@ -351,7 +457,7 @@ type MCPConfig struct {
 // MCPServerConfig holds configuration for a single MCP server.
 type MCPServerConfig struct {
 	Name        string            `yaml:"name"`                  // Unique identifier
-	Transport   string            `yaml:"transport"`             // "stdio" or "http"
+	Transport   string            `yaml:"transport"`             // "stdio", "http" or "sse"
 	Command     string            `yaml:"command,omitempty"`     // For stdio: command to run
 	Args        []string          `yaml:"args,omitempty"`        // For stdio: command arguments
 	Env         map[string]string `yaml:"env,omitempty"`         // Additional env vars (supports ${VAR})
@@ -362,6 +468,31 @@ type MCPServerConfig struct {
 	MaxRetries  int               `yaml:"max_retries,omitempty"` // Retry count
 	RetryDelay  time.Duration     `yaml:"retry_delay,omitempty"` // Between retries
 	ToolPrefix  string            `yaml:"tool_prefix,omitempty"` // Prefix for tool names
+
+	// OAuth settings for http servers; endpoints are discovered and the client
+	// is registered dynamically when client_id is empty
+	OAuth *MCPOAuthConfig `yaml:"oauth,omitempty"`
+}
+
+// MCPOAuthConfig holds OAuth client settings for an MCP server.
+type MCPOAuthConfig struct {
+	ClientID     string   `yaml:"client_id,omitempty"`
+	ClientSecret string   `yaml:"client_secret,omitempty"`
+	Scopes       []string `yaml:"scopes,omitempty"`
+	CallbackPort int      `yaml:"callback_port,omitempty"` // Local redirect port (default: any free port)
+}
+
+// CassetteConfig replaces or records the model for deterministic runs.
+// At most one of the paths may be set.
+type CassetteConfig struct {
+	Record string // Record live model traffic to this cassette file (GOKIN_RECORD)
+	Replay string // Answer from a recorded cassette instead of the API (GOKIN_REPLAY)
+	Mock   string // Answer from a scripted YAML conversation (GOKIN_MOCK)
+}
+
+// Offline reports whether the model is replaced and no API access is needed.
+func (c CassetteConfig) Offline() bool {
+	return c.Replay != "" || c.Mock != ""
 }
 
 // UpdateConfig holds self-update settings.
@@ -396,6 +527,7 @@ func DefaultConfig() *Config {
 			MaxOutputTokens: 8192,
 			EnableThinking:  false, // Disabled by default
 			ThinkingBudget:  0,     // 0 = disabled
+			PromptCaching:   true,  // Cache breakpoints on Anthropic-compatible APIs
 			MaxPoolSize:     5,     // Default pool size
 		},
 		Tools: ToolsConfig{
@@ -459,6 +591,7 @@ func DefaultConfig() *Config {
 			ClearContext:       true,  // Clear context before plan execution
 			DelegateSteps:      true,  // Run each step in isolated sub-agent
 			AbortOnStepFailure: false, // Continue by default on step failure
+			ParallelWorktrees:  false, // Run parallel steps one at a time
 			PlanningTimeout:    60 * time.Second,
 			UseLLMExpansion:    true,
 			Algorithm:          "beam", // Tree search algorithm: beam, mcts, astar
@@ -474,6 +607,7 @@ func DefaultConfig() *Config {
 			Enabled:      true,            // Enabled by default
 			SaveInterval: 2 * time.Minute, // Save every 2 minutes
 			AutoLoad:     true,            // Auto-load last session on startup
+			Archive:      true,            // Searchable archive of saved sessions
 		},
 		Memory: MemoryConfig{
 			Enabled:    true, // Enabled by default
@@ -508,13 +642,36 @@ func DefaultConfig() *Config {
 		DiffPreview: DiffPreviewConfig{
 			Enabled: true, // Enabled by default - show diff preview before write/edit
 		},
+		Checkpoint: CheckpointConfig{
+			Enabled:        true, // Snapshots live outside the project in a shadow repository
+			MaxCheckpoints: 50,
+		},
+		Secrets: SecretsConfig{
+			Backend: "auto", // OS keyring when available, encrypted file otherwise
+		},
+		LSP: LSPConfig{
+			Enabled:            true, // Servers start on first use and only if installed
+			Diagnostics:        true,
+			DiagnosticsTimeout: 3 * time.Second,
+		},
 		Semantic: SemanticConfig{
 			Enabled:      false,                // Disabled by default (requires API calls)
+			Provider:     "auto",               // Gemini if a key is set, else Ollama if active, else local
 			Model:        "text-embedding-004", // Default embedding model
 			IndexOnStart: false,                // Don't index on startup by default
 			MaxFileSize:  100 * 1024,           // 100KB max file size
 			CacheTTL:     24 * time.Hour,       // Cache embeddings for 24 hours
 			TopK:         10,                   // Return top 10 results
+			Hybrid:       true,                 // Combine keyword and vector search
+			Rerank:       false,                // LLM reranking costs a request per search
+			RerankTopN:   20,                   // Rerank the 20 best fused results
+			ANN: ANNConfig{
+				Enabled:        true,
+				MinChunks:      5000,
+				M:              16,
+				EfConstruction: 200,
+				EfSearch:       64,
+			},
 		},
 		Contract: ContractConfig{
 			Enabled:         true,
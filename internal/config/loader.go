@@ -1,6 +1,9 @@
 package config
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log/slog"
 	"os"
@@ -9,6 +12,8 @@ import (
 	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"gokin/internal/secrets"
 )
 
 // Load loads configuration from file and environment variables.
@@ -33,6 +38,9 @@ func Load() (*Config, error) {
 	// Merge per-project config if it exists
 	loadProjectConfig(cfg)
 
+	// Keys and tokens live in the secret store, not in the config file
+	openSecrets(cfg, configPath)
+
 	return cfg, nil
 }
 
@@ -45,7 +53,7 @@ func LoadWithProjectDir(projectDir string) (*Config, error) {
 
 	// Load project-specific config
 	projectConfigPath := filepath.Join(projectDir, ".gokin", "config.yaml")
-	if err := loadFromFile(cfg, projectConfigPath); err != nil {
+	if err := mergeProjectConfig(cfg, projectConfigPath); err != nil {
 		if !os.IsNotExist(err) {
 			return nil, fmt.Errorf("failed to load project config: %w", err)
 		}
@@ -66,7 +74,7 @@ func loadProjectConfig(cfg *Config) {
 		projectConfig := filepath.Join(dir, ".gokin", "config.yaml")
 		if _, err := os.Stat(projectConfig); err == nil {
 			// Found project config, merge it
-			if err := loadFromFile(cfg, projectConfig); err != nil {
+			if err := mergeProjectConfig(cfg, projectConfig); err != nil {
 				slog.Warn("failed to load project config", "path", projectConfig, "error", err)
 			}
 			return
@@ -80,6 +88,132 @@ func loadProjectConfig(cfg *Config) {
 	}
 }
 
+// mergeProjectConfig merges a project config file into cfg. The project's
+// allow, ask and deny permission rules are kept apart in
+// cfg.Permission.Project, so that they add to the user's rules.
+func mergeProjectConfig(cfg *Config, path string) error {
+	allow, ask, deny := cfg.Permission.Allow, cfg.Permission.Ask, cfg.Permission.Deny
+	if err := loadFromFile(cfg, path); err != nil {
+		return err
+	}
+	cfg.Permission.Allow, cfg.Permission.Ask, cfg.Permission.Deny = allow, ask, deny
+
+	var project struct {
+		Permission PermissionConfig `yaml:"permission"`
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal([]byte(expandSafeEnvVars(string(data))), &project); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	cfg.Permission.Project = &project.Permission
+	cfg.Permission.ProjectFile = path
+	return nil
+}
+
+// AddPermissionRule appends a rule to the allow, ask or deny permission
+// list of a config file, creating the file if needed. Comments and the
+// other settings in the file are kept.
+func AddPermissionRule(path, list, rule string) error {
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	permission, err := childNode(doc.Content[0], "permission", yaml.MappingNode)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	rules, err := childNode(permission, list, yaml.SequenceNode)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, node := range rules.Content {
+		if node.Value == rule {
+			return nil
+		}
+	}
+	rules.Content = append(rules.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: rule})
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return os.WriteFile(path, out.Bytes(), 0600)
+}
+
+// ProjectGrantsPath returns the file that keeps the permission rules granted
+// with "always allow" in the project at workDir. It lives in the user's
+// config directory and is named by a hash of workDir, so the rules only
+// apply to that project and are never committed with it.
+func ProjectGrantsPath(workDir string) string {
+	configPath := getConfigPath()
+	if configPath == "" {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(filepath.Clean(workDir)))
+	return filepath.Join(filepath.Dir(configPath), "grants", hex.EncodeToString(hash[:8])+".yaml")
+}
+
+// LoadProjectGrants returns the allow rules saved with AddPermissionRule in
+// a grants file. A missing file has none.
+func LoadProjectGrants(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var grants struct {
+		Permission PermissionConfig `yaml:"permission"`
+	}
+	if err := yaml.Unmarshal(data, &grants); err != nil {
+		return nil, fmt.Errorf("failed to parse grants file %s: %w", path, err)
+	}
+	return grants.Permission.Allow, nil
+}
+
+// childNode returns the value of key in a YAML mapping, adding it with the
+// given kind when it is missing or empty.
+func childNode(mapping *yaml.Node, key string, kind yaml.Kind) (*yaml.Node, error) {
+	if mapping.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected a mapping")
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != key {
+			continue
+		}
+		value := mapping.Content[i+1]
+		if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+			*value = yaml.Node{Kind: kind}
+		}
+		if value.Kind != kind {
+			return nil, fmt.Errorf("unexpected value for %s", key)
+		}
+		return value, nil
+	}
+	value := &yaml.Node{Kind: kind}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	return value, nil
+}
+
 // getConfigPath returns the path to the config file.
 func getConfigPath() string {
 	// Check XDG_CONFIG_HOME first
@@ -191,10 +325,19 @@ func loadFromEnv(cfg *Config) {
 	if backend := os.Getenv("GOKIN_BACKEND"); backend != "" {
 		cfg.API.Backend = backend
 	}
+
+	cfg.Cassette.Record = os.Getenv("GOKIN_RECORD")
+	cfg.Cassette.Replay = os.Getenv("GOKIN_REPLAY")
+	cfg.Cassette.Mock = os.Getenv("GOKIN_MOCK")
 }
 
 // Validate validates the configuration.
 func (c *Config) Validate() error {
+	// Replayed and scripted models need no credentials
+	if c.Cassette.Offline() {
+		return nil
+	}
+
 	// Check OAuth first
 	if c.API.HasOAuthToken("gemini") {
 		return nil
@@ -210,6 +353,11 @@ func (c *Config) Validate() error {
 		return nil
 	}
 
+	// OpenAI-compatible endpoints may run without a key (vLLM, llama.cpp, LM Studio)
+	if _, ok := c.API.OpenAIProvider(c.API.GetActiveProvider()); ok {
+		return nil
+	}
+
 	return ErrMissingAuth
 }
 
@@ -242,8 +390,20 @@ func (c *Config) Save() error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
+	// Secrets go to the secret store; the file keeps everything else. A
+	// store that cannot be written (e.g. no passphrase) does not keep the
+	// other settings from being saved, and the secrets are not written to
+	// the file in its place.
+	out := c
+	if store := secrets.Default(); store != nil {
+		if err := store.Update(c.API.secretValues()); err != nil {
+			slog.Warn("secrets not saved, they last for this session only", "store", store.Name(), "error", err)
+		}
+		out = c.withoutSecrets()
+	}
+
 	// Marshal config to YAML with proper ordering
-	data, err := yaml.Marshal(c)
+	data, err := yaml.Marshal(out)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -1,8 +1,14 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
+	"os"
 	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"gokin/internal/secrets"
 )
 
 // MigrateConfig migrates old configuration format to new format.
@@ -36,6 +42,101 @@ func MigrateConfig(cfg *Config) {
 	}
 }
 
+// MigrateSecrets moves API keys and OAuth tokens still kept in plain text
+// in the config file at path into the secret store, then removes them
+// from the file, keeping its other settings and comments. Values that
+// refer to environment variables are left in place. It returns the number
+// of secrets moved.
+func MigrateSecrets(path string, store *secrets.Store) (int, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return 0, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return 0, nil
+	}
+	api := findNode(doc.Content[0], "api", yaml.MappingNode)
+	if api == nil {
+		return 0, nil
+	}
+
+	moved := make(map[string]string)
+	take := func(mapping *yaml.Node, key, name string) {
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			value := mapping.Content[i+1]
+			if mapping.Content[i].Value != key || value.Kind != yaml.ScalarNode {
+				continue
+			}
+			if value.Value == "" || strings.Contains(value.Value, "${") {
+				return
+			}
+			moved[name] = value.Value
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+
+	for _, key := range secretKeys {
+		take(api, key, key)
+	}
+	if oauth := findNode(api, "gemini_oauth", yaml.MappingNode); oauth != nil {
+		take(oauth, "access_token", secretOAuthAccess)
+		take(oauth, "refresh_token", secretOAuthRefresh)
+	}
+	if providers := findNode(api, "openai_compatible", yaml.MappingNode); providers != nil {
+		for i := 0; i+1 < len(providers.Content); i += 2 {
+			if providers.Content[i+1].Kind == yaml.MappingNode {
+				take(providers.Content[i+1], "api_key", openAISecret(providers.Content[i].Value))
+			}
+		}
+	}
+	if len(moved) == 0 {
+		return 0, nil
+	}
+
+	// Store first: the file only loses the secrets once they are safe
+	if err := store.Update(moved); err != nil {
+		return 0, err
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return 0, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, out.Bytes(), 0600); err != nil {
+		return 0, fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return 0, fmt.Errorf("failed to write config file: %w", err)
+	}
+	return len(moved), nil
+}
+
+// findNode returns the value of key in a YAML mapping if it has the given
+// kind.
+func findNode(mapping *yaml.Node, key string, kind yaml.Kind) *yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key && mapping.Content[i+1].Kind == kind {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
 // DetectProvider determines the provider from model name.
 func DetectProvider(modelName string) string {
 	if modelName == "" {
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"log/slog"
+	"path/filepath"
+
+	"gokin/internal/secrets"
+)
+
+// Names of the OAuth tokens in the secret store. The other secrets are
+// named after their key under api:, e.g. "gemini_key" and
+// "openai_compatible.openrouter.api_key".
+const (
+	secretOAuthAccess  = "gemini_oauth.access_token"
+	secretOAuthRefresh = "gemini_oauth.refresh_token"
+)
+
+// secretKeys are the API keys under api: kept in the secret store.
+var secretKeys = []string{"api_key", "gemini_key", "glm_key", "deepseek_key", "ollama_key"}
+
+// openAISecret returns the secret store name of an OpenAI-compatible
+// provider's key.
+func openAISecret(provider string) string {
+	return "openai_compatible." + provider + ".api_key"
+}
+
+// keyFields returns pointers to the API keys by secret store name.
+func (c *APIConfig) keyFields() map[string]*string {
+	return map[string]*string{
+		"api_key":      &c.APIKey,
+		"gemini_key":   &c.GeminiKey,
+		"glm_key":      &c.GLMKey,
+		"deepseek_key": &c.DeepSeekKey,
+		"ollama_key":   &c.OllamaKey,
+	}
+}
+
+// secretValues returns every secret by secret store name, "" for those
+// not set.
+func (c *APIConfig) secretValues() map[string]string {
+	values := make(map[string]string)
+	for name, field := range c.keyFields() {
+		values[name] = *field
+	}
+	values[secretOAuthAccess], values[secretOAuthRefresh] = "", ""
+	if c.GeminiOAuth != nil {
+		values[secretOAuthAccess] = c.GeminiOAuth.AccessToken
+		values[secretOAuthRefresh] = c.GeminiOAuth.RefreshToken
+	}
+	for name, p := range c.OpenAICompatible {
+		values[openAISecret(name)] = p.APIKey
+	}
+	return values
+}
+
+// fillSecrets sets the secrets the config files do not have from the
+// store.
+func (c *APIConfig) fillSecrets(values map[string]string) {
+	for name, field := range c.keyFields() {
+		if *field == "" {
+			*field = values[name]
+		}
+	}
+
+	if refresh := values[secretOAuthRefresh]; refresh != "" {
+		if c.GeminiOAuth == nil {
+			c.GeminiOAuth = &OAuthTokenConfig{}
+		}
+		if c.GeminiOAuth.RefreshToken == "" {
+			c.GeminiOAuth.RefreshToken = refresh
+			c.GeminiOAuth.AccessToken = values[secretOAuthAccess]
+		}
+	}
+
+	for name, p := range c.OpenAICompatible {
+		if p.APIKey == "" && values[openAISecret(name)] != "" {
+			p.APIKey = values[openAISecret(name)]
+			c.OpenAICompatible[name] = p
+		}
+	}
+}
+
+// withoutSecrets returns a copy of the config with the secrets cleared,
+// for writing to the config file.
+func (c *Config) withoutSecrets() *Config {
+	out := *c
+	for _, field := range out.API.keyFields() {
+		*field = ""
+	}
+	if c.API.GeminiOAuth != nil {
+		oauth := *c.API.GeminiOAuth
+		oauth.AccessToken, oauth.RefreshToken = "", ""
+		out.API.GeminiOAuth = &oauth
+	}
+	if c.API.OpenAICompatible != nil {
+		out.API.OpenAICompatible = make(map[string]OpenAICompatibleConfig, len(c.API.OpenAICompatible))
+		for name, p := range c.API.OpenAICompatible {
+			p.APIKey = ""
+			out.API.OpenAICompatible[name] = p
+		}
+	}
+	return &out
+}
+
+// openSecrets opens the secret store next to the config file, moves the
+// secrets still in the file into it and fills in those the config lacks.
+// A store that cannot be opened as configured falls back to the
+// encrypted file, so secrets are only written in plain text with
+// backend "none".
+func openSecrets(cfg *Config, configPath string) {
+	if configPath == "" {
+		return
+	}
+	dir := filepath.Dir(configPath)
+
+	store, err := secrets.Open(cfg.Secrets.Backend, dir)
+	if err != nil {
+		slog.Warn("secret store unavailable, using the encrypted file", "backend", cfg.Secrets.Backend, "error", err)
+		store = secrets.NewStore(secrets.NewFileBackend(dir))
+	}
+	secrets.SetDefault(store)
+	if store == nil {
+		return
+	}
+
+	// The encrypted file may ask for its passphrase, but only here,
+	// before the UI takes over the terminal
+	if file, ok := store.Backend().(*secrets.FileBackend); ok {
+		file.SetInteractive(true)
+		defer file.SetInteractive(false)
+	}
+
+	if moved, err := MigrateSecrets(configPath, store); err != nil {
+		slog.Warn("failed to move secrets out of the config file", "path", configPath, "error", err)
+	} else if moved > 0 {
+		slog.Info("moved secrets from the config file to the secret store", "count", moved, "store", store.Name())
+	}
+
+	values, err := store.Values()
+	if err != nil {
+		slog.Warn("failed to read secret store", "store", store.Name(), "error", err)
+		return
+	}
+	cfg.API.fillSecrets(values)
+}
@@ -9,6 +9,7 @@ import (
 	"gokin/internal/chat"
 	"gokin/internal/client"
 	"gokin/internal/config"
+	"gokin/internal/hooks"
 	"gokin/internal/logging"
 
 	"google.golang.org/genai"
@@ -54,6 +55,9 @@ type ContextManager struct {
 
 	// Semaphore to limit concurrent async token count goroutines
 	tokenCountSem chan struct{}
+
+	// hooks runs pre_compact hooks before summarization (optional)
+	hooks *hooks.Manager
 }
 
 // NewContextManager creates a new context manager.
@@ -109,6 +113,31 @@ func NewContextManager(
 	}
 }
 
+// SetHooks sets the hooks manager consulted before compaction.
+func (m *ContextManager) SetHooks(hm *hooks.Manager) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = hm
+}
+
+// runPreCompactHooks runs pre_compact hooks and returns an error if one of
+// them blocks the compaction.
+func (m *ContextManager) runPreCompactHooks(ctx context.Context, trigger string, messageCount int) error {
+	m.mu.RLock()
+	hm := m.hooks
+	m.mu.RUnlock()
+	if hm == nil {
+		return nil
+	}
+
+	outcome := hm.RunPreCompact(ctx, trigger, messageCount)
+	if outcome.Denied {
+		logging.Info("compaction blocked by hook", "hook", outcome.DeniedBy, "trigger", trigger, "reason", outcome.Reason)
+		return fmt.Errorf("compaction blocked by hook %q: %s", outcome.DeniedBy, outcome.Reason)
+	}
+	return nil
+}
+
 // SetClient updates the underlying client for token counting and summarization.
 func (m *ContextManager) SetClient(c client.Client) {
 	m.mu.Lock()
@@ -320,6 +349,11 @@ func (m *ContextManager) backgroundOptimize(ctx context.Context) {
 
 // OptimizeContext optimizes the context by summarizing old messages.
 func (m *ContextManager) OptimizeContext(ctx context.Context) error {
+	return m.optimizeContext(ctx, "auto")
+}
+
+// optimizeContext summarizes old messages; trigger is reported to pre_compact hooks.
+func (m *ContextManager) optimizeContext(ctx context.Context, trigger string) error {
 	startTime := time.Now()
 
 	history := m.session.GetHistory()
@@ -336,6 +370,10 @@ func (m *ContextManager) OptimizeContext(ctx context.Context) error {
 		return nil
 	}
 
+	if err := m.runPreCompactHooks(ctx, trigger, len(history)); err != nil {
+		return err
+	}
+
 	// Check cache first
 	messageHash := HashMessages(plan.ToSummarize)
 	cachedSummary, found := m.summaryCache.Get(messageHash)
@@ -520,7 +558,7 @@ func (m *ContextManager) ForceSummarize(ctx context.Context) error {
 	if m.summarizer == nil {
 		return nil
 	}
-	return m.OptimizeContext(ctx)
+	return m.optimizeContext(ctx, "manual")
 }
 
 // trackKeyFiles extracts file paths from session changes to track critical files.
@@ -609,6 +647,10 @@ func (m *ContextManager) IncrementalCompact(ctx context.Context) error {
 		return nil
 	}
 
+	if err := m.runPreCompactHooks(ctx, "auto", len(history)); err != nil {
+		return err
+	}
+
 	// Summarize old messages
 	summary, err := m.summarizer.Summarize(ctx, oldMessages)
 	if err != nil {
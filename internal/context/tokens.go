@@ -46,9 +46,13 @@ var DefaultModelLimits = map[string]TokenLimits{
 }
 
 // ModelPricing defines the cost per 1M tokens in USD.
+// Cache prices of zero default to Anthropic's multipliers of the input price
+// (1.25x for cache writes, 0.1x for cache reads).
 type ModelPricing struct {
-	InputCostPer1M  float64
-	OutputCostPer1M float64
+	InputCostPer1M      float64
+	OutputCostPer1M     float64
+	CacheWriteCostPer1M float64
+	CacheReadCostPer1M  float64
 }
 
 // DefaultPricing provides cost estimation for known models.
@@ -61,6 +65,12 @@ var DefaultPricing = map[string]ModelPricing{
 	"gemini-3-flash":   {InputCostPer1M: 0.50, OutputCostPer1M: 3.00},
 	"gemini-3-pro":     {InputCostPer1M: 2.00, OutputCostPer1M: 12.00},
 	"glm-4":            {InputCostPer1M: 1.00, OutputCostPer1M: 1.00}, // Placeholder
+	"glm-4.7":          {InputCostPer1M: 0.60, OutputCostPer1M: 2.20, CacheWriteCostPer1M: 0.60, CacheReadCostPer1M: 0.11},
+	"deepseek":         {InputCostPer1M: 0.28, OutputCostPer1M: 0.42, CacheWriteCostPer1M: 0.28, CacheReadCostPer1M: 0.028},
+	"claude-opus-4":    {InputCostPer1M: 15.00, OutputCostPer1M: 75.00},
+	"claude-opus-4-5":  {InputCostPer1M: 5.00, OutputCostPer1M: 25.00},
+	"claude-sonnet-4":  {InputCostPer1M: 3.00, OutputCostPer1M: 15.00},
+	"claude-haiku-4":   {InputCostPer1M: 1.00, OutputCostPer1M: 5.00},
 }
 
 // TokenLimits defines token limits for a model.
@@ -234,22 +244,69 @@ func (t *TokenCounter) GetUsage(tokenCount int) TokenUsage {
 	}
 }
 
+// CostBreakdown itemizes the estimated USD cost of token usage.
+type CostBreakdown struct {
+	UncachedInputTokens int
+	CacheWriteTokens    int
+	CacheReadTokens     int
+	OutputTokens        int
+
+	UncachedInputCost float64
+	CacheWriteCost    float64
+	CacheReadCost     float64
+	OutputCost        float64
+	Total             float64
+}
+
 // CalculateCost estimates the USD cost for the given token usage.
-func (t *TokenCounter) CalculateCost(inputTokens, outputTokens int) float64 {
+// inputTokens is the full prompt size; cacheWriteTokens and cacheReadTokens
+// are the parts of it written to and served from the prompt cache.
+func (t *TokenCounter) CalculateCost(inputTokens, outputTokens, cacheWriteTokens, cacheReadTokens int) CostBreakdown {
+	pricing := t.GetPricing()
+
+	b := CostBreakdown{
+		UncachedInputTokens: max(inputTokens-cacheWriteTokens-cacheReadTokens, 0),
+		CacheWriteTokens:    cacheWriteTokens,
+		CacheReadTokens:     cacheReadTokens,
+		OutputTokens:        outputTokens,
+	}
+	b.UncachedInputCost = (float64(b.UncachedInputTokens) / 1000000.0) * pricing.InputCostPer1M
+	b.CacheWriteCost = (float64(b.CacheWriteTokens) / 1000000.0) * pricing.CacheWriteCostPer1M
+	b.CacheReadCost = (float64(b.CacheReadTokens) / 1000000.0) * pricing.CacheReadCostPer1M
+	b.OutputCost = (float64(b.OutputTokens) / 1000000.0) * pricing.OutputCostPer1M
+	b.Total = b.UncachedInputCost + b.CacheWriteCost + b.CacheReadCost + b.OutputCost
+	return b
+}
+
+// GetPricing returns the pricing used for the counter's model,
+// with cache prices filled in from the input price when unset.
+func (t *TokenCounter) GetPricing() ModelPricing {
+	t.mu.RLock()
 	pricing := getPricing(t.model)
-	inputCost := (float64(inputTokens) / 1000000.0) * pricing.InputCostPer1M
-	outputCost := (float64(outputTokens) / 1000000.0) * pricing.OutputCostPer1M
-	return inputCost + outputCost
+	t.mu.RUnlock()
+
+	if pricing.CacheWriteCostPer1M == 0 {
+		pricing.CacheWriteCostPer1M = pricing.InputCostPer1M * 1.25
+	}
+	if pricing.CacheReadCostPer1M == 0 {
+		pricing.CacheReadCostPer1M = pricing.InputCostPer1M * 0.1
+	}
+	return pricing
 }
 
 // getPricing returns pricing for a model, with fallback defaults.
+// The longest matching key wins, so "glm-4.7" takes precedence over "glm-4".
 func getPricing(model string) ModelPricing {
 	modelLower := strings.ToLower(model)
-	for key, pricing := range DefaultPricing {
-		if strings.Contains(modelLower, key) {
-			return pricing
+	bestKey := ""
+	for key := range DefaultPricing {
+		if strings.Contains(modelLower, key) && len(key) > len(bestKey) {
+			bestKey = key
 		}
 	}
+	if bestKey != "" {
+		return DefaultPricing[bestKey]
+	}
 	// Default to Flash-like pricing for unknown models
 	return DefaultPricing["gemini-1.5-flash"]
 }
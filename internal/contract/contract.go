@@ -0,0 +1,240 @@
+package contract
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Status represents the lifecycle state of a contract.
+type Status int
+
+const (
+	StatusProposed   Status = iota // Waiting for user approval
+	StatusActive                   // Approved, implementation in progress
+	StatusVerified                 // All verifiable clauses passed
+	StatusFailed                   // Last verification had failing clauses
+	StatusRejected                 // Rejected by the user
+	StatusSuperseded               // Replaced by a newer contract
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusProposed:
+		return "proposed"
+	case StatusActive:
+		return "active"
+	case StatusVerified:
+		return "verified"
+	case StatusFailed:
+		return "failed"
+	case StatusRejected:
+		return "rejected"
+	case StatusSuperseded:
+		return "superseded"
+	default:
+		return "unknown"
+	}
+}
+
+// IsOpen returns true if the contract still governs the work (approved, not yet verified).
+func (s Status) IsOpen() bool {
+	return s == StatusActive || s == StatusFailed
+}
+
+// ClauseKind identifies the role of a clause in a contract.
+type ClauseKind string
+
+const (
+	KindPrecondition  ClauseKind = "precondition"
+	KindPostcondition ClauseKind = "postcondition"
+	KindInvariant     ClauseKind = "invariant"
+	KindExample       ClauseKind = "example"
+)
+
+// Match types for comparing command output with the expected output.
+const (
+	MatchContains = "contains"
+	MatchExact    = "exact"
+	MatchRegex    = "regex"
+	MatchExitCode = "exit_code" // Only the exit code matters (default when no expected output)
+)
+
+// Clause is a single condition of a contract. Clauses with a Command are
+// verified by running it; the others are informational for the model.
+type Clause struct {
+	Kind           ClauseKind `json:"kind"`
+	Name           string     `json:"name"`
+	Description    string     `json:"description,omitempty"`
+	Command        string     `json:"command,omitempty"`
+	ExpectedOutput string     `json:"expected_output,omitempty"`
+	MatchType      string     `json:"match_type,omitempty"`
+}
+
+// Verifiable returns true if the clause can be checked automatically.
+func (c Clause) Verifiable() bool {
+	return strings.TrimSpace(c.Command) != ""
+}
+
+// Boundary describes an input, output or side effect of the change.
+type Boundary struct {
+	Type        string `json:"type"` // input, output, side_effect, ...
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Contract is an agreement between the user and the model about what a change
+// must do: its boundaries and the conditions that must hold afterwards.
+type Contract struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Intent     string     `json:"intent"`
+	Boundaries []Boundary `json:"boundaries,omitempty"`
+	Clauses    []Clause   `json:"clauses"`
+	PlanID     string     `json:"plan_id,omitempty"` // Plan this contract belongs to (if any)
+	Status     Status     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	LastVerification *VerificationReport `json:"last_verification,omitempty"`
+}
+
+// NewContract creates a new proposed contract.
+func NewContract(name, intent string) *Contract {
+	return &Contract{
+		ID:        fmt.Sprintf("contract_%d", time.Now().UnixNano()),
+		Name:      name,
+		Intent:    intent,
+		Status:    StatusProposed,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// ClausesOfKind returns the clauses of the given kind.
+func (c *Contract) ClausesOfKind(kind ClauseKind) []Clause {
+	var result []Clause
+	for _, clause := range c.Clauses {
+		if clause.Kind == kind {
+			result = append(result, clause)
+		}
+	}
+	return result
+}
+
+// VerifiableCount returns the number of clauses with a verification command.
+func (c *Contract) VerifiableCount() int {
+	n := 0
+	for _, clause := range c.Clauses {
+		if clause.Verifiable() {
+			n++
+		}
+	}
+	return n
+}
+
+// Validate checks that the contract is well-formed.
+func (c *Contract) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return fmt.Errorf("contract name is required")
+	}
+	if len(c.Clauses) == 0 {
+		return fmt.Errorf("contract must have at least one precondition, postcondition, invariant or example")
+	}
+	for i, clause := range c.Clauses {
+		if strings.TrimSpace(clause.Name) == "" && strings.TrimSpace(clause.Description) == "" {
+			return fmt.Errorf("%s %d needs a name or description", clause.Kind, i+1)
+		}
+		switch clause.MatchType {
+		case "", MatchContains, MatchExact, MatchRegex, MatchExitCode:
+		default:
+			return fmt.Errorf("%s %q: unknown match_type %q", clause.Kind, clause.Name, clause.MatchType)
+		}
+	}
+	return nil
+}
+
+// Format renders the contract as text for prompts and status output.
+func (c *Contract) Format() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Contract: %s [%s]\n", c.Name, c.Status))
+	if c.Intent != "" {
+		sb.WriteString(fmt.Sprintf("Intent: %s\n", c.Intent))
+	}
+
+	if len(c.Boundaries) > 0 {
+		sb.WriteString("\nBoundaries:\n")
+		for _, b := range c.Boundaries {
+			sb.WriteString(fmt.Sprintf("  - %s %s", b.Type, b.Name))
+			if b.Description != "" {
+				sb.WriteString(": " + b.Description)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	sections := []struct {
+		kind  ClauseKind
+		title string
+	}{
+		{KindPrecondition, "Preconditions"},
+		{KindPostcondition, "Postconditions"},
+		{KindInvariant, "Invariants"},
+		{KindExample, "Examples"},
+	}
+	for _, section := range sections {
+		clauses := c.ClausesOfKind(section.kind)
+		if len(clauses) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n%s:\n", section.title))
+		for _, clause := range clauses {
+			sb.WriteString("  - " + clause.Summary())
+			if result := c.lastResult(clause); result != nil {
+				sb.WriteString(fmt.Sprintf(" [%s]", result.Status))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// Summary renders a single-line description of the clause.
+func (c Clause) Summary() string {
+	var sb strings.Builder
+	sb.WriteString(c.Name)
+	if c.Description != "" {
+		if c.Name != "" {
+			sb.WriteString(": ")
+		}
+		sb.WriteString(c.Description)
+	}
+	if c.Verifiable() {
+		sb.WriteString(fmt.Sprintf(" (verify: `%s`", c.Command))
+		if c.ExpectedOutput != "" {
+			matchType := c.MatchType
+			if matchType == "" {
+				matchType = MatchContains
+			}
+			sb.WriteString(fmt.Sprintf(", expect %s %q", matchType, c.ExpectedOutput))
+		}
+		sb.WriteString(")")
+	}
+	return sb.String()
+}
+
+// lastResult returns the result of the clause in the last verification, if any.
+func (c *Contract) lastResult(clause Clause) *ClauseResult {
+	if c.LastVerification == nil {
+		return nil
+	}
+	for i := range c.LastVerification.Results {
+		r := &c.LastVerification.Results[i]
+		if r.Kind == clause.Kind && r.Name == clause.Name {
+			return r
+		}
+	}
+	return nil
+}
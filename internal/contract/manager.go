@@ -0,0 +1,350 @@
+package contract
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gokin/internal/config"
+	"gokin/internal/logging"
+)
+
+// ApprovalDecision represents the user's decision on a proposed contract.
+type ApprovalDecision int
+
+const (
+	ApprovalPending ApprovalDecision = iota
+	ApprovalApproved
+	ApprovalRejected
+	ApprovalModified
+)
+
+// ApprovalHandler is called to get user approval for a contract.
+type ApprovalHandler func(ctx context.Context, c *Contract) (ApprovalDecision, error)
+
+// Manager manages the contract lifecycle: proposal, approval, persistence,
+// context injection and verification.
+type Manager struct {
+	config   config.ContractConfig
+	store    *Store
+	verifier *Verifier
+
+	active          *Contract // Approved contract governing the current work
+	restored        *Contract // Open contract of an earlier session, back to proposed
+	approvalHandler ApprovalHandler
+
+	mu sync.RWMutex
+}
+
+// NewManager creates a new contract manager for the given working directory.
+// Contracts left open by a previous session are set back to proposed: the
+// store is part of the project, so its clause commands only run after they
+// are approved again in this session.
+func NewManager(cfg config.ContractConfig, workDir string) *Manager {
+	store := NewStore(workDir, cfg.StorePath)
+
+	m := &Manager{
+		config:   cfg,
+		store:    store,
+		verifier: NewVerifier(workDir, cfg.VerifyTimeout),
+	}
+
+	if contracts, err := store.List(); err == nil {
+		for _, c := range contracts {
+			if !c.Status.IsOpen() {
+				continue
+			}
+			c.Status = StatusProposed
+			c.UpdatedAt = time.Now()
+			if err := store.Save(c); err != nil {
+				logging.Warn("failed to save restored contract", "id", c.ID, "error", err)
+			}
+			if m.restored == nil {
+				m.restored = c
+			}
+			logging.Debug("restored contract awaits approval", "id", c.ID, "name", c.Name)
+		}
+	}
+
+	return m
+}
+
+// SetShell sets the shell policy clause commands are checked and sandboxed
+// with.
+func (m *Manager) SetShell(shell Shell) {
+	m.verifier.SetShell(shell)
+}
+
+// SetApprovalHandler sets the handler for contract approval.
+func (m *Manager) SetApprovalHandler(handler ApprovalHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.approvalHandler = handler
+}
+
+// AutoDetect returns whether the model should propose contracts on its own.
+func (m *Manager) AutoDetect() bool {
+	return m.config.AutoDetect
+}
+
+// AutoVerify returns whether contracts are verified automatically after implementation.
+func (m *Manager) AutoVerify() bool {
+	return m.config.AutoVerify
+}
+
+// Propose validates and stores a new contract and asks the user to approve it.
+// An approved contract becomes active and supersedes the previous one.
+func (m *Manager) Propose(ctx context.Context, c *Contract) (ApprovalDecision, error) {
+	if err := c.Validate(); err != nil {
+		return ApprovalRejected, err
+	}
+
+	c.Status = StatusProposed
+	c.UpdatedAt = time.Now()
+	if err := m.store.Save(c); err != nil {
+		return ApprovalRejected, err
+	}
+
+	m.mu.RLock()
+	handler := m.approvalHandler
+	m.mu.RUnlock()
+
+	decision := ApprovalApproved
+	if m.config.RequireApproval && handler != nil {
+		var err error
+		decision, err = handler(ctx, c)
+		if err != nil {
+			_ = m.Reject(c.ID)
+			return ApprovalRejected, err
+		}
+	}
+
+	if decision == ApprovalApproved {
+		return decision, m.Activate(c.ID)
+	}
+	return decision, m.Reject(c.ID)
+}
+
+// Draft stores a proposed contract without asking for approval.
+// It is used when the contract is approved together with a plan.
+func (m *Manager) Draft(c *Contract) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	c.Status = StatusProposed
+	c.UpdatedAt = time.Now()
+	return m.store.Save(c)
+}
+
+// Activate makes a stored contract the active one.
+func (m *Manager) Activate(id string) error {
+	c, err := m.store.Load(id)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.active != nil && m.active.ID != c.ID && m.active.Status.IsOpen() {
+		m.active.Status = StatusSuperseded
+		m.active.UpdatedAt = time.Now()
+		if err := m.store.Save(m.active); err != nil {
+			logging.Warn("failed to save superseded contract", "id", m.active.ID, "error", err)
+		}
+	}
+
+	c.Status = StatusActive
+	c.UpdatedAt = time.Now()
+	m.active = c
+	return m.store.Save(c)
+}
+
+// Approve activates a proposed contract on the user's request, e.g. one
+// restored from an earlier session. Without an id the most recently
+// updated proposed contract is approved.
+func (m *Manager) Approve(id string) (*Contract, error) {
+	c, err := m.proposed(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Activate(c.ID); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	if m.restored != nil && m.restored.ID == c.ID {
+		m.restored = nil
+	}
+	m.mu.Unlock()
+	return m.Active(), nil
+}
+
+// RejectProposed rejects a proposed contract like Approve approves one.
+func (m *Manager) RejectProposed(id string) (*Contract, error) {
+	c, err := m.proposed(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Reject(c.ID); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	if m.restored != nil && m.restored.ID == c.ID {
+		m.restored = nil
+	}
+	m.mu.Unlock()
+	return c, nil
+}
+
+// Pending returns the most recently updated proposed contract, or nil.
+func (m *Manager) Pending() *Contract {
+	c, _ := m.proposed("")
+	return c
+}
+
+// Restored returns the contract set back to proposed at startup, or nil
+// once it was approved or rejected.
+func (m *Manager) Restored() *Contract {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.restored
+}
+
+// proposed returns the proposed contract with the given id, or the most
+// recently updated one when id is empty.
+func (m *Manager) proposed(id string) (*Contract, error) {
+	if id != "" {
+		c, err := m.store.Load(id)
+		if err != nil {
+			return nil, err
+		}
+		if c.Status != StatusProposed {
+			return nil, fmt.Errorf("contract %s is %s, not proposed", id, c.Status)
+		}
+		return c, nil
+	}
+
+	contracts, err := m.store.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range contracts {
+		if c.Status == StatusProposed {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("no proposed contract")
+}
+
+// Reject marks a stored contract as rejected.
+func (m *Manager) Reject(id string) error {
+	c, err := m.store.Load(id)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.active != nil && m.active.ID == id {
+		m.active = nil
+	}
+	c.Status = StatusRejected
+	c.UpdatedAt = time.Now()
+	return m.store.Save(c)
+}
+
+// Active returns the active contract, or nil if there is none.
+func (m *Manager) Active() *Contract {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.active
+}
+
+// Get returns a stored contract by ID.
+func (m *Manager) Get(id string) (*Contract, error) {
+	m.mu.RLock()
+	if m.active != nil && m.active.ID == id {
+		defer m.mu.RUnlock()
+		return m.active, nil
+	}
+	m.mu.RUnlock()
+	return m.store.Load(id)
+}
+
+// List returns stored contracts, most recently updated first.
+func (m *Manager) List() ([]*Contract, error) {
+	return m.store.List()
+}
+
+// Verify runs the verification commands of the active contract.
+// A passing contract is marked verified and stops being active; a failing one
+// stays active so the model keeps working against it.
+func (m *Manager) Verify(ctx context.Context) (*Contract, *VerificationReport, error) {
+	m.mu.RLock()
+	c := m.active
+	m.mu.RUnlock()
+
+	if c == nil {
+		return nil, nil, fmt.Errorf("no active contract")
+	}
+	if c.VerifiableCount() == 0 {
+		return c, nil, fmt.Errorf("contract %q has no verification commands", c.Name)
+	}
+
+	report := m.verifier.Verify(ctx, c)
+	if ctx.Err() != nil {
+		return c, report, ctx.Err()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c.LastVerification = report
+	c.UpdatedAt = time.Now()
+	if report.Passed() {
+		c.Status = StatusVerified
+		if m.active == c {
+			m.active = nil
+		}
+	} else {
+		c.Status = StatusFailed
+	}
+
+	if err := m.store.Save(c); err != nil {
+		logging.Warn("failed to save contract verification", "id", c.ID, "error", err)
+	}
+
+	return c, report, nil
+}
+
+// GetActiveContractContext returns the active contract formatted for the system prompt.
+func (m *Manager) GetActiveContractContext() string {
+	if !m.config.InjectContext {
+		return ""
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.active == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(m.active.Format())
+
+	if report := m.active.LastVerification; report != nil && !report.Passed() {
+		sb.WriteString("\nThe last verification failed:\n")
+		for _, res := range report.Results {
+			if res.Status == ResultFailed {
+				sb.WriteString(fmt.Sprintf("- %s %s: %s\n", res.Kind, res.Name, res.Error))
+			}
+		}
+		sb.WriteString("Fix the implementation, then run contract_verify.\n")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,140 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Store provides persistent storage for contracts.
+type Store struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewStore creates a new contract store.
+// storePath is resolved relative to workDir unless it is absolute.
+// The directory is created on the first save.
+func NewStore(workDir, storePath string) *Store {
+	if storePath == "" {
+		storePath = ".gokin/contracts/"
+	}
+	dir := storePath
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(workDir, dir)
+	}
+
+	return &Store{
+		dir: dir,
+	}
+}
+
+// Dir returns the directory contracts are stored in.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// checkID rejects contract IDs that would resolve outside the store
+// directory.
+func checkID(id string) error {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return fmt.Errorf("invalid contract id: %q", id)
+	}
+	return nil
+}
+
+// Save saves a contract to disk.
+func (s *Store) Save(c *Contract) error {
+	if c == nil {
+		return fmt.Errorf("cannot save nil contract")
+	}
+	if err := checkID(c.ID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal contract: %w", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create contracts directory: %w", err)
+	}
+
+	filePath := filepath.Join(s.dir, c.ID+".json")
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write contract: %w", err)
+	}
+
+	return nil
+}
+
+// Load loads a contract from disk by ID.
+func (s *Store) Load(id string) (*Contract, error) {
+	if err := checkID(id); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, err := os.ReadFile(filepath.Join(s.dir, id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("contract not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to read contract: %w", err)
+	}
+
+	var c Contract
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal contract: %w", err)
+	}
+
+	return &c, nil
+}
+
+// List returns all stored contracts, most recently updated first.
+func (s *Store) List() ([]*Contract, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read contracts directory: %w", err)
+	}
+
+	var contracts []*Contract
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		c := new(Contract)
+		if err := json.Unmarshal(data, c); err != nil {
+			continue
+		}
+		contracts = append(contracts, c)
+	}
+
+	sort.Slice(contracts, func(i, j int) bool {
+		return contracts[i].UpdatedAt.After(contracts[j].UpdatedAt)
+	})
+
+	return contracts, nil
+}
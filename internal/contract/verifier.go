@@ -0,0 +1,319 @@
+package contract
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gokin/internal/security"
+)
+
+// Clause result statuses.
+const (
+	ResultPassed     = "passed"
+	ResultFailed     = "failed"
+	ResultUnverified = "unverified" // No command to run
+)
+
+// maxResultOutput limits the command output kept per clause.
+const maxResultOutput = 2000
+
+// ClauseResult is the outcome of verifying a single clause.
+type ClauseResult struct {
+	Kind     ClauseKind    `json:"kind"`
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Command  string        `json:"command,omitempty"`
+	ExitCode int           `json:"exit_code"`
+	Output   string        `json:"output,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// VerificationReport is the outcome of verifying a contract.
+type VerificationReport struct {
+	ContractID string         `json:"contract_id"`
+	StartedAt  time.Time      `json:"started_at"`
+	Duration   time.Duration  `json:"duration"`
+	Results    []ClauseResult `json:"results"`
+}
+
+// Counts returns the number of passed, failed and unverified clauses.
+func (r *VerificationReport) Counts() (passed, failed, unverified int) {
+	for _, res := range r.Results {
+		switch res.Status {
+		case ResultPassed:
+			passed++
+		case ResultFailed:
+			failed++
+		default:
+			unverified++
+		}
+	}
+	return
+}
+
+// Passed returns true if no verifiable clause failed and at least one passed.
+func (r *VerificationReport) Passed() bool {
+	passed, failed, _ := r.Counts()
+	return failed == 0 && passed > 0
+}
+
+// Format renders the report with one line per clause.
+func (r *VerificationReport) Format() string {
+	var sb strings.Builder
+	passed, failed, unverified := r.Counts()
+	sb.WriteString(fmt.Sprintf("Verification: %d passed, %d failed, %d unverified (%s)\n",
+		passed, failed, unverified, r.Duration.Round(time.Millisecond)))
+
+	for _, res := range r.Results {
+		icon := "✓"
+		switch res.Status {
+		case ResultFailed:
+			icon = "✗"
+		case ResultUnverified:
+			icon = "○"
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s %s", icon, res.Kind, res.Name))
+		if res.Status == ResultFailed {
+			if res.Error != "" {
+				sb.WriteString(": " + res.Error)
+			}
+			if res.Output != "" {
+				sb.WriteString("\n      " + strings.ReplaceAll(strings.TrimSpace(res.Output), "\n", "\n      "))
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// Shell vets and sandboxes clause commands the way the bash tool does its
+// own, since the model writes both.
+type Shell interface {
+	// CheckCommand returns an error when the command must not run.
+	CheckCommand(command string) error
+	// SandboxConfig returns the sandbox commands run in; Enabled is false
+	// when sandboxing is off.
+	SandboxConfig() security.SandboxConfig
+}
+
+// Verifier runs the verification commands of a contract.
+type Verifier struct {
+	workDir string
+	timeout time.Duration
+	shell   Shell
+}
+
+// NewVerifier creates a verifier that runs commands in workDir.
+// timeout applies to each command (0 = 2 minutes).
+func NewVerifier(workDir string, timeout time.Duration) *Verifier {
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	return &Verifier{
+		workDir: workDir,
+		timeout: timeout,
+	}
+}
+
+// SetShell sets the shell policy for clause commands. Without one, commands
+// are still checked with the command validator but not sandboxed.
+func (v *Verifier) SetShell(shell Shell) {
+	v.shell = shell
+}
+
+// Verify checks every clause of the contract in order.
+func (v *Verifier) Verify(ctx context.Context, c *Contract) *VerificationReport {
+	report := &VerificationReport{
+		ContractID: c.ID,
+		StartedAt:  time.Now(),
+		Results:    make([]ClauseResult, 0, len(c.Clauses)),
+	}
+
+	for _, clause := range c.Clauses {
+		if ctx.Err() != nil {
+			break
+		}
+		report.Results = append(report.Results, v.verifyClause(ctx, clause))
+	}
+
+	report.Duration = time.Since(report.StartedAt)
+	return report
+}
+
+// verifyClause runs a single clause's command and matches its output.
+func (v *Verifier) verifyClause(ctx context.Context, clause Clause) ClauseResult {
+	result := ClauseResult{
+		Kind:    clause.Kind,
+		Name:    clause.Name,
+		Command: clause.Command,
+	}
+	if !clause.Verifiable() {
+		result.Status = ResultUnverified
+		return result
+	}
+
+	if err := v.checkCommand(clause.Command); err != nil {
+		result.Status = ResultFailed
+		result.ExitCode = -1
+		result.Error = fmt.Sprintf("blocked: %v", err)
+		return result
+	}
+
+	cmdCtx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	start := time.Now()
+	output, exitCode, err := v.run(cmdCtx, clause.Command)
+	result.Duration = time.Since(start)
+	result.Output = truncateOutput(output)
+	result.ExitCode = exitCode
+	if cmdCtx.Err() == context.DeadlineExceeded {
+		result.Status = ResultFailed
+		result.Error = fmt.Sprintf("timed out after %s", v.timeout)
+		return result
+	}
+	if result.ExitCode == -1 {
+		result.Status = ResultFailed
+		result.Error = fmt.Sprintf("failed to run: %v", err)
+		return result
+	}
+
+	if ok, reason := matchOutput(clause, output, result.ExitCode); ok {
+		result.Status = ResultPassed
+	} else {
+		result.Status = ResultFailed
+		result.Error = reason
+	}
+	return result
+}
+
+// checkCommand vets a clause command with the shell, or with the command
+// validator when there is none.
+func (v *Verifier) checkCommand(command string) error {
+	if v.shell != nil {
+		return v.shell.CheckCommand(command)
+	}
+	if result := security.ValidateCommand(command); !result.Valid {
+		return errors.New(result.Reason)
+	}
+	return nil
+}
+
+// run runs a command, in the shell's sandbox when it has one enabled, and
+// returns its combined output and exit code; -1 when it could not run.
+func (v *Verifier) run(ctx context.Context, command string) (string, int, error) {
+	if v.shell != nil {
+		if cfg := v.shell.SandboxConfig(); cfg.Enabled {
+			sandboxed, err := security.NewSandboxedCommand(ctx, v.workDir, command, cfg)
+			if err != nil {
+				return "", -1, err
+			}
+			res := sandboxed.Run(v.timeout)
+			output := string(res.Stdout) + string(res.Stderr)
+			if res.Error != nil {
+				return output, -1, res.Error
+			}
+			return output, res.ExitCode, nil
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "bash", "-c", command)
+	cmd.Dir = v.workDir
+	cmd.Env = verificationEnv()
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return output.String(), 0, nil
+	case errors.As(err, &exitErr):
+		return output.String(), exitErr.ExitCode(), nil
+	default:
+		return output.String(), -1, err
+	}
+}
+
+// matchOutput compares the command result with the clause's expectation.
+func matchOutput(clause Clause, output string, exitCode int) (bool, string) {
+	matchType := clause.MatchType
+	if matchType == "" {
+		if clause.ExpectedOutput == "" {
+			matchType = MatchExitCode
+		} else {
+			matchType = MatchContains
+		}
+	}
+
+	switch matchType {
+	case MatchExitCode:
+		want := 0
+		if clause.ExpectedOutput != "" {
+			n, err := strconv.Atoi(strings.TrimSpace(clause.ExpectedOutput))
+			if err != nil {
+				return false, fmt.Sprintf("invalid expected exit code %q", clause.ExpectedOutput)
+			}
+			want = n
+		}
+		if exitCode != want {
+			return false, fmt.Sprintf("exit code %d, expected %d", exitCode, want)
+		}
+		return true, ""
+
+	case MatchExact:
+		if strings.TrimSpace(output) != strings.TrimSpace(clause.ExpectedOutput) {
+			return false, "output does not match expected output exactly"
+		}
+		return true, ""
+
+	case MatchRegex:
+		re, err := regexp.Compile(clause.ExpectedOutput)
+		if err != nil {
+			return false, fmt.Sprintf("invalid regex: %v", err)
+		}
+		if !re.MatchString(output) {
+			return false, fmt.Sprintf("output does not match /%s/", clause.ExpectedOutput)
+		}
+		return true, ""
+
+	default: // MatchContains
+		if !strings.Contains(output, clause.ExpectedOutput) {
+			return false, fmt.Sprintf("output does not contain %q", clause.ExpectedOutput)
+		}
+		return true, ""
+	}
+}
+
+// verificationEnv returns the current environment without credentials.
+func verificationEnv() []string {
+	env := make([]string, 0, len(os.Environ()))
+	for _, kv := range os.Environ() {
+		name := strings.ToUpper(strings.SplitN(kv, "=", 2)[0])
+		if strings.Contains(name, "API_KEY") || strings.Contains(name, "TOKEN") ||
+			strings.Contains(name, "SECRET") || strings.Contains(name, "PASSWORD") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+// truncateOutput keeps the tail of long command output, where failures usually are.
+func truncateOutput(s string) string {
+	if len(s) <= maxResultOutput {
+		return s
+	}
+	return "..." + s[len(s)-maxResultOutput:]
+}
@@ -0,0 +1,235 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// worktreeDirName is the directory under the git common dir that holds
+	// gokin's worktrees, keeping them out of the project tree.
+	worktreeDirName = "gokin-worktrees"
+
+	// worktreeBranchPrefix prefixes the temporary branch of each worktree.
+	worktreeBranchPrefix = "gokin/"
+)
+
+// Worktree is a git worktree checked out on a temporary branch, used to
+// isolate an agent's edits from the main working tree.
+type Worktree struct {
+	RepoDir string // Top level of the main working tree
+	Path    string // Top level of the worktree checkout
+	Prefix  string // Work dir relative to the top level ("" or "sub/dir/")
+	Branch  string
+	Base    string // Commit the worktree was created from
+}
+
+// Dir returns the worktree counterpart of the work dir it was created for.
+func (w *Worktree) Dir() string {
+	return filepath.Join(w.Path, filepath.FromSlash(w.Prefix))
+}
+
+// Change is a file changed in a worktree relative to its base.
+type Change struct {
+	Path    string // Relative to the top level, slash separated
+	Status  FileStatus
+	Base    []byte // Content at the base; nil for added files
+	Content []byte // Content in the worktree; nil for deleted files
+}
+
+// CreateWorktree creates a worktree of the repository containing workDir
+// on a new branch gokin/<name>. The worktree starts from the current
+// working tree state: uncommitted changes to tracked files are included,
+// untracked files are not.
+func CreateWorktree(ctx context.Context, workDir, name string) (*Worktree, error) {
+	top, err := runGitIn(ctx, workDir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("not a git repository: %w", err)
+	}
+	prefix, _ := runGitIn(ctx, workDir, "rev-parse", "--show-prefix")
+	root, err := worktreeRoot(ctx, workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// "stash create" snapshots tracked changes without touching the tree;
+	// it prints nothing when the tree is clean.
+	base, err := runGitIn(ctx, top, "stash", "create")
+	if err != nil || base == "" {
+		if base, err = runGitIn(ctx, top, "rev-parse", "HEAD"); err != nil {
+			return nil, fmt.Errorf("repository has no commits: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	wt := &Worktree{
+		RepoDir: filepath.Clean(top),
+		Path:    filepath.Join(root, name),
+		Prefix:  prefix,
+		Branch:  worktreeBranchPrefix + name,
+		Base:    base,
+	}
+	if _, err := runGitIn(ctx, top, "worktree", "add", "--quiet", "-b", wt.Branch, wt.Path, base); err != nil {
+		return nil, fmt.Errorf("failed to create worktree: %w", err)
+	}
+	return wt, nil
+}
+
+// Changes returns the files added, modified or deleted in the worktree
+// since its base, including uncommitted and untracked (not ignored) files.
+func (w *Worktree) Changes(ctx context.Context) ([]Change, error) {
+	if _, err := runGitIn(ctx, w.Path, "add", "-A"); err != nil {
+		return nil, err
+	}
+	out, err := runGitIn(ctx, w.Path, "diff", "--cached", "--name-status", "-z", "--no-renames", w.Base)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Split(strings.TrimRight(out, "\x00"), "\x00")
+	var changes []Change
+	for i := 0; i+1 < len(fields); i += 2 {
+		c := Change{Status: FileStatus(fields[i][:1]), Path: fields[i+1]}
+		if c.Status != StatusAdded {
+			if c.Base, err = w.baseContent(ctx, c.Path); err != nil {
+				return nil, err
+			}
+		}
+		if c.Status != StatusDeleted {
+			if c.Content, err = os.ReadFile(filepath.Join(w.Path, filepath.FromSlash(c.Path))); err != nil {
+				return nil, err
+			}
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// baseContent returns a file's content at the worktree base.
+func (w *Worktree) baseContent(ctx context.Context, path string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "blob", w.Base+":"+path)
+	cmd.Dir = w.Path
+	return cmd.Output()
+}
+
+// Commit commits all changes in the worktree to its branch. It does
+// nothing when there is nothing to commit.
+func (w *Worktree) Commit(ctx context.Context, message string) error {
+	if _, err := runGitIn(ctx, w.Path, "add", "-A"); err != nil {
+		return err
+	}
+	if status, err := runGitIn(ctx, w.Path, "status", "--porcelain"); err != nil || status == "" {
+		return err
+	}
+
+	args := []string{"commit", "--quiet", "--no-verify", "-m", message}
+	// Commit even if no identity is configured
+	if email, _ := runGitIn(ctx, w.Path, "config", "user.email"); email == "" {
+		args = append([]string{"-c", "user.name=gokin", "-c", "user.email=gokin@localhost"}, args...)
+	}
+	_, err := runGitIn(ctx, w.Path, args...)
+	return err
+}
+
+// Remove deletes the worktree checkout and, unless keepBranch is set, its
+// branch.
+func (w *Worktree) Remove(ctx context.Context, keepBranch bool) error {
+	_, err := runGitIn(ctx, w.RepoDir, "worktree", "remove", "--force", w.Path)
+	if err != nil {
+		// Fall back for checkouts git no longer knows about
+		_ = os.RemoveAll(w.Path)
+		_, _ = runGitIn(ctx, w.RepoDir, "worktree", "prune")
+	}
+	if !keepBranch {
+		if _, branchErr := runGitIn(ctx, w.RepoDir, "branch", "-D", w.Branch); branchErr != nil && err == nil {
+			err = branchErr
+		}
+	}
+	return err
+}
+
+// Abandon commits any changes in the worktree to its branch and removes
+// the checkout. The branch is kept only if it has work on it; kept
+// reports whether it was.
+func (w *Worktree) Abandon(ctx context.Context, message string) (kept bool, err error) {
+	if err := w.Commit(ctx, message); err != nil {
+		// Keep the checkout rather than lose uncommitted work
+		return true, err
+	}
+	tip, err := runGitIn(ctx, w.Path, "rev-parse", "HEAD")
+	kept = err != nil || tip != w.Base
+	return kept, w.Remove(ctx, kept)
+}
+
+// MergeFile three-way merges the changes from base to current and from
+// base to other, like "git merge-file". Conflicting hunks are written with
+// conflict markers labelled currentLabel and otherLabel, and conflicts
+// reports whether there were any.
+func MergeFile(ctx context.Context, current, base, other []byte, currentLabel, otherLabel string) (merged []byte, conflicts bool, err error) {
+	dir, err := os.MkdirTemp("", "gokin-merge-")
+	if err != nil {
+		return nil, false, err
+	}
+	defer os.RemoveAll(dir)
+
+	paths := make([]string, 3)
+	for i, content := range [][]byte{current, base, other} {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("%d", i))
+		if err := os.WriteFile(paths[i], content, 0600); err != nil {
+			return nil, false, err
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "merge-file", "-p",
+		"-L", currentLabel, "-L", "base", "-L", otherLabel,
+		paths[0], paths[1], paths[2])
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	merged, err = cmd.Output()
+	if err != nil {
+		// A positive exit code below 128 is the number of conflicts
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 && exitErr.ExitCode() < 128 {
+			return merged, true, nil
+		}
+		return nil, false, fmt.Errorf("git merge-file: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return merged, false, nil
+}
+
+// worktreeRoot returns the directory holding gokin's worktrees.
+func worktreeRoot(ctx context.Context, workDir string) (string, error) {
+	common, err := runGitIn(ctx, workDir, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(workDir, common)
+	}
+	return filepath.Join(common, worktreeDirName), nil
+}
+
+// runGitIn runs git in dir and returns its trimmed output. Errors include
+// git's stderr.
+func runGitIn(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
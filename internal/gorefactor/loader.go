@@ -0,0 +1,497 @@
+// Package gorefactor implements type-checked refactorings for Go modules.
+package gorefactor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gokin/internal/logging"
+)
+
+// maxTypeErrors bounds the type errors kept per package.
+const maxTypeErrors = 20
+
+// Module is a Go module on disk.
+type Module struct {
+	Root string // Directory containing go.mod
+	Path string // Module path
+}
+
+// FindModule returns the module containing dir.
+func FindModule(dir string) (*Module, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for d := dir; ; {
+		modPath, err := readModulePath(filepath.Join(d, "go.mod"))
+		if err == nil {
+			return &Module{Root: d, Path: modPath}, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return nil, fmt.Errorf("no go.mod found above %s", dir)
+		}
+		d = parent
+	}
+}
+
+// readModulePath returns the module path declared in a go.mod file.
+func readModulePath(goMod string) (string, error) {
+	f, err := os.Open(goMod)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		rest, ok := strings.CutPrefix(line, "module")
+		if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '\t' && rest[0] != '"') {
+			continue
+		}
+		rest = strings.TrimSpace(rest)
+		if unquoted, err := strconv.Unquote(rest); err == nil {
+			rest = unquoted
+		}
+		return rest, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s has no module directive", goMod)
+}
+
+// pkgDir holds the Go files of one directory, split by package.
+type pkgDir struct {
+	importPath string
+	dir        string
+	name       string
+	goFiles    []string // Non-test files
+	testFiles  []string // In-package _test.go files
+	xtestFiles []string // External _test package files
+	imports    map[string]bool
+}
+
+// Package is one type-checked variant of a package: the package itself, the
+// package augmented with its in-package tests, or its external test package.
+type Package struct {
+	ImportPath string
+	Dir        string
+	Files      []*ast.File
+	Types      *types.Package
+	Info       *types.Info
+	Errors     []error
+	variant    string // "", "test" or "xtest"
+}
+
+// Program is a set of type-checked packages of one module sharing a file set.
+type Program struct {
+	Module   *Module
+	Fset     *token.FileSet
+	Packages []*Package // Every checked variant
+
+	ctx      context.Context
+	dirs     map[string]*pkgDir // import path -> directory
+	parsed   map[string]*ast.File
+	base     map[string]*Package // import path -> non-test variant
+	checked  map[string]bool     // import paths whose variants are all checked
+	checking map[string]bool
+	external types.ImporterFrom
+}
+
+// newProgram scans the module's directories and reads their imports.
+func newProgram(ctx context.Context, mod *Module) (*Program, error) {
+	p := &Program{
+		Module:   mod,
+		Fset:     token.NewFileSet(),
+		ctx:      ctx,
+		dirs:     make(map[string]*pkgDir),
+		parsed:   make(map[string]*ast.File),
+		base:     make(map[string]*Package),
+		checked:  make(map[string]bool),
+		checking: make(map[string]bool),
+	}
+	p.external = &externalImporter{fset: p.Fset, ctx: ctx, dir: mod.Root, deps: p.externalImports}
+
+	err := filepath.WalkDir(mod.Root, func(dir string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if dir != mod.Root {
+			name := d.Name()
+			if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+				name == "testdata" || name == "vendor" || name == "node_modules" {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+				return filepath.SkipDir // Nested module
+			}
+		}
+		pd, err := p.scanDir(dir)
+		if err != nil {
+			return err
+		}
+		if pd != nil {
+			p.dirs[pd.importPath] = pd
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// scanDir classifies the buildable Go files of dir. Returns nil if there are none.
+func (p *Program) scanDir(dir string) (*pkgDir, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	rel, err := filepath.Rel(p.Module.Root, dir)
+	if err != nil {
+		return nil, err
+	}
+	pd := &pkgDir{
+		importPath: p.Module.Path,
+		dir:        dir,
+		imports:    make(map[string]bool),
+	}
+	if rel != "." {
+		pd.importPath = path.Join(p.Module.Path, filepath.ToSlash(rel))
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		if ok, err := build.Default.MatchFile(dir, name); err != nil || !ok {
+			continue
+		}
+		filename := filepath.Join(dir, name)
+		f, err := p.parse(filename)
+		if err != nil {
+			continue
+		}
+
+		pkgName := f.Name.Name
+		isTest := strings.HasSuffix(name, "_test.go")
+		switch {
+		case isTest && strings.HasSuffix(pkgName, "_test"):
+			pd.xtestFiles = append(pd.xtestFiles, filename)
+		case pd.name != "" && pkgName != pd.name:
+			continue // Stray file of another package
+		case isTest:
+			pd.name = pkgName
+			pd.testFiles = append(pd.testFiles, filename)
+		default:
+			pd.name = pkgName
+			pd.goFiles = append(pd.goFiles, filename)
+		}
+		for _, spec := range f.Imports {
+			if importPath, err := strconv.Unquote(spec.Path.Value); err == nil {
+				pd.imports[importPath] = true
+			}
+		}
+	}
+
+	if len(pd.goFiles)+len(pd.testFiles)+len(pd.xtestFiles) == 0 {
+		return nil, nil
+	}
+	return pd, nil
+}
+
+// parse parses a file once; later calls return the same syntax tree so that
+// all package variants share identifier positions.
+func (p *Program) parse(filename string) (*ast.File, error) {
+	if f, ok := p.parsed[filename]; ok {
+		return f, nil
+	}
+	f, err := parser.ParseFile(p.Fset, filename, nil, parser.ParseComments|parser.SkipObjectResolution)
+	if f == nil {
+		return nil, err
+	}
+	p.parsed[filename] = f
+	return f, nil
+}
+
+// dirOf returns the package directory containing filename.
+func (p *Program) dirOf(filename string) *pkgDir {
+	dir := filepath.Dir(filename)
+	for _, pd := range p.dirs {
+		if pd.dir == dir {
+			return pd
+		}
+	}
+	return nil
+}
+
+// importers returns the import paths of packages that import any of paths,
+// directly or transitively, including paths themselves.
+func (p *Program) importers(paths ...string) []string {
+	seen := make(map[string]bool)
+	queue := append([]string(nil), paths...)
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if seen[next] {
+			continue
+		}
+		seen[next] = true
+		for importPath, pd := range p.dirs {
+			if !seen[importPath] && pd.imports[next] {
+				queue = append(queue, importPath)
+			}
+		}
+	}
+
+	result := make([]string, 0, len(seen))
+	for importPath := range seen {
+		if _, ok := p.dirs[importPath]; ok {
+			result = append(result, importPath)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// checkAll type-checks every variant of the given packages. Packages that are
+// already checked are skipped.
+func (p *Program) checkAll(paths []string) error {
+	for _, importPath := range paths {
+		if err := p.ctx.Err(); err != nil {
+			return err
+		}
+		pd := p.dirs[importPath]
+		if pd == nil || p.checked[importPath] {
+			continue
+		}
+		p.checked[importPath] = true
+		if len(pd.goFiles) > 0 {
+			if _, err := p.checkBase(importPath); err != nil {
+				return err
+			}
+		}
+		if len(pd.testFiles) > 0 {
+			p.check(pd, "test", append(append([]string(nil), pd.goFiles...), pd.testFiles...), nil)
+		}
+		if len(pd.xtestFiles) > 0 {
+			// The external test package sees the package with its in-package tests
+			override := p.base[importPath]
+			for _, pkg := range p.Packages {
+				if pkg.ImportPath == importPath && pkg.variant == "test" {
+					override = pkg
+				}
+			}
+			p.check(pd, "xtest", pd.xtestFiles, override)
+		}
+	}
+	return nil
+}
+
+// checkBase type-checks the non-test variant of a module package once.
+func (p *Program) checkBase(importPath string) (*Package, error) {
+	if pkg, ok := p.base[importPath]; ok {
+		return pkg, nil
+	}
+	if p.checking[importPath] {
+		return nil, fmt.Errorf("import cycle through %s", importPath)
+	}
+	p.checking[importPath] = true
+	defer delete(p.checking, importPath)
+
+	pd := p.dirs[importPath]
+	pkg := p.check(pd, "", pd.goFiles, nil)
+	p.base[importPath] = pkg
+	return pkg, nil
+}
+
+// check type-checks files as one package. If override is set, imports of its
+// path resolve to it instead of the non-test variant.
+func (p *Program) check(pd *pkgDir, variant string, filenames []string, override *Package) *Package {
+	pkg := &Package{
+		ImportPath: pd.importPath,
+		Dir:        pd.dir,
+		variant:    variant,
+		Info: &types.Info{
+			Types:      make(map[ast.Expr]types.TypeAndValue),
+			Defs:       make(map[*ast.Ident]types.Object),
+			Uses:       make(map[*ast.Ident]types.Object),
+			Implicits:  make(map[ast.Node]types.Object),
+			Selections: make(map[*ast.SelectorExpr]*types.Selection),
+			Scopes:     make(map[ast.Node]*types.Scope),
+		},
+	}
+	for _, filename := range filenames {
+		if f, err := p.parse(filename); err == nil {
+			pkg.Files = append(pkg.Files, f)
+		}
+	}
+
+	path := pd.importPath
+	if variant == "xtest" {
+		path += "_test"
+	}
+	conf := types.Config{
+		Importer: importerFunc(func(importPath, dir string, mode types.ImportMode) (*types.Package, error) {
+			if override != nil && importPath == override.ImportPath {
+				return override.Types, nil
+			}
+			if _, ok := p.dirs[importPath]; ok {
+				dep, err := p.checkBase(importPath)
+				if err != nil {
+					return nil, err
+				}
+				return dep.Types, nil
+			}
+			return p.external.ImportFrom(importPath, dir, mode)
+		}),
+		Error: func(err error) {
+			if len(pkg.Errors) < maxTypeErrors {
+				pkg.Errors = append(pkg.Errors, err)
+			}
+		},
+	}
+	// Errors are collected above; checking continues past them
+	pkg.Types, _ = conf.Check(path, p.Fset, pkg.Files, pkg.Info)
+
+	p.Packages = append(p.Packages, pkg)
+	return pkg
+}
+
+// packageOf returns the checked variant whose files include filename.
+func (p *Program) packageOf(filename string) *Package {
+	for _, pkg := range p.Packages {
+		for _, f := range pkg.Files {
+			if p.Fset.File(f.Pos()).Name() == filename {
+				return pkg
+			}
+		}
+	}
+	return nil
+}
+
+// fileOf returns the parsed file containing pos.
+func (p *Program) fileOf(pkg *Package, pos token.Pos) *ast.File {
+	for _, f := range pkg.Files {
+		if f.FileStart <= pos && pos <= f.FileEnd {
+			return f
+		}
+	}
+	return nil
+}
+
+// externalImporter imports packages outside the module from the compiler's
+// export data, located with a single "go list -export" of all dependencies.
+// If that fails, packages are type-checked from source instead, which is
+// much slower. One importer is used throughout so that types stay identical.
+type externalImporter struct {
+	fset *token.FileSet
+	ctx  context.Context
+	dir  string
+	deps func() []string
+	imp  types.ImporterFrom
+}
+
+func (e *externalImporter) Import(path string) (*types.Package, error) {
+	return e.ImportFrom(path, "", 0)
+}
+
+func (e *externalImporter) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	if e.imp == nil {
+		exports, err := e.listExports()
+		if err != nil {
+			logging.Debug("go list -export failed, importing dependencies from source", "error", err)
+			e.imp = importer.ForCompiler(e.fset, "source", nil).(types.ImporterFrom)
+		} else {
+			e.imp = importer.ForCompiler(e.fset, "gc", func(path string) (io.ReadCloser, error) {
+				file, ok := exports[path]
+				if !ok {
+					return nil, fmt.Errorf("no export data for %s", path)
+				}
+				return os.Open(file)
+			}).(types.ImporterFrom)
+		}
+	}
+	return e.imp.ImportFrom(path, dir, mode)
+}
+
+// listExports builds the dependencies and maps import paths to export data files.
+func (e *externalImporter) listExports() (map[string]string, error) {
+	args := append([]string{"list", "-e", "-export", "-deps", "-f", "{{if .Export}}{{.ImportPath}}={{.Export}}{{end}}"}, e.deps()...)
+	cmd := exec.CommandContext(e.ctx, "go", args...)
+	cmd.Dir = e.dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	exports := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		if path, file, ok := strings.Cut(strings.TrimSpace(line), "="); ok {
+			exports[path] = file
+		}
+	}
+	return exports, nil
+}
+
+// externalImports returns the imports of the module's packages that are not
+// part of the module.
+func (p *Program) externalImports() []string {
+	seen := make(map[string]bool)
+	for _, pd := range p.dirs {
+		for importPath := range pd.imports {
+			if _, ok := p.dirs[importPath]; !ok && importPath != "C" && importPath != "unsafe" {
+				seen[importPath] = true
+			}
+		}
+	}
+	result := make([]string, 0, len(seen))
+	for importPath := range seen {
+		result = append(result, importPath)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// importerFunc adapts a function to types.ImporterFrom.
+type importerFunc func(path, dir string, mode types.ImportMode) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path, "", 0)
+}
+
+func (f importerFunc) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	return f(path, dir, mode)
+}
@@ -0,0 +1,379 @@
+package gorefactor
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Target identifies a Go identifier by position, or by name within a file.
+type Target struct {
+	File   string
+	Line   int    // 1-based; 0 looks Name up among the file's top-level declarations
+	Column int    // 1-based byte offset in the line; 0 picks the first Name on Line
+	Name   string // Expected identifier name; optional when Line and Column are set
+}
+
+// Reference is an occurrence of an object in source.
+type Reference struct {
+	File   string
+	Line   int
+	Column int
+	Offset int
+	Text   string // Source line, trimmed
+	IsDecl bool
+
+	ident *ast.Ident
+	obj   types.Object
+	pkg   *Package
+}
+
+// Query is a resolved target object and its references.
+type Query struct {
+	Program *Program
+	Object  types.Object // The target as seen by the package of Target.File
+	Refs    []Reference
+
+	pkg  *Package
+	keys map[token.Pos]bool // Declaration positions of the objects referenced
+}
+
+// Describe returns a short description of the target, e.g. "method (*Server).Close".
+func (q *Query) Describe() string {
+	return describe(q.Object)
+}
+
+// Files returns the number of distinct files with references.
+func (q *Query) Files() int {
+	files := make(map[string]bool)
+	for _, ref := range q.Refs {
+		files[ref.File] = true
+	}
+	return len(files)
+}
+
+// TypeErrors returns type errors of the packages with references.
+func (q *Query) TypeErrors() []error {
+	seen := make(map[*Package]bool)
+	var errs []error
+	for _, ref := range q.Refs {
+		if !seen[ref.pkg] {
+			seen[ref.pkg] = true
+			errs = append(errs, ref.pkg.Errors...)
+		}
+	}
+	return errs
+}
+
+// FindReferences type-checks the module containing target.File and returns
+// every use of the object the target identifies, including its declaration
+// and uses in tests.
+func FindReferences(ctx context.Context, target Target) (*Query, error) {
+	q, err := resolve(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	if q.wide() {
+		if err := q.Program.checkAll(q.Program.importers(q.pkg.ImportPath)); err != nil {
+			return nil, err
+		}
+	}
+	q.keys = map[token.Pos]bool{q.Object.Pos(): true}
+	q.collect()
+	return q, nil
+}
+
+// resolve loads the package of target.File and finds the target object.
+func resolve(ctx context.Context, target Target) (*Query, error) {
+	filename, err := filepath.Abs(target.File)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(filename, ".go") {
+		return nil, fmt.Errorf("%s is not a Go file", target.File)
+	}
+	if _, err := os.Stat(filename); err != nil {
+		return nil, err
+	}
+	target.File = filename
+
+	mod, err := FindModule(filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+	prog, err := newProgram(ctx, mod)
+	if err != nil {
+		return nil, err
+	}
+	pd := prog.dirOf(filename)
+	if pd == nil {
+		return nil, fmt.Errorf("%s is not part of a package in module %s", target.File, mod.Path)
+	}
+	if err := prog.checkAll([]string{pd.importPath}); err != nil {
+		return nil, err
+	}
+
+	pkg := prog.packageOf(filename)
+	if pkg == nil {
+		return nil, fmt.Errorf("%s is excluded by build constraints", target.File)
+	}
+	ident, err := prog.locate(pkg, target)
+	if err != nil {
+		return nil, err
+	}
+	obj := objectOf(pkg, ident)
+	switch {
+	case obj == nil:
+		return nil, fmt.Errorf("%s does not denote a named entity (package clause or unresolved identifier)", ident.Name)
+	case obj.Pkg() == nil:
+		return nil, fmt.Errorf("%s is predeclared", ident.Name)
+	}
+	if _, ok := obj.(*types.PkgName); ok {
+		return nil, fmt.Errorf("%s is an imported package name", ident.Name)
+	}
+	if f := prog.Fset.File(obj.Pos()); f == nil || prog.parsed[f.Name()] == nil {
+		return nil, fmt.Errorf("%s is declared outside module %s", describe(obj), mod.Path)
+	}
+
+	return &Query{Program: prog, Object: obj, pkg: pkg}, nil
+}
+
+// wide reports whether other packages can refer to the target.
+func (q *Query) wide() bool {
+	obj := q.Object
+	return obj.Exported() && (obj.Parent() == nil || obj.Parent() == obj.Pkg().Scope())
+}
+
+// collect finds all identifiers denoting an object declared at one of q.keys.
+// Objects are matched by declaration position because every package variant
+// has its own objects for the same declaration.
+func (q *Query) collect() {
+	prog := q.Program
+	keys := q.keys
+
+	// Renaming a type renames fields that embed it; include their selectors
+	if _, ok := q.Object.(*types.TypeName); ok {
+		keys = make(map[token.Pos]bool, len(q.keys))
+		for pos := range q.keys {
+			keys[pos] = true
+		}
+		for _, pkg := range prog.Packages {
+			for ident, obj := range pkg.Info.Uses {
+				if v, ok := pkg.Info.Defs[ident].(*types.Var); ok && v.Embedded() && q.keys[obj.Pos()] {
+					keys[v.Pos()] = true
+				}
+			}
+		}
+	}
+
+	seen := make(map[token.Pos]bool)
+	add := func(pkg *Package, ident *ast.Ident, obj types.Object, isDecl bool) {
+		if obj == nil || !keys[obj.Pos()] || seen[ident.Pos()] {
+			return
+		}
+		seen[ident.Pos()] = true
+		pos := prog.Fset.Position(ident.Pos())
+		q.Refs = append(q.Refs, Reference{
+			File:   pos.Filename,
+			Line:   pos.Line,
+			Column: pos.Column,
+			Offset: pos.Offset,
+			IsDecl: isDecl,
+			ident:  ident,
+			obj:    obj,
+			pkg:    pkg,
+		})
+	}
+	for _, pkg := range prog.Packages {
+		for ident, obj := range pkg.Info.Defs {
+			add(pkg, ident, obj, true)
+		}
+		for ident, obj := range pkg.Info.Uses {
+			add(pkg, ident, obj, false)
+		}
+	}
+
+	sort.Slice(q.Refs, func(i, j int) bool {
+		a, b := q.Refs[i], q.Refs[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Offset < b.Offset
+	})
+
+	lines := make(map[string][]string)
+	for i := range q.Refs {
+		ref := &q.Refs[i]
+		fileLines, ok := lines[ref.File]
+		if !ok {
+			if data, err := os.ReadFile(ref.File); err == nil {
+				fileLines = strings.Split(string(data), "\n")
+			}
+			lines[ref.File] = fileLines
+		}
+		if ref.Line-1 < len(fileLines) {
+			ref.Text = strings.TrimSpace(fileLines[ref.Line-1])
+		}
+	}
+}
+
+// locate finds the identifier a target points at.
+func (p *Program) locate(pkg *Package, target Target) (*ast.Ident, error) {
+	var file *ast.File
+	for _, f := range pkg.Files {
+		if p.Fset.File(f.Pos()).Name() == target.File {
+			file = f
+			break
+		}
+	}
+	if file == nil {
+		return nil, fmt.Errorf("%s is not part of package %s", target.File, pkg.ImportPath)
+	}
+
+	if target.Line <= 0 {
+		return locateDecl(file, target.Name)
+	}
+
+	tf := p.Fset.File(file.Pos())
+	if target.Line > tf.LineCount() {
+		return nil, fmt.Errorf("line %d is past the end of %s (%d lines)", target.Line, target.File, tf.LineCount())
+	}
+	lineStart := tf.LineStart(target.Line)
+	lineEnd := file.FileEnd
+	if target.Line < tf.LineCount() {
+		lineEnd = tf.LineStart(target.Line + 1)
+	}
+
+	var found *ast.Ident
+	ast.Inspect(file, func(n ast.Node) bool {
+		if found != nil || n == nil || n.End() < lineStart || n.Pos() >= lineEnd {
+			return false
+		}
+		ident, ok := n.(*ast.Ident)
+		if !ok || ident.Pos() < lineStart {
+			return true
+		}
+		if target.Column > 0 {
+			pos := lineStart + token.Pos(target.Column-1)
+			if ident.Pos() <= pos && pos <= ident.End() && (target.Name == "" || ident.Name == target.Name) {
+				found = ident
+			}
+		} else if ident.Name == target.Name {
+			found = ident
+		}
+		return true
+	})
+
+	if found == nil {
+		if target.Column > 0 {
+			return nil, fmt.Errorf("no identifier %sat %s:%d:%d", quoteName(target.Name), target.File, target.Line, target.Column)
+		}
+		return nil, fmt.Errorf("no identifier %son %s:%d", quoteName(target.Name), target.File, target.Line)
+	}
+	return found, nil
+}
+
+// locateDecl finds the top-level declaration of name in file.
+func locateDecl(file *ast.File, name string) (*ast.Ident, error) {
+	if name == "" {
+		return nil, fmt.Errorf("a line or a name is required")
+	}
+
+	var matches []*ast.Ident
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Name.Name == name {
+				matches = append(matches, d.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.Name == name {
+						matches = append(matches, s.Name)
+					}
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						if n.Name == name {
+							matches = append(matches, n)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no top-level declaration of %s in file; pass the line and column of an occurrence", name)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("%s is declared %d times in file (methods of different types?); pass the line and column of the one to use", name, len(matches))
+	}
+}
+
+// objectOf returns the object an identifier defines or uses.
+func objectOf(pkg *Package, ident *ast.Ident) types.Object {
+	if obj := pkg.Info.Defs[ident]; obj != nil {
+		return obj
+	}
+	if obj := pkg.Info.Uses[ident]; obj != nil {
+		return obj
+	}
+	// The symbol of a type switch guard declares one implicit object per clause
+	for _, obj := range pkg.Info.Implicits {
+		if obj.Pos() == ident.Pos() {
+			return obj
+		}
+	}
+	return nil
+}
+
+// describe returns a short description of an object.
+func describe(obj types.Object) string {
+	switch o := obj.(type) {
+	case *types.Func:
+		if recv := o.Type().(*types.Signature).Recv(); recv != nil {
+			return fmt.Sprintf("method (%s).%s", types.TypeString(recv.Type(), relativeTo(o.Pkg())), o.Name())
+		}
+		return "func " + o.Name()
+	case *types.Var:
+		if o.IsField() {
+			return "field " + o.Name()
+		}
+		return "var " + o.Name()
+	case *types.Const:
+		return "const " + o.Name()
+	case *types.TypeName:
+		return "type " + o.Name()
+	case *types.Label:
+		return "label " + o.Name()
+	}
+	return obj.Name()
+}
+
+// relativeTo qualifies types outside pkg by package name.
+func relativeTo(pkg *types.Package) types.Qualifier {
+	return func(other *types.Package) string {
+		if other == pkg {
+			return ""
+		}
+		return other.Name()
+	}
+}
+
+// quoteName formats an optional name for error messages.
+func quoteName(name string) string {
+	if name == "" {
+		return ""
+	}
+	return fmt.Sprintf("%q ", name)
+}
@@ -0,0 +1,424 @@
+package gorefactor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileEdit is the rewritten content of one file.
+type FileEdit struct {
+	Path        string
+	OldContent  []byte
+	NewContent  []byte
+	Occurrences int
+}
+
+// RenameResult describes a computed rename. Nothing is written to disk.
+type RenameResult struct {
+	Query *Query
+	Edits []FileEdit
+}
+
+// Occurrences returns the number of renamed identifiers.
+func (r *RenameResult) Occurrences() int {
+	return len(r.Query.Refs)
+}
+
+// ConflictError reports why a rename would break or change the program.
+type ConflictError struct {
+	Conflicts []string
+}
+
+func (e *ConflictError) Error() string {
+	return "rename would conflict:\n  " + strings.Join(e.Conflicts, "\n  ")
+}
+
+// Rename computes the edits that rename the object at target to newName in
+// every package of the module, including tests. Methods are renamed together
+// with the interface methods they implement and vice versa. It returns a
+// *ConflictError if the new name would collide with or shadow other
+// declarations, or would break references.
+func Rename(ctx context.Context, target Target, newName string) (*RenameResult, error) {
+	if !token.IsIdentifier(newName) || newName == "_" {
+		return nil, fmt.Errorf("%q is not a valid Go identifier", newName)
+	}
+
+	q, err := resolve(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	obj := q.Object
+	if obj.Name() == newName {
+		return nil, fmt.Errorf("%s is already named %s", describe(obj), newName)
+	}
+	if v, ok := obj.(*types.Var); ok && v.Embedded() {
+		return nil, fmt.Errorf("%s is an embedded field; rename its type instead", obj.Name())
+	}
+	if obj.Parent() == obj.Pkg().Scope() {
+		if obj.Name() == "init" || (obj.Name() == "main" && obj.Pkg().Name() == "main") {
+			return nil, fmt.Errorf("%s cannot be renamed", describe(obj))
+		}
+		if newName == "init" || (newName == "main" && obj.Pkg().Name() == "main") {
+			return nil, fmt.Errorf("%s is reserved at package level", newName)
+		}
+	}
+
+	// Exported objects and the methods coupled to them through interfaces can
+	// be referenced from any package importing their declaring packages
+	prog := q.Program
+	objects := []types.Object{obj}
+	if q.wide() {
+		loaded := make(map[string]bool)
+		for {
+			var more []string
+			for _, o := range objects {
+				if path := o.Pkg().Path(); !loaded[path] {
+					loaded[path] = true
+					more = append(more, strings.TrimSuffix(path, "_test"))
+				}
+			}
+			if len(more) == 0 {
+				break
+			}
+			if err := prog.checkAll(prog.importers(more...)); err != nil {
+				return nil, err
+			}
+			objects = coupledObjects(prog, obj)
+		}
+	} else {
+		objects = coupledObjects(prog, obj)
+	}
+
+	q.keys = make(map[token.Pos]bool, len(objects))
+	var conflicts []string
+	for _, o := range objects {
+		if f := prog.Fset.File(o.Pos()); f == nil || prog.parsed[f.Name()] == nil {
+			conflicts = append(conflicts, fmt.Sprintf("%s must keep the name of %s, which is declared outside the module",
+				describe(obj), describe(o)))
+			continue
+		}
+		q.keys[o.Pos()] = true
+	}
+	q.collect()
+
+	conflicts = append(conflicts, checkConflicts(q, newName)...)
+	if len(conflicts) > 0 {
+		return nil, &ConflictError{Conflicts: dedupe(conflicts)}
+	}
+
+	edits, err := buildEdits(q, newName)
+	if err != nil {
+		return nil, err
+	}
+	return &RenameResult{Query: q, Edits: edits}, nil
+}
+
+// coupledObjects returns obj and, for methods, every method that must share
+// its name: interface methods it implements and implementations of interface
+// methods, transitively across the checked packages.
+func coupledObjects(prog *Program, obj types.Object) []types.Object {
+	fn, ok := obj.(*types.Func)
+	if !ok || fn.Type().(*types.Signature).Recv() == nil {
+		return []types.Object{obj}
+	}
+
+	var named []*types.Named
+	seenType := make(map[token.Pos]bool)
+	for _, pkg := range prog.Packages {
+		if pkg.Types == nil || pkg.variant == "xtest" {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || tn.IsAlias() || seenType[tn.Pos()] {
+				continue
+			}
+			if n, ok := tn.Type().(*types.Named); ok && n.TypeParams().Len() == 0 {
+				seenType[tn.Pos()] = true
+				named = append(named, n)
+			}
+		}
+	}
+
+	name := fn.Name()
+	result := []types.Object{fn}
+	seen := map[token.Pos]bool{fn.Pos(): true}
+	for i := 0; i < len(result); i++ {
+		m := result[i].(*types.Func)
+		recv := m.Type().(*types.Signature).Recv().Type()
+
+		if iface, ok := recv.Underlying().(*types.Interface); ok {
+			// Implementations of an interface method
+			for _, n := range named {
+				if types.IsInterface(n) {
+					continue
+				}
+				if !types.Implements(n, iface) && !types.Implements(types.NewPointer(n), iface) {
+					continue
+				}
+				if impl, ok := lookupMethod(n, m.Pkg(), name); ok && !seen[impl.Pos()] {
+					seen[impl.Pos()] = true
+					result = append(result, impl)
+				}
+			}
+			continue
+		}
+
+		// Interface methods implemented by a concrete method
+		if ptr, ok := recv.(*types.Pointer); ok {
+			recv = ptr.Elem()
+		}
+		for _, n := range named {
+			iface, ok := n.Underlying().(*types.Interface)
+			if !ok {
+				continue
+			}
+			decl, ok := lookupMethod(n, m.Pkg(), name)
+			if !ok || seen[decl.Pos()] {
+				continue
+			}
+			if types.Implements(recv, iface) || types.Implements(types.NewPointer(recv), iface) {
+				seen[decl.Pos()] = true
+				result = append(result, decl)
+			}
+		}
+	}
+	return result
+}
+
+// lookupMethod returns the method name of t, including promoted methods.
+func lookupMethod(t types.Type, pkg *types.Package, name string) (*types.Func, bool) {
+	obj, _, _ := types.LookupFieldOrMethod(t, true, pkg, name)
+	fn, ok := obj.(*types.Func)
+	return fn, ok
+}
+
+// checkConflicts reports declarations the new name would collide with,
+// references it would capture, and references it would break.
+func checkConflicts(q *Query, newName string) []string {
+	prog := q.Program
+	var conflicts []string
+	at := func(pos token.Pos) string {
+		p := prog.Fset.Position(pos)
+		return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+	}
+
+	for _, pkg := range prog.Packages {
+		if pkg.Types == nil {
+			continue
+		}
+		// The target objects as declared in this package variant
+		var decls []types.Object
+		for ident, obj := range pkg.Info.Defs {
+			if obj != nil && q.keys[obj.Pos()] && ident.Pos() == obj.Pos() {
+				decls = append(decls, obj)
+			}
+		}
+		for _, obj := range pkg.Info.Implicits {
+			if q.keys[obj.Pos()] {
+				decls = append(decls, obj)
+			}
+		}
+
+		for _, obj := range decls {
+			if obj.Parent() == nil {
+				conflicts = append(conflicts, memberConflicts(pkg, obj, newName, at)...)
+			} else {
+				conflicts = append(conflicts, scopeConflicts(pkg, obj, newName, at)...)
+			}
+		}
+
+		// Selectors whose receiver would find another field or method first
+		for sel, selection := range pkg.Info.Selections {
+			if !q.keys[selection.Obj().Pos()] {
+				continue
+			}
+			if other, _, _ := types.LookupFieldOrMethod(selection.Recv(), true, pkg.Types, newName); other != nil && !q.keys[other.Pos()] {
+				conflicts = append(conflicts, fmt.Sprintf("selector at %s would refer to %s declared at %s",
+					at(sel.Sel.Pos()), describe(other), at(other.Pos())))
+			}
+		}
+	}
+
+	// References from other packages need the name to stay exported
+	if token.IsExported(q.Object.Name()) && !token.IsExported(newName) {
+		for _, ref := range q.Refs {
+			declFile := prog.Fset.File(ref.obj.Pos()).Name()
+			if ref.pkg.variant == "xtest" || filepath.Dir(declFile) != ref.pkg.Dir {
+				conflicts = append(conflicts, fmt.Sprintf("%s would become unexported but is used from package %s at %s",
+					describe(q.Object), ref.pkg.Types.Path(), at(ref.ident.Pos())))
+			}
+		}
+	}
+	return conflicts
+}
+
+// memberConflicts checks a field or method against its siblings.
+func memberConflicts(pkg *Package, obj types.Object, newName string, at func(token.Pos) string) []string {
+	if fn, ok := obj.(*types.Func); ok {
+		recv := fn.Type().(*types.Signature).Recv().Type()
+		if other, _, _ := types.LookupFieldOrMethod(recv, true, pkg.Types, newName); other != nil {
+			return []string{fmt.Sprintf("%s already has %s declared at %s",
+				types.TypeString(recv, relativeTo(pkg.Types)), describe(other), at(other.Pos()))}
+		}
+		return nil
+	}
+
+	// Find the innermost struct declaring the field and the type it defines
+	var conflicts []string
+	for _, f := range pkg.Files {
+		var st *ast.StructType
+		var spec *ast.TypeSpec
+		ast.Inspect(f, func(n ast.Node) bool {
+			if n == nil || obj.Pos() < n.Pos() || obj.Pos() >= n.End() {
+				return false
+			}
+			switch n := n.(type) {
+			case *ast.StructType:
+				st = n
+			case *ast.TypeSpec:
+				spec = n
+			}
+			return true
+		})
+		if st == nil {
+			continue
+		}
+		if spec != nil && spec.Type == st {
+			if tn, ok := pkg.Info.Defs[spec.Name].(*types.TypeName); ok {
+				if other, _, _ := types.LookupFieldOrMethod(tn.Type(), true, pkg.Types, newName); other != nil {
+					conflicts = append(conflicts, fmt.Sprintf("type %s already has %s declared at %s", tn.Name(), describe(other), at(other.Pos())))
+					continue
+				}
+			}
+		}
+		if s, ok := pkg.Info.TypeOf(st).(*types.Struct); ok {
+			for i := 0; i < s.NumFields(); i++ {
+				if field := s.Field(i); field.Name() == newName {
+					conflicts = append(conflicts, fmt.Sprintf("struct already has field %s declared at %s", newName, at(field.Pos())))
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// scopeConflicts checks a scoped object for redeclaration in its scope,
+// references that an inner declaration would shadow, and references to an
+// outer declaration that the renamed object would capture.
+func scopeConflicts(pkg *Package, obj types.Object, newName string, at func(token.Pos) string) []string {
+	scope := obj.Parent()
+	pkgScope := pkg.Types.Scope()
+	var conflicts []string
+
+	if other := scope.Lookup(newName); other != nil {
+		conflicts = append(conflicts, fmt.Sprintf("%s is already declared in the same scope at %s", newName, at(other.Pos())))
+	}
+	if scope == pkgScope {
+		for _, f := range pkg.Files {
+			if fileScope := pkg.Info.Scopes[f]; fileScope != nil {
+				if other := fileScope.Lookup(newName); other != nil {
+					conflicts = append(conflicts, fmt.Sprintf("%s conflicts with the import at %s", newName, at(other.Pos())))
+				}
+			}
+		}
+	}
+
+	for ident, used := range pkg.Info.Uses {
+		switch {
+		case used.Pos() == obj.Pos():
+			// A reference to the target: no inner declaration of newName may hide it
+			for s := pkgScope.Innermost(ident.Pos()); s != nil && s != scope && s != pkgScope; s = s.Parent() {
+				if other := s.Lookup(newName); other != nil && (s.Parent() == pkgScope || other.Pos() < ident.Pos()) {
+					conflicts = append(conflicts, fmt.Sprintf("reference at %s would be shadowed by %s declared at %s",
+						at(ident.Pos()), describe(other), at(other.Pos())))
+					break
+				}
+			}
+		case ident.Name == newName && used.Parent() != nil && encloses(used.Parent(), scope):
+			// A reference to an outer newName inside the target's scope would be captured
+			inScope := scope == pkgScope || (scope.Contains(ident.Pos()) && ident.Pos() > obj.Pos())
+			if inScope {
+				conflicts = append(conflicts, fmt.Sprintf("renamed %s would shadow %s used at %s",
+					obj.Name(), describe(used), at(ident.Pos())))
+			}
+		}
+	}
+	return conflicts
+}
+
+// encloses reports whether outer is a proper ancestor of inner.
+func encloses(outer, inner *types.Scope) bool {
+	for s := inner.Parent(); s != nil; s = s.Parent() {
+		if s == outer {
+			return true
+		}
+	}
+	return false
+}
+
+// buildEdits rewrites every reference in its file.
+func buildEdits(q *Query, newName string) ([]FileEdit, error) {
+	oldName := q.Object.Name()
+	byFile := make(map[string][]Reference)
+	for _, ref := range q.Refs {
+		byFile[ref.File] = append(byFile[ref.File], ref)
+	}
+
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	edits := make([]FileEdit, 0, len(files))
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+		last := 0
+		for _, ref := range byFile[file] {
+			end := ref.Offset + len(oldName)
+			if end > len(content) || string(content[ref.Offset:end]) != oldName {
+				return nil, fmt.Errorf("%s changed while renaming; retry", file)
+			}
+			buf.Write(content[last:ref.Offset])
+			buf.WriteString(newName)
+			last = end
+		}
+		buf.Write(content[last:])
+
+		edits = append(edits, FileEdit{
+			Path:        file,
+			OldContent:  content,
+			NewContent:  buf.Bytes(),
+			Occurrences: len(byFile[file]),
+		})
+	}
+	return edits, nil
+}
+
+// dedupe removes repeated messages, keeping order.
+func dedupe(items []string) []string {
+	seen := make(map[string]bool, len(items))
+	result := items[:0]
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -3,6 +3,9 @@ package hooks
 import (
 	"os"
 	"strings"
+	"sync"
+
+	"gokin/internal/logging"
 )
 
 // Type represents when a hook should be triggered.
@@ -19,6 +22,21 @@ const (
 	OnStart Type = "on_start"
 	// OnExit runs when the application exits.
 	OnExit Type = "on_exit"
+	// UserPromptSubmit runs when the user submits a prompt. A deny blocks the
+	// prompt; additional context is appended to it.
+	UserPromptSubmit Type = "user_prompt_submit"
+	// PreCompact runs before the conversation is compacted. A deny skips the
+	// compaction.
+	PreCompact Type = "pre_compact"
+	// Stop runs when the main agent finishes a turn. A deny makes the agent
+	// continue, with the reason as its next instruction.
+	Stop Type = "stop"
+	// SubagentStop runs when a sub-agent finishes. A deny marks its result as
+	// failed. ToolName matches the agent type.
+	SubagentStop Type = "subagent_stop"
+	// PlanStep runs when a plan step starts, completes, fails or is skipped.
+	// A deny on completion fails the step.
+	PlanStep Type = "plan_step"
 )
 
 // Condition represents when a hook should run relative to previous results.
@@ -37,12 +55,21 @@ const (
 type Hook struct {
 	Name        string    `yaml:"name"`          // Human-readable name
 	Type        Type      `yaml:"type"`          // When to trigger
-	ToolName    string    `yaml:"tool_name"`     // Which tool triggers this (empty = all)
+	ToolName    string    `yaml:"tool_name"`     // Which tool triggers this: name, glob or /regex/ (empty = all)
 	Command     string    `yaml:"command"`       // Shell command to execute
 	Enabled     bool      `yaml:"enabled"`       // Whether hook is active
 	Condition   Condition `yaml:"condition"`     // Condition for running (always, if_previous_success, if_previous_failure)
 	FailOnError bool      `yaml:"fail_on_error"` // When true and hook fails, cancel tool execution
 	DependsOn   string    `yaml:"depends_on"`    // Name of another hook that must complete first
+
+	// MatchArgs restricts the hook to calls whose arguments match, keyed by
+	// argument name. Values are glob or /regex/ patterns like ToolName.
+	MatchArgs map[string]string `yaml:"match_args"`
+
+	compileOnce  sync.Once
+	toolPattern  *Pattern
+	argPatterns  map[string]*Pattern
+	patternError bool
 }
 
 // ShouldRun checks whether the hook should run given the context and completed hooks.
@@ -96,6 +123,14 @@ type Context struct {
 	Extra           map[string]string // Additional variables
 	previousSuccess bool              // Whether the previous tool call succeeded
 	CapturedOutput  string            // Stdout+stderr captured from last hook execution
+
+	Prompt         string     // Submitted prompt (user_prompt_submit only)
+	Trigger        string     // "manual" or "auto" (pre_compact only)
+	MessageCount   int        // Messages in the conversation (pre_compact only)
+	Response       string     // Final model response (stop only)
+	StopHookActive bool       // Whether the turn already continued because of a stop hook
+	Agent          *AgentInfo // Finished sub-agent (subagent_stop only)
+	Step           *StepInfo  // Plan step (plan_step only)
 }
 
 // NewContext creates a new hook context.
@@ -108,6 +143,37 @@ func NewContext(toolName string, args map[string]any, workDir string) *Context {
 	}
 }
 
+// event builds the JSON event for a hook.
+func (c *Context) event(hookType Type, hookName, sessionID string) Event {
+	return Event{
+		Event:      hookType,
+		HookName:   hookName,
+		SessionID:  sessionID,
+		WorkDir:    c.WorkDir,
+		ToolName:   c.ToolName,
+		ToolArgs:   c.ToolArgs,
+		ToolResult: c.ToolResult,
+		ToolError:  c.ToolError,
+
+		Prompt:         c.Prompt,
+		Trigger:        c.Trigger,
+		MessageCount:   c.MessageCount,
+		Response:       c.Response,
+		StopHookActive: c.StopHookActive,
+		Agent:          c.Agent,
+		Step:           c.Step,
+	}
+}
+
+// target returns the name a hook's tool_name pattern is matched against:
+// the agent type for subagent_stop, otherwise the tool name.
+func (c *Context) target() string {
+	if c.Agent != nil {
+		return c.Agent.Type
+	}
+	return c.ToolName
+}
+
 // SetResult sets the tool result for post-tool hooks.
 func (c *Context) SetResult(result string) {
 	c.ToolResult = result
@@ -137,6 +203,9 @@ func (c *Context) GetCapturedOutput() string {
 //   - ${WORK_DIR} - working directory
 //   - ${RESULT} - tool result (post_tool only)
 //   - ${ERROR} - error message (on_error only)
+//   - ${TRIGGER} - "manual" or "auto" (pre_compact only)
+//   - ${AGENT_TYPE}, ${AGENT_ID} - finished sub-agent (subagent_stop only)
+//   - ${STEP_ID}, ${STEP_STATUS} - plan step (plan_step only)
 //   - Any environment variable
 func (c *Context) ExpandCommand(command string) string {
 	result := command
@@ -198,9 +267,56 @@ func (h *Hook) Matches(hookType Type, toolName string) bool {
 		return false
 	}
 
+	h.compile()
+	if h.patternError {
+		return false
+	}
+
 	// Empty tool_name means match all tools
-	if h.ToolName == "" {
+	if h.toolPattern == nil {
 		return true
 	}
-	return h.ToolName == toolName
+	return h.toolPattern.Match(toolName)
+}
+
+// MatchesArgs checks the hook's argument patterns against the tool arguments.
+// A pattern on an argument that is absent does not match.
+func (h *Hook) MatchesArgs(args map[string]any) bool {
+	h.compile()
+	if h.patternError {
+		return false
+	}
+	for name, pattern := range h.argPatterns {
+		value, ok := args[name]
+		if !ok || !pattern.Match(argString(value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// compile compiles the tool name and argument patterns once. A hook with an
+// invalid pattern never matches.
+func (h *Hook) compile() {
+	h.compileOnce.Do(func() {
+		if h.ToolName != "" {
+			p, err := CompilePattern(h.ToolName)
+			if err != nil {
+				logging.Warn("invalid hook tool_name pattern", "hook", h.Name, "error", err)
+				h.patternError = true
+				return
+			}
+			h.toolPattern = p
+		}
+		h.argPatterns = make(map[string]*Pattern, len(h.MatchArgs))
+		for name, pattern := range h.MatchArgs {
+			p, err := CompilePattern(pattern)
+			if err != nil {
+				logging.Warn("invalid hook match_args pattern", "hook", h.Name, "arg", name, "error", err)
+				h.patternError = true
+				return
+			}
+			h.argPatterns[name] = p
+		}
+	})
 }
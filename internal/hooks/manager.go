@@ -3,8 +3,10 @@ package hooks
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os/exec"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
@@ -12,10 +14,11 @@ import (
 
 // Result represents the result of running a hook.
 type Result struct {
-	Hook    *Hook
-	Output  string
-	Error   error
-	Elapsed time.Duration
+	Hook     *Hook
+	Output   string
+	Response *Response // Parsed JSON response, nil if the output was not JSON
+	Error    error
+	Elapsed  time.Duration
 }
 
 // Handler is called when a hook produces output or errors.
@@ -23,11 +26,12 @@ type Handler func(hook *Hook, output string, err error)
 
 // Manager manages and executes hooks.
 type Manager struct {
-	enabled bool
-	hooks   []*Hook
-	workDir string
-	timeout time.Duration
-	handler Handler
+	enabled   bool
+	hooks     []*Hook
+	workDir   string
+	sessionID string
+	timeout   time.Duration
+	handler   Handler
 
 	mu sync.RWMutex
 }
@@ -63,6 +67,13 @@ func (m *Manager) SetTimeout(timeout time.Duration) {
 	m.timeout = timeout
 }
 
+// SetSessionID sets the session ID passed to hooks in their JSON event.
+func (m *Manager) SetSessionID(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessionID = id
+}
+
 // SetHandler sets the output handler.
 func (m *Manager) SetHandler(handler Handler) {
 	m.mu.Lock()
@@ -104,6 +115,8 @@ func (m *Manager) GetHooks() []*Hook {
 // Run executes all matching hooks for the given type and context.
 // It supports hook chaining (DependsOn), conditions (previousSuccess),
 // output capture (CapturedOutput), and FailOnError cancellation.
+// Argument rewrites from a hook's JSON response are applied to hctx.ToolArgs
+// before later hooks run; a deny decision stops further hooks.
 func (m *Manager) Run(ctx context.Context, hookType Type, hctx *Context) []Result {
 	m.mu.RLock()
 	if !m.enabled {
@@ -113,6 +126,7 @@ func (m *Manager) Run(ctx context.Context, hookType Type, hctx *Context) []Resul
 	hooks := m.hooks
 	timeout := m.timeout
 	handler := m.handler
+	sessionID := m.sessionID
 	m.mu.RUnlock()
 
 	if hctx.WorkDir == "" {
@@ -123,7 +137,7 @@ func (m *Manager) Run(ctx context.Context, hookType Type, hctx *Context) []Resul
 	completedHooks := make(map[string]bool)
 
 	for _, hook := range hooks {
-		if !hook.Matches(hookType, hctx.ToolName) {
+		if !hook.Matches(hookType, hctx.target()) || !hook.MatchesArgs(hctx.ToolArgs) {
 			continue
 		}
 
@@ -131,9 +145,14 @@ func (m *Manager) Run(ctx context.Context, hookType Type, hctx *Context) []Resul
 			continue
 		}
 
-		result := m.executeHook(ctx, hook, hctx, timeout)
+		event := hctx.event(hookType, hook.Name, sessionID)
+		result := m.executeHook(ctx, hook, hctx, event, timeout)
 		results = append(results, result)
 
+		if result.Response != nil && len(result.Response.UpdatedArgs) > 0 && hookType == PreTool {
+			hctx.ToolArgs = mergeArgs(hctx.ToolArgs, result.Response.UpdatedArgs)
+		}
+
 		// Capture output into context for subsequent hooks
 		hctx.CapturedOutput = result.Output
 
@@ -150,29 +169,34 @@ func (m *Manager) Run(ctx context.Context, hookType Type, hctx *Context) []Resul
 		if hook.FailOnError && result.Error != nil {
 			break
 		}
+		if result.Response.Denies() {
+			break
+		}
 	}
 
 	return results
 }
 
-// RunPreTool runs pre-tool hooks.
-func (m *Manager) RunPreTool(ctx context.Context, toolName string, args map[string]any) []Result {
+// RunPreTool runs pre-tool hooks. The outcome carries the possibly
+// rewritten arguments and whether a hook blocked the call.
+func (m *Manager) RunPreTool(ctx context.Context, toolName string, args map[string]any) *Outcome {
 	hctx := NewContext(toolName, args, m.workDir)
-	return m.Run(ctx, PreTool, hctx)
+	results := m.Run(ctx, PreTool, hctx)
+	return newOutcome(results, hctx.ToolArgs)
 }
 
 // RunPostTool runs post-tool hooks.
-func (m *Manager) RunPostTool(ctx context.Context, toolName string, args map[string]any, result string) []Result {
+func (m *Manager) RunPostTool(ctx context.Context, toolName string, args map[string]any, result string) *Outcome {
 	hctx := NewContext(toolName, args, m.workDir)
 	hctx.SetResult(result)
-	return m.Run(ctx, PostTool, hctx)
+	return newOutcome(m.Run(ctx, PostTool, hctx), args)
 }
 
 // RunOnError runs on-error hooks.
-func (m *Manager) RunOnError(ctx context.Context, toolName string, args map[string]any, err string) []Result {
+func (m *Manager) RunOnError(ctx context.Context, toolName string, args map[string]any, err string) *Outcome {
 	hctx := NewContext(toolName, args, m.workDir)
 	hctx.SetError(err)
-	return m.Run(ctx, OnError, hctx)
+	return newOutcome(m.Run(ctx, OnError, hctx), args)
 }
 
 // RunOnStart runs on-start hooks.
@@ -187,6 +211,57 @@ func (m *Manager) RunOnExit(ctx context.Context) []Result {
 	return m.Run(ctx, OnExit, hctx)
 }
 
+// RunUserPromptSubmit runs user_prompt_submit hooks for a prompt.
+func (m *Manager) RunUserPromptSubmit(ctx context.Context, prompt string) *Outcome {
+	hctx := &Context{WorkDir: m.workDir, Prompt: prompt, Extra: make(map[string]string)}
+	return newOutcome(m.Run(ctx, UserPromptSubmit, hctx), nil)
+}
+
+// RunPreCompact runs pre_compact hooks. Trigger is "manual" for /compact and
+// "auto" when the context manager compacts on its own.
+func (m *Manager) RunPreCompact(ctx context.Context, trigger string, messageCount int) *Outcome {
+	hctx := &Context{
+		WorkDir:      m.workDir,
+		Trigger:      trigger,
+		MessageCount: messageCount,
+		Extra:        map[string]string{"TRIGGER": trigger},
+	}
+	return newOutcome(m.Run(ctx, PreCompact, hctx), nil)
+}
+
+// RunStop runs stop hooks with the final response of a turn. stopHookActive
+// reports whether the turn is already a continuation forced by a stop hook,
+// so hooks can avoid looping forever.
+func (m *Manager) RunStop(ctx context.Context, response string, stopHookActive bool) *Outcome {
+	hctx := &Context{
+		WorkDir:        m.workDir,
+		Response:       response,
+		StopHookActive: stopHookActive,
+		Extra:          make(map[string]string),
+	}
+	return newOutcome(m.Run(ctx, Stop, hctx), nil)
+}
+
+// RunSubagentStop runs subagent_stop hooks for a finished sub-agent.
+func (m *Manager) RunSubagentStop(ctx context.Context, agent AgentInfo) *Outcome {
+	hctx := &Context{
+		WorkDir: m.workDir,
+		Agent:   &agent,
+		Extra:   map[string]string{"AGENT_TYPE": agent.Type, "AGENT_ID": agent.ID},
+	}
+	return newOutcome(m.Run(ctx, SubagentStop, hctx), nil)
+}
+
+// RunPlanStep runs plan_step hooks for a step transition.
+func (m *Manager) RunPlanStep(ctx context.Context, step StepInfo) *Outcome {
+	hctx := &Context{
+		WorkDir: m.workDir,
+		Step:    &step,
+		Extra:   map[string]string{"STEP_ID": strconv.Itoa(step.ID), "STEP_STATUS": step.Status},
+	}
+	return newOutcome(m.Run(ctx, PlanStep, hctx), nil)
+}
+
 // killHookProcess attempts graceful shutdown with SIGTERM, then SIGKILL after grace period.
 func killHookProcess(cmd *exec.Cmd, gracePeriod time.Duration) {
 	if cmd.Process == nil {
@@ -207,8 +282,8 @@ func killHookProcess(cmd *exec.Cmd, gracePeriod time.Duration) {
 	cmd.Process.Kill()
 }
 
-// executeHook executes a single hook.
-func (m *Manager) executeHook(ctx context.Context, hook *Hook, hctx *Context, timeout time.Duration) Result {
+// executeHook executes a single hook, passing event as JSON on stdin.
+func (m *Manager) executeHook(ctx context.Context, hook *Hook, hctx *Context, event Event, timeout time.Duration) Result {
 	start := time.Now()
 
 	// Expand variables in command
@@ -221,6 +296,9 @@ func (m *Manager) executeHook(ctx context.Context, hook *Hook, hctx *Context, ti
 	// Execute command
 	cmd := exec.CommandContext(execCtx, "sh", "-c", command)
 	cmd.Dir = hctx.WorkDir
+	if input, err := json.Marshal(event); err == nil {
+		cmd.Stdin = bytes.NewReader(input)
+	}
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -295,10 +373,11 @@ func (m *Manager) executeHook(ctx context.Context, hook *Hook, hctx *Context, ti
 	}
 
 	return Result{
-		Hook:    hook,
-		Output:  output,
-		Error:   finalErr,
-		Elapsed: elapsed,
+		Hook:     hook,
+		Output:   output,
+		Response: parseResponse(stdout.String()),
+		Error:    finalErr,
+		Elapsed:  elapsed,
 	}
 }
 
@@ -0,0 +1,248 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+)
+
+// Event is the JSON document a hook receives on stdin.
+type Event struct {
+	Event      Type           `json:"event"`
+	HookName   string         `json:"hook_name,omitempty"`
+	SessionID  string         `json:"session_id,omitempty"`
+	WorkDir    string         `json:"work_dir"`
+	ToolName   string         `json:"tool_name,omitempty"`
+	ToolArgs   map[string]any `json:"tool_args,omitempty"`
+	ToolResult string         `json:"tool_result,omitempty"`
+	ToolError  string         `json:"tool_error,omitempty"`
+
+	Prompt         string     `json:"prompt,omitempty"`
+	Trigger        string     `json:"trigger,omitempty"`
+	MessageCount   int        `json:"message_count,omitempty"`
+	Response       string     `json:"response,omitempty"`
+	StopHookActive bool       `json:"stop_hook_active,omitempty"`
+	Agent          *AgentInfo `json:"agent,omitempty"`
+	Step           *StepInfo  `json:"step,omitempty"`
+}
+
+// AgentInfo describes a finished sub-agent in a subagent_stop event.
+type AgentInfo struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Status     string `json:"status"`
+	Output     string `json:"output,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Plan step states reported in plan_step events.
+const (
+	StepStarted   = "started"
+	StepCompleted = "completed"
+	StepFailed    = "failed"
+	StepSkipped   = "skipped"
+)
+
+// StepInfo describes a plan step in a plan_step event.
+type StepInfo struct {
+	PlanID string `json:"plan_id"`
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Decision is a hook's verdict on the event.
+type Decision string
+
+const (
+	// DecisionAllow lets the action proceed (the default).
+	DecisionAllow Decision = "allow"
+	// DecisionDeny blocks the action; Reason is shown to the model.
+	DecisionDeny Decision = "deny"
+	// DecisionBlock is an alias for DecisionDeny.
+	DecisionBlock Decision = "block"
+)
+
+// Response is the JSON a hook may print on stdout. Output that is not a JSON
+// object is treated as plain text.
+type Response struct {
+	Decision Decision `json:"decision,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+	// UpdatedArgs replaces the named tool arguments (pre_tool only).
+	UpdatedArgs map[string]any `json:"updated_args,omitempty"`
+	// AdditionalContext is appended to the tool result (or prompt, for
+	// user_prompt_submit) for the model.
+	AdditionalContext string `json:"additional_context,omitempty"`
+	// SystemMessage is shown to the user and added to the conversation.
+	SystemMessage string `json:"system_message,omitempty"`
+}
+
+// Denies reports whether the response blocks the action.
+func (r *Response) Denies() bool {
+	return r != nil && (r.Decision == DecisionDeny || r.Decision == DecisionBlock)
+}
+
+// parseResponse extracts a Response from hook output, if it is one.
+func parseResponse(output string) *Response {
+	trimmed := strings.TrimSpace(output)
+	if !strings.HasPrefix(trimmed, "{") {
+		return nil
+	}
+	var resp Response
+	if err := json.Unmarshal([]byte(trimmed), &resp); err != nil {
+		return nil
+	}
+	return &resp
+}
+
+// Outcome combines the results of all hooks run for one event.
+type Outcome struct {
+	Results []Result
+
+	// Denied is set when a hook denied the action or a FailOnError hook failed.
+	Denied   bool
+	DeniedBy string
+	Reason   string
+
+	// Args are the tool arguments after any rewrites.
+	Args map[string]any
+
+	AdditionalContext []string
+	SystemMessages    []string
+}
+
+// newOutcome folds hook results into an Outcome.
+func newOutcome(results []Result, args map[string]any) *Outcome {
+	o := &Outcome{Results: results, Args: args}
+	for _, r := range results {
+		if resp := r.Response; resp != nil {
+			if resp.AdditionalContext != "" {
+				o.AdditionalContext = append(o.AdditionalContext, resp.AdditionalContext)
+			}
+			if resp.SystemMessage != "" {
+				o.SystemMessages = append(o.SystemMessages, resp.SystemMessage)
+			}
+		}
+
+		switch {
+		case r.Response.Denies():
+			o.deny(r.Hook, r.Response.Reason)
+		case r.Error != nil && r.Hook.FailOnError:
+			o.deny(r.Hook, r.Error.Error())
+		}
+	}
+	return o
+}
+
+func (o *Outcome) deny(hook *Hook, reason string) {
+	if o.Denied {
+		return
+	}
+	o.Denied = true
+	o.DeniedBy = hook.Name
+	o.Reason = reason
+	if o.Reason == "" {
+		o.Reason = "no reason given"
+	}
+}
+
+// Notes renders additional context and system messages for appending to a
+// tool result. It returns "" when there are none.
+func (o *Outcome) Notes() string {
+	if o == nil {
+		return ""
+	}
+	var parts []string
+	for _, c := range o.AdditionalContext {
+		parts = append(parts, "[Hook context] "+c)
+	}
+	for _, m := range o.SystemMessages {
+		parts = append(parts, "[System] "+m)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// mergeArgs returns args with updates applied; a null update removes the key.
+func mergeArgs(args, updates map[string]any) map[string]any {
+	merged := make(map[string]any, len(args)+len(updates))
+	maps.Copy(merged, args)
+	for k, v := range updates {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// Pattern matches tool names and argument values. A pattern wrapped in
+// slashes ("/^git push/") is a regular expression; anything else is a glob
+// where * matches any run of characters (including /), ? one character, and
+// | separates alternatives ("write|edit").
+type Pattern struct {
+	re *regexp.Regexp
+}
+
+// CompilePattern compiles a glob or /regex/ pattern.
+func CompilePattern(pattern string) (*Pattern, error) {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %s: %w", pattern, err)
+		}
+		return &Pattern{re: re}, nil
+	}
+
+	var alts []string
+	for _, alt := range strings.Split(pattern, "|") {
+		alts = append(alts, globToRegex(alt))
+	}
+	re, err := regexp.Compile("^(?:" + strings.Join(alts, "|") + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+	}
+	return &Pattern{re: re}, nil
+}
+
+// globToRegex converts one glob alternative into a regular expression.
+func globToRegex(glob string) string {
+	var sb strings.Builder
+	for _, r := range glob {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return sb.String()
+}
+
+// Match reports whether s matches the pattern.
+func (p *Pattern) Match(s string) bool {
+	return p.re.MatchString(s)
+}
+
+// argString renders an argument value for pattern matching.
+func argString(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case nil:
+		return ""
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprint(val)
+		}
+		return string(data)
+	}
+}
@@ -0,0 +1,337 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gokin/internal/logging"
+)
+
+// shutdownTimeout bounds the shutdown handshake with a server.
+const shutdownTimeout = 2 * time.Second
+
+// settleDelay is how long to keep collecting diagnostics after the first
+// report for a change; servers often publish syntax errors before type
+// errors.
+const settleDelay = 300 * time.Millisecond
+
+// languageIDs maps file extensions to the language IDs of text documents.
+var languageIDs = map[string]string{
+	".go":  "go",
+	".py":  "python",
+	".rs":  "rust",
+	".ts":  "typescript",
+	".tsx": "typescriptreact",
+	".js":  "javascript",
+	".jsx": "javascriptreact",
+	".mjs": "javascript",
+	".cjs": "javascript",
+}
+
+// Client is a connection to one running language server.
+type Client struct {
+	config ServerConfig
+	root   string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	conn   *conn
+
+	mu          sync.Mutex
+	versions    map[string]int          // Open documents by URI
+	hashes      map[string][32]byte     // Hashes of the content last sent by URI
+	diagnostics map[string][]Diagnostic // Latest diagnostics by URI
+	published   map[string]int          // Diagnostics reports received by URI
+	reported    chan struct{}           // Closed and replaced on every report
+}
+
+// startClient starts the language server and initializes it for root.
+func startClient(ctx context.Context, config ServerConfig, root string) (*Client, error) {
+	cmd := exec.Command(config.Command, config.Args...)
+	cmd.Dir = root
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", config.Command, err)
+	}
+
+	c := &Client{
+		config:      config,
+		root:        root,
+		cmd:         cmd,
+		stdin:       stdin,
+		versions:    make(map[string]int),
+		hashes:      make(map[string][32]byte),
+		diagnostics: make(map[string][]Diagnostic),
+		published:   make(map[string]int),
+		reported:    make(chan struct{}),
+	}
+	c.conn = newConn(stdout, stdin, c.handleNotification, c.handleRequest)
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			logging.Debug("language server stderr", "server", config.Language, "line", scanner.Text())
+		}
+	}()
+
+	if err := c.initialize(ctx); err != nil {
+		c.kill()
+		_ = cmd.Wait()
+		return nil, fmt.Errorf("failed to initialize %s: %w", config.Command, err)
+	}
+	logging.Info("language server started", "language", config.Language, "command", config.Command, "pid", cmd.Process.Pid)
+	return c, nil
+}
+
+// initialize performs the initialize handshake.
+func (c *Client) initialize(ctx context.Context) error {
+	rootURI := PathToURI(c.root)
+	params := map[string]any{
+		"processId": os.Getpid(),
+		"clientInfo": map[string]any{
+			"name": "gokin",
+		},
+		"rootUri":  rootURI,
+		"rootPath": c.root,
+		"workspaceFolders": []map[string]any{
+			{"uri": rootURI, "name": filepath.Base(c.root)},
+		},
+		"capabilities": map[string]any{
+			"workspace": map[string]any{
+				"workspaceFolders": true,
+				"configuration":    true,
+				"workspaceEdit":    map[string]any{"documentChanges": true},
+				"symbol":           map[string]any{},
+			},
+			"textDocument": map[string]any{
+				"synchronization": map[string]any{"didSave": true},
+				"publishDiagnostics": map[string]any{
+					"versionSupport": true,
+				},
+				"hover":      map[string]any{"contentFormat": []string{"markdown", "plaintext"}},
+				"definition": map[string]any{"linkSupport": true},
+				"references": map[string]any{},
+				"rename":     map[string]any{},
+			},
+		},
+	}
+	if c.config.InitializationOptions != nil {
+		params["initializationOptions"] = c.config.InitializationOptions
+	}
+	if err := c.conn.call(ctx, "initialize", params, nil); err != nil {
+		return err
+	}
+	return c.conn.notify("initialized", map[string]any{})
+}
+
+// handleNotification records diagnostics published by the server.
+func (c *Client) handleNotification(method string, params json.RawMessage) {
+	if method != "textDocument/publishDiagnostics" {
+		return
+	}
+	var report struct {
+		URI         string       `json:"uri"`
+		Version     *int         `json:"version"`
+		Diagnostics []Diagnostic `json:"diagnostics"`
+	}
+	if err := json.Unmarshal(params, &report); err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Reports for content that has since changed are stale
+	if report.Version != nil && *report.Version < c.versions[report.URI] {
+		return
+	}
+	c.diagnostics[report.URI] = report.Diagnostics
+	c.published[report.URI]++
+	close(c.reported)
+	c.reported = make(chan struct{})
+}
+
+// handleRequest answers requests from the server with empty results.
+func (c *Client) handleRequest(method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "workspace/configuration":
+		// One null setting per requested item means "use defaults"
+		var req struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		_ = json.Unmarshal(params, &req)
+		return make([]any, len(req.Items)), nil
+	case "workspace/workspaceFolders":
+		return []map[string]any{{"uri": PathToURI(c.root), "name": filepath.Base(c.root)}}, nil
+	case "window/workDoneProgress/create", "client/registerCapability",
+		"client/unregisterCapability", "window/showMessageRequest":
+		return nil, nil
+	case "workspace/applyEdit":
+		// Edits are only applied by gokin's own tools
+		return map[string]any{"applied": false}, nil
+	}
+	return nil, fmt.Errorf("method not supported: %s", method)
+}
+
+// languageID returns the language ID of a document.
+func (c *Client) languageID(path string) string {
+	if id, ok := languageIDs[strings.ToLower(filepath.Ext(path))]; ok {
+		return id
+	}
+	return c.config.Language
+}
+
+// sync sends the content of path to the server, opening the document on
+// first use. saved tells the server the content is also on disk. It returns
+// the number of diagnostics reports for the document received so far.
+func (c *Client) sync(path string, content []byte, saved bool) (int, error) {
+	uri := PathToURI(path)
+
+	c.mu.Lock()
+	version, open := c.versions[uri]
+	version++
+	c.versions[uri] = version
+	c.hashes[uri] = sha256.Sum256(content)
+	published := c.published[uri]
+	c.mu.Unlock()
+
+	var err error
+	if !open {
+		err = c.conn.notify("textDocument/didOpen", map[string]any{
+			"textDocument": map[string]any{
+				"uri":        uri,
+				"languageId": c.languageID(path),
+				"version":    version,
+				"text":       string(content),
+			},
+		})
+	} else {
+		err = c.conn.notify("textDocument/didChange", map[string]any{
+			"textDocument":   map[string]any{"uri": uri, "version": version},
+			"contentChanges": []map[string]any{{"text": string(content)}},
+		})
+	}
+	if err == nil && saved && open {
+		err = c.conn.notify("textDocument/didSave", map[string]any{
+			"textDocument": map[string]any{"uri": uri},
+		})
+	}
+	return published, err
+}
+
+// refresh sends the content of path on disk to the server unless the
+// server already has it, e.g. after a shell command changed the file.
+func (c *Client) refresh(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	hash, open := c.hashes[PathToURI(path)]
+	c.mu.Unlock()
+	if open && hash == sha256.Sum256(content) {
+		return nil
+	}
+	_, err = c.sync(path, content, open)
+	return err
+}
+
+// waitDiagnostics waits until the server has reported diagnostics for path
+// more than after times, then briefly for further reports, and returns the
+// latest diagnostics. ok is false if no new report arrived before ctx ended.
+func (c *Client) waitDiagnostics(ctx context.Context, path string, after int) (diagnostics []Diagnostic, ok bool) {
+	uri := PathToURI(path)
+	var settle <-chan time.Time
+	for {
+		c.mu.Lock()
+		count := c.published[uri]
+		diagnostics = c.diagnostics[uri]
+		reported := c.reported
+		c.mu.Unlock()
+
+		if count > after && settle == nil {
+			ok = true
+			settle = time.After(settleDelay)
+		}
+
+		select {
+		case <-reported:
+		case <-settle:
+			return diagnostics, true
+		case <-ctx.Done():
+			return diagnostics, ok
+		case <-c.conn.done:
+			return diagnostics, ok
+		}
+	}
+}
+
+// Diagnostics returns the latest diagnostics reported for path.
+func (c *Client) Diagnostics(path string) []Diagnostic {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.diagnostics[PathToURI(path)]
+}
+
+// call sends a request to the server.
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	return c.conn.call(ctx, method, params, result)
+}
+
+// alive reports whether the server is still running.
+func (c *Client) alive() bool {
+	select {
+	case <-c.conn.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Close shuts the server down, killing it if it does not exit in time.
+func (c *Client) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := c.conn.call(ctx, "shutdown", nil, nil); err == nil {
+		_ = c.conn.notify("exit", nil)
+	}
+	_ = c.stdin.Close()
+
+	exited := make(chan struct{})
+	go func() {
+		_ = c.cmd.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-ctx.Done():
+		c.kill()
+		<-exited
+	}
+	return nil
+}
+
+// kill stops the server process immediately.
+func (c *Client) kill() {
+	if c.cmd.Process != nil {
+		_ = c.cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,209 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// message is a JSON-RPC 2.0 request, notification or response.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *responseError  `json:"error,omitempty"`
+}
+
+// responseError is the error of a JSON-RPC response.
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// errClosed is returned for requests on a closed connection.
+var errClosed = errors.New("language server connection closed")
+
+// conn is a JSON-RPC connection to a language server using the
+// Content-Length framing of the base protocol.
+type conn struct {
+	w   io.Writer
+	wmu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[string]chan *message
+	closed  bool
+
+	// Handlers for messages from the server; requests are answered with
+	// the handler's result
+	onNotification func(method string, params json.RawMessage)
+	onRequest      func(method string, params json.RawMessage) (any, error)
+
+	done chan struct{}
+}
+
+// newConn starts reading messages from r. The handlers are called from the
+// read loop and must not block on requests to the server.
+func newConn(r io.Reader, w io.Writer,
+	onNotification func(string, json.RawMessage),
+	onRequest func(string, json.RawMessage) (any, error)) *conn {
+	c := &conn{
+		w:              w,
+		pending:        make(map[string]chan *message),
+		onNotification: onNotification,
+		onRequest:      onRequest,
+		done:           make(chan struct{}),
+	}
+	go c.readLoop(bufio.NewReader(r))
+	return c
+}
+
+// call sends a request and decodes its result into result, which may be nil.
+func (c *conn) call(ctx context.Context, method string, params, result any) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return errClosed
+	}
+	c.nextID++
+	id := c.nextID
+	key := strconv.FormatInt(id, 10)
+	ch := make(chan *message, 1)
+	c.pending[key] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, key)
+		c.mu.Unlock()
+	}()
+
+	if err := c.send(&message{ID: json.RawMessage(key), Method: method}, params); err != nil {
+		return err
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil || len(resp.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(resp.Result, result)
+	case <-c.done:
+		return errClosed
+	case <-ctx.Done():
+		// Let the server stop working on it
+		_ = c.notify("$/cancelRequest", map[string]any{"id": id})
+		return ctx.Err()
+	}
+}
+
+// notify sends a notification.
+func (c *conn) notify(method string, params any) error {
+	return c.send(&message{Method: method}, params)
+}
+
+// send writes a message with params, or a result when msg has no method.
+func (c *conn) send(msg *message, payload any) error {
+	msg.JSONRPC = "2.0"
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		if msg.Method != "" {
+			msg.Params = data
+		} else {
+			msg.Result = data
+		}
+	} else if msg.Method == "" && msg.Error == nil {
+		msg.Result = json.RawMessage("null")
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+		return err
+	}
+	_, err = c.w.Write(data)
+	return err
+}
+
+// readLoop dispatches messages from the server until the stream ends.
+func (c *conn) readLoop(r *bufio.Reader) {
+	defer func() {
+		c.mu.Lock()
+		c.closed = true
+		c.mu.Unlock()
+		close(c.done)
+	}()
+
+	tp := textproto.NewReader(r)
+	for {
+		header, err := tp.ReadMIMEHeader()
+		if err != nil {
+			return
+		}
+		length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+		if err != nil || length < 0 {
+			return
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return
+		}
+
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			continue
+		}
+		switch {
+		case msg.Method != "" && len(msg.ID) > 0:
+			c.handleRequest(&msg)
+		case msg.Method != "":
+			if c.onNotification != nil {
+				c.onNotification(msg.Method, msg.Params)
+			}
+		default:
+			c.mu.Lock()
+			ch := c.pending[string(msg.ID)]
+			c.mu.Unlock()
+			if ch != nil {
+				ch <- &msg
+			}
+		}
+	}
+}
+
+// handleRequest answers a request from the server.
+func (c *conn) handleRequest(msg *message) {
+	var result any
+	var err error
+	if c.onRequest != nil {
+		result, err = c.onRequest(msg.Method, msg.Params)
+	}
+	resp := &message{ID: msg.ID}
+	if err != nil {
+		resp.Error = &responseError{Code: -32601, Message: err.Error()}
+	}
+	_ = c.send(resp, result)
+}
@@ -0,0 +1,429 @@
+// Package lsp runs language servers for a project and speaks the Language
+// Server Protocol with them: documents are kept in sync as files change,
+// diagnostics are collected after edits, and navigation requests
+// (definition, references, hover, rename, workspace symbols) are answered
+// by the server for the file's language.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gokin/internal/logging"
+)
+
+const (
+	// DefaultDiagnosticsTimeout is how long to wait for diagnostics after
+	// an edit when no timeout is configured.
+	DefaultDiagnosticsTimeout = 3 * time.Second
+
+	// startTimeout bounds starting and initializing a server.
+	startTimeout = 30 * time.Second
+)
+
+// ErrNoServer is returned for files no language server is available for.
+var ErrNoServer = errors.New("no language server available")
+
+// ServerConfig describes how to run the language server for a language.
+type ServerConfig struct {
+	Language              string         // Language name, also the default document language ID
+	Command               string         // Executable, looked up in PATH
+	Args                  []string       // Arguments, e.g. --stdio
+	Extensions            []string       // File extensions served, with the dot
+	RootMarkers           []string       // Files in the project root that show the language is used
+	InitializationOptions map[string]any // Server specific initialization options
+}
+
+// DefaultServers returns the built-in language servers.
+func DefaultServers() []ServerConfig {
+	return []ServerConfig{
+		{
+			Language:    "go",
+			Command:     "gopls",
+			Extensions:  []string{".go"},
+			RootMarkers: []string{"go.mod", "go.work"},
+		},
+		{
+			Language:    "python",
+			Command:     "pyright-langserver",
+			Args:        []string{"--stdio"},
+			Extensions:  []string{".py"},
+			RootMarkers: []string{"pyproject.toml", "setup.py", "setup.cfg", "requirements.txt", "pyrightconfig.json"},
+		},
+		{
+			Language:    "rust",
+			Command:     "rust-analyzer",
+			Extensions:  []string{".rs"},
+			RootMarkers: []string{"Cargo.toml"},
+		},
+		{
+			Language:    "typescript",
+			Command:     "typescript-language-server",
+			Args:        []string{"--stdio"},
+			Extensions:  []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs"},
+			RootMarkers: []string{"tsconfig.json", "jsconfig.json", "package.json"},
+		},
+	}
+}
+
+// Manager starts language servers for a project on demand, one per
+// language, and routes requests to them by file extension.
+type Manager struct {
+	root    string
+	servers []ServerConfig
+	timeout time.Duration
+
+	startMu sync.Mutex // Serializes starting servers
+
+	mu      sync.Mutex
+	clients map[string]*Client // Running servers by language
+	failed  map[string]error   // Servers that could not be started
+	closed  bool
+}
+
+// NewManager creates a manager for the project in root using servers.
+func NewManager(root string, servers []ServerConfig) *Manager {
+	return &Manager{
+		root:    root,
+		servers: servers,
+		timeout: DefaultDiagnosticsTimeout,
+		clients: make(map[string]*Client),
+		failed:  make(map[string]error),
+	}
+}
+
+// SetDiagnosticsTimeout sets how long to wait for diagnostics after an edit.
+func (m *Manager) SetDiagnosticsTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		m.timeout = timeout
+	}
+}
+
+// serverFor returns the server configured for path's extension.
+func (m *Manager) serverFor(path string) (ServerConfig, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, s := range m.servers {
+		for _, e := range s.Extensions {
+			if strings.EqualFold(e, ext) {
+				return s, true
+			}
+		}
+	}
+	return ServerConfig{}, false
+}
+
+// Handles reports whether a language server is configured for path.
+func (m *Manager) Handles(path string) bool {
+	_, ok := m.serverFor(path)
+	return ok
+}
+
+// clientFor returns the running server for path, starting it if needed.
+func (m *Manager) clientFor(ctx context.Context, path string) (*Client, error) {
+	server, ok := m.serverFor(path)
+	if !ok {
+		return nil, fmt.Errorf("%w for %s files", ErrNoServer, filepath.Ext(path))
+	}
+	return m.client(ctx, server)
+}
+
+// client returns the running server for a configuration, starting it if
+// needed. Servers that failed to start are not retried.
+func (m *Manager) client(ctx context.Context, server ServerConfig) (*Client, error) {
+	m.mu.Lock()
+	c := m.clients[server.Language]
+	failed := m.failed[server.Language]
+	closed := m.closed
+	m.mu.Unlock()
+	switch {
+	case closed:
+		return nil, fmt.Errorf("language servers are shut down")
+	case c != nil && c.alive():
+		return c, nil
+	case failed != nil:
+		return nil, failed
+	}
+
+	m.startMu.Lock()
+	defer m.startMu.Unlock()
+
+	// Another caller may have started it meanwhile
+	m.mu.Lock()
+	if c := m.clients[server.Language]; c != nil && c.alive() {
+		m.mu.Unlock()
+		return c, nil
+	}
+	m.mu.Unlock()
+
+	if _, err := exec.LookPath(server.Command); err != nil {
+		err = fmt.Errorf("%w: %s is not installed", ErrNoServer, server.Command)
+		m.mu.Lock()
+		m.failed[server.Language] = err
+		m.mu.Unlock()
+		return nil, err
+	}
+
+	startCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), startTimeout)
+	defer cancel()
+	c, err := startClient(startCtx, server, m.root)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		logging.Warn("failed to start language server", "language", server.Language, "error", err)
+		m.failed[server.Language] = err
+		return nil, err
+	}
+	if m.closed {
+		go c.Close()
+		return nil, fmt.Errorf("language servers are shut down")
+	}
+	m.clients[server.Language] = c
+	return c, nil
+}
+
+// Diagnose sends the new content of path to its language server and
+// returns the diagnostics the change introduced: those reported for the
+// new content that were not reported before. It waits for the server's
+// report up to the diagnostics timeout. Files without a server have no
+// diagnostics.
+func (m *Manager) Diagnose(ctx context.Context, path string, content []byte) ([]Diagnostic, error) {
+	c, err := m.clientFor(ctx, path)
+	if err != nil {
+		if errors.Is(err, ErrNoServer) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	before := make(map[string]int)
+	for _, d := range c.Diagnostics(path) {
+		before[d.key()]++
+	}
+
+	published, err := c.sync(path, content, true)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+	after, _ := c.waitDiagnostics(ctx, path, published)
+
+	var introduced []Diagnostic
+	for _, d := range after {
+		if before[d.key()] > 0 {
+			before[d.key()]--
+			continue
+		}
+		introduced = append(introduced, d)
+	}
+	sort.SliceStable(introduced, func(i, j int) bool {
+		if introduced[i].Severity != introduced[j].Severity {
+			return introduced[i].Severity < introduced[j].Severity
+		}
+		return introduced[i].Range.Start.Line < introduced[j].Range.Start.Line
+	})
+	return introduced, nil
+}
+
+// Changed tells a running language server about the new content of path
+// without waiting for diagnostics.
+func (m *Manager) Changed(path string, content []byte) {
+	server, ok := m.serverFor(path)
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	c := m.clients[server.Language]
+	m.mu.Unlock()
+	if c != nil && c.alive() {
+		if _, err := c.sync(path, content, true); err != nil {
+			logging.Debug("failed to sync document", "path", path, "error", err)
+		}
+	}
+}
+
+// request opens path in its server and sends a request about it.
+func (m *Manager) request(ctx context.Context, path, method string, params map[string]any, result any) error {
+	c, err := m.clientFor(ctx, path)
+	if err != nil {
+		return err
+	}
+	if err := c.refresh(path); err != nil {
+		return err
+	}
+	params["textDocument"] = map[string]any{"uri": PathToURI(path)}
+	return c.call(ctx, method, params, result)
+}
+
+// Definition returns the locations where the symbol at pos in path is
+// defined.
+func (m *Manager) Definition(ctx context.Context, path string, pos Position) ([]Location, error) {
+	var raw json.RawMessage
+	if err := m.request(ctx, path, "textDocument/definition", map[string]any{"position": pos}, &raw); err != nil {
+		return nil, err
+	}
+	return parseLocations(raw)
+}
+
+// References returns the locations that reference the symbol at pos in
+// path, including its declaration.
+func (m *Manager) References(ctx context.Context, path string, pos Position) ([]Location, error) {
+	var locations []Location
+	params := map[string]any{
+		"position": pos,
+		"context":  map[string]any{"includeDeclaration": true},
+	}
+	if err := m.request(ctx, path, "textDocument/references", params, &locations); err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// Hover returns the documentation and type of the symbol at pos in path.
+func (m *Manager) Hover(ctx context.Context, path string, pos Position) (string, error) {
+	var h *hover
+	if err := m.request(ctx, path, "textDocument/hover", map[string]any{"position": pos}, &h); err != nil {
+		return "", err
+	}
+	if h == nil {
+		return "", nil
+	}
+	return h.text(), nil
+}
+
+// Rename returns the edits that rename the symbol at pos in path to
+// newName. The edits are not applied.
+func (m *Manager) Rename(ctx context.Context, path string, pos Position, newName string) (*WorkspaceEdit, error) {
+	var edit *WorkspaceEdit
+	params := map[string]any{"position": pos, "newName": newName}
+	if err := m.request(ctx, path, "textDocument/rename", params, &edit); err != nil {
+		return nil, err
+	}
+	if edit == nil {
+		return nil, fmt.Errorf("the symbol cannot be renamed")
+	}
+	return edit, nil
+}
+
+// WorkspaceSymbols searches the symbols of the project matching query in
+// every running language server and in those the project root shows are
+// used.
+func (m *Manager) WorkspaceSymbols(ctx context.Context, query string) ([]SymbolInformation, error) {
+	var symbols []SymbolInformation
+	var errs []error
+	queried := 0
+	for _, server := range m.servers {
+		m.mu.Lock()
+		_, running := m.clients[server.Language]
+		m.mu.Unlock()
+		if !running && !m.usesLanguage(server) {
+			continue
+		}
+		c, err := m.client(ctx, server)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		queried++
+
+		var found []SymbolInformation
+		if err := c.call(ctx, "workspace/symbol", map[string]any{"query": query}, &found); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", server.Language, err))
+			continue
+		}
+		symbols = append(symbols, found...)
+	}
+	if queried == 0 {
+		if len(errs) > 0 {
+			return nil, errors.Join(errs...)
+		}
+		return nil, fmt.Errorf("%w for this project", ErrNoServer)
+	}
+	return symbols, nil
+}
+
+// usesLanguage reports whether the project root has a marker file of the
+// server's language.
+func (m *Manager) usesLanguage(server ServerConfig) bool {
+	for _, marker := range server.RootMarkers {
+		if _, err := os.Stat(filepath.Join(m.root, marker)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Running returns the languages whose servers are running.
+func (m *Manager) Running() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var languages []string
+	for language, c := range m.clients {
+		if c.alive() {
+			languages = append(languages, language)
+		}
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Close shuts down all language servers.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	clients := m.clients
+	m.clients = make(map[string]*Client)
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, c := range clients {
+		wg.Add(1)
+		go func(c *Client) {
+			defer wg.Done()
+			_ = c.Close()
+		}(c)
+	}
+	wg.Wait()
+	return nil
+}
+
+// parseLocations decodes a definition result: a location, a list of
+// locations or a list of location links.
+func parseLocations(raw json.RawMessage) ([]Location, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var single Location
+	if err := json.Unmarshal(raw, &single); err == nil && single.URI != "" {
+		return []Location{single}, nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+	locations := make([]Location, 0, len(items))
+	for _, item := range items {
+		var link locationLink
+		if err := json.Unmarshal(item, &link); err == nil && link.TargetURI != "" {
+			locations = append(locations, Location{URI: link.TargetURI, Range: link.TargetSelectionRange})
+			continue
+		}
+		var loc Location
+		if err := json.Unmarshal(item, &loc); err != nil {
+			return nil, err
+		}
+		locations = append(locations, loc)
+	}
+	return locations, nil
+}
@@ -0,0 +1,302 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Position is a zero-based line and UTF-16 character offset in a document.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a span between two positions in a document.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a range in a document.
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// locationLink is returned by servers that support definition links.
+type locationLink struct {
+	TargetURI            string `json:"targetUri"`
+	TargetRange          Range  `json:"targetRange"`
+	TargetSelectionRange Range  `json:"targetSelectionRange"`
+}
+
+// Severity is the severity of a diagnostic.
+type Severity int
+
+const (
+	SeverityError       Severity = 1
+	SeverityWarning     Severity = 2
+	SeverityInformation Severity = 3
+	SeverityHint        Severity = 4
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInformation:
+		return "info"
+	case SeverityHint:
+		return "hint"
+	default:
+		return "error"
+	}
+}
+
+// Diagnostic is a problem reported by a language server.
+type Diagnostic struct {
+	Range    Range           `json:"range"`
+	Severity Severity        `json:"severity,omitempty"`
+	Code     json.RawMessage `json:"code,omitempty"`
+	Source   string          `json:"source,omitempty"`
+	Message  string          `json:"message"`
+}
+
+// key identifies a diagnostic independently of its position, so the same
+// problem is recognized after the lines around it moved.
+func (d Diagnostic) key() string {
+	return fmt.Sprintf("%d|%s|%s|%s", d.Severity, d.Source, d.Code, d.Message)
+}
+
+// TextEdit replaces a range of a document.
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// WorkspaceEdit is a set of edits across documents, as returned by rename.
+type WorkspaceEdit struct {
+	Changes         map[string][]TextEdit `json:"changes,omitempty"`
+	DocumentChanges []json.RawMessage     `json:"documentChanges,omitempty"`
+}
+
+// textDocumentEdit is an entry of WorkspaceEdit.DocumentChanges. Entries
+// with a kind create, rename or delete files.
+type textDocumentEdit struct {
+	Kind         string `json:"kind,omitempty"`
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Edits []TextEdit `json:"edits"`
+}
+
+// FileEdits returns the edits of the workspace edit by file path. File
+// creation, renaming and deletion are not supported.
+func (e *WorkspaceEdit) FileEdits() (map[string][]TextEdit, error) {
+	edits := make(map[string][]TextEdit)
+	for uri, changes := range e.Changes {
+		path, err := URIToPath(uri)
+		if err != nil {
+			return nil, err
+		}
+		edits[path] = append(edits[path], changes...)
+	}
+	for _, raw := range e.DocumentChanges {
+		var change textDocumentEdit
+		if err := json.Unmarshal(raw, &change); err != nil {
+			return nil, err
+		}
+		if change.Kind != "" {
+			return nil, fmt.Errorf("the edit would %s a file, which is not supported", change.Kind)
+		}
+		path, err := URIToPath(change.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		edits[path] = append(edits[path], change.Edits...)
+	}
+	return edits, nil
+}
+
+// SymbolKind is the kind of a workspace symbol.
+type SymbolKind int
+
+var symbolKindNames = map[SymbolKind]string{
+	1: "file", 2: "module", 3: "namespace", 4: "package", 5: "class",
+	6: "method", 7: "property", 8: "field", 9: "constructor", 10: "enum",
+	11: "interface", 12: "function", 13: "variable", 14: "constant",
+	15: "string", 16: "number", 17: "boolean", 18: "array", 19: "object",
+	20: "key", 21: "null", 22: "enum member", 23: "struct", 24: "event",
+	25: "operator", 26: "type parameter",
+}
+
+func (k SymbolKind) String() string {
+	if name, ok := symbolKindNames[k]; ok {
+		return name
+	}
+	return "symbol"
+}
+
+// SymbolInformation is a symbol found by a workspace symbol search.
+type SymbolInformation struct {
+	Name          string     `json:"name"`
+	Kind          SymbolKind `json:"kind"`
+	Location      Location   `json:"location"`
+	ContainerName string     `json:"containerName,omitempty"`
+}
+
+// hover is the result of a hover request. Contents is a MarkupContent, a
+// MarkedString or an array of MarkedStrings.
+type hover struct {
+	Contents json.RawMessage `json:"contents"`
+}
+
+// text returns the hover contents as plain text.
+func (h *hover) text() string {
+	var markup struct {
+		Kind     string `json:"kind"`
+		Value    string `json:"value"`
+		Language string `json:"language"`
+	}
+	var s string
+	var list []json.RawMessage
+
+	switch {
+	case json.Unmarshal(h.Contents, &s) == nil:
+		return strings.TrimSpace(s)
+	case json.Unmarshal(h.Contents, &list) == nil:
+		var parts []string
+		for _, item := range list {
+			if text := (&hover{Contents: item}).text(); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, "\n\n")
+	case json.Unmarshal(h.Contents, &markup) == nil:
+		if markup.Language != "" {
+			return fmt.Sprintf("```%s\n%s\n```", markup.Language, strings.TrimSpace(markup.Value))
+		}
+		return strings.TrimSpace(markup.Value)
+	}
+	return ""
+}
+
+// PathToURI converts a file path to a file:// URI.
+func PathToURI(path string) string {
+	path = filepath.ToSlash(path)
+	if runtime.GOOS == "windows" {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// URIToPath converts a file:// URI to a file path.
+func URIToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported URI scheme: %s", uri)
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.FromSlash(path), nil
+}
+
+// lineStarts returns the byte offset at which each line of content starts.
+func lineStarts(content []byte) []int {
+	starts := []int{0}
+	for i, b := range content {
+		if b == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// Offset converts an LSP position to a byte offset in content. Positions
+// past the end of a line or of the content are clamped.
+func Offset(content []byte, pos Position) int {
+	starts := lineStarts(content)
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(starts) {
+		return len(content)
+	}
+	offset := starts[pos.Line]
+	units := 0
+	for offset < len(content) && content[offset] != '\n' && units < pos.Character {
+		r, size := utf8.DecodeRune(content[offset:])
+		units += utf16.RuneLen(r)
+		if units > pos.Character && utf16.RuneLen(r) > 1 {
+			break
+		}
+		offset += size
+	}
+	return offset
+}
+
+// PositionAt converts a 1-based line and 1-based byte column to an LSP
+// position in content.
+func PositionAt(content []byte, line, column int) Position {
+	starts := lineStarts(content)
+	if line < 1 {
+		line = 1
+	}
+	if line > len(starts) {
+		line = len(starts)
+	}
+	start := starts[line-1]
+	end := len(content)
+	if line < len(starts) {
+		end = starts[line] - 1
+	}
+	col := min(max(column-1, 0), end-start)
+
+	units := 0
+	for _, r := range string(content[start : start+col]) {
+		units += utf16.RuneLen(r)
+	}
+	return Position{Line: line - 1, Character: units}
+}
+
+// ApplyEdits applies non-overlapping text edits to content.
+func ApplyEdits(content []byte, edits []TextEdit) ([]byte, error) {
+	type span struct {
+		start, end int
+		text       string
+	}
+	spans := make([]span, len(edits))
+	for i, e := range edits {
+		spans[i] = span{Offset(content, e.Range.Start), Offset(content, e.Range.End), e.NewText}
+		if spans[i].end < spans[i].start {
+			return nil, fmt.Errorf("invalid edit range")
+		}
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var out []byte
+	last := 0
+	for _, s := range spans {
+		if s.start < last {
+			return nil, fmt.Errorf("overlapping edits")
+		}
+		out = append(out, content[last:s.start]...)
+		out = append(out, s.text...)
+		last = s.end
+	}
+	return append(out, content[last:]...), nil
+}
@@ -11,16 +11,32 @@ import (
 	"gokin/internal/logging"
 )
 
+// SamplingHandler answers a server's sampling/createMessage request,
+// typically by asking the user and then calling the active model.
+type SamplingHandler func(ctx context.Context, server string, params *CreateMessageParams) (*CreateMessageResult, error)
+
+// NotificationHandler is called for notifications sent by a server.
+type NotificationHandler func(server, method string)
+
 // Client handles JSON-RPC communication with an MCP server.
 type Client struct {
-	transport   Transport
-	serverInfo  *ServerInfo
-	tools       []*ToolInfo
-	resources   []*Resource
+	transport    Transport
+	serverInfo   *ServerInfo
+	capabilities *ServerCapability
+	tools        []*ToolInfo
+	resources    []*Resource
+	prompts      []*Prompt
+
+	// Handlers for server-initiated messages (guarded by handlersMu, since
+	// they are read by the receive loop while Initialize holds mu)
+	samplingHandler     SamplingHandler
+	notificationHandler NotificationHandler
+	handlersMu          sync.RWMutex
 
 	// Connection state
 	initialized bool
 	mu          sync.RWMutex
+	initMu      sync.Mutex
 
 	// Request tracking
 	nextID     int64
@@ -30,6 +46,7 @@ type Client struct {
 	// Configuration
 	serverName string
 	config     *ServerConfig
+	tokens     *TokenStore
 
 	// Lifecycle
 	ctx    context.Context
@@ -43,20 +60,10 @@ type Client struct {
 	maxReconnectAttempts int
 }
 
-// NewClient creates a new MCP client with the specified transport.
-func NewClient(cfg *ServerConfig) (*Client, error) {
-	var transport Transport
-	var err error
-
-	switch cfg.Transport {
-	case "stdio":
-		transport, err = NewStdioTransport(cfg.Command, cfg.Args, cfg.Env)
-	case "http":
-		transport, err = NewHTTPTransport(cfg.URL, cfg.Headers, cfg.Timeout)
-	default:
-		return nil, fmt.Errorf("unknown transport type: %s", cfg.Transport)
-	}
-
+// NewClient creates a new MCP client with the specified transport. OAuth
+// tokens for HTTP servers are read from tokens, which may be nil.
+func NewClient(cfg *ServerConfig, tokens *TokenStore) (*Client, error) {
+	transport, err := newTransport(cfg, tokens)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create transport: %w", err)
 	}
@@ -67,6 +74,7 @@ func NewClient(cfg *ServerConfig) (*Client, error) {
 		transport:  transport,
 		serverName: cfg.Name,
 		config:     cfg,
+		tokens:     tokens,
 		pending:    make(map[int64]chan *JSONRPCMessage),
 		ctx:        ctx,
 		cancel:     cancel,
@@ -82,6 +90,18 @@ func NewClient(cfg *ServerConfig) (*Client, error) {
 	return c, nil
 }
 
+// newTransport creates the transport selected by the server configuration.
+func newTransport(cfg *ServerConfig, tokens *TokenStore) (Transport, error) {
+	switch cfg.Transport {
+	case "stdio":
+		return NewStdioTransport(cfg.Command, cfg.Args, cfg.Env)
+	case "http", "sse":
+		return NewHTTPTransport(cfg, tokens)
+	default:
+		return nil, fmt.Errorf("unknown transport type: %s", cfg.Transport)
+	}
+}
+
 // receiveLoop reads messages from the transport and routes them.
 func (c *Client) receiveLoop() {
 	defer close(c.done)
@@ -153,19 +173,7 @@ func (c *Client) reconnect() bool {
 	}
 
 	// Try to recreate transport
-	var transport Transport
-	var err error
-
-	switch cfg.Transport {
-	case "stdio":
-		transport, err = NewStdioTransport(cfg.Command, cfg.Args, cfg.Env)
-	case "http":
-		transport, err = NewHTTPTransport(cfg.URL, cfg.Headers, cfg.Timeout)
-	default:
-		logging.Error("MCP unknown transport for reconnect", "transport", cfg.Transport)
-		return false
-	}
-
+	transport, err := newTransport(cfg, c.tokens)
 	if err != nil {
 		logging.Warn("MCP transport recreation failed", "error", err, "consecutive_fails", c.consecutiveFails)
 		return true // Keep trying (consecutiveFails will be checked next iteration)
@@ -181,16 +189,19 @@ func (c *Client) reconnect() bool {
 	// Close old transport (ignore errors)
 	_ = oldTransport.Close()
 
-	// Re-initialize
-	initCtx, cancel := context.WithTimeout(c.ctx, 10*time.Second)
-	defer cancel()
+	// Re-initialize in the background: the response arrives through the
+	// receive loop, which must keep running to deliver it.
+	go func() {
+		initCtx, cancel := context.WithTimeout(c.ctx, 10*time.Second)
+		defer cancel()
 
-	if err := c.Initialize(initCtx); err != nil {
-		logging.Warn("MCP re-initialize failed", "error", err, "consecutive_fails", c.consecutiveFails)
-		return true // Keep trying (consecutiveFails will be checked next iteration)
-	}
-
-	logging.Info("MCP reconnected successfully", "server", c.serverName)
+		if err := c.Initialize(initCtx); err != nil {
+			logging.Warn("MCP re-initialize failed", "server", c.serverName, "error", err)
+			transport.Close() // Makes the receive loop try again
+			return
+		}
+		logging.Info("MCP reconnected successfully", "server", c.serverName)
+	}()
 	return true
 }
 
@@ -225,13 +236,82 @@ func (c *Client) handleMessage(msg *JSONRPCMessage) {
 		} else {
 			logging.Warn("MCP response for unknown request", "id", id)
 		}
+	} else if msg.IsRequest() {
+		// Server requests may wait for the user; don't block the receive loop
+		go c.handleRequest(msg)
 	} else if msg.IsNotification() {
-		// Handle notifications
-		logging.Debug("MCP notification received", "method", msg.Method)
-		// Could dispatch to notification handlers here
+		logging.Debug("MCP notification received", "server", c.serverName, "method", msg.Method)
+		c.handlersMu.RLock()
+		handler := c.notificationHandler
+		c.handlersMu.RUnlock()
+		if handler != nil {
+			handler(c.serverName, msg.Method)
+		}
+	}
+}
+
+// handleRequest answers a request sent by the server.
+func (c *Client) handleRequest(msg *JSONRPCMessage) {
+	resp := &JSONRPCMessage{ID: msg.ID}
+
+	switch msg.Method {
+	case MethodPing:
+		resp.Result = struct{}{}
+
+	case MethodSamplingCreateMessage:
+		c.handlersMu.RLock()
+		handler := c.samplingHandler
+		c.handlersMu.RUnlock()
+		if handler == nil {
+			resp.Error = &Error{Code: ErrCodeMethodNotFound, Message: "sampling is not supported"}
+			break
+		}
+
+		var params CreateMessageParams
+		if err := decodeResult(msg.Params, &params); err != nil {
+			resp.Error = &Error{Code: ErrCodeInvalidParams, Message: fmt.Sprintf("invalid sampling params: %v", err)}
+			break
+		}
+		result, err := handler(c.ctx, c.serverName, &params)
+		if err != nil {
+			resp.Error = &Error{Code: ErrCodeUserRejected, Message: err.Error()}
+			break
+		}
+		resp.Result = result
+
+	default:
+		resp.Error = &Error{Code: ErrCodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)}
+	}
+
+	if err := c.transport.Send(resp); err != nil {
+		logging.Warn("MCP failed to answer server request", "server", c.serverName, "method", msg.Method, "error", err)
 	}
 }
 
+// SetSamplingHandler enables sampling/createMessage requests from the server.
+// It must be called before Initialize so that the capability is advertised.
+func (c *Client) SetSamplingHandler(handler SamplingHandler) {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+	c.samplingHandler = handler
+}
+
+// SetNotificationHandler sets the function called for server notifications.
+func (c *Client) SetNotificationHandler(handler NotificationHandler) {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+	c.notificationHandler = handler
+}
+
+// decodeResult converts a generically decoded JSON value into a typed struct.
+func decodeResult(v any, out any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
 // request sends a request and waits for a response.
 func (c *Client) request(ctx context.Context, method string, params any) (*JSONRPCMessage, error) {
 	id := atomic.AddInt64(&c.nextID, 1)
@@ -290,21 +370,32 @@ func (c *Client) notify(method string, params any) error {
 
 // Initialize initializes the connection with the MCP server.
 func (c *Client) Initialize(ctx context.Context) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	// mu must not be held while waiting: the receive loop takes it before
+	// delivering the response. initMu keeps initializations sequential.
+	c.initMu.Lock()
+	defer c.initMu.Unlock()
 
-	if c.initialized {
+	c.mu.RLock()
+	initialized := c.initialized
+	c.mu.RUnlock()
+	if initialized {
 		return nil
 	}
 
 	// Send initialize request
+	capabilities := map[string]any{}
+	c.handlersMu.RLock()
+	if c.samplingHandler != nil {
+		capabilities["sampling"] = struct{}{}
+	}
+	c.handlersMu.RUnlock()
 	params := &InitializeParams{
-		ProtocolVersion: ProtocolVersion,
+		ProtocolVersion: LatestProtocolVersion,
 		ClientInfo: &ClientInfo{
 			Name:    "gokin",
 			Version: "1.0.0",
 		},
-		Capabilities: map[string]any{}, // We don't advertise any client capabilities yet
+		Capabilities: capabilities,
 	}
 
 	resp, err := c.request(ctx, MethodInitialize, params)
@@ -323,19 +414,41 @@ func (c *Client) Initialize(ctx context.Context) error {
 		return fmt.Errorf("failed to parse initialize result: %w", err)
 	}
 
-	c.serverInfo = result.ServerInfo
+	if !containsString(SupportedProtocolVersions, result.ProtocolVersion) {
+		logging.Warn("MCP server negotiated an unknown protocol version",
+			"server", c.serverName, "version", result.ProtocolVersion)
+	}
+	if t, ok := c.transport.(interface{ SetProtocolVersion(string) }); ok {
+		t.SetProtocolVersion(result.ProtocolVersion)
+	}
+
+	capability := &ServerCapability{}
+	if result.Capabilities != nil {
+		if err := decodeResult(result.Capabilities, capability); err != nil {
+			logging.Debug("MCP server capabilities not understood", "server", c.serverName, "error", err)
+		}
+	}
 
 	// Send initialized notification
 	if err := c.notify(MethodInitialized, nil); err != nil {
 		return fmt.Errorf("failed to send initialized notification: %w", err)
 	}
 
+	serverInfo := result.ServerInfo
+	if serverInfo == nil {
+		serverInfo = &ServerInfo{}
+	}
+
+	c.mu.Lock()
+	c.serverInfo = serverInfo
+	c.capabilities = capability
 	c.initialized = true
+	c.mu.Unlock()
 
 	logging.Info("MCP server initialized",
 		"name", c.serverName,
-		"server", c.serverInfo.Name,
-		"version", c.serverInfo.Version)
+		"server", serverInfo.Name,
+		"version", serverInfo.Version)
 
 	return nil
 }
@@ -450,6 +563,102 @@ func (c *Client) ListResources(ctx context.Context) ([]*Resource, error) {
 	return result.Resources, nil
 }
 
+// ReadResource reads the contents of a resource from the server.
+func (c *Client) ReadResource(ctx context.Context, uri string) ([]*ResourceContent, error) {
+	c.mu.RLock()
+	if !c.initialized {
+		c.mu.RUnlock()
+		return nil, fmt.Errorf("client not initialized")
+	}
+	c.mu.RUnlock()
+
+	resp, err := c.request(ctx, MethodResourcesRead, &ReadResourceParams{URI: uri})
+	if err != nil {
+		return nil, fmt.Errorf("resources/read failed: %w", err)
+	}
+
+	var result ReadResourceResult
+	if err := decodeResult(resp.Result, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse resource result: %w", err)
+	}
+
+	logging.Debug("MCP resource read",
+		"server", c.serverName,
+		"uri", uri,
+		"contents", len(result.Contents))
+
+	return result.Contents, nil
+}
+
+// ListPrompts retrieves the list of prompt templates from the server.
+func (c *Client) ListPrompts(ctx context.Context) ([]*Prompt, error) {
+	c.mu.RLock()
+	if !c.initialized {
+		c.mu.RUnlock()
+		return nil, fmt.Errorf("client not initialized")
+	}
+	c.mu.RUnlock()
+
+	resp, err := c.request(ctx, MethodPromptsList, nil)
+	if err != nil {
+		return nil, fmt.Errorf("prompts/list failed: %w", err)
+	}
+
+	var result ListPromptsResult
+	if err := decodeResult(resp.Result, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse prompts result: %w", err)
+	}
+
+	c.mu.Lock()
+	c.prompts = result.Prompts
+	c.mu.Unlock()
+
+	logging.Debug("MCP prompts listed",
+		"server", c.serverName,
+		"count", len(result.Prompts))
+
+	return result.Prompts, nil
+}
+
+// GetPrompt renders a prompt template with the given arguments.
+func (c *Client) GetPrompt(ctx context.Context, name string, args map[string]string) (*GetPromptResult, error) {
+	c.mu.RLock()
+	if !c.initialized {
+		c.mu.RUnlock()
+		return nil, fmt.Errorf("client not initialized")
+	}
+	c.mu.RUnlock()
+
+	params := &GetPromptParams{Name: name}
+	if len(args) > 0 {
+		params.Arguments = make(map[string]any, len(args))
+		for k, v := range args {
+			params.Arguments[k] = v
+		}
+	}
+
+	resp, err := c.request(ctx, MethodPromptsGet, params)
+	if err != nil {
+		return nil, fmt.Errorf("prompts/get failed: %w", err)
+	}
+
+	var result GetPromptResult
+	if err := decodeResult(resp.Result, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse prompt result: %w", err)
+	}
+	return &result, nil
+}
+
+// Capabilities returns the capabilities the server announced during initialization.
+func (c *Client) Capabilities() *ServerCapability {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.capabilities == nil {
+		return &ServerCapability{}
+	}
+	return c.capabilities
+}
+
 // GetServerInfo returns information about the connected server.
 func (c *Client) GetServerInfo() *ServerInfo {
 	c.mu.RLock()
@@ -480,6 +689,12 @@ func (c *Client) ConsecutiveFails() int {
 func (c *Client) Close() error {
 	c.cancel()
 
+	// Close transport first: the receive loop is blocked reading from it
+	c.mu.RLock()
+	transport := c.transport
+	c.mu.RUnlock()
+	closeErr := transport.Close()
+
 	// Wait for receive loop to finish
 	select {
 	case <-c.done:
@@ -487,9 +702,8 @@ func (c *Client) Close() error {
 		logging.Warn("MCP client receive loop did not stop in time")
 	}
 
-	// Close transport
-	if err := c.transport.Close(); err != nil {
-		return fmt.Errorf("failed to close transport: %w", err)
+	if closeErr != nil {
+		return fmt.Errorf("failed to close transport: %w", closeErr)
 	}
 
 	logging.Debug("MCP client closed", "server", c.serverName)
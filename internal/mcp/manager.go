@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -23,8 +24,19 @@ type Manager struct {
 	healMu        sync.Mutex // protects healingCancel and healingDone (separate from mu to avoid deadlock)
 	healingCancel context.CancelFunc
 	healingDone   chan struct{}
+
+	// Server-initiated messages
+	samplingHandler SamplingHandler
+	onToolsChanged  ToolsChangedHandler
+
+	// OAuth tokens for HTTP servers
+	tokens *TokenStore
 }
 
+// ToolsChangedHandler is called after a server's tools were refreshed, with
+// the wrappers that were removed and the ones that replace them.
+type ToolsChangedHandler func(server string, removed, added []tools.Tool)
+
 // ServerHealth tracks health status of an MCP server.
 type ServerHealth struct {
 	Healthy            bool
@@ -51,6 +63,58 @@ func NewManager(servers []*ServerConfig) *Manager {
 	return m
 }
 
+// SetSamplingHandler answers sampling requests from servers connected afterwards.
+func (m *Manager) SetSamplingHandler(handler SamplingHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.samplingHandler = handler
+}
+
+// SetToolsChangedHandler sets the function called when a server's tool list
+// changes, so that the tool registry can be updated.
+func (m *Manager) SetToolsChangedHandler(handler ToolsChangedHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onToolsChanged = handler
+}
+
+// SetTokenStore sets where OAuth tokens of HTTP servers are read from.
+func (m *Manager) SetTokenStore(store *TokenStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokens = store
+}
+
+// newClient creates a client with the manager's handlers installed.
+func (m *Manager) newClient(cfg *ServerConfig, sampling SamplingHandler, tokens *TokenStore) (*Client, error) {
+	client, err := NewClient(cfg, tokens)
+	if err != nil {
+		return nil, err
+	}
+	if sampling != nil {
+		client.SetSamplingHandler(sampling)
+	}
+	client.SetNotificationHandler(m.handleNotification)
+	return client, nil
+}
+
+// handleNotification reacts to list changes announced by a server.
+func (m *Manager) handleNotification(server, method string) {
+	switch method {
+	case MethodToolsListChanged:
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), defaultServerTimeout)
+			defer cancel()
+			if err := m.RefreshTools(ctx, server); err != nil {
+				logging.Warn("MCP tools refresh failed", "name", server, "error", err)
+			}
+		}()
+	case MethodResourcesListChanged, MethodPromptsListChanged:
+		// Resources and prompts are listed on demand
+		logging.Debug("MCP list changed", "name", server, "method", method)
+	}
+}
+
 // defaultServerTimeout is the per-server connection timeout.
 const defaultServerTimeout = 15 * time.Second
 
@@ -75,6 +139,8 @@ func (m *Manager) ConnectAll(ctx context.Context) error {
 		}
 		toConnect = append(toConnect, cfg)
 	}
+	sampling := m.samplingHandler
+	tokens := m.tokens
 	m.mu.RUnlock()
 
 	if len(toConnect) == 0 {
@@ -96,7 +162,7 @@ func (m *Manager) ConnectAll(ctx context.Context) error {
 			res := serverResult{name: cfg.Name}
 
 			// Create client (no lock needed — pure network I/O)
-			client, err := NewClient(cfg)
+			client, err := m.newClient(cfg, sampling, tokens)
 			if err != nil {
 				res.err = fmt.Errorf("failed to create client: %w", err)
 				results <- res
@@ -166,7 +232,7 @@ func (m *Manager) ConnectAll(ctx context.Context) error {
 // Must be called with m.mu held.
 func (m *Manager) connectServer(ctx context.Context, cfg *ServerConfig) error {
 	// Create client
-	client, err := NewClient(cfg)
+	client, err := m.newClient(cfg, m.samplingHandler, m.tokens)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
@@ -453,30 +519,35 @@ func (m *Manager) GetServerStatus() []*ServerStatus {
 // RefreshTools refreshes the tool list from a specific server.
 func (m *Manager) RefreshTools(ctx context.Context, name string) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 
 	client, exists := m.clients[name]
 	if !exists {
+		m.mu.Unlock()
 		return fmt.Errorf("server not connected: %s", name)
 	}
 
 	cfg, exists := m.servers[name]
 	if !exists {
+		m.mu.Unlock()
 		return fmt.Errorf("server config not found: %s", name)
 	}
 
 	// Get updated tool list
 	mcpTools, err := client.ListTools(ctx)
 	if err != nil {
+		m.mu.Unlock()
 		return fmt.Errorf("failed to list tools: %w", err)
 	}
 
 	// Remove old tools from this server
+	var removed, added []tools.Tool
 	newTools := make([]tools.Tool, 0, len(m.tools))
 	for _, t := range m.tools {
 		if mcpTool, ok := t.(*MCPTool); ok {
 			if mcpTool.GetServerName() != name {
 				newTools = append(newTools, t)
+			} else {
+				removed = append(removed, t)
 			}
 		} else {
 			newTools = append(newTools, t)
@@ -487,14 +558,98 @@ func (m *Manager) RefreshTools(ctx context.Context, name string) error {
 	for _, t := range mcpTools {
 		tool := NewMCPTool(client, name, cfg.ToolPrefix, t)
 		newTools = append(newTools, tool)
+		added = append(added, tool)
 	}
 
 	m.tools = newTools
+	onChanged := m.onToolsChanged
+	m.mu.Unlock()
 
 	logging.Debug("MCP tools refreshed", "name", name, "tools", len(mcpTools))
+
+	if onChanged != nil {
+		onChanged(name, removed, added)
+	}
 	return nil
 }
 
+// ServerResources are the resources offered by one server.
+type ServerResources struct {
+	Server    string
+	Resources []*Resource
+}
+
+// ListResources lists the resources of every connected server that offers them.
+// Servers that fail are skipped and reported in the returned error.
+func (m *Manager) ListResources(ctx context.Context) ([]*ServerResources, error) {
+	var result []*ServerResources
+	var errs []error
+	for _, name := range m.sortedConnected() {
+		client, ok := m.GetClient(name)
+		if !ok || client.Capabilities().Resources == nil {
+			continue
+		}
+		resources, err := client.ListResources(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		result = append(result, &ServerResources{Server: name, Resources: resources})
+	}
+	return result, errors.Join(errs...)
+}
+
+// ReadResource reads a resource from a connected server.
+func (m *Manager) ReadResource(ctx context.Context, server, uri string) ([]*ResourceContent, error) {
+	client, ok := m.GetClient(server)
+	if !ok {
+		return nil, fmt.Errorf("server not connected: %s", server)
+	}
+	return client.ReadResource(ctx, uri)
+}
+
+// ServerPrompts are the prompt templates offered by one server.
+type ServerPrompts struct {
+	Server  string
+	Prompts []*Prompt
+}
+
+// ListPrompts lists the prompts of every connected server that offers them.
+// Servers that fail are skipped and reported in the returned error.
+func (m *Manager) ListPrompts(ctx context.Context) ([]*ServerPrompts, error) {
+	var result []*ServerPrompts
+	var errs []error
+	for _, name := range m.sortedConnected() {
+		client, ok := m.GetClient(name)
+		if !ok || client.Capabilities().Prompts == nil {
+			continue
+		}
+		prompts, err := client.ListPrompts(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		result = append(result, &ServerPrompts{Server: name, Prompts: prompts})
+	}
+	return result, errors.Join(errs...)
+}
+
+// GetPrompt renders a prompt template of a connected server.
+func (m *Manager) GetPrompt(ctx context.Context, server, name string, args map[string]string) (*GetPromptResult, error) {
+	client, ok := m.GetClient(server)
+	if !ok {
+		return nil, fmt.Errorf("server not connected: %s", server)
+	}
+	return client.GetPrompt(ctx, name, args)
+}
+
+// sortedConnected returns the names of connected servers in a stable order.
+func (m *Manager) sortedConnected() []string {
+	names := m.GetConnectedServers()
+	sort.Strings(names)
+	return names
+}
+
 // StartHealthCheck starts a background goroutine that periodically checks
 // server health and attempts to reconnect unhealthy servers.
 func (m *Manager) StartHealthCheck(ctx context.Context, interval time.Duration) {
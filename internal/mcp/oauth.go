@@ -0,0 +1,586 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gokin/internal/auth"
+	"gokin/internal/logging"
+)
+
+// OAuthConfig holds OAuth settings for an HTTP server. All fields are
+// optional: endpoints are discovered from the server and the client is
+// registered dynamically when no client ID is given.
+type OAuthConfig struct {
+	ClientID     string   `yaml:"client_id,omitempty" json:"clientId,omitempty"`
+	ClientSecret string   `yaml:"client_secret,omitempty" json:"clientSecret,omitempty"`
+	Scopes       []string `yaml:"scopes,omitempty" json:"scopes,omitempty"`
+	CallbackPort int      `yaml:"callback_port,omitempty" json:"callbackPort,omitempty"` // Local port for the redirect (default: any free port)
+}
+
+// AuthRequiredError is returned when a server requires authorization and no
+// usable token is stored.
+type AuthRequiredError struct {
+	Server string
+}
+
+func (e *AuthRequiredError) Error() string {
+	return fmt.Sprintf("server %s requires authorization (run 'gokin mcp login %s')", e.Server, e.Server)
+}
+
+// StoredToken is the OAuth state kept for one server: the client registration
+// and the current tokens.
+type StoredToken struct {
+	ServerURL    string `json:"server_url"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	RedirectURI  string `json:"redirect_uri,omitempty"`
+	TokenURL     string `json:"token_url"`
+	Resource     string `json:"resource,omitempty"`
+
+	Token *auth.OAuthToken `json:"token"`
+}
+
+// TokenStore persists OAuth tokens per server in a JSON file readable only
+// by the user.
+type TokenStore struct {
+	path string
+
+	mu     sync.Mutex
+	tokens map[string]*StoredToken
+	loaded bool
+}
+
+// NewTokenStore creates a token store backed by the file at path.
+func NewTokenStore(path string) *TokenStore {
+	return &TokenStore{path: path}
+}
+
+// Get returns the stored token for a server, or nil when none is stored for
+// the server's current URL.
+func (s *TokenStore) Get(server, serverURL string) *StoredToken {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+
+	st, ok := s.tokens[server]
+	if !ok || st.ServerURL != serverURL {
+		return nil
+	}
+	cp := *st
+	if st.Token != nil {
+		tok := *st.Token
+		cp.Token = &tok
+	}
+	return &cp
+}
+
+// Put stores the token for a server.
+func (s *TokenStore) Put(server string, st *StoredToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+
+	s.tokens[server] = st
+	return s.save()
+}
+
+// Delete removes the token for a server. It reports whether one was stored.
+func (s *TokenStore) Delete(server string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+
+	if _, ok := s.tokens[server]; !ok {
+		return false, nil
+	}
+	delete(s.tokens, server)
+	return true, s.save()
+}
+
+// load reads the token file once. Caller must hold s.mu.
+func (s *TokenStore) load() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	s.tokens = make(map[string]*StoredToken)
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logging.Warn("failed to read MCP token store", "path", s.path, "error", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &s.tokens); err != nil {
+		logging.Warn("failed to parse MCP token store", "path", s.path, "error", err)
+		s.tokens = make(map[string]*StoredToken)
+	}
+}
+
+// save writes the token file atomically. Caller must hold s.mu.
+func (s *TokenStore) save() error {
+	data, err := json.MarshalIndent(s.tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create token directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write tokens: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// oauthTokens supplies access tokens for one server to the HTTP transport,
+// refreshing them when they expire.
+type oauthTokens struct {
+	server    string
+	serverURL string
+	store     *TokenStore
+
+	mu sync.Mutex
+}
+
+func newOAuthTokens(server, serverURL string, store *TokenStore) *oauthTokens {
+	if store == nil {
+		return nil
+	}
+	return &oauthTokens{server: server, serverURL: serverURL, store: store}
+}
+
+// token returns the current access token, or "" when none is stored.
+func (o *oauthTokens) token(ctx context.Context) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	st := o.store.Get(o.server, o.serverURL)
+	if st == nil || st.Token == nil {
+		return "", nil
+	}
+	if st.Token.IsExpired() && st.Token.RefreshToken != "" {
+		return o.refreshLocked(ctx, st)
+	}
+	return st.Token.AccessToken, nil
+}
+
+// refresh exchanges the refresh token for a new access token after the
+// server rejected the current one.
+func (o *oauthTokens) refresh(ctx context.Context) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	st := o.store.Get(o.server, o.serverURL)
+	if st == nil || st.Token == nil || st.Token.RefreshToken == "" {
+		return "", &AuthRequiredError{Server: o.server}
+	}
+	return o.refreshLocked(ctx, st)
+}
+
+func (o *oauthTokens) refreshLocked(ctx context.Context, st *StoredToken) (string, error) {
+	manager := auth.NewOAuthManager(auth.OAuthConfig{
+		ClientID:     st.ClientID,
+		ClientSecret: st.ClientSecret,
+		TokenURL:     st.TokenURL,
+		Resource:     st.Resource,
+	})
+	tok, err := manager.RefreshToken(ctx, st.Token.RefreshToken)
+	if err != nil {
+		logging.Warn("MCP OAuth token refresh failed", "server", o.server, "error", err)
+		return "", &AuthRequiredError{Server: o.server}
+	}
+
+	st.Token = tok
+	if err := o.store.Put(o.server, st); err != nil {
+		logging.Warn("failed to save refreshed MCP token", "server", o.server, "error", err)
+	}
+	logging.Debug("MCP OAuth token refreshed", "server", o.server)
+	return tok.AccessToken, nil
+}
+
+// authServerMetadata is the subset of RFC 8414 metadata used by the flow.
+type authServerMetadata struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	RegistrationEndpoint          string   `json:"registration_endpoint,omitempty"`
+	ScopesSupported               []string `json:"scopes_supported,omitempty"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported,omitempty"`
+}
+
+// protectedResourceMetadata is the subset of RFC 9728 metadata used by the flow.
+type protectedResourceMetadata struct {
+	Resource             string   `json:"resource"`
+	AuthorizationServers []string `json:"authorization_servers"`
+	ScopesSupported      []string `json:"scopes_supported,omitempty"`
+}
+
+// LoginOptions are the interactive parts of the authorization flow.
+type LoginOptions struct {
+	// OpenBrowser opens the authorization URL; it reports whether it succeeded.
+	OpenBrowser func(url string) bool
+
+	// Status receives progress messages for the user.
+	Status func(msg string)
+}
+
+// Login runs the OAuth 2.1 authorization code flow with PKCE for an HTTP
+// server and stores the resulting tokens. The authorization server is
+// discovered from the server's protected resource metadata, and the client
+// is registered dynamically unless a client ID is configured.
+func Login(ctx context.Context, cfg *ServerConfig, store *TokenStore, opts LoginOptions) error {
+	if cfg.Transport != "http" && cfg.Transport != "sse" {
+		return fmt.Errorf("server %s does not use the http transport", cfg.Name)
+	}
+	status := opts.Status
+	if status == nil {
+		status = func(string) {}
+	}
+
+	httpClient := &http.Client{Timeout: auth.OAuthHTTPTimeout}
+	resource := canonicalResource(cfg.URL)
+
+	prm := discoverProtectedResource(ctx, httpClient, cfg)
+	issuer := originOf(cfg.URL)
+	var scopes []string
+	if prm != nil {
+		// RFC 9728 §3.3: metadata naming another resource must not be used
+		if prm.Resource != "" && !resourceCovers(prm.Resource, resource) {
+			return fmt.Errorf("protected resource metadata of %s is for another resource: %s", cfg.Name, prm.Resource)
+		}
+		if len(prm.AuthorizationServers) > 0 {
+			issuer = prm.AuthorizationServers[0]
+		}
+		if prm.Resource != "" {
+			resource = prm.Resource
+		}
+		scopes = prm.ScopesSupported
+	}
+
+	meta, err := discoverAuthServer(ctx, httpClient, issuer)
+	if err != nil {
+		return err
+	}
+	if len(meta.CodeChallengeMethodsSupported) > 0 && !containsString(meta.CodeChallengeMethodsSupported, "S256") {
+		return fmt.Errorf("authorization server %s does not support PKCE with S256", issuer)
+	}
+
+	var oauthCfg OAuthConfig
+	if cfg.OAuth != nil {
+		oauthCfg = *cfg.OAuth
+	}
+	if len(oauthCfg.Scopes) > 0 {
+		scopes = oauthCfg.Scopes
+	}
+
+	// Reuse the previous registration and its redirect port when possible,
+	// since registrations are bound to the redirect URI.
+	previous := store.Get(cfg.Name, cfg.URL)
+	port := oauthCfg.CallbackPort
+	if port == 0 && previous != nil && oauthCfg.ClientID == "" {
+		if p := redirectPort(previous.RedirectURI); p != 0 && portFree(p) {
+			port = p
+		}
+	}
+	if port == 0 {
+		if port, err = freePort(); err != nil {
+			return fmt.Errorf("failed to find a callback port: %w", err)
+		}
+	}
+	redirectURI := fmt.Sprintf("http://localhost:%d/oauth2callback", port)
+
+	clientID, clientSecret := oauthCfg.ClientID, oauthCfg.ClientSecret
+	if clientID == "" && previous != nil && previous.RedirectURI == redirectURI && previous.TokenURL == meta.TokenEndpoint {
+		clientID, clientSecret = previous.ClientID, previous.ClientSecret
+	}
+	if clientID == "" {
+		if meta.RegistrationEndpoint == "" {
+			return fmt.Errorf("authorization server %s does not support dynamic client registration; set oauth.client_id for %s", issuer, cfg.Name)
+		}
+		status("Registering gokin with the authorization server...")
+		clientID, clientSecret, err = registerClient(ctx, httpClient, meta.RegistrationEndpoint, redirectURI)
+		if err != nil {
+			return err
+		}
+	}
+
+	manager := auth.NewOAuthManager(auth.OAuthConfig{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURI:  redirectURI,
+		Scopes:       scopes,
+		AuthURL:      meta.AuthorizationEndpoint,
+		TokenURL:     meta.TokenEndpoint,
+		Resource:     resource,
+	})
+	authURL, err := manager.GenerateAuthURL()
+	if err != nil {
+		return fmt.Errorf("failed to generate auth URL: %w", err)
+	}
+
+	server := auth.NewCallbackServer(port, manager.GetState())
+	if err := server.Start(); err != nil {
+		return fmt.Errorf("failed to start callback server: %w", err)
+	}
+	defer server.Stop()
+
+	if opts.OpenBrowser == nil || !opts.OpenBrowser(authURL) {
+		status("Open this URL in your browser to authorize gokin:\n\n" + authURL + "\n")
+	} else {
+		status("Opened the browser to authorize gokin...")
+	}
+	status("Waiting for authorization (timeout: 5 minutes)...")
+
+	code, err := server.WaitForCode(auth.OAuthCallbackTimeout)
+	if err != nil {
+		return fmt.Errorf("authorization failed: %w", err)
+	}
+	token, err := manager.ExchangeCode(ctx, code)
+	if err != nil {
+		return fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	return store.Put(cfg.Name, &StoredToken{
+		ServerURL:    cfg.URL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURI:  redirectURI,
+		TokenURL:     meta.TokenEndpoint,
+		Resource:     resource,
+		Token:        token,
+	})
+}
+
+// discoverProtectedResource finds the server's protected resource metadata,
+// first from the WWW-Authenticate header of an unauthorized request, then at
+// the well-known locations. It returns nil for servers without metadata.
+func discoverProtectedResource(ctx context.Context, httpClient *http.Client, cfg *ServerConfig) *protectedResourceMetadata {
+	var candidates []string
+
+	probe := []byte(`{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"` + LatestProtocolVersion + `","capabilities":{},"clientInfo":{"name":"gokin","version":"1.0.0"}}}`)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, bytes.NewReader(probe))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		for k, v := range cfg.Headers {
+			req.Header.Set(k, os.ExpandEnv(v))
+		}
+		if resp, err := httpClient.Do(req); err == nil {
+			resp.Body.Close()
+			if u := authParam(resp.Header.Get("WWW-Authenticate"), "resource_metadata"); u != "" {
+				candidates = append(candidates, u)
+			}
+		}
+	}
+
+	if u, err := url.Parse(cfg.URL); err == nil {
+		origin := u.Scheme + "://" + u.Host
+		if path := strings.TrimSuffix(u.Path, "/"); path != "" {
+			candidates = append(candidates, origin+"/.well-known/oauth-protected-resource"+path)
+		}
+		candidates = append(candidates, origin+"/.well-known/oauth-protected-resource")
+	}
+
+	for _, u := range candidates {
+		var prm protectedResourceMetadata
+		if err := getJSON(ctx, httpClient, u, &prm); err == nil && len(prm.AuthorizationServers) > 0 {
+			return &prm
+		}
+	}
+	return nil
+}
+
+// discoverAuthServer fetches RFC 8414 or OpenID Connect metadata for an
+// issuer. Servers without metadata get the default endpoints relative to
+// the issuer, as in the 2025-03-26 specification.
+func discoverAuthServer(ctx context.Context, httpClient *http.Client, issuer string) (*authServerMetadata, error) {
+	u, err := url.Parse(issuer)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid authorization server %q", issuer)
+	}
+	origin := u.Scheme + "://" + u.Host
+	path := strings.TrimSuffix(u.Path, "/")
+
+	candidates := []string{
+		origin + "/.well-known/oauth-authorization-server" + path,
+		origin + "/.well-known/openid-configuration" + path,
+	}
+	if path != "" {
+		candidates = append(candidates, origin+path+"/.well-known/openid-configuration")
+	}
+
+	for _, c := range candidates {
+		var meta authServerMetadata
+		if err := getJSON(ctx, httpClient, c, &meta); err == nil && meta.AuthorizationEndpoint != "" && meta.TokenEndpoint != "" {
+			return &meta, nil
+		}
+	}
+
+	logging.Debug("no authorization server metadata, using default endpoints", "issuer", issuer)
+	return &authServerMetadata{
+		Issuer:                issuer,
+		AuthorizationEndpoint: origin + "/authorize",
+		TokenEndpoint:         origin + "/token",
+		RegistrationEndpoint:  origin + "/register",
+	}, nil
+}
+
+// registerClient registers gokin as a public client (RFC 7591).
+func registerClient(ctx context.Context, httpClient *http.Client, endpoint, redirectURI string) (string, string, error) {
+	body, _ := json.Marshal(map[string]any{
+		"client_name":                "gokin",
+		"redirect_uris":              []string{redirectURI},
+		"grant_types":                []string{"authorization_code", "refresh_token"},
+		"response_types":             []string{"code"},
+		"token_endpoint_auth_method": "none",
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("client registration failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", "", fmt.Errorf("client registration failed (%d): %s", resp.StatusCode, string(data))
+	}
+
+	var reg struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret,omitempty"`
+	}
+	if err := json.Unmarshal(data, &reg); err != nil || reg.ClientID == "" {
+		return "", "", errors.New("client registration returned no client_id")
+	}
+	return reg.ClientID, reg.ClientSecret, nil
+}
+
+func getJSON(ctx context.Context, httpClient *http.Client, u string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("MCP-Protocol-Version", LatestProtocolVersion)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
+
+// authParam extracts a parameter from a WWW-Authenticate challenge.
+func authParam(header, name string) string {
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if i := strings.LastIndex(part, " "); i >= 0 && !strings.Contains(part[:i], "=") {
+			part = part[i+1:] // Strip the scheme ("Bearer x=y")
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if ok && strings.EqualFold(strings.TrimSpace(key), name) {
+			return strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return ""
+}
+
+// canonicalResource returns the RFC 8707 resource identifier for a server URL.
+func canonicalResource(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawQuery = ""
+	return strings.TrimSuffix(u.String(), "/")
+}
+
+// resourceCovers reports whether the resource identifier names the server
+// URL: the same origin, with a path equal to or a prefix of the server's.
+func resourceCovers(resource, serverURL string) bool {
+	r, err := url.Parse(canonicalResource(resource))
+	if err != nil {
+		return false
+	}
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return false
+	}
+	if r.Scheme != u.Scheme || r.Host != u.Host {
+		return false
+	}
+	prefix := strings.TrimSuffix(r.Path, "/")
+	return u.Path == prefix || strings.HasPrefix(u.Path, prefix+"/")
+}
+
+func originOf(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func redirectPort(redirectURI string) int {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return 0
+	}
+	port, _ := strconv.Atoi(u.Port())
+	return port
+}
+
+func portFree(port int) bool {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	ln.Close()
+	return true
+}
+
+func freePort() (int, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxResourceText limits how much of a resource is attached to a message.
+const maxResourceText = 100 * 1024
+
+// ResourceMention is an @server:uri reference to a resource in a chat message.
+type ResourceMention struct {
+	Text   string // The mention as written, including "@"
+	Server string
+	URI    string
+}
+
+// ParseResourceMentions finds @server:uri references to the given servers.
+// Trailing punctuation is not part of the URI, so "see @docs:guide://intro."
+// mentions "guide://intro".
+func ParseResourceMentions(text string, servers []string) []ResourceMention {
+	if len(servers) == 0 || !strings.Contains(text, "@") {
+		return nil
+	}
+	known := make(map[string]bool, len(servers))
+	for _, s := range servers {
+		known[s] = true
+	}
+
+	var mentions []ResourceMention
+	seen := make(map[string]bool)
+	for _, field := range strings.Fields(text) {
+		if !strings.HasPrefix(field, "@") {
+			continue
+		}
+		server, uri, ok := strings.Cut(field[1:], ":")
+		if !ok || !known[server] {
+			continue
+		}
+		uri = strings.TrimRight(uri, ".,;:!?)]}'\"")
+		if uri == "" {
+			continue
+		}
+		mention := "@" + server + ":" + uri
+		if seen[mention] {
+			continue
+		}
+		seen[mention] = true
+		mentions = append(mentions, ResourceMention{Text: mention, Server: server, URI: uri})
+	}
+	return mentions
+}
+
+// FormatResourceContents renders resource contents as text for the model.
+// Binary contents are described rather than included.
+func FormatResourceContents(contents []*ResourceContent) string {
+	var sb strings.Builder
+	for i, c := range contents {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		switch {
+		case c.Text != "":
+			text := c.Text
+			if len(text) > maxResourceText {
+				text = text[:maxResourceText] + "\n...[truncated]"
+			}
+			sb.WriteString(text)
+		case c.Blob != "":
+			sb.WriteString(fmt.Sprintf("[binary content: %s, %d bytes base64]", mimeOrUnknown(c.MIMEType), len(c.Blob)))
+		default:
+			sb.WriteString("(empty)")
+		}
+	}
+	return sb.String()
+}
+
+// FormatPromptMessages renders the messages of a prompt as a single user
+// message. A prompt made only of user messages becomes their text; other
+// roles are labelled so the model sees the intended conversation.
+func FormatPromptMessages(result *GetPromptResult) string {
+	onlyUser := true
+	for _, msg := range result.Messages {
+		if msg.Role != "user" {
+			onlyUser = false
+			break
+		}
+	}
+
+	var parts []string
+	for _, msg := range result.Messages {
+		if msg.Content == nil {
+			continue
+		}
+		text := formatContentBlocks([]*ContentBlock{msg.Content})
+		if !onlyUser {
+			role := msg.Role
+			if role != "" {
+				role = strings.ToUpper(role[:1]) + role[1:]
+			}
+			text = role + ": " + text
+		}
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func mimeOrUnknown(mime string) string {
+	if mime == "" {
+		return "unknown type"
+	}
+	return mime
+}
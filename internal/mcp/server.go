@@ -0,0 +1,350 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"gokin/internal/logging"
+	"gokin/internal/tools"
+)
+
+// SupportedProtocolVersions lists the protocol versions the server accepts,
+// newest first. The server answers initialize with the client's version when
+// it is supported and with the newest one otherwise.
+var SupportedProtocolVersions = []string{LatestProtocolVersion, "2025-03-26", ProtocolVersion}
+
+// ToolCaller executes a tool call on behalf of a remote client.
+type ToolCaller func(ctx context.Context, name string, args map[string]any) tools.ToolResult
+
+// ServerOptions configures an MCP server.
+type ServerOptions struct {
+	Name         string
+	Version      string
+	Instructions string // Optional usage hints returned from initialize
+
+	// Allow reports whether a tool is exposed to clients (nil exposes all).
+	Allow func(name string) bool
+}
+
+// Server exposes Gokin tools to MCP clients.
+// It is transport-agnostic: ServeStdio and HTTPHandler feed it messages.
+type Server struct {
+	opts   ServerOptions
+	lister tools.ToolLister
+	call   ToolCaller
+}
+
+// NewServer creates a server that lists tools from lister and executes them with call.
+func NewServer(opts ServerOptions, lister tools.ToolLister, call ToolCaller) *Server {
+	if opts.Name == "" {
+		opts.Name = "gokin"
+	}
+	return &Server{opts: opts, lister: lister, call: call}
+}
+
+// serverRequest is an incoming message. ID and params are kept raw so that
+// IDs are echoed back unchanged and params decode into typed structs.
+type serverRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+func (r *serverRequest) isNotification() bool {
+	return len(r.ID) == 0 || string(r.ID) == "null"
+}
+
+// serverSession is the per-connection state of a client.
+type serverSession struct {
+	mu         sync.Mutex
+	clientName string
+	protocol   string
+	inflight   map[string]context.CancelFunc
+}
+
+func newServerSession() *serverSession {
+	return &serverSession{inflight: make(map[string]context.CancelFunc)}
+}
+
+// caller identifies the session's client in audit entries.
+func (s *serverSession) caller() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clientName == "" {
+		return "mcp"
+	}
+	return "mcp:" + s.clientName
+}
+
+// track registers a cancellable in-flight request and returns its release func.
+func (s *serverSession) track(id json.RawMessage, cancel context.CancelFunc) func() {
+	key := string(id)
+	s.mu.Lock()
+	s.inflight[key] = cancel
+	s.mu.Unlock()
+	return func() {
+		s.mu.Lock()
+		delete(s.inflight, key)
+		s.mu.Unlock()
+		cancel()
+	}
+}
+
+// cancel aborts an in-flight request.
+func (s *serverSession) cancel(id json.RawMessage) {
+	s.mu.Lock()
+	cancel := s.inflight[string(id)]
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// handle processes one message and returns the response, or nil for notifications.
+func (s *Server) handle(ctx context.Context, sess *serverSession, req *serverRequest) *JSONRPCMessage {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		if req.isNotification() {
+			return nil
+		}
+		return errorResponse(req.ID, ErrCodeInvalidRequest, "invalid JSON-RPC request")
+	}
+
+	if req.isNotification() {
+		switch req.Method {
+		case MethodCancelled:
+			var params struct {
+				RequestID json.RawMessage `json:"requestId"`
+			}
+			if err := json.Unmarshal(req.Params, &params); err == nil && len(params.RequestID) > 0 {
+				sess.cancel(params.RequestID)
+			}
+		case MethodInitialized:
+		default:
+			logging.Debug("MCP server ignoring notification", "method", req.Method)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer sess.track(req.ID, cancel)()
+
+	var (
+		result any
+		rpcErr *Error
+	)
+	switch req.Method {
+	case MethodInitialize:
+		result, rpcErr = s.initialize(sess, req.Params)
+	case MethodPing:
+		result = struct{}{}
+	case MethodToolsList:
+		result = &ListToolsResult{Tools: s.listTools()}
+	case MethodToolsCall:
+		result, rpcErr = s.callTool(tools.ContextWithCaller(ctx, sess.caller()), req.Params)
+	default:
+		rpcErr = &Error{Code: ErrCodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)}
+	}
+
+	if rpcErr != nil {
+		return &JSONRPCMessage{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+	}
+	return &JSONRPCMessage{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+// initialize negotiates the protocol version and records the client's name.
+func (s *Server) initialize(sess *serverSession, raw json.RawMessage) (any, *Error) {
+	var params InitializeParams
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, &Error{Code: ErrCodeInvalidParams, Message: fmt.Sprintf("invalid initialize params: %v", err)}
+		}
+	}
+
+	version := SupportedProtocolVersions[0]
+	for _, v := range SupportedProtocolVersions {
+		if v == params.ProtocolVersion {
+			version = v
+			break
+		}
+	}
+
+	sess.mu.Lock()
+	sess.protocol = version
+	if params.ClientInfo != nil {
+		sess.clientName = params.ClientInfo.Name
+	}
+	sess.mu.Unlock()
+
+	logging.Info("MCP client connected", "client", sess.caller(), "protocol", version)
+
+	return &InitializeResult{
+		ProtocolVersion: version,
+		ServerInfo:      &ServerInfo{Name: s.opts.Name, Version: s.opts.Version},
+		Capabilities:    &ServerCapability{Tools: &ToolsCapability{}},
+		Instructions:    s.opts.Instructions,
+	}, nil
+}
+
+// listTools converts the exposed tool declarations to MCP tool descriptions.
+func (s *Server) listTools() []*ToolInfo {
+	decls := s.lister.Declarations()
+	list := make([]*ToolInfo, 0, len(decls))
+	for _, decl := range decls {
+		if decl == nil || !s.allowed(decl.Name) {
+			continue
+		}
+		schema := ConvertGeminiSchemaToMCP(decl.Parameters)
+		if schema == nil {
+			schema = &JSONSchema{Type: "object"}
+		}
+		list = append(list, &ToolInfo{
+			Name:        decl.Name,
+			Description: decl.Description,
+			InputSchema: schema,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// callTool runs a tool and wraps its output as text content.
+// Tool failures are reported in the result with isError, as the protocol asks,
+// so that the calling model can see them.
+func (s *Server) callTool(ctx context.Context, raw json.RawMessage) (any, *Error) {
+	var params CallToolParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, &Error{Code: ErrCodeInvalidParams, Message: fmt.Sprintf("invalid tools/call params: %v", err)}
+	}
+	if params.Name == "" || !s.allowed(params.Name) || !s.declared(params.Name) {
+		return nil, &Error{Code: ErrCodeInvalidParams, Message: fmt.Sprintf("unknown tool: %s", params.Name)}
+	}
+
+	result := s.call(ctx, params.Name, params.Arguments)
+	if !result.Success {
+		text := result.Error
+		if text == "" {
+			text = "tool failed"
+		}
+		if result.Content != "" {
+			text = result.Content + "\n\n" + text
+		}
+		return &CallToolResult{Content: []*ContentBlock{{Type: "text", Text: text}}, IsError: true}, nil
+	}
+
+	text := result.Content
+	if text == "" {
+		text = "(no output)"
+	}
+	return &CallToolResult{Content: []*ContentBlock{{Type: "text", Text: text}}}, nil
+}
+
+func (s *Server) allowed(name string) bool {
+	return s.opts.Allow == nil || s.opts.Allow(name)
+}
+
+func (s *Server) declared(name string) bool {
+	for _, n := range s.lister.Names() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *JSONRPCMessage {
+	msg := &JSONRPCMessage{JSONRPC: "2.0", Error: &Error{Code: code, Message: message}}
+	if len(id) > 0 {
+		msg.ID = id
+	} else {
+		// Responses to unidentifiable requests carry a null ID
+		msg.ID = json.RawMessage("null")
+	}
+	return msg
+}
+
+// ServeStdio serves a single client over newline-delimited JSON on r and w
+// until r is exhausted or ctx is cancelled. Requests are handled concurrently;
+// responses are written in completion order.
+func (s *Server) ServeStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sess := newServerSession()
+	var (
+		writeMu sync.Mutex
+		wg      sync.WaitGroup
+	)
+	write := func(msg *JSONRPCMessage) {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			logging.Warn("MCP server failed to encode response", "error", err)
+			return
+		}
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		w.Write(append(data, '\n'))
+	}
+
+	lines := make(chan []byte)
+	scanErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		scanErr <- scanner.Err()
+		close(lines)
+	}()
+
+	var err error
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break loop
+		case line, ok := <-lines:
+			if !ok {
+				err = <-scanErr
+				break loop
+			}
+			if len(line) == 0 {
+				continue
+			}
+			var req serverRequest
+			if jsonErr := json.Unmarshal(line, &req); jsonErr != nil {
+				write(errorResponse(nil, ErrCodeParseError, "parse error"))
+				continue
+			}
+			if req.isNotification() || req.Method == MethodInitialize {
+				// Handled inline so that cancellations and initialization are ordered
+				if resp := s.handle(ctx, sess, &req); resp != nil {
+					write(resp)
+				}
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if resp := s.handle(ctx, sess, &req); resp != nil {
+					write(resp)
+				}
+			}()
+		}
+	}
+
+	wg.Wait()
+	return err
+}
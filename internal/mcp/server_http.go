@@ -0,0 +1,312 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gokin/internal/logging"
+)
+
+// SessionHeader carries the session ID of the streamable HTTP transport.
+const SessionHeader = "Mcp-Session-Id"
+
+// maxHTTPBody limits the size of a single POST body.
+const maxHTTPBody = 16 * 1024 * 1024
+
+// Session limits of the HTTP transport. Clients are expected to DELETE their
+// session, but many never do.
+const (
+	httpSessionIdleTimeout = 30 * time.Minute // Sessions unused this long are dropped
+	maxHTTPSessions        = 256              // Least recently used sessions are dropped beyond this
+)
+
+// HTTPOptions configures the streamable HTTP transport of a Server.
+type HTTPOptions struct {
+	// Token, when set, is required as "Authorization: Bearer <token>".
+	Token string
+
+	// AllowedOrigins lists browser origins besides localhost that may call
+	// the server. Requests with any other Origin header are rejected to
+	// prevent DNS rebinding.
+	AllowedOrigins []string
+}
+
+// httpHandler implements the streamable HTTP transport: clients POST JSON-RPC
+// messages and receive JSON responses. The server never initiates requests,
+// so there is no SSE stream and GET is not supported.
+type httpHandler struct {
+	server *Server
+	opts   HTTPOptions
+	ctx    context.Context
+
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+}
+
+// httpSession is a session of the HTTP transport.
+type httpSession struct {
+	*serverSession
+	lastUsed time.Time
+}
+
+// HTTPHandler returns an http.Handler serving the streamable HTTP transport.
+// Requests are cancelled when ctx is done.
+func (s *Server) HTTPHandler(ctx context.Context, opts HTTPOptions) http.Handler {
+	return &httpHandler{
+		server:   s,
+		opts:     opts,
+		ctx:      ctx,
+		sessions: make(map[string]*httpSession),
+	}
+}
+
+// ServeHTTP dispatches on the request method after authorization checks.
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.originAllowed(r.Header.Get("Origin")) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	if h.opts.Token != "" {
+		auth := r.Header.Get("Authorization")
+		token, ok := strings.CutPrefix(auth, "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.opts.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gokin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		h.handlePost(w, r)
+	case http.MethodDelete:
+		id := r.Header.Get(SessionHeader)
+		h.mu.Lock()
+		_, ok := h.sessions[id]
+		delete(h.sessions, id)
+		h.mu.Unlock()
+		if !ok {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePost handles a single message or a batch.
+func (h *httpHandler) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxHTTPBody+1))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxHTTPBody {
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	body = bytes.TrimSpace(body)
+	batch := len(body) > 0 && body[0] == '['
+	var reqs []*serverRequest
+	if batch {
+		err = json.Unmarshal(body, &reqs)
+	} else {
+		var req serverRequest
+		err = json.Unmarshal(body, &req)
+		reqs = []*serverRequest{&req}
+	}
+	if err != nil || len(reqs) == 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse(nil, ErrCodeParseError, "parse error"))
+		return
+	}
+
+	// initialize starts a session; every other message must belong to one
+	// when the client sends a session ID.
+	var sess *serverSession
+	sessionID := r.Header.Get(SessionHeader)
+	if len(reqs) == 1 && reqs[0].Method == MethodInitialize {
+		sess = newServerSession()
+		sessionID = newSessionID()
+		h.addSession(sessionID, sess)
+		w.Header().Set(SessionHeader, sessionID)
+	} else if sessionID != "" {
+		sess = h.session(sessionID)
+		if sess == nil {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+	} else {
+		// Stateless client: no cancellation or client name across requests
+		sess = newServerSession()
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	stop := context.AfterFunc(h.ctx, cancel)
+	defer stop()
+
+	responses := make([]*JSONRPCMessage, len(reqs))
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		if req.isNotification() {
+			h.server.handle(ctx, sess, req)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i] = h.server.handle(ctx, sess, req)
+		}()
+	}
+	wg.Wait()
+
+	var out []*JSONRPCMessage
+	for _, resp := range responses {
+		if resp != nil {
+			out = append(out, resp)
+		}
+	}
+	switch {
+	case len(out) == 0:
+		w.WriteHeader(http.StatusAccepted)
+	case batch:
+		writeJSON(w, http.StatusOK, out)
+	default:
+		writeJSON(w, http.StatusOK, out[0])
+	}
+}
+
+// addSession stores a new session, dropping idle ones and, at the limit,
+// the least recently used.
+func (h *httpHandler) addSession(id string, sess *serverSession) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	h.expireLocked(now)
+	for len(h.sessions) >= maxHTTPSessions {
+		var oldestID string
+		var oldest time.Time
+		for sid, s := range h.sessions {
+			if oldestID == "" || s.lastUsed.Before(oldest) {
+				oldestID, oldest = sid, s.lastUsed
+			}
+		}
+		delete(h.sessions, oldestID)
+		logging.Debug("MCP HTTP session evicted", "session_id", oldestID)
+	}
+	h.sessions[id] = &httpSession{serverSession: sess, lastUsed: now}
+}
+
+// session returns a live session and marks it used, or nil.
+func (h *httpHandler) session(id string) *serverSession {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	h.expireLocked(now)
+	s, ok := h.sessions[id]
+	if !ok {
+		return nil
+	}
+	s.lastUsed = now
+	return s.serverSession
+}
+
+// expireLocked drops the sessions idle for longer than
+// httpSessionIdleTimeout. Callers must hold h.mu.
+func (h *httpHandler) expireLocked(now time.Time) {
+	for id, s := range h.sessions {
+		if now.Sub(s.lastUsed) > httpSessionIdleTimeout {
+			delete(h.sessions, id)
+		}
+	}
+}
+
+// originAllowed accepts requests without an Origin header (non-browser
+// clients), from localhost, and from the configured origins.
+func (h *httpHandler) originAllowed(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range h.opts.AllowedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		logging.Warn("MCP server failed to encode response", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func newSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ListenAndServeHTTP serves the streamable HTTP transport at addr under path
+// until ctx is cancelled.
+func (s *Server) ListenAndServeHTTP(ctx context.Context, addr, path string, opts HTTPOptions) error {
+	if path == "" {
+		path = "/mcp"
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, s.HTTPHandler(ctx, opts))
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logging.Warn("MCP HTTP server shutdown failed", "error", err)
+		}
+		return nil
+	}
+}
@@ -177,7 +177,11 @@ func formatContentBlocks(blocks []*ContentBlock) string {
 		case "image":
 			parts = append(parts, fmt.Sprintf("[Image: %s]", block.MIMEType))
 		case "resource":
-			parts = append(parts, fmt.Sprintf("[Resource: %s]", block.URI))
+			if block.Resource != nil {
+				parts = append(parts, fmt.Sprintf("[Resource: %s]\n%s", block.Resource.URI, FormatResourceContents([]*ResourceContent{block.Resource})))
+			} else {
+				parts = append(parts, fmt.Sprintf("[Resource: %s]", block.URI))
+			}
 		default:
 			if block.Text != "" {
 				parts = append(parts, block.Text)
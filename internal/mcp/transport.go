@@ -2,12 +2,9 @@ package mcp
 
 import (
 	"bufio"
-	"bytes"
-	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"strings"
@@ -283,152 +280,3 @@ func (t *StdioTransport) Close() error {
 
 	return nil
 }
-
-// HTTPTransport communicates with an MCP server via HTTP (Streamable HTTP).
-type HTTPTransport struct {
-	url     string
-	headers map[string]string
-	timeout time.Duration
-
-	// For receiving messages (long-polling or SSE)
-	recvChan chan *JSONRPCMessage
-	errChan  chan error
-
-	mu     sync.Mutex
-	closed bool
-	ctx    context.Context
-	cancel context.CancelFunc
-}
-
-// NewHTTPTransport creates a new HTTP transport.
-func NewHTTPTransport(url string, headers map[string]string, timeout time.Duration) (*HTTPTransport, error) {
-	if timeout == 0 {
-		timeout = 30 * time.Second
-	}
-
-	ctx, cancel := context.WithCancel(context.Background())
-
-	t := &HTTPTransport{
-		url:      url,
-		headers:  headers,
-		timeout:  timeout,
-		recvChan: make(chan *JSONRPCMessage, 10),
-		errChan:  make(chan error, 1),
-		ctx:      ctx,
-		cancel:   cancel,
-	}
-
-	logging.Debug("MCP HTTP transport created", "url", url)
-
-	return t, nil
-}
-
-// Send sends a JSON-RPC message to the server via HTTP POST.
-func (t *HTTPTransport) Send(msg *JSONRPCMessage) error {
-	t.mu.Lock()
-	if t.closed {
-		t.mu.Unlock()
-		return fmt.Errorf("transport is closed")
-	}
-	t.mu.Unlock()
-
-	// Ensure JSONRPC version is set
-	msg.JSONRPC = "2.0"
-
-	// Serialize message
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
-	}
-
-	// Create request with timeout
-	ctx, cancel := context.WithTimeout(t.ctx, t.timeout)
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(ctx, "POST", t.url, bytes.NewReader(data))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	for k, v := range t.headers {
-		req.Header.Set(k, v)
-	}
-
-	// Send request
-	client := &http.Client{Timeout: t.timeout}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("HTTP request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check status
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(body))
-	}
-
-	// Parse response (if any)
-	if resp.ContentLength > 0 || resp.ContentLength == -1 {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("failed to read response body: %w", err)
-		}
-
-		if len(body) > 0 {
-			var response JSONRPCMessage
-			if err := json.Unmarshal(body, &response); err != nil {
-				return fmt.Errorf("failed to decode response: %w", err)
-			}
-
-			// Send response to receive channel
-			select {
-			case t.recvChan <- &response:
-			case <-t.ctx.Done():
-				return t.ctx.Err()
-			}
-		}
-	}
-
-	logging.Debug("MCP HTTP message sent",
-		"method", msg.Method,
-		"id", msg.ID)
-
-	return nil
-}
-
-// Receive receives a JSON-RPC message from the server.
-func (t *HTTPTransport) Receive() (*JSONRPCMessage, error) {
-	t.mu.Lock()
-	if t.closed {
-		t.mu.Unlock()
-		return nil, io.EOF
-	}
-	t.mu.Unlock()
-
-	select {
-	case msg := <-t.recvChan:
-		return msg, nil
-	case err := <-t.errChan:
-		return nil, err
-	case <-t.ctx.Done():
-		return nil, io.EOF
-	}
-}
-
-// Close closes the HTTP transport.
-func (t *HTTPTransport) Close() error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	if t.closed {
-		return nil
-	}
-	t.closed = true
-	t.cancel()
-
-	logging.Debug("MCP HTTP transport closed")
-	return nil
-}
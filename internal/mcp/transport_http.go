@@ -0,0 +1,674 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gokin/internal/logging"
+)
+
+// ErrSessionExpired is returned when the server no longer knows the session;
+// the client reconnects with a new one.
+var ErrSessionExpired = errors.New("MCP session expired")
+
+// errStreamUnsupported means the server offers no GET stream.
+var errStreamUnsupported = errors.New("server does not offer an SSE stream")
+
+const (
+	defaultSSERetry = time.Second
+	maxSSERetry     = 30 * time.Second
+)
+
+// HTTPTransport communicates with an MCP server via the streamable HTTP
+// transport. Messages are POSTed and answered with JSON or an SSE stream, and
+// a GET stream carries messages the server initiates. Streams that break are
+// resumed with Last-Event-ID. Servers that only speak the older HTTP+SSE
+// transport are detected on initialize and used through their SSE endpoint.
+type HTTPTransport struct {
+	url     string
+	server  string
+	headers map[string]string
+	timeout time.Duration
+	client  *http.Client
+	tokens  *oauthTokens // nil without OAuth
+
+	recvChan chan *JSONRPCMessage
+	errChan  chan error
+
+	mu              sync.Mutex
+	closed          bool
+	sessionID       string
+	protocolVersion string
+	listening       bool
+	retryDelay      time.Duration
+
+	// Older HTTP+SSE transport
+	legacy  bool
+	postURL string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewHTTPTransport creates a new HTTP transport for a server. Tokens stored
+// for the server in store are sent as bearer tokens and refreshed as needed.
+func NewHTTPTransport(cfg *ServerConfig, store *TokenStore) (*HTTPTransport, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("url is required for the http transport")
+	}
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	headers := make(map[string]string, len(cfg.Headers))
+	for k, v := range cfg.Headers {
+		headers[k] = os.ExpandEnv(v) // Expands ${VAR}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t := &HTTPTransport{
+		url:        cfg.URL,
+		server:     cfg.Name,
+		headers:    headers,
+		timeout:    timeout,
+		client:     &http.Client{}, // Streams are long-lived; requests use their own deadlines
+		tokens:     newOAuthTokens(cfg.Name, cfg.URL, store),
+		recvChan:   make(chan *JSONRPCMessage, 10),
+		errChan:    make(chan error, 1),
+		retryDelay: defaultSSERetry,
+		legacy:     cfg.Transport == "sse",
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+
+	logging.Debug("MCP HTTP transport created", "url", cfg.URL, "legacy_sse", t.legacy)
+
+	return t, nil
+}
+
+// SetProtocolVersion sets the negotiated protocol version, which is sent
+// with every request after initialization.
+func (t *HTTPTransport) SetProtocolVersion(version string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.protocolVersion = version
+}
+
+// Send sends a JSON-RPC message to the server via HTTP POST.
+func (t *HTTPTransport) Send(msg *JSONRPCMessage) error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return fmt.Errorf("transport is closed")
+	}
+	legacy := t.legacy
+	t.mu.Unlock()
+
+	// Ensure JSONRPC version is set
+	msg.JSONRPC = "2.0"
+
+	// Serialize message
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	if legacy {
+		err = t.sendLegacy(data)
+	} else {
+		err = t.post(msg, data)
+	}
+	if err != nil {
+		return err
+	}
+
+	logging.Debug("MCP HTTP message sent",
+		"method", msg.Method,
+		"id", msg.ID)
+
+	// Once initialized, open the stream for server-initiated messages
+	if msg.Method == MethodInitialized && !legacy {
+		t.startListening()
+	}
+
+	return nil
+}
+
+// post sends a message and routes the response. A rejected token is
+// refreshed once before giving up.
+func (t *HTTPTransport) post(msg *JSONRPCMessage, data []byte) error {
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithCancel(t.ctx)
+		timer := time.AfterFunc(t.timeout, cancel)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(data))
+		if err != nil {
+			timer.Stop()
+			cancel()
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		sessionID, err := t.setHeaders(ctx, req)
+		if err != nil {
+			timer.Stop()
+			cancel()
+			return err
+		}
+
+		resp, err := t.client.Do(req)
+		if err != nil {
+			timer.Stop()
+			cancel()
+			return fmt.Errorf("HTTP request failed: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 && t.tokens != nil {
+			resp.Body.Close()
+			timer.Stop()
+			cancel()
+			if _, err := t.tokens.refresh(t.ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		return t.handleResponse(msg, sessionID, resp, timer, cancel)
+	}
+}
+
+// handleResponse routes the response to a POST: JSON bodies are delivered
+// immediately, SSE streams are read in the background.
+func (t *HTTPTransport) handleResponse(msg *JSONRPCMessage, sessionID string, resp *http.Response, timer *time.Timer, cancel context.CancelFunc) error {
+	streaming := false
+	defer func() {
+		if !streaming {
+			resp.Body.Close()
+			timer.Stop()
+			cancel()
+		}
+	}()
+
+	if id := resp.Header.Get(SessionHeader); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return &AuthRequiredError{Server: t.server}
+
+	case resp.StatusCode == http.StatusNotFound && sessionID != "":
+		t.mu.Lock()
+		t.sessionID = ""
+		t.mu.Unlock()
+		t.fail(ErrSessionExpired)
+		return ErrSessionExpired
+
+	case msg.Method == MethodInitialize && sessionID == "" &&
+		(resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed):
+		// Server predates streamable HTTP; fall back to HTTP+SSE
+		logging.Info("MCP server does not support streamable HTTP, trying HTTP+SSE", "server", t.server, "status", resp.StatusCode)
+		t.mu.Lock()
+		t.legacy = true
+		t.mu.Unlock()
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		return t.sendLegacy(data)
+
+	case resp.StatusCode == http.StatusAccepted:
+		return nil
+
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(body))
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		streaming = true
+		timer.Stop()
+		go func() {
+			defer cancel()
+			defer resp.Body.Close()
+			lastID, err := t.readStream(resp.Body, nil)
+			if err != nil && t.ctx.Err() == nil && lastID != "" {
+				logging.Debug("MCP response stream interrupted, resuming", "server", t.server, "last_event_id", lastID)
+				t.resume(lastID)
+			}
+		}()
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBody))
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	return t.deliverJSON(body)
+}
+
+// setHeaders adds the configured headers, session, protocol version and
+// access token to a request. It returns the session ID that was sent.
+func (t *HTTPTransport) setHeaders(ctx context.Context, req *http.Request) (string, error) {
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	t.mu.Lock()
+	sessionID := t.sessionID
+	version := t.protocolVersion
+	t.mu.Unlock()
+
+	if sessionID != "" {
+		req.Header.Set(SessionHeader, sessionID)
+	}
+	if version != "" {
+		req.Header.Set("MCP-Protocol-Version", version)
+	}
+
+	if t.tokens != nil && req.Header.Get("Authorization") == "" {
+		token, err := t.tokens.token(ctx)
+		if err != nil {
+			return "", err
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	return sessionID, nil
+}
+
+// startListening opens the GET stream once.
+func (t *HTTPTransport) startListening() {
+	t.mu.Lock()
+	if t.listening {
+		t.mu.Unlock()
+		return
+	}
+	t.listening = true
+	t.mu.Unlock()
+
+	go t.listen()
+}
+
+// listen keeps a GET stream open for server-initiated messages, reconnecting
+// with Last-Event-ID when it ends. It stops if the server offers no stream.
+func (t *HTTPTransport) listen() {
+	var lastID string
+	backoff := time.Duration(0)
+
+	for t.ctx.Err() == nil {
+		body, err := t.openStream(lastID)
+		if errors.Is(err, errStreamUnsupported) {
+			logging.Debug("MCP server offers no SSE stream", "server", t.server)
+			return
+		}
+		if err == nil {
+			var id string
+			id, err = t.readStream(body, nil)
+			body.Close()
+			if id != "" {
+				lastID = id
+			}
+		}
+		if t.ctx.Err() != nil {
+			return
+		}
+
+		t.mu.Lock()
+		delay := t.retryDelay
+		t.mu.Unlock()
+		if err != nil {
+			logging.Debug("MCP SSE stream error", "server", t.server, "error", err)
+			backoff = min(max(backoff*2, delay), maxSSERetry)
+			delay = backoff
+		} else {
+			backoff = 0
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-t.ctx.Done():
+			return
+		}
+	}
+}
+
+// resume replays the messages of an interrupted response stream.
+func (t *HTTPTransport) resume(lastID string) {
+	body, err := t.openStream(lastID)
+	if err != nil {
+		logging.Debug("MCP stream resumption failed", "server", t.server, "error", err)
+		return
+	}
+	defer body.Close()
+	if _, err := t.readStream(body, nil); err != nil && t.ctx.Err() == nil {
+		logging.Debug("MCP resumed stream ended", "server", t.server, "error", err)
+	}
+}
+
+// openStream issues a GET for an SSE stream, resuming after lastID if set.
+func (t *HTTPTransport) openStream(lastID string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodGet, t.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	headerCtx, cancel := context.WithTimeout(t.ctx, t.timeout)
+	defer cancel()
+	sessionID, err := t.setHeaders(headerCtx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusMethodNotAllowed:
+		resp.Body.Close()
+		return nil, errStreamUnsupported
+	case resp.StatusCode == http.StatusNotFound && sessionID != "":
+		resp.Body.Close()
+		t.fail(ErrSessionExpired)
+		return nil, ErrSessionExpired
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, errStreamUnsupported
+	case resp.StatusCode != http.StatusOK:
+		resp.Body.Close()
+		return nil, fmt.Errorf("SSE stream failed: %s", resp.Status)
+	case !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"):
+		resp.Body.Close()
+		return nil, errStreamUnsupported
+	}
+	return resp.Body, nil
+}
+
+// readStream delivers the messages of an SSE stream and returns the ID of the
+// last event. Events other than messages are passed to other, if set.
+func (t *HTTPTransport) readStream(r io.Reader, other func(event, data string)) (string, error) {
+	var lastID string
+	err := readSSE(r, func(ev sseEvent) {
+		if ev.ID != "" {
+			lastID = ev.ID
+		}
+		if ev.Retry > 0 {
+			t.mu.Lock()
+			t.retryDelay = ev.Retry
+			t.mu.Unlock()
+		}
+		if ev.Event != "" && ev.Event != "message" {
+			if other != nil {
+				other(ev.Event, ev.Data)
+			}
+			return
+		}
+		if strings.TrimSpace(ev.Data) == "" {
+			return
+		}
+		if err := t.deliverJSON([]byte(ev.Data)); err != nil {
+			logging.Warn("MCP invalid SSE message", "server", t.server, "error", err)
+		}
+	})
+	return lastID, err
+}
+
+// deliverJSON parses a single message or a batch and queues it for Receive.
+func (t *HTTPTransport) deliverJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	var msgs []*JSONRPCMessage
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &msgs); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	} else {
+		var msg JSONRPCMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		msgs = append(msgs, &msg)
+	}
+
+	for _, msg := range msgs {
+		select {
+		case t.recvChan <- msg:
+		case <-t.ctx.Done():
+			return t.ctx.Err()
+		}
+	}
+	return nil
+}
+
+// fail reports a broken connection to Receive so that the client reconnects.
+func (t *HTTPTransport) fail(err error) {
+	select {
+	case t.errChan <- err:
+	default:
+	}
+}
+
+// connectLegacy opens the SSE stream of the HTTP+SSE transport and waits
+// for the endpoint event that names the URL for POSTs.
+func (t *HTTPTransport) connectLegacy() error {
+	body, err := t.openStream("")
+	if err != nil {
+		return fmt.Errorf("failed to open SSE stream: %w", err)
+	}
+
+	endpoint := make(chan string, 1)
+	go func() {
+		defer body.Close()
+		_, err := t.readStream(body, func(event, data string) {
+			if event == "endpoint" {
+				select {
+				case endpoint <- strings.TrimSpace(data):
+				default:
+				}
+			}
+		})
+		if t.ctx.Err() == nil {
+			if err == nil {
+				err = io.ErrUnexpectedEOF
+			}
+			t.fail(fmt.Errorf("SSE stream closed: %w", err))
+		}
+	}()
+
+	select {
+	case ep := <-endpoint:
+		base, err := url.Parse(t.url)
+		if err != nil {
+			return err
+		}
+		ref, err := url.Parse(ep)
+		if err != nil {
+			return fmt.Errorf("invalid SSE endpoint %q: %w", ep, err)
+		}
+		resolved := base.ResolveReference(ref)
+		if resolved.Host != base.Host {
+			return fmt.Errorf("SSE endpoint %q is on another host", ep)
+		}
+		t.mu.Lock()
+		t.postURL = resolved.String()
+		t.mu.Unlock()
+		return nil
+	case <-time.After(t.timeout):
+		return fmt.Errorf("timeout waiting for SSE endpoint")
+	case <-t.ctx.Done():
+		return t.ctx.Err()
+	}
+}
+
+// sendLegacy POSTs a message to the HTTP+SSE endpoint; responses arrive on
+// the SSE stream.
+func (t *HTTPTransport) sendLegacy(data []byte) error {
+	t.mu.Lock()
+	postURL := t.postURL
+	t.mu.Unlock()
+	if postURL == "" {
+		if err := t.connectLegacy(); err != nil {
+			return err
+		}
+		t.mu.Lock()
+		postURL = t.postURL
+		t.mu.Unlock()
+	}
+
+	ctx, cancel := context.WithTimeout(t.ctx, t.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, postURL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if _, err := t.setHeaders(ctx, req); err != nil {
+		return err
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusNoContent:
+		return nil
+	case http.StatusUnauthorized:
+		return &AuthRequiredError{Server: t.server}
+	default:
+		return fmt.Errorf("HTTP error %d", resp.StatusCode)
+	}
+}
+
+// Receive receives a JSON-RPC message from the server.
+func (t *HTTPTransport) Receive() (*JSONRPCMessage, error) {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil, io.EOF
+	}
+	t.mu.Unlock()
+
+	select {
+	case msg := <-t.recvChan:
+		return msg, nil
+	case err := <-t.errChan:
+		return nil, err
+	case <-t.ctx.Done():
+		return nil, io.EOF
+	}
+}
+
+// Close closes the HTTP transport and ends the session on the server.
+func (t *HTTPTransport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	sessionID := t.sessionID
+	legacy := t.legacy
+	t.mu.Unlock()
+
+	t.cancel()
+
+	if sessionID != "" && !legacy {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.url, nil); err == nil {
+			for k, v := range t.headers {
+				req.Header.Set(k, v)
+			}
+			req.Header.Set(SessionHeader, sessionID)
+			if resp, err := t.client.Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+	}
+
+	logging.Debug("MCP HTTP transport closed")
+	return nil
+}
+
+// sseEvent is one event of a server-sent events stream.
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+	Retry time.Duration
+}
+
+// readSSE parses a server-sent events stream and calls handle for each event.
+// It returns nil at the end of the stream.
+func readSSE(r io.Reader, handle func(sseEvent)) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+	var ev sseEvent
+	var data []string
+
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 || err == nil {
+			line = strings.TrimRight(line, "\r\n")
+			switch {
+			case line == "":
+				if data != nil || ev.Retry > 0 {
+					ev.Data = strings.Join(data, "\n")
+					handle(ev)
+				}
+				ev = sseEvent{}
+				data = nil
+			case strings.HasPrefix(line, ":"):
+				// Comment (keep-alive)
+			default:
+				field, value, _ := strings.Cut(line, ":")
+				value = strings.TrimPrefix(value, " ")
+				switch field {
+				case "event":
+					ev.Event = value
+				case "data":
+					data = append(data, value)
+				case "id":
+					ev.ID = value
+				case "retry":
+					if ms, err := strconv.Atoi(value); err == nil && ms > 0 {
+						ev.Retry = time.Duration(ms) * time.Millisecond
+					}
+				}
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -99,6 +99,7 @@ type InitializeResult struct {
 	ProtocolVersion string      `json:"protocolVersion"`
 	ServerInfo      *ServerInfo `json:"serverInfo"`
 	Capabilities    any         `json:"capabilities,omitempty"`
+	Instructions    string      `json:"instructions,omitempty"`
 }
 
 // ToolInfo describes an MCP tool.
@@ -143,11 +144,12 @@ type CallToolResult struct {
 
 // ContentBlock represents a content block in tool results.
 type ContentBlock struct {
-	Type     string `json:"type"`               // "text", "image", "resource"
-	Text     string `json:"text,omitempty"`     // For text content
-	MIMEType string `json:"mimeType,omitempty"` // For image/resource content
-	Data     string `json:"data,omitempty"`     // Base64 encoded data for images
-	URI      string `json:"uri,omitempty"`      // For resource references
+	Type     string           `json:"type"`               // "text", "image", "resource"
+	Text     string           `json:"text,omitempty"`     // For text content
+	MIMEType string           `json:"mimeType,omitempty"` // For image/resource content
+	Data     string           `json:"data,omitempty"`     // Base64 encoded data for images
+	URI      string           `json:"uri,omitempty"`      // For resource references
+	Resource *ResourceContent `json:"resource,omitempty"` // For embedded resources
 }
 
 // Resource represents an MCP resource.
@@ -218,6 +220,32 @@ type PromptMessage struct {
 	Content *ContentBlock   `json:"content"`
 }
 
+// SamplingMessage is a message in a sampling/createMessage request.
+type SamplingMessage struct {
+	Role    string        `json:"role"` // "user" or "assistant"
+	Content *ContentBlock `json:"content"`
+}
+
+// CreateMessageParams are the parameters of a server's sampling/createMessage request.
+type CreateMessageParams struct {
+	Messages         []*SamplingMessage `json:"messages"`
+	SystemPrompt     string             `json:"systemPrompt,omitempty"`
+	IncludeContext   string             `json:"includeContext,omitempty"` // "none", "thisServer", "allServers"
+	Temperature      *float64           `json:"temperature,omitempty"`
+	MaxTokens        int                `json:"maxTokens"`
+	StopSequences    []string           `json:"stopSequences,omitempty"`
+	ModelPreferences any                `json:"modelPreferences,omitempty"`
+	Metadata         any                `json:"metadata,omitempty"`
+}
+
+// CreateMessageResult is the client's answer to sampling/createMessage.
+type CreateMessageResult struct {
+	Role       string        `json:"role"`
+	Content    *ContentBlock `json:"content"`
+	Model      string        `json:"model"`
+	StopReason string        `json:"stopReason,omitempty"` // "endTurn", "stopSequence", "maxTokens"
+}
+
 // ServerConfig holds configuration for an MCP server connection.
 type ServerConfig struct {
 	Name        string            `yaml:"name" json:"name"`                   // Unique identifier
@@ -240,11 +268,18 @@ type ServerConfig struct {
 
 	// Tool settings
 	ToolPrefix  string            `yaml:"tool_prefix,omitempty" json:"toolPrefix,omitempty"` // Prefix for tool names
+
+	// OAuth settings for HTTP servers that require authorization
+	OAuth *OAuthConfig `yaml:"oauth,omitempty" json:"oauth,omitempty"`
 }
 
 // MCP protocol version
 const ProtocolVersion = "2024-11-05"
 
+// LatestProtocolVersion is the newest protocol version gokin speaks; clients
+// request it and fall back to whatever the server negotiates.
+const LatestProtocolVersion = "2025-06-18"
+
 // MCP method names
 const (
 	MethodInitialize     = "initialize"
@@ -256,4 +291,14 @@ const (
 	MethodPromptsList    = "prompts/list"
 	MethodPromptsGet     = "prompts/get"
 	MethodPing           = "ping"
+
+	// Server-to-client requests and notifications
+	MethodSamplingCreateMessage = "sampling/createMessage"
+	MethodCancelled             = "notifications/cancelled"
+	MethodToolsListChanged      = "notifications/tools/list_changed"
+	MethodResourcesListChanged  = "notifications/resources/list_changed"
+	MethodPromptsListChanged    = "notifications/prompts/list_changed"
 )
+
+// ErrCodeUserRejected is returned when the user declines a server request.
+const ErrCodeUserRejected = -1
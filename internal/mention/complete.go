@@ -0,0 +1,179 @@
+package mention
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// fileListTTL is how long the project file list is reused for completion.
+	fileListTTL = 10 * time.Second
+
+	// maxListedFiles bounds the project paths offered for completion.
+	maxListedFiles = 20000
+)
+
+// Suggestion is a completion for a partial mention.
+type Suggestion struct {
+	Value  string // Mention text to insert after the @
+	Kind   Kind
+	Detail string // Short description shown next to the value
+}
+
+// Complete returns up to limit completions for the text typed after an @:
+// project files and directories (fuzzy matched, ignoring gitignored paths)
+// and symbols from the SymbolLookup. URLs are not completed.
+func (r *Resolver) Complete(query string, limit int) []Suggestion {
+	if limit <= 0 || isURL(query) {
+		return nil
+	}
+
+	files := r.completePaths(query, limit)
+
+	// Path-like queries only complete paths
+	var symbols []Suggestion
+	if r.symbols != nil && query != "" && !strings.ContainsAny(query, "/.") {
+		for _, s := range r.symbols(query, limit) {
+			symbols = append(symbols, Suggestion{
+				Value:  s.Name,
+				Kind:   KindSymbol,
+				Detail: s.Kind + " · " + r.rel(s.FilePath),
+			})
+		}
+	}
+
+	// Files first, keeping a third of the slots for symbols
+	nFiles := min(len(files), max(limit-len(symbols), limit-limit/3))
+	result := append(files[:nFiles:nFiles], symbols...)
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// completePaths returns the project paths that best match query.
+func (r *Resolver) completePaths(query string, limit int) []Suggestion {
+	type scored struct {
+		path  string
+		score int
+	}
+	var matches []scored
+	for _, p := range r.projectFiles() {
+		if score := pathScore(p, query); score > 0 {
+			matches = append(matches, scored{p, score})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].path < matches[j].path
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	suggestions := make([]Suggestion, len(matches))
+	for i, m := range matches {
+		kind := KindFile
+		if strings.HasSuffix(m.path, "/") {
+			kind = KindDir
+		}
+		suggestions[i] = Suggestion{Value: m.path, Kind: kind, Detail: string(kind)}
+	}
+	return suggestions
+}
+
+// pathScore scores how well a project path matches query; 0 means no
+// match. Matches in the base name, at the start of path segments and in
+// runs score higher; shallow paths win ties.
+func pathScore(path, query string) int {
+	lower := strings.ToLower(path)
+	query = strings.ToLower(query)
+	depthPenalty := strings.Count(strings.TrimSuffix(path, "/"), "/")
+	if query == "" {
+		return 100 - min(depthPenalty*10, 90)
+	}
+
+	base := strings.TrimSuffix(lower, "/")
+	base = base[strings.LastIndex(base, "/")+1:]
+	switch {
+	case strings.HasPrefix(lower, query):
+		return 3000 - len(path)
+	case strings.HasPrefix(base, query):
+		return 2000 - len(path)
+	case strings.Contains(lower, query):
+		return 1000 - len(path)
+	}
+
+	// Fuzzy subsequence match
+	score, qi, last := 0, 0, -2
+	for i := 0; i < len(lower) && qi < len(query); i++ {
+		if lower[i] != query[qi] {
+			continue
+		}
+		score += 10
+		if i == last+1 {
+			score += 5
+		}
+		if i == 0 || strings.ContainsRune("/_-.", rune(lower[i-1])) {
+			score += 15
+		}
+		last = i
+		qi++
+	}
+	if qi < len(query) {
+		return 0
+	}
+	return max(1, score-depthPenalty)
+}
+
+// projectFiles returns the project's paths relative to the work dir,
+// skipping .git and gitignored paths. The list is cached for fileListTTL.
+func (r *Resolver) projectFiles() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.files != nil && time.Since(r.listedAt) < fileListTTL {
+		return r.files
+	}
+
+	_ = r.gitIgnore.Reload() // Pick up .gitignore edits
+
+	files := make([]string, 0, 256)
+	_ = filepath.WalkDir(r.workDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == r.workDir {
+			return nil
+		}
+		if len(files) >= maxListedFiles {
+			return filepath.SkipAll
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if r.gitIgnore.IsIgnored(p) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(r.workDir, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			rel += "/"
+		}
+		files = append(files, rel)
+		return nil
+	})
+
+	r.files = files
+	r.listedAt = time.Now()
+	return files
+}
@@ -0,0 +1,327 @@
+// Package mention resolves @file, @dir, @symbol and @url mentions in
+// prompts into attached context blocks.
+package mention
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	appcontext "gokin/internal/context"
+	"gokin/internal/git"
+)
+
+// Kind is what a mention refers to.
+type Kind string
+
+const (
+	KindFile   Kind = "file"
+	KindDir    Kind = "dir"
+	KindSymbol Kind = "symbol"
+	KindURL    Kind = "url"
+)
+
+const (
+	// maxAttachedBytes is the largest file or page attached in full.
+	maxAttachedBytes = 100 * 1024
+
+	// maxDirEntries is the largest directory listing attached.
+	maxDirEntries = 200
+
+	// maxSymbolMatches bounds the definitions attached for one symbol.
+	maxSymbolMatches = 3
+
+	// fetchTimeout bounds fetching an @url.
+	fetchTimeout = 30 * time.Second
+)
+
+var (
+	mentionPattern   = regexp.MustCompile("(^|\\s)@([^\\s`]+)")
+	lineRangePattern = regexp.MustCompile(`^(.+):(\d+)(?:-(\d+))?$`)
+)
+
+// Find returns the distinct mentions in text, without the @, in order of
+// appearance. Trailing punctuation is not part of a mention.
+func Find(text string) []string {
+	var mentions []string
+	seen := make(map[string]bool)
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		value := strings.TrimRight(m[2], ".,;:!?)'\"")
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		mentions = append(mentions, value)
+	}
+	return mentions
+}
+
+// Symbol is a code symbol that can be mentioned.
+type Symbol struct {
+	Name      string
+	Kind      string
+	FilePath  string
+	LineStart int
+	LineEnd   int
+	Content   string
+}
+
+// SymbolLookup returns up to limit symbols matching query, best first.
+type SymbolLookup func(query string, limit int) []Symbol
+
+// Fetcher fetches the text of a URL.
+type Fetcher func(ctx context.Context, url string) (string, error)
+
+// Attachment is the context attached for one mention.
+type Attachment struct {
+	Kind    Kind
+	Mention string // The mention without the @
+	Title   string // What was attached, e.g. "main.go:10-20"
+	Content string
+	Tokens  int   // Estimated tokens of the attached block
+	Err     error // Set when the mention could not be attached
+}
+
+// Block formats the attachment as a context block for the prompt.
+func (a Attachment) Block() string {
+	return fmt.Sprintf("<attachment type=%q name=%q>\n%s\n</attachment>", a.Kind, a.Title, a.Content)
+}
+
+// Resolver resolves mentions against a project: paths relative to the work
+// dir, symbols through a SymbolLookup and URLs through a Fetcher. It also
+// completes partial mentions.
+type Resolver struct {
+	workDir   string
+	gitIgnore *git.GitIgnore
+	symbols   SymbolLookup
+	fetch     Fetcher
+
+	mu       sync.Mutex
+	files    []string // Cached project paths; directories end with "/"
+	listedAt time.Time
+}
+
+// NewResolver creates a resolver for the project in workDir.
+func NewResolver(workDir string) *Resolver {
+	gitIgnore := git.NewGitIgnore(workDir)
+	_ = gitIgnore.Load() // Ignore error - gitignore is optional
+
+	return &Resolver{
+		workDir:   workDir,
+		gitIgnore: gitIgnore,
+	}
+}
+
+// SetSymbolLookup sets how @symbol mentions are looked up.
+func (r *Resolver) SetSymbolLookup(lookup SymbolLookup) {
+	r.symbols = lookup
+}
+
+// SetFetcher sets how @url mentions are fetched.
+func (r *Resolver) SetFetcher(fetch Fetcher) {
+	r.fetch = fetch
+}
+
+// Expand resolves the mentions in prompt and returns the prompt followed by
+// a context block per resolved mention, along with the attachments.
+// Mentions that match no file, directory or symbol are left as typed;
+// attachments that failed carry Err and are not added to the prompt.
+func (r *Resolver) Expand(ctx context.Context, prompt string) (string, []Attachment) {
+	var attachments []Attachment
+	var sb strings.Builder
+	sb.WriteString(prompt)
+	for _, m := range Find(prompt) {
+		for _, a := range r.Resolve(ctx, m) {
+			if a.Err == nil {
+				block := a.Block()
+				a.Tokens = appcontext.EstimateTokens(block)
+				sb.WriteString("\n\n" + block)
+			}
+			attachments = append(attachments, a)
+		}
+	}
+	return sb.String(), attachments
+}
+
+// Resolve returns the attachments for one mention (without the @).
+func (r *Resolver) Resolve(ctx context.Context, mention string) []Attachment {
+	if isURL(mention) {
+		return []Attachment{r.resolveURL(ctx, mention)}
+	}
+
+	if a, ok := r.resolvePath(mention); ok {
+		return []Attachment{a}
+	}
+
+	return r.resolveSymbol(mention)
+}
+
+// isURL reports whether a mention is a web URL.
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+func (r *Resolver) resolveURL(ctx context.Context, url string) Attachment {
+	a := Attachment{Kind: KindURL, Mention: url, Title: url}
+	if r.fetch == nil {
+		a.Err = fmt.Errorf("URL fetching is not available")
+		return a
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	content, err := r.fetch(ctx, url)
+	if err != nil {
+		a.Err = err
+		return a
+	}
+	a.Content = truncate(content)
+	return a
+}
+
+// resolvePath attaches a file, a line range of a file (path:10-20) or a
+// directory listing. ok is false if the mention is not an existing path.
+func (r *Resolver) resolvePath(mention string) (Attachment, bool) {
+	name, from, to := mention, 0, 0
+	info, err := os.Stat(r.abs(name))
+	if err != nil {
+		m := lineRangePattern.FindStringSubmatch(mention)
+		if m == nil {
+			return Attachment{}, false
+		}
+		name = m[1]
+		from, _ = strconv.Atoi(m[2])
+		to = from
+		if m[3] != "" {
+			to, _ = strconv.Atoi(m[3])
+		}
+		if info, err = os.Stat(r.abs(name)); err != nil || info.IsDir() || from < 1 || to < from {
+			return Attachment{}, false
+		}
+	}
+
+	if info.IsDir() {
+		a := Attachment{Kind: KindDir, Mention: mention, Title: strings.TrimSuffix(name, "/") + "/"}
+		a.Content, a.Err = r.listDir(r.abs(name))
+		return a, true
+	}
+	if !info.Mode().IsRegular() {
+		return Attachment{}, false
+	}
+
+	a := Attachment{Kind: KindFile, Mention: mention, Title: name}
+	data, err := os.ReadFile(r.abs(name))
+	if err != nil {
+		a.Err = err
+		return a, true
+	}
+	if bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		a.Err = fmt.Errorf("binary file")
+		return a, true
+	}
+
+	content := strings.TrimRight(string(data), "\n")
+	if from > 0 {
+		lines := strings.Split(content, "\n")
+		if from > len(lines) {
+			a.Err = fmt.Errorf("%s has only %d lines", name, len(lines))
+			return a, true
+		}
+		to = min(to, len(lines))
+		content = strings.Join(lines[from-1:to], "\n")
+		a.Title = fmt.Sprintf("%s:%d-%d", name, from, to)
+	}
+	a.Content = truncate(content)
+	return a, true
+}
+
+// listDir lists a directory one level deep, skipping ignored entries.
+func (r *Resolver) listDir(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.Name() == ".git" || r.gitIgnore.IsIgnored(filepath.Join(dir, e.Name())) {
+			continue
+		}
+		name := e.Name()
+		if e.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) > maxDirEntries {
+		more := len(names) - maxDirEntries
+		names = append(names[:maxDirEntries], fmt.Sprintf("... (%d more)", more))
+	}
+	if len(names) == 0 {
+		return "(empty)", nil
+	}
+	return strings.Join(names, "\n"), nil
+}
+
+// resolveSymbol attaches the definitions of the symbols named exactly as
+// the mention. Methods also match by their bare name.
+func (r *Resolver) resolveSymbol(mention string) []Attachment {
+	if r.symbols == nil {
+		return nil
+	}
+
+	var attachments []Attachment
+	for _, s := range r.symbols(mention, maxSymbolMatches*4) {
+		member := s.Name
+		if dot := strings.LastIndex(member, "."); dot >= 0 {
+			member = member[dot+1:]
+		}
+		if !strings.EqualFold(s.Name, mention) && !strings.EqualFold(member, mention) {
+			continue
+		}
+		attachments = append(attachments, Attachment{
+			Kind:    KindSymbol,
+			Mention: mention,
+			Title:   fmt.Sprintf("%s (%s, %s:%d-%d)", s.Name, s.Kind, r.rel(s.FilePath), s.LineStart, s.LineEnd),
+			Content: truncate(strings.TrimRight(s.Content, "\n")),
+		})
+		if len(attachments) == maxSymbolMatches {
+			break
+		}
+	}
+	return attachments
+}
+
+// abs resolves a mentioned path against the work dir.
+func (r *Resolver) abs(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(r.workDir, filepath.FromSlash(p))
+}
+
+// rel returns p relative to the work dir when it is inside it.
+func (r *Resolver) rel(p string) string {
+	if rel, err := filepath.Rel(r.workDir, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return p
+}
+
+// truncate caps attached content at maxAttachedBytes.
+func truncate(content string) string {
+	if len(content) <= maxAttachedBytes {
+		return content
+	}
+	return content[:maxAttachedBytes] + fmt.Sprintf("\n... (truncated at %d bytes)", maxAttachedBytes)
+}
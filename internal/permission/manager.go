@@ -4,10 +4,12 @@ import (
 	"context"
 	"crypto/sha256"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
 	"gokin/internal/cache"
+	"gokin/internal/security"
 )
 
 // PromptHandler is a function that prompts the user for permission.
@@ -29,6 +31,16 @@ type Manager struct {
 	// Prompt handler for asking the user
 	promptHandler PromptHandler
 
+	// Working directory, for flagging bash commands that write outside it
+	// and resolving relative path rules
+	workDir string
+
+	// Called with the rule an "always allow" answer grants
+	onGrant func(Rule)
+
+	// Recent decisions, oldest first
+	decisions []DecisionRecord
+
 	mu sync.RWMutex
 }
 
@@ -53,6 +65,67 @@ func (m *Manager) SetPromptHandler(handler PromptHandler) {
 	m.promptHandler = handler
 }
 
+// SetWorkDir sets the working directory bash commands are checked against.
+func (m *Manager) SetWorkDir(workDir string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.workDir = workDir
+}
+
+// SetGrantHandler sets the function called with the rule an "always
+// allow" answer grants, e.g. to save it to the project config.
+func (m *Manager) SetGrantHandler(handler func(Rule)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onGrant = handler
+}
+
+// maxDecisions is the number of recent decisions kept for /permissions.
+const maxDecisions = 20
+
+// DecisionRecord describes a permission check and what decided it.
+type DecisionRecord struct {
+	Time    time.Time
+	Tool    string
+	Subject string // Command, path or URL checked
+	Allowed bool
+	Asked   bool   // The user answered a prompt
+	Basis   string // Rule or policy that decided, e.g. "allow bash(go test:*) [project]"
+}
+
+// RecentDecisions returns the most recent permission decisions, oldest
+// first.
+func (m *Manager) RecentDecisions() []DecisionRecord {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.decisions)
+}
+
+// record adds a decision to the recent decisions.
+func (m *Manager) record(toolName string, args map[string]any, resp *Response, asked bool, basis string) {
+	subject := ""
+	for _, key := range []string{"command", "file_path", "path", "source", "directory_path", "url", "query"} {
+		if val, ok := args[key].(string); ok && val != "" {
+			subject = val
+			break
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.decisions = append(m.decisions, DecisionRecord{
+		Time:    time.Now(),
+		Tool:    toolName,
+		Subject: subject,
+		Allowed: resp.Allowed,
+		Asked:   asked,
+		Basis:   basis,
+	})
+	if len(m.decisions) > maxDecisions {
+		m.decisions = m.decisions[len(m.decisions)-maxDecisions:]
+	}
+}
+
 // cacheKey generates a cache key for a tool invocation.
 // For sensitive tools, the key includes a hash of relevant arguments.
 func (m *Manager) cacheKey(toolName string, args map[string]any) string {
@@ -63,6 +136,16 @@ func (m *Manager) cacheKey(toolName string, args map[string]any) string {
 			hash := sha256.Sum256([]byte(cmd))
 			return fmt.Sprintf("%s:%x", toolName, hash[:8])
 		}
+	case "history_search":
+		// Answers about other projects do not cover this one
+		if projects, _ := args["projects"].(string); projects == "all" {
+			return toolName + ":all"
+		}
+	case "mcp_sampling":
+		// Approvals hold per server
+		if server, ok := args["server"].(string); ok {
+			return fmt.Sprintf("%s:%s", toolName, server)
+		}
 	case "write", "edit":
 		// Include file path to differentiate different file operations
 		if path, ok := args["path"].(string); ok {
@@ -83,6 +166,18 @@ func (m *Manager) Check(ctx context.Context, toolName string, args map[string]an
 		return &Response{Allowed: true, Decision: DecisionAllow}, nil
 	}
 
+	m.mu.RLock()
+	workDir := m.workDir
+	m.mu.RUnlock()
+
+	// Rules come first, so a deny rule holds even after "always allow"
+	policy, basis, reason := m.policy(toolName, args, workDir)
+	if policy == LevelDeny {
+		resp := &Response{Allowed: false, Decision: DecisionDeny, Reason: reason}
+		m.record(toolName, args, resp, false, basis)
+		return resp, nil
+	}
+
 	// Generate cache key that may include args for sensitive tools
 	key := m.cacheKey(toolName, args)
 
@@ -90,52 +185,148 @@ func (m *Manager) Check(ctx context.Context, toolName string, args map[string]an
 	if decision, ok := m.sessionCache.Get(key); ok {
 		switch decision {
 		case DecisionAllowSession:
-			return &Response{Allowed: true, Decision: decision}, nil
+			resp := &Response{Allowed: true, Decision: decision}
+			m.record(toolName, args, resp, false, "earlier answer this session")
+			return resp, nil
 		case DecisionDenySession:
-			return &Response{
+			resp := &Response{
 				Allowed:  false,
 				Decision: decision,
 				Reason:   "Denied for session",
-			}, nil
+			}
+			m.record(toolName, args, resp, false, "earlier answer this session")
+			return resp, nil
 		}
 	}
 
-	// Get policy from rules
-	policy := m.rules.GetPolicy(toolName)
+	if policy == LevelAllow {
+		resp := &Response{Allowed: true, Decision: DecisionAllow}
+		m.record(toolName, args, resp, false, basis)
+		return resp, nil
+	}
 
-	switch policy {
-	case LevelAllow:
-		return &Response{Allowed: true, Decision: DecisionAllow}, nil
+	// Auto-approve caution-level tools if previously approved this session
+	if GetToolRiskLevel(toolName) == RiskMedium {
+		m.mu.RLock()
+		autoApproved := m.autoApprovedTools[toolName]
+		m.mu.RUnlock()
+		if autoApproved {
+			resp := &Response{Allowed: true, Decision: DecisionAllowSession}
+			m.record(toolName, args, resp, false, "tool approved earlier this session")
+			return resp, nil
+		}
+	}
 
-	case LevelDeny:
-		return &Response{
-			Allowed:  false,
-			Decision: DecisionDeny,
-			Reason:   "Tool is not permitted by configuration",
-		}, nil
+	resp, err := m.askUser(ctx, toolName, args, reason, workDir)
+	if resp != nil {
+		if resp.Decision == DecisionAllowSession {
+			if scope, ok := ScopedRule(toolName, args, workDir); ok {
+				basis = "granted " + scope.Describe()
+			}
+		}
+		m.record(toolName, args, resp, true, basis)
+	}
+	return resp, err
+}
+
+// policy returns the level for a tool invocation, a description of the
+// rule or policy that set it, and the reason to show with a denial or
+// prompt.
+func (m *Manager) policy(toolName string, args map[string]any, workDir string) (Level, string, string) {
+	policy, basis := m.toolPolicy(toolName)
+	if toolName == "bash" {
+		if cmd, ok := args["command"].(string); ok {
+			return m.commandPolicy(cmd, policy, basis, workDir)
+		}
+	}
+
+	if rule, ok := m.rules.Match(toolName, args, workDir); ok {
+		reason := ""
+		if rule.Level == LevelDeny {
+			reason = fmt.Sprintf("Denied by rule %s", rule)
+		}
+		return rule.Level, rule.Describe(), reason
+	}
+	if policy == LevelDeny {
+		return policy, basis, "Tool is not permitted by configuration"
+	}
+	if policy == LevelAllow {
+		if reason, ok := beyondProject(toolName, args); ok {
+			return LevelAsk, basis + "; " + reason, reason
+		}
+	}
+	return policy, basis, ""
+}
+
+// toolPolicy returns the policy for a whole tool and the config setting
+// it comes from.
+func (m *Manager) toolPolicy(toolName string) (Level, string) {
+	if level, ok := m.rules.ToolPolicies[toolName]; ok {
+		return level, fmt.Sprintf("%s rules.%s", level, toolName)
+	}
+	return m.rules.DefaultPolicy, fmt.Sprintf("%s default_policy", m.rules.DefaultPolicy)
+}
 
-	case LevelAsk:
-		// Auto-approve caution-level tools if previously approved this session
-		risk := GetToolRiskLevel(toolName)
-		if risk == RiskMedium {
-			m.mu.RLock()
-			autoApproved := m.autoApprovedTools[toolName]
-			m.mu.RUnlock()
-			if autoApproved {
-				return &Response{Allowed: true, Decision: DecisionAllowSession}, nil
+// commandPolicy applies the bash rules to each command a bash command
+// line runs, including those in pipelines, substitutions and nested
+// scripts. The most restrictive level wins; commands no rule matches get
+// the bash policy, and commands with computed names only match rules
+// without a pattern. A line that only allow rules match is still asked
+// about when it has constructs such as a write outside the working
+// directory. The reason names the command and rule that decided.
+func (m *Manager) commandPolicy(command string, policy Level, basis, workDir string) (Level, string, string) {
+	analysis, err := security.AnalyzeShell(command, workDir)
+	if err != nil || len(analysis.Commands) == 0 {
+		if policy == LevelDeny {
+			return policy, basis, "Tool is not permitted by configuration"
+		}
+		return policy, basis, ""
+	}
+
+	level := LevelAllow
+	decided, reason := "", ""
+	for _, cmd := range analysis.Commands {
+		args := map[string]any{}
+		if !cmd.DynamicName {
+			args["command"] = cmd.String()
+		}
+		l, b := policy, basis
+		rule, ok := m.rules.Match("bash", args, workDir)
+		if ok {
+			l, b = rule.Level, rule.Describe()
+		}
+		if decided != "" && restrictiveness(l) <= restrictiveness(level) {
+			continue
+		}
+		level, decided = l, b
+		switch {
+		case ok:
+			name := cmd.String()
+			if len(name) > 40 {
+				name = name[:37] + "..."
 			}
+			reason = fmt.Sprintf("%s: %s by rule %s", name, l, rule)
+		case l == LevelDeny:
+			reason = "Tool is not permitted by configuration"
+		default:
+			reason = ""
 		}
-		return m.askUser(ctx, toolName, args)
 	}
 
-	// Default to asking
-	return m.askUser(ctx, toolName, args)
+	if level == LevelAllow && policy != LevelAllow {
+		if cautions := analysis.Cautions(); len(cautions) > 0 {
+			return LevelAsk, decided + "; " + cautions[0].Reason, cautions[0].Reason
+		}
+	}
+	return level, decided, reason
 }
 
-// askUser prompts the user for permission.
-func (m *Manager) askUser(ctx context.Context, toolName string, args map[string]any) (*Response, error) {
+// askUser prompts the user for permission. A non-empty note replaces the
+// generic reason shown with the request.
+func (m *Manager) askUser(ctx context.Context, toolName string, args map[string]any, note, workDir string) (*Response, error) {
 	m.mu.RLock()
 	handler := m.promptHandler
+	onGrant := m.onGrant
 	m.mu.RUnlock()
 
 	if handler == nil {
@@ -145,6 +336,13 @@ func (m *Manager) askUser(ctx context.Context, toolName string, args map[string]
 
 	// Create permission request
 	req := NewRequest(toolName, args)
+	if note != "" {
+		req.Reason = note
+	}
+	scope, scoped := ScopedRule(toolName, args, workDir)
+	if scoped {
+		req.Scope = scope.String()
+	}
 
 	// Ask the user
 	decision, err := handler(ctx, req)
@@ -171,6 +369,14 @@ func (m *Manager) askUser(ctx context.Context, toolName string, args map[string]
 		return &Response{Allowed: true, Decision: decision}, nil
 
 	case DecisionAllowSession:
+		// Remember a narrow rule rather than the whole tool when there is one
+		if scoped {
+			m.rules.AddRule(scope)
+			if onGrant != nil {
+				onGrant(scope)
+			}
+			return &Response{Allowed: true, Decision: decision}, nil
+		}
 		m.rememberKey(key, decision)
 		// Also mark caution-level tools for auto-approve
 		if GetToolRiskLevel(toolName) == RiskMedium {
@@ -226,9 +432,10 @@ func (m *Manager) ForgetWithArgs(toolName string, args map[string]any) {
 	m.sessionCache.Delete(key)
 }
 
-// ClearSession clears all session-level decisions.
+// ClearSession clears all session-level decisions and rules.
 func (m *Manager) ClearSession() {
 	m.sessionCache.Clear()
+	m.rules.RemoveRules(SourceSession)
 	m.mu.Lock()
 	m.autoApprovedTools = make(map[string]bool)
 	m.mu.Unlock()
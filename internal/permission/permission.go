@@ -45,6 +45,7 @@ type Request struct {
 	Args      map[string]any // Arguments passed to the tool
 	RiskLevel RiskLevel      // Risk level of the operation
 	Reason    string         // Human-readable reason for the request
+	Scope     string         // Rule an "always allow" answer grants, when narrower than the tool
 }
 
 // NewRequest creates a new permission request.
@@ -86,19 +87,34 @@ func GetToolRiskLevel(toolName string) RiskLevel {
 	case "read", "glob", "grep", "tree", "diff", "env", "list_dir",
 		"git_status", "git_log", "git_diff", "git_blame",
 		"code_graph", "semantic_search", "history_search",
+		"lsp_definition", "lsp_references", "lsp_hover", "lsp_symbols",
 		"web_search", "web_fetch", "todo",
 		"task_output", "task_stop":
 		return RiskLow
 	case "write", "edit", "git_add", "copy", "move", "mkdir",
 		"atomicwrite", "task", "batch":
 		return RiskMedium
-	case "bash", "delete", "git_commit", "ssh":
+	case "bash", "delete", "git_commit", "ssh",
+		"contract_propose", "contract_verify", // Contracts carry shell commands
+		"mcp_sampling": // Runs a server's prompt on the user's model
 		return RiskHigh
 	default:
 		return RiskMedium
 	}
 }
 
+// beyondProject reports whether an otherwise allowed call reaches beyond
+// the project, so that it is asked about unless a rule allows it, e.g.
+// history_search(projects:all).
+func beyondProject(toolName string, args map[string]any) (string, bool) {
+	if toolName == "history_search" {
+		if projects, _ := args["projects"].(string); projects == "all" {
+			return "Searches past sessions of every project", true
+		}
+	}
+	return "", false
+}
+
 // buildReason creates a human-readable reason for the permission request.
 func buildReason(toolName string, args map[string]any) string {
 	switch toolName {
@@ -141,6 +157,12 @@ func buildReason(toolName string, args map[string]any) string {
 		}
 		return "Search file contents"
 
+	case "mcp_sampling":
+		if server, ok := args["server"].(string); ok {
+			return fmt.Sprintf("%s requests a model completion", server)
+		}
+		return "MCP server requests a model completion"
+
 	default:
 		return fmt.Sprintf("Execute tool: %s", toolName)
 	}
@@ -0,0 +1,237 @@
+package permission
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	"gokin/internal/security"
+)
+
+// Rule sources, the layers rules come from.
+const (
+	SourceUser    = "user"    // The user's config file
+	SourceProject = "project" // The project's .gokin/config.yaml
+	SourceGrant   = "grant"   // Granted with "always allow" earlier in this project
+	SourceSession = "session" // Granted with "always allow" in this session
+)
+
+// Rule is a permission rule for a tool, optionally narrowed to the
+// invocations whose arguments match a pattern:
+//
+//	bash(git diff:*)            commands starting with "git diff"
+//	edit(src/**)                edits of files under src/
+//	web_fetch(domain:*.go.dev)  fetches from subdomains of go.dev
+//	ssh(host:build-*)           any argument, by name
+//	write                       every invocation of the tool
+type Rule struct {
+	Tool    string // Tool name
+	Pattern string // Argument pattern; empty matches every invocation
+	Level   Level  // Level the rule sets
+	Source  string // Layer the rule comes from
+}
+
+// pathArgs are the argument names tools take file paths in.
+var pathArgs = []string{"file_path", "path", "source", "destination", "directory_path"}
+
+// argPattern matches "name:pattern" rule patterns.
+var argPattern = regexp.MustCompile(`^([a-z_]+):(.*)$`)
+
+// ParseRule parses a rule such as "bash(git diff:*)", "edit(src/**)" or
+// a bare tool name.
+func ParseRule(spec string, level Level, source string) (Rule, error) {
+	spec = strings.TrimSpace(spec)
+	tool, pattern := spec, ""
+	if open := strings.IndexByte(spec, '('); open >= 0 {
+		if !strings.HasSuffix(spec, ")") {
+			return Rule{}, fmt.Errorf("invalid permission rule %q: missing closing parenthesis", spec)
+		}
+		tool = strings.TrimSpace(spec[:open])
+		pattern = strings.TrimSpace(spec[open+1 : len(spec)-1])
+	}
+	if tool == "" || strings.ContainsAny(tool, " ()") {
+		return Rule{}, fmt.Errorf("invalid permission rule %q: bad tool name", spec)
+	}
+	if pattern == "*" || pattern == "**" {
+		pattern = ""
+	}
+	if tool == "bash" {
+		pattern = strings.Join(strings.Fields(pattern), " ")
+	}
+	return Rule{Tool: tool, Pattern: pattern, Level: level, Source: source}, nil
+}
+
+// String returns the rule in the form ParseRule accepts.
+func (r Rule) String() string {
+	if r.Pattern == "" {
+		return r.Tool
+	}
+	return r.Tool + "(" + r.Pattern + ")"
+}
+
+// Describe returns the rule with its level and layer, e.g.
+// "allow bash(go test:*) [project]".
+func (r Rule) Describe() string {
+	return fmt.Sprintf("%s %s [%s]", r.Level, r, r.Source)
+}
+
+// Matches reports whether the rule's pattern matches tool arguments.
+// Bash patterns are matched against the "command" argument, which the
+// manager sets to one command at a time. Paths are resolved against
+// workDir; for tools taking several paths an allow rule must match all
+// of them, while ask and deny rules match when any does.
+func (r Rule) Matches(args map[string]any, workDir string) bool {
+	if r.Pattern == "" {
+		return true
+	}
+
+	if r.Tool == "bash" {
+		cmd, ok := args["command"].(string)
+		return ok && matchCommand(r.Pattern, strings.Join(strings.Fields(cmd), " "))
+	}
+
+	if domain, ok := strings.CutPrefix(r.Pattern, "domain:"); ok {
+		raw, _ := args["url"].(string)
+		u, err := url.Parse(raw)
+		return err == nil && u.Hostname() != "" && wildcard(strings.ToLower(domain), strings.ToLower(u.Hostname()))
+	}
+
+	if m := argPattern.FindStringSubmatch(r.Pattern); m != nil {
+		val, ok := args[m[1]].(string)
+		return ok && wildcard(m[2], val)
+	}
+
+	pattern := resolvePath(r.Pattern, workDir)
+	matched, total := 0, 0
+	for _, key := range pathArgs {
+		p, ok := args[key].(string)
+		if !ok || p == "" {
+			continue
+		}
+		total++
+		if ok, _ := doublestar.Match(filepath.ToSlash(pattern), filepath.ToSlash(resolvePath(p, workDir))); ok {
+			matched++
+		}
+	}
+	if total > 0 {
+		if r.Level == LevelAllow {
+			return matched == total
+		}
+		return matched > 0
+	}
+
+	for _, key := range []string{"query", "pattern", "url", "name"} {
+		if val, ok := args[key].(string); ok {
+			return wildcard(r.Pattern, val)
+		}
+	}
+	return false
+}
+
+// resolvePath makes a path or path pattern absolute, expanding ~.
+func resolvePath(p, workDir string) string {
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			p = filepath.Join(home, rest)
+		}
+	}
+	if !filepath.IsAbs(p) && workDir != "" {
+		p = filepath.Join(workDir, p)
+	}
+	return filepath.Clean(p)
+}
+
+// exactCommands are commands an "always allow" answer covers only with
+// the same arguments, since their arguments decide what they do.
+var exactCommands = map[string]bool{
+	"rm": true, "rmdir": true, "mv": true, "cp": true, "dd": true, "ln": true,
+	"chmod": true, "chown": true, "chgrp": true, "shred": true, "truncate": true,
+	"kill": true, "pkill": true, "killall": true,
+	"sh": true, "bash": true, "zsh": true, "fish": true,
+	"python": true, "python3": true, "node": true, "ruby": true, "perl": true, "php": true,
+	"deno": true, "bun": true,
+	"curl": true, "wget": true, "ssh": true, "scp": true, "rsync": true,
+	"find": true, "sed": true, "awk": true, "gawk": true, "xargs": true,
+	"env": true, "sudo": true, "nohup": true, "nice": true, "timeout": true,
+}
+
+// subcommand matches arguments that name a subcommand, like "test" in
+// "go test ./...".
+var subcommand = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// ScopedRule returns the narrowest useful allow rule covering a tool
+// invocation, to remember an "always allow" answer without allowing the
+// whole tool:
+//
+//	bash "go test ./..."        bash(go test:*)
+//	bash "rm -rf build"         bash(rm -rf build)
+//	bash "git -C ../x status"   bash(git -C ../x status)
+//	edit internal/app/app.go    edit(internal/app/**)
+//	web_fetch https://go.dev/x  web_fetch(domain:go.dev)
+//
+// ok is false when the invocation has no such scope, e.g. a pipeline or
+// a move between two paths.
+func ScopedRule(toolName string, args map[string]any, workDir string) (Rule, bool) {
+	rule := Rule{Tool: toolName, Level: LevelAllow, Source: SourceSession}
+
+	if toolName == "bash" {
+		command, _ := args["command"].(string)
+		analysis, err := security.AnalyzeShell(command, workDir)
+		if err != nil || len(analysis.Commands) != 1 || len(analysis.Findings) > 0 {
+			return Rule{}, false
+		}
+		cmd := analysis.Commands[0]
+		if cmd.DynamicName || cmd.Dynamic {
+			return Rule{}, false
+		}
+		// Only a leading subcommand widens the rule; flags such as
+		// "git -C dir" change what any later arguments act on
+		if exactCommands[cmd.BaseName()] || len(cmd.Args) == 0 || !subcommand.MatchString(cmd.Args[0]) {
+			// A * would widen an exact pattern
+			if strings.Contains(cmd.String(), "*") {
+				return Rule{}, false
+			}
+			rule.Pattern = cmd.String()
+			return rule, true
+		}
+		rule.Pattern = cmd.Name + " " + cmd.Args[0] + ":*"
+		return rule, true
+	}
+
+	if raw, ok := args["url"].(string); ok {
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" {
+			return Rule{}, false
+		}
+		rule.Pattern = "domain:" + strings.ToLower(u.Hostname())
+		return rule, true
+	}
+
+	var paths []string
+	for _, key := range pathArgs {
+		if p, ok := args[key].(string); ok && p != "" {
+			paths = append(paths, p)
+		}
+	}
+	if len(paths) != 1 {
+		return Rule{}, false
+	}
+	abs := resolvePath(paths[0], workDir)
+	rel, err := filepath.Rel(workDir, abs)
+	inside := workDir != "" && err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+
+	switch {
+	case !inside:
+		rule.Pattern = abs
+	case toolName == "delete" || filepath.Dir(rel) == ".":
+		rule.Pattern = filepath.ToSlash(rel)
+	default:
+		rule.Pattern = filepath.ToSlash(filepath.Dir(rel)) + "/**"
+	}
+	return rule, true
+}
@@ -1,9 +1,21 @@
 package permission
 
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
 // Rules holds the permission rules for tools.
 type Rules struct {
 	DefaultPolicy Level            // Default policy for unknown tools
 	ToolPolicies  map[string]Level // Per-tool policies
+
+	// Argument-pattern rules from every layer. A matching rule overrides
+	// the tool policy.
+	patterns []Rule
+	mu       sync.RWMutex
 }
 
 // DefaultRules returns the default permission rules.
@@ -28,6 +40,10 @@ func DefaultRules() *Rules {
 			"code_graph":      LevelAllow,
 			"semantic_search": LevelAllow,
 			"history_search":  LevelAllow,
+			"lsp_definition":  LevelAllow,
+			"lsp_references":  LevelAllow,
+			"lsp_hover":       LevelAllow,
+			"lsp_symbols":     LevelAllow,
 			"web_search":      LevelAllow,
 			"web_fetch":       LevelAllow,
 			"task_output":     LevelAllow,
@@ -43,10 +59,11 @@ func DefaultRules() *Rules {
 			"mkdir":   LevelAsk,
 
 			// System/dangerous tools - always ask (dangerous)
-			"bash":       LevelAsk,
-			"delete":     LevelAsk,
-			"git_commit": LevelAsk,
-			"ssh":        LevelAsk,
+			"bash":         LevelAsk,
+			"delete":       LevelAsk,
+			"git_commit":   LevelAsk,
+			"ssh":          LevelAsk,
+			"mcp_sampling": LevelAsk,
 		},
 	}
 }
@@ -64,6 +81,132 @@ func (r *Rules) SetPolicy(toolName string, level Level) {
 	r.ToolPolicies[toolName] = level
 }
 
+// AddRule adds an argument-pattern rule. An identical rule from the same
+// layer is not added twice.
+func (r *Rules) AddRule(rule Rule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.patterns {
+		if existing == rule {
+			return
+		}
+	}
+	r.patterns = append(r.patterns, rule)
+}
+
+// AddRules parses rule specs such as "bash(git diff:*)" and adds them at
+// one level. Specs that do not parse are skipped and returned as errors.
+func (r *Rules) AddRules(specs []string, level Level, source string) []error {
+	var errs []error
+	for _, spec := range specs {
+		rule, err := ParseRule(spec, level, source)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		r.AddRule(rule)
+	}
+	return errs
+}
+
+// AddCommandPolicies adds bash rules from a map of command patterns to
+// levels, e.g. "go test *": "allow".
+func (r *Rules) AddCommandPolicies(policies map[string]string, source string) {
+	for pattern, policy := range policies {
+		r.AddRule(Rule{
+			Tool:    "bash",
+			Pattern: strings.Join(strings.Fields(pattern), " "),
+			Level:   parseLevel(policy),
+			Source:  source,
+		})
+	}
+}
+
+// RemoveRules removes all rules of a layer.
+func (r *Rules) RemoveRules(source string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.patterns = slices.DeleteFunc(r.patterns, func(rule Rule) bool {
+		return rule.Source == source
+	})
+}
+
+// List returns the argument-pattern rules.
+func (r *Rules) List() []Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.patterns)
+}
+
+// Match returns the rule that decides a tool invocation. Deny rules take
+// precedence; otherwise the most specific matching rule wins, and among
+// equally specific ones the most restrictive. ok is false when no rule
+// matches and the tool policy applies. Relative paths are resolved
+// against workDir.
+func (r *Rules) Match(toolName string, args map[string]any, workDir string) (Rule, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var best Rule
+	ok := false
+	for _, rule := range r.patterns {
+		if rule.Tool != toolName || !rule.Matches(args, workDir) {
+			continue
+		}
+		if !ok || precedes(rule, best) {
+			best, ok = rule, true
+		}
+	}
+	return best, ok
+}
+
+// precedes reports whether rule a decides over rule b.
+func precedes(a, b Rule) bool {
+	if (a.Level == LevelDeny) != (b.Level == LevelDeny) {
+		return a.Level == LevelDeny
+	}
+	if len(a.Pattern) != len(b.Pattern) {
+		return len(a.Pattern) > len(b.Pattern)
+	}
+	return restrictiveness(a.Level) > restrictiveness(b.Level)
+}
+
+// matchCommand reports whether command matches a command pattern. A * in
+// a pattern matches any text, and a trailing " *" or ":*" also matches no
+// arguments at all: "go test:*" matches "go test" and "go test ./...".
+func matchCommand(pattern, command string) bool {
+	if prefix, found := strings.CutSuffix(pattern, ":*"); found {
+		pattern = prefix + " *"
+	}
+	if prefix, found := strings.CutSuffix(pattern, " *"); found && command == prefix {
+		return true
+	}
+	return wildcard(pattern, command)
+}
+
+// wildcard reports whether s matches a pattern in which * matches any
+// text.
+func wildcard(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	re, err := regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+	return err == nil && re.MatchString(s)
+}
+
+// restrictiveness orders levels from allow to deny.
+func restrictiveness(l Level) int {
+	switch l {
+	case LevelAllow:
+		return 0
+	case LevelDeny:
+		return 2
+	default:
+		return 1
+	}
+}
+
 // NewRulesFromConfig creates rules from a config map.
 func NewRulesFromConfig(defaultPolicy string, toolPolicies map[string]string) *Rules {
 	rules := &Rules{
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+
+	"gokin/internal/hooks"
 )
 
 // ApprovalDecision represents the user's decision on a plan.
@@ -20,6 +22,11 @@ const (
 // ApprovalHandler is called to get user approval for a plan.
 type ApprovalHandler func(ctx context.Context, plan *Plan) (ApprovalDecision, error)
 
+// ContractProvider supplies the active contract for context injection.
+type ContractProvider interface {
+	GetActiveContractContext() string
+}
+
 // StepHandler is called before executing each step.
 // It can be used to show progress or confirm individual steps.
 type StepHandler func(step *Step)
@@ -37,6 +44,8 @@ type Manager struct {
 	onStepComplete   StepHandler
 	onProgressUpdate func(progress *ProgressUpdate) // Progress update handler
 	undoExtension    *ManagerUndoExtension          // Undo/redo support
+	contracts        ContractProvider               // Active contract context (optional)
+	hooks            *hooks.Manager                 // plan_step hooks (optional)
 
 	// Plan persistence
 	planStore *PlanStore
@@ -69,6 +78,24 @@ func (m *Manager) SetApprovalHandler(handler ApprovalHandler) {
 	m.approvalHandler = handler
 }
 
+// SetContractProvider sets the source of the active contract context.
+func (m *Manager) SetContractProvider(provider ContractProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.contracts = provider
+}
+
+// GetActiveContractContext returns the active contract formatted for the prompt.
+func (m *Manager) GetActiveContractContext() string {
+	m.mu.RLock()
+	provider := m.contracts
+	m.mu.RUnlock()
+	if provider == nil {
+		return ""
+	}
+	return provider.GetActiveContractContext()
+}
+
 // SetStepHandlers sets the step lifecycle handlers.
 func (m *Manager) SetStepHandlers(onStart, onComplete StepHandler) {
 	m.mu.Lock()
@@ -77,6 +104,30 @@ func (m *Manager) SetStepHandlers(onStart, onComplete StepHandler) {
 	m.onStepComplete = onComplete
 }
 
+// SetHooks sets the hooks manager for plan_step hooks.
+func (m *Manager) SetHooks(hm *hooks.Manager) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = hm
+}
+
+// runStepHooks runs plan_step hooks for a step transition. It returns nil
+// when no hooks manager is set.
+func (m *Manager) runStepHooks(plan *Plan, stepID int, status, output, errMsg string) *hooks.Outcome {
+	m.mu.RLock()
+	hm := m.hooks
+	m.mu.RUnlock()
+	if hm == nil {
+		return nil
+	}
+
+	info := hooks.StepInfo{PlanID: plan.ID, ID: stepID, Status: status, Output: output, Error: errMsg}
+	if step := plan.GetStep(stepID); step != nil {
+		info.Title = step.Title
+	}
+	return hm.RunPlanStep(context.Background(), info)
+}
+
 // IsEnabled returns whether plan mode is enabled.
 func (m *Manager) IsEnabled() bool {
 	return m.enabled
@@ -204,6 +255,7 @@ func (m *Manager) StartStep(stepID int) {
 	}
 
 	plan.StartStep(stepID)
+	m.runStepHooks(plan, stepID, hooks.StepStarted, "", "")
 
 	// Save progress (for crash recovery - we know which step was running)
 	if store != nil {
@@ -235,8 +287,9 @@ func (m *Manager) StartStep(stepID int) {
 	}
 }
 
-// CompleteStep marks a step as completed.
-func (m *Manager) CompleteStep(stepID int, output string) {
+// CompleteStep marks a step as completed. If a plan_step hook rejects the
+// completion, the step is failed instead and the hook's reason is returned.
+func (m *Manager) CompleteStep(stepID int, output string) error {
 	m.mu.Lock()
 	plan := m.currentPlan
 	store := m.planStore
@@ -245,7 +298,19 @@ func (m *Manager) CompleteStep(stepID int, output string) {
 	m.mu.Unlock()
 
 	if plan == nil {
-		return
+		return nil
+	}
+
+	// A plan_step hook can reject the completion (e.g. tests still failing)
+	if outcome := m.runStepHooks(plan, stepID, hooks.StepCompleted, output, ""); outcome != nil {
+		if outcome.Denied {
+			err := fmt.Errorf("blocked by hook %q: %s", outcome.DeniedBy, outcome.Reason)
+			m.FailStep(stepID, err.Error())
+			return err
+		}
+		if notes := outcome.Notes(); notes != "" {
+			output += "\n\n" + notes
+		}
 	}
 
 	plan.CompleteStep(stepID, output)
@@ -282,6 +347,8 @@ func (m *Manager) CompleteStep(stepID int, output string) {
 			onComplete(step)
 		}
 	}
+
+	return nil
 }
 
 // FailStep marks a step as failed.
@@ -297,6 +364,7 @@ func (m *Manager) FailStep(stepID int, errMsg string) {
 	}
 
 	plan.FailStep(stepID, errMsg)
+	m.runStepHooks(plan, stepID, hooks.StepFailed, "", errMsg)
 
 	// Auto-save failed plan for potential retry
 	if store != nil {
@@ -333,6 +401,7 @@ func (m *Manager) SkipStep(stepID int) {
 	}
 
 	plan.SkipStep(stepID)
+	m.runStepHooks(plan, stepID, hooks.StepSkipped, "", "")
 
 	// Send progress update
 	if onProgress != nil {
@@ -96,7 +96,8 @@ type Plan struct {
 	Status      Status    `json:"status"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
-	Request     string    `json:"request"` // Original user request
+	Request     string    `json:"request"`               // Original user request
+	ContractID  string    `json:"contract_id,omitempty"` // Contract approved together with this plan
 
 	// Context snapshot from planning conversation (preserved across session clear)
 	ContextSnapshot string `json:"context_snapshot,omitempty"`
@@ -0,0 +1,258 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// PassphraseEnv is the environment variable holding the passphrase of the
+// encrypted file. Without it the passphrase is asked for on the terminal
+// at startup. A secrets.key file written by earlier versions is still
+// read, but a key stored next to the file only obfuscates the secrets.
+const PassphraseEnv = "GOKIN_SECRETS_PASSPHRASE"
+
+// maxPassphraseAttempts bounds the passphrase prompts for an existing file.
+const maxPassphraseAttempts = 3
+
+// scrypt parameters for deriving the file key.
+const (
+	scryptN   = 1 << 15
+	scryptR   = 8
+	scryptP   = 1
+	keyLength = 32
+)
+
+// fileEnvelope is the on-disk format of the encrypted file.
+type fileEnvelope struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// FileBackend keeps secrets in a file encrypted with AES-256-GCM, using a
+// key derived with scrypt from a passphrase.
+type FileBackend struct {
+	path    string
+	keyPath string
+
+	pass        []byte // Passphrase entered on the terminal
+	interactive bool   // Whether the passphrase may be asked for
+	mu          sync.Mutex
+}
+
+// NewFileBackend creates a backend for secrets.enc in dir.
+func NewFileBackend(dir string) *FileBackend {
+	return &FileBackend{
+		path:    filepath.Join(dir, "secrets.enc"),
+		keyPath: filepath.Join(dir, "secrets.key"),
+	}
+}
+
+// Name returns where the file is, and whether its key is kept in plain
+// text beside it.
+func (f *FileBackend) Name() string {
+	if f.legacyKey() {
+		return "file " + f.path + " (obfuscated, not encrypted: its key is stored in " + f.keyPath + ")"
+	}
+	return "encrypted file " + f.path
+}
+
+// SetInteractive lets the backend ask for the passphrase on the terminal
+// while on. It should be off once the UI owns the terminal.
+func (f *FileBackend) SetInteractive(on bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.interactive = on
+}
+
+// legacyKey reports whether the passphrase comes from a key file.
+func (f *FileBackend) legacyKey() bool {
+	f.mu.Lock()
+	entered := f.pass != nil
+	f.mu.Unlock()
+	if os.Getenv(PassphraseEnv) != "" || entered {
+		return false
+	}
+	_, err := os.Stat(f.keyPath)
+	return err == nil
+}
+
+// Path returns the path of the encrypted file.
+func (f *FileBackend) Path() string {
+	return f.path
+}
+
+// passphrase returns the passphrase from the environment, the terminal or
+// a key file of earlier versions. prompted is set when it was just
+// entered; create asks for it twice, for a new file.
+func (f *FileBackend) passphrase(create bool) (pass []byte, prompted bool, err error) {
+	if p := os.Getenv(PassphraseEnv); p != "" {
+		return []byte(p), false, nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.pass != nil {
+		return f.pass, false, nil
+	}
+	if data, err := os.ReadFile(f.keyPath); err == nil {
+		return []byte(strings.TrimSpace(string(data))), false, nil
+	}
+
+	if !f.interactive || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, false, fmt.Errorf("no passphrase for %s: set %s", f.path, PassphraseEnv)
+	}
+	pass, err = readPassphrase(fmt.Sprintf("Passphrase for %s: ", f.path))
+	if err != nil {
+		return nil, false, err
+	}
+	if create {
+		again, err := readPassphrase("Repeat the passphrase: ")
+		if err != nil {
+			return nil, false, err
+		}
+		if !bytes.Equal(pass, again) {
+			return nil, false, errors.New("passphrases do not match")
+		}
+	}
+	f.pass = pass
+	return pass, true, nil
+}
+
+// forget drops a passphrase entered on the terminal.
+func (f *FileBackend) forget() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pass = nil
+}
+
+// readPassphrase reads a non-empty passphrase from the terminal without
+// echoing it.
+func readPassphrase(prompt string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	pass, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if len(pass) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+	return pass, nil
+}
+
+// Load decrypts the file.
+func (f *FileBackend) Load() (map[string]string, error) {
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var env fileEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("corrupt secrets file %s: %w", f.path, err)
+	}
+	if env.Version != 1 || env.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported secrets file %s (version %d, kdf %q)", f.path, env.Version, env.KDF)
+	}
+
+	var plain []byte
+	for attempt := 1; ; attempt++ {
+		passphrase, prompted, err := f.passphrase(false)
+		if err != nil {
+			return nil, err
+		}
+		gcm, err := newGCM(passphrase, env.Salt)
+		if err != nil {
+			return nil, err
+		}
+		plain, err = gcm.Open(nil, env.Nonce, env.Data, nil)
+		if err == nil {
+			break
+		}
+		if prompted {
+			f.forget()
+		}
+		if !prompted || attempt == maxPassphraseAttempts {
+			return nil, errors.New("cannot decrypt " + f.path + ": wrong passphrase")
+		}
+		fmt.Fprintln(os.Stderr, "Wrong passphrase.")
+	}
+
+	values := make(map[string]string)
+	if err := json.Unmarshal(plain, &values); err != nil {
+		return nil, fmt.Errorf("corrupt secrets file %s: %w", f.path, err)
+	}
+	return values, nil
+}
+
+// Save encrypts the secrets with a fresh salt and nonce and replaces the
+// file atomically.
+func (f *FileBackend) Save(values map[string]string) error {
+	_, statErr := os.Stat(f.path)
+	passphrase, _, err := f.passphrase(os.IsNotExist(statErr))
+	if err != nil {
+		return err
+	}
+
+	plain, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	env := fileEnvelope{Version: 1, KDF: "scrypt", Salt: make([]byte, 16)}
+	if _, err := rand.Read(env.Salt); err != nil {
+		return err
+	}
+	gcm, err := newGCM(passphrase, env.Salt)
+	if err != nil {
+		return err
+	}
+	env.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return err
+	}
+	env.Data = gcm.Seal(nil, env.Nonce, plain, nil)
+
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
+
+// newGCM derives the key from a passphrase and salt.
+func newGCM(passphrase, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, keyLength)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,118 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keyringAccount is the account the secrets are stored under.
+const keyringAccount = "secrets"
+
+// KeyringBackend keeps secrets in a single item of the OS keyring: the
+// macOS Keychain through security(1), or the Secret Service (GNOME
+// Keyring, KWallet) through secret-tool(1). Secrets are passed on stdin,
+// never on the command line.
+type KeyringBackend struct {
+	service string
+	tool    string
+}
+
+// NewKeyringBackend returns a backend for the OS keyring, and false when
+// there is none: on other systems, without the tool, or on Linux without a
+// D-Bus session.
+func NewKeyringBackend(service string) (*KeyringBackend, bool) {
+	var tool string
+	switch runtime.GOOS {
+	case "darwin":
+		tool = "security"
+	case "linux", "freebsd", "openbsd":
+		if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+			return nil, false
+		}
+		tool = "secret-tool"
+	default:
+		return nil, false
+	}
+	path, err := exec.LookPath(tool)
+	if err != nil {
+		return nil, false
+	}
+	return &KeyringBackend{service: service, tool: path}, true
+}
+
+// Name returns the keyring's name.
+func (k *KeyringBackend) Name() string {
+	if runtime.GOOS == "darwin" {
+		return "macOS Keychain"
+	}
+	return "Secret Service keyring"
+}
+
+// Load reads the item. A missing item is an empty set of secrets.
+func (k *KeyringBackend) Load() (map[string]string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command(k.tool, "find-generic-password", "-s", k.service, "-a", keyringAccount, "-w")
+	} else {
+		cmd = exec.Command(k.tool, "lookup", "service", k.service, "account", keyringAccount)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// A missing item is exit code 44 for security and a silent 1 for
+		// secret-tool
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code := exitErr.ExitCode()
+			if (runtime.GOOS == "darwin" && code == 44) || (runtime.GOOS != "darwin" && code == 1 && stderr.Len() == 0) {
+				return map[string]string{}, nil
+			}
+		}
+		return nil, fmt.Errorf("keyring lookup failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	encoded := strings.TrimSpace(string(out))
+	if encoded == "" {
+		return map[string]string{}, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt keyring item: %w", err)
+	}
+	values := make(map[string]string)
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("corrupt keyring item: %w", err)
+	}
+	return values, nil
+}
+
+// Save replaces the item.
+func (k *KeyringBackend) Save(values map[string]string) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		// Interactive mode reads the command, and so the secret, from stdin
+		cmd = exec.Command(k.tool, "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", k.service, keyringAccount, encoded))
+	} else {
+		cmd = exec.Command(k.tool, "store", "--label=Gokin credentials", "service", k.service, "account", keyringAccount)
+		cmd.Stdin = strings.NewReader(encoded)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("keyring store failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+// Package secrets keeps API keys and OAuth tokens out of the config file,
+// in the OS keyring or in a file encrypted with a passphrase-derived key.
+package secrets
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// Backend setting values.
+const (
+	BackendAuto    = "auto"    // The OS keyring when available, the encrypted file otherwise
+	BackendKeyring = "keyring" // The OS keyring
+	BackendFile    = "file"    // An encrypted file in the config directory
+	BackendNone    = "none"    // No store; secrets stay in the config file
+)
+
+// Backend persists the whole set of secrets at once.
+type Backend interface {
+	// Name describes where the secrets are kept, e.g. "macOS Keychain".
+	Name() string
+	// Load returns the stored secrets; an empty map if there are none.
+	Load() (map[string]string, error)
+	// Save replaces the stored secrets.
+	Save(values map[string]string) error
+}
+
+// Store caches the secrets of a backend.
+type Store struct {
+	backend Backend
+
+	values  map[string]string
+	loadErr error
+	loaded  bool
+	mu      sync.Mutex
+}
+
+// NewStore creates a store on a backend.
+func NewStore(backend Backend) *Store {
+	return &Store{backend: backend}
+}
+
+// Open returns the store for a backend setting, with the encrypted file
+// kept in dir. It returns nil for BackendNone.
+func Open(backend, dir string) (*Store, error) {
+	switch backend {
+	case BackendNone:
+		return nil, nil
+	case BackendKeyring:
+		kr, ok := NewKeyringBackend("gokin")
+		if !ok {
+			return nil, fmt.Errorf("no OS keyring available")
+		}
+		return NewStore(kr), nil
+	case BackendFile:
+		return NewStore(NewFileBackend(dir)), nil
+	case BackendAuto, "":
+		if kr, ok := NewKeyringBackend("gokin"); ok {
+			return NewStore(kr), nil
+		}
+		return NewStore(NewFileBackend(dir)), nil
+	default:
+		return nil, fmt.Errorf("unknown secret store backend: %s", backend)
+	}
+}
+
+// Backend returns the backend of the store.
+func (s *Store) Backend() Backend {
+	return s.backend
+}
+
+// Name describes where the store keeps secrets.
+func (s *Store) Name() string {
+	return s.backend.Name()
+}
+
+// load reads the secrets once. Must be called with mu held.
+func (s *Store) load() error {
+	if !s.loaded {
+		s.values, s.loadErr = s.backend.Load()
+		s.loaded = true
+	}
+	return s.loadErr
+}
+
+// Get returns a secret, or "" if it is not stored or the store cannot be
+// read.
+func (s *Store) Get(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.load() != nil {
+		return ""
+	}
+	return s.values[name]
+}
+
+// Values returns all stored secrets.
+func (s *Store) Values() (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return maps.Clone(s.values), nil
+}
+
+// Update sets secrets, removing those set to "". The backend is only
+// written when something changed. A store that could not be read is not
+// written, so that secrets under another passphrase are not overwritten.
+func (s *Store) Update(values map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	next := maps.Clone(s.values)
+	if next == nil {
+		next = make(map[string]string)
+	}
+	for name, value := range values {
+		if value == "" {
+			delete(next, name)
+		} else {
+			next[name] = value
+		}
+	}
+	if maps.Equal(next, s.values) {
+		return nil
+	}
+
+	if err := s.backend.Save(next); err != nil {
+		return fmt.Errorf("failed to save secrets to %s: %w", s.backend.Name(), err)
+	}
+	s.values = next
+	return nil
+}
+
+var (
+	defaultStore *Store
+	defaultMu    sync.RWMutex
+)
+
+// SetDefault sets the store used by Default.
+func SetDefault(s *Store) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultStore = s
+}
+
+// Default returns the store opened with the configuration, or nil when
+// secrets are kept in the config file.
+func Default() *Store {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultStore
+}
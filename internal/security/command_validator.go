@@ -7,12 +7,12 @@ import (
 )
 
 // CommandValidator provides unified command validation for bash execution.
-// It implements both whitelist and blocklist approaches for maximum security.
+// Commands are parsed into a shell syntax tree, so quoting, command
+// substitutions, pipelines and nested scripts cannot hide what runs. Every
+// simple command is checked on its own against the blocklists.
 type CommandValidator struct {
 	// blockedPatterns are regex patterns that should never be allowed
 	blockedPatterns []*regexp.Regexp
-	// cautionPatterns are patterns that are suspicious but not outright blocked
-	cautionPatterns []*regexp.Regexp
 	// blockedCommands are exact command strings that are blocked
 	blockedCommands []string
 	// blockedSubstrings are substrings that indicate dangerous commands
@@ -20,6 +20,9 @@ type CommandValidator struct {
 }
 
 // NewCommandValidator creates a new CommandValidator with secure defaults.
+// Destructive commands, downloads piped into shells, eval, fork bombs,
+// reverse shells and writes to system locations are recognized by
+// AnalyzeShell; the lists below cover what a single command may mention.
 func NewCommandValidator() *CommandValidator {
 	cv := &CommandValidator{
 		blockedCommands: []string{
@@ -28,41 +31,6 @@ func NewCommandValidator() *CommandValidator {
 			":(){ :|:& };:",
 		},
 		blockedSubstrings: []string{
-			// Destructive filesystem operations
-			"rm -rf /",
-			"rm -rf /*",
-			"rm -rf ~",
-			"rm -rf $HOME",
-			"rm -rf ${HOME}",
-			"rm -fr /",
-			"rm -fr /*",
-			// Disk operations
-			"mkfs.",
-			"mkfs ",
-			"> /dev/sda",
-			"> /dev/nvme",
-			"> /dev/hd",
-			"> /dev/vd",
-			"dd if=/dev/zero of=/dev/sd",
-			"dd if=/dev/zero of=/dev/nvme",
-			"dd if=/dev/zero of=/dev/hd",
-			"dd if=/dev/zero of=/dev/vd",
-			"dd if=/dev/urandom of=/dev/sd",
-			"dd if=/dev/urandom of=/dev/nvme",
-			"dd if=/dev/random of=/dev/sd",
-			// Permission attacks
-			"chmod -R 777 /",
-			"chmod 777 /",
-			"chown -R root /",
-			// Network attacks / reverse shells
-			"nc -e",
-			"nc -c",
-			"ncat -e",
-			"ncat -c",
-			"bash -i >& /dev/tcp",
-			"bash -i >& /dev/udp",
-			"/dev/tcp/",
-			"/dev/udp/",
 			// Sensitive file access
 			"/etc/shadow",
 			"/etc/passwd",
@@ -73,103 +41,21 @@ func NewCommandValidator() *CommandValidator {
 			".aws/credentials",
 			".kube/config",
 			".gnupg/",
-			// Kernel/system modification
-			"insmod ",
-			"rmmod ",
-			"modprobe ",
-			"/proc/sys",
-			"/sys/kernel",
-			// Boot modification
-			"/boot/",
-			"grub-install",
-			"update-grub",
 			// Credential theft
 			"mimikatz",
 			"hashdump",
 			"secretsdump",
-			// Env variable injection for code loading
-			"LD_PRELOAD=",
-			"LD_LIBRARY_PATH=",
-			"DYLD_INSERT_LIBRARIES=",
 		},
 	}
 
-	// Compile regex patterns for more complex detection
+	// Patterns are matched against each command with quoting removed
 	cv.blockedPatterns = []*regexp.Regexp{
-		// Fork bomb patterns
-		regexp.MustCompile(`:\s*\(\s*\)\s*\{`),                // :(){
-		regexp.MustCompile(`\$\{?0\}?\s*[&|]\s*\$\{?0\}?`),    // $0 & $0 or $0 | $0
-		regexp.MustCompile(`while\s+true\s*;\s*do.*&`),        // while true; do ... &
-		regexp.MustCompile(`(?i)fork\s*bomb`),                 // "fork bomb" mention
-		regexp.MustCompile(`\byes\s*\|\s*sh`),                 // yes | sh
-		regexp.MustCompile(`\beval\s+\$\(`),                   // eval $(...)
-		regexp.MustCompile(`\bexec\s+\$\{?0\}?`),              // exec $0
-
-		// Recursive deletion with variable expansion
-		regexp.MustCompile(`rm\s+(-[rRf]+\s+)+/`),             // rm -rf / variants
-		regexp.MustCompile(`rm\s+(-[rRf]+\s+)+\$`),            // rm -rf $VAR
-
-		// dd to block devices
-		regexp.MustCompile(`dd\s+.*of=/dev/[snhv]d`),          // dd of=/dev/sd*
-		regexp.MustCompile(`dd\s+.*of=/dev/nvme`),             // dd of=/dev/nvme*
-
-		// Wget/curl piped to shell (potential malware download)
-		regexp.MustCompile(`(?i)(wget|curl)\s+.*\|\s*(ba)?sh`),
-		regexp.MustCompile(`(?i)(wget|curl)\s+-[^|]*\|\s*(ba)?sh`),
-
-		// Base64 decode piped to shell
-		regexp.MustCompile(`base64\s+-d.*\|\s*(ba)?sh`),
-
 		// Python/Perl one-liners that could be reverse shells
-		regexp.MustCompile(`python[23]?\s+-c\s+['"].*socket.*exec`),
-		regexp.MustCompile(`perl\s+-e\s+['"].*socket.*exec`),
-
-		// Overwriting MBR/bootloader
-		regexp.MustCompile(`dd\s+.*of=/dev/[snhv]d[a-z]$`),    // dd to disk (no partition)
-
-		// Mounting attacks
-		regexp.MustCompile(`mount\s+.*-o\s+.*remount.*rw\s+/`),
-
-		// crontab manipulation for persistence
-		regexp.MustCompile(`echo\s+.*>>\s*/etc/cron`),
-		regexp.MustCompile(`echo\s+.*>>\s*/var/spool/cron`),
-
-		// SSH key injection
-		regexp.MustCompile(`echo\s+.*>>\s*.*authorized_keys`),
-
-		// Systemd service creation (persistence)
-		regexp.MustCompile(`cat\s+.*>\s*/etc/systemd/system/`),
-
-		// History clearing (covering tracks)
-		regexp.MustCompile(`>\s*~/\..*history`),
-		regexp.MustCompile(`history\s+-c`),
-		regexp.MustCompile(`unset\s+HISTFILE`),
+		regexp.MustCompile(`^python[23]?\s+-c\s+.*socket.*(exec|subprocess|pty)`),
+		regexp.MustCompile(`^perl\s+-e\s+.*socket.*exec`),
 
-		// Process hiding attempts
-		regexp.MustCompile(`LD_PRELOAD.*\.so`),
-
-		// Obfuscated commands (hex/octal escapes)
-		regexp.MustCompile(`\\[0-7]{3}`),                       // \OOO
-		regexp.MustCompile(`(?i)printf\s+.*\\`),                // printf with escapes
-
-		// Shell injection separators and constructs
-		regexp.MustCompile(`[;&|]\s*(ba)?sh`),                 // ;sh, |sh, &sh
-		regexp.MustCompile(`(?i)eval\s+.*(base64|curl|wget|nc\b)`), // eval with known-dangerous commands
-		regexp.MustCompile(`>\s*/dev/(tcp|udp)/`),              // redundant but safer with separators
-
-		// Additional eval patterns
-		regexp.MustCompile(`\beval\s+["']`),                    // eval "..." / eval '...'
-		regexp.MustCompile(`\beval\s+\$`),                      // eval $var
-		regexp.MustCompile(`\bsource\s+/dev/`),                 // source /dev/tcp
-	}
-
-	// Caution patterns: suspicious but not outright blocked.
-	// These are checked only by ValidateWithLevel() for elevated permission prompts.
-	cv.cautionPatterns = []*regexp.Regexp{
-		regexp.MustCompile(`\\x[0-9a-fA-F]{2}`),   // \xXX hex escapes
-		regexp.MustCompile(`\$\(\s*.*\s*\)`),       // $( ... ) command substitution
-		regexp.MustCompile("`[^`]*`"),               // `...` backtick substitution
-		regexp.MustCompile(`\$\{[^}]+\}`),           // ${...} parameter expansion
+		// Remounting the root filesystem writable
+		regexp.MustCompile(`^mount\s+.*-o\s+\S*remount\S*rw\S*\s+/$`),
 	}
 
 	return cv
@@ -192,10 +78,8 @@ func (cv *CommandValidator) Validate(command string) ValidationResult {
 		}
 	}
 
-	// Normalize the command for checking
-	normalizedCmd := strings.ToLower(command)
-
 	// Check exact blocked commands
+	normalizedCmd := strings.ToLower(command)
 	for _, blocked := range cv.blockedCommands {
 		if command == blocked || normalizedCmd == strings.ToLower(blocked) {
 			return ValidationResult{
@@ -206,24 +90,66 @@ func (cv *CommandValidator) Validate(command string) ValidationResult {
 		}
 	}
 
-	// Check blocked substrings
-	for _, substr := range cv.blockedSubstrings {
-		if strings.Contains(normalizedCmd, strings.ToLower(substr)) {
-			return ValidationResult{
-				Valid:   false,
-				Reason:  fmt.Sprintf("contains blocked pattern: %s", substr),
-				Pattern: substr,
+	// Bash would run the lines before a syntax error, so unparseable
+	// commands are not run at all
+	analysis, err := AnalyzeShell(command, "")
+	if err != nil {
+		return ValidationResult{
+			Valid:  false,
+			Reason: fmt.Sprintf("cannot parse command: %s", err),
+		}
+	}
+	return cv.check(analysis)
+}
+
+// check validates the commands of an analyzed command line.
+func (cv *CommandValidator) check(analysis *ShellAnalysis) ValidationResult {
+	if finding := analysis.Blocked(); finding != nil {
+		return ValidationResult{
+			Valid:   false,
+			Reason:  finding.Reason,
+			Pattern: finding.Command,
+		}
+	}
+
+	for _, cmd := range analysis.Commands {
+		text := cmd.text()
+		normalized := strings.ToLower(text)
+
+		// Check blocked substrings
+		for _, substr := range cv.blockedSubstrings {
+			if strings.Contains(normalized, strings.ToLower(substr)) {
+				return ValidationResult{
+					Valid:   false,
+					Reason:  fmt.Sprintf("%s: contains blocked pattern: %s", cmd.BaseName(), substr),
+					Pattern: substr,
+				}
+			}
+		}
+
+		// Check regex patterns
+		for _, pattern := range cv.blockedPatterns {
+			if pattern.MatchString(text) {
+				return ValidationResult{
+					Valid:   false,
+					Reason:  fmt.Sprintf("%s: matches dangerous pattern", cmd.BaseName()),
+					Pattern: pattern.String(),
+				}
 			}
 		}
 	}
 
-	// Check regex patterns
-	for _, pattern := range cv.blockedPatterns {
-		if pattern.MatchString(command) {
-			return ValidationResult{
-				Valid:   false,
-				Reason:  "matches dangerous pattern",
-				Pattern: pattern.String(),
+	// Blocked substrings may also hide in assignments and redirections:
+	// x=/etc/shadow; cat $x
+	for _, word := range analysis.Words {
+		normalized := strings.ToLower(word)
+		for _, substr := range cv.blockedSubstrings {
+			if strings.Contains(normalized, strings.ToLower(substr)) {
+				return ValidationResult{
+					Valid:   false,
+					Reason:  fmt.Sprintf("contains blocked pattern: %s", substr),
+					Pattern: substr,
+				}
 			}
 		}
 	}
@@ -235,23 +161,25 @@ func (cv *CommandValidator) Validate(command string) ValidationResult {
 }
 
 // ValidateWithLevel checks a command and returns validation result with safety level.
-// The level is one of: "blocked", "caution", or "safe".
+// The level is one of: "blocked", "caution", or "safe". Commands are
+// "caution" when they run commands whose names are computed at run time,
+// pipe commands into a shell, or use sudo.
 func (cv *CommandValidator) ValidateWithLevel(command string) (ValidationResult, string) {
 	result := cv.Validate(command)
 	if !result.Valid {
 		return result, "blocked"
 	}
 
-	// Check caution patterns
-	normalized := strings.ToLower(command)
-	for _, pattern := range cv.cautionPatterns {
-		if pattern.MatchString(normalized) || pattern.MatchString(command) {
-			return ValidationResult{
-				Valid:   true,
-				Reason:  "command contains potentially dangerous constructs",
-				Pattern: pattern.String(),
-			}, "caution"
-		}
+	analysis, err := AnalyzeShell(command, "")
+	if err != nil {
+		return result, "safe"
+	}
+	if cautions := analysis.Cautions(); len(cautions) > 0 {
+		return ValidationResult{
+			Valid:   true,
+			Reason:  cautions[0].Reason,
+			Pattern: cautions[0].Command,
+		}, "caution"
 	}
 
 	return result, "safe"
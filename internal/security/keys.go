@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"gokin/internal/secrets"
 )
 
 // KeySource represents where an API key was loaded from
@@ -14,7 +16,8 @@ const (
 	KeySourceEnvironment KeySource = "environment"
 	// KeySourceConfig indicates the key was loaded from config file
 	KeySourceConfig KeySource = "config"
-	// KeySourceKeyring indicates the key was loaded from system keyring
+	// KeySourceKeyring indicates the key was loaded from the secret store
+	// (the OS keyring or the encrypted secrets file)
 	KeySourceKeyring KeySource = "keyring"
 	// KeySourceNotSet indicates no key was found
 	KeySourceNotSet KeySource = "not_set"
@@ -48,16 +51,18 @@ func (k *LoadedKey) IsSet() bool {
 
 // GetAPIKey loads an API key from multiple sources in priority order:
 // 1. Environment variables (highest priority)
-// 2. Config file value (fallback)
-// 3. System keyring (optional, future enhancement)
+// 2. Secret store: the OS keyring or the encrypted secrets file
+// 3. Config file value (fallback)
 //
 // Priority ensures that environment variables override config files,
-// allowing secure deployment without storing keys in configs.
+// allowing secure deployment without storing keys in configs. The config
+// is filled in from the secret store when loaded, so a config value equal
+// to the stored one is reported as coming from the store.
 //
 // Parameters:
 //   - envVarNames: List of environment variable names to check (in priority order)
 //   - configValue: Fallback value from config file
-//   - keyringService: Optional service name for keyring lookup (empty string = skip keyring)
+//   - keyringService: Name of the key in the secret store (empty string = skip the store)
 //
 // Returns:
 //   - LoadedKey: The loaded key with source information
@@ -72,7 +77,17 @@ func GetAPIKey(envVarNames []string, configValue string, keyringService string)
 		}
 	}
 
-	// Priority 2: Config file (fallback)
+	// Priority 2: Secret store, unless a config file sets another value
+	if store := secrets.Default(); store != nil && keyringService != "" {
+		if value := store.Get(keyringService); value != "" && (configValue == "" || configValue == value) {
+			return &LoadedKey{
+				Value:  value,
+				Source: KeySourceKeyring,
+			}
+		}
+	}
+
+	// Priority 3: Config file (fallback)
 	if configValue != "" {
 		return &LoadedKey{
 			Value:  configValue,
@@ -80,11 +95,6 @@ func GetAPIKey(envVarNames []string, configValue string, keyringService string)
 		}
 	}
 
-	// Priority 3: System keyring (reserved for future use)
-	// Keyring support is not implemented to avoid external dependencies.
-	// Use environment variables for secure key storage instead.
-	_ = keyringService
-
 	// No key found
 	return &LoadedKey{
 		Value:  "",
@@ -99,7 +109,7 @@ func GetAPIKey(envVarNames []string, configValue string, keyringService string)
 //   - GEMINI_API_KEY (generic, for compatibility)
 //   - GOOGLE_API_KEY (generic Google API key)
 //
-// Config fallback:
+// Secret store and config fallback:
 //   - api.gemini_key (new field)
 //   - api.api_key (legacy field, for backward compatibility)
 func GetGeminiKey(configGeminiKey, configLegacyKey string) *LoadedKey {
@@ -110,12 +120,12 @@ func GetGeminiKey(configGeminiKey, configLegacyKey string) *LoadedKey {
 	}
 
 	// Try new config field first, then legacy
-	configValue := configGeminiKey
+	configValue, name := configGeminiKey, "gemini_key"
 	if configValue == "" {
-		configValue = configLegacyKey
+		configValue, name = configLegacyKey, "api_key"
 	}
 
-	return GetAPIKey(envVars, configValue, "")
+	return GetAPIKey(envVars, configValue, name)
 }
 
 // GetGLMKey loads the GLM (GLM-4.7) API key from environment or config
@@ -125,7 +135,7 @@ func GetGeminiKey(configGeminiKey, configLegacyKey string) *LoadedKey {
 //   - GLM_API_KEY (generic)
 //   - ANTHROPIC_API_KEY (for Anthropic-compatible APIs)
 //
-// Config fallback:
+// Secret store and config fallback:
 //   - api.glm_key (new field)
 //   - api.anthropic_api_key (legacy field, for backward compatibility)
 func GetGLMKey(configGLMKey, configLegacyKey string) *LoadedKey {
@@ -136,12 +146,12 @@ func GetGLMKey(configGLMKey, configLegacyKey string) *LoadedKey {
 	}
 
 	// Try new config field first, then legacy
-	configValue := configGLMKey
+	configValue, name := configGLMKey, "glm_key"
 	if configValue == "" {
-		configValue = configLegacyKey
+		configValue, name = configLegacyKey, "api_key"
 	}
 
-	return GetAPIKey(envVars, configValue, "")
+	return GetAPIKey(envVars, configValue, name)
 }
 
 // GetOllamaKey loads the optional Ollama API key from environment or config.
@@ -152,7 +162,7 @@ func GetGLMKey(configGLMKey, configLegacyKey string) *LoadedKey {
 //   - GOKIN_OLLAMA_KEY (recommended, explicit)
 //   - OLLAMA_API_KEY (generic)
 //
-// Config fallback:
+// Secret store and config fallback:
 //   - api.ollama_key
 func GetOllamaKey(configOllamaKey string) *LoadedKey {
 	envVars := []string{
@@ -160,7 +170,7 @@ func GetOllamaKey(configOllamaKey string) *LoadedKey {
 		"OLLAMA_API_KEY",   // Generic Ollama
 	}
 
-	return GetAPIKey(envVars, configOllamaKey, "")
+	return GetAPIKey(envVars, configOllamaKey, "ollama_key")
 }
 
 // GetDeepSeekKey loads the DeepSeek API key from environment or config
@@ -169,7 +179,7 @@ func GetOllamaKey(configOllamaKey string) *LoadedKey {
 //   - GOKIN_DEEPSEEK_KEY (recommended, explicit)
 //   - DEEPSEEK_API_KEY (generic)
 //
-// Config fallback:
+// Secret store and config fallback:
 //   - api.deepseek_key (new field)
 //   - api.api_key (legacy field, for backward compatibility)
 func GetDeepSeekKey(configDeepSeekKey, configLegacyKey string) *LoadedKey {
@@ -179,12 +189,36 @@ func GetDeepSeekKey(configDeepSeekKey, configLegacyKey string) *LoadedKey {
 	}
 
 	// Try new config field first, then legacy
-	configValue := configDeepSeekKey
+	configValue, name := configDeepSeekKey, "deepseek_key"
 	if configValue == "" {
-		configValue = configLegacyKey
+		configValue, name = configLegacyKey, "api_key"
 	}
 
-	return GetAPIKey(envVars, configValue, "")
+	return GetAPIKey(envVars, configValue, name)
+}
+
+// GetOpenAICompatibleKey loads the optional API key of a named OpenAI-compatible provider
+//
+// Environment variables checked (in priority order):
+//   - the variable named by api_key_env, if set
+//   - GOKIN_<NAME>_KEY (e.g. GOKIN_OPENROUTER_KEY)
+//
+// Secret store and config fallback:
+//   - api.openai_compatible.<name>.api_key
+func GetOpenAICompatibleKey(name, configKeyEnv, configKey string) *LoadedKey {
+	var envVars []string
+	if configKeyEnv != "" {
+		envVars = append(envVars, configKeyEnv)
+	}
+	envName := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(name))
+	envVars = append(envVars, "GOKIN_"+envName+"_KEY")
+
+	return GetAPIKey(envVars, configKey, "openai_compatible."+name+".api_key")
 }
 
 // MaskKey masks an API key for safe logging/display
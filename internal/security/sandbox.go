@@ -16,23 +16,79 @@ import (
 type SandboxConfig struct {
 	// Enabled determines if sandboxing is active
 	Enabled bool
-	// RootDir is the root directory for chroot (empty = use current workDir)
+	// RootDir is the root directory for chroot (empty = no chroot)
 	RootDir string
 	// EnableSeccomp enables seccomp-bpf syscall filtering (Linux only)
 	EnableSeccomp bool
-	// ReadOnly makes the sandbox filesystem read-only
+	// EnableLandlock restricts filesystem writes with Landlock where the kernel supports it (Linux only)
+	EnableLandlock bool
+	// ReadOnly makes the whole sandbox filesystem read-only, including the workDir
 	ReadOnly bool
+	// WritableDirs are additional directories that stay writable (workDir is always writable unless ReadOnly)
+	WritableDirs []string
+	// DisableNetwork runs the command in an empty network namespace (Linux only)
+	DisableNetwork bool
 }
 
 // DefaultSandboxConfig returns the default sandbox configuration
 func DefaultSandboxConfig() SandboxConfig {
 	return SandboxConfig{
-		Enabled:       true,
-		EnableSeccomp: false, // Disabled by default (requires libseccomp)
-		ReadOnly:      false,
+		Enabled:        true,
+		EnableSeccomp:  true,
+		EnableLandlock: true,
+		ReadOnly:       false,
+		DisableNetwork: false,
 	}
 }
 
+// SandboxLayer describes a single isolation layer and whether it is in effect.
+type SandboxLayer struct {
+	Name   string
+	Active bool
+	Detail string // Why the layer is inactive, or what it enforces
+}
+
+// SandboxStatus reports which isolation layers sandboxed commands actually get.
+type SandboxStatus struct {
+	Platform string
+	Layers   []SandboxLayer
+}
+
+// ActiveCount returns the number of active layers.
+func (s SandboxStatus) ActiveCount() int {
+	n := 0
+	for _, l := range s.Layers {
+		if l.Active {
+			n++
+		}
+	}
+	return n
+}
+
+// GetSandboxStatus returns the isolation layers that a command started with the
+// given configuration would run under. On Linux the layers are probed by actually
+// starting a sandboxed helper process, so the result reflects kernel support.
+func GetSandboxStatus(config SandboxConfig) SandboxStatus {
+	return SandboxStatus{
+		Platform: runtime.GOOS + "/" + runtime.GOARCH,
+		Layers:   sandboxLayers(config),
+	}
+}
+
+// sandboxInitArg is the hidden argument used to re-execute the binary as the
+// sandbox init process, which sets up isolation and then execs bash.
+const sandboxInitArg = "__gokin-sandbox-init"
+
+// sandboxSpecEnv carries the sandbox spec (JSON) to the init process.
+const sandboxSpecEnv = "GOKIN_SANDBOX_SPEC"
+
+// IsSandboxInit reports whether the current process was started as the sandbox
+// init helper. main() must check this before doing anything else and call
+// RunSandboxInit, which never returns.
+func IsSandboxInit() bool {
+	return len(os.Args) > 1 && os.Args[1] == sandboxInitArg
+}
+
 // SandboxResult represents the result of a sandboxed command execution
 type SandboxResult struct {
 	ExitCode int
@@ -43,13 +99,15 @@ type SandboxResult struct {
 
 // SandboxedCommand represents a command that will be executed in a sandbox
 type SandboxedCommand struct {
-	cmd    *exec.Cmd
-	config SandboxConfig
+	cmd     *exec.Cmd
+	command string
+	config  SandboxConfig
 }
 
 // NewSandboxedCommand creates a new sandboxed command
-// Note: Full chroot and seccomp require Linux and specific permissions
-// This implementation provides basic isolation with safety checks
+// On Linux the command runs in user/mount (and optionally network) namespaces
+// with seccomp and Landlock applied where the kernel supports them; other
+// platforms get basic process isolation only
 func NewSandboxedCommand(ctx context.Context, workDir string, command string, config SandboxConfig) (*SandboxedCommand, error) {
 	// Validate workDir before doing anything
 	if workDir == "" {
@@ -75,8 +133,9 @@ func NewSandboxedCommand(ctx context.Context, workDir string, command string, co
 	cmd.Env = safeEnvironment(absWorkDir)
 
 	sandboxed := &SandboxedCommand{
-		cmd:    cmd,
-		config: config,
+		cmd:     cmd,
+		command: command,
+		config:  config,
 	}
 
 	// Apply sandboxing if enabled
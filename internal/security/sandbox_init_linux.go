@@ -0,0 +1,329 @@
+//go:build linux
+
+package security
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// sandboxInitExitCode is returned when the sandbox could not be set up.
+const sandboxInitExitCode = 125
+
+// RunSandboxInit sets up the isolation described by the spec passed in the
+// environment and replaces the process with bash. It never returns.
+func RunSandboxInit() {
+	// Landlock and seccomp apply to the calling thread; keep everything,
+	// including the final exec, on one thread.
+	runtime.LockOSThread()
+
+	raw := os.Getenv(sandboxSpecEnv)
+	os.Unsetenv(sandboxSpecEnv)
+
+	var spec sandboxSpec
+	if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+		sandboxInitFail(fmt.Errorf("invalid sandbox spec: %w", err))
+	}
+
+	report := sandboxProbeReport{}
+
+	// 1. Filesystem isolation in the mount namespace
+	if spec.Mounts {
+		if err := setupSandboxMounts(spec); err != nil {
+			if !spec.Probe {
+				sandboxInitFail(err)
+			}
+			report.Mounts = err.Error()
+		}
+		if err := unix.Sethostname([]byte("gokin-sandbox")); err != nil && !spec.Probe {
+			sandboxInitFail(fmt.Errorf("failed to set hostname: %w", err))
+		}
+	}
+
+	// 2. Loopback in the empty network namespace (best effort)
+	if spec.Network {
+		if err := bringUpLoopback(); err != nil {
+			report.Loopback = err.Error()
+		}
+	}
+
+	// 3. Required by both Landlock and unprivileged seccomp
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		sandboxInitFail(fmt.Errorf("failed to set no_new_privs: %w", err))
+	}
+
+	// 4. Landlock write restrictions
+	if spec.Landlock {
+		abi, err := applyLandlock(spec.WritableDirs)
+		if err != nil {
+			if !spec.Probe {
+				sandboxInitFail(err)
+			}
+			report.Landlock = err.Error()
+		}
+		report.LandlockABI = abi
+	}
+
+	// 5. Seccomp last: it denies mount and friends
+	if spec.Seccomp {
+		if err := applySeccomp(); err != nil {
+			if !spec.Probe {
+				sandboxInitFail(err)
+			}
+			report.Seccomp = err.Error()
+		}
+	}
+
+	if spec.Probe {
+		data, _ := json.Marshal(report)
+		os.Stdout.Write(data)
+		os.Exit(0)
+	}
+
+	// 6. Drop the capabilities kept for setup; exec as a non-root uid clears the rest
+	unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0)
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		sandboxInitFail(fmt.Errorf("bash not found: %w", err))
+	}
+	if err := unix.Exec(bash, []string{"bash", "-c", spec.Command}, os.Environ()); err != nil {
+		sandboxInitFail(fmt.Errorf("failed to exec bash: %w", err))
+	}
+}
+
+// sandboxInitFail reports a setup error and exits without running the command.
+func sandboxInitFail(err error) {
+	fmt.Fprintf(os.Stderr, "gokin sandbox: %v\n", err)
+	os.Exit(sandboxInitExitCode)
+}
+
+// setupSandboxMounts makes the filesystem read-only except for the writable
+// directories and a private /tmp, and chroots into RootDir if set.
+func setupSandboxMounts(spec sandboxSpec) error {
+	// Keep our mount changes from propagating back to the host
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %w", err)
+	}
+
+	// Hold the writable dirs open: a private /tmp may hide those below it
+	fds := make([]int, 0, len(spec.WritableDirs))
+	defer func() {
+		for _, fd := range fds {
+			unix.Close(fd)
+		}
+	}()
+	for _, dir := range spec.WritableDirs {
+		fd, err := unix.Open(dir, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+		if err != nil {
+			return fmt.Errorf("failed to open writable dir %s: %w", dir, err)
+		}
+		fds = append(fds, fd)
+	}
+
+	if err := unix.Mount("tmpfs", "/tmp", "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=1777"); err != nil {
+		return fmt.Errorf("failed to mount private /tmp: %w", err)
+	}
+
+	for i, dir := range spec.WritableDirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to prepare writable dir %s: %w", dir, err)
+		}
+		src := fmt.Sprintf("/proc/self/fd/%d", fds[i])
+		if err := unix.Mount(src, dir, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+			return fmt.Errorf("failed to bind writable dir %s: %w", dir, err)
+		}
+	}
+
+	skip := append([]string{"/tmp", "/proc", "/dev"}, spec.WritableDirs...)
+	if err := remountReadOnly(skip); err != nil {
+		return err
+	}
+
+	if spec.RootDir != "" {
+		if err := unix.Chroot(spec.RootDir); err != nil {
+			return fmt.Errorf("failed to chroot to %s: %w", spec.RootDir, err)
+		}
+		dir := "/"
+		if rel, err := filepath.Rel(spec.RootDir, spec.WorkDir); err == nil && !strings.HasPrefix(rel, "..") {
+			dir = filepath.Join("/", rel)
+		}
+		if err := unix.Chdir(dir); err != nil {
+			return fmt.Errorf("failed to chdir to %s: %w", dir, err)
+		}
+	}
+
+	return nil
+}
+
+// lockedMountFlags must be preserved on remount inside a user namespace.
+const lockedMountFlags = unix.MS_NOSUID | unix.MS_NODEV | unix.MS_NOEXEC |
+	unix.MS_NOATIME | unix.MS_NODIRATIME | unix.MS_RELATIME
+
+// remountReadOnly bind-remounts every mount read-only except those at or below skip.
+func remountReadOnly(skip []string) error {
+	mountPoints, err := readMountPoints()
+	if err != nil {
+		return err
+	}
+
+	for _, mp := range mountPoints {
+		if isUnderAny(mp, skip) {
+			continue
+		}
+		var st unix.Statfs_t
+		if err := unix.Statfs(mp, &st); err != nil {
+			if mp == "/" {
+				return fmt.Errorf("failed to stat /: %w", err)
+			}
+			continue
+		}
+		flags := uintptr(unix.MS_REMOUNT|unix.MS_BIND|unix.MS_RDONLY) | uintptr(st.Flags)&lockedMountFlags
+		if err := unix.Mount("", mp, "", flags, ""); err != nil && mp == "/" {
+			return fmt.Errorf("failed to remount / read-only: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// readMountPoints returns mount points from /proc/self/mountinfo in mount order.
+func readMountPoints() ([]string, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mountinfo: %w", err)
+	}
+	defer f.Close()
+
+	var points []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		points = append(points, unescapeMountPath(fields[4]))
+	}
+	return points, scanner.Err()
+}
+
+// unescapeMountPath decodes the octal escapes used in mountinfo (\040 etc.).
+func unescapeMountPath(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			var v byte
+			if _, err := fmt.Sscanf(s[i+1:i+4], "%03o", &v); err == nil {
+				b.WriteByte(v)
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// isUnderAny reports whether path equals or is below one of the dirs.
+func isUnderAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// bringUpLoopback enables the lo interface in a fresh network namespace.
+func bringUpLoopback() error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	ifr, err := unix.NewIfreq("lo")
+	if err != nil {
+		return err
+	}
+	ifr.SetUint16(unix.IFF_UP | unix.IFF_LOOPBACK | unix.IFF_RUNNING)
+	return unix.IoctlIfreq(fd, unix.SIOCSIFFLAGS, ifr)
+}
+
+// landlockWriteAccess returns the write-related rights known to the given ABI.
+func landlockWriteAccess(abi int) uint64 {
+	access := uint64(unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+		unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM)
+	if abi >= 2 {
+		access |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		access |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	return access
+}
+
+// applyLandlock restricts filesystem writes to the writable dirs and /tmp.
+// Reads stay unrestricted. Returns the kernel's Landlock ABI version.
+func applyLandlock(writableDirs []string) (int, error) {
+	v, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return 0, fmt.Errorf("landlock not supported by kernel: %w", errno)
+	}
+	abi := int(v)
+	handled := landlockWriteAccess(abi)
+
+	attr := unix.LandlockRulesetAttr{Access_fs: handled}
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return abi, fmt.Errorf("failed to create landlock ruleset: %w", errno)
+	}
+	defer unix.Close(int(fd))
+
+	// /dev stays writable for /dev/null, /dev/tty and friends
+	devAccess := handled & (unix.LANDLOCK_ACCESS_FS_WRITE_FILE | unix.LANDLOCK_ACCESS_FS_TRUNCATE)
+	rules := map[string]uint64{"/tmp": handled, "/dev": devAccess}
+	for _, dir := range writableDirs {
+		rules[dir] = handled
+	}
+
+	for path, access := range rules {
+		pathFd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+		if err != nil {
+			continue // Missing paths simply stay read-only
+		}
+		rule := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(pathFd)}
+		_, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, fd, unix.LANDLOCK_RULE_PATH_BENEATH,
+			uintptr(unsafe.Pointer(&rule)), 0, 0, 0)
+		unix.Close(pathFd)
+		if errno != 0 {
+			return abi, fmt.Errorf("failed to add landlock rule for %s: %w", path, errno)
+		}
+	}
+
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, fd, 0, 0); errno != 0 {
+		return abi, fmt.Errorf("failed to enforce landlock ruleset: %w", errno)
+	}
+
+	return abi, nil
+}
@@ -3,18 +3,324 @@
 package security
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// sandboxSpec is passed from the parent to the sandbox init process.
+type sandboxSpec struct {
+	Command      string   `json:"command"`
+	WorkDir      string   `json:"work_dir"`
+	RootDir      string   `json:"root_dir,omitempty"`
+	WritableDirs []string `json:"writable_dirs,omitempty"`
+	Mounts       bool     `json:"mounts"`   // Remount everything except WritableDirs read-only
+	Network      bool     `json:"network"`  // Running in a fresh network namespace
+	Landlock     bool     `json:"landlock"` // Restrict writes to WritableDirs with Landlock
+	Seccomp      bool     `json:"seccomp"`  // Install the seccomp-bpf deny filter
+	Probe        bool     `json:"probe"`    // Report which layers work instead of running Command
+}
+
+// sandboxProbeReport is printed by the init process in probe mode.
+// Empty error strings mean the layer was applied successfully.
+type sandboxProbeReport struct {
+	Mounts      string `json:"mounts"`
+	Loopback    string `json:"loopback"`
+	Landlock    string `json:"landlock"`
+	LandlockABI int    `json:"landlock_abi"`
+	Seccomp     string `json:"seccomp"`
+}
+
+// sandboxSupport is the cached result of probing the kernel.
+type sandboxSupport struct {
+	namespaces   bool   // user/mount namespaces could be created
+	userNS       bool   // an unprivileged user namespace is used
+	namespaceErr string // why namespaces are unavailable
+	network      bool   // an empty network namespace could be created
+	networkErr   string
+	report       sandboxProbeReport
+	probeErr     string // the init helper could not be run at all
+}
+
+var (
+	sandboxProbeMu    sync.Mutex
+	sandboxProbeCache = make(map[bool]*sandboxSupport) // keyed by DisableNetwork
 )
 
-// applySandbox applies sandbox restrictions to the command (Linux-specific)
+// sandboxProbeTimeout bounds how long probing may take.
+const sandboxProbeTimeout = 5 * time.Second
+
+// applySandbox applies sandbox restrictions to the command (Linux-specific).
+// The command is re-executed through the gokin binary, which enters the
+// namespaces, remounts the filesystem, applies Landlock and seccomp and then
+// execs bash with the original command.
 func (sc *SandboxedCommand) applySandbox(workDir string) error {
-	// Set process attributes for basic isolation
-	sc.cmd.SysProcAttr = &syscall.SysProcAttr{
+	support := probeSandboxSupport(sc.config.DisableNetwork)
+	if support.probeErr != "" {
+		return fmt.Errorf("sandbox unavailable: %s", support.probeErr)
+	}
+	if sc.config.DisableNetwork && !support.network {
+		return fmt.Errorf("network isolation requested but unavailable: %s", support.networkErr)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	spec := sandboxSpec{
+		Command:  sc.command,
+		WorkDir:  workDir,
+		RootDir:  sc.config.RootDir,
+		Mounts:   support.namespaces && support.report.Mounts == "",
+		Network:  sc.config.DisableNetwork,
+		Landlock: sc.config.EnableLandlock && support.report.Landlock == "",
+		Seccomp:  sc.config.EnableSeccomp && support.report.Seccomp == "",
+	}
+	if !sc.config.ReadOnly {
+		spec.WritableDirs = resolveWritableDirs(workDir, sc.config.WritableDirs)
+	}
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("failed to encode sandbox spec: %w", err)
+	}
+
+	sc.cmd.Path = exe
+	sc.cmd.Args = []string{exe, sandboxInitArg}
+	sc.cmd.Env = append(sc.cmd.Env, sandboxSpecEnv+"="+string(data))
+	sc.cmd.SysProcAttr = sandboxSysProcAttr(support.namespaces, sc.config.DisableNetwork)
+
+	return nil
+}
+
+// sandboxSysProcAttr builds the clone attributes for the init process.
+func sandboxSysProcAttr(namespaces, network bool) *syscall.SysProcAttr {
+	attr := &syscall.SysProcAttr{
 		// Create new process group
 		Setpgid: true,
-		// Set clone flags for basic isolation
-		Cloneflags: syscall.CLONE_NEWNS | syscall.CLONE_NEWUTS,
+	}
+	if !namespaces {
+		return attr
 	}
 
-	return nil
+	attr.Cloneflags = syscall.CLONE_NEWNS | syscall.CLONE_NEWUTS | syscall.CLONE_NEWIPC
+	if network {
+		attr.Cloneflags |= syscall.CLONE_NEWNET
+	}
+
+	// Unprivileged: map our own uid/gid into a new user namespace and keep the
+	// capabilities needed for mount setup across exec of the init process.
+	// They are dropped again before bash is executed.
+	if os.Geteuid() != 0 {
+		uid, gid := os.Getuid(), os.Getgid()
+		attr.Cloneflags |= syscall.CLONE_NEWUSER
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}}
+		attr.GidMappingsEnableSetgroups = false
+		attr.AmbientCaps = []uintptr{unix.CAP_SYS_ADMIN, unix.CAP_SYS_CHROOT}
+	}
+
+	return attr
+}
+
+// resolveWritableDirs returns the absolute, symlink-free writable directories.
+func resolveWritableDirs(workDir string, extra []string) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, dir := range append([]string{workDir}, extra...) {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+			abs = resolved
+		}
+		if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+			continue
+		}
+		if !seen[abs] {
+			seen[abs] = true
+			dirs = append(dirs, abs)
+		}
+	}
+	return dirs
+}
+
+// probeSandboxSupport starts the init helper in probe mode to find out which
+// layers the kernel actually allows. Results are cached for the process lifetime.
+func probeSandboxSupport(disableNetwork bool) *sandboxSupport {
+	sandboxProbeMu.Lock()
+	defer sandboxProbeMu.Unlock()
+
+	if cached, ok := sandboxProbeCache[disableNetwork]; ok {
+		return cached
+	}
+
+	support := &sandboxSupport{userNS: os.Geteuid() != 0}
+
+	// 1. Try the full namespace setup
+	report, err := runSandboxProbe(true, disableNetwork)
+	if err == nil {
+		support.namespaces = true
+		support.network = disableNetwork
+	} else if disableNetwork {
+		// 2. Network namespaces may be restricted separately
+		support.networkErr = err.Error()
+		report, err = runSandboxProbe(true, false)
+		if err == nil {
+			support.namespaces = true
+		}
+	}
+
+	// 3. Fall back to Landlock and seccomp without namespaces
+	if err != nil {
+		support.namespaceErr = err.Error()
+		if support.networkErr == "" && disableNetwork {
+			support.networkErr = err.Error()
+		}
+		report, err = runSandboxProbe(false, false)
+		if err != nil {
+			support.probeErr = err.Error()
+		}
+	}
+	if report != nil {
+		support.report = *report
+	}
+
+	sandboxProbeCache[disableNetwork] = support
+	return support
+}
+
+// runSandboxProbe runs the init helper once in probe mode.
+func runSandboxProbe(namespaces, network bool) (*sandboxProbeReport, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	spec := sandboxSpec{
+		WorkDir:  os.TempDir(),
+		Mounts:   namespaces,
+		Network:  network,
+		Landlock: true,
+		Seccomp:  true,
+		Probe:    true,
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sandboxProbeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, exe, sandboxInitArg)
+	cmd.Dir = "/"
+	cmd.Env = []string{"PATH=/usr/local/bin:/usr/bin:/bin", sandboxSpecEnv + "=" + string(data)}
+	cmd.SysProcAttr = sandboxSysProcAttr(namespaces, network)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	var report sandboxProbeReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		return nil, fmt.Errorf("invalid probe output: %w", err)
+	}
+	return &report, nil
+}
+
+// sandboxLayers reports the isolation layers in effect for the given config.
+func sandboxLayers(config SandboxConfig) []SandboxLayer {
+	if !config.Enabled {
+		return []SandboxLayer{{Name: "sandbox", Active: false, Detail: "disabled"}}
+	}
+
+	support := probeSandboxSupport(config.DisableNetwork)
+	if support.probeErr != "" {
+		return []SandboxLayer{{Name: "sandbox", Active: false, Detail: support.probeErr}}
+	}
+
+	layers := make([]SandboxLayer, 0, 5)
+
+	// User namespace
+	switch {
+	case !support.namespaces:
+		layers = append(layers, SandboxLayer{Name: "user namespace", Detail: support.namespaceErr})
+	case support.userNS:
+		layers = append(layers, SandboxLayer{Name: "user namespace", Active: true, Detail: "unprivileged, own uid/gid mapped"})
+	default:
+		layers = append(layers, SandboxLayer{Name: "user namespace", Detail: "not needed (running as root)"})
+	}
+
+	// Mount namespace
+	mounts := SandboxLayer{Name: "mount namespace"}
+	switch {
+	case !support.namespaces:
+		mounts.Detail = "namespaces unavailable"
+	case support.report.Mounts != "":
+		mounts.Detail = support.report.Mounts
+	case config.ReadOnly:
+		mounts.Active, mounts.Detail = true, "entire filesystem read-only, private /tmp"
+	default:
+		mounts.Active, mounts.Detail = true, "read-only except workdir and allowed dirs, private /tmp"
+	}
+	layers = append(layers, mounts)
+
+	// Network namespace
+	network := SandboxLayer{Name: "network namespace"}
+	switch {
+	case !config.DisableNetwork:
+		network.Detail = "network allowed (tools.bash.sandbox_disable_network: false)"
+	case support.network:
+		network.Active, network.Detail = true, "empty, loopback only"
+	default:
+		network.Detail = support.networkErr
+	}
+	layers = append(layers, network)
+
+	// Seccomp
+	seccomp := SandboxLayer{Name: "seccomp"}
+	switch {
+	case !config.EnableSeccomp:
+		seccomp.Detail = "disabled"
+	case support.report.Seccomp != "":
+		seccomp.Detail = support.report.Seccomp
+	default:
+		seccomp.Active, seccomp.Detail = true, "mount, ptrace, bpf, kexec, module and namespace syscalls denied"
+	}
+	layers = append(layers, seccomp)
+
+	// Landlock
+	landlock := SandboxLayer{Name: "landlock"}
+	switch {
+	case !config.EnableLandlock:
+		landlock.Detail = "disabled"
+	case support.report.Landlock != "":
+		landlock.Detail = support.report.Landlock
+	default:
+		landlock.Active = true
+		landlock.Detail = fmt.Sprintf("ABI v%d, writes limited to workdir, allowed dirs and /tmp", support.report.LandlockABI)
+	}
+	layers = append(layers, landlock)
+
+	return layers
 }
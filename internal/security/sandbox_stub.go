@@ -3,6 +3,8 @@
 package security
 
 import (
+	"fmt"
+	"os"
 	"syscall"
 )
 
@@ -15,3 +17,20 @@ func (sc *SandboxedCommand) applySandbox(workDir string) error {
 
 	return nil
 }
+
+// sandboxLayers reports the isolation layers available on this platform.
+func sandboxLayers(config SandboxConfig) []SandboxLayer {
+	if !config.Enabled {
+		return []SandboxLayer{{Name: "sandbox", Active: false, Detail: "disabled"}}
+	}
+	return []SandboxLayer{
+		{Name: "process group", Active: true, Detail: "command runs in its own process group"},
+		{Name: "namespaces, seccomp, landlock", Active: false, Detail: "Linux only"},
+	}
+}
+
+// RunSandboxInit is only used on Linux.
+func RunSandboxInit() {
+	fmt.Fprintln(os.Stderr, "gokin sandbox: not supported on this platform")
+	os.Exit(125)
+}
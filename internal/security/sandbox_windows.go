@@ -2,9 +2,30 @@
 
 package security
 
+import (
+	"fmt"
+	"os"
+)
+
 // applySandbox applies basic process isolation for Windows
 func (sc *SandboxedCommand) applySandbox(workDir string) error {
 	// Windows doesn't support Unix process groups
 	// Basic isolation is handled by the OS
 	return nil
 }
+
+// sandboxLayers reports the isolation layers available on this platform.
+func sandboxLayers(config SandboxConfig) []SandboxLayer {
+	if !config.Enabled {
+		return []SandboxLayer{{Name: "sandbox", Active: false, Detail: "disabled"}}
+	}
+	return []SandboxLayer{
+		{Name: "namespaces, seccomp, landlock", Active: false, Detail: "Linux only"},
+	}
+}
+
+// RunSandboxInit is only used on Linux.
+func RunSandboxInit() {
+	fmt.Fprintln(os.Stderr, "gokin sandbox: not supported on this platform")
+	os.Exit(125)
+}
@@ -0,0 +1,123 @@
+//go:build linux && (amd64 || arm64)
+
+package security
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// seccompDeniedSyscalls are syscalls a sandboxed command has no business making:
+// changing mounts or namespaces, loading kernel code, tracing other processes
+// and changing system-wide state. They fail with EPERM.
+var seccompDeniedSyscalls = []uint32{
+	unix.SYS_MOUNT,
+	unix.SYS_UMOUNT2,
+	unix.SYS_PIVOT_ROOT,
+	unix.SYS_FSOPEN,
+	unix.SYS_FSCONFIG,
+	unix.SYS_FSMOUNT,
+	unix.SYS_FSPICK,
+	unix.SYS_MOVE_MOUNT,
+	unix.SYS_OPEN_TREE,
+	unix.SYS_MOUNT_SETATTR,
+	unix.SYS_SETNS,
+	unix.SYS_UNSHARE,
+	unix.SYS_PTRACE,
+	unix.SYS_KEXEC_LOAD,
+	unix.SYS_KEXEC_FILE_LOAD,
+	unix.SYS_INIT_MODULE,
+	unix.SYS_FINIT_MODULE,
+	unix.SYS_DELETE_MODULE,
+	unix.SYS_BPF,
+	unix.SYS_PERF_EVENT_OPEN,
+	unix.SYS_USERFAULTFD,
+	unix.SYS_OPEN_BY_HANDLE_AT,
+	unix.SYS_KEYCTL,
+	unix.SYS_ADD_KEY,
+	unix.SYS_REQUEST_KEY,
+	unix.SYS_REBOOT,
+	unix.SYS_SWAPON,
+	unix.SYS_SWAPOFF,
+	unix.SYS_ACCT,
+	unix.SYS_QUOTACTL,
+	unix.SYS_SETTIMEOFDAY,
+	unix.SYS_CLOCK_SETTIME,
+	unix.SYS_CLOCK_ADJTIME,
+}
+
+// seccompCloneNamespaceFlags are the clone flags that create namespaces.
+// clone with any of them fails with EPERM, like unshare. clone3 passes its
+// flags in memory the filter cannot read, so it fails with ENOSYS and libc
+// falls back to clone.
+const seccompCloneNamespaceFlags = unix.CLONE_NEWNS | unix.CLONE_NEWCGROUP | unix.CLONE_NEWUTS |
+	unix.CLONE_NEWIPC | unix.CLONE_NEWUSER | unix.CLONE_NEWPID | unix.CLONE_NEWNET
+
+// Offsets into struct seccomp_data.
+const (
+	seccompDataNrOffset   = 0
+	seccompDataArchOffset = 4
+	seccompDataArg0Offset = 16 // Low 32 bits of the first argument (little-endian)
+)
+
+// buildSeccompFilter assembles the BPF deny-list program.
+func buildSeccompFilter() []unix.SockFilter {
+	stmt := func(code uint16, k uint32) unix.SockFilter {
+		return unix.SockFilter{Code: code, K: k}
+	}
+	jump := func(code uint16, k uint32, jt, jf uint8) unix.SockFilter {
+		return unix.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+	}
+	deny := stmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_ERRNO|uint32(unix.EPERM)&unix.SECCOMP_RET_DATA)
+
+	denied := append(append([]uint32{}, seccompDeniedSyscalls...), seccompArchDeniedSyscalls...)
+
+	filter := []unix.SockFilter{
+		// Kill anything using a foreign syscall ABI
+		stmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataArchOffset),
+		jump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, seccompAuditArch, 1, 0),
+		stmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_KILL_PROCESS),
+		stmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataNrOffset),
+	}
+	if seccompSyscallLimit > 0 {
+		// Reject alternate syscall tables (x32 on amd64)
+		filter = append(filter,
+			jump(unix.BPF_JMP|unix.BPF_JGE|unix.BPF_K, seccompSyscallLimit, 0, 1),
+			deny)
+	}
+	filter = append(filter,
+		// clone3 -> ENOSYS
+		jump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, unix.SYS_CLONE3, 0, 1),
+		stmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_ERRNO|uint32(unix.ENOSYS)&unix.SECCOMP_RET_DATA),
+		// clone with namespace flags -> EPERM
+		jump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, unix.SYS_CLONE, 0, 4),
+		stmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataArg0Offset),
+		jump(unix.BPF_JMP|unix.BPF_JSET|unix.BPF_K, seccompCloneNamespaceFlags, 0, 1),
+		deny,
+		stmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataNrOffset),
+	)
+	for _, nr := range denied {
+		filter = append(filter,
+			jump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, nr, 0, 1),
+			deny)
+	}
+	filter = append(filter, stmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_ALLOW))
+
+	return filter
+}
+
+// applySeccomp installs the filter for the calling thread and its future children.
+// no_new_privs must already be set.
+func applySeccomp() error {
+	filter := buildSeccompFilter()
+	prog := unix.SockFprog{
+		Len:    uint16(len(filter)),
+		Filter: &filter[0],
+	}
+	if err := unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&prog)), 0, 0); err != nil {
+		return fmt.Errorf("failed to install seccomp filter: %w", err)
+	}
+	return nil
+}
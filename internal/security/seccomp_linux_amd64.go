@@ -0,0 +1,15 @@
+//go:build linux
+
+package security
+
+import "golang.org/x/sys/unix"
+
+const seccompAuditArch = unix.AUDIT_ARCH_X86_64
+
+// seccompSyscallLimit marks the start of the x32 syscall table.
+const seccompSyscallLimit = 0x40000000
+
+var seccompArchDeniedSyscalls = []uint32{
+	unix.SYS_IOPL,
+	unix.SYS_IOPERM,
+}
@@ -0,0 +1,12 @@
+//go:build linux
+
+package security
+
+import "golang.org/x/sys/unix"
+
+const seccompAuditArch = unix.AUDIT_ARCH_AARCH64
+
+// seccompSyscallLimit is unused on arm64 (single syscall table).
+const seccompSyscallLimit = 0
+
+var seccompArchDeniedSyscalls = []uint32{}
@@ -0,0 +1,10 @@
+//go:build linux && !amd64 && !arm64
+
+package security
+
+import "errors"
+
+// applySeccomp is not implemented for this architecture.
+func applySeccomp() error {
+	return errors.New("seccomp filter not available on this architecture")
+}
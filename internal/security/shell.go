@@ -0,0 +1,919 @@
+package security
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// maxShellDepth bounds the nesting of scripts analyzed inside scripts
+// (bash -c, eval, here-documents fed to a shell).
+const maxShellDepth = 4
+
+// ShellCommand is a simple command found in a shell command line, with the
+// quoting removed from its words.
+type ShellCommand struct {
+	Name        string          // Command name, e.g. rm or /usr/bin/git
+	Args        []string        // Arguments
+	Assigns     []string        // NAME=value assignments for the command
+	Redirects   []ShellRedirect // Redirections of the command
+	DynamicName bool            // The name is computed at run time
+	Dynamic     bool            // The name or an argument is computed at run time
+	Text        string          // Source text
+
+	dynamicArgs []bool
+}
+
+// String returns the command and its arguments separated by spaces.
+// Expansions that cannot be resolved statically appear as written.
+func (c ShellCommand) String() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// BaseName returns the command name without its directory.
+func (c ShellCommand) BaseName() string {
+	return filepath.Base(c.Name)
+}
+
+// text returns the command with its redirections, for pattern checks.
+func (c ShellCommand) text() string {
+	s := c.String()
+	for _, r := range c.Redirects {
+		s += " " + r.Op + " " + r.Target
+	}
+	return s
+}
+
+// ShellRedirect is a redirection of a simple command.
+type ShellRedirect struct {
+	Op      string // e.g. >, >>, <
+	Target  string
+	Dynamic bool
+}
+
+// ShellFinding is a dangerous construct found in a command line.
+type ShellFinding struct {
+	Blocked bool   // The command must not run; otherwise it needs a closer look
+	Reason  string // What the construct does
+	Command string // Source text of the sub-command concerned
+}
+
+// ShellAnalysis describes what a shell command line runs.
+type ShellAnalysis struct {
+	Commands []ShellCommand // Every simple command, including nested ones
+	Words    []string       // Every word outside here-documents, with quoting removed, including assignment values and redirection targets
+	Findings []ShellFinding
+}
+
+// Blocked returns the first finding that blocks the command line, or nil.
+func (a *ShellAnalysis) Blocked() *ShellFinding {
+	for i := range a.Findings {
+		if a.Findings[i].Blocked {
+			return &a.Findings[i]
+		}
+	}
+	return nil
+}
+
+// Cautions returns the findings that do not block the command line.
+func (a *ShellAnalysis) Cautions() []ShellFinding {
+	var cautions []ShellFinding
+	for _, f := range a.Findings {
+		if !f.Blocked {
+			cautions = append(cautions, f)
+		}
+	}
+	return cautions
+}
+
+// AnalyzeShell parses a bash command line and extracts every simple command
+// it runs, in pipelines, lists, subshells, functions, command and process
+// substitutions, and scripts passed to bash -c or eval. Commands run through
+// wrappers such as sudo, env, timeout or xargs are listed both with and
+// without the wrapper. Redirections that write outside workDir are flagged;
+// an empty workDir skips that check.
+func AnalyzeShell(command, workDir string) (*ShellAnalysis, error) {
+	result := &ShellAnalysis{}
+	if err := analyzeShell(command, workDir, 0, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func analyzeShell(src, workDir string, depth int, result *ShellAnalysis) error {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(src), "")
+	if err != nil {
+		return err
+	}
+	a := &shellAnalyzer{src: src, workDir: workDir, depth: depth, result: result, hdocs: make(map[*syntax.Word]bool)}
+	syntax.Walk(file, a.visit)
+	return nil
+}
+
+// shellAnalyzer walks the syntax tree of one script.
+type shellAnalyzer struct {
+	src     string
+	workDir string
+	depth   int
+	result  *ShellAnalysis
+	hdocs   map[*syntax.Word]bool // Here-document bodies, which are data rather than words
+}
+
+func (a *shellAnalyzer) visit(node syntax.Node) bool {
+	switch n := node.(type) {
+	case *syntax.Stmt:
+		if call, ok := n.Cmd.(*syntax.CallExpr); ok {
+			a.call(call, n.Redirs)
+		} else {
+			a.redirects(n.Redirs, a.source(n))
+		}
+	case *syntax.BinaryCmd:
+		if n.Op == syntax.Pipe || n.Op == syntax.PipeAll {
+			a.pipe(n)
+		}
+	case *syntax.DeclClause:
+		for _, as := range n.Args {
+			if as.Name != nil {
+				value := ""
+				if as.Value != nil {
+					value, _ = a.literal(as.Value)
+				}
+				a.checkAssign(as.Name.Value+"="+value, a.source(n))
+			}
+		}
+	case *syntax.Redirect:
+		if n.Hdoc != nil {
+			a.hdocs[n.Hdoc] = true
+		}
+	case *syntax.Word:
+		if !a.hdocs[n] {
+			value, _ := a.literal(n)
+			a.result.Words = append(a.result.Words, value)
+		}
+	case *syntax.FuncDecl:
+		a.funcDecl(n)
+	case *syntax.WhileClause:
+		a.loop(n)
+	}
+	return true
+}
+
+// block records a finding that blocks the command line.
+func (a *shellAnalyzer) block(command, format string, args ...any) {
+	a.result.Findings = append(a.result.Findings, ShellFinding{Blocked: true, Reason: fmt.Sprintf(format, args...), Command: command})
+}
+
+// caution records a finding that needs a closer look.
+func (a *shellAnalyzer) caution(command, format string, args ...any) {
+	a.result.Findings = append(a.result.Findings, ShellFinding{Reason: fmt.Sprintf(format, args...), Command: command})
+}
+
+// source returns the source text of a node.
+func (a *shellAnalyzer) source(node syntax.Node) string {
+	start, end := int(node.Pos().Offset()), int(node.End().Offset())
+	if start < 0 || end > len(a.src) || start > end {
+		return ""
+	}
+	return a.src[start:end]
+}
+
+// literal returns the value of a word with quoting removed, and whether it
+// depends on expansions. Parts that cannot be resolved are kept as written.
+func (a *shellAnalyzer) literal(word *syntax.Word) (string, bool) {
+	var sb strings.Builder
+	dynamic := false
+	for _, part := range word.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(unescapeShell(p.Value, false))
+		case *syntax.SglQuoted:
+			if p.Dollar {
+				// $'\x72\x6d' is rm
+				if value, err := expand.Literal(nil, &syntax.Word{Parts: []syntax.WordPart{p}}); err == nil {
+					sb.WriteString(value)
+					continue
+				}
+			}
+			sb.WriteString(p.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range p.Parts {
+				switch ip := inner.(type) {
+				case *syntax.Lit:
+					sb.WriteString(unescapeShell(ip.Value, true))
+				case *syntax.CmdSubst:
+					// "$(echo rm)" is rm
+					if output, ok := a.staticOutput(ip.Stmts); ok {
+						sb.WriteString(strings.TrimRight(output, "\n"))
+						continue
+					}
+					dynamic = true
+					sb.WriteString(a.source(ip))
+				default:
+					dynamic = true
+					sb.WriteString(a.source(inner))
+				}
+			}
+		case *syntax.CmdSubst:
+			// $(echo rm) is rm
+			if output, ok := a.staticOutput(p.Stmts); ok {
+				sb.WriteString(strings.TrimRight(output, "\n"))
+				continue
+			}
+			dynamic = true
+			sb.WriteString(a.source(p))
+		default:
+			dynamic = true
+			sb.WriteString(a.source(p))
+		}
+	}
+	return sb.String(), dynamic
+}
+
+// unescapeShell removes backslash escapes from an unquoted or double-quoted
+// literal.
+func unescapeShell(s string, quoted bool) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		next := s[i+1]
+		switch {
+		case next == '\n':
+			// Line continuation
+		case !quoted || strings.IndexByte("$`\"\\", next) >= 0:
+			sb.WriteByte(next)
+		default:
+			sb.WriteByte('\\')
+			sb.WriteByte(next)
+		}
+		i++
+	}
+	return sb.String()
+}
+
+// staticOutput returns the output of statements that only echo or printf
+// literal text, which obfuscated command lines use to build commands.
+func (a *shellAnalyzer) staticOutput(stmts []*syntax.Stmt) (string, bool) {
+	if len(stmts) != 1 || len(stmts[0].Redirs) > 0 {
+		return "", false
+	}
+	call, ok := stmts[0].Cmd.(*syntax.CallExpr)
+	if !ok || len(call.Args) == 0 || len(call.Assigns) > 0 {
+		return "", false
+	}
+	var words []string
+	for _, w := range call.Args {
+		value, dynamic := a.literal(w)
+		if dynamic {
+			return "", false
+		}
+		words = append(words, value)
+	}
+
+	switch words[0] {
+	case "echo":
+		args := words[1:]
+		newline := true
+		for len(args) > 0 && (args[0] == "-n" || args[0] == "-e" || args[0] == "-E") {
+			if args[0] == "-n" {
+				newline = false
+			}
+			args = args[1:]
+		}
+		output := strings.Join(args, " ")
+		if newline {
+			output += "\n"
+		}
+		return output, true
+	case "printf":
+		if len(words) < 2 {
+			return "", false
+		}
+		output, _, err := expand.Format(nil, words[1], words[2:])
+		return output, err == nil
+	}
+	return "", false
+}
+
+// call records a simple command and checks it.
+func (a *shellAnalyzer) call(call *syntax.CallExpr, redirs []*syntax.Redirect) {
+	text := a.source(call)
+	cmd := ShellCommand{Text: text}
+	for _, as := range call.Assigns {
+		if as.Name == nil {
+			continue
+		}
+		value := ""
+		if as.Value != nil {
+			value, _ = a.literal(as.Value)
+		}
+		cmd.Assigns = append(cmd.Assigns, as.Name.Value+"="+value)
+		a.checkAssign(as.Name.Value+"="+value, text)
+	}
+	for _, r := range redirs {
+		redirect := ShellRedirect{Op: r.Op.String()}
+		if r.Word != nil {
+			redirect.Target, redirect.Dynamic = a.literal(r.Word)
+		}
+		cmd.Redirects = append(cmd.Redirects, redirect)
+	}
+	a.redirects(redirs, text)
+	if len(call.Args) == 0 {
+		return
+	}
+
+	for i, w := range call.Args {
+		value, dynamic := a.literal(w)
+		if i == 0 {
+			cmd.Name = value
+			cmd.DynamicName = dynamic
+			if !dynamic && w.Lit() == "" && strings.ContainsAny(a.source(w), "$`") {
+				a.caution(text, "the command name %s is obfuscated", a.source(w))
+			}
+		} else {
+			cmd.Args = append(cmd.Args, value)
+			cmd.dynamicArgs = append(cmd.dynamicArgs, dynamic)
+		}
+		cmd.Dynamic = cmd.Dynamic || dynamic
+	}
+	a.add(cmd, redirs)
+}
+
+// add records a command, checks it, and adds the commands it runs.
+func (a *shellAnalyzer) add(cmd ShellCommand, redirs []*syntax.Redirect) {
+	a.result.Commands = append(a.result.Commands, cmd)
+	if cmd.DynamicName {
+		a.caution(cmd.Text, "the command name %s is computed at run time", cmd.Name)
+		return
+	}
+	a.check(cmd)
+
+	if inner, ok := unwrapCommand(cmd); ok {
+		a.add(inner, redirs)
+		return
+	}
+	switch name := cmd.BaseName(); {
+	case isShell(name):
+		a.shellScript(cmd, redirs)
+	case name == "eval":
+		if cmd.Dynamic {
+			a.block(cmd.Text, "eval runs text computed at run time as shell code")
+			return
+		}
+		a.nested(strings.Join(cmd.Args, " "), cmd.Text)
+	}
+}
+
+// nested analyzes a script run by a command.
+func (a *shellAnalyzer) nested(script, command string) {
+	if a.depth >= maxShellDepth {
+		a.block(command, "shell scripts are nested too deeply")
+		return
+	}
+	if err := analyzeShell(script, a.workDir, a.depth+1, a.result); err != nil {
+		a.caution(command, "runs a shell script that cannot be parsed: %v", err)
+	}
+}
+
+// shellScript analyzes the script a shell is asked to run with -c, in a
+// here-document or in a here-string.
+func (a *shellAnalyzer) shellScript(cmd ShellCommand, redirs []*syntax.Redirect) {
+	for i, arg := range cmd.Args {
+		if arg == "-c" || (strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.HasSuffix(arg, "c")) {
+			if i+1 == len(cmd.Args) {
+				return
+			}
+			if cmd.dynamicArgs[i+1] {
+				a.caution(cmd.Text, "%s -c runs a script computed at run time", cmd.BaseName())
+				return
+			}
+			a.nested(cmd.Args[i+1], cmd.Text)
+			return
+		}
+	}
+	if !readsStdin(cmd) {
+		return
+	}
+	for _, r := range redirs {
+		var doc *syntax.Word
+		switch {
+		case r.Hdoc != nil:
+			doc = r.Hdoc
+		case r.Op == syntax.WordHdoc:
+			// sh <<< 'rm -rf /'
+			doc = r.Word
+		case r.Op == syntax.RdrIn && r.Word != nil && isProcSubst(r.Word):
+			// sh < <(curl ...)
+			a.block(cmd.Text, "%s runs the output of a command as a script", cmd.BaseName())
+			continue
+		default:
+			continue
+		}
+		if script, dynamic := a.literal(doc); !dynamic {
+			a.nested(script, cmd.Text)
+		} else {
+			a.caution(cmd.Text, "feeds %s a here-document computed at run time", cmd.BaseName())
+		}
+	}
+}
+
+// isProcSubst reports whether a word is a process substitution such as
+// <(curl ...).
+func isProcSubst(word *syntax.Word) bool {
+	if len(word.Parts) != 1 {
+		return false
+	}
+	_, ok := word.Parts[0].(*syntax.ProcSubst)
+	return ok
+}
+
+// pipe checks what a pipeline feeds into an interpreter.
+func (a *shellAnalyzer) pipe(n *syntax.BinaryCmd) {
+	call, ok := n.Y.Cmd.(*syntax.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return
+	}
+	// Look through wrappers: curl ... | sudo bash
+	cmd := ShellCommand{Text: a.source(call)}
+	for i, w := range call.Args {
+		value, dynamic := a.literal(w)
+		if i == 0 {
+			cmd.Name = value
+		} else {
+			cmd.Args = append(cmd.Args, value)
+			cmd.dynamicArgs = append(cmd.dynamicArgs, dynamic)
+		}
+	}
+	for {
+		inner, ok := unwrapCommand(cmd)
+		if !ok {
+			break
+		}
+		cmd = inner
+	}
+	name := cmd.BaseName()
+	if !isInterpreter(name) || !readsStdin(cmd) {
+		return
+	}
+
+	// What produces the input
+	var sources []string
+	syntax.Walk(n.X, func(node syntax.Node) bool {
+		if c, ok := node.(*syntax.CallExpr); ok && len(c.Args) > 0 {
+			value, _ := a.literal(c.Args[0])
+			sources = append(sources, filepath.Base(value))
+		}
+		return true
+	})
+	for _, source := range sources {
+		switch source {
+		case "curl", "wget", "fetch", "aria2c", "nc", "ncat", "netcat":
+			a.block(a.source(n), "downloads a script with %s and pipes it into %s", source, name)
+			return
+		case "base64", "xxd", "openssl", "gpg", "rev", "tr":
+			a.block(a.source(n), "decodes data with %s and pipes it into %s", source, name)
+			return
+		}
+	}
+	if !isShell(name) {
+		return
+	}
+	// echo 'rm -rf /' | sh
+	if stmt := n.X; stmt != nil {
+		if output, ok := a.staticOutput([]*syntax.Stmt{stmt}); ok {
+			a.nested(output, a.source(n))
+			return
+		}
+	}
+	a.caution(a.source(n), "pipes commands into %s", name)
+}
+
+// funcDecl flags functions that spawn copies of themselves.
+func (a *shellAnalyzer) funcDecl(n *syntax.FuncDecl) {
+	if n.Name == nil {
+		return
+	}
+	name := n.Name.Value
+	forks := false
+	syntax.Walk(n.Body, func(node syntax.Node) bool {
+		switch s := node.(type) {
+		case *syntax.BinaryCmd:
+			if (s.Op == syntax.Pipe || s.Op == syntax.PipeAll) && (callsName(s.X, name) || callsName(s.Y, name)) {
+				forks = true
+			}
+		case *syntax.Stmt:
+			if s.Background && callsName(s, name) {
+				forks = true
+			}
+		}
+		return !forks
+	})
+	if forks {
+		a.block(a.source(n), "defines a fork bomb: %s starts copies of itself", name)
+	}
+}
+
+// callsName reports whether a statement calls the command name.
+func callsName(stmt *syntax.Stmt, name string) bool {
+	found := false
+	syntax.Walk(stmt, func(node syntax.Node) bool {
+		if call, ok := node.(*syntax.CallExpr); ok && len(call.Args) > 0 && call.Args[0].Lit() == name {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// loop flags endless loops that start background processes.
+func (a *shellAnalyzer) loop(n *syntax.WhileClause) {
+	if n.Until || len(n.Cond) != 1 {
+		return
+	}
+	call, ok := n.Cond[0].Cmd.(*syntax.CallExpr)
+	if !ok || len(call.Args) != 1 || (call.Args[0].Lit() != "true" && call.Args[0].Lit() != ":") {
+		return
+	}
+	for _, stmt := range n.Do {
+		if stmt.Background {
+			a.block(a.source(n), "starts background processes in an endless loop")
+			return
+		}
+	}
+}
+
+// unwrapCommand returns the command run by a wrapper such as sudo, env,
+// timeout or xargs.
+func unwrapCommand(cmd ShellCommand) (ShellCommand, bool) {
+	name := cmd.BaseName()
+	valueFlags, ok := wrapperValueFlags[name]
+	if !ok {
+		return ShellCommand{}, false
+	}
+
+	var assigns []string
+	positional := 0 // Leading operands that are not the command, e.g. timeout's duration
+	if name == "timeout" {
+		positional = 1
+	}
+	for i := 0; i < len(cmd.Args); i++ {
+		arg := cmd.Args[i]
+		switch {
+		case arg == "--":
+			continue
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			if strings.Contains(valueFlags, " "+arg+" ") {
+				i++
+			}
+			continue
+		case name == "env" && strings.Contains(arg, "=") && !cmd.dynamicArgs[i]:
+			assigns = append(assigns, arg)
+			continue
+		case positional > 0:
+			positional--
+			continue
+		}
+		return ShellCommand{
+			Name:        arg,
+			Args:        cmd.Args[i+1:],
+			Assigns:     append(append([]string(nil), cmd.Assigns...), assigns...),
+			Redirects:   cmd.Redirects,
+			DynamicName: cmd.dynamicArgs[i],
+			// xargs appends arguments read at run time
+			Dynamic:     cmd.Dynamic || name == "xargs",
+			Text:        cmd.Text,
+			dynamicArgs: cmd.dynamicArgs[i+1:],
+		}, true
+	}
+	return ShellCommand{}, false
+}
+
+// wrapperValueFlags lists, for commands that run another command, their
+// options that take a separate value.
+var wrapperValueFlags = map[string]string{
+	"sudo":    " -u -g -h -p -C -D -R -T -U ",
+	"doas":    " -u -C ",
+	"env":     " -u -C -S ",
+	"nice":    " -n ",
+	"ionice":  " -c -n -p ",
+	"timeout": " -s -k ",
+	"xargs":   " -n -I -L -P -d -E -s -a ",
+	"nohup":   " ",
+	"time":    " -f -o ",
+	"command": " ",
+	"builtin": " ",
+	"exec":    " -a ",
+	"stdbuf":  " -i -o -e ",
+	"chroot":  " ",
+}
+
+// isShell reports whether name is a shell.
+func isShell(name string) bool {
+	switch name {
+	case "sh", "bash", "zsh", "dash", "ksh", "ash", "fish", "mksh":
+		return true
+	}
+	return false
+}
+
+// isInterpreter reports whether name runs code it reads.
+func isInterpreter(name string) bool {
+	if isShell(name) {
+		return true
+	}
+	switch strings.TrimRight(name, "0123456789.") {
+	case "python", "perl", "ruby", "node", "php", "lua":
+		return true
+	}
+	return false
+}
+
+// readsStdin reports whether an interpreter reads its program from stdin.
+func readsStdin(cmd ShellCommand) bool {
+	for _, arg := range cmd.Args {
+		switch {
+		case arg == "-" || arg == "-s":
+			return true
+		case arg == "-c" || arg == "-e":
+			return false
+		case !strings.HasPrefix(arg, "-"):
+			return false
+		}
+	}
+	return true
+}
+
+// check flags dangerous uses of a command.
+func (a *shellAnalyzer) check(cmd ShellCommand) {
+	name := cmd.BaseName()
+	flags, operands := splitFlags(cmd.Args)
+
+	for _, assign := range cmd.Assigns {
+		a.checkAssign(assign, cmd.Text)
+	}
+	for _, arg := range cmd.Args {
+		if strings.Contains(arg, "/dev/tcp/") || strings.Contains(arg, "/dev/udp/") {
+			a.block(cmd.Text, "opens a network connection through %s", arg)
+		}
+	}
+
+	switch {
+	case name == "rm":
+		if !hasFlag(flags, "r", "R", "--recursive") {
+			return
+		}
+		for i, target := range cmd.Args {
+			if reason := dangerousRemoval(target, cmd.dynamicArgs[i]); reason != "" {
+				a.block(cmd.Text, "rm -r of %s", reason)
+				return
+			}
+		}
+	case name == "mkfs" || strings.HasPrefix(name, "mkfs.") || name == "mke2fs" || name == "wipefs":
+		a.block(cmd.Text, "%s formats or wipes a filesystem", name)
+	case name == "dd":
+		for _, arg := range cmd.Args {
+			if target, ok := strings.CutPrefix(arg, "of="); ok && isBlockDevice(target) {
+				a.block(cmd.Text, "dd overwrites the block device %s", target)
+			}
+		}
+	case name == "chmod" || name == "chown" || name == "chgrp":
+		for _, target := range operands {
+			if filepath.Clean(target) == "/" {
+				a.block(cmd.Text, "%s changes the ownership or permissions of /", name)
+				return
+			}
+		}
+	case name == "nc" || name == "ncat" || name == "netcat":
+		for _, f := range flags {
+			if f == "-e" || f == "-c" || f == "--exec" || f == "--sh-exec" ||
+				(!strings.HasPrefix(f, "--") && strings.ContainsAny(f[1:], "ec")) {
+				a.block(cmd.Text, "%s %s runs a program for a network connection (reverse shell)", name, f)
+				return
+			}
+		}
+	case name == "insmod" || name == "rmmod" || name == "modprobe":
+		a.block(cmd.Text, "%s loads or unloads kernel modules", name)
+	case name == "grub-install" || name == "update-grub" || name == "grub-mkconfig":
+		a.block(cmd.Text, "%s modifies the boot loader", name)
+	case name == "history":
+		if hasFlag(flags, "c") {
+			a.block(cmd.Text, "history -c clears the shell history")
+		}
+	case name == "unset":
+		for _, v := range operands {
+			if v == "HISTFILE" {
+				a.block(cmd.Text, "unset HISTFILE disables the shell history")
+			}
+		}
+	case name == "source" || name == ".":
+		if len(operands) > 0 && strings.HasPrefix(operands[0], "/dev/") {
+			a.block(cmd.Text, "%s runs the script in %s", name, operands[0])
+		} else if len(operands) > 0 && strings.HasPrefix(operands[0], "<(") {
+			a.block(cmd.Text, "%s runs the output of a command as a script", name)
+		}
+	case isInterpreter(name):
+		// bash <(curl ...) runs the output of a command like source <(...)
+		for i, arg := range cmd.Args {
+			if strings.HasPrefix(arg, "-") && arg != "-" {
+				continue
+			}
+			if strings.HasPrefix(arg, "<(") && cmd.dynamicArgs[i] {
+				a.block(cmd.Text, "%s runs the output of a command as a script", name)
+			}
+			break
+		}
+	case name == "sudo" || name == "doas" || name == "su":
+		a.caution(cmd.Text, "%s runs commands as another user", name)
+	case name == "tee":
+		for _, target := range operands {
+			a.checkWrite(target, false, cmd.Text)
+		}
+	}
+}
+
+// checkAssign flags variable assignments that inject code into programs.
+func (a *shellAnalyzer) checkAssign(assign, command string) {
+	name, _, _ := strings.Cut(assign, "=")
+	switch name {
+	case "LD_PRELOAD", "LD_LIBRARY_PATH", "LD_AUDIT", "DYLD_INSERT_LIBRARIES", "DYLD_LIBRARY_PATH":
+		a.block(command, "sets %s, which loads arbitrary code into programs", name)
+	}
+}
+
+// redirects checks the redirections of a statement.
+func (a *shellAnalyzer) redirects(redirs []*syntax.Redirect, command string) {
+	for _, r := range redirs {
+		if r.Word == nil {
+			continue
+		}
+		target, dynamic := a.literal(r.Word)
+		if strings.Contains(target, "/dev/tcp/") || strings.Contains(target, "/dev/udp/") {
+			a.block(command, "opens a network connection through %s", target)
+			continue
+		}
+		switch r.Op {
+		case syntax.RdrOut, syntax.AppOut, syntax.RdrInOut, syntax.ClbOut, syntax.RdrAll, syntax.AppAll:
+			a.checkWrite(target, dynamic, command)
+		}
+	}
+}
+
+// checkWrite flags writes to devices, system locations and, when a work
+// directory is set, anywhere outside it.
+func (a *shellAnalyzer) checkWrite(target string, dynamic bool, command string) {
+	if dynamic {
+		if a.workDir != "" {
+			a.caution(command, "writes to a path computed at run time: %s", target)
+		}
+		return
+	}
+	path := expandHome(target)
+	base := filepath.Base(path)
+	switch {
+	case isBlockDevice(path):
+		a.block(command, "overwrites the block device %s", target)
+		return
+	case strings.HasPrefix(base, ".") && strings.HasSuffix(base, "history"):
+		a.block(command, "overwrites the shell history %s", target)
+		return
+	case base == "authorized_keys" || base == "authorized_keys2":
+		a.block(command, "modifies SSH authorized keys in %s", target)
+		return
+	}
+	for _, prefix := range protectedWritePrefixes {
+		if strings.HasPrefix(path, prefix) {
+			a.block(command, "writes to the system location %s", target)
+			return
+		}
+	}
+
+	if a.workDir == "" || isHarmlessWrite(path) {
+		return
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(a.workDir, path)
+	}
+	if !isWithin(filepath.Clean(path), a.workDir) && !isWithin(filepath.Clean(path), os.TempDir()) && !isWithin(filepath.Clean(path), "/tmp") {
+		a.caution(command, "writes outside the working directory: %s", target)
+	}
+}
+
+// protectedWritePrefixes are system locations that commands must not write.
+var protectedWritePrefixes = []string{
+	"/etc/cron", "/var/spool/cron", "/etc/systemd/system/", "/etc/init.d/",
+	"/proc/sys/", "/sys/kernel/", "/boot/", "/etc/sudoers", "/etc/shadow", "/etc/passwd",
+}
+
+// isHarmlessWrite reports whether writing path has no lasting effect.
+func isHarmlessWrite(path string) bool {
+	switch path {
+	case "/dev/null", "/dev/stdout", "/dev/stderr", "/dev/tty":
+		return true
+	}
+	return strings.HasPrefix(path, "/dev/fd/")
+}
+
+// isWithin reports whether path is dir or inside it.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// expandHome replaces a leading ~ with the home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return home + path[1:]
+}
+
+// isBlockDevice reports whether path is a disk device.
+func isBlockDevice(path string) bool {
+	for _, prefix := range []string{"/dev/sd", "/dev/nvme", "/dev/hd", "/dev/vd", "/dev/xvd", "/dev/mmcblk", "/dev/disk"} {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// dangerousRemoval describes what recursively removing target destroys, or
+// returns "" if it is not dangerous.
+func dangerousRemoval(target string, dynamic bool) string {
+	cleaned := filepath.Clean(strings.TrimSuffix(target, "*"))
+	switch {
+	case cleaned == "~" || cleaned == "$HOME" || cleaned == "${HOME}":
+		return fmt.Sprintf("%s deletes the home directory", target)
+	case dynamic && isVariable(target):
+		return fmt.Sprintf("%s deletes / if the variable is empty", target)
+	case dynamic:
+		return ""
+	case cleaned == "/":
+		return fmt.Sprintf("%s deletes the whole system", target)
+	case filepath.IsAbs(cleaned) && filepath.Dir(cleaned) == "/" && cleaned != "/tmp":
+		return fmt.Sprintf("%s deletes a system directory", target)
+	}
+	if home, err := os.UserHomeDir(); err == nil && cleaned == filepath.Clean(home) {
+		return fmt.Sprintf("%s deletes the home directory", target)
+	}
+	return ""
+}
+
+// isVariable reports whether s is a variable expansion, optionally followed
+// by / or /*, which expands to / when the variable is empty.
+func isVariable(s string) bool {
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "*"), "/")
+	if !strings.HasPrefix(s, "$") || len(s) < 2 {
+		return false
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(s[1:], "{"), "}")
+	return name != "" && strings.IndexFunc(name, func(r rune) bool {
+		return r != '_' && (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	}) < 0
+}
+
+// splitFlags separates options from operands. Everything after -- is an
+// operand.
+func splitFlags(args []string) (flags, operands []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return flags, append(operands, args[i+1:]...)
+		}
+		if strings.HasPrefix(arg, "-") && arg != "-" {
+			flags = append(flags, arg)
+		} else {
+			operands = append(operands, arg)
+		}
+	}
+	return flags, operands
+}
+
+// hasFlag reports whether flags contain one of the short letters, possibly
+// combined like -rf, or one of the long options.
+func hasFlag(flags []string, names ...string) bool {
+	for _, f := range flags {
+		for _, name := range names {
+			if strings.HasPrefix(name, "--") {
+				if f == name {
+					return true
+				}
+			} else if !strings.HasPrefix(f, "--") && strings.Contains(f[1:], name) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package semantic
+
+import (
+	"time"
+
+	"gokin/internal/logging"
+)
+
+// QueryStats holds search latency statistics. Latency covers the index lookup
+// only; embedding the query is excluded.
+type QueryStats struct {
+	Count       int64
+	ANNCount    int64 // Queries answered by the HNSW index
+	TotalTime   time.Duration
+	LastLatency time.Duration
+	MaxLatency  time.Duration
+}
+
+// AvgLatency returns the mean lookup latency.
+func (s QueryStats) AvgLatency() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.TotalTime / time.Duration(s.Count)
+}
+
+// setANN attaches an approximate index and syncs it with the indexed chunks.
+// Searches use it once it holds at least minChunks vectors.
+func (i *Indexer) setANN(ann *HNSWIndex, minChunks int) {
+	i.mu.Lock()
+	i.ann = ann
+	i.annMinChunks = minChunks
+	files := make(map[string][]ChunkInfo, len(i.chunks))
+	for path, chunks := range i.chunks {
+		files[path] = chunks
+	}
+	i.mu.Unlock()
+
+	for path, chunks := range files {
+		i.syncANNFile(ann, path, chunks)
+	}
+}
+
+// ANN returns the approximate index, or nil when searches scan all chunks.
+func (i *Indexer) ANN() *HNSWIndex {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.ann
+}
+
+// pruneANN removes files from the approximate index that are no longer indexed,
+// e.g. files deleted while gokin was not running.
+func (i *Indexer) pruneANN() {
+	i.mu.RLock()
+	ann := i.ann
+	if ann == nil {
+		i.mu.RUnlock()
+		return
+	}
+	var stale []string
+	for _, path := range ann.Files() {
+		if _, ok := i.chunks[path]; !ok {
+			stale = append(stale, path)
+		}
+	}
+	i.mu.RUnlock()
+
+	for _, path := range stale {
+		ann.DeleteFile(path)
+	}
+}
+
+// syncANNFile writes the embedded chunks of a file to the approximate index.
+func (i *Indexer) syncANNFile(ann *HNSWIndex, filePath string, chunks []ChunkInfo) {
+	keys := make([]ANNKey, 0, len(chunks))
+	vectors := make([][]float32, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk.Embedding == nil {
+			continue
+		}
+		keys = append(keys, ANNKey{
+			FilePath:  filePath,
+			LineStart: chunk.LineStart,
+			LineEnd:   chunk.LineEnd,
+			Hash:      ContentHash(chunk.Content),
+		})
+		vectors = append(vectors, chunk.Embedding)
+	}
+
+	if _, err := ann.ReplaceFile(filePath, keys, vectors); err != nil {
+		logging.Warn("failed to update semantic ann index", "path", filePath, "error", err)
+	}
+}
+
+// searchANN answers a query from the approximate index.
+func (i *Indexer) searchANN(ann *HNSWIndex, queryEmbedding []float32, topK int) SearchResults {
+	matches := ann.Search(queryEmbedding, topK)
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	results := make(SearchResults, 0, len(matches))
+	for _, match := range matches {
+		for _, chunk := range i.chunks[match.Key.FilePath] {
+			if chunk.LineStart != match.Key.LineStart {
+				continue
+			}
+			results = append(results, newSearchResult(chunk, match.Score))
+			break
+		}
+	}
+	return results
+}
+
+// recordQuery adds a search to the latency statistics.
+func (i *Indexer) recordQuery(latency time.Duration, usedANN bool) {
+	i.statsMu.Lock()
+	defer i.statsMu.Unlock()
+
+	i.queryStats.Count++
+	if usedANN {
+		i.queryStats.ANNCount++
+	}
+	i.queryStats.TotalTime += latency
+	i.queryStats.LastLatency = latency
+	if latency > i.queryStats.MaxLatency {
+		i.queryStats.MaxLatency = latency
+	}
+}
+
+// GetQueryStats returns search latency statistics.
+func (i *Indexer) GetQueryStats() QueryStats {
+	i.statsMu.Lock()
+	defer i.statsMu.Unlock()
+	return i.queryStats
+}
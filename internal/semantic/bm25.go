@@ -0,0 +1,210 @@
+package semantic
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// BM25 ranking parameters.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// LexicalMatch is a hit of the BM25 index.
+type LexicalMatch struct {
+	FilePath  string
+	LineStart int
+	Score     float64
+	Terms     []string // Query terms found in the chunk
+}
+
+// bm25Doc is an indexed chunk.
+type bm25Doc struct {
+	filePath  string
+	lineStart int
+	kind      string
+	length    int
+	terms     map[string]int // term -> frequency
+}
+
+// BM25Index is an in-memory inverted index over chunk contents. Identifiers
+// are indexed whole and split into their camelCase/snake_case parts, so exact
+// names and their components both match.
+type BM25Index struct {
+	docs     []*bm25Doc // nil slots are free
+	free     []int
+	postings map[string]map[int]struct{} // term -> docs containing it
+	byFile   map[string][]int
+	totalLen int
+	live     int
+	mu       sync.RWMutex
+}
+
+// NewBM25Index creates an empty BM25 index.
+func NewBM25Index() *BM25Index {
+	return &BM25Index{
+		postings: make(map[string]map[int]struct{}),
+		byFile:   make(map[string][]int),
+	}
+}
+
+// Len returns the number of indexed chunks.
+func (b *BM25Index) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.live
+}
+
+// ReplaceFile indexes the chunks of a file, replacing previous ones.
+func (b *BM25Index) ReplaceFile(filePath string, chunks []ChunkInfo) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.removeFileLocked(filePath)
+	for _, chunk := range chunks {
+		tokens := tokenizeIdentifiers(chunk.Content)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		doc := &bm25Doc{
+			filePath:  filePath,
+			lineStart: chunk.LineStart,
+			kind:      chunk.Kind,
+			length:    len(tokens),
+			terms:     make(map[string]int),
+		}
+		for _, t := range tokens {
+			doc.terms[t]++
+		}
+
+		var id int
+		if n := len(b.free); n > 0 {
+			id = b.free[n-1]
+			b.free = b.free[:n-1]
+			b.docs[id] = doc
+		} else {
+			id = len(b.docs)
+			b.docs = append(b.docs, doc)
+		}
+
+		for t := range doc.terms {
+			posting, ok := b.postings[t]
+			if !ok {
+				posting = make(map[int]struct{})
+				b.postings[t] = posting
+			}
+			posting[id] = struct{}{}
+		}
+		b.byFile[filePath] = append(b.byFile[filePath], id)
+		b.totalLen += doc.length
+		b.live++
+	}
+}
+
+// RemoveFile drops the chunks of a file.
+func (b *BM25Index) RemoveFile(filePath string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.removeFileLocked(filePath)
+}
+
+// Reset drops all chunks.
+func (b *BM25Index) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.docs = nil
+	b.free = nil
+	b.postings = make(map[string]map[int]struct{})
+	b.byFile = make(map[string][]int)
+	b.totalLen = 0
+	b.live = 0
+}
+
+// removeFileLocked drops the chunks of a file. Callers must hold b.mu.
+func (b *BM25Index) removeFileLocked(filePath string) {
+	for _, id := range b.byFile[filePath] {
+		doc := b.docs[id]
+		for t := range doc.terms {
+			if posting, ok := b.postings[t]; ok {
+				delete(posting, id)
+				if len(posting) == 0 {
+					delete(b.postings, t)
+				}
+			}
+		}
+		b.totalLen -= doc.length
+		b.live--
+		b.docs[id] = nil
+		b.free = append(b.free, id)
+	}
+	delete(b.byFile, filePath)
+}
+
+// Search returns the k best chunks for query. keep, if not nil, filters
+// chunks by path and symbol kind before ranking.
+func (b *BM25Index) Search(query string, k int, keep func(filePath, kind string) bool) []LexicalMatch {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.live == 0 || k <= 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var terms []string
+	for _, t := range tokenizeIdentifiers(query) {
+		if !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+
+	avgLen := float64(b.totalLen) / float64(b.live)
+	scores := make(map[int]*LexicalMatch)
+	for _, t := range terms {
+		posting := b.postings[t]
+		if len(posting) == 0 {
+			continue
+		}
+		df := float64(len(posting))
+		idf := math.Log(1 + (float64(b.live)-df+0.5)/(df+0.5))
+
+		for id := range posting {
+			doc := b.docs[id]
+			if keep != nil && !keep(doc.filePath, doc.kind) {
+				continue
+			}
+			tf := float64(doc.terms[t])
+			score := idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(doc.length)/avgLen))
+
+			match, ok := scores[id]
+			if !ok {
+				match = &LexicalMatch{FilePath: doc.filePath, LineStart: doc.lineStart}
+				scores[id] = match
+			}
+			match.Score += score
+			match.Terms = append(match.Terms, t)
+		}
+	}
+
+	matches := make([]LexicalMatch, 0, len(scores))
+	for _, m := range scores {
+		matches = append(matches, *m)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		if matches[i].FilePath != matches[j].FilePath {
+			return matches[i].FilePath < matches[j].FilePath
+		}
+		return matches[i].LineStart < matches[j].LineStart
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
@@ -26,6 +27,7 @@ type EmbeddingCache struct {
 	ttl       time.Duration
 	dirty     bool
 	projectID string // Unique identifier for the project
+	namespace string // Embedder namespace (provider/model/dimensions) prefixed to keys
 }
 
 // NewEmbeddingCache creates a new embedding cache for a specific project.
@@ -55,6 +57,30 @@ func generateProjectID(projectDir string) string {
 	return hex.EncodeToString(hash[:8]) // 8 characters = 16M possible values
 }
 
+// SetNamespace scopes all entries to an embedder namespace (see EmbedderNamespace).
+// Entries written under another provider, model or dimension are never returned,
+// so switching embedders cannot mix incompatible vectors.
+func (c *EmbeddingCache) SetNamespace(namespace string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.namespace = namespace
+}
+
+// Namespace returns the embedder namespace of the cache.
+func (c *EmbeddingCache) Namespace() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.namespace
+}
+
+// scopedKey prefixes key with the namespace. Callers must hold c.mu.
+func (c *EmbeddingCache) scopedKey(key string) string {
+	if c.namespace == "" {
+		return key
+	}
+	return c.namespace + "|" + key
+}
+
 // GetProjectID returns the project identifier for this cache.
 func (c *EmbeddingCache) GetProjectID() string {
 	return c.projectID
@@ -90,7 +116,7 @@ func (c *EmbeddingCache) Get(key string, contentHash string) ([]float32, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	entry, ok := c.entries[key]
+	entry, ok := c.entries[c.scopedKey(key)]
 	if !ok {
 		return nil, false
 	}
@@ -113,7 +139,7 @@ func (c *EmbeddingCache) Set(key string, embedding []float32, contentHash string
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.entries[key] = CacheEntry{
+	c.entries[c.scopedKey(key)] = CacheEntry{
 		Embedding: embedding,
 		Hash:      contentHash,
 		Timestamp: time.Now(),
@@ -126,7 +152,7 @@ func (c *EmbeddingCache) Delete(key string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	delete(c.entries, key)
+	delete(c.entries, c.scopedKey(key))
 	c.dirty = true
 }
 
@@ -136,8 +162,12 @@ func (c *EmbeddingCache) InvalidateByPath(filePath string) {
 	defer c.mu.Unlock()
 
 	for key := range c.entries {
-		// Keys are typically "filepath:chunkIndex"
-		if len(key) >= len(filePath) && key[:len(filePath)] == filePath {
+		// Keys are typically "namespace|filepath:lineStart"
+		unscoped := key
+		if idx := strings.Index(key, "|"); idx >= 0 {
+			unscoped = key[idx+1:]
+		}
+		if strings.HasPrefix(unscoped, filePath) {
 			delete(c.entries, key)
 			c.dirty = true
 		}
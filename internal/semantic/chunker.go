@@ -124,15 +124,17 @@ func (c *StructuralChunker) chunkGo(filePath, content string) []ChunkInfo {
 	// Process top-level declarations
 	for _, decl := range f.Decls {
 		var start, end token.Pos
-		var chunkType string
+		var chunkType, kind, symbol string
 
 		switch d := decl.(type) {
 		case *ast.FuncDecl:
 			start, end = d.Pos(), d.End()
 			chunkType = "function"
+			kind, symbol = goFuncSymbol(d)
 		case *ast.GenDecl:
 			start, end = d.Pos(), d.End()
 			chunkType = "declaration"
+			kind, symbol = goGenDeclSymbol(d)
 		default:
 			continue
 		}
@@ -152,6 +154,8 @@ func (c *StructuralChunker) chunkGo(filePath, content string) []ChunkInfo {
 				LineStart: startPos.Line,
 				LineEnd:   endPos.Line,
 				Content:   fmt.Sprintf("// Type: %s\n%s", chunkType, chunkContent),
+				Kind:      kind,
+				Symbol:    symbol,
 			})
 		}
 	}
@@ -210,11 +214,14 @@ func (c *StructuralChunker) addChunk(filePath string, lines []string, start, end
 		return
 	}
 	
+	kind, symbol := detectSymbol(lines[start])
 	*chunks = append(*chunks, ChunkInfo{
 		FilePath:  filePath,
 		LineStart: start + 1,
 		LineEnd:   end,
 		Content:   chunkContent,
+		Kind:      kind,
+		Symbol:    symbol,
 	})
 }
 
@@ -248,3 +255,100 @@ func (c *StructuralChunker) chunkSlidingWindow(filePath, content string) []Chunk
 
 	return chunks
 }
+
+// symbolRegex matches a declaration keyword followed by the declared name.
+var symbolRegex = regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:(?:public|private|protected|internal|static|abstract|final|async|pub|sealed|open|data)\s+)*(class|interface|@interface|trait|enum|struct|def|function|func|fn|type|module|namespace|const|let|var)\s+([a-zA-Z_][a-zA-Z0-9_]*)`)
+
+// detectSymbol returns the symbol kind and name declared on a line, if any.
+func detectSymbol(line string) (kind, name string) {
+	m := symbolRegex.FindStringSubmatch(line)
+	if m == nil {
+		return "", ""
+	}
+
+	switch m[1] {
+	case "def", "function", "func", "fn":
+		kind = "function"
+	case "interface", "@interface", "trait":
+		kind = "interface"
+	case "module", "namespace":
+		kind = "module"
+	case "const", "let", "var":
+		kind = "variable"
+	default:
+		kind = m[1] // class, enum, struct, type
+	}
+	return kind, m[2]
+}
+
+// goFuncSymbol returns the kind and name of a Go function or method.
+func goFuncSymbol(d *ast.FuncDecl) (kind, name string) {
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		return "function", d.Name.Name
+	}
+
+	recv := d.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	if index, ok := recv.(*ast.IndexExpr); ok {
+		recv = index.X
+	}
+	if index, ok := recv.(*ast.IndexListExpr); ok {
+		recv = index.X
+	}
+	if ident, ok := recv.(*ast.Ident); ok {
+		return "method", ident.Name + "." + d.Name.Name
+	}
+	return "method", d.Name.Name
+}
+
+// goGenDeclSymbol returns the kind and first name of a Go type, const, var or import declaration.
+func goGenDeclSymbol(d *ast.GenDecl) (kind, name string) {
+	switch d.Tok {
+	case token.TYPE:
+		kind = "type"
+		if len(d.Specs) > 0 {
+			if spec, ok := d.Specs[0].(*ast.TypeSpec); ok {
+				name = spec.Name.Name
+				switch spec.Type.(type) {
+				case *ast.StructType:
+					kind = "struct"
+				case *ast.InterfaceType:
+					kind = "interface"
+				}
+			}
+		}
+	case token.CONST, token.VAR:
+		kind = "variable"
+		if d.Tok == token.CONST {
+			kind = "const"
+		}
+		if len(d.Specs) > 0 {
+			if spec, ok := d.Specs[0].(*ast.ValueSpec); ok && len(spec.Names) > 0 {
+				name = spec.Names[0].Name
+			}
+		}
+	case token.IMPORT:
+		kind = "import"
+	}
+	return kind, name
+}
+
+// languageExtensions maps file extensions to language names used by search filters.
+var languageExtensions = map[string]string{
+	".go": "go", ".py": "python", ".js": "javascript", ".jsx": "javascript",
+	".ts": "typescript", ".tsx": "typescript", ".java": "java", ".c": "c",
+	".h": "c", ".cpp": "cpp", ".hpp": "cpp", ".rs": "rust", ".rb": "ruby",
+	".php": "php", ".swift": "swift", ".kt": "kotlin", ".scala": "scala",
+	".cs": "csharp", ".fs": "fsharp", ".hs": "haskell", ".ml": "ocaml",
+	".lua": "lua", ".r": "r", ".sh": "shell", ".bash": "shell", ".zsh": "shell",
+	".sql": "sql", ".html": "html", ".css": "css", ".scss": "css", ".less": "css",
+	".json": "json", ".yaml": "yaml", ".yml": "yaml", ".toml": "toml",
+	".xml": "xml", ".md": "markdown", ".txt": "text", ".rst": "text",
+}
+
+// LanguageForPath returns the language of a file based on its extension.
+func LanguageForPath(path string) string {
+	return languageExtensions[strings.ToLower(filepath.Ext(path))]
+}
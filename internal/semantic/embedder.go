@@ -3,29 +3,41 @@ package semantic
 import (
 	"context"
 	"fmt"
+)
 
-	"google.golang.org/genai"
+// Embedding providers.
+const (
+	ProviderGemini = "gemini"
+	ProviderOllama = "ollama"
+	ProviderLocal  = "local"
 )
 
-// Embedder generates embeddings using Gemini API.
-type Embedder struct {
-	client *genai.Client
-	model  string
+// Embedder generates embeddings for text.
+type Embedder interface {
+	// Embed generates an embedding for a single text.
+	Embed(ctx context.Context, text string) ([]float32, error)
+
+	// EmbedBatch generates embeddings for multiple texts, in order.
+	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
+
+	// Provider returns the provider name (gemini, ollama, local).
+	Provider() string
+
+	// GetModel returns the embedding model name.
+	GetModel() string
+
+	// Dimensions returns the requested vector size, or 0 for the model's native size.
+	Dimensions() int
 }
 
-// NewEmbedder creates a new embedder.
-func NewEmbedder(client *genai.Client, model string) *Embedder {
-	if model == "" {
-		model = "text-embedding-004"
-	}
-	return &Embedder{
-		client: client,
-		model:  model,
-	}
+// EmbedderNamespace identifies the vector space of an embedder. Vectors from
+// different namespaces are not comparable and must never be mixed.
+func EmbedderNamespace(e Embedder) string {
+	return fmt.Sprintf("%s/%s/%d", e.Provider(), e.GetModel(), e.Dimensions())
 }
 
-// Embed generates an embedding for a single text.
-func (e *Embedder) Embed(ctx context.Context, text string) ([]float32, error) {
+// embedSingle embeds one text through EmbedBatch.
+func embedSingle(ctx context.Context, e Embedder, text string) ([]float32, error) {
 	embeddings, err := e.EmbedBatch(ctx, []string{text})
 	if err != nil {
 		return nil, err
@@ -36,21 +48,17 @@ func (e *Embedder) Embed(ctx context.Context, text string) ([]float32, error) {
 	return embeddings[0], nil
 }
 
-// EmbedBatch generates embeddings for multiple texts.
-// Splits into groups of maxBatchSize items to avoid API limits.
-func (e *Embedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+// embedInBatches splits texts into groups of maxBatchSize and concatenates the results.
+func embedInBatches(ctx context.Context, texts []string, maxBatchSize int, embed func(context.Context, []string) ([][]float32, error)) ([][]float32, error) {
 	if len(texts) == 0 {
 		return nil, nil
 	}
 
-	const maxBatchSize = 20
-
 	// If small enough, send in one call
 	if len(texts) <= maxBatchSize {
-		return e.embedBatchSingle(ctx, texts)
+		return embed(ctx, texts)
 	}
 
-	// Split into groups of maxBatchSize and concatenate results
 	allEmbeddings := make([][]float32, 0, len(texts))
 	for start := 0; start < len(texts); start += maxBatchSize {
 		end := start + maxBatchSize
@@ -64,8 +72,7 @@ func (e *Embedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32,
 		default:
 		}
 
-		batch := texts[start:end]
-		embeddings, err := e.embedBatchSingle(ctx, batch)
+		embeddings, err := embed(ctx, texts[start:end])
 		if err != nil {
 			return allEmbeddings, fmt.Errorf("embedding batch %d-%d failed: %w", start, end, err)
 		}
@@ -74,33 +81,3 @@ func (e *Embedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32,
 
 	return allEmbeddings, nil
 }
-
-// embedBatchSingle sends a single batch of texts to the embedding API.
-func (e *Embedder) embedBatchSingle(ctx context.Context, texts []string) ([][]float32, error) {
-	// Build content parts for embedding
-	contents := make([]*genai.Content, len(texts))
-	for i, text := range texts {
-		contents[i] = &genai.Content{
-			Parts: []*genai.Part{{Text: text}},
-		}
-	}
-
-	// Call embedding API
-	resp, err := e.client.Models.EmbedContent(ctx, e.model, contents, nil)
-	if err != nil {
-		return nil, fmt.Errorf("embedding API error: %w", err)
-	}
-
-	// Extract embeddings
-	embeddings := make([][]float32, len(resp.Embeddings))
-	for i, emb := range resp.Embeddings {
-		embeddings[i] = emb.Values
-	}
-
-	return embeddings, nil
-}
-
-// GetModel returns the embedding model name.
-func (e *Embedder) GetModel() string {
-	return e.model
-}
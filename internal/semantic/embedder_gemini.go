@@ -0,0 +1,85 @@
+package semantic
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/genai"
+)
+
+// GeminiEmbedder generates embeddings using Gemini API.
+type GeminiEmbedder struct {
+	client     *genai.Client
+	model      string
+	dimensions int
+}
+
+// NewGeminiEmbedder creates a new Gemini embedder.
+// dimensions > 0 requests a reduced output size (newer models only).
+func NewGeminiEmbedder(client *genai.Client, model string, dimensions int) *GeminiEmbedder {
+	if model == "" {
+		model = "text-embedding-004"
+	}
+	return &GeminiEmbedder{
+		client:     client,
+		model:      model,
+		dimensions: dimensions,
+	}
+}
+
+// Embed generates an embedding for a single text.
+func (e *GeminiEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return embedSingle(ctx, e, text)
+}
+
+// EmbedBatch generates embeddings for multiple texts.
+// Splits into groups of 20 items to avoid API limits.
+func (e *GeminiEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedInBatches(ctx, texts, 20, e.embedBatchSingle)
+}
+
+// embedBatchSingle sends a single batch of texts to the embedding API.
+func (e *GeminiEmbedder) embedBatchSingle(ctx context.Context, texts []string) ([][]float32, error) {
+	// Build content parts for embedding
+	contents := make([]*genai.Content, len(texts))
+	for i, text := range texts {
+		contents[i] = &genai.Content{
+			Parts: []*genai.Part{{Text: text}},
+		}
+	}
+
+	var config *genai.EmbedContentConfig
+	if e.dimensions > 0 {
+		dims := int32(e.dimensions)
+		config = &genai.EmbedContentConfig{OutputDimensionality: &dims}
+	}
+
+	// Call embedding API
+	resp, err := e.client.Models.EmbedContent(ctx, e.model, contents, config)
+	if err != nil {
+		return nil, fmt.Errorf("embedding API error: %w", err)
+	}
+
+	// Extract embeddings
+	embeddings := make([][]float32, len(resp.Embeddings))
+	for i, emb := range resp.Embeddings {
+		embeddings[i] = emb.Values
+	}
+
+	return embeddings, nil
+}
+
+// Provider returns the provider name.
+func (e *GeminiEmbedder) Provider() string {
+	return ProviderGemini
+}
+
+// GetModel returns the embedding model name.
+func (e *GeminiEmbedder) GetModel() string {
+	return e.model
+}
+
+// Dimensions returns the requested output size (0 = native).
+func (e *GeminiEmbedder) Dimensions() int {
+	return e.dimensions
+}
@@ -0,0 +1,184 @@
+package semantic
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// LocalEmbedderModel is the model name reported by the local embedder.
+// Bump it whenever the feature extraction changes so cached vectors are invalidated.
+const LocalEmbedderModel = "hash-tf-v1"
+
+// DefaultLocalDimensions is the default vector size of the local embedder.
+const DefaultLocalDimensions = 512
+
+// localStopWords are frequent keywords that carry little meaning for code search.
+var localStopWords = map[string]bool{
+	"the": true, "a": true, "an": true, "and": true, "or": true, "of": true, "to": true,
+	"in": true, "is": true, "it": true, "for": true, "on": true, "be": true, "as": true,
+	"if": true, "else": true, "return": true, "func": true, "function": true, "def": true,
+	"var": true, "let": true, "const": true, "nil": true, "null": true, "true": true,
+	"false": true, "this": true, "self": true, "err": true, "new": true, "int": true,
+	"string": true, "with": true, "from": true, "import": true, "package": true,
+}
+
+// LocalEmbedder generates embeddings offline by feature hashing: identifier
+// parts and character trigrams are hashed into a fixed-size vector with
+// sublinear term frequency weights. It needs no service and is deterministic,
+// so it works as a fallback when no embedding API is available.
+type LocalEmbedder struct {
+	dimensions int
+}
+
+// NewLocalEmbedder creates a new local hashing embedder.
+func NewLocalEmbedder(dimensions int) *LocalEmbedder {
+	if dimensions <= 0 {
+		dimensions = DefaultLocalDimensions
+	}
+	return &LocalEmbedder{dimensions: dimensions}
+}
+
+// Embed generates an embedding for a single text.
+func (e *LocalEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return e.embed(text), nil
+}
+
+// EmbedBatch generates embeddings for multiple texts.
+func (e *LocalEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		if i%64 == 0 && ctx.Err() != nil {
+			return embeddings[:i], ctx.Err()
+		}
+		embeddings[i] = e.embed(text)
+	}
+	return embeddings, nil
+}
+
+// embed builds the normalized feature vector of text.
+func (e *LocalEmbedder) embed(text string) []float32 {
+	counts := make(map[string]float64)
+	for _, token := range tokenizeIdentifiers(text) {
+		if localStopWords[token] {
+			continue
+		}
+		counts["w:"+token]++
+
+		// Character trigrams make the vector robust to inflections and abbreviations
+		if len(token) > 3 {
+			padded := "^" + token + "$"
+			for i := 0; i+3 <= len(padded); i++ {
+				counts["t:"+padded[i:i+3]] += 0.25
+			}
+		}
+	}
+
+	vec := make([]float32, e.dimensions)
+	h := fnv.New64a()
+	for feature, tf := range counts {
+		h.Reset()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+
+		idx := int(sum % uint64(e.dimensions))
+		weight := 1 + math.Log(tf)
+		if tf < 1 {
+			weight = tf
+		}
+		// Signed hashing keeps collisions from biasing the dot product
+		if sum>>63 == 1 {
+			weight = -weight
+		}
+		vec[idx] += float32(weight)
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		inv := float32(1 / math.Sqrt(norm))
+		for i := range vec {
+			vec[i] *= inv
+		}
+	}
+	return vec
+}
+
+// Provider returns the provider name.
+func (e *LocalEmbedder) Provider() string {
+	return ProviderLocal
+}
+
+// GetModel returns the embedding model name.
+func (e *LocalEmbedder) GetModel() string {
+	return LocalEmbedderModel
+}
+
+// Dimensions returns the vector size.
+func (e *LocalEmbedder) Dimensions() int {
+	return e.dimensions
+}
+
+// tokenizeIdentifiers splits text into lowercase words, breaking identifiers
+// at camelCase, snake_case and digit boundaries. Whole identifiers are kept
+// as well so exact names still match strongly.
+func tokenizeIdentifiers(text string) []string {
+	var tokens []string
+
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for _, word := range words {
+		parts := splitIdentifier(word)
+		if len(parts) > 1 {
+			tokens = append(tokens, strings.ToLower(strings.ReplaceAll(word, "_", "")))
+		}
+		for _, part := range parts {
+			if len(part) > 1 {
+				tokens = append(tokens, strings.ToLower(part))
+			}
+		}
+	}
+	return tokens
+}
+
+// splitIdentifier splits an identifier into its camelCase/snake_case parts.
+func splitIdentifier(word string) []string {
+	var parts []string
+	runes := []rune(word)
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '_' {
+			if i > start {
+				parts = append(parts, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+		if i == start {
+			continue
+		}
+		prev := runes[i-1]
+		switch {
+		case unicode.IsUpper(r) && unicode.IsLower(prev):
+			// fooBar -> foo | Bar
+		case unicode.IsUpper(r) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(prev):
+			// HTTPServer -> HTTP | Server
+		case unicode.IsDigit(r) != unicode.IsDigit(prev):
+			// utf8 -> utf | 8
+		default:
+			continue
+		}
+		parts = append(parts, string(runes[start:i]))
+		start = i
+	}
+	if start < len(runes) {
+		parts = append(parts, string(runes[start:]))
+	}
+	return parts
+}
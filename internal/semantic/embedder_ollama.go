@@ -0,0 +1,139 @@
+package semantic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultOllamaEmbedModel is used when no Ollama embedding model is configured.
+const DefaultOllamaEmbedModel = "nomic-embed-text"
+
+// OllamaEmbedder generates embeddings using Ollama's /api/embed endpoint.
+type OllamaEmbedder struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	dimensions int
+	httpClient *http.Client
+}
+
+// ollamaEmbedRequest is the request body of /api/embed.
+type ollamaEmbedRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Truncate   bool     `json:"truncate"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+// ollamaEmbedResponse is the response body of /api/embed.
+type ollamaEmbedResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float32 `json:"embeddings"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// NewOllamaEmbedder creates a new Ollama embedder.
+// baseURL defaults to http://localhost:11434; apiKey is optional (remote servers).
+func NewOllamaEmbedder(baseURL, apiKey, model string, dimensions int) *OllamaEmbedder {
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
+	}
+	if model == "" {
+		model = DefaultOllamaEmbedModel
+	}
+	return &OllamaEmbedder{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+		dimensions: dimensions,
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+// Embed generates an embedding for a single text.
+func (e *OllamaEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return embedSingle(ctx, e, text)
+}
+
+// EmbedBatch generates embeddings for multiple texts.
+// Splits into groups of 32 items to keep requests small.
+func (e *OllamaEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedInBatches(ctx, texts, 32, e.embedBatchSingle)
+}
+
+// embedBatchSingle sends a single batch of texts to /api/embed.
+func (e *OllamaEmbedder) embedBatchSingle(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(ollamaEmbedRequest{
+		Model:      e.model,
+		Input:      texts,
+		Truncate:   true,
+		Dimensions: e.dimensions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode embed request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ollama embed request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 256<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ollama embed response: %w", err)
+	}
+
+	var result ollamaEmbedResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("ollama embed error (HTTP %d): %s", resp.StatusCode, strings.TrimSpace(string(data)))
+		}
+		return nil, fmt.Errorf("invalid ollama embed response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg := result.Error
+		if msg == "" {
+			msg = http.StatusText(resp.StatusCode)
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			msg += fmt.Sprintf(" (run 'ollama pull %s')", e.model)
+		}
+		return nil, fmt.Errorf("ollama embed error (HTTP %d): %s", resp.StatusCode, msg)
+	}
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d inputs", len(result.Embeddings), len(texts))
+	}
+
+	return result.Embeddings, nil
+}
+
+// Provider returns the provider name.
+func (e *OllamaEmbedder) Provider() string {
+	return ProviderOllama
+}
+
+// GetModel returns the embedding model name.
+func (e *OllamaEmbedder) GetModel() string {
+	return e.model
+}
+
+// Dimensions returns the requested output size (0 = native).
+func (e *OllamaEmbedder) Dimensions() int {
+	return e.dimensions
+}
@@ -8,8 +8,13 @@ import (
 	"path/filepath"
 	"sync"
 	"time"
+
+	"gokin/internal/logging"
 )
 
+// annSaveInterval throttles rewriting the ANN index while files keep changing.
+const annSaveInterval = 2 * time.Minute
+
 // IndexData represents the persistent index data.
 type IndexData struct {
 	Version     string               `json:"version"`
@@ -35,6 +40,8 @@ type ChunkMeta struct {
 	LineStart int    `json:"line_start"`
 	LineEnd   int    `json:"line_end"`
 	Hash      string `json:"hash"`
+	Kind      string `json:"kind,omitempty"`
+	Symbol    string `json:"symbol,omitempty"`
 }
 
 // EnhancedIndexer extends Indexer with persistent storage.
@@ -43,12 +50,15 @@ type EnhancedIndexer struct {
 	configDir  string
 	projectID  string
 	indexPath  string
+	annPath    string
+	annSavedAt time.Time
+	annMu      sync.Mutex // Serializes ANN saves
 	projectMgr *ProjectManager
 	mu         sync.RWMutex
 }
 
 // NewEnhancedIndexer creates a new enhanced indexer with persistence.
-func NewEnhancedIndexer(embedder *Embedder, workDir string, cache *EmbeddingCache, maxFileSize int64, configDir string) *EnhancedIndexer {
+func NewEnhancedIndexer(embedder Embedder, workDir string, cache *EmbeddingCache, maxFileSize int64, configDir string) *EnhancedIndexer {
 	// Create base indexer
 	baseIndexer := NewIndexer(embedder, workDir, cache, maxFileSize)
 
@@ -56,6 +66,7 @@ func NewEnhancedIndexer(embedder *Embedder, workDir string, cache *EmbeddingCach
 	projectID := cache.GetProjectID()
 
 	indexPath := filepath.Join(configDir, "semantic_cache", projectID, "index.json")
+	annPath := filepath.Join(configDir, "semantic_cache", projectID, "hnsw.idx")
 
 	// Create project manager
 	projectMgr := NewProjectManager(configDir)
@@ -65,10 +76,56 @@ func NewEnhancedIndexer(embedder *Embedder, workDir string, cache *EmbeddingCach
 		configDir:  configDir,
 		projectID:  projectID,
 		indexPath:  indexPath,
+		annPath:    annPath,
 		projectMgr: projectMgr,
 	}
 }
 
+// EnableANN switches searches over minChunks chunks to an HNSW index stored
+// next to index.json. A saved index of the same embedder is memory-mapped;
+// otherwise a new one is built as files are indexed.
+func (ei *EnhancedIndexer) EnableANN(cfg HNSWConfig, minChunks int) {
+	namespace := EmbedderNamespace(ei.embedder)
+
+	ann, err := LoadHNSWIndex(ei.annPath, namespace, cfg)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logging.Debug("rebuilding semantic ann index", "reason", err)
+		}
+		ann = NewHNSWIndex(namespace, cfg)
+	} else {
+		ei.annSavedAt = time.Now()
+		logging.Debug("semantic ann index loaded", "path", ei.annPath, "vectors", ann.Len())
+	}
+
+	ei.Indexer.setANN(ann, minChunks)
+}
+
+// saveANN persists the ANN index if it changed. Unless forced, saves are
+// throttled to annSaveInterval since the index can be large.
+func (ei *EnhancedIndexer) saveANN(force bool) error {
+	ann := ei.Indexer.ANN()
+	if ann == nil || !ann.Dirty() {
+		return nil
+	}
+
+	ei.annMu.Lock()
+	defer ei.annMu.Unlock()
+	if !force && time.Since(ei.annSavedAt) < annSaveInterval {
+		return nil
+	}
+	ei.annSavedAt = time.Now()
+	return ann.Save(ei.annPath)
+}
+
+// SaveCache saves the embedding cache and the ANN index to disk.
+func (ei *EnhancedIndexer) SaveCache() error {
+	if err := ei.Indexer.SaveCache(); err != nil {
+		return err
+	}
+	return ei.saveANN(true)
+}
+
 // LoadIndex loads the index from disk if available and fresh.
 func (ei *EnhancedIndexer) LoadIndex(maxAge time.Duration) (bool, error) {
 	ei.mu.Lock()
@@ -131,13 +188,15 @@ func (ei *EnhancedIndexer) LoadIndex(maxAge time.Duration) (bool, error) {
 					LineStart: chunkMeta.LineStart,
 					LineEnd:   chunkMeta.LineEnd,
 					Embedding: embedding,
+					Kind:      chunkMeta.Kind,
+					Symbol:    chunkMeta.Symbol,
 					// Content will be loaded on-demand
 				})
 			}
 		}
 
 		if len(chunks) > 0 {
-			ei.chunks[filePath] = chunks
+			ei.setFileChunks(filePath, chunks)
 			loaded++
 		}
 	}
@@ -173,6 +232,8 @@ func (ei *EnhancedIndexer) SaveIndex() error {
 				LineStart: chunk.LineStart,
 				LineEnd:   chunk.LineEnd,
 				Hash:      ContentHash(chunk.Content),
+				Kind:      chunk.Kind,
+				Symbol:    chunk.Symbol,
 			})
 			indexData.ChunkCount++
 		}
@@ -206,7 +267,14 @@ func (ei *EnhancedIndexer) SaveIndex() error {
 	}
 
 	// Atomic rename
-	return os.Rename(tmpPath, ei.indexPath)
+	if err := os.Rename(tmpPath, ei.indexPath); err != nil {
+		return err
+	}
+
+	if err := ei.saveANN(false); err != nil {
+		logging.Warn("failed to save semantic ann index", "error", err)
+	}
+	return nil
 }
 
 // IndexDirectory indexes all files and persists the index.
@@ -217,8 +285,14 @@ func (ei *EnhancedIndexer) IndexDirectory(ctx context.Context, dir string) error
 		return err
 	}
 
+	// Drop vectors of files deleted since the ANN index was saved
+	ei.pruneANN()
+
 	// Persist index after indexing
 	_ = ei.SaveIndex() // Ignore error - indexing succeeded
+	if err := ei.saveANN(true); err != nil {
+		logging.Warn("failed to save semantic ann index", "error", err)
+	}
 
 	return nil
 }
@@ -251,10 +325,21 @@ func (ei *EnhancedIndexer) GetIndexStats() *IndexStats {
 		CacheSizeBytes: 0,
 		IndexSizeBytes: 0,
 	}
+	stats.EmbeddingProvider = ei.embedder.Provider()
+	stats.EmbeddingModel = ei.embedder.GetModel()
+	stats.EmbeddingDimensions = ei.embedder.Dimensions()
 
 	for _, chunks := range ei.chunks {
 		stats.ChunkCount += len(chunks)
 		stats.TotalChunks += len(chunks)
+		if stats.EmbeddingDimensions == 0 {
+			for _, chunk := range chunks {
+				if len(chunk.Embedding) > 0 {
+					stats.EmbeddingDimensions = len(chunk.Embedding)
+					break
+				}
+			}
+		}
 	}
 
 	// Get cache size
@@ -273,6 +358,25 @@ func (ei *EnhancedIndexer) GetIndexStats() *IndexStats {
 		stats.LastIndexTime = info.ModTime().Unix()
 	}
 
+	// Approximate index
+	if ann := ei.Indexer.ANN(); ann != nil {
+		annStats := ann.Stats()
+		stats.ANNEnabled = true
+		stats.ANNActive = annStats.Nodes > 0 && annStats.Nodes >= ei.annMinChunks
+		stats.ANNMinChunks = ei.annMinChunks
+		stats.ANNNodes = annStats.Nodes
+		stats.ANNDeleted = annStats.Deleted
+		stats.ANNLevels = annStats.Levels
+		stats.ANNMapped = annStats.Mapped
+		stats.ANNM = annStats.M
+		stats.ANNEfConstruction = annStats.EfConstruction
+		stats.ANNEfSearch = annStats.EfSearch
+		if info, err := os.Stat(ei.annPath); err == nil {
+			stats.ANNSizeBytes = info.Size()
+		}
+	}
+	stats.Queries = ei.GetQueryStats()
+
 	return stats
 }
 
@@ -295,6 +399,27 @@ type IndexStats struct {
 	EmbeddingsCached int   `json:"embeddings_cached"`
 	IndexLoads       int   `json:"index_loads"`
 	LastIndexTime    int64 `json:"last_index_time"`
+
+	// Embedding provider
+	EmbeddingProvider   string `json:"embedding_provider"`
+	EmbeddingModel      string `json:"embedding_model"`
+	EmbeddingDimensions int    `json:"embedding_dimensions"`
+
+	// Approximate nearest-neighbour index
+	ANNEnabled        bool  `json:"ann_enabled"`
+	ANNActive         bool  `json:"ann_active"` // Searches currently use the index
+	ANNMinChunks      int   `json:"ann_min_chunks"`
+	ANNNodes          int   `json:"ann_nodes"`
+	ANNDeleted        int   `json:"ann_deleted"`
+	ANNLevels         int   `json:"ann_levels"`
+	ANNMapped         bool  `json:"ann_mapped"`
+	ANNSizeBytes      int64 `json:"ann_size_bytes"`
+	ANNM              int   `json:"ann_m"`
+	ANNEfConstruction int   `json:"ann_ef_construction"`
+	ANNEfSearch       int   `json:"ann_ef_search"`
+
+	// Search latency
+	Queries QueryStats `json:"queries"`
 }
 
 // LoadOrIndex loads the index if fresh, otherwise re-indexes.
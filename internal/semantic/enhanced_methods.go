@@ -10,8 +10,8 @@ func (ei *EnhancedIndexer) Clear() error {
 	ei.mu.Lock()
 	defer ei.mu.Unlock()
 
-	// Clear chunks
-	ei.Indexer.chunks = make(map[string][]ChunkInfo)
+	// Clear chunks and the ANN index
+	ei.Indexer.resetChunks()
 
 	return nil
 }
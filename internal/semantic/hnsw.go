@@ -0,0 +1,567 @@
+package semantic
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// HNSWConfig holds the parameters of a hierarchical navigable small world graph.
+type HNSWConfig struct {
+	M              int // Links per node on upper layers; layer 0 keeps 2*M
+	EfConstruction int // Candidate list size while inserting
+	EfSearch       int // Candidate list size while searching (higher = better recall)
+}
+
+// DefaultHNSWConfig returns the default graph parameters.
+func DefaultHNSWConfig() HNSWConfig {
+	return HNSWConfig{M: 16, EfConstruction: 200, EfSearch: 64}
+}
+
+// withDefaults fills unset parameters.
+func (c HNSWConfig) withDefaults() HNSWConfig {
+	def := DefaultHNSWConfig()
+	if c.M < 2 {
+		c.M = def.M
+	}
+	if c.EfConstruction <= 0 {
+		c.EfConstruction = def.EfConstruction
+	}
+	if c.EfSearch <= 0 {
+		c.EfSearch = def.EfSearch
+	}
+	return c
+}
+
+// ANNKey identifies the chunk stored in an index node.
+type ANNKey struct {
+	FilePath  string
+	LineStart int
+	LineEnd   int
+	Hash      string // Content hash; unchanged chunks are not re-inserted
+}
+
+// ANNMatch is a hit of the approximate index.
+type ANNMatch struct {
+	Key   ANNKey
+	Score float32 // Cosine similarity
+}
+
+// HNSWStats describes the state of an HNSW index.
+type HNSWStats struct {
+	Nodes          int  // Live vectors
+	Deleted        int  // Tombstoned vectors awaiting compaction
+	Dimensions     int  // Vector size
+	Levels         int  // Number of graph layers
+	Mapped         bool // Vectors are served from a memory-mapped file
+	M              int
+	EfConstruction int
+	EfSearch       int
+}
+
+// hnswNode is a vector in the graph.
+type hnswNode struct {
+	key     ANNKey
+	links   [][]uint32 // Neighbour IDs per layer
+	deleted bool
+}
+
+// HNSWIndex is an approximate nearest-neighbour index over normalized vectors.
+// Removed vectors are tombstoned and keep routing queries until the index is
+// compacted on save. Vectors loaded from disk stay memory-mapped; vectors
+// inserted afterwards live on the heap.
+type HNSWIndex struct {
+	cfg       HNSWConfig
+	wanted    HNSWConfig // Configured parameters, applied when the graph is rebuilt
+	namespace string
+	dims      int
+
+	nodes    []hnswNode
+	mapped   []float32 // Vectors of nodes [0, mappedN), backed by mapping
+	mappedN  int
+	heapVecs []float32 // Vectors of nodes [mappedN, len(nodes))
+	mapping  *mappedFile
+
+	byFile   map[string][]uint32
+	entry    int
+	maxLevel int
+	deleted  int
+	dirty    bool
+
+	levelMult float64
+	rng       *rand.Rand
+	mu        sync.RWMutex
+}
+
+// NewHNSWIndex creates an empty index for vectors of the given embedder namespace.
+func NewHNSWIndex(namespace string, cfg HNSWConfig) *HNSWIndex {
+	cfg = cfg.withDefaults()
+	return &HNSWIndex{
+		cfg:       cfg,
+		wanted:    cfg,
+		namespace: namespace,
+		byFile:    make(map[string][]uint32),
+		entry:     -1,
+		levelMult: 1 / math.Log(float64(cfg.M)),
+		rng:       rand.New(rand.NewSource(1)),
+	}
+}
+
+// Namespace returns the embedder namespace of the indexed vectors.
+func (h *HNSWIndex) Namespace() string {
+	return h.namespace
+}
+
+// Len returns the number of live vectors.
+func (h *HNSWIndex) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.nodes) - h.deleted
+}
+
+// Dirty reports whether the index changed since it was last saved or loaded.
+func (h *HNSWIndex) Dirty() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.dirty
+}
+
+// SetEfSearch changes the query-time candidate list size.
+func (h *HNSWIndex) SetEfSearch(ef int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ef > 0 {
+		h.cfg.EfSearch = ef
+		h.wanted.EfSearch = ef
+	}
+}
+
+// Stats returns a snapshot of the index state.
+func (h *HNSWIndex) Stats() HNSWStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	levels := 0
+	if h.entry >= 0 {
+		levels = h.maxLevel + 1
+	}
+	return HNSWStats{
+		Nodes:          len(h.nodes) - h.deleted,
+		Deleted:        h.deleted,
+		Dimensions:     h.dims,
+		Levels:         levels,
+		Mapped:         h.mappedN > 0,
+		M:              h.cfg.M,
+		EfConstruction: h.cfg.EfConstruction,
+		EfSearch:       h.cfg.EfSearch,
+	}
+}
+
+// Files returns the paths that have live vectors in the index.
+func (h *HNSWIndex) Files() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	files := make([]string, 0, len(h.byFile))
+	for path := range h.byFile {
+		files = append(files, path)
+	}
+	return files
+}
+
+// ReplaceFile replaces the vectors of a file. keys and vectors are parallel.
+// Nothing changes if the file already holds exactly these chunks, so
+// re-indexing an unchanged file is free. Returns whether the index changed.
+func (h *HNSWIndex) ReplaceFile(filePath string, keys []ANNKey, vectors [][]float32) (bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.fileMatchesLocked(filePath, keys) {
+		return false, nil
+	}
+
+	h.deleteFileLocked(filePath)
+	for idx, key := range keys {
+		if err := h.insertLocked(key, vectors[idx]); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// DeleteFile removes all vectors of a file.
+func (h *HNSWIndex) DeleteFile(filePath string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.deleteFileLocked(filePath)
+}
+
+// Reset removes all vectors and releases the file mapping.
+func (h *HNSWIndex) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.resetLocked()
+	h.dirty = true
+}
+
+// Close releases the file mapping. The index must not be used afterwards.
+func (h *HNSWIndex) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	err := h.mapping.Close()
+	h.mapping = nil
+	h.mapped = nil
+	return err
+}
+
+// Search returns the k nearest live vectors to query by cosine similarity.
+func (h *HNSWIndex) Search(query []float32, k int) []ANNMatch {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.entry < 0 || k <= 0 || len(query) != h.dims {
+		return nil
+	}
+
+	q := normalizeVector(query)
+	ep := h.entry
+	for level := h.maxLevel; level > 0; level-- {
+		ep = h.greedyClosest(q, ep, level)
+	}
+
+	ef := h.cfg.EfSearch
+	if ef < k {
+		ef = k
+	}
+	candidates := h.searchLayer(q, ep, ef, 0)
+
+	matches := make([]ANNMatch, 0, k)
+	for _, c := range candidates {
+		node := &h.nodes[c.id]
+		if node.deleted {
+			continue
+		}
+		matches = append(matches, ANNMatch{Key: node.key, Score: 1 - c.dist})
+		if len(matches) == k {
+			break
+		}
+	}
+	return matches
+}
+
+// fileMatchesLocked reports whether the live vectors of a file are exactly keys.
+func (h *HNSWIndex) fileMatchesLocked(filePath string, keys []ANNKey) bool {
+	ids := h.byFile[filePath]
+	if len(ids) != len(keys) {
+		return false
+	}
+	existing := make(map[ANNKey]bool, len(ids))
+	for _, id := range ids {
+		existing[h.nodes[id].key] = true
+	}
+	for _, key := range keys {
+		if !existing[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// deleteFileLocked tombstones all vectors of a file.
+func (h *HNSWIndex) deleteFileLocked(filePath string) {
+	ids, ok := h.byFile[filePath]
+	if !ok {
+		return
+	}
+	for _, id := range ids {
+		if !h.nodes[id].deleted {
+			h.nodes[id].deleted = true
+			h.deleted++
+		}
+	}
+	delete(h.byFile, filePath)
+	h.dirty = true
+
+	// An index without live vectors starts over
+	if h.deleted == len(h.nodes) {
+		h.resetLocked()
+	}
+}
+
+// resetLocked clears the graph and applies the configured parameters.
+func (h *HNSWIndex) resetLocked() {
+	_ = h.mapping.Close()
+	h.mapping = nil
+	h.mapped = nil
+	h.mappedN = 0
+	h.heapVecs = nil
+	h.nodes = nil
+	h.byFile = make(map[string][]uint32)
+	h.entry = -1
+	h.maxLevel = 0
+	h.deleted = 0
+	h.dims = 0
+	h.cfg = h.wanted
+	h.levelMult = 1 / math.Log(float64(h.cfg.M))
+}
+
+// insertLocked adds a vector to the graph.
+func (h *HNSWIndex) insertLocked(key ANNKey, vector []float32) error {
+	if len(vector) == 0 {
+		return fmt.Errorf("empty vector for %s:%d", key.FilePath, key.LineStart)
+	}
+	if h.dims == 0 {
+		h.dims = len(vector)
+	}
+	if len(vector) != h.dims {
+		return fmt.Errorf("vector size %d does not match index size %d", len(vector), h.dims)
+	}
+
+	id := len(h.nodes)
+	level := int(-math.Log(1-h.rng.Float64()) * h.levelMult)
+	h.nodes = append(h.nodes, hnswNode{key: key, links: make([][]uint32, level+1)})
+	h.heapVecs = append(h.heapVecs, normalizeVector(vector)...)
+	h.byFile[key.FilePath] = append(h.byFile[key.FilePath], uint32(id))
+	h.dirty = true
+
+	if h.entry < 0 {
+		h.entry = id
+		h.maxLevel = level
+		return nil
+	}
+
+	q := h.vector(id)
+	ep := h.entry
+	for l := h.maxLevel; l > level; l-- {
+		ep = h.greedyClosest(q, ep, l)
+	}
+
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		candidates := h.searchLayer(q, ep, h.cfg.EfConstruction, l)
+		neighbours := h.selectNeighbours(candidates, h.cfg.M)
+		h.nodes[id].links[l] = neighbours
+
+		maxLinks := h.cfg.M
+		if l == 0 {
+			maxLinks = 2 * h.cfg.M
+		}
+		for _, n := range neighbours {
+			links := append(h.nodes[n].links[l], uint32(id))
+			if len(links) > maxLinks {
+				links = h.pruneLinks(int(n), links, maxLinks)
+			}
+			h.nodes[n].links[l] = links
+		}
+		ep = int(candidates[0].id)
+	}
+
+	if level > h.maxLevel {
+		h.maxLevel = level
+		h.entry = id
+	}
+	return nil
+}
+
+// vector returns the normalized vector of a node.
+func (h *HNSWIndex) vector(id int) []float32 {
+	if id < h.mappedN {
+		return h.mapped[id*h.dims : (id+1)*h.dims]
+	}
+	off := (id - h.mappedN) * h.dims
+	return h.heapVecs[off : off+h.dims]
+}
+
+// distance returns the cosine distance between a normalized query and a node.
+func (h *HNSWIndex) distance(q []float32, id int) float32 {
+	v := h.vector(id)
+	var dot float32
+	for i := range q {
+		dot += q[i] * v[i]
+	}
+	return 1 - dot
+}
+
+// greedyClosest walks a layer towards q and returns the closest node found.
+func (h *HNSWIndex) greedyClosest(q []float32, ep int, level int) int {
+	cur := ep
+	curDist := h.distance(q, cur)
+	for changed := true; changed; {
+		changed = false
+		for _, n := range h.linksAt(cur, level) {
+			if d := h.distance(q, int(n)); d < curDist {
+				cur, curDist = int(n), d
+				changed = true
+			}
+		}
+	}
+	return cur
+}
+
+// linksAt returns the neighbours of a node on a layer.
+func (h *HNSWIndex) linksAt(id, level int) []uint32 {
+	links := h.nodes[id].links
+	if level >= len(links) {
+		return nil
+	}
+	return links[level]
+}
+
+// searchLayer runs a best-first search on one layer and returns up to ef
+// candidates ordered by increasing distance.
+func (h *HNSWIndex) searchLayer(q []float32, ep int, ef int, level int) []hnswCandidate {
+	visited := make(map[uint32]struct{}, ef*4)
+	visited[uint32(ep)] = struct{}{}
+
+	start := hnswCandidate{id: uint32(ep), dist: h.distance(q, ep)}
+	candidates := &candidateHeap{items: []hnswCandidate{start}}
+	results := &candidateHeap{items: []hnswCandidate{start}, farthestFirst: true}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(hnswCandidate)
+		if results.Len() >= ef && c.dist > results.items[0].dist {
+			break
+		}
+		for _, n := range h.linksAt(int(c.id), level) {
+			if _, seen := visited[n]; seen {
+				continue
+			}
+			visited[n] = struct{}{}
+
+			d := h.distance(q, int(n))
+			if results.Len() < ef || d < results.items[0].dist {
+				heap.Push(candidates, hnswCandidate{id: n, dist: d})
+				heap.Push(results, hnswCandidate{id: n, dist: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	out := results.items
+	sort.Slice(out, func(a, b int) bool { return out[a].dist < out[b].dist })
+	return out
+}
+
+// selectNeighbours picks up to m diverse neighbours from candidates sorted by
+// distance: a candidate is skipped when it is closer to an already selected
+// neighbour than to the new node. Skipped candidates fill remaining slots.
+func (h *HNSWIndex) selectNeighbours(candidates []hnswCandidate, m int) []uint32 {
+	if len(candidates) <= m {
+		ids := make([]uint32, len(candidates))
+		for i, c := range candidates {
+			ids[i] = c.id
+		}
+		return ids
+	}
+
+	selected := make([]uint32, 0, m)
+	var skipped []uint32
+	for _, c := range candidates {
+		if len(selected) == m {
+			break
+		}
+		diverse := true
+		cv := h.vector(int(c.id))
+		for _, s := range selected {
+			if h.distance(cv, int(s)) < c.dist {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			selected = append(selected, c.id)
+		} else {
+			skipped = append(skipped, c.id)
+		}
+	}
+	for _, id := range skipped {
+		if len(selected) == m {
+			break
+		}
+		selected = append(selected, id)
+	}
+	return selected
+}
+
+// pruneLinks shrinks the link list of node id to maxLinks neighbours.
+func (h *HNSWIndex) pruneLinks(id int, links []uint32, maxLinks int) []uint32 {
+	v := h.vector(id)
+	candidates := make([]hnswCandidate, len(links))
+	for i, n := range links {
+		candidates[i] = hnswCandidate{id: n, dist: h.distance(v, int(n))}
+	}
+	sort.Slice(candidates, func(a, b int) bool { return candidates[a].dist < candidates[b].dist })
+	return h.selectNeighbours(candidates, maxLinks)
+}
+
+// compactLocked rebuilds the graph from live vectors, dropping tombstones.
+func (h *HNSWIndex) compactLocked() error {
+	type liveNode struct {
+		key    ANNKey
+		vector []float32
+	}
+	live := make([]liveNode, 0, len(h.nodes)-h.deleted)
+	for id := range h.nodes {
+		if h.nodes[id].deleted {
+			continue
+		}
+		vec := make([]float32, h.dims)
+		copy(vec, h.vector(id))
+		live = append(live, liveNode{key: h.nodes[id].key, vector: vec})
+	}
+
+	h.resetLocked()
+	for _, n := range live {
+		if err := h.insertLocked(n.key, n.vector); err != nil {
+			return err
+		}
+	}
+	h.dirty = true
+	return nil
+}
+
+// normalizeVector returns a unit-length copy of v.
+func normalizeVector(v []float32) []float32 {
+	out := make([]float32, len(v))
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return out
+	}
+	inv := float32(1 / math.Sqrt(norm))
+	for i, x := range v {
+		out[i] = x * inv
+	}
+	return out
+}
+
+// hnswCandidate is a node with its distance to the query.
+type hnswCandidate struct {
+	id   uint32
+	dist float32
+}
+
+// candidateHeap is a heap of candidates, closest first unless farthestFirst is set.
+type candidateHeap struct {
+	items         []hnswCandidate
+	farthestFirst bool
+}
+
+func (c *candidateHeap) Len() int { return len(c.items) }
+func (c *candidateHeap) Less(i, j int) bool {
+	if c.farthestFirst {
+		return c.items[i].dist > c.items[j].dist
+	}
+	return c.items[i].dist < c.items[j].dist
+}
+func (c *candidateHeap) Swap(i, j int) { c.items[i], c.items[j] = c.items[j], c.items[i] }
+func (c *candidateHeap) Push(x any)    { c.items = append(c.items, x.(hnswCandidate)) }
+func (c *candidateHeap) Pop() any {
+	last := c.items[len(c.items)-1]
+	c.items = c.items[:len(c.items)-1]
+	return last
+}
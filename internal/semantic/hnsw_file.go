@@ -0,0 +1,338 @@
+package semantic
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"unsafe"
+)
+
+// HNSW file layout (little-endian):
+//
+//	header     64 bytes (see hnswHeader)
+//	namespace  embedder namespace the vectors belong to
+//	vectors    count*dims float32, 64-byte aligned so it can be mapped directly
+//	graph      per node: flags, level, key, then the links of every layer
+const (
+	hnswMagic      = "GKHNSW01"
+	hnswHeaderSize = 64
+	hnswAlign      = 64
+)
+
+// ErrANNNamespaceMismatch is returned when a saved index belongs to another embedder.
+var ErrANNNamespaceMismatch = errors.New("ann index was built by a different embedder")
+
+// hnswHeader is the fixed-size file header.
+type hnswHeader struct {
+	Magic          [8]byte
+	Dims           uint32
+	M              uint32
+	EfConstruction uint32
+	Count          uint32
+	Entry          int32
+	MaxLevel       uint32
+	NamespaceLen   uint32
+	_              uint32
+	VectorsOffset  uint64
+	GraphOffset    uint64
+	GraphLen       uint64
+}
+
+// hostLittleEndian reports whether float32 slices can alias file bytes directly.
+var hostLittleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+// Save writes the index to path atomically. Indexes with many tombstones are
+// compacted first.
+func (h *HNSWIndex) Save(path string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.deleted > 0 && h.deleted*4 >= len(h.nodes) {
+		if err := h.compactLocked(); err != nil {
+			return fmt.Errorf("failed to compact ann index: %w", err)
+		}
+	}
+
+	graph := h.encodeGraphLocked()
+	vectorsOffset := alignUp(hnswHeaderSize+len(h.namespace), hnswAlign)
+	vectorsLen := len(h.nodes) * h.dims * 4
+
+	header := hnswHeader{
+		Dims:           uint32(h.dims),
+		M:              uint32(h.cfg.M),
+		EfConstruction: uint32(h.cfg.EfConstruction),
+		Count:          uint32(len(h.nodes)),
+		Entry:          int32(h.entry),
+		MaxLevel:       uint32(h.maxLevel),
+		NamespaceLen:   uint32(len(h.namespace)),
+		VectorsOffset:  uint64(vectorsOffset),
+		GraphOffset:    uint64(vectorsOffset + vectorsLen),
+		GraphLen:       uint64(len(graph)),
+	}
+	copy(header.Magic[:], hnswMagic)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriterSize(f, 1<<20)
+	err = binary.Write(w, binary.LittleEndian, header)
+	if err == nil {
+		_, err = w.WriteString(h.namespace)
+	}
+	if err == nil {
+		_, err = w.Write(make([]byte, vectorsOffset-hnswHeaderSize-len(h.namespace)))
+	}
+	if err == nil {
+		err = h.writeVectorsLocked(w)
+	}
+	if err == nil {
+		_, err = w.Write(graph)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// The live mapping keeps referencing the old file, so replacing it is safe
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	h.dirty = false
+	return nil
+}
+
+// writeVectorsLocked writes all vectors in node order.
+func (h *HNSWIndex) writeVectorsLocked(w *bufio.Writer) error {
+	buf := make([]byte, h.dims*4)
+	for id := range h.nodes {
+		for i, x := range h.vector(id) {
+			binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(x))
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeGraphLocked serializes node keys and links.
+func (h *HNSWIndex) encodeGraphLocked() []byte {
+	var buf bytes.Buffer
+	var scratch [4]byte
+	putU16 := func(v int) {
+		binary.LittleEndian.PutUint16(scratch[:2], uint16(v))
+		buf.Write(scratch[:2])
+	}
+	putU32 := func(v uint32) {
+		binary.LittleEndian.PutUint32(scratch[:], v)
+		buf.Write(scratch[:])
+	}
+
+	for _, node := range h.nodes {
+		var flags byte
+		if node.deleted {
+			flags = 1
+		}
+		buf.WriteByte(flags)
+		buf.WriteByte(byte(len(node.links) - 1))
+
+		putU16(len(node.key.FilePath))
+		buf.WriteString(node.key.FilePath)
+		putU32(uint32(node.key.LineStart))
+		putU32(uint32(node.key.LineEnd))
+		buf.WriteByte(byte(len(node.key.Hash)))
+		buf.WriteString(node.key.Hash)
+
+		for _, links := range node.links {
+			putU16(len(links))
+			for _, n := range links {
+				putU32(n)
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+// LoadHNSWIndex memory-maps an index saved by Save. The graph is decoded into
+// memory; vectors are read from the mapping. The saved graph parameters are
+// kept until the index is rebuilt; cfg.EfSearch applies immediately.
+func LoadHNSWIndex(path, namespace string, cfg HNSWConfig) (*HNSWIndex, error) {
+	mapping, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := decodeHNSW(mapping, namespace, cfg)
+	if err != nil {
+		_ = mapping.Close()
+		return nil, fmt.Errorf("invalid ann index %s: %w", path, err)
+	}
+	return h, nil
+}
+
+// decodeHNSW builds an index from mapped file contents.
+func decodeHNSW(mapping *mappedFile, namespace string, cfg HNSWConfig) (*HNSWIndex, error) {
+	data := mapping.data
+	if len(data) < hnswHeaderSize {
+		return nil, errors.New("file too short")
+	}
+
+	var header hnswHeader
+	if err := binary.Read(bytes.NewReader(data[:hnswHeaderSize]), binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	if string(header.Magic[:]) != hnswMagic {
+		return nil, errors.New("bad magic")
+	}
+
+	nsEnd := hnswHeaderSize + int(header.NamespaceLen)
+	if nsEnd > len(data) {
+		return nil, errors.New("truncated namespace")
+	}
+	if string(data[hnswHeaderSize:nsEnd]) != namespace {
+		return nil, ErrANNNamespaceMismatch
+	}
+
+	count := int(header.Count)
+	dims := int(header.Dims)
+	vectorsLen := uint64(count) * uint64(dims) * 4
+	if header.VectorsOffset+vectorsLen != header.GraphOffset ||
+		header.GraphOffset+header.GraphLen > uint64(len(data)) {
+		return nil, errors.New("truncated file")
+	}
+
+	h := NewHNSWIndex(namespace, cfg)
+	h.cfg.M = int(header.M)
+	h.cfg.EfConstruction = int(header.EfConstruction)
+	h.cfg = h.cfg.withDefaults()
+	h.levelMult = 1 / math.Log(float64(h.cfg.M))
+	h.dims = dims
+	h.entry = int(header.Entry)
+	h.maxLevel = int(header.MaxLevel)
+	if count == 0 {
+		h.entry = -1
+	}
+
+	vectors := data[header.VectorsOffset:header.GraphOffset]
+	if count > 0 {
+		if hostLittleEndian {
+			h.mapped = unsafe.Slice((*float32)(unsafe.Pointer(&vectors[0])), count*dims)
+		} else {
+			h.mapped = make([]float32, count*dims)
+			for i := range h.mapped {
+				h.mapped[i] = math.Float32frombits(binary.LittleEndian.Uint32(vectors[i*4:]))
+			}
+		}
+	}
+	h.mappedN = count
+	h.mapping = mapping
+
+	nodes, err := decodeGraph(data[header.GraphOffset:header.GraphOffset+header.GraphLen], count)
+	if err != nil {
+		return nil, err
+	}
+	h.nodes = nodes
+	for id, node := range nodes {
+		if node.deleted {
+			h.deleted++
+			continue
+		}
+		h.byFile[node.key.FilePath] = append(h.byFile[node.key.FilePath], uint32(id))
+	}
+	if h.entry >= count {
+		return nil, errors.New("invalid entry point")
+	}
+
+	return h, nil
+}
+
+// decodeGraph parses the graph section written by encodeGraphLocked.
+func decodeGraph(data []byte, count int) ([]hnswNode, error) {
+	errTruncated := errors.New("truncated graph")
+	pos := 0
+	need := func(n int) bool { return pos+n <= len(data) }
+	u16 := func() int {
+		v := binary.LittleEndian.Uint16(data[pos:])
+		pos += 2
+		return int(v)
+	}
+	u32 := func() uint32 {
+		v := binary.LittleEndian.Uint32(data[pos:])
+		pos += 4
+		return v
+	}
+
+	nodes := make([]hnswNode, count)
+	for id := range nodes {
+		if !need(4) {
+			return nil, errTruncated
+		}
+		node := &nodes[id]
+		node.deleted = data[pos]&1 != 0
+		levels := int(data[pos+1]) + 1
+		pos += 2
+
+		pathLen := u16()
+		if !need(pathLen + 9) {
+			return nil, errTruncated
+		}
+		node.key.FilePath = string(data[pos : pos+pathLen])
+		pos += pathLen
+		node.key.LineStart = int(u32())
+		node.key.LineEnd = int(u32())
+		hashLen := int(data[pos])
+		pos++
+		if !need(hashLen) {
+			return nil, errTruncated
+		}
+		node.key.Hash = string(data[pos : pos+hashLen])
+		pos += hashLen
+
+		node.links = make([][]uint32, levels)
+		for l := range node.links {
+			if !need(2) {
+				return nil, errTruncated
+			}
+			n := u16()
+			if !need(n * 4) {
+				return nil, errTruncated
+			}
+			links := make([]uint32, n)
+			for i := range links {
+				links[i] = u32()
+				if int(links[i]) >= count {
+					return nil, errors.New("link out of range")
+				}
+			}
+			node.links[l] = links
+		}
+	}
+	return nodes, nil
+}
+
+// alignUp rounds n up to a multiple of align.
+func alignUp(n, align int) int {
+	return (n + align - 1) / align * align
+}
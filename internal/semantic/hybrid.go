@@ -0,0 +1,282 @@
+package semantic
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	"gokin/internal/logging"
+)
+
+// Search modes of HybridSearch.
+const (
+	SearchModeHybrid  = "hybrid"  // BM25 and vector rankings fused
+	SearchModeVector  = "vector"  // Embedding similarity only
+	SearchModeLexical = "lexical" // BM25 only
+)
+
+// rrfK dampens the advantage of top ranks in reciprocal rank fusion.
+const rrfK = 60
+
+// languageAliases maps common short names to LanguageForPath names.
+var languageAliases = map[string]string{
+	"golang": "go", "py": "python", "js": "javascript", "ts": "typescript",
+	"rs": "rust", "rb": "ruby", "c++": "cpp", "cs": "csharp", "kt": "kotlin",
+	"sh": "shell", "bash": "shell", "md": "markdown", "yml": "yaml",
+}
+
+// SearchFilter restricts search results. Empty fields match everything.
+type SearchFilter struct {
+	PathGlob string // Glob on the project-relative path; without "/" it also matches the base name
+	Language string // Language (go, python, typescript, ...)
+	Kind     string // Symbol kind (function, method, class, struct, interface, type, ...)
+}
+
+// IsZero reports whether the filter matches everything.
+func (f SearchFilter) IsZero() bool {
+	return f.PathGlob == "" && f.Language == "" && f.Kind == ""
+}
+
+// matcher returns a predicate for chunks under workDir, or nil if f matches everything.
+func (f SearchFilter) matcher(workDir string) func(filePath, kind string) bool {
+	if f.IsZero() {
+		return nil
+	}
+
+	language := strings.ToLower(f.Language)
+	if alias, ok := languageAliases[language]; ok {
+		language = alias
+	}
+	kind := strings.ToLower(f.Kind)
+	glob := filepath.ToSlash(f.PathGlob)
+
+	return func(filePath, chunkKind string) bool {
+		if language != "" && LanguageForPath(filePath) != language {
+			return false
+		}
+		if kind != "" && chunkKind != kind {
+			return false
+		}
+		if glob != "" {
+			rel, err := filepath.Rel(workDir, filePath)
+			if err != nil {
+				rel = filePath
+			}
+			rel = filepath.ToSlash(rel)
+			ok, _ := doublestar.Match(glob, rel)
+			if !ok && !strings.Contains(glob, "/") {
+				ok, _ = doublestar.Match(glob, path.Base(rel))
+			}
+			if !ok {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// SearchOptions configures HybridSearch.
+type SearchOptions struct {
+	TopK       int
+	Mode       string // hybrid (default), vector or lexical
+	Filter     SearchFilter
+	Reranker   Reranker // Optional; reorders the best RerankTopN fused results
+	RerankTopN int
+}
+
+// HybridSearch ranks chunks by BM25 and by embedding similarity and fuses both
+// rankings with reciprocal rank fusion. Each result records which signals
+// matched. If the query cannot be embedded, hybrid search falls back to BM25.
+func (i *Indexer) HybridSearch(ctx context.Context, query string, opts SearchOptions) (SearchResults, error) {
+	topK := opts.TopK
+	if topK <= 0 {
+		topK = 10
+	}
+	mode := opts.Mode
+	if mode == "" {
+		mode = SearchModeHybrid
+	}
+	if mode != SearchModeHybrid && mode != SearchModeVector && mode != SearchModeLexical {
+		return nil, fmt.Errorf("unknown search mode: %s", mode)
+	}
+
+	// Rank a deeper pool than requested so fusion and reranking have room to reorder
+	pool := topK * 4
+	if pool < 50 {
+		pool = 50
+	}
+	if opts.Reranker != nil && opts.RerankTopN > pool {
+		pool = opts.RerankTopN
+	}
+	keep := opts.Filter.matcher(i.workDir)
+
+	var vector, lexical SearchResults
+	var lookup time.Duration
+	usedANN := false
+
+	if mode != SearchModeLexical {
+		queryEmbedding, err := i.embedder.Embed(ctx, query)
+		switch {
+		case err == nil:
+			start := time.Now()
+			vector, usedANN = i.vectorCandidates(queryEmbedding, pool, keep)
+			lookup += time.Since(start)
+		case mode == SearchModeVector:
+			return nil, fmt.Errorf("failed to embed query: %w", err)
+		default:
+			logging.Warn("query embedding failed, using keyword search only", "error", err)
+		}
+	}
+	if mode != SearchModeVector {
+		start := time.Now()
+		lexical = i.lexicalCandidates(query, pool, keep)
+		lookup += time.Since(start)
+	}
+	i.recordQuery(lookup, usedANN)
+
+	var results SearchResults
+	switch {
+	case mode == SearchModeVector || len(lexical) == 0:
+		results = vector
+	case len(vector) == 0:
+		results = lexical
+	default:
+		results = fuseRankings(vector, lexical)
+	}
+
+	if opts.Reranker != nil && len(results) > 1 {
+		reranked, err := rerankResults(ctx, opts.Reranker, query, results, opts.RerankTopN)
+		if err != nil {
+			logging.Warn("search reranking failed, keeping fused order", "error", err)
+		} else {
+			results = reranked
+		}
+	}
+
+	if len(results) > topK {
+		results = results[:topK]
+	}
+	return results, nil
+}
+
+// lexicalCandidates returns up to n chunks ranked by BM25 that pass keep.
+func (i *Indexer) lexicalCandidates(query string, n int, keep func(filePath, kind string) bool) SearchResults {
+	matches := i.lexical.Search(query, n, keep)
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	results := make(SearchResults, 0, len(matches))
+	for _, match := range matches {
+		for _, chunk := range i.chunks[match.FilePath] {
+			if chunk.LineStart != match.LineStart {
+				continue
+			}
+			result := newSearchResult(chunk, float32(match.Score))
+			result.LexicalRank = len(results) + 1
+			result.LexicalScore = match.Score
+			result.MatchedTerms = match.Terms
+			results = append(results, result)
+			break
+		}
+	}
+	return results
+}
+
+// fuseRankings merges vector and lexical rankings by reciprocal rank fusion:
+// each list contributes 1/(rrfK+rank) to a chunk's score.
+func fuseRankings(vector, lexical SearchResults) SearchResults {
+	fused := make(map[string]*SearchResult, len(vector)+len(lexical))
+	var order []string
+
+	add := func(result SearchResult, rank int) {
+		key := fmt.Sprintf("%s:%d", result.FilePath, result.LineStart)
+		existing, ok := fused[key]
+		if !ok {
+			result.Score = 0
+			existing = &result
+			fused[key] = existing
+			order = append(order, key)
+		}
+		existing.Score += float32(1 / float64(rrfK+rank))
+		if result.VectorRank > 0 {
+			existing.VectorRank = result.VectorRank
+			existing.VectorScore = result.VectorScore
+		}
+		if result.LexicalRank > 0 {
+			existing.LexicalRank = result.LexicalRank
+			existing.LexicalScore = result.LexicalScore
+			existing.MatchedTerms = result.MatchedTerms
+		}
+	}
+	for _, result := range vector {
+		add(result, result.VectorRank)
+	}
+	for _, result := range lexical {
+		add(result, result.LexicalRank)
+	}
+
+	results := make(SearchResults, 0, len(order))
+	for _, key := range order {
+		results = append(results, *fused[key])
+	}
+	sort.SliceStable(results, func(a, b int) bool {
+		return results[a].Score > results[b].Score
+	})
+	return results
+}
+
+// rerankResults reorders the first topN results by reranker score; the rest keep their order.
+func rerankResults(ctx context.Context, reranker Reranker, query string, results SearchResults, topN int) (SearchResults, error) {
+	if topN <= 0 || topN > len(results) {
+		topN = len(results)
+	}
+	head := make(SearchResults, topN)
+	copy(head, results[:topN])
+
+	scores, err := reranker.Rerank(ctx, query, head)
+	if err != nil {
+		return nil, err
+	}
+	if len(scores) != len(head) {
+		return nil, fmt.Errorf("reranker returned %d scores for %d results", len(scores), len(head))
+	}
+
+	for idx := range head {
+		head[idx].Reranked = true
+		head[idx].RerankScore = scores[idx]
+	}
+	sort.SliceStable(head, func(a, b int) bool {
+		return head[a].RerankScore > head[b].RerankScore
+	})
+	return append(head, results[topN:]...), nil
+}
+
+// SignalSummary describes which signals matched a result, e.g.
+// "vector #2 (0.812) + bm25 #1 (7.41: parse, config) + rerank 8/10".
+func (r SearchResult) SignalSummary() string {
+	var parts []string
+	if r.VectorRank > 0 {
+		parts = append(parts, fmt.Sprintf("vector #%d (%.3f)", r.VectorRank, r.VectorScore))
+	}
+	if r.LexicalRank > 0 {
+		part := fmt.Sprintf("bm25 #%d (%.2f", r.LexicalRank, r.LexicalScore)
+		if len(r.MatchedTerms) > 0 {
+			part += ": " + strings.Join(r.MatchedTerms, ", ")
+		}
+		parts = append(parts, part+")")
+	}
+	if r.Reranked {
+		parts = append(parts, fmt.Sprintf("rerank %.0f/10", r.RerankScore))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, " + ")
+}
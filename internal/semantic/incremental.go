@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
@@ -143,12 +144,9 @@ func (i *IncrementalIndexer) IndexChanged(ctx context.Context, dir string) (*Ind
 	stats.DeletedFiles = len(deletedFiles)
 
 	// Remove deleted files from index
-	i.stateMu.Lock()
 	for _, path := range deletedFiles {
-		delete(i.fileStates, path)
-		delete(i.chunks, path)
+		i.RemoveFile(path)
 	}
-	i.stateMu.Unlock()
 
 	// Combine files to index
 	filesToIndex := make([]string, 0, len(newFiles)+len(modifiedFiles))
@@ -250,9 +248,7 @@ func (i *IncrementalIndexer) indexFilesParallel(ctx context.Context, files []str
 			continue
 		}
 		if len(res.chunks) > 0 {
-			i.mu.Lock()
-			i.chunks[res.path] = res.chunks
-			i.mu.Unlock()
+			i.setFileChunks(res.path, res.chunks)
 			chunksIndexed += len(res.chunks)
 			embedBatches += res.embedBatch
 		}
@@ -390,6 +386,30 @@ func (i *IncrementalIndexer) updateFileStates(files []string) {
 	}
 }
 
+// IndexFile indexes a single changed file. A file that no longer exists is
+// removed from the index instead.
+func (i *IncrementalIndexer) IndexFile(ctx context.Context, filePath string) error {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		i.RemoveFile(filePath)
+		return nil
+	}
+
+	if err := i.EnhancedIndexer.IndexFile(ctx, filePath); err != nil {
+		return err
+	}
+	i.updateFileStates([]string{filePath})
+	return nil
+}
+
+// RemoveFile drops a file from the index, the ANN index and the tracked states.
+func (i *IncrementalIndexer) RemoveFile(filePath string) {
+	i.stateMu.Lock()
+	delete(i.fileStates, filePath)
+	i.stateMu.Unlock()
+
+	i.removeFileChunks(filePath)
+}
+
 // GetFileStates returns a copy of current file states.
 func (i *IncrementalIndexer) GetFileStates() map[string]FileState {
 	i.stateMu.RLock()
@@ -442,7 +462,7 @@ func (i *IncrementalIndexer) SetWorkers(workers int) {
 
 // chunkCacheKey generates a cache key for a chunk.
 func chunkCacheKey(filePath string, lineStart int) string {
-	return filePath + ":" + string(rune(lineStart))
+	return fmt.Sprintf("%s:%d", filePath, lineStart)
 }
 
 // ReindexAll forces a full re-index, ignoring cached states.
@@ -452,9 +472,7 @@ func (i *IncrementalIndexer) ReindexAll(ctx context.Context, dir string) (*Index
 	i.fileStates = make(map[string]FileState)
 	i.stateMu.Unlock()
 
-	i.mu.Lock()
-	i.chunks = make(map[string][]ChunkInfo)
-	i.mu.Unlock()
+	i.resetChunks()
 
 	// Now index as if all files are new
 	return i.IndexChanged(ctx, dir)
@@ -8,6 +8,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"gokin/internal/git"
 )
@@ -19,25 +20,38 @@ type ChunkInfo struct {
 	LineEnd   int
 	Content   string
 	Embedding []float32
+	Kind      string // Symbol kind (function, method, class, type, ...); empty for plain blocks
+	Symbol    string // Name of the declared symbol, if any
 }
 
 // Indexer manages file indexing for semantic search.
 type Indexer struct {
-	embedder    *Embedder
+	embedder    Embedder
 	workDir     string
 	cache       *EmbeddingCache
 	gitIgnore   *git.GitIgnore
 	maxFileSize int64
 	chunker     Chunker
 	chunks      map[string][]ChunkInfo // filePath -> chunks
+	lexical     *BM25Index             // Keyword index over the same chunks
 	mu          sync.RWMutex
+
+	ann          *HNSWIndex // Approximate index (nil = scan all chunks)
+	annMinChunks int        // Scan all chunks below this size
+	queryStats   QueryStats
+	statsMu      sync.Mutex
 }
 
 // NewIndexer creates a new indexer.
-func NewIndexer(embedder *Embedder, workDir string, cache *EmbeddingCache, maxFileSize int64) *Indexer {
+func NewIndexer(embedder Embedder, workDir string, cache *EmbeddingCache, maxFileSize int64) *Indexer {
 	gitIgnore := git.NewGitIgnore(workDir)
 	_ = gitIgnore.Load() // Ignore error - gitignore is optional
 
+	// Keep cached vectors of other embedders out of this index
+	if cache != nil {
+		cache.SetNamespace(EmbedderNamespace(embedder))
+	}
+
 	return &Indexer{
 		embedder:    embedder,
 		workDir:     workDir,
@@ -46,6 +60,7 @@ func NewIndexer(embedder *Embedder, workDir string, cache *EmbeddingCache, maxFi
 		maxFileSize: maxFileSize,
 		chunker:     NewStructuralChunker(50, 10),
 		chunks:      make(map[string][]ChunkInfo),
+		lexical:     NewBM25Index(),
 	}
 }
 
@@ -114,9 +129,7 @@ func (i *Indexer) IndexFile(ctx context.Context, filePath string) error {
 	}
 
 	// Store indexed chunks
-	i.mu.Lock()
-	i.chunks[filePath] = indexedChunks
-	i.mu.Unlock()
+	i.setFileChunks(filePath, indexedChunks)
 
 	return nil
 }
@@ -156,33 +169,105 @@ func (i *Indexer) Search(ctx context.Context, query string, topK int) ([]SearchR
 		return nil, fmt.Errorf("failed to embed query: %w", err)
 	}
 
-	// Search all chunks
+	start := time.Now()
+	results, usedANN := i.vectorCandidates(queryEmbedding, topK, nil)
+	i.recordQuery(time.Since(start), usedANN)
+
+	return results, nil
+}
+
+// vectorCandidates returns up to n chunks closest to queryEmbedding that pass
+// keep (nil keeps all). Large indexes are searched through the HNSW graph.
+func (i *Indexer) vectorCandidates(queryEmbedding []float32, n int, keep func(filePath, kind string) bool) (SearchResults, bool) {
 	i.mu.RLock()
+	ann := i.ann
+	useANN := ann != nil && len(i.chunks) > 0 && ann.Len() >= i.annMinChunks
+	i.mu.RUnlock()
+
 	var results SearchResults
-	for _, chunks := range i.chunks {
-		for _, chunk := range chunks {
-			if chunk.Embedding == nil {
-				continue
+	if useANN {
+		// Over-fetch so filtering still leaves enough results
+		fetch := n
+		if keep != nil {
+			fetch = n * 8
+		}
+		for _, result := range i.searchANN(ann, queryEmbedding, fetch) {
+			if keep == nil || keep(result.FilePath, result.Kind) {
+				results = append(results, result)
+			}
+		}
+	} else {
+		// Search all chunks
+		i.mu.RLock()
+		for _, chunks := range i.chunks {
+			for _, chunk := range chunks {
+				if chunk.Embedding == nil {
+					continue
+				}
+				if keep != nil && !keep(chunk.FilePath, chunk.Kind) {
+					continue
+				}
+				score := CosineSimilarity(queryEmbedding, chunk.Embedding)
+				results = append(results, newSearchResult(chunk, score))
 			}
-			score := CosineSimilarity(queryEmbedding, chunk.Embedding)
-			results = append(results, SearchResult{
-				FilePath:  chunk.FilePath,
-				Score:     score,
-				Content:   chunk.Content,
-				LineStart: chunk.LineStart,
-				LineEnd:   chunk.LineEnd,
-			})
 		}
+		i.mu.RUnlock()
+		sort.Sort(results)
 	}
-	i.mu.RUnlock()
 
-	// Sort by score and take top K
-	sort.Sort(results)
-	if len(results) > topK {
-		results = results[:topK]
+	// Take top N
+	if len(results) > n {
+		results = results[:n]
+	}
+	for rank := range results {
+		results[rank].VectorRank = rank + 1
+		results[rank].VectorScore = results[rank].Score
 	}
+	return results, useANN
+}
 
-	return results, nil
+// setFileChunks replaces the indexed chunks of a file.
+func (i *Indexer) setFileChunks(filePath string, chunks []ChunkInfo) {
+	i.mu.Lock()
+	i.chunks[filePath] = chunks
+	ann := i.ann
+	i.mu.Unlock()
+
+	i.lexical.ReplaceFile(filePath, chunks)
+	if ann != nil {
+		i.syncANNFile(ann, filePath, chunks)
+	}
+}
+
+// removeFileChunks drops a file from the index.
+func (i *Indexer) removeFileChunks(filePath string) {
+	i.mu.Lock()
+	delete(i.chunks, filePath)
+	ann := i.ann
+	i.mu.Unlock()
+
+	i.lexical.RemoveFile(filePath)
+	if ann != nil {
+		ann.DeleteFile(filePath)
+	}
+}
+
+// resetChunks drops all indexed chunks.
+func (i *Indexer) resetChunks() {
+	i.mu.Lock()
+	i.chunks = make(map[string][]ChunkInfo)
+	ann := i.ann
+	i.mu.Unlock()
+
+	i.lexical.Reset()
+	if ann != nil {
+		ann.Reset()
+	}
+}
+
+// GetEmbedder returns the embedder used by the index.
+func (i *Indexer) GetEmbedder() Embedder {
+	return i.embedder
 }
 
 // GetIndexedFileCount returns the number of indexed files.
@@ -0,0 +1,28 @@
+//go:build !unix
+
+package semantic
+
+import "os"
+
+// mappedFile is a read-only view of a file's contents.
+// Without mmap support the file is read into memory.
+type mappedFile struct {
+	data []byte
+}
+
+// mapFile reads a file into memory.
+func mapFile(path string) (*mappedFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &mappedFile{data: data}, nil
+}
+
+// Close releases the contents. It is safe to call on a nil mapping.
+func (m *mappedFile) Close() error {
+	if m != nil {
+		m.data = nil
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+//go:build unix
+
+package semantic
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// mappedFile is a read-only view of a file's contents.
+type mappedFile struct {
+	data []byte
+}
+
+// mapFile maps a file read-only into memory.
+func mapFile(path string) (*mappedFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() == 0 {
+		return &mappedFile{}, nil
+	}
+
+	data, err := unix.Mmap(int(f.Fd()), 0, int(info.Size()), unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	return &mappedFile{data: data}, nil
+}
+
+// Close unmaps the file. It is safe to call on a nil mapping.
+func (m *mappedFile) Close() error {
+	if m == nil || m.data == nil {
+		return nil
+	}
+	err := unix.Munmap(m.data)
+	m.data = nil
+	return err
+}
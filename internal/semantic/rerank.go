@@ -0,0 +1,85 @@
+package semantic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Reranker scores how relevant candidate chunks are to a query.
+type Reranker interface {
+	// Rerank returns one score per candidate; higher is more relevant.
+	Rerank(ctx context.Context, query string, candidates []SearchResult) ([]float64, error)
+}
+
+// CompletionFunc sends a prompt to a language model and returns its reply.
+type CompletionFunc func(ctx context.Context, prompt string) (string, error)
+
+// maxRerankLines limits how much of each candidate is shown to the model.
+const maxRerankLines = 40
+
+// LLMReranker grades candidates with a language model on a 0-10 scale.
+type LLMReranker struct {
+	complete CompletionFunc
+	workDir  string
+}
+
+// NewLLMReranker creates a reranker backed by a model completion function.
+func NewLLMReranker(complete CompletionFunc, workDir string) *LLMReranker {
+	return &LLMReranker{complete: complete, workDir: workDir}
+}
+
+// Rerank asks the model to grade every candidate in a single request.
+// Candidates the model does not grade score 0.
+func (r *LLMReranker) Rerank(ctx context.Context, query string, candidates []SearchResult) ([]float64, error) {
+	reply, err := r.complete(ctx, r.buildPrompt(query, candidates))
+	if err != nil {
+		return nil, err
+	}
+
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("reranker reply contains no JSON object")
+	}
+	var grades map[string]float64
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &grades); err != nil {
+		return nil, fmt.Errorf("invalid reranker reply: %w", err)
+	}
+
+	scores := make([]float64, len(candidates))
+	for key, grade := range grades {
+		n, err := strconv.Atoi(strings.TrimSpace(key))
+		if err != nil || n < 1 || n > len(candidates) {
+			continue
+		}
+		scores[n-1] = grade
+	}
+	return scores, nil
+}
+
+// buildPrompt lists the numbered candidates and asks for JSON grades.
+func (r *LLMReranker) buildPrompt(query string, candidates []SearchResult) string {
+	var sb strings.Builder
+	sb.WriteString("You are ranking code search results. Grade how well each candidate answers the query ")
+	sb.WriteString("from 0 (irrelevant) to 10 (exactly what is searched for).\n\n")
+	sb.WriteString(fmt.Sprintf("Query: %s\n\n", query))
+
+	for idx, c := range candidates {
+		rel, err := filepath.Rel(r.workDir, c.FilePath)
+		if err != nil {
+			rel = c.FilePath
+		}
+		content := c.Content
+		if lines := strings.Split(content, "\n"); len(lines) > maxRerankLines {
+			content = strings.Join(lines[:maxRerankLines], "\n") + "\n..."
+		}
+		sb.WriteString(fmt.Sprintf("[%d] %s (lines %d-%d)\n```\n%s\n```\n\n", idx+1, rel, c.LineStart, c.LineEnd, content))
+	}
+
+	sb.WriteString(`Respond with only a JSON object mapping candidate numbers to grades, e.g. {"1": 8, "2": 0}.`)
+	return sb.String()
+}
@@ -27,10 +27,36 @@ func CosineSimilarity(a, b []float32) float32 {
 // SearchResult represents a search result with similarity score.
 type SearchResult struct {
 	FilePath  string  // Path to the file
-	Score     float32 // Similarity score (0-1)
+	Score     float32 // Similarity score (0-1); fused score in hybrid search
 	Content   string  // Matched content chunk
 	LineStart int     // Starting line number
 	LineEnd   int     // Ending line number
+	Language  string  // Language of the file
+	Kind      string  // Symbol kind of the chunk, if known
+	Symbol    string  // Symbol declared by the chunk, if known
+
+	// Signals that matched the query
+	VectorRank   int      // 1-based rank among vector matches (0 = no match)
+	VectorScore  float32  // Cosine similarity
+	LexicalRank  int      // 1-based rank among BM25 matches (0 = no match)
+	LexicalScore float64  // BM25 score
+	MatchedTerms []string // Query terms found by BM25
+	Reranked     bool     // RerankScore was set by a reranker
+	RerankScore  float64  // Reranker relevance (0-10 for the LLM reranker)
+}
+
+// newSearchResult creates a result for a chunk.
+func newSearchResult(chunk ChunkInfo, score float32) SearchResult {
+	return SearchResult{
+		FilePath:  chunk.FilePath,
+		Score:     score,
+		Content:   chunk.Content,
+		LineStart: chunk.LineStart,
+		LineEnd:   chunk.LineEnd,
+		Language:  LanguageForPath(chunk.FilePath),
+		Kind:      chunk.Kind,
+		Symbol:    chunk.Symbol,
+	}
 }
 
 // SearchResults is a sortable slice of SearchResult.
@@ -0,0 +1,113 @@
+package semantic
+
+import (
+	"sort"
+	"strings"
+)
+
+// SymbolInfo describes a symbol declared in an indexed chunk.
+type SymbolInfo struct {
+	Name      string // Declared name; methods are Type.Method
+	Kind      string // function, method, struct, ...
+	FilePath  string
+	LineStart int
+	LineEnd   int
+	Content   string // Source of the declaring chunk
+}
+
+// Symbols returns up to limit indexed symbols matching query, best first:
+// exact names, then methods named query, then prefixes, substrings and
+// fuzzy (subsequence) matches. Matching ignores case.
+func (i *Indexer) Symbols(query string, limit int) []SymbolInfo {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" || limit <= 0 {
+		return nil
+	}
+
+	type scored struct {
+		info SymbolInfo
+		rank int
+	}
+	var matches []scored
+
+	i.mu.RLock()
+	for filePath, chunks := range i.chunks {
+		for _, c := range chunks {
+			if c.Symbol == "" {
+				continue
+			}
+			rank := symbolRank(strings.ToLower(c.Symbol), query)
+			if rank < 0 {
+				continue
+			}
+			matches = append(matches, scored{
+				info: SymbolInfo{
+					Name:      c.Symbol,
+					Kind:      c.Kind,
+					FilePath:  filePath,
+					LineStart: c.LineStart,
+					LineEnd:   c.LineEnd,
+					Content:   c.Content,
+				},
+				rank: rank,
+			})
+		}
+	}
+	i.mu.RUnlock()
+
+	sort.Slice(matches, func(a, b int) bool {
+		if matches[a].rank != matches[b].rank {
+			return matches[a].rank < matches[b].rank
+		}
+		if len(matches[a].info.Name) != len(matches[b].info.Name) {
+			return len(matches[a].info.Name) < len(matches[b].info.Name)
+		}
+		if matches[a].info.Name != matches[b].info.Name {
+			return matches[a].info.Name < matches[b].info.Name
+		}
+		return matches[a].info.FilePath < matches[b].info.FilePath
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	result := make([]SymbolInfo, len(matches))
+	for j, m := range matches {
+		result[j] = m.info
+	}
+	return result
+}
+
+// symbolRank ranks how well a lowercased symbol name matches query, lower
+// is better; -1 means no match.
+func symbolRank(name, query string) int {
+	member := name
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		member = name[dot+1:]
+	}
+
+	switch {
+	case name == query:
+		return 0
+	case member == query:
+		return 1
+	case strings.HasPrefix(name, query), strings.HasPrefix(member, query):
+		return 2
+	case strings.Contains(name, query):
+		return 3
+	case isSubsequence(name, query):
+		return 4
+	}
+	return -1
+}
+
+// isSubsequence reports whether the characters of query appear in s in order.
+func isSubsequence(s, query string) bool {
+	qi := 0
+	for i := 0; i < len(s) && qi < len(query); i++ {
+		if s[i] == query[qi] {
+			qi++
+		}
+	}
+	return qi == len(query)
+}
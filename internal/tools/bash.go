@@ -3,6 +3,7 @@ package tools
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -153,6 +154,8 @@ type BashTool struct {
 	taskManager      *tasks.Manager
 	timeout          time.Duration // Explicit timeout for commands
 	sandboxEnabled   bool          // Enable sandboxing for bash commands
+	sandboxNoNetwork bool          // Isolate sandboxed commands from the network
+	allowedDirs      []string      // Additional writable directories inside the sandbox
 	unrestrictedMode bool          // Skip command validation when both sandbox and permissions are off
 }
 
@@ -162,7 +165,7 @@ func NewBashTool(workDir string) *BashTool {
 		workDir:        workDir,
 		session:        NewBashSession(workDir),
 		timeout:        DefaultBashTimeout, // Set default timeout
-		sandboxEnabled: false,              // Sandbox disabled by default (requires root)
+		sandboxEnabled: false,              // Sandbox disabled by default
 	}
 }
 
@@ -211,12 +214,32 @@ func (t *BashTool) SetTaskManager(manager *tasks.Manager) {
 }
 
 // SetSandboxEnabled enables or disables sandbox mode.
-// When enabled, commands run in a Linux namespace sandbox (requires root).
-// When disabled, commands run directly without isolation.
+// When enabled, commands run in an unprivileged Linux sandbox (namespaces,
+// seccomp and Landlock where available). When disabled, commands run directly
+// without isolation.
 func (t *BashTool) SetSandboxEnabled(enabled bool) {
 	t.sandboxEnabled = enabled
 }
 
+// SetSandboxNetworkDisabled runs sandboxed commands without network access.
+func (t *BashTool) SetSandboxNetworkDisabled(disabled bool) {
+	t.sandboxNoNetwork = disabled
+}
+
+// SetAllowedDirs sets additional directories that stay writable inside the sandbox.
+func (t *BashTool) SetAllowedDirs(dirs []string) {
+	t.allowedDirs = dirs
+}
+
+// SandboxConfig returns the sandbox configuration used for commands.
+func (t *BashTool) SandboxConfig() security.SandboxConfig {
+	cfg := security.DefaultSandboxConfig()
+	cfg.Enabled = t.sandboxEnabled
+	cfg.WritableDirs = t.allowedDirs
+	cfg.DisableNetwork = t.sandboxNoNetwork
+	return cfg
+}
+
 // SetUnrestrictedMode enables or disables unrestricted mode.
 // When enabled (both sandbox and permissions are off), command validation is skipped.
 func (t *BashTool) SetUnrestrictedMode(enabled bool) {
@@ -297,6 +320,16 @@ func (t *BashTool) Validate(args map[string]any) error {
 		return NewValidationError("command", "is required")
 	}
 
+	if err := t.CheckCommand(command); err != nil {
+		return NewValidationError("command", fmt.Sprintf("blocked: %s", err))
+	}
+
+	return nil
+}
+
+// CheckCommand runs the command validator on a command, unless the tool is
+// in unrestricted mode. Contract verification uses it for clause commands.
+func (t *BashTool) CheckCommand(command string) error {
 	// Skip command validation in unrestricted mode (sandbox=off + permissions=off)
 	if t.unrestrictedMode {
 		return nil
@@ -305,9 +338,8 @@ func (t *BashTool) Validate(args map[string]any) error {
 	// Use unified command validator for comprehensive security checks
 	result := security.ValidateCommand(command)
 	if !result.Valid {
-		return NewValidationError("command", fmt.Sprintf("blocked: %s", result.Reason))
+		return errors.New(result.Reason)
 	}
-
 	return nil
 }
 
@@ -773,7 +805,7 @@ func (t *BashTool) buildResult(stdoutStr, stderrStr string) ToolResult {
 // executeSandboxed executes the command with sandbox isolation
 func (t *BashTool) executeSandboxed(ctx context.Context, command string) (ToolResult, error) {
 	// Create sandbox configuration
-	sandboxConfig := security.DefaultSandboxConfig()
+	sandboxConfig := t.SandboxConfig()
 	sandboxConfig.Enabled = true
 
 	// Create sandboxed command
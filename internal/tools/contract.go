@@ -0,0 +1,397 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gokin/internal/contract"
+
+	"google.golang.org/genai"
+)
+
+// contractClauseSchema describes a single clause argument.
+func contractClauseSchema(description string) *genai.Schema {
+	return &genai.Schema{
+		Type:        genai.TypeArray,
+		Description: description,
+		Items: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"name": {
+					Type:        genai.TypeString,
+					Description: "Short identifier for the clause",
+				},
+				"description": {
+					Type:        genai.TypeString,
+					Description: "What must hold",
+				},
+				"command": {
+					Type:        genai.TypeString,
+					Description: "Shell command that verifies the clause (run in the project root)",
+				},
+				"expected_output": {
+					Type:        genai.TypeString,
+					Description: "Expected command output (or exit code for match_type exit_code)",
+				},
+				"match_type": {
+					Type:        genai.TypeString,
+					Description: "How to compare the output (default: contains, or exit_code when expected_output is empty)",
+					Enum:        []string{contract.MatchContains, contract.MatchExact, contract.MatchRegex, contract.MatchExitCode},
+				},
+			},
+			Required: []string{"name"},
+		},
+	}
+}
+
+// contractBoundarySchema describes the boundaries argument.
+func contractBoundarySchema() *genai.Schema {
+	return &genai.Schema{
+		Type:        genai.TypeArray,
+		Description: "Inputs, outputs and side effects of the change",
+		Items: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"type": {
+					Type:        genai.TypeString,
+					Description: "Boundary type (input, output, side_effect)",
+				},
+				"name": {
+					Type:        genai.TypeString,
+					Description: "Name of the input, output or side effect",
+				},
+				"description": {
+					Type:        genai.TypeString,
+					Description: "Description of the boundary",
+				},
+			},
+			Required: []string{"name"},
+		},
+	}
+}
+
+// contractClauseArgs maps tool arguments to clause kinds.
+var contractClauseArgs = []struct {
+	key  string
+	kind contract.ClauseKind
+}{
+	{"preconditions", contract.KindPrecondition},
+	{"postconditions", contract.KindPostcondition},
+	{"invariants", contract.KindInvariant},
+	{"examples", contract.KindExample},
+}
+
+// hasContractArgs returns true if args contain a contract definition.
+func hasContractArgs(args map[string]any) bool {
+	if name, ok := GetString(args, "contract_name"); ok && strings.TrimSpace(name) != "" {
+		return true
+	}
+	return false
+}
+
+// parseContractArgs builds a contract from tool arguments.
+// nameKey is the argument holding the contract name.
+func parseContractArgs(args map[string]any, nameKey string) *contract.Contract {
+	name, _ := GetString(args, nameKey)
+	intent, _ := GetString(args, "intent")
+	c := contract.NewContract(strings.TrimSpace(name), intent)
+
+	if raw, ok := args["boundaries"].([]any); ok {
+		for _, item := range raw {
+			b, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			boundary := contract.Boundary{}
+			boundary.Type, _ = b["type"].(string)
+			boundary.Name, _ = b["name"].(string)
+			boundary.Description, _ = b["description"].(string)
+			c.Boundaries = append(c.Boundaries, boundary)
+		}
+	}
+
+	for _, arg := range contractClauseArgs {
+		raw, ok := args[arg.key].([]any)
+		if !ok {
+			continue
+		}
+		for _, item := range raw {
+			m, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			clause := contract.Clause{Kind: arg.kind}
+			clause.Name, _ = m["name"].(string)
+			clause.Description, _ = m["description"].(string)
+			clause.Command, _ = m["command"].(string)
+			clause.ExpectedOutput, _ = m["expected_output"].(string)
+			clause.MatchType, _ = m["match_type"].(string)
+			c.Clauses = append(c.Clauses, clause)
+		}
+	}
+
+	return c
+}
+
+// ContractProposeTool lets the model propose a contract for a change.
+type ContractProposeTool struct {
+	manager *contract.Manager
+}
+
+// NewContractProposeTool creates a new contract propose tool.
+func NewContractProposeTool(manager *contract.Manager) *ContractProposeTool {
+	return &ContractProposeTool{manager: manager}
+}
+
+// SetManager sets the contract manager.
+func (t *ContractProposeTool) SetManager(manager *contract.Manager) {
+	t.manager = manager
+}
+
+func (t *ContractProposeTool) Name() string {
+	return "contract_propose"
+}
+
+func (t *ContractProposeTool) Description() string {
+	return "Propose a contract for a change (preconditions, postconditions, invariants and examples with verification commands) and request user approval. The approved contract stays in context and is verified after implementation."
+}
+
+func (t *ContractProposeTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"name": {
+					Type:        genai.TypeString,
+					Description: "Short identifier for the contract (e.g., 'email_validator')",
+				},
+				"intent": {
+					Type:        genai.TypeString,
+					Description: "One sentence describing what the change must achieve",
+				},
+				"boundaries":     contractBoundarySchema(),
+				"preconditions":  contractClauseSchema("Conditions that must hold before the change"),
+				"postconditions": contractClauseSchema("Conditions that must hold after the change"),
+				"invariants":     contractClauseSchema("Conditions that must keep holding (e.g., existing tests pass)"),
+				"examples":       contractClauseSchema("Concrete input/output examples, ideally with a command that checks them"),
+			},
+			Required: []string{"name", "intent"},
+		},
+	}
+}
+
+func (t *ContractProposeTool) Validate(args map[string]any) error {
+	if name, ok := GetString(args, "name"); !ok || strings.TrimSpace(name) == "" {
+		return NewValidationError("name", "name is required")
+	}
+	if _, ok := GetString(args, "intent"); !ok {
+		return NewValidationError("intent", "intent is required")
+	}
+	if err := parseContractArgs(args, "name").Validate(); err != nil {
+		return NewValidationError("contract", err.Error())
+	}
+	return nil
+}
+
+func (t *ContractProposeTool) Execute(ctx context.Context, args map[string]any) (ToolResult, error) {
+	if t.manager == nil {
+		return NewErrorResult("contract system is disabled in configuration"), nil
+	}
+
+	c := parseContractArgs(args, "name")
+	decision, err := t.manager.Propose(ctx, c)
+	if err != nil {
+		return NewErrorResult(fmt.Sprintf("contract proposal failed: %s", err)), nil
+	}
+
+	switch decision {
+	case contract.ApprovalApproved:
+		msg := fmt.Sprintf("Contract approved: %s\n%d of %d clauses have verification commands.",
+			c.Name, c.VerifiableCount(), len(c.Clauses))
+		if t.manager.AutoVerify() {
+			msg += " They will run automatically after implementation; you can also call contract_verify."
+		} else {
+			msg += " Call contract_verify when the implementation is done."
+		}
+		return NewSuccessResultWithData(msg, map[string]any{
+			"approved":    true,
+			"contract_id": c.ID,
+			"decision":    "approved",
+		}), nil
+
+	case contract.ApprovalModified:
+		return NewSuccessResultWithData(
+			"User requested modifications to the contract. Please revise and resubmit.",
+			map[string]any{
+				"approved": false,
+				"decision": "modification_requested",
+			},
+		), nil
+
+	default:
+		return NewSuccessResultWithData(
+			"Contract rejected by user. Please ask for clarification or propose a different contract.",
+			map[string]any{
+				"approved": false,
+				"decision": "rejected",
+			},
+		), nil
+	}
+}
+
+// ContractVerifyTool runs the verification commands of the active contract.
+type ContractVerifyTool struct {
+	manager *contract.Manager
+}
+
+// NewContractVerifyTool creates a new contract verify tool.
+func NewContractVerifyTool(manager *contract.Manager) *ContractVerifyTool {
+	return &ContractVerifyTool{manager: manager}
+}
+
+// SetManager sets the contract manager.
+func (t *ContractVerifyTool) SetManager(manager *contract.Manager) {
+	t.manager = manager
+}
+
+func (t *ContractVerifyTool) Name() string {
+	return "contract_verify"
+}
+
+func (t *ContractVerifyTool) Description() string {
+	return "Run the verification commands of the active contract and report pass/fail per clause"
+}
+
+func (t *ContractVerifyTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &genai.Schema{
+			Type:       genai.TypeObject,
+			Properties: map[string]*genai.Schema{},
+		},
+	}
+}
+
+func (t *ContractVerifyTool) Validate(args map[string]any) error {
+	return nil
+}
+
+func (t *ContractVerifyTool) Execute(ctx context.Context, args map[string]any) (ToolResult, error) {
+	if t.manager == nil {
+		return NewErrorResult("contract system is disabled in configuration"), nil
+	}
+
+	c, report, err := t.manager.Verify(ctx)
+	if err != nil {
+		return NewErrorResult(fmt.Sprintf("contract verification failed: %s", err)), nil
+	}
+
+	passed, failed, unverified := report.Counts()
+	content := fmt.Sprintf("Contract %s: %s\n\n%s", c.Name, c.Status, report.Format())
+	data := map[string]any{
+		"contract_id": c.ID,
+		"status":      c.Status.String(),
+		"passed":      passed,
+		"failed":      failed,
+		"unverified":  unverified,
+	}
+	if failed > 0 {
+		content += "\nFix the failing clauses and run contract_verify again."
+	}
+	return NewSuccessResultWithData(content, data), nil
+}
+
+// ContractStatusTool shows the active contract or a stored one.
+type ContractStatusTool struct {
+	manager *contract.Manager
+}
+
+// NewContractStatusTool creates a new contract status tool.
+func NewContractStatusTool(manager *contract.Manager) *ContractStatusTool {
+	return &ContractStatusTool{manager: manager}
+}
+
+// SetManager sets the contract manager.
+func (t *ContractStatusTool) SetManager(manager *contract.Manager) {
+	t.manager = manager
+}
+
+func (t *ContractStatusTool) Name() string {
+	return "contract_status"
+}
+
+func (t *ContractStatusTool) Description() string {
+	return "Show the active contract with its last verification results, or list recent contracts"
+}
+
+func (t *ContractStatusTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"contract_id": {
+					Type:        genai.TypeString,
+					Description: "ID of a specific contract (default: the active contract)",
+				},
+				"list": {
+					Type:        genai.TypeBoolean,
+					Description: "List recent contracts instead of showing one",
+				},
+			},
+		},
+	}
+}
+
+func (t *ContractStatusTool) Validate(args map[string]any) error {
+	return nil
+}
+
+func (t *ContractStatusTool) Execute(ctx context.Context, args map[string]any) (ToolResult, error) {
+	if t.manager == nil {
+		return NewErrorResult("contract system is disabled in configuration"), nil
+	}
+
+	if GetBoolDefault(args, "list", false) {
+		contracts, err := t.manager.List()
+		if err != nil {
+			return NewErrorResult(err.Error()), nil
+		}
+		if len(contracts) == 0 {
+			return NewSuccessResult("No contracts."), nil
+		}
+		var sb strings.Builder
+		for i, c := range contracts {
+			if i >= 20 {
+				sb.WriteString(fmt.Sprintf("... and %d more\n", len(contracts)-i))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("%s  %-10s  %s\n", c.ID, c.Status, c.Name))
+		}
+		return NewSuccessResult(sb.String()), nil
+	}
+
+	var c *contract.Contract
+	if id, ok := GetString(args, "contract_id"); ok && id != "" {
+		var err error
+		if c, err = t.manager.Get(id); err != nil {
+			return NewErrorResult(err.Error()), nil
+		}
+	} else if c = t.manager.Active(); c == nil {
+		return NewSuccessResult("No active contract. Use contract_propose to create one."), nil
+	}
+
+	content := c.Format()
+	if c.LastVerification != nil {
+		content += "\n" + c.LastVerification.Format()
+	}
+	return NewSuccessResultWithData(content, map[string]any{
+		"contract_id": c.ID,
+		"status":      c.Status.String(),
+	}), nil
+}
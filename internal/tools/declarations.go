@@ -610,28 +610,57 @@ func BatchToolDeclaration() *genai.FunctionDeclaration {
 func RefactorToolDeclaration() *genai.FunctionDeclaration {
 	return &genai.FunctionDeclaration{
 		Name:        "refactor",
-		Description: "Refactors code across multiple files (rename, extract, etc.).",
+		Description: "Refactors code: rename, extract, inline, find references. Go rename and find_refs are type-checked across the whole module including tests.",
 		Parameters: &genai.Schema{
 			Type: genai.TypeObject,
 			Properties: map[string]*genai.Schema{
 				"operation": {
 					Type:        genai.TypeString,
-					Description: "The refactoring operation (rename, extract_function, inline, etc.)",
+					Description: "The refactoring operation",
+					Enum:        []string{"rename", "extract", "inline", "find_refs"},
 				},
-				"target": {
+				"file_path": {
 					Type:        genai.TypeString,
-					Description: "The target identifier to refactor",
+					Description: "The file to refactor; for Go rename/find_refs, the file containing the identifier",
+				},
+				"line": {
+					Type:        genai.TypeInteger,
+					Description: "Line of the Go identifier (rename/find_refs)",
+				},
+				"column": {
+					Type:        genai.TypeInteger,
+					Description: "Column of the Go identifier on line (rename/find_refs)",
+				},
+				"pattern": {
+					Type:        genai.TypeString,
+					Description: "Glob pattern for multi-file operations on non-Go files",
+				},
+				"old_name": {
+					Type:        genai.TypeString,
+					Description: "Current name (for rename)",
 				},
 				"new_name": {
 					Type:        genai.TypeString,
-					Description: "The new name (for rename operations)",
+					Description: "The new name (for rename)",
 				},
-				"scope": {
+				"extract_name": {
 					Type:        genai.TypeString,
-					Description: "The scope of the refactoring (file, package, project)",
+					Description: "Name for the extracted function (for extract)",
+				},
+				"start_line": {
+					Type:        genai.TypeInteger,
+					Description: "Start line for extraction (for extract)",
+				},
+				"end_line": {
+					Type:        genai.TypeInteger,
+					Description: "End line for extraction (for extract)",
+				},
+				"target_name": {
+					Type:        genai.TypeString,
+					Description: "Name to find references to or inline (for find_refs/inline)",
 				},
 			},
-			Required: []string{"operation", "target"},
+			Required: []string{"operation"},
 		},
 	}
 }
@@ -1039,7 +1068,7 @@ func UpdateScratchpadToolDeclaration() *genai.FunctionDeclaration {
 func SemanticSearchToolDeclaration() *genai.FunctionDeclaration {
 	return &genai.FunctionDeclaration{
 		Name:        "semantic_search",
-		Description: "Searches code using semantic similarity.",
+		Description: "Searches code by meaning and keywords (hybrid BM25 + embeddings).",
 		Parameters: &genai.Schema{
 			Type: genai.TypeObject,
 			Properties: map[string]*genai.Schema{
@@ -1051,6 +1080,26 @@ func SemanticSearchToolDeclaration() *genai.FunctionDeclaration {
 					Type:        genai.TypeInteger,
 					Description: "Number of results to return (default: 10)",
 				},
+				"mode": {
+					Type:        genai.TypeString,
+					Description: "hybrid (default), vector or lexical",
+				},
+				"path": {
+					Type:        genai.TypeString,
+					Description: "Glob filter on file paths",
+				},
+				"language": {
+					Type:        genai.TypeString,
+					Description: "Language filter (e.g., go, python)",
+				},
+				"kind": {
+					Type:        genai.TypeString,
+					Description: "Symbol kind filter (function, method, class, struct, interface, type)",
+				},
+				"rerank": {
+					Type:        genai.TypeBoolean,
+					Description: "Rerank top results with the language model",
+				},
 			},
 			Required: []string{"query"},
 		},
@@ -1317,16 +1366,37 @@ func PinContextToolDeclaration() *genai.FunctionDeclaration {
 func HistorySearchToolDeclaration() *genai.FunctionDeclaration {
 	return &genai.FunctionDeclaration{
 		Name:        "history_search",
-		Description: "Searches through session message history using regex. Use to recover details lost to context truncation.",
+		Description: "Searches the current session's history by regex, or this project's past sessions by full-text query (scope all; projects all also searches other projects after asking the user). Use to recover details lost to context truncation or to find how something was done before.",
 		Parameters: &genai.Schema{
 			Type: genai.TypeObject,
 			Properties: map[string]*genai.Schema{
 				"pattern": {
 					Type:        genai.TypeString,
-					Description: "Regex pattern to search for in history",
+					Description: "Regex pattern to search for in the current session's history",
+				},
+				"query": {
+					Type:        genai.TypeString,
+					Description: "Full-text query over all past sessions",
+				},
+				"scope": {
+					Type:        genai.TypeString,
+					Description: "Where to search: session or all",
+					Enum:        []string{"session", "all"},
+				},
+				"projects": {
+					Type:        genai.TypeString,
+					Description: "Past sessions of the current project or of all projects (asks the user)",
+					Enum:        []string{"current", "all"},
+				},
+				"since": {
+					Type:        genai.TypeString,
+					Description: "Only past sessions active since then: 7d, 2w, 24h or YYYY-MM-DD",
+				},
+				"limit": {
+					Type:        genai.TypeInteger,
+					Description: "Maximum past-session results (default: 10, at most 50)",
 				},
 			},
-			Required: []string{"pattern"},
 		},
 	}
 }
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// reviewDiff shows a diff preview through handler. Handlers without hunk
+// support fall back to a whole-file decision.
+func reviewDiff(ctx context.Context, handler DiffHandler, filePath, oldContent, newContent, toolName string, isNewFile bool) (*DiffReview, error) {
+	if h, ok := handler.(HunkDiffHandler); ok {
+		return h.ReviewDiff(ctx, filePath, oldContent, newContent, toolName, isNewFile)
+	}
+
+	approved, err := handler.PromptDiff(ctx, filePath, oldContent, newContent, toolName, isNewFile)
+	if err != nil {
+		return nil, err
+	}
+	return &DiffReview{FilePath: filePath, Approved: approved, Content: newContent}, nil
+}
+
+// Partial reports whether the user rejected or edited some hunks.
+func (r *DiffReview) Partial() bool {
+	return len(r.Rejected) > 0 || len(r.Edited) > 0
+}
+
+// Note describes the hunks that were not applied as proposed, so the model
+// does not re-apply rejected changes. It is empty if the change was applied
+// in full.
+func (r *DiffReview) Note() string {
+	if !r.Partial() {
+		return ""
+	}
+
+	var sb strings.Builder
+	if len(r.Rejected) > 0 {
+		sb.WriteString(fmt.Sprintf("\n\nThe user rejected %d hunk(s) of this change to %s. They were NOT applied; do not re-apply them:\n", len(r.Rejected), r.FilePath))
+		sb.WriteString(strings.Join(r.Rejected, "\n"))
+	}
+	if len(r.Edited) > 0 {
+		sb.WriteString(fmt.Sprintf("\n\nThe user edited %d hunk(s) of this change to %s before applying. The file contains their version:\n", len(r.Edited), r.FilePath))
+		sb.WriteString(strings.Join(r.Edited, "\n"))
+	}
+	return sb.String()
+}
@@ -9,6 +9,7 @@ import (
 
 	"google.golang.org/genai"
 
+	"gokin/internal/lsp"
 	"gokin/internal/security"
 	"gokin/internal/undo"
 )
@@ -22,6 +23,7 @@ type EditTool struct {
 	diffEnabled   bool
 	workDir       string
 	pathValidator *security.PathValidator
+	lspManager    *lsp.Manager
 }
 
 // NewEditTool creates a new EditTool instance.
@@ -50,6 +52,12 @@ func (t *EditTool) SetDiffEnabled(enabled bool) {
 	t.diffEnabled = enabled
 }
 
+// SetLSPManager sets the language servers that report diagnostics for
+// edited files.
+func (t *EditTool) SetLSPManager(manager *lsp.Manager) {
+	t.lspManager = manager
+}
+
 // SetWorkDir sets the working directory and initializes path validator.
 func (t *EditTool) SetWorkDir(workDir string) {
 	t.workDir = workDir
@@ -331,14 +339,17 @@ func (t *EditTool) Execute(ctx context.Context, args map[string]any) (ToolResult
 
 	// Show diff preview and wait for approval if enabled
 	// Skip diff approval when running in delegated plan execution (context flag)
+	var reviewNote string
 	if t.diffEnabled && t.diffHandler != nil && !ShouldSkipDiff(ctx) {
-		approved, err := t.diffHandler.PromptDiff(ctx, filePath, content, newContent, "edit", false)
+		review, err := reviewDiff(ctx, t.diffHandler, filePath, content, newContent, "edit", false)
 		if err != nil {
 			return NewErrorResult(fmt.Sprintf("diff preview error: %s", err)), nil
 		}
-		if !approved {
+		if !review.Approved {
 			return NewErrorResult("changes rejected by user"), nil
 		}
+		newContent = review.Content
+		reviewNote = review.Note()
 	}
 
 	// Write back atomically to prevent data corruption on interruption
@@ -360,7 +371,7 @@ func (t *EditTool) Execute(ctx context.Context, args map[string]any) (ToolResult
 		status = fmt.Sprintf("Replaced 1 occurrence in %s", filePath)
 	}
 
-	return NewSuccessResult(status), nil
+	return NewSuccessResult(status + reviewNote + diagnosticsNote(ctx, t.lspManager, filePath, newContentBytes)), nil
 }
 
 // executeMultiEdit applies multiple edits to a single file sequentially.
@@ -420,14 +431,17 @@ func (t *EditTool) executeMultiEdit(ctx context.Context, filePath string, edits
 	}
 
 	// Show combined diff preview
+	var reviewNote string
 	if t.diffEnabled && t.diffHandler != nil && !ShouldSkipDiff(ctx) {
-		approved, err := t.diffHandler.PromptDiff(ctx, filePath, string(oldContent), content, "edit", false)
+		review, err := reviewDiff(ctx, t.diffHandler, filePath, string(oldContent), content, "edit", false)
 		if err != nil {
 			return NewErrorResult(fmt.Sprintf("diff preview error: %s", err)), nil
 		}
-		if !approved {
+		if !review.Approved {
 			return NewErrorResult("changes rejected by user"), nil
 		}
+		content = review.Content
+		reviewNote = review.Note()
 	}
 
 	// Write atomically
@@ -442,7 +456,8 @@ func (t *EditTool) executeMultiEdit(ctx context.Context, filePath string, edits
 		t.undoManager.Record(*change)
 	}
 
-	return NewSuccessResult(fmt.Sprintf("Applied %d edit(s) to %s", totalReplacements, filePath)), nil
+	return NewSuccessResult(fmt.Sprintf("Applied %d edit(s) to %s", totalReplacements, filePath) + reviewNote +
+		diagnosticsNote(ctx, t.lspManager, filePath, newContentBytes)), nil
 }
 
 // executeLineEdit replaces a range of lines in a file.
@@ -503,14 +518,17 @@ func (t *EditTool) executeLineEdit(ctx context.Context, filePath string, lineSta
 	newContent := strings.Join(parts, "\n")
 
 	// Show diff preview
+	var reviewNote string
 	if t.diffEnabled && t.diffHandler != nil && !ShouldSkipDiff(ctx) {
-		approved, err := t.diffHandler.PromptDiff(ctx, filePath, content, newContent, "edit", false)
+		review, err := reviewDiff(ctx, t.diffHandler, filePath, content, newContent, "edit", false)
 		if err != nil {
 			return NewErrorResult(fmt.Sprintf("diff preview error: %s", err)), nil
 		}
-		if !approved {
+		if !review.Approved {
 			return NewErrorResult("changes rejected by user"), nil
 		}
+		newContent = review.Content
+		reviewNote = review.Note()
 	}
 
 	// Write atomically
@@ -526,7 +544,8 @@ func (t *EditTool) executeLineEdit(ctx context.Context, filePath string, lineSta
 	}
 
 	replacedCount := lineEnd - lineStart + 1
-	return NewSuccessResult(fmt.Sprintf("Replaced lines %d-%d (%d lines) in %s", lineStart, lineEnd, replacedCount, filePath)), nil
+	return NewSuccessResult(fmt.Sprintf("Replaced lines %d-%d (%d lines) in %s", lineStart, lineEnd, replacedCount, filePath) + reviewNote +
+		diagnosticsNote(ctx, t.lspManager, filePath, newContentBytes)), nil
 }
 
 // extractFileContext formats file content with line numbers for error context.
@@ -28,6 +28,10 @@ const (
 	MaxConcurrentToolExecutions = 5
 )
 
+// ErrBudgetExhausted is returned when the executor's turn or token budget is used up
+// before the model produced a final answer.
+var ErrBudgetExhausted = errors.New("execution budget exhausted")
+
 // ResultCompactor interface for compacting tool results.
 type ResultCompactor interface {
 	CompactForType(toolName string, result ToolResult) ToolResult
@@ -170,8 +174,13 @@ type Executor struct {
 	lastInputTokens  int
 	lastOutputTokens int
 
+	// Optional execution budget (0 = unlimited). Used by non-interactive runs.
+	maxTurns    int
+	tokenBudget int
+
 	// Circuit breakers for tools
 	toolBreakers map[string]*robustness.CircuitBreaker
+	breakersMu   sync.Mutex
 
 	// Tool result cache
 	toolCache *ToolResultCache
@@ -196,6 +205,9 @@ type ExecutionHandler struct {
 	// OnThinking is called when thinking/reasoning content is streamed.
 	OnThinking func(text string)
 
+	// OnUsage is called after each model response with its API usage metadata.
+	OnUsage func(resp *client.Response)
+
 	// OnToolStart is called when a tool begins execution.
 	OnToolStart func(name string, args map[string]any)
 
@@ -318,6 +330,15 @@ func (e *Executor) SetToolCache(cache *ToolResultCache) {
 	e.toolCache = cache
 }
 
+// SetBudget limits a single Execute call to maxTurns model requests and
+// tokenBudget total (input + output) tokens. Zero disables the respective limit.
+// When a limit is hit while tools are still being called, Execute returns an
+// error wrapping ErrBudgetExhausted.
+func (e *Executor) SetBudget(maxTurns, tokenBudget int) {
+	e.maxTurns = maxTurns
+	e.tokenBudget = tokenBudget
+}
+
 // GetNotificationManager returns the notification manager.
 func (e *Executor) GetNotificationManager() *NotificationManager {
 	return e.notificationMgr
@@ -347,6 +368,28 @@ func (e *Executor) executeLoop(ctx context.Context, history []*genai.Content) ([
 	var toolsUsed []string        // Track which tools were used for smart fallback
 	var lastToolResult ToolResult // Track the last tool result for context
 
+	// Budget accounting for this call (only enforced when SetBudget was used).
+	// A final answer is always returned; the budget only stops the run when
+	// the model still wants to call tools.
+	turns, tokensUsed := 0, 0
+	checkBudget := func(resp *client.Response) error {
+		turns++
+		if resp == nil {
+			return nil
+		}
+		tokensUsed += resp.InputTokens + resp.OutputTokens
+		if len(resp.FunctionCalls) == 0 {
+			return nil
+		}
+		if e.tokenBudget > 0 && tokensUsed >= e.tokenBudget {
+			return fmt.Errorf("%w: used %d of %d tokens", ErrBudgetExhausted, tokensUsed, e.tokenBudget)
+		}
+		if e.maxTurns > 0 && turns >= e.maxTurns {
+			return fmt.Errorf("%w: reached %d turns", ErrBudgetExhausted, e.maxTurns)
+		}
+		return nil
+	}
+
 	for i := 0; i < maxIterations; i++ {
 		// Get response from model
 		resp, err := e.getModelResponse(ctx, history)
@@ -366,6 +409,9 @@ func (e *Executor) executeLoop(ctx context.Context, history []*genai.Content) ([
 				}
 			}
 		}
+		if err := checkBudget(resp); err != nil {
+			return history, resp.Text, err
+		}
 
 		// Add model response to history (with mutex protection)
 		modelContent := &genai.Content{
@@ -421,6 +467,9 @@ func (e *Executor) executeLoop(ctx context.Context, history []*genai.Content) ([
 					}
 				}
 			}
+			if err := checkBudget(resp); err != nil {
+				return history, resp.Text, err
+			}
 
 			// CRITICAL: Add function results to history for chained tool calls
 			funcResultParts := make([]*genai.Part, len(results))
@@ -547,10 +596,14 @@ func (e *Executor) collectStreamWithHandler(ctx context.Context, stream *client.
 		onText = e.handler.OnText
 		onThinking = e.handler.OnThinking
 	}
-	return client.ProcessStream(ctx, stream, &client.StreamHandler{
+	resp, err := client.ProcessStream(ctx, stream, &client.StreamHandler{
 		OnText:     onText,
 		OnThinking: onThinking,
 	})
+	if err == nil && resp != nil && e.handler != nil && e.handler.OnUsage != nil {
+		e.handler.OnUsage(resp)
+	}
+	return resp, err
 }
 
 // executeTools executes a list of function calls with enhanced safety checks.
@@ -658,15 +711,27 @@ func (e *Executor) executeTools(ctx context.Context, calls []*genai.FunctionCall
 	return results, nil
 }
 
+// ExecuteCall runs a single tool call outside the model loop, e.g. on behalf of
+// an MCP client. It goes through the same safety checks, permission rules, hooks,
+// audit logging and redaction as calls made by the model.
+func (e *Executor) ExecuteCall(ctx context.Context, name string, args map[string]any) ToolResult {
+	if args == nil {
+		args = make(map[string]any)
+	}
+	return e.executeTool(ctx, &genai.FunctionCall{Name: name, Args: args})
+}
+
 // executeTool executes a single tool call with enhanced safety and user awareness.
 func (e *Executor) executeTool(ctx context.Context, call *genai.FunctionCall) ToolResult {
 	// Step 0: Check circuit breaker
+	e.breakersMu.Lock()
 	breaker, ok := e.toolBreakers[call.Name]
 	if !ok {
 		// Initialize with default threshold (5 failures) and timeout (1 minute)
 		breaker = robustness.NewCircuitBreaker(5, 1*time.Minute)
 		e.toolBreakers[call.Name] = breaker
 	}
+	e.breakersMu.Unlock()
 
 	var result ToolResult
 	err := breaker.Execute(ctx, func() error {
@@ -700,6 +765,30 @@ func (e *Executor) doExecuteTool(ctx context.Context, call *genai.FunctionCall)
 	if !ok {
 		return NewErrorResult(fmt.Sprintf("unknown tool: %s", call.Name))
 	}
+	if !IsToolAllowed(ctx, call.Name) {
+		reason := fmt.Sprintf("tool %s is not in the allowed tools of this command", call.Name)
+		if e.handler != nil && e.handler.OnToolDenied != nil {
+			e.handler.OnToolDenied(call.Name, reason)
+		}
+		return NewErrorResult(reason)
+	}
+
+	// Step 1.5: Run pre-tool hooks. They may block the call or rewrite its
+	// arguments, so they run before validation, safety and permission checks.
+	var preHooks *hooks.Outcome
+	if e.hooks != nil {
+		preHooks = e.hooks.RunPreTool(ctx, call.Name, call.Args)
+		if preHooks.Denied {
+			reason := fmt.Sprintf("blocked by hook %q: %s", preHooks.DeniedBy, preHooks.Reason)
+			if e.handler != nil && e.handler.OnToolDenied != nil {
+				e.handler.OnToolDenied(call.Name, reason)
+			}
+			e.reportHookMessages(preHooks)
+			return NewErrorResult(reason)
+		}
+		// Copy the call so rewritten arguments don't alter the model's history
+		call = &genai.FunctionCall{ID: call.ID, Name: call.Name, Args: preHooks.Args}
+	}
 
 	if err := tool.Validate(call.Args); err != nil {
 		return NewErrorResult(fmt.Sprintf("validation error: %s", err))
@@ -795,6 +884,8 @@ func (e *Executor) doExecuteTool(ctx context.Context, call *genai.FunctionCall)
 	if e.toolCache != nil {
 		if cached, hit := e.toolCache.Get(call.Name, call.Args); hit {
 			logging.Debug("tool cache hit", "tool", call.Name)
+			e.reportHookMessages(preHooks)
+			appendHookNotes(&cached, preHooks)
 			return cached
 		}
 	}
@@ -809,11 +900,6 @@ func (e *Executor) doExecuteTool(ctx context.Context, call *genai.FunctionCall)
 		}
 	}
 
-	// Step 6: Run pre-tool hooks
-	if e.hooks != nil {
-		e.hooks.RunPreTool(ctx, call.Name, call.Args)
-	}
-
 	// Step 7: Create execution context
 	execInfo := &ExecutionInfo{
 		StartTime:      time.Now(),
@@ -908,6 +994,7 @@ func (e *Executor) doExecuteTool(ctx context.Context, call *genai.FunctionCall)
 	if e.auditLogger != nil {
 		entry := audit.NewEntry(e.sessionID, call.Name, call.Args)
 		entry.Complete(result.Content, result.Success, result.Error, duration)
+		entry.Caller = CallerFromContext(ctx)
 
 		// Add safety context to audit log
 		if preFlight != nil {
@@ -921,12 +1008,19 @@ func (e *Executor) doExecuteTool(ctx context.Context, call *genai.FunctionCall)
 	}
 
 	// Step 10: Run post-tool or on-error hooks
+	var postHooks *hooks.Outcome
 	if e.hooks != nil {
 		if result.Success {
-			e.hooks.RunPostTool(ctx, call.Name, call.Args, result.Content)
+			postHooks = e.hooks.RunPostTool(ctx, call.Name, call.Args, result.Content)
 		} else {
-			e.hooks.RunOnError(ctx, call.Name, call.Args, result.Error)
+			postHooks = e.hooks.RunOnError(ctx, call.Name, call.Args, result.Error)
+		}
+		if postHooks.Denied {
+			postHooks.AdditionalContext = append(postHooks.AdditionalContext,
+				fmt.Sprintf("hook %q flagged this result: %s", postHooks.DeniedBy, postHooks.Reason))
 		}
+		e.reportHookMessages(preHooks)
+		e.reportHookMessages(postHooks)
 	}
 
 	// Step 11: apply redaction
@@ -960,6 +1054,11 @@ func (e *Executor) doExecuteTool(ctx context.Context, call *genai.FunctionCall)
 		}
 	}
 
+	// Step 12.7: Attach hook notes after compaction and caching so they are
+	// neither truncated nor replayed on cache hits
+	appendHookNotes(&result, preHooks)
+	appendHookNotes(&result, postHooks)
+
 	// Step 13: Notify completion and send notifications
 	if e.handler != nil && e.handler.OnToolEnd != nil {
 		e.handler.OnToolEnd(call.Name, result)
@@ -988,6 +1087,30 @@ func (e *Executor) doExecuteTool(ctx context.Context, call *genai.FunctionCall)
 	return result
 }
 
+// reportHookMessages shows hook system messages to the user.
+func (e *Executor) reportHookMessages(outcome *hooks.Outcome) {
+	if outcome == nil || e.handler == nil || e.handler.OnWarning == nil {
+		return
+	}
+	for _, msg := range outcome.SystemMessages {
+		e.handler.OnWarning("[hook] " + msg)
+	}
+}
+
+// appendHookNotes adds hook context and system messages to the text the
+// model sees for a tool result.
+func appendHookNotes(result *ToolResult, outcome *hooks.Outcome) {
+	notes := outcome.Notes()
+	if notes == "" {
+		return
+	}
+	if result.Success {
+		result.Content = strings.TrimRight(result.Content, "\n") + "\n\n" + notes
+	} else {
+		result.Error = strings.TrimRight(result.Error, "\n") + "\n\n" + notes
+	}
+}
+
 // buildResponseParts returns Parts from a response.
 func (e *Executor) buildResponseParts(resp *client.Response) []*genai.Part {
 	if len(resp.Parts) > 0 {
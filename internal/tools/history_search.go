@@ -5,13 +5,23 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
+
+	"gokin/internal/chat"
 
 	"google.golang.org/genai"
 )
 
-// HistorySearchTool searches through the agent's message history.
+// maxArchiveResults caps the past-session results of one search.
+const maxArchiveResults = 50
+
+// HistorySearchTool searches through the agent's message history, and
+// through the archive of saved sessions. Sessions of other projects are
+// only searched when asked for explicitly.
 type HistorySearchTool struct {
 	historyGetter func() []*genai.Content
+	archive       *chat.Archive
+	workDir       string
 }
 
 // NewHistorySearchTool creates a new HistorySearchTool.
@@ -26,19 +36,43 @@ func (t *HistorySearchTool) SetHistoryGetter(fn func() []*genai.Content) {
 	t.historyGetter = fn
 }
 
+// WithHistoryGetter returns a copy of the tool searching another history,
+// sharing the archive. Agents use it to search their own history.
+func (t *HistorySearchTool) WithHistoryGetter(fn func() []*genai.Content) *HistorySearchTool {
+	return &HistorySearchTool{historyGetter: fn, archive: t.archive, workDir: t.workDir}
+}
+
+// SetArchive sets the archive of saved sessions searched with scope "all".
+func (t *HistorySearchTool) SetArchive(archive *chat.Archive) {
+	t.archive = archive
+}
+
+// SetWorkDir sets the project whose sessions scope "all" searches by
+// default.
+func (t *HistorySearchTool) SetWorkDir(workDir string) {
+	t.workDir = workDir
+}
+
 func (t *HistorySearchTool) Name() string {
 	return "history_search"
 }
 
 func (t *HistorySearchTool) Description() string {
-	return `Searches through the current session's message history using a regular expression.
-Use this to recover specific details, file paths, or error messages from earlier in the conversation that might have been lost due to context truncation or summarization.
+	return `Searches the current session's message history with a regular expression, or the archive of this project's past sessions with a full-text query.
+Use the current session scope to recover details, file paths, or error messages lost to context truncation or summarization.
+Use scope "all" to find how something was done in earlier sessions, e.g. "how did we fix the flaky TestFoo last week?".
 
 PARAMETERS:
-- pattern (required): The regular expression pattern to search for in message history.
+- pattern: Regular expression to search for in the current session's history.
+- query: Words to search for in past sessions: prompts, replies, tool calls, tool results and touched files.
+- scope: "session" (default with pattern) or "all" (default with query).
+- projects: "current" (default) or "all" to also search other projects' sessions; the user is asked first.
+- since: Only past sessions active since then, as "7d", "2w", "24h" or a date like "2025-01-31".
+- limit: Maximum past-session results (default: 10, at most 50).
 
 RETURNS:
-- A list of matching message excerpts with their roles and order in history.`
+- Current session: matching message excerpts with their roles and order in history.
+- All sessions: matching turns with their session, turn, touched files and the /resume command jumping to that turn.`
 }
 
 func (t *HistorySearchTool) Declaration() *genai.FunctionDeclaration {
@@ -50,28 +84,80 @@ func (t *HistorySearchTool) Declaration() *genai.FunctionDeclaration {
 			Properties: map[string]*genai.Schema{
 				"pattern": {
 					Type:        genai.TypeString,
-					Description: "Regex pattern to search for in history",
+					Description: "Regex pattern to search for in the current session's history",
+				},
+				"query": {
+					Type:        genai.TypeString,
+					Description: "Full-text query over all past sessions",
+				},
+				"scope": {
+					Type:        genai.TypeString,
+					Description: "Where to search: session or all",
+					Enum:        []string{"session", "all"},
+				},
+				"projects": {
+					Type:        genai.TypeString,
+					Description: "Past sessions of the current project or of all projects (asks the user)",
+					Enum:        []string{"current", "all"},
+				},
+				"since": {
+					Type:        genai.TypeString,
+					Description: "Only past sessions active since then: 7d, 2w, 24h or YYYY-MM-DD",
+				},
+				"limit": {
+					Type:        genai.TypeInteger,
+					Description: "Maximum past-session results (default: 10, at most 50)",
 				},
 			},
-			Required: []string{"pattern"},
 		},
 	}
 }
 
 func (t *HistorySearchTool) Validate(args map[string]any) error {
-	pattern, ok := GetString(args, "pattern")
-	if !ok || pattern == "" {
-		return NewValidationError("pattern", "is required")
+	pattern, _ := GetString(args, "pattern")
+	query, _ := GetString(args, "query")
+	if pattern == "" && query == "" {
+		return NewValidationError("pattern", "pattern or query is required")
 	}
-	_, err := regexp.Compile(pattern)
-	if err != nil {
-		return NewValidationError("pattern", fmt.Sprintf("invalid regex: %v", err))
+	if pattern != "" {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return NewValidationError("pattern", fmt.Sprintf("invalid regex: %v", err))
+		}
+	}
+	if scope, ok := GetString(args, "scope"); ok && scope != "" && scope != "session" && scope != "all" {
+		return NewValidationError("scope", "must be session or all")
+	}
+	if scope := GetStringDefault(args, "scope", ""); scope == "session" && pattern == "" {
+		return NewValidationError("pattern", "is required for scope session")
+	}
+	if projects, ok := GetString(args, "projects"); ok && projects != "" && projects != "current" && projects != "all" {
+		return NewValidationError("projects", "must be current or all")
+	}
+	if since, ok := GetString(args, "since"); ok && since != "" {
+		if _, err := chat.ParseSince(since, time.Now()); err != nil {
+			return NewValidationError("since", err.Error())
+		}
 	}
 	return nil
 }
 
 func (t *HistorySearchTool) Execute(ctx context.Context, args map[string]any) (ToolResult, error) {
 	patternStr, _ := GetString(args, "pattern")
+	query, _ := GetString(args, "query")
+	scope := GetStringDefault(args, "scope", "")
+	if scope == "" {
+		scope = "session"
+		if query != "" {
+			scope = "all"
+		}
+	}
+	if scope == "all" {
+		if query == "" {
+			query = patternStr
+		}
+		return t.searchArchive(ctx, query, args)
+	}
+
 	re := regexp.MustCompile("(?i)" + patternStr)
 
 	if t.historyGetter == nil {
@@ -122,3 +208,32 @@ func (t *HistorySearchTool) Execute(ctx context.Context, args map[string]any) (T
 
 	return NewSuccessResult(strings.Join(results, "\n")), nil
 }
+
+// searchArchive searches the turns of the current project's saved
+// sessions, or of every project's with projects "all".
+func (t *HistorySearchTool) searchArchive(ctx context.Context, query string, args map[string]any) (ToolResult, error) {
+	if t.archive == nil {
+		return NewErrorResult("session archive not available (session persistence is disabled)"), nil
+	}
+
+	limit := min(max(GetIntDefault(args, "limit", 10), 1), maxArchiveResults)
+	q := chat.ArchiveQuery{Text: query, Limit: limit}
+	if GetStringDefault(args, "projects", "current") != "all" {
+		if t.workDir == "" {
+			return NewErrorResult("current project unknown; set projects to all to search every project"), nil
+		}
+		q.WorkDir = t.workDir
+	}
+	if since, ok := GetString(args, "since"); ok && since != "" {
+		q.Since, _ = chat.ParseSince(since, time.Now())
+	}
+
+	hits, err := t.archive.Search(ctx, q)
+	if err != nil {
+		return NewErrorResult(fmt.Sprintf("archive search failed: %v", err)), nil
+	}
+	if len(hits) == 0 {
+		return NewSuccessResult("No matches found in past sessions."), nil
+	}
+	return NewSuccessResult(fmt.Sprintf("Found %d matching turns in past sessions:\n\n%s", len(hits), chat.FormatArchiveHits(hits))), nil
+}
@@ -0,0 +1,526 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"gokin/internal/gorefactor"
+	"gokin/internal/lsp"
+	"gokin/internal/security"
+	"gokin/internal/undo"
+)
+
+const (
+	// maxLSPLocations caps the locations listed by lsp tools.
+	maxLSPLocations = 100
+
+	// maxReportedDiagnostics caps the diagnostics appended to an edit.
+	maxReportedDiagnostics = 20
+)
+
+// diagnosticsNote sends a file's new content to its language server and
+// describes the errors and warnings the change introduced, so the model can
+// fix them right away. It is empty when there are none or no server.
+func diagnosticsNote(ctx context.Context, manager *lsp.Manager, path string, content []byte) string {
+	if manager == nil || !manager.Handles(path) {
+		return ""
+	}
+	diagnostics, err := manager.Diagnose(ctx, path, content)
+	if err != nil || len(diagnostics) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	count := 0
+	for _, d := range diagnostics {
+		if d.Severity > lsp.SeverityWarning {
+			continue
+		}
+		if count == maxReportedDiagnostics {
+			sb.WriteString("  ...\n")
+			break
+		}
+		source := ""
+		if d.Source != "" {
+			source = " (" + d.Source + ")"
+		}
+		fmt.Fprintf(&sb, "  line %d:%d %s: %s%s\n", d.Range.Start.Line+1, d.Range.Start.Character+1,
+			d.Severity, strings.TrimSpace(d.Message), source)
+		count++
+	}
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n\nNew diagnostics in %s after this change:\n%s", filepath.Base(path), strings.TrimRight(sb.String(), "\n"))
+}
+
+// lspTool holds what the language server tools share.
+type lspTool struct {
+	manager       *lsp.Manager
+	workDir       string
+	pathValidator *security.PathValidator
+}
+
+func newLSPTool(manager *lsp.Manager, workDir string) lspTool {
+	return lspTool{
+		manager:       manager,
+		workDir:       workDir,
+		pathValidator: security.NewPathValidator([]string{workDir}, false),
+	}
+}
+
+// positionSchema returns the parameters locating a symbol in a file.
+func positionSchema() map[string]*genai.Schema {
+	return map[string]*genai.Schema{
+		"file_path": {
+			Type:        genai.TypeString,
+			Description: "File containing the symbol",
+		},
+		"line": {
+			Type:        genai.TypeInteger,
+			Description: "Line of the symbol (1-based)",
+		},
+		"symbol": {
+			Type:        genai.TypeString,
+			Description: "Name of the symbol on that line; its first occurrence on the line is used",
+		},
+		"column": {
+			Type:        genai.TypeInteger,
+			Description: "Column of the symbol (1-based byte offset). Overrides symbol",
+		},
+	}
+}
+
+// validatePosition checks the parameters from positionSchema.
+func validatePosition(args map[string]any) error {
+	if path, ok := GetString(args, "file_path"); !ok || path == "" {
+		return NewValidationError("file_path", "is required")
+	}
+	if line, ok := GetInt(args, "line"); !ok || line < 1 {
+		return NewValidationError("line", "must be a positive line number")
+	}
+	symbol, _ := GetString(args, "symbol")
+	column, _ := GetInt(args, "column")
+	if symbol == "" && column < 1 {
+		return NewValidationError("symbol", "symbol or column is required")
+	}
+	return nil
+}
+
+// position resolves the file and the LSP position of the symbol the
+// arguments point at.
+func (t *lspTool) position(args map[string]any) (string, lsp.Position, error) {
+	filePath, _ := GetString(args, "file_path")
+	line, _ := GetInt(args, "line")
+	column, _ := GetInt(args, "column")
+	symbol, _ := GetString(args, "symbol")
+
+	if !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(t.workDir, filePath)
+	}
+	validPath, err := t.pathValidator.ValidateFile(filePath)
+	if err != nil {
+		return "", lsp.Position{}, fmt.Errorf("path validation failed: %s", err)
+	}
+	content, err := os.ReadFile(validPath)
+	if err != nil {
+		return "", lsp.Position{}, err
+	}
+
+	lines := bytes.Split(content, []byte("\n"))
+	if line > len(lines) {
+		return "", lsp.Position{}, fmt.Errorf("%s has only %d lines", filePath, len(lines))
+	}
+	if column < 1 {
+		idx := bytes.Index(lines[line-1], []byte(symbol))
+		if idx < 0 {
+			return "", lsp.Position{}, fmt.Errorf("%q not found on line %d: %s", symbol, line, strings.TrimSpace(string(lines[line-1])))
+		}
+		column = idx + 1
+	}
+	return validPath, lsp.PositionAt(content, line, column), nil
+}
+
+// relPath returns path relative to the working directory when possible.
+func (t *lspTool) relPath(path string) string {
+	if rel, err := filepath.Rel(t.workDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// formatLocations lists locations as path:line:column with the line's text.
+func (t *lspTool) formatLocations(locations []lsp.Location) string {
+	var sb strings.Builder
+	contents := make(map[string][][]byte)
+	for i, loc := range locations {
+		if i == maxLSPLocations {
+			fmt.Fprintf(&sb, "... and %d more\n", len(locations)-i)
+			break
+		}
+		path, err := lsp.URIToPath(loc.URI)
+		if err != nil {
+			continue
+		}
+		line := loc.Range.Start.Line
+		fmt.Fprintf(&sb, "%s:%d:%d", t.relPath(path), line+1, loc.Range.Start.Character+1)
+
+		lines, ok := contents[path]
+		if !ok {
+			if data, err := os.ReadFile(path); err == nil {
+				lines = bytes.Split(data, []byte("\n"))
+			}
+			contents[path] = lines
+		}
+		if line < len(lines) {
+			fmt.Fprintf(&sb, ": %s", strings.TrimSpace(string(lines[line])))
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// LSPDefinitionTool finds where a symbol is defined using a language server.
+type LSPDefinitionTool struct{ lspTool }
+
+// NewLSPDefinitionTool creates a new LSPDefinitionTool instance.
+func NewLSPDefinitionTool(manager *lsp.Manager, workDir string) *LSPDefinitionTool {
+	return &LSPDefinitionTool{newLSPTool(manager, workDir)}
+}
+
+func (t *LSPDefinitionTool) Name() string { return "lsp_definition" }
+
+func (t *LSPDefinitionTool) Description() string {
+	return "Go to definition: finds where the symbol at file_path:line is defined, using the project's language server (type-aware, follows imports)."
+}
+
+func (t *LSPDefinitionTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &genai.Schema{
+			Type:       genai.TypeObject,
+			Properties: positionSchema(),
+			Required:   []string{"file_path", "line"},
+		},
+	}
+}
+
+func (t *LSPDefinitionTool) Validate(args map[string]any) error { return validatePosition(args) }
+
+func (t *LSPDefinitionTool) Execute(ctx context.Context, args map[string]any) (ToolResult, error) {
+	path, pos, err := t.position(args)
+	if err != nil {
+		return NewErrorResult(err.Error()), nil
+	}
+	locations, err := t.manager.Definition(ctx, path, pos)
+	if err != nil {
+		return NewErrorResult(fmt.Sprintf("definition failed: %s", err)), nil
+	}
+	if len(locations) == 0 {
+		return NewSuccessResult("No definition found."), nil
+	}
+	return NewSuccessResult(t.formatLocations(locations)), nil
+}
+
+// LSPReferencesTool finds the references to a symbol using a language server.
+type LSPReferencesTool struct{ lspTool }
+
+// NewLSPReferencesTool creates a new LSPReferencesTool instance.
+func NewLSPReferencesTool(manager *lsp.Manager, workDir string) *LSPReferencesTool {
+	return &LSPReferencesTool{newLSPTool(manager, workDir)}
+}
+
+func (t *LSPReferencesTool) Name() string { return "lsp_references" }
+
+func (t *LSPReferencesTool) Description() string {
+	return "Finds all references to the symbol at file_path:line across the project, including its declaration, using the project's language server. Unlike grep, only uses of this exact symbol are returned."
+}
+
+func (t *LSPReferencesTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &genai.Schema{
+			Type:       genai.TypeObject,
+			Properties: positionSchema(),
+			Required:   []string{"file_path", "line"},
+		},
+	}
+}
+
+func (t *LSPReferencesTool) Validate(args map[string]any) error { return validatePosition(args) }
+
+func (t *LSPReferencesTool) Execute(ctx context.Context, args map[string]any) (ToolResult, error) {
+	path, pos, err := t.position(args)
+	if err != nil {
+		return NewErrorResult(err.Error()), nil
+	}
+	locations, err := t.manager.References(ctx, path, pos)
+	if err != nil {
+		return NewErrorResult(fmt.Sprintf("references failed: %s", err)), nil
+	}
+	if len(locations) == 0 {
+		return NewSuccessResult("No references found."), nil
+	}
+	return NewSuccessResult(fmt.Sprintf("%d reference(s):\n%s", len(locations), t.formatLocations(locations))), nil
+}
+
+// LSPHoverTool shows the type and documentation of a symbol using a
+// language server.
+type LSPHoverTool struct{ lspTool }
+
+// NewLSPHoverTool creates a new LSPHoverTool instance.
+func NewLSPHoverTool(manager *lsp.Manager, workDir string) *LSPHoverTool {
+	return &LSPHoverTool{newLSPTool(manager, workDir)}
+}
+
+func (t *LSPHoverTool) Name() string { return "lsp_hover" }
+
+func (t *LSPHoverTool) Description() string {
+	return "Shows the type signature and documentation of the symbol at file_path:line, using the project's language server."
+}
+
+func (t *LSPHoverTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &genai.Schema{
+			Type:       genai.TypeObject,
+			Properties: positionSchema(),
+			Required:   []string{"file_path", "line"},
+		},
+	}
+}
+
+func (t *LSPHoverTool) Validate(args map[string]any) error { return validatePosition(args) }
+
+func (t *LSPHoverTool) Execute(ctx context.Context, args map[string]any) (ToolResult, error) {
+	path, pos, err := t.position(args)
+	if err != nil {
+		return NewErrorResult(err.Error()), nil
+	}
+	text, err := t.manager.Hover(ctx, path, pos)
+	if err != nil {
+		return NewErrorResult(fmt.Sprintf("hover failed: %s", err)), nil
+	}
+	if text == "" {
+		return NewSuccessResult("No information available for this position."), nil
+	}
+	return NewSuccessResult(text), nil
+}
+
+// LSPSymbolsTool searches the project's symbols using language servers.
+type LSPSymbolsTool struct{ lspTool }
+
+// NewLSPSymbolsTool creates a new LSPSymbolsTool instance.
+func NewLSPSymbolsTool(manager *lsp.Manager, workDir string) *LSPSymbolsTool {
+	return &LSPSymbolsTool{newLSPTool(manager, workDir)}
+}
+
+func (t *LSPSymbolsTool) Name() string { return "lsp_symbols" }
+
+func (t *LSPSymbolsTool) Description() string {
+	return "Searches the project's functions, types, methods and other symbols by name (fuzzy), using the project's language servers. Returns each symbol's kind and location."
+}
+
+func (t *LSPSymbolsTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"query": {
+					Type:        genai.TypeString,
+					Description: "Symbol name or part of it",
+				},
+			},
+			Required: []string{"query"},
+		},
+	}
+}
+
+func (t *LSPSymbolsTool) Validate(args map[string]any) error {
+	if query, ok := GetString(args, "query"); !ok || query == "" {
+		return NewValidationError("query", "is required")
+	}
+	return nil
+}
+
+func (t *LSPSymbolsTool) Execute(ctx context.Context, args map[string]any) (ToolResult, error) {
+	query, _ := GetString(args, "query")
+	symbols, err := t.manager.WorkspaceSymbols(ctx, query)
+	if err != nil {
+		return NewErrorResult(fmt.Sprintf("symbol search failed: %s", err)), nil
+	}
+	if len(symbols) == 0 {
+		return NewSuccessResult(fmt.Sprintf("No symbols matching %q.", query)), nil
+	}
+
+	var sb strings.Builder
+	for i, s := range symbols {
+		if i == maxLSPLocations {
+			fmt.Fprintf(&sb, "... and %d more\n", len(symbols)-i)
+			break
+		}
+		name := s.Name
+		if s.ContainerName != "" {
+			name = s.ContainerName + "." + s.Name
+		}
+		path, err := lsp.URIToPath(s.Location.URI)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&sb, "%s %s  %s:%d\n", s.Kind, name, t.relPath(path), s.Location.Range.Start.Line+1)
+	}
+	return NewSuccessResult(strings.TrimRight(sb.String(), "\n")), nil
+}
+
+// LSPRenameTool renames a symbol across the project using a language server.
+type LSPRenameTool struct {
+	lspTool
+	undoManager *undo.Manager
+	diffHandler DiffHandler
+	diffEnabled bool
+}
+
+// NewLSPRenameTool creates a new LSPRenameTool instance.
+func NewLSPRenameTool(manager *lsp.Manager, workDir string) *LSPRenameTool {
+	return &LSPRenameTool{lspTool: newLSPTool(manager, workDir)}
+}
+
+// SetUndoManager sets the undo manager for tracking changes.
+func (t *LSPRenameTool) SetUndoManager(manager *undo.Manager) {
+	t.undoManager = manager
+}
+
+// SetDiffHandler sets the diff handler for preview approval.
+func (t *LSPRenameTool) SetDiffHandler(handler DiffHandler) {
+	t.diffHandler = handler
+}
+
+// SetDiffEnabled enables or disables diff preview.
+func (t *LSPRenameTool) SetDiffEnabled(enabled bool) {
+	t.diffEnabled = enabled
+}
+
+// SetAllowedDirs sets additional allowed directories for path validation.
+func (t *LSPRenameTool) SetAllowedDirs(dirs []string) {
+	t.pathValidator = security.NewPathValidator(append([]string{t.workDir}, dirs...), false)
+}
+
+func (t *LSPRenameTool) Name() string { return "lsp_rename" }
+
+func (t *LSPRenameTool) Description() string {
+	return "Renames the symbol at file_path:line to new_name everywhere it is used, using the project's language server. Only this exact symbol is renamed, in every language the server understands."
+}
+
+func (t *LSPRenameTool) Declaration() *genai.FunctionDeclaration {
+	props := positionSchema()
+	props["new_name"] = &genai.Schema{
+		Type:        genai.TypeString,
+		Description: "New name of the symbol",
+	}
+	return &genai.FunctionDeclaration{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &genai.Schema{
+			Type:       genai.TypeObject,
+			Properties: props,
+			Required:   []string{"file_path", "line", "new_name"},
+		},
+	}
+}
+
+func (t *LSPRenameTool) Validate(args map[string]any) error {
+	if name, ok := GetString(args, "new_name"); !ok || name == "" {
+		return NewValidationError("new_name", "is required")
+	}
+	return validatePosition(args)
+}
+
+func (t *LSPRenameTool) Execute(ctx context.Context, args map[string]any) (ToolResult, error) {
+	newName, _ := GetString(args, "new_name")
+	path, pos, err := t.position(args)
+	if err != nil {
+		return NewErrorResult(err.Error()), nil
+	}
+
+	workspaceEdit, err := t.manager.Rename(ctx, path, pos, newName)
+	if err != nil {
+		return NewErrorResult(fmt.Sprintf("rename failed: %s", err)), nil
+	}
+	fileEdits, err := workspaceEdit.FileEdits()
+	if err != nil {
+		return NewErrorResult(fmt.Sprintf("rename failed: %s", err)), nil
+	}
+
+	var edits []gorefactor.FileEdit
+	for file, textEdits := range fileEdits {
+		if _, err := t.pathValidator.ValidateFile(file); err != nil {
+			return NewErrorResult(fmt.Sprintf("rename would modify %s: %s", file, err)), nil
+		}
+		old, err := os.ReadFile(file)
+		if err != nil {
+			return NewErrorResult(fmt.Sprintf("error reading %s: %s", file, err)), nil
+		}
+		content, err := lsp.ApplyEdits(old, textEdits)
+		if err != nil {
+			return NewErrorResult(fmt.Sprintf("rename failed in %s: %s", t.relPath(file), err)), nil
+		}
+		if !bytes.Equal(old, content) {
+			edits = append(edits, gorefactor.FileEdit{Path: file, OldContent: old, NewContent: content, Occurrences: len(textEdits)})
+		}
+	}
+	if len(edits) == 0 {
+		return NewSuccessResult("Nothing to rename."), nil
+	}
+
+	// One preview for all files
+	var reviewNote string
+	if t.diffEnabled && t.diffHandler != nil && !ShouldSkipDiff(ctx) {
+		edits, reviewNote, err = reviewFileEdits(ctx, t.diffHandler, edits, "lsp_rename", t.relPath)
+		if err != nil {
+			return NewErrorResult(fmt.Sprintf("diff preview error: %s", err)), nil
+		}
+		if len(edits) == 0 {
+			return NewErrorResult("changes rejected by user"), nil
+		}
+	}
+
+	changes := make([]undo.FileChange, 0, len(edits))
+	occurrences := 0
+	for _, e := range edits {
+		if err := AtomicWrite(e.Path, e.NewContent, 0644); err != nil {
+			// Restore the files already written
+			for _, c := range changes {
+				_ = AtomicWrite(c.FilePath, c.OldContent, 0644)
+			}
+			return NewErrorResult(fmt.Sprintf("error writing %s: %s (no files changed)", e.Path, err)), nil
+		}
+		changes = append(changes, *undo.NewFileChange(e.Path, "lsp_rename", e.OldContent, e.NewContent, false))
+		occurrences += e.Occurrences
+	}
+	if t.undoManager != nil {
+		if len(changes) == 1 {
+			t.undoManager.Record(changes[0])
+		} else {
+			t.undoManager.Record(*undo.NewTransaction("lsp_rename", changes))
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Renamed to '%s': %d occurrence(s) in %d file(s):\n", newName, occurrences, len(edits))
+	for _, e := range edits {
+		t.manager.Changed(e.Path, e.NewContent)
+		fmt.Fprintf(&sb, "%s: %d changes\n", t.relPath(e.Path), e.Occurrences)
+	}
+	return NewSuccessResult(strings.TrimRight(sb.String(), "\n") + reviewNote), nil
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 
+	"gokin/internal/contract"
+	"gokin/internal/logging"
 	"gokin/internal/plan"
 
 	"google.golang.org/genai"
@@ -12,7 +14,8 @@ import (
 
 // EnterPlanModeTool allows the model to create execution plans and request user approval.
 type EnterPlanModeTool struct {
-	manager *plan.Manager
+	manager   *plan.Manager
+	contracts *contract.Manager // Optional: contracts proposed together with plans
 }
 
 // NewEnterPlanModeTool creates a new enter plan mode tool.
@@ -25,6 +28,11 @@ func (t *EnterPlanModeTool) SetManager(manager *plan.Manager) {
 	t.manager = manager
 }
 
+// SetContractManager enables contract fields for plans.
+func (t *EnterPlanModeTool) SetContractManager(manager *contract.Manager) {
+	t.contracts = manager
+}
+
 func (t *EnterPlanModeTool) Name() string {
 	return "enter_plan_mode"
 }
@@ -76,6 +84,19 @@ func (t *EnterPlanModeTool) Declaration() *genai.FunctionDeclaration {
 					Type:        genai.TypeString,
 					Description: "The original user request that prompted this plan",
 				},
+				"contract_name": {
+					Type:        genai.TypeString,
+					Description: "Optional: name of a contract approved together with the plan (for tasks with clear inputs and outputs)",
+				},
+				"intent": {
+					Type:        genai.TypeString,
+					Description: "Contract intent: what the change must achieve",
+				},
+				"boundaries":     contractBoundarySchema(),
+				"preconditions":  contractClauseSchema("Contract preconditions"),
+				"postconditions": contractClauseSchema("Contract postconditions"),
+				"invariants":     contractClauseSchema("Contract invariants"),
+				"examples":       contractClauseSchema("Contract examples with verification commands"),
 			},
 			Required: []string{"title", "steps"},
 		},
@@ -108,6 +129,12 @@ func (t *EnterPlanModeTool) Validate(args map[string]any) error {
 		}
 	}
 
+	if t.contracts != nil && hasContractArgs(args) {
+		if err := parseContractArgs(args, "contract_name").Validate(); err != nil {
+			return NewValidationError("contract_name", err.Error())
+		}
+	}
+
 	return nil
 }
 
@@ -166,16 +193,37 @@ func (t *EnterPlanModeTool) Execute(ctx context.Context, args map[string]any) (T
 		}
 	}
 
+	// Draft the contract so it is shown and approved together with the plan
+	if t.contracts != nil && hasContractArgs(args) {
+		c := parseContractArgs(args, "contract_name")
+		c.PlanID = p.ID
+		if err := t.contracts.Draft(c); err != nil {
+			logging.Warn("failed to store plan contract", "plan", p.ID, "error", err)
+		} else {
+			p.ContractID = c.ID
+		}
+	}
+
 	// Request approval
 	decision, err := t.manager.RequestApproval(ctx)
 	if err != nil {
+		t.rejectContract(p)
 		t.manager.ClearPlan()
 		return NewErrorResult(fmt.Sprintf("approval request failed: %s", err)), nil
 	}
 
+	if decision != plan.ApprovalApproved {
+		t.rejectContract(p)
+	}
+
 	// Handle decision
 	switch decision {
 	case plan.ApprovalApproved:
+		if p.ContractID != "" {
+			if err := t.contracts.Activate(p.ContractID); err != nil {
+				logging.Warn("failed to activate plan contract", "contract", p.ContractID, "error", err)
+			}
+		}
 		// Signal context clear for focused plan execution
 		t.manager.RequestContextClear(p)
 		return NewSuccessResultWithData(
@@ -216,6 +264,16 @@ func (t *EnterPlanModeTool) Execute(ctx context.Context, args map[string]any) (T
 	}
 }
 
+// rejectContract marks the plan's draft contract as rejected.
+func (t *EnterPlanModeTool) rejectContract(p *plan.Plan) {
+	if t.contracts == nil || p.ContractID == "" {
+		return
+	}
+	if err := t.contracts.Reject(p.ContractID); err != nil {
+		logging.Warn("failed to reject plan contract", "contract", p.ContractID, "error", err)
+	}
+}
+
 // UpdatePlanProgressTool updates the progress of plan execution.
 type UpdatePlanProgressTool struct {
 	manager *plan.Manager
@@ -317,7 +375,9 @@ func (t *UpdatePlanProgressTool) Execute(ctx context.Context, args map[string]an
 		), nil
 
 	case "complete":
-		t.manager.CompleteStep(stepID, output)
+		if err := t.manager.CompleteStep(stepID, output); err != nil {
+			return NewErrorResult(fmt.Sprintf("step %d not completed: %s", stepID, err)), nil
+		}
 		return NewSuccessResultWithData(
 			fmt.Sprintf("Completed step %d: %s", stepID, step.Title),
 			buildProgressData(p, step, "completed"),
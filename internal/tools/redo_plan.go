@@ -80,7 +80,7 @@ func (t *RedoPlanTool) Execute(ctx context.Context, args map[string]any) (ToolRe
 				break
 			}
 			if change != nil {
-				redoneFiles = append(redoneFiles, change.FilePath)
+				redoneFiles = append(redoneFiles, change.Paths()...)
 			}
 		}
 	}
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/parser"
@@ -13,6 +14,7 @@ import (
 
 	"google.golang.org/genai"
 
+	"gokin/internal/gorefactor"
 	"gokin/internal/security"
 	"gokin/internal/undo"
 )
@@ -57,7 +59,7 @@ func (t *RefactorTool) Name() string {
 }
 
 func (t *RefactorTool) Description() string {
-	return "Performs intelligent code refactoring: rename functions/variables, extract code, find references. Uses AST analysis for safe transformations."
+	return "Performs intelligent code refactoring: rename functions/variables, extract code, find references. Go rename and find_refs are type-checked: only uses of the identifier at file_path:line:column are affected, across all packages and tests of the module."
 }
 
 func (t *RefactorTool) Declaration() *genai.FunctionDeclaration {
@@ -74,11 +76,19 @@ func (t *RefactorTool) Declaration() *genai.FunctionDeclaration {
 				},
 				"file_path": {
 					Type:        genai.TypeString,
-					Description: "Path to the file to refactor (for rename/extract/inline)",
+					Description: "Path to the file to refactor (for rename/extract/inline). For Go rename/find_refs, the file containing the identifier",
+				},
+				"line": {
+					Type:        genai.TypeInteger,
+					Description: "Line of the identifier (Go rename/find_refs). Omit to use the top-level declaration of old_name/target_name in file_path",
+				},
+				"column": {
+					Type:        genai.TypeInteger,
+					Description: "Column (1-based byte offset) of the identifier on line (Go rename/find_refs). Omit to use the first occurrence of the name on line",
 				},
 				"pattern": {
 					Type:        genai.TypeString,
-					Description: "Glob pattern for multi-file operations (e.g., '**/*.go')",
+					Description: "Glob pattern for multi-file operations on non-Go files (e.g., '**/*.ts')",
 				},
 				"old_name": {
 					Type:        genai.TypeString,
@@ -123,10 +133,12 @@ func (t *RefactorTool) Validate(args map[string]any) error {
 			return NewValidationError("file_path", "is required for this operation")
 		}
 	case "find_refs":
-		// Can work with just pattern and target_name
+		// Can work with just pattern and target_name, or a Go position
 		targetName, _ := GetString(args, "target_name")
-		if targetName == "" {
-			return NewValidationError("target_name", "is required for find_refs")
+		filePath, _ := GetString(args, "file_path")
+		line, _ := GetInt(args, "line")
+		if targetName == "" && (!strings.HasSuffix(filePath, ".go") || line <= 0) {
+			return NewValidationError("target_name", "is required for find_refs unless file_path and line point at a Go identifier")
 		}
 	}
 
@@ -157,6 +169,10 @@ func (t *RefactorTool) executeRename(ctx context.Context, args map[string]any) (
 	newName, _ := GetString(args, "new_name")
 	pattern, _ := GetString(args, "pattern")
 
+	if strings.HasSuffix(filePath, ".go") {
+		return t.renameGo(ctx, args)
+	}
+
 	if oldName == "" || newName == "" {
 		return NewErrorResult("old_name and new_name are required for rename"), nil
 	}
@@ -198,68 +214,212 @@ func (t *RefactorTool) executeRename(ctx context.Context, args map[string]any) (
 		oldName, newName, len(results), strings.Join(results, "\n"))), nil
 }
 
-// renameInFile performs AST-based renaming in a single file.
-func (t *RefactorTool) renameInFile(_ context.Context, filePath, oldName, newName string) (int, error) {
-	// Read file
-	content, err := os.ReadFile(filePath)
+// renameGo renames a Go object and all its uses in the module after type
+// checking, then applies every file edit as one undoable change.
+func (t *RefactorTool) renameGo(ctx context.Context, args map[string]any) (ToolResult, error) {
+	target, err := t.goTarget(args, "old_name")
 	if err != nil {
-		return 0, err
+		return NewErrorResult(err.Error()), nil
+	}
+	newName, _ := GetString(args, "new_name")
+	if newName == "" {
+		return NewErrorResult("new_name is required for rename"), nil
 	}
 
-	// Parse AST only for Go files
-	if strings.HasSuffix(filePath, ".go") {
-		return t.renameInGoFile(filePath, content, oldName, newName)
+	result, err := gorefactor.Rename(ctx, target, newName)
+	if err != nil {
+		var conflict *gorefactor.ConflictError
+		if errors.As(err, &conflict) {
+			return NewErrorResult(fmt.Sprintf("cannot rename to '%s': %s", newName, err)), nil
+		}
+		return NewErrorResult(fmt.Sprintf("rename failed: %s", err)), nil
 	}
 
-	// For non-Go files, use simple text replacement with scope awareness
-	return t.renameInTextFile(filePath, content, oldName, newName)
+	for _, e := range result.Edits {
+		if t.pathValidator != nil {
+			if _, err := t.pathValidator.ValidateFile(e.Path); err != nil {
+				return NewErrorResult(fmt.Sprintf("rename would modify %s: %s", e.Path, err)), nil
+			}
+		}
+	}
+
+	// One preview for all files
+	edits := result.Edits
+	var reviewNote string
+	if t.diffEnabled && t.diffHandler != nil && !ShouldSkipDiff(ctx) {
+		var err error
+		edits, reviewNote, err = reviewFileEdits(ctx, t.diffHandler, result.Edits, "refactor", t.relPath)
+		if err != nil {
+			return NewErrorResult(fmt.Sprintf("diff preview error: %s", err)), nil
+		}
+		if len(edits) == 0 {
+			return NewErrorResult("changes rejected by user"), nil
+		}
+	}
+
+	changes := make([]undo.FileChange, 0, len(edits))
+	occurrences := 0
+	for _, e := range edits {
+		if err := AtomicWrite(e.Path, e.NewContent, 0644); err != nil {
+			// Restore the files already written
+			for _, c := range changes {
+				_ = AtomicWrite(c.FilePath, c.OldContent, 0644)
+			}
+			return NewErrorResult(fmt.Sprintf("error writing %s: %s (no files changed)", e.Path, err)), nil
+		}
+		changes = append(changes, *undo.NewFileChange(e.Path, "refactor_rename", e.OldContent, e.NewContent, false))
+		occurrences += e.Occurrences
+	}
+	if t.undoManager != nil {
+		if len(changes) == 1 {
+			t.undoManager.Record(changes[0])
+		} else {
+			t.undoManager.Record(*undo.NewTransaction("refactor_rename", changes))
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Renamed %s to '%s': %d occurrence(s) in %d file(s):\n",
+		result.Query.Describe(), newName, occurrences, len(edits)))
+	for _, e := range edits {
+		sb.WriteString(fmt.Sprintf("%s: %d changes\n", t.relPath(e.Path), e.Occurrences))
+	}
+	writeTypeErrorNote(&sb, result.Query.TypeErrors())
+	return NewSuccessResult(strings.TrimRight(sb.String(), "\n") + reviewNote), nil
 }
 
-// renameInGoFile performs AST-based renaming for Go code.
-func (t *RefactorTool) renameInGoFile(filePath string, content []byte, oldName, newName string) (int, error) {
-	fset := token.NewFileSet()
-	node, err := parser.ParseFile(fset, filePath, content, parser.ParseComments)
-	if err != nil {
-		// Fall back to text-based replacement
-		return t.renameInTextFile(filePath, content, oldName, newName)
+// reviewFileEdits lets the user review multi-file edits hunk by hunk when
+// the handler supports it. It returns the edits to write, with rejected files
+// dropped and partially applied files holding the reviewed content, and a
+// note on what was not applied as proposed. Other handlers must approve
+// every file.
+func reviewFileEdits(ctx context.Context, handler DiffHandler, edits []gorefactor.FileEdit, operation string, relPath func(string) string) ([]gorefactor.FileEdit, string, error) {
+	hunks, ok := handler.(HunkDiffHandler)
+	if !ok {
+		approved, err := promptFileEdits(ctx, handler, edits, operation)
+		if err != nil || !approved {
+			return nil, "", err
+		}
+		return edits, "", nil
 	}
 
-	// Find all identifiers matching oldName
-	var positions []token.Pos
-	ast.Inspect(node, func(n ast.Node) bool {
-		if ident, ok := n.(*ast.Ident); ok && ident.Name == oldName {
-			positions = append(positions, ident.Pos())
+	files := make([]FileDiff, len(edits))
+	for i, e := range edits {
+		files[i] = FileDiff{FilePath: e.Path, OldContent: string(e.OldContent), NewContent: string(e.NewContent)}
+	}
+	var reviews []*DiffReview
+	if len(files) > 1 {
+		var err error
+		if reviews, err = hunks.ReviewMultiDiff(ctx, files, operation); err != nil {
+			return nil, "", err
 		}
-		return true
-	})
+	} else {
+		review, err := hunks.ReviewDiff(ctx, files[0].FilePath, files[0].OldContent, files[0].NewContent, operation, false)
+		if err != nil {
+			return nil, "", err
+		}
+		reviews = []*DiffReview{review}
+	}
 
-	if len(positions) == 0 {
-		return 0, nil
+	var reviewed []gorefactor.FileEdit
+	var note strings.Builder
+	for i, review := range reviews {
+		if !review.Approved {
+			note.WriteString(fmt.Sprintf("\n\nThe user rejected the change to %s. It was NOT applied; do not re-apply it.", relPath(edits[i].Path)))
+			continue
+		}
+		e := edits[i]
+		e.NewContent = []byte(review.Content)
+		reviewed = append(reviewed, e)
+		note.WriteString(review.Note())
 	}
+	return reviewed, note.String(), nil
+}
 
-	// Apply replacements in reverse order to preserve positions
-	newContent := string(content)
-	for i := len(positions) - 1; i >= 0; i-- {
-		pos := positions[i]
-		position := fset.Position(pos)
-		offset := position.Offset - 1 // Convert to 0-indexed
+// promptFileEdits shows all edits in one diff preview when the handler
+// supports it, otherwise file by file. Returns true only if every file is
+// approved.
+func promptFileEdits(ctx context.Context, handler DiffHandler, edits []gorefactor.FileEdit, operation string) (bool, error) {
+	if multi, ok := handler.(MultiDiffHandler); ok && len(edits) > 1 {
+		files := make([]FileDiff, len(edits))
+		for i, e := range edits {
+			files[i] = FileDiff{FilePath: e.Path, OldContent: string(e.OldContent), NewContent: string(e.NewContent)}
+		}
+		return multi.PromptMultiDiff(ctx, files, operation)
+	}
 
-		// Replace identifier
-		newContent = newContent[:offset] + newName + newContent[offset+len(oldName):]
+	for _, e := range edits {
+		approved, err := handler.PromptDiff(ctx, e.Path, string(e.OldContent), string(e.NewContent), operation, false)
+		if err != nil || !approved {
+			return false, err
+		}
 	}
+	return true, nil
+}
 
-	// Write back (diff preview requires context, skip for now)
-	if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
-		return 0, err
+// goTarget builds a Go identifier target from file_path, line, column and nameArg.
+func (t *RefactorTool) goTarget(args map[string]any, nameArg string) (gorefactor.Target, error) {
+	filePath, _ := GetString(args, "file_path")
+	line, _ := GetInt(args, "line")
+	column, _ := GetInt(args, "column")
+	name, _ := GetString(args, nameArg)
+
+	if line <= 0 && name == "" {
+		return gorefactor.Target{}, fmt.Errorf("%s or line is required", nameArg)
+	}
+	if !filepath.IsAbs(filePath) && t.workDir != "" {
+		filePath = filepath.Join(t.workDir, filePath)
+	}
+	if t.pathValidator != nil {
+		validPath, err := t.pathValidator.ValidateFile(filePath)
+		if err != nil {
+			return gorefactor.Target{}, fmt.Errorf("path validation failed: %s", err)
+		}
+		filePath = validPath
 	}
+	return gorefactor.Target{File: filePath, Line: line, Column: column, Name: name}, nil
+}
 
-	// Record for undo
-	if t.undoManager != nil {
-		change := undo.NewFileChange(filePath, "refactor_rename", content, []byte(newContent), false)
-		t.undoManager.Record(*change)
+// relPath returns path relative to the working directory when possible.
+func (t *RefactorTool) relPath(path string) string {
+	if t.workDir != "" {
+		if rel, err := filepath.Rel(t.workDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
 	}
+	return path
+}
 
-	return len(positions), nil
+// writeTypeErrorNote warns that type errors may hide references.
+func writeTypeErrorNote(sb *strings.Builder, typeErrors []error) {
+	if len(typeErrors) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\nNote: %d type error(s) in the affected packages; references in ill-typed code may be missed:\n", len(typeErrors)))
+	for i, err := range typeErrors {
+		if i == 3 {
+			sb.WriteString("  ...\n")
+			break
+		}
+		sb.WriteString(fmt.Sprintf("  %s\n", err))
+	}
+}
+
+// renameInFile performs word-boundary renaming in a single non-Go file.
+func (t *RefactorTool) renameInFile(_ context.Context, filePath, oldName, newName string) (int, error) {
+	// Go identifiers are only renamed by type (see renameGo)
+	if strings.HasSuffix(filePath, ".go") {
+		return 0, fmt.Errorf("Go files are renamed with type checking; pass file_path and line of the identifier instead of a pattern")
+	}
+
+	// Read file
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return 0, err
+	}
+
+	// Use simple text replacement with scope awareness
+	return t.renameInTextFile(filePath, content, oldName, newName)
 }
 
 // renameInTextFile performs scope-aware text replacement.
@@ -359,9 +519,14 @@ func (t *RefactorTool) executeExtract(_ context.Context, args map[string]any) (T
 }
 
 // executeFindRefs finds all references to a function/variable.
-func (t *RefactorTool) executeFindRefs(_ context.Context, args map[string]any) (ToolResult, error) {
+func (t *RefactorTool) executeFindRefs(ctx context.Context, args map[string]any) (ToolResult, error) {
 	targetName, _ := GetString(args, "target_name")
 	pattern, _ := GetString(args, "pattern")
+	filePath, _ := GetString(args, "file_path")
+
+	if strings.HasSuffix(filePath, ".go") {
+		return t.findRefsGo(ctx, args)
+	}
 
 	if targetName == "" {
 		return NewErrorResult("target_name is required"), nil
@@ -415,6 +580,37 @@ func (t *RefactorTool) executeFindRefs(_ context.Context, args map[string]any) (
 		targetName, len(refs), strings.Join(refs, "\n\n"))), nil
 }
 
+// findRefsGo lists the uses of a Go object found by type checking.
+func (t *RefactorTool) findRefsGo(ctx context.Context, args map[string]any) (ToolResult, error) {
+	target, err := t.goTarget(args, "target_name")
+	if err != nil {
+		return NewErrorResult(err.Error()), nil
+	}
+
+	query, err := gorefactor.FindReferences(ctx, target)
+	if err != nil {
+		return NewErrorResult(fmt.Sprintf("find_refs failed: %s", err)), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Found %d reference(s) to %s in %d file(s):\n",
+		len(query.Refs), query.Describe(), query.Files()))
+	lastFile := ""
+	for _, ref := range query.Refs {
+		if ref.File != lastFile {
+			sb.WriteString(fmt.Sprintf("\n%s:\n", t.relPath(ref.File)))
+			lastFile = ref.File
+		}
+		marker := ""
+		if ref.IsDecl {
+			marker = " (declaration)"
+		}
+		sb.WriteString(fmt.Sprintf("  %d:%d%s: %s\n", ref.Line, ref.Column, marker, ref.Text))
+	}
+	writeTypeErrorNote(&sb, query.TypeErrors())
+	return NewSuccessResult(strings.TrimRight(sb.String(), "\n")), nil
+}
+
 // executeInline inlines a function by replacing call sites with the function body.
 func (t *RefactorTool) executeInline(_ context.Context, args map[string]any) (ToolResult, error) {
 	filePath, _ := GetString(args, "file_path")
@@ -80,6 +80,14 @@ func (r *Registry) Register(tool Tool) error {
 	return nil
 }
 
+// Unregister removes a tool from the registry. It is a no-op for unknown names.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.tools, name)
+}
+
 // MustRegister adds a tool to the registry and logs a warning on error.
 func (r *Registry) MustRegister(tool Tool) {
 	if err := r.Register(tool); err != nil {
@@ -136,6 +144,7 @@ var toolSetDefinitions = map[ToolSet][]string{
 	ToolSetPlanning: {
 		"enter_plan_mode", "update_plan_progress", "get_plan_status",
 		"exit_plan_mode", "undo_plan", "redo_plan",
+		"contract_propose", "contract_verify", "contract_status",
 		"task", "task_output", "task_stop",
 	},
 	ToolSetAgent: {
@@ -147,6 +156,7 @@ var toolSetDefinitions = map[ToolSet][]string{
 	ToolSetAdvanced: {
 		"batch", "refactor", "check_impact",
 		"verify_code", "run_tests",
+		"lsp_definition", "lsp_references", "lsp_hover", "lsp_symbols", "lsp_rename",
 	},
 	ToolSetSemantic: {
 		"semantic_search", "code_graph",
@@ -270,6 +280,9 @@ func DefaultRegistry(workDir string) *Registry {
 	// Agent Scratchpad tool (Phase 7)
 	r.MustRegister(NewUpdateScratchpadTool(nil))
 
+	// Session history search
+	r.MustRegister(NewHistorySearchTool(nil))
+
 	return r
 }
 
@@ -7,14 +7,19 @@ import (
 
 	"google.golang.org/genai"
 
+	"gokin/internal/client"
 	"gokin/internal/semantic"
 )
 
-// SemanticSearchTool performs semantic search across indexed files.
+// SemanticSearchTool performs hybrid keyword and semantic search across indexed files.
 type SemanticSearchTool struct {
-	indexer *semantic.EnhancedIndexer
-	workDir string
-	topK    int
+	indexer    *semantic.EnhancedIndexer
+	workDir    string
+	topK       int
+	hybrid     bool
+	rerank     bool
+	rerankTopN int
+	client     client.Client
 }
 
 // NewSemanticSearchTool creates a new semantic search tool.
@@ -23,18 +28,34 @@ func NewSemanticSearchTool(indexer *semantic.EnhancedIndexer, workDir string, to
 		topK = 10
 	}
 	return &SemanticSearchTool{
-		indexer: indexer,
-		workDir: workDir,
-		topK:    topK,
+		indexer:    indexer,
+		workDir:    workDir,
+		topK:       topK,
+		hybrid:     true,
+		rerankTopN: 20,
 	}
 }
 
+// SetRetrieval configures the default search mode and LLM reranking.
+func (t *SemanticSearchTool) SetRetrieval(hybrid, rerank bool, rerankTopN int) {
+	t.hybrid = hybrid
+	t.rerank = rerank
+	if rerankTopN > 0 {
+		t.rerankTopN = rerankTopN
+	}
+}
+
+// SetClient sets the model client used for reranking.
+func (t *SemanticSearchTool) SetClient(c client.Client) {
+	t.client = c
+}
+
 func (t *SemanticSearchTool) Name() string {
 	return "semantic_search"
 }
 
 func (t *SemanticSearchTool) Description() string {
-	return "Performs semantic search across the codebase using embeddings. Finds code that is conceptually similar to the query, even if it doesn't contain the exact keywords."
+	return "Searches the codebase by meaning and by keywords. Combines embedding similarity with BM25 keyword ranking, so it finds both conceptually similar code and exact identifiers. Supports filters by path glob, language and symbol kind."
 }
 
 func (t *SemanticSearchTool) Declaration() *genai.FunctionDeclaration {
@@ -52,6 +73,27 @@ func (t *SemanticSearchTool) Declaration() *genai.FunctionDeclaration {
 					Type:        genai.TypeInteger,
 					Description: "Number of results to return (default: 10)",
 				},
+				"mode": {
+					Type:        genai.TypeString,
+					Description: "Ranking signals: 'hybrid' (keywords + embeddings, default), 'vector' (embeddings only) or 'lexical' (keywords only)",
+					Enum:        []string{semantic.SearchModeHybrid, semantic.SearchModeVector, semantic.SearchModeLexical},
+				},
+				"path": {
+					Type:        genai.TypeString,
+					Description: "Only search files matching this glob, relative to the project (e.g., 'internal/**/*.go', '*_test.go')",
+				},
+				"language": {
+					Type:        genai.TypeString,
+					Description: "Only search files in this language (e.g., 'go', 'python', 'typescript')",
+				},
+				"kind": {
+					Type:        genai.TypeString,
+					Description: "Only return chunks declaring this symbol kind: function, method, class, struct, interface, type, enum, const, variable",
+				},
+				"rerank": {
+					Type:        genai.TypeBoolean,
+					Description: "Rerank the best results with the language model for higher precision (slower, costs a request)",
+				},
 			},
 			Required: []string{"query"},
 		},
@@ -63,6 +105,10 @@ func (t *SemanticSearchTool) Validate(args map[string]any) error {
 	if !ok || query == "" {
 		return NewValidationError("query", "is required")
 	}
+	if mode, ok := GetString(args, "mode"); ok && mode != "" &&
+		mode != semantic.SearchModeHybrid && mode != semantic.SearchModeVector && mode != semantic.SearchModeLexical {
+		return NewValidationError("mode", "must be 'hybrid', 'vector' or 'lexical'")
+	}
 	return nil
 }
 
@@ -70,6 +116,24 @@ func (t *SemanticSearchTool) Execute(ctx context.Context, args map[string]any) (
 	query, _ := GetString(args, "query")
 	topK := GetIntDefault(args, "top_k", t.topK)
 
+	defaultMode := semantic.SearchModeVector
+	if t.hybrid {
+		defaultMode = semantic.SearchModeHybrid
+	}
+	opts := semantic.SearchOptions{
+		TopK: topK,
+		Mode: GetStringDefault(args, "mode", defaultMode),
+		Filter: semantic.SearchFilter{
+			PathGlob: GetStringDefault(args, "path", ""),
+			Language: GetStringDefault(args, "language", ""),
+			Kind:     GetStringDefault(args, "kind", ""),
+		},
+	}
+	if GetBoolDefault(args, "rerank", t.rerank) && t.client != nil {
+		opts.Reranker = semantic.NewLLMReranker(t.complete, t.workDir)
+		opts.RerankTopN = t.rerankTopN
+	}
+
 	if t.indexer == nil {
 		return NewErrorResult("semantic search is not initialized - enable it in config"), nil
 	}
@@ -80,7 +144,7 @@ func (t *SemanticSearchTool) Execute(ctx context.Context, args map[string]any) (
 	}
 
 	// Perform search
-	results, err := t.indexer.Search(ctx, query, topK)
+	results, err := t.indexer.HybridSearch(ctx, query, opts)
 	if err != nil {
 		return NewErrorResult(fmt.Sprintf("search failed: %s", err)), nil
 	}
@@ -91,11 +155,15 @@ func (t *SemanticSearchTool) Execute(ctx context.Context, args map[string]any) (
 
 	// Format results
 	var output strings.Builder
-	output.WriteString(fmt.Sprintf("Found %d results for: %q\n\n", len(results), query))
+	output.WriteString(fmt.Sprintf("Found %d results for: %q (%s)\n\n", len(results), query, opts.Mode))
 
 	for i, result := range results {
 		output.WriteString(fmt.Sprintf("### Result %d (score: %.3f)\n", i+1, result.Score))
 		output.WriteString(fmt.Sprintf("**File:** %s (lines %d-%d)\n", result.FilePath, result.LineStart, result.LineEnd))
+		if result.Symbol != "" {
+			output.WriteString(fmt.Sprintf("**Symbol:** %s %s\n", result.Kind, result.Symbol))
+		}
+		output.WriteString(fmt.Sprintf("**Matched:** %s\n", result.SignalSummary()))
 		output.WriteString("```\n")
 
 		// Truncate content if too long
@@ -112,3 +180,23 @@ func (t *SemanticSearchTool) Execute(ctx context.Context, args map[string]any) (
 
 	return NewSuccessResultWithData(output.String(), results), nil
 }
+
+// rerankSystemInstruction replaces the agent's system prompt for reranking.
+const rerankSystemInstruction = "You rank code search results by relevance to a query. Reply only in the format the prompt asks for."
+
+// complete sends a one-off prompt to the model for reranking. A separate
+// client keeps the agent's system prompt and tools out of the request.
+func (t *SemanticSearchTool) complete(ctx context.Context, prompt string) (string, error) {
+	c := t.client.WithModel(t.client.GetModel())
+	c.SetTools(nil)
+	c.SetSystemInstruction(rerankSystemInstruction)
+	stream, err := c.SendMessage(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	resp, err := stream.Collect()
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
@@ -109,6 +109,11 @@ func (t *TaskTool) Declaration() *genai.FunctionDeclaration {
 					Type:        genai.TypeString,
 					Description: "Agent ID to resume from previous execution. If provided, continues from saved state.",
 				},
+				"isolation": {
+					Type:        genai.TypeString,
+					Description: "Set to 'worktree' to run the agent in its own git worktree; its changes are merged back when it finishes. Use for agents editing files in parallel.",
+					Enum:        []string{"worktree"},
+				},
 			},
 			Required: []string{"prompt"},
 		},
@@ -139,6 +144,10 @@ func (t *TaskTool) Validate(args map[string]any) error {
 		return NewValidationError("subagent_type", "must be 'explore', 'bash', 'general', 'plan', or 'claude-code-guide'")
 	}
 
+	if isolation := GetStringDefault(args, "isolation", ""); isolation != "" && isolation != "worktree" {
+		return NewValidationError("isolation", "must be 'worktree'")
+	}
+
 	return nil
 }
 
@@ -155,6 +164,10 @@ func (t *TaskTool) Execute(ctx context.Context, args map[string]any) (ToolResult
 	model := GetStringDefault(args, "model", "")
 	resume := GetStringDefault(args, "resume", "")
 
+	if GetStringDefault(args, "isolation", "") == "worktree" {
+		ctx = ContextWithWorktreeIsolation(ctx)
+	}
+
 	// If resuming an existing agent
 	if resume != "" {
 		if runInBackground {
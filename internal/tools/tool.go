@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"fmt"
+	"path"
 
 	"google.golang.org/genai"
 
@@ -224,6 +225,42 @@ type DiffHandler interface {
 	PromptDiff(ctx context.Context, filePath, oldContent, newContent, toolName string, isNewFile bool) (bool, error)
 }
 
+// FileDiff is a proposed change to one file of a multi-file diff preview.
+type FileDiff struct {
+	FilePath   string
+	OldContent string
+	NewContent string
+	IsNewFile  bool
+}
+
+// MultiDiffHandler is implemented by diff handlers that can preview changes
+// to several files in a single prompt.
+type MultiDiffHandler interface {
+	// PromptMultiDiff displays all diffs and waits for user approval.
+	// Returns true only if the user approved every file.
+	PromptMultiDiff(ctx context.Context, files []FileDiff, toolName string) (bool, error)
+}
+
+// DiffReview is the outcome of a hunk-level diff review of one file.
+type DiffReview struct {
+	FilePath string
+	Approved bool     // True if at least one hunk is applied
+	Content  string   // Content to write, with rejected hunks reverted and edited hunks replaced
+	Rejected []string // Rejected hunks as unified diff fragments
+	Edited   []string // Edited hunks as unified diff fragments of the lines written
+}
+
+// HunkDiffHandler is implemented by diff handlers that let the user accept,
+// reject or edit individual hunks instead of whole files.
+type HunkDiffHandler interface {
+	// ReviewDiff displays a diff preview and returns the reviewed change.
+	ReviewDiff(ctx context.Context, filePath, oldContent, newContent, toolName string, isNewFile bool) (*DiffReview, error)
+
+	// ReviewMultiDiff displays all diffs and returns one review per file, in
+	// the order of files.
+	ReviewMultiDiff(ctx context.Context, files []FileDiff, toolName string) ([]*DiffReview, error)
+}
+
 // skipDiffKey is a context key to signal that diff approval should be skipped.
 // Used during delegated plan execution where the plan itself was already approved.
 type skipDiffKeyType struct{}
@@ -239,6 +276,63 @@ func ShouldSkipDiff(ctx context.Context) bool {
 	return v
 }
 
+// allowedToolsKeyType is a context key restricting which tools may run.
+// Used by custom commands that declare allowed-tools.
+type allowedToolsKeyType struct{}
+
+// ContextWithAllowedTools returns a context in which only tools matching one
+// of the patterns (tool names or path.Match globs) may run.
+func ContextWithAllowedTools(ctx context.Context, patterns []string) context.Context {
+	return context.WithValue(ctx, allowedToolsKeyType{}, patterns)
+}
+
+// IsToolAllowed reports whether the named tool may run in this context.
+func IsToolAllowed(ctx context.Context, name string) bool {
+	patterns, ok := ctx.Value(allowedToolsKeyType{}).([]string)
+	if !ok {
+		return true
+	}
+	for _, p := range patterns {
+		if matched, _ := path.Match(p, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// callerKeyType is a context key identifying who requested a tool call.
+type callerKeyType struct{}
+
+// ContextWithCaller returns a context that attributes tool calls to caller
+// (e.g. "mcp:zed") in the audit log. Calls made by the model have no caller.
+func ContextWithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKeyType{}, caller)
+}
+
+// CallerFromContext returns the caller set by ContextWithCaller, if any.
+func CallerFromContext(ctx context.Context) string {
+	v, _ := ctx.Value(callerKeyType{}).(string)
+	return v
+}
+
+// worktreeKeyType is a context key requesting that agents spawned with the
+// context run in their own git worktree.
+type worktreeKeyType struct{}
+
+// ContextWithWorktreeIsolation returns a context in which agents spawned by
+// the runner edit an isolated git worktree that is merged back when they
+// finish.
+func ContextWithWorktreeIsolation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, worktreeKeyType{}, true)
+}
+
+// WantsWorktreeIsolation reports whether agents spawned with this context
+// should run in their own git worktree.
+func WantsWorktreeIsolation(ctx context.Context) bool {
+	v, _ := ctx.Value(worktreeKeyType{}).(bool)
+	return v
+}
+
 // StreamingToolResult represents a tool result that streams its output.
 type StreamingToolResult struct {
 	Chunks <-chan string    // Chunks of output
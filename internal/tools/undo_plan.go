@@ -95,7 +95,7 @@ func (t *UndoPlanTool) Execute(ctx context.Context, args map[string]any) (ToolRe
 				break
 			}
 			if change != nil {
-				undoneFiles = append(undoneFiles, change.FilePath)
+				undoneFiles = append(undoneFiles, change.Paths()...)
 			}
 		}
 	}
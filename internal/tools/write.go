@@ -8,6 +8,7 @@ import (
 
 	"google.golang.org/genai"
 
+	"gokin/internal/lsp"
 	"gokin/internal/security"
 	"gokin/internal/undo"
 )
@@ -19,6 +20,7 @@ type WriteTool struct {
 	diffHandler   DiffHandler
 	diffEnabled   bool
 	pathValidator *security.PathValidator
+	lspManager    *lsp.Manager
 }
 
 // NewWriteTool creates a new WriteTool instance.
@@ -44,6 +46,12 @@ func (t *WriteTool) SetDiffEnabled(enabled bool) {
 	t.diffEnabled = enabled
 }
 
+// SetLSPManager sets the language servers that report diagnostics for
+// written files.
+func (t *WriteTool) SetLSPManager(manager *lsp.Manager) {
+	t.lspManager = manager
+}
+
 // SetAllowedDirs sets additional allowed directories for path validation.
 func (t *WriteTool) SetAllowedDirs(dirs []string) {
 	allDirs := append([]string{t.workDir}, dirs...)
@@ -141,14 +149,18 @@ func (t *WriteTool) Execute(ctx context.Context, args map[string]any) (ToolResul
 
 	// Show diff preview and wait for approval if enabled
 	// Skip diff approval when running in delegated plan execution (context flag)
+	var reviewNote string
 	if t.diffEnabled && t.diffHandler != nil && !ShouldSkipDiff(ctx) {
-		approved, err := t.diffHandler.PromptDiff(ctx, filePath, string(oldContent), finalContent, "write", isNew)
+		review, err := reviewDiff(ctx, t.diffHandler, filePath, string(oldContent), finalContent, "write", isNew)
 		if err != nil {
 			return NewErrorResult(fmt.Sprintf("diff preview error: %s", err)), nil
 		}
-		if !approved {
+		if !review.Approved {
 			return NewErrorResult("changes rejected by user"), nil
 		}
+		// The user may have rejected or edited some hunks
+		finalContent = review.Content
+		reviewNote = review.Note()
 	}
 
 	// Write file atomically to prevent data corruption on interruption
@@ -173,5 +185,5 @@ func (t *WriteTool) Execute(ctx context.Context, args map[string]any) (ToolResul
 		status = fmt.Sprintf("Updated file: %s (%d bytes)", filePath, len(content))
 	}
 
-	return NewSuccessResult(status), nil
+	return NewSuccessResult(status + reviewNote + diagnosticsNote(ctx, t.lspManager, filePath, newContent)), nil
 }
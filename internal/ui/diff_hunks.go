@@ -0,0 +1,404 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// HunkDecision is the user's decision on a single hunk of a diff preview.
+type HunkDecision int
+
+const (
+	// HunkPending hunks are applied unless the whole diff is rejected.
+	HunkPending HunkDecision = iota
+	HunkAccepted
+	HunkRejected
+	// HunkEdited hunks are applied with the user's replacement lines.
+	HunkEdited
+)
+
+// DiffHunk is one contiguous block of changed lines.
+type DiffHunk struct {
+	OldStart int      // First removed line in the old content (1-based)
+	NewStart int      // First added line in the proposed content (1-based)
+	Removed  []string // Original lines, without line endings
+	Added    []string // Proposed lines, without line endings
+	Edited   []string // Replacement for Added when Decision is HunkEdited
+	Decision HunkDecision
+
+	start, end int // Range of the hunk's lines in hunkSet.lines
+}
+
+// Applied reports whether the hunk's change is kept.
+func (h DiffHunk) Applied() bool {
+	return h.Decision != HunkRejected
+}
+
+// Lines returns the lines the hunk adds: the user's lines for edited hunks,
+// the proposal otherwise.
+func (h DiffHunk) Lines() []string {
+	if h.Decision == HunkEdited {
+		return h.Edited
+	}
+	return h.Added
+}
+
+// Header returns the unified diff header of the hunk.
+func (h DiffHunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, len(h.Removed), h.NewStart, len(h.Lines()))
+}
+
+// Patch renders the hunk as a unified diff fragment.
+func (h DiffHunk) Patch() string {
+	var sb strings.Builder
+	sb.WriteString(h.Header())
+	for _, line := range h.Removed {
+		sb.WriteString("\n-" + line)
+	}
+	for _, line := range h.Lines() {
+		sb.WriteString("\n+" + line)
+	}
+	return sb.String()
+}
+
+// DiffResult is the outcome of a diff preview.
+type DiffResult struct {
+	Decision DiffDecision
+	// Content is the content to write: the proposal with rejected hunks
+	// reverted and edited hunks replaced.
+	Content string
+	Hunks   []DiffHunk
+}
+
+// Partial reports whether the user rejected or edited some hunks.
+func (r DiffResult) Partial() bool {
+	for _, h := range r.Hunks {
+		if h.Decision == HunkRejected || h.Decision == HunkEdited {
+			return true
+		}
+	}
+	return false
+}
+
+// hunkLine is one line of a line-level diff, with its line ending.
+type hunkLine struct {
+	Type diffmatchpatch.Operation
+	Raw  string
+}
+
+// hunkSet holds a line-level diff split into hunks and rebuilds the content
+// from the decisions made on them.
+type hunkSet struct {
+	lines []hunkLine
+	hunks []DiffHunk
+}
+
+// newHunkSet diffs oldContent and newContent line by line.
+func newHunkSet(oldContent, newContent string) *hunkSet {
+	dmp := diffmatchpatch.New()
+	a, b, lineArray := dmp.DiffLinesToChars(oldContent, newContent)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lineArray)
+
+	s := &hunkSet{}
+	for _, d := range diffs {
+		for _, raw := range strings.SplitAfter(d.Text, "\n") {
+			if raw != "" {
+				s.lines = append(s.lines, hunkLine{Type: d.Type, Raw: raw})
+			}
+		}
+	}
+
+	oldLine, newLine := 1, 1
+	for i := 0; i < len(s.lines); {
+		if s.lines[i].Type == diffmatchpatch.DiffEqual {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+		h := DiffHunk{OldStart: oldLine, NewStart: newLine, start: i}
+		for ; i < len(s.lines) && s.lines[i].Type != diffmatchpatch.DiffEqual; i++ {
+			text := strings.TrimSuffix(s.lines[i].Raw, "\n")
+			if s.lines[i].Type == diffmatchpatch.DiffDelete {
+				h.Removed = append(h.Removed, text)
+				oldLine++
+			} else {
+				h.Added = append(h.Added, text)
+				newLine++
+			}
+		}
+		h.end = i
+		s.hunks = append(s.hunks, h)
+	}
+	return s
+}
+
+// diffLines returns the diff as display lines without line endings.
+func (s *hunkSet) diffLines() []diffLine {
+	lines := make([]diffLine, len(s.lines))
+	for i, l := range s.lines {
+		lines[i] = diffLine{Type: l.Type, Text: strings.TrimSuffix(l.Raw, "\n")}
+	}
+	return lines
+}
+
+// content rebuilds the file content from the hunk decisions.
+func (s *hunkSet) content() string {
+	var sb strings.Builder
+	pos := 0
+	for _, h := range s.hunks {
+		for ; pos < h.start; pos++ {
+			sb.WriteString(s.lines[pos].Raw)
+		}
+		switch h.Decision {
+		case HunkRejected:
+			s.writeRaw(&sb, h, diffmatchpatch.DiffDelete)
+		case HunkEdited:
+			if len(h.Edited) > 0 {
+				sb.WriteString(strings.Join(h.Edited, "\n"))
+				if s.endsWithNewline(h) {
+					sb.WriteString("\n")
+				}
+			}
+		default:
+			s.writeRaw(&sb, h, diffmatchpatch.DiffInsert)
+		}
+		pos = h.end
+	}
+	for ; pos < len(s.lines); pos++ {
+		sb.WriteString(s.lines[pos].Raw)
+	}
+	return sb.String()
+}
+
+// writeRaw writes the hunk's lines of the given type.
+func (s *hunkSet) writeRaw(sb *strings.Builder, h DiffHunk, op diffmatchpatch.Operation) {
+	for _, l := range s.lines[h.start:h.end] {
+		if l.Type == op {
+			sb.WriteString(l.Raw)
+		}
+	}
+}
+
+// endsWithNewline reports whether the text a hunk replaces is followed by a
+// line ending, so edited lines keep the file's final newline (or lack of one).
+func (s *hunkSet) endsWithNewline(h DiffHunk) bool {
+	if h.end < len(s.lines) {
+		return true
+	}
+	last := s.lines[h.end-1].Raw
+	return strings.HasSuffix(last, "\n")
+}
+
+// setAll sets the decision of every hunk.
+func (s *hunkSet) setAll(decision HunkDecision) {
+	for i := range s.hunks {
+		s.hunks[i].Decision = decision
+	}
+}
+
+// result builds the DiffResult for a preview that was applied. Rejecting
+// every hunk is a rejection of the whole diff.
+func (s *hunkSet) result() DiffResult {
+	res := DiffResult{Decision: DiffReject, Content: s.content(), Hunks: append([]DiffHunk(nil), s.hunks...)}
+	for _, h := range s.hunks {
+		if h.Applied() {
+			res.Decision = DiffApply
+			break
+		}
+	}
+	if len(s.hunks) == 0 {
+		res.Decision = DiffApply
+	}
+	return res
+}
+
+// counts returns how many hunks are accepted (including pending), rejected
+// and edited.
+func (s *hunkSet) counts() (accepted, rejected, edited int) {
+	for _, h := range s.hunks {
+		switch h.Decision {
+		case HunkRejected:
+			rejected++
+		case HunkEdited:
+			edited++
+		default:
+			accepted++
+		}
+	}
+	return
+}
+
+// hunkTag labels a hunk header with its decision.
+func hunkTag(d HunkDecision) string {
+	switch d {
+	case HunkAccepted:
+		return " [accepted]"
+	case HunkRejected:
+		return " [rejected]"
+	case HunkEdited:
+		return " [edited]"
+	default:
+		return ""
+	}
+}
+
+// renderHunks renders the hunks as a unified diff with contextLines lines of
+// context around each one. It returns the diff and, for each hunk, the line
+// of its header in the diff (-1 if the hunk is hidden).
+func renderHunks(filePath string, s *hunkSet, contextLines int, ignoreWhitespace bool, current int) (string, []int) {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("--- %s\n", filePath))
+	sb.WriteString(fmt.Sprintf("+++ %s\n", filePath))
+	row := 2
+
+	lines := s.diffLines()
+	offsets := make([]int, len(s.hunks))
+	prevEnd := 0
+	for i, h := range s.hunks {
+		offsets[i] = -1
+		if ignoreWhitespace && isWhitespaceOnlyHunk(lines[h.start:h.end]) {
+			continue
+		}
+
+		ctxStart := max(h.start-contextLines, prevEnd)
+		ctxEnd := h.end + contextLines
+		if i+1 < len(s.hunks) {
+			ctxEnd = min(ctxEnd, s.hunks[i+1].start)
+		}
+		ctxEnd = min(ctxEnd, len(lines))
+		prevEnd = ctxEnd
+
+		header := h.Header()
+		if funcName := findNearestFuncName(lines, h.start); funcName != "" {
+			header += " " + funcName
+		}
+		header += hunkTag(h.Decision)
+		if i == current {
+			header += "  ◀"
+		}
+		offsets[i] = row
+		sb.WriteString(header + "\n")
+		row++
+
+		for _, dl := range lines[ctxStart:h.start] {
+			sb.WriteString(" " + dl.Text + "\n")
+			row++
+		}
+		for _, text := range h.Removed {
+			sb.WriteString("-" + text + "\n")
+			row++
+		}
+		for _, text := range h.Lines() {
+			sb.WriteString("+" + text + "\n")
+			row++
+		}
+		for _, dl := range lines[h.end:ctxEnd] {
+			sb.WriteString(" " + dl.Text + "\n")
+			row++
+		}
+	}
+	return sb.String(), offsets
+}
+
+// hunkEditedMsg reports the result of editing a hunk in $EDITOR.
+type hunkEditedMsg struct {
+	filePath string
+	hunk     int
+	lines    []string
+	err      error
+}
+
+// editHunkCmd opens the hunk's proposed lines in the user's editor and
+// reports the edited lines when the editor exits.
+func editHunkCmd(filePath string, index int, h DiffHunk) tea.Cmd {
+	lines := h.Lines()
+
+	tmp, err := os.CreateTemp("", "gokin-hunk-*"+filepath.Ext(filePath))
+	if err != nil {
+		return func() tea.Msg { return hunkEditedMsg{filePath: filePath, hunk: index, err: err} }
+	}
+	text := strings.Join(lines, "\n")
+	if len(lines) > 0 {
+		text += "\n"
+	}
+	_, err = tmp.WriteString(text)
+	tmp.Close()
+	if err != nil {
+		os.Remove(tmp.Name())
+		return func() tea.Msg { return hunkEditedMsg{filePath: filePath, hunk: index, err: err} }
+	}
+
+	return tea.ExecProcess(editorCommand(tmp.Name()), func(err error) tea.Msg {
+		defer os.Remove(tmp.Name())
+		msg := hunkEditedMsg{filePath: filePath, hunk: index, err: err}
+		if err != nil {
+			return msg
+		}
+		data, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		// An empty file deletes the hunk's lines.
+		if len(data) > 0 {
+			msg.lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		}
+		return msg
+	})
+}
+
+// editorCommand builds the command that opens path in $VISUAL or $EDITOR,
+// falling back to vi (notepad on Windows).
+func editorCommand(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if runtime.GOOS == "windows" {
+		if editor == "" {
+			editor = "notepad"
+		}
+		return exec.Command(editor, path)
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// The editor may carry arguments ("code --wait"); the path is passed as $1.
+	return exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+}
+
+// applyEdit records edited lines for a hunk. Lines identical to the proposal
+// count as accepting it.
+func (s *hunkSet) applyEdit(index int, lines []string) {
+	if index < 0 || index >= len(s.hunks) {
+		return
+	}
+	h := &s.hunks[index]
+	if slicesEqual(lines, h.Added) {
+		h.Edited = nil
+		h.Decision = HunkAccepted
+		return
+	}
+	h.Edited = lines
+	h.Decision = HunkEdited
+}
+
+func slicesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
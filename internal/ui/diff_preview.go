@@ -41,6 +41,14 @@ type DiffPreviewModel struct {
 	// Ignore whitespace-only changes
 	ignoreWhitespace bool
 
+	// Hunks of the diff, their decisions and their line offsets in the diff
+	hunks       *hunkSet
+	currentHunk int
+	hunkOffsets []int
+
+	// Error from the last $EDITOR session, shown until the next action
+	editErr error
+
 	// Callback when user makes a decision
 	onDecision func(decision DiffDecision)
 }
@@ -55,10 +63,18 @@ type DiffPreviewRequestMsg struct {
 }
 
 // DiffPreviewResponseMsg is sent when user makes a decision.
+// NewContent is the content to write, with rejected hunks reverted and
+// edited hunks replaced.
 type DiffPreviewResponseMsg struct {
 	Decision   DiffDecision
 	FilePath   string
 	NewContent string
+	Hunks      []DiffHunk
+}
+
+// Result returns the decision as a DiffResult.
+func (msg DiffPreviewResponseMsg) Result() DiffResult {
+	return DiffResult{Decision: msg.Decision, Content: msg.NewContent, Hunks: msg.Hunks}
 }
 
 // NewDiffPreviewModel creates a new diff preview model.
@@ -96,15 +112,64 @@ func (m *DiffPreviewModel) SetContent(filePath, oldContent, newContent, toolName
 	m.toolName = toolName
 	m.isNewFile = isNewFile
 	m.decision = DiffPending
+	m.hunks = newHunkSet(oldContent, newContent)
+	m.currentHunk = 0
+	m.editErr = nil
 
 	m.refreshDiffView()
+	m.viewport.GotoTop()
 }
 
-// refreshDiffView regenerates and re-renders the diff with current settings.
+// refreshDiffView regenerates and re-renders the diff with current settings,
+// keeping the scroll position.
 func (m *DiffPreviewModel) refreshDiffView() {
 	m.diff = m.generateDiff(m.oldContent, m.newContent)
 	m.viewport.SetContent(m.highlightDiff(m.diff))
-	m.viewport.GotoTop()
+}
+
+// gotoHunk selects the hunk at index and scrolls it into view.
+func (m *DiffPreviewModel) gotoHunk(index int) {
+	if m.hunks == nil || index < 0 || index >= len(m.hunks.hunks) {
+		return
+	}
+	m.currentHunk = index
+	m.refreshDiffView()
+	if index < len(m.hunkOffsets) && m.hunkOffsets[index] >= 0 {
+		m.viewport.SetYOffset(m.hunkOffsets[index])
+	}
+}
+
+// decideHunk sets the decision of the current hunk and moves to the next one.
+func (m *DiffPreviewModel) decideHunk(decision HunkDecision) {
+	if m.hunks == nil || len(m.hunks.hunks) == 0 {
+		return
+	}
+	h := &m.hunks.hunks[m.currentHunk]
+	h.Decision = decision
+	h.Edited = nil
+	if m.currentHunk < len(m.hunks.hunks)-1 {
+		m.gotoHunk(m.currentHunk + 1)
+	} else {
+		m.refreshDiffView()
+	}
+}
+
+// respond builds the response for the given decision.
+func (m *DiffPreviewModel) respond(decision DiffDecision) tea.Cmd {
+	m.decision = decision
+	if m.onDecision != nil {
+		m.onDecision(decision)
+	}
+	msg := DiffPreviewResponseMsg{
+		Decision:   decision,
+		FilePath:   m.filePath,
+		NewContent: m.newContent,
+	}
+	if decision == DiffApply && m.hunks != nil {
+		res := m.hunks.result()
+		msg.Decision, msg.NewContent, msg.Hunks = res.Decision, res.Content, res.Hunks
+	}
+	return func() tea.Msg { return msg }
 }
 
 // SetDecisionCallback sets the callback for when user makes a decision.
@@ -118,154 +183,19 @@ type diffLine struct {
 	Text string
 }
 
-// generateDiff creates a unified diff between old and new content.
+// generateDiff creates a unified diff between old and new content with one
+// hunk per block of changed lines.
 func (m *DiffPreviewModel) generateDiff(oldContent, newContent string) string {
-	dmp := diffmatchpatch.New()
-
-	var result strings.Builder
-
-	// Header
-	result.WriteString(fmt.Sprintf("--- %s\n", m.filePath))
-	result.WriteString(fmt.Sprintf("+++ %s\n", m.filePath))
-
-	// Generate line-based diff
-	diffs := dmp.DiffMain(oldContent, newContent, true)
-	diffs = dmp.DiffCleanupSemantic(diffs)
-
-	// Convert diffs to individual lines with their types
-	var allLines []diffLine
-	for _, d := range diffs {
-		lines := strings.Split(d.Text, "\n")
-		for i, line := range lines {
-			if i == len(lines)-1 && line == "" {
-				continue
-			}
-			allLines = append(allLines, diffLine{Type: d.Type, Text: line})
-		}
+	if m.hunks == nil {
+		m.hunks = newHunkSet(oldContent, newContent)
 	}
-
-	// Build hunks with context lines
 	contextN := m.contextLines
 	if contextN < 0 {
 		contextN = 0
 	}
-
-	// Find ranges of changed lines (non-Equal)
-	type changeRange struct {
-		start, end int // indices into allLines
-	}
-	var changes []changeRange
-	i := 0
-	for i < len(allLines) {
-		if allLines[i].Type != diffmatchpatch.DiffEqual {
-			start := i
-			for i < len(allLines) && allLines[i].Type != diffmatchpatch.DiffEqual {
-				i++
-			}
-			changes = append(changes, changeRange{start, i})
-		} else {
-			i++
-		}
-	}
-
-	if len(changes) == 0 {
-		return result.String()
-	}
-
-	// Merge change ranges that are close together (separated by <= 2*contextN equal lines)
-	type hunkRange struct {
-		start, end int // indices into allLines, including context
-	}
-	var hunks []hunkRange
-
-	for _, ch := range changes {
-		hStart := ch.start - contextN
-		if hStart < 0 {
-			hStart = 0
-		}
-		hEnd := ch.end + contextN
-		if hEnd > len(allLines) {
-			hEnd = len(allLines)
-		}
-
-		if len(hunks) > 0 && hStart <= hunks[len(hunks)-1].end {
-			// Merge with previous hunk
-			hunks[len(hunks)-1].end = hEnd
-		} else {
-			hunks = append(hunks, hunkRange{hStart, hEnd})
-		}
-	}
-
-	// Compute old/new line numbers for each position in allLines
-	oldLineNums := make([]int, len(allLines)+1)
-	newLineNums := make([]int, len(allLines)+1)
-	oldLine := 1
-	newLine := 1
-	for idx, dl := range allLines {
-		oldLineNums[idx] = oldLine
-		newLineNums[idx] = newLine
-		switch dl.Type {
-		case diffmatchpatch.DiffEqual:
-			oldLine++
-			newLine++
-		case diffmatchpatch.DiffDelete:
-			oldLine++
-		case diffmatchpatch.DiffInsert:
-			newLine++
-		}
-	}
-	oldLineNums[len(allLines)] = oldLine
-	newLineNums[len(allLines)] = newLine
-
-	// Render each hunk
-	for _, hunk := range hunks {
-		// Calculate hunk header line counts
-		oldStart := oldLineNums[hunk.start]
-		newStart := newLineNums[hunk.start]
-		oldCount := 0
-		newCount := 0
-		for idx := hunk.start; idx < hunk.end; idx++ {
-			switch allLines[idx].Type {
-			case diffmatchpatch.DiffEqual:
-				oldCount++
-				newCount++
-			case diffmatchpatch.DiffDelete:
-				oldCount++
-			case diffmatchpatch.DiffInsert:
-				newCount++
-			}
-		}
-
-		// Check if this hunk is whitespace-only when ignoreWhitespace is enabled
-		if m.ignoreWhitespace && isWhitespaceOnlyHunk(allLines[hunk.start:hunk.end]) {
-			continue
-		}
-
-		// Find the nearest function/class/def line above the hunk start
-		funcName := findNearestFuncName(allLines, hunk.start)
-
-		// Write hunk header
-		header := fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldStart, oldCount, newStart, newCount)
-		if funcName != "" {
-			header += " " + funcName
-		}
-		result.WriteString(header + "\n")
-
-		// Write hunk lines
-		for idx := hunk.start; idx < hunk.end; idx++ {
-			dl := allLines[idx]
-			switch dl.Type {
-			case diffmatchpatch.DiffEqual:
-				result.WriteString(fmt.Sprintf(" %s\n", dl.Text))
-			case diffmatchpatch.DiffDelete:
-				result.WriteString(fmt.Sprintf("-%s\n", dl.Text))
-			case diffmatchpatch.DiffInsert:
-				result.WriteString(fmt.Sprintf("+%s\n", dl.Text))
-			}
-		}
-	}
-
-	return result.String()
+	diff, offsets := renderHunks(m.filePath, m.hunks, contextN, m.ignoreWhitespace, m.currentHunk)
+	m.hunkOffsets = offsets
+	return diff
 }
 
 // findNearestFuncName searches backward from the given position to find the nearest
@@ -356,6 +286,9 @@ func (m *DiffPreviewModel) highlightDiff(diff string) string {
 		}
 	}
 
+	rejectedStyle := lipgloss.NewStyle().Foreground(ColorDim).Strikethrough(true)
+	inRejected := false
+
 	for idx, line := range lines {
 		var styledLine string
 
@@ -363,7 +296,10 @@ func (m *DiffPreviewModel) highlightDiff(diff string) string {
 		case strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---"):
 			styledLine = headerStyle.Render(line)
 		case strings.HasPrefix(line, "@@"):
+			inRejected = strings.Contains(line, hunkTag(HunkRejected))
 			styledLine = hunkStyle.Render(line)
+		case inRejected && (strings.HasPrefix(line, "-") || strings.HasPrefix(line, "+")):
+			styledLine = rejectedStyle.Render(line)
 		case strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---"):
 			if partnerIdx, ok := paired[idx]; ok && strings.HasPrefix(lines[partnerIdx], "+") {
 				// Word-level highlight for paired removed line
@@ -429,30 +365,38 @@ func (m DiffPreviewModel) Update(msg tea.Msg) (DiffPreviewModel, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "y", "Y":
-			m.decision = DiffApply
-			if m.onDecision != nil {
-				m.onDecision(DiffApply)
-			}
-			return m, func() tea.Msg {
-				return DiffPreviewResponseMsg{
-					Decision:   DiffApply,
-					FilePath:   m.filePath,
-					NewContent: m.newContent,
-				}
-			}
+			// Apply every hunk that was not rejected
+			return m, m.respond(DiffApply)
 
 		case "n", "N", "esc":
-			m.decision = DiffReject
-			if m.onDecision != nil {
-				m.onDecision(DiffReject)
-			}
-			return m, func() tea.Msg {
-				return DiffPreviewResponseMsg{
-					Decision:   DiffReject,
-					FilePath:   m.filePath,
-					NewContent: m.newContent,
-				}
+			return m, m.respond(DiffReject)
+
+		case "tab", "]":
+			m.editErr = nil
+			m.gotoHunk(m.currentHunk + 1)
+			return m, nil
+
+		case "shift+tab", "[":
+			m.editErr = nil
+			m.gotoHunk(m.currentHunk - 1)
+			return m, nil
+
+		case "a":
+			m.editErr = nil
+			m.decideHunk(HunkAccepted)
+			return m, nil
+
+		case "r":
+			m.editErr = nil
+			m.decideHunk(HunkRejected)
+			return m, nil
+
+		case "e":
+			m.editErr = nil
+			if m.hunks == nil || len(m.hunks.hunks) == 0 {
+				return m, nil
 			}
+			return m, editHunkCmd(m.filePath, m.currentHunk, m.hunks.hunks[m.currentHunk])
 
 		case "j", "down":
 			m.viewport, cmd = m.viewport.Update(tea.KeyMsg{Type: tea.KeyDown})
@@ -500,6 +444,18 @@ func (m DiffPreviewModel) Update(msg tea.Msg) (DiffPreviewModel, tea.Cmd) {
 			return m, nil
 		}
 
+	case hunkEditedMsg:
+		if msg.filePath != m.filePath || m.hunks == nil {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.editErr = msg.err
+			return m, nil
+		}
+		m.hunks.applyEdit(msg.hunk, msg.lines)
+		m.gotoHunk(msg.hunk)
+		return m, nil
+
 	case tea.MouseMsg:
 		m.viewport, cmd = m.viewport.Update(msg)
 		return m, cmd
@@ -550,6 +506,14 @@ func (m DiffPreviewModel) View() string {
 	}
 	settings := settingsStyle.Render(fmt.Sprintf("  context: %d | ignore-ws: %s", m.contextLines, wsLabel))
 	builder.WriteString(markerStyle.Render("     ") + stats + settings)
+	if m.hunks != nil && len(m.hunks.hunks) > 0 {
+		accepted, rejected, edited := m.hunks.counts()
+		builder.WriteString(settingsStyle.Render(fmt.Sprintf("  hunk %d/%d | applying: %d | rejected: %d | edited: %d",
+			m.currentHunk+1, len(m.hunks.hunks), accepted+edited, rejected, edited)))
+	}
+	if m.editErr != nil {
+		builder.WriteString("\n" + markerStyle.Render("     ") + removedStyle.Render("Editor failed: "+m.editErr.Error()))
+	}
 	builder.WriteString("\n\n")
 
 	// Diff viewport without border
@@ -598,6 +562,8 @@ func (m DiffPreviewModel) renderActions(builder *strings.Builder) {
 	builder.WriteString(rejectStyle.Render("n Reject"))
 	builder.WriteString("\n\n")
 
+	builder.WriteString(hintStyle.Render("Tab/]: Next hunk | Shift+Tab/[: Prev hunk | a: Accept hunk | r: Reject hunk | e: Edit hunk in $EDITOR"))
+	builder.WriteString("\n")
 	builder.WriteString(hintStyle.Render("j/k: Scroll | g/G: Top/Bottom | Ctrl+D/U: Half page | +/-: Context | I: Ignore whitespace"))
 }
 
@@ -636,6 +602,15 @@ type MultiDiffPreviewModel struct {
 	height       int
 	focusOnList  bool // true if focus is on file list, false if on diff
 
+	// Hunks of each file, the selected hunk of the current file and its
+	// hunks' line offsets in the diff
+	hunks       []*hunkSet
+	currentHunk int
+	hunkOffsets []int
+
+	// Error from the last $EDITOR session, shown until the next action
+	editErr error
+
 	// Callback when user makes decisions
 	onComplete func(decisions map[string]DiffDecision)
 }
@@ -646,8 +621,10 @@ type MultiDiffPreviewRequestMsg struct {
 }
 
 // MultiDiffPreviewResponseMsg is sent when user completes multi-file decisions.
+// Results holds the content to write and the hunk decisions of each file.
 type MultiDiffPreviewResponseMsg struct {
 	Decisions map[string]DiffDecision
+	Results   map[string]DiffResult
 }
 
 // NewMultiDiffPreviewModel creates a new multi-file diff preview model.
@@ -682,11 +659,15 @@ func (m *MultiDiffPreviewModel) SetSize(width, height int) {
 func (m *MultiDiffPreviewModel) SetFiles(files []DiffFile) {
 	m.files = files
 	m.currentIndex = 0
+	m.currentHunk = 0
+	m.editErr = nil
 	m.decisions = make(map[int]DiffDecision)
+	m.hunks = make([]*hunkSet, len(files))
 
 	// Initialize all decisions to pending
-	for i := range files {
+	for i, file := range files {
 		m.decisions[i] = DiffPending
+		m.hunks[i] = newHunkSet(file.OldContent, file.NewContent)
 	}
 
 	// Generate diffs for all files
@@ -711,34 +692,15 @@ func (m *MultiDiffPreviewModel) generateDiff(index int) string {
 		return ""
 	}
 
-	file := m.files[index]
-	dmp := diffmatchpatch.New()
-
-	var result strings.Builder
-	result.WriteString(fmt.Sprintf("--- %s\n", file.FilePath))
-	result.WriteString(fmt.Sprintf("+++ %s\n", file.FilePath))
-
-	diffs := dmp.DiffMain(file.OldContent, file.NewContent, true)
-	diffs = dmp.DiffCleanupSemantic(diffs)
-
-	for _, d := range diffs {
-		lines := strings.Split(d.Text, "\n")
-		for i, line := range lines {
-			if i == len(lines)-1 && line == "" {
-				continue
-			}
-			switch d.Type {
-			case diffmatchpatch.DiffEqual:
-				result.WriteString(fmt.Sprintf(" %s\n", line))
-			case diffmatchpatch.DiffDelete:
-				result.WriteString(fmt.Sprintf("-%s\n", line))
-			case diffmatchpatch.DiffInsert:
-				result.WriteString(fmt.Sprintf("+%s\n", line))
-			}
-		}
+	current := -1
+	if index == m.currentIndex {
+		current = m.currentHunk
 	}
-
-	return result.String()
+	diff, offsets := renderHunks(m.files[index].FilePath, m.hunks[index], 3, false, current)
+	if index == m.currentIndex {
+		m.hunkOffsets = offsets
+	}
+	return diff
 }
 
 // highlightMultiDiff applies syntax highlighting to the diff.
@@ -746,16 +708,24 @@ func (m *MultiDiffPreviewModel) highlightMultiDiff(diff string) string {
 	addedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#10B981")).Bold(true)
 	removedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444")).Bold(true)
 	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#06B6D4")).Bold(true)
+	hunkStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#A78BFA"))
+	rejectedStyle := lipgloss.NewStyle().Foreground(ColorDim).Strikethrough(true)
 	contextStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF"))
 
 	lines := strings.Split(diff, "\n")
 	var result strings.Builder
+	inRejected := false
 
 	for _, line := range lines {
 		var styledLine string
 		switch {
 		case strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---"):
 			styledLine = headerStyle.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			inRejected = strings.Contains(line, hunkTag(HunkRejected))
+			styledLine = hunkStyle.Render(line)
+		case inRejected && (strings.HasPrefix(line, "-") || strings.HasPrefix(line, "+")):
+			styledLine = rejectedStyle.Render(line)
 		case strings.HasPrefix(line, "+"):
 			styledLine = addedStyle.Render(line)
 		case strings.HasPrefix(line, "-"):
@@ -773,12 +743,61 @@ func (m *MultiDiffPreviewModel) highlightMultiDiff(diff string) string {
 // updateViewport updates the viewport with the current file's diff.
 func (m *MultiDiffPreviewModel) updateViewport() {
 	if m.currentIndex >= 0 && m.currentIndex < len(m.files) {
-		diff := m.files[m.currentIndex].Diff
-		m.viewport.SetContent(m.highlightMultiDiff(diff))
+		m.currentHunk = 0
+		m.refreshViewport()
 		m.viewport.GotoTop()
 	}
 }
 
+// refreshViewport re-renders the current file's diff, keeping the scroll
+// position.
+func (m *MultiDiffPreviewModel) refreshViewport() {
+	m.files[m.currentIndex].Diff = m.generateDiff(m.currentIndex)
+	m.viewport.SetContent(m.highlightMultiDiff(m.files[m.currentIndex].Diff))
+}
+
+// gotoHunk selects a hunk of the current file and scrolls it into view.
+func (m *MultiDiffPreviewModel) gotoHunk(index int) {
+	if m.currentIndex >= len(m.hunks) || index < 0 || index >= len(m.hunks[m.currentIndex].hunks) {
+		return
+	}
+	m.currentHunk = index
+	m.refreshViewport()
+	if index < len(m.hunkOffsets) && m.hunkOffsets[index] >= 0 {
+		m.viewport.SetYOffset(m.hunkOffsets[index])
+	}
+}
+
+// decideHunk sets the decision of the current hunk, updates the file's
+// decision from its hunks and moves to the next hunk.
+func (m *MultiDiffPreviewModel) decideHunk(decision HunkDecision) {
+	if m.currentIndex >= len(m.hunks) || len(m.hunks[m.currentIndex].hunks) == 0 {
+		return
+	}
+	set := m.hunks[m.currentIndex]
+	set.hunks[m.currentHunk].Decision = decision
+	set.hunks[m.currentHunk].Edited = nil
+	m.decisions[m.currentIndex] = set.result().Decision
+	if m.currentHunk < len(set.hunks)-1 {
+		m.gotoHunk(m.currentHunk + 1)
+	} else {
+		m.refreshViewport()
+	}
+}
+
+// acceptFile accepts the current file. A file whose hunks were all rejected
+// is accepted in full.
+func (m *MultiDiffPreviewModel) acceptFile(index int) {
+	set := m.hunks[index]
+	if set.result().Decision == DiffReject {
+		set.setAll(HunkPending)
+		if index == m.currentIndex {
+			m.refreshViewport()
+		}
+	}
+	m.decisions[index] = DiffApply
+}
+
 // Init initializes the multi-diff preview model.
 func (m MultiDiffPreviewModel) Init() tea.Cmd {
 	return nil
@@ -824,7 +843,7 @@ func (m MultiDiffPreviewModel) Update(msg tea.Msg) (MultiDiffPreviewModel, tea.C
 
 		case "y":
 			// Accept current file
-			m.decisions[m.currentIndex] = DiffApply
+			m.acceptFile(m.currentIndex)
 			// Move to next pending file
 			m.moveToNextPending()
 			return m, nil
@@ -839,7 +858,7 @@ func (m MultiDiffPreviewModel) Update(msg tea.Msg) (MultiDiffPreviewModel, tea.C
 		case "Y":
 			// Accept all
 			for i := range m.files {
-				m.decisions[i] = DiffApply
+				m.acceptFile(i)
 			}
 			return m, m.finish()
 
@@ -884,7 +903,48 @@ func (m MultiDiffPreviewModel) Update(msg tea.Msg) (MultiDiffPreviewModel, tea.C
 				m.viewport.HalfViewUp()
 			}
 			return m, nil
+
+		case "]":
+			m.editErr = nil
+			m.gotoHunk(m.currentHunk + 1)
+			return m, nil
+
+		case "[":
+			m.editErr = nil
+			m.gotoHunk(m.currentHunk - 1)
+			return m, nil
+
+		case "a":
+			m.editErr = nil
+			m.decideHunk(HunkAccepted)
+			return m, nil
+
+		case "r":
+			m.editErr = nil
+			m.decideHunk(HunkRejected)
+			return m, nil
+
+		case "e":
+			m.editErr = nil
+			if m.currentIndex >= len(m.hunks) || len(m.hunks[m.currentIndex].hunks) == 0 {
+				return m, nil
+			}
+			file := m.files[m.currentIndex]
+			return m, editHunkCmd(file.FilePath, m.currentHunk, m.hunks[m.currentIndex].hunks[m.currentHunk])
+		}
+
+	case hunkEditedMsg:
+		if m.currentIndex >= len(m.files) || msg.filePath != m.files[m.currentIndex].FilePath {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.editErr = msg.err
+			return m, nil
 		}
+		m.hunks[m.currentIndex].applyEdit(msg.hunk, msg.lines)
+		m.decisions[m.currentIndex] = m.hunks[m.currentIndex].result().Decision
+		m.gotoHunk(msg.hunk)
+		return m, nil
 
 	case tea.MouseMsg:
 		if !m.focusOnList {
@@ -924,8 +984,14 @@ func (m *MultiDiffPreviewModel) moveToNextPending() {
 // finish creates the completion message.
 func (m *MultiDiffPreviewModel) finish() tea.Cmd {
 	decisions := make(map[string]DiffDecision)
+	results := make(map[string]DiffResult)
 	for i, file := range m.files {
-		decisions[file.FilePath] = m.decisions[i]
+		result := DiffResult{Decision: m.decisions[i], Content: file.NewContent}
+		if m.decisions[i] == DiffApply {
+			result = m.hunks[i].result()
+		}
+		decisions[file.FilePath] = result.Decision
+		results[file.FilePath] = result
 	}
 
 	if m.onComplete != nil {
@@ -935,6 +1001,7 @@ func (m *MultiDiffPreviewModel) finish() tea.Cmd {
 	return func() tea.Msg {
 		return MultiDiffPreviewResponseMsg{
 			Decisions: decisions,
+			Results:   results,
 		}
 	}
 }
@@ -1075,7 +1142,15 @@ func (m MultiDiffPreviewModel) renderMultiActions(builder *strings.Builder) {
 	// Status line
 	statusStyle := lipgloss.NewStyle().Foreground(ColorMuted)
 	status := fmt.Sprintf("Accepted: %d | Rejected: %d | Pending: %d", accepted, rejected, pending)
+	if m.currentIndex < len(m.hunks) && len(m.hunks[m.currentIndex].hunks) > 0 {
+		_, hunksRejected, hunksEdited := m.hunks[m.currentIndex].counts()
+		status += fmt.Sprintf(" | Hunk %d/%d (rejected: %d, edited: %d)",
+			m.currentHunk+1, len(m.hunks[m.currentIndex].hunks), hunksRejected, hunksEdited)
+	}
 	builder.WriteString(statusStyle.Render(status))
+	if m.editErr != nil {
+		builder.WriteString("\n" + lipgloss.NewStyle().Foreground(ColorError).Render("Editor failed: "+m.editErr.Error()))
+	}
 	builder.WriteString("\n\n")
 
 	// Buttons
@@ -1091,6 +1166,8 @@ func (m MultiDiffPreviewModel) renderMultiActions(builder *strings.Builder) {
 	builder.WriteString("\n\n")
 
 	builder.WriteString(hintStyle.Render("Tab: Switch focus | ↑/↓: Navigate | j/k: Scroll | Esc: Cancel"))
+	builder.WriteString("\n")
+	builder.WriteString(hintStyle.Render("]/[: Next/prev hunk | a: Accept hunk | r: Reject hunk | e: Edit hunk in $EDITOR"))
 }
 
 // GetDecisions returns the current decisions map.
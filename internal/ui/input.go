@@ -49,6 +49,12 @@ type InputModel struct {
 	suggestionIndex int
 	showSuggestions bool
 
+	// @mention autocomplete
+	mentionProvider    MentionProvider
+	mentionSuggestions []MentionSuggestion
+	mentionIndex       int
+	mentionStart       int // Offset of the @ being completed
+
 	// Ghost text (inline completion hint)
 	ghostText    string // Suggested completion shown in dim color
 	ghostEnabled bool   // Whether ghost text is enabled
@@ -70,7 +76,7 @@ type InputModel struct {
 // NewInputModel creates a new input model.
 func NewInputModel(styles *Styles) InputModel {
 	ta := textarea.New()
-	ta.Placeholder = "Message or /command (Tab: complete)"
+	ta.Placeholder = "Message, @file or /command (Tab: complete)"
 	ta.Focus()
 	ta.CharLimit = 10000
 	ta.ShowLineNumbers = false
@@ -110,10 +116,34 @@ func defaultCommands() []CommandInfo {
 			Name:        "resume",
 			Description: "Resume a saved session",
 			Category:    "Session",
-			Args:        []ArgInfo{{Name: "session", Required: true, Type: "string"}},
-			Usage:       "/resume <session>",
+			Args: []ArgInfo{
+				{Name: "session", Required: true, Type: "string"},
+				{Name: "turn", Required: false, Type: "number"},
+			},
+			Usage: "/resume <session> [turn]",
 		},
 		{Name: "sessions", Description: "List saved sessions", Category: "Session"},
+		{
+			Name:        "history",
+			Description: "Search all saved sessions",
+			Category:    "Session",
+			Args: []ArgInfo{
+				{Name: "query", Required: false, Type: "string"},
+				{Name: "--since", Required: false, Type: "option"},
+				{Name: "--here", Required: false, Type: "option"},
+			},
+			Usage: "/history <query> [--since 7d] [--here]",
+		},
+		{
+			Name:        "rewind",
+			Description: "Restore files to a checkpoint",
+			Category:    "Session",
+			Args: []ArgInfo{
+				{Name: "n", Required: false, Type: "number"},
+				{Name: "--conversation", Required: false, Type: "option"},
+			},
+			Usage: "/rewind [n|id] [--conversation]",
+		},
 
 		// History commands
 		{
@@ -198,6 +228,12 @@ func (m InputModel) Update(msg tea.Msg) (InputModel, tea.Cmd) {
 			return m, nil
 
 		case tea.KeyTab:
+			// Accept @mention completion
+			if m.showingMentions() {
+				m.acceptMention()
+				return m, nil
+			}
+
 			// Accept ghost text if visible (and no dropdown)
 			if m.ghostText != "" && !m.showSuggestions {
 				m.textarea.SetValue(m.textarea.Value() + m.ghostText)
@@ -256,6 +292,11 @@ func (m InputModel) Update(msg tea.Msg) (InputModel, tea.Cmd) {
 			return m, nil
 
 		case tea.KeyEnter:
+			if m.showingMentions() {
+				m.acceptMention()
+				return m, nil
+			}
+
 			// Handle autocomplete on Enter
 			if m.showSuggestions && len(m.suggestions) > 0 {
 				// Accept current suggestion
@@ -275,6 +316,12 @@ func (m InputModel) Update(msg tea.Msg) (InputModel, tea.Cmd) {
 
 		case tea.KeyUp:
 			// Navigate suggestions or history
+			if m.showingMentions() {
+				if m.mentionIndex > 0 {
+					m.mentionIndex--
+				}
+				return m, nil
+			}
 			if m.showSuggestions && len(m.suggestions) > 0 {
 				if m.suggestionIndex > 0 {
 					m.suggestionIndex--
@@ -296,6 +343,12 @@ func (m InputModel) Update(msg tea.Msg) (InputModel, tea.Cmd) {
 
 		case tea.KeyDown:
 			// Navigate suggestions or history
+			if m.showingMentions() {
+				if m.mentionIndex < len(m.mentionSuggestions)-1 {
+					m.mentionIndex++
+				}
+				return m, nil
+			}
 			if m.showSuggestions && len(m.suggestions) > 0 {
 				if m.suggestionIndex < len(m.suggestions)-1 {
 					m.suggestionIndex++
@@ -317,6 +370,10 @@ func (m InputModel) Update(msg tea.Msg) (InputModel, tea.Cmd) {
 
 		case tea.KeyEscape:
 			// Cancel suggestions and ghost text
+			if m.showingMentions() {
+				m.mentionSuggestions = nil
+				return m, nil
+			}
 			if m.showSuggestions || m.ghostText != "" || m.showArgHints {
 				m.showSuggestions = false
 				m.suggestions = nil
@@ -353,6 +410,7 @@ func (m InputModel) Update(msg tea.Msg) (InputModel, tea.Cmd) {
 				m.currentCommand = nil
 			}
 		}
+		m.updateMentions()
 
 		return m, cmd
 	}
@@ -582,6 +640,12 @@ func (m InputModel) View() string {
 		result.WriteString("\n")
 	}
 
+	// Show @mention completions
+	if m.showingMentions() {
+		result.WriteString(m.renderMentionSuggestions())
+		result.WriteString("\n")
+	}
+
 	// Show argument hints after command
 	if m.showArgHints && m.currentCommand != nil && len(m.currentCommand.Args) > 0 {
 		argHints := m.renderArgHints()
@@ -697,6 +761,7 @@ func (m InputModel) Value() string {
 // Reset clears the input and optionally saves to history.
 func (m *InputModel) Reset() {
 	m.textarea.Reset()
+	m.mentionSuggestions = nil
 	m.historyIndex = -1
 	m.savedInput = ""
 }
@@ -851,7 +916,7 @@ func (m *InputModel) updatePlaceholder() {
 	if m.activeTask != "" {
 		m.textarea.Placeholder = "Continue: " + m.activeTask
 	} else {
-		m.textarea.Placeholder = "Message or /command (Tab: complete)"
+		m.textarea.Placeholder = "Message, @file or /command (Tab: complete)"
 	}
 }
 
@@ -879,5 +944,5 @@ func (m *InputModel) IsHistorySearchMode() bool {
 
 // ShowingSuggestions returns whether suggestions are being shown.
 func (m *InputModel) ShowingSuggestions() bool {
-	return m.showSuggestions
+	return m.showSuggestions || m.showingMentions()
 }
@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxMentionSuggestions is how many completions are requested for an @mention.
+const maxMentionSuggestions = 20
+
+// MentionSuggestion is a completion for an @mention in the input.
+type MentionSuggestion struct {
+	Value  string // Text inserted after the @
+	Kind   string // "file", "dir", "symbol" or "url"
+	Detail string
+}
+
+// MentionProvider completes the text typed after an @ in the input.
+type MentionProvider interface {
+	CompleteMentions(query string, limit int) []MentionSuggestion
+}
+
+// SetMentionProvider sets the provider for @mention completion.
+func (m *InputModel) SetMentionProvider(provider MentionProvider) {
+	m.mentionProvider = provider
+}
+
+// showingMentions reports whether the @mention dropdown is open.
+func (m InputModel) showingMentions() bool {
+	return len(m.mentionSuggestions) > 0
+}
+
+// updateMentions refreshes the @mention completions for the word being
+// typed at the end of the input.
+func (m *InputModel) updateMentions() {
+	m.mentionSuggestions = nil
+	m.mentionIndex = 0
+	if m.mentionProvider == nil {
+		return
+	}
+
+	value := m.textarea.Value()
+	start := strings.LastIndexAny(value, " \t\n") + 1
+	if !strings.HasPrefix(value[start:], "@") {
+		return
+	}
+	// Don't complete while typing a command name
+	if strings.HasPrefix(value, "/") && start == 0 {
+		return
+	}
+
+	m.mentionStart = start
+	m.mentionSuggestions = m.mentionProvider.CompleteMentions(value[start+1:], maxMentionSuggestions)
+}
+
+// acceptMention replaces the @word being typed with the selected mention.
+// Directories stay open for completing their contents.
+func (m *InputModel) acceptMention() {
+	selected := m.mentionSuggestions[m.mentionIndex]
+	value := m.textarea.Value()[:m.mentionStart] + "@" + selected.Value
+	if selected.Kind != "dir" {
+		value += " "
+	}
+	m.textarea.SetValue(value)
+	m.textarea.CursorEnd()
+	m.updateMentions()
+}
+
+// renderMentionSuggestions renders the @mention completion box.
+func (m InputModel) renderMentionSuggestions() string {
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorBorder).
+		Padding(0, 1)
+
+	selectedStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(ColorSecondary)
+
+	normalStyle := lipgloss.NewStyle().
+		Foreground(ColorText)
+
+	descStyle := lipgloss.NewStyle().
+		Foreground(ColorDim)
+
+	maxShow := min(6, len(m.mentionSuggestions))
+	start := 0
+	if m.mentionIndex >= maxShow {
+		start = m.mentionIndex - maxShow + 1
+	}
+	end := min(start+maxShow, len(m.mentionSuggestions))
+
+	var lines []string
+	for i := start; i < end; i++ {
+		s := m.mentionSuggestions[i]
+		style := normalStyle
+		prefix := "  "
+		if i == m.mentionIndex {
+			style = selectedStyle
+			prefix = "> "
+		}
+		lines = append(lines, prefix+style.Render("@"+s.Value)+" "+descStyle.Render(s.Detail))
+	}
+
+	if len(m.mentionSuggestions) > maxShow {
+		indicator := lipgloss.NewStyle().Foreground(ColorDim).Render(
+			fmt.Sprintf("↑↓ %d", len(m.mentionSuggestions)),
+		)
+		lines = append(lines, indicator)
+	}
+
+	return boxStyle.Render(strings.Join(lines, "\n"))
+}
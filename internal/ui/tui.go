@@ -99,7 +99,11 @@ type Model struct {
 	// Diff preview state
 	diffPreview    DiffPreviewModel
 	diffRequest    *DiffPreviewRequestMsg
-	onDiffDecision func(decision DiffDecision)
+	onDiffDecision func(result DiffResult)
+
+	// Multi-file diff preview state
+	multiDiffPreview    MultiDiffPreviewModel
+	onMultiDiffDecision func(results map[string]DiffResult)
 
 	// Search results state
 	searchResults  SearchResultsModel
@@ -258,6 +262,7 @@ func NewModel() *Model {
 		minSubmitDelay:       500 * time.Millisecond, // Debounce: 500ms between submissions
 		sessionStart:         time.Now(),
 		diffPreview:          NewDiffPreviewModel(styles),
+		multiDiffPreview:     NewMultiDiffPreviewModel(styles),
 		searchResults:        NewSearchResultsModel(styles),
 		gitStatusModel:       NewGitStatusModel(styles),
 		fileBrowser:          NewFileBrowserModel(styles),
@@ -467,6 +472,13 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) tea.Cmd {
 		return cmd
 	}
 
+	// Handle multi-file diff preview keys
+	if m.state == StateMultiDiffPreview {
+		var cmd tea.Cmd
+		m.multiDiffPreview, cmd = m.multiDiffPreview.Update(msg)
+		return cmd
+	}
+
 	// Handle search results keys
 	if m.state == StateSearchResults {
 		var cmd tea.Cmd
@@ -744,7 +756,7 @@ func (m *Model) handlePlanApprovalKeys(msg tea.KeyMsg) tea.Cmd {
 	case "y":
 		// Quick approve
 		// Initialize plan progress panel with the approved plan
-		if m.planRequest != nil && m.planProgressPanel != nil {
+		if m.planRequest != nil && len(m.planRequest.Steps) > 0 && m.planProgressPanel != nil {
 			m.planProgressPanel.StartPlan(
 				"", // planID - will be filled by progress updates
 				m.planRequest.Title,
@@ -1416,23 +1428,61 @@ func (m *Model) handleMessageTypes(msg tea.Msg) tea.Cmd {
 		m.diffPreview.SetContent(msg.FilePath, msg.OldContent, msg.NewContent, msg.ToolName, msg.IsNewFile)
 		m.state = StateDiffPreview
 
+	case hunkEditedMsg:
+		var cmd tea.Cmd
+		switch m.state {
+		case StateDiffPreview:
+			m.diffPreview, cmd = m.diffPreview.Update(msg)
+		case StateMultiDiffPreview:
+			m.multiDiffPreview, cmd = m.multiDiffPreview.Update(msg)
+		}
+		cmds = append(cmds, cmd)
+
 	case DiffPreviewResponseMsg:
 		m.diffRequest = nil
+		result := msg.Result()
 		if msg.Decision == DiffApply {
 			m.state = StateProcessing
+			m.appendPartialApplyNote(result)
 			if m.onDiffDecision != nil {
-				m.onDiffDecision(msg.Decision)
+				m.onDiffDecision(result)
 			}
 		} else {
 			m.state = StateInput
 			m.output.AppendLine(m.styles.Warning.Render(" Changes rejected"))
 			m.output.AppendLine("")
 			if m.onDiffDecision != nil {
-				m.onDiffDecision(msg.Decision)
+				m.onDiffDecision(result)
 			}
 			cmds = append(cmds, m.input.Focus())
 		}
 
+	case MultiDiffPreviewRequestMsg:
+		m.multiDiffPreview.SetSize(m.width, m.height)
+		m.multiDiffPreview.SetFiles(msg.Files)
+		m.state = StateMultiDiffPreview
+
+	case MultiDiffPreviewResponseMsg:
+		// Accepted files are applied even if others were rejected
+		approved := false
+		for _, result := range msg.Results {
+			if result.Decision == DiffApply {
+				approved = true
+				m.appendPartialApplyNote(result)
+			}
+		}
+		if approved {
+			m.state = StateProcessing
+		} else {
+			m.state = StateInput
+			m.output.AppendLine(m.styles.Warning.Render(" Changes rejected"))
+			m.output.AppendLine("")
+			cmds = append(cmds, m.input.Focus())
+		}
+		if m.onMultiDiffDecision != nil {
+			m.onMultiDiffDecision(msg.Results)
+		}
+
 	case SearchResultsRequestMsg:
 		m.searchRequest = &msg
 		m.searchResults.SetSize(m.width, m.height)
@@ -1884,6 +1934,12 @@ func (m Model) View() string {
 		builder.WriteString("\n")
 	}
 
+	// Multi-file diff preview
+	if m.state == StateMultiDiffPreview {
+		builder.WriteString(m.multiDiffPreview.View())
+		builder.WriteString("\n")
+	}
+
 	// Search results
 	if m.state == StateSearchResults {
 		builder.WriteString(m.searchResults.View())
@@ -2131,6 +2187,24 @@ func (m *Model) GetBackgroundTasks() map[string]*BackgroundTaskState {
 
 // ========== Toast Notification Methods ==========
 
+// appendPartialApplyNote notes hunks that were rejected or edited in an
+// applied diff.
+func (m *Model) appendPartialApplyNote(result DiffResult) {
+	if !result.Partial() {
+		return
+	}
+	rejected, edited := 0, 0
+	for _, h := range result.Hunks {
+		switch h.Decision {
+		case HunkRejected:
+			rejected++
+		case HunkEdited:
+			edited++
+		}
+	}
+	m.output.AppendLine(m.styles.Warning.Render(fmt.Sprintf(" Partially applied: %d hunk(s) rejected, %d edited", rejected, edited)))
+}
+
 // ShowToastSuccess displays a success toast notification.
 func (m *Model) ShowToastSuccess(message string) {
 	if m.toastManager != nil {
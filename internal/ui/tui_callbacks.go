@@ -89,10 +89,15 @@ func (m *Model) GetSandboxEnabled() bool {
 }
 
 // SetDiffDecisionCallback sets the callback for diff preview decisions.
-func (m *Model) SetDiffDecisionCallback(onDiffDecision func(DiffDecision)) {
+func (m *Model) SetDiffDecisionCallback(onDiffDecision func(DiffResult)) {
 	m.onDiffDecision = onDiffDecision
 }
 
+// SetMultiDiffDecisionCallback sets the callback for multi-file diff preview decisions.
+func (m *Model) SetMultiDiffDecisionCallback(onMultiDiffDecision func(map[string]DiffResult)) {
+	m.onMultiDiffDecision = onMultiDiffDecision
+}
+
 // SetSearchActionCallback sets the callback for search result actions.
 func (m *Model) SetSearchActionCallback(onSearchAction func(SearchAction)) {
 	m.onSearchAction = onSearchAction
@@ -167,6 +172,11 @@ func (m *Model) SetPaletteProvider(provider PaletteProvider) {
 	}
 }
 
+// SetMentionProvider sets the provider for @mention completion in the input.
+func (m *Model) SetMentionProvider(provider MentionProvider) {
+	m.input.SetMentionProvider(provider)
+}
+
 // RegisterPaletteActions registers keyboard shortcut actions in the command palette.
 func (m *Model) RegisterPaletteActions() {
 	if m.commandPalette == nil {
@@ -207,6 +207,8 @@ func (m Model) getCommandHint(input string) string {
 		"save":        "Save the current session to disk",
 		"resume":      "Resume a previously saved session",
 		"sessions":    "List all saved sessions",
+		"history":     "Search prompts, tool calls and files of all saved sessions",
+		"rewind":      "Restore files and optionally the conversation to a checkpoint",
 		"commit":      "Create a git commit with AI-generated message",
 		"pr":          "Create a pull request",
 		"checkpoint":  "Save a checkpoint of the current state",
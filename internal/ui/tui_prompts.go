@@ -67,6 +67,16 @@ func (m Model) renderPermissionPrompt() string {
 		builder.WriteString("\n")
 	}
 
+	// What "Always" allows from now on
+	if m.permRequest.Scope != "" {
+		scope := m.permRequest.Scope
+		if len(scope) > 60 {
+			scope = scope[:57] + "..."
+		}
+		builder.WriteString(markerStyle.Render("     ") + labelStyle.Render("Always allows: ") + valueStyle.Render(scope))
+		builder.WriteString("\n")
+	}
+
 	builder.WriteString("\n")
 
 	// Inline options — single line
@@ -282,6 +292,13 @@ func (m Model) renderPlanApproval() string {
 	stepCount := len(m.planRequest.Steps)
 	headerInfo := fmt.Sprintf(" %d steps ", stepCount)
 	headerTitle := " Plan Approval "
+	if stepCount == 0 && m.planRequest.ContractName != "" {
+		// Contract proposed on its own (contract_propose)
+		clauseCount := len(m.planRequest.Preconditions) + len(m.planRequest.Postconditions) +
+			len(m.planRequest.Invariants) + len(m.planRequest.Examples)
+		headerInfo = fmt.Sprintf(" %d clauses ", clauseCount)
+		headerTitle = " Contract Approval "
+	}
 
 	// Calculate width using lipgloss.Width for styled text
 	styledTitleWidth := lipgloss.Width(titleStyle.Render(headerTitle))
@@ -339,16 +356,18 @@ func (m Model) renderPlanApproval() string {
 	builder.WriteString("\n")
 
 	// Steps header
-	stepsHeader := "  Steps:"
-	stepsHeaderPadding := panelWidth - 1 - lipgloss.Width(infoStyle.Render(stepsHeader))
-	if stepsHeaderPadding < 0 {
-		stepsHeaderPadding = 0
+	if stepCount > 0 {
+		stepsHeader := "  Steps:"
+		stepsHeaderPadding := panelWidth - 1 - lipgloss.Width(infoStyle.Render(stepsHeader))
+		if stepsHeaderPadding < 0 {
+			stepsHeaderPadding = 0
+		}
+		builder.WriteString(borderStyle.Render("│"))
+		builder.WriteString(infoStyle.Render(stepsHeader))
+		builder.WriteString(strings.Repeat(" ", stepsHeaderPadding))
+		builder.WriteString(borderStyle.Render("│"))
+		builder.WriteString("\n")
 	}
-	builder.WriteString(borderStyle.Render("│"))
-	builder.WriteString(infoStyle.Render(stepsHeader))
-	builder.WriteString(strings.Repeat(" ", stepsHeaderPadding))
-	builder.WriteString(borderStyle.Render("│"))
-	builder.WriteString("\n")
 
 	// Steps tree view
 	for _, step := range m.planRequest.Steps {
@@ -386,10 +405,12 @@ func (m Model) renderPlanApproval() string {
 
 	// Contract info (if present)
 	if m.planRequest.ContractName != "" {
-		builder.WriteString(borderStyle.Render("│"))
-		builder.WriteString(strings.Repeat(" ", panelWidth-1))
-		builder.WriteString(borderStyle.Render("│"))
-		builder.WriteString("\n")
+		if stepCount > 0 {
+			builder.WriteString(borderStyle.Render("│"))
+			builder.WriteString(strings.Repeat(" ", panelWidth-1))
+			builder.WriteString(borderStyle.Render("│"))
+			builder.WriteString("\n")
+		}
 
 		contractLine := "  Contract: " + m.planRequest.ContractName
 		styledContractLine := lipgloss.NewStyle().Foreground(ColorAccent).Render(contractLine)
@@ -402,6 +423,46 @@ func (m Model) renderPlanApproval() string {
 		builder.WriteString(strings.Repeat(" ", contractPadding))
 		builder.WriteString(borderStyle.Render("│"))
 		builder.WriteString("\n")
+
+		// writeContractLine writes a single truncated, padded line inside the panel.
+		writeContractLine := func(indent, text string, style lipgloss.Style) {
+			maxLen := panelWidth - 3 - len(indent)
+			if maxLen > 3 && len(text) > maxLen {
+				text = text[:maxLen-3] + "..."
+			}
+			line := indent + style.Render(text)
+			builder.WriteString(borderStyle.Render("│"))
+			builder.WriteString(line)
+			if padding := panelWidth - 1 - lipgloss.Width(line); padding > 0 {
+				builder.WriteString(strings.Repeat(" ", padding))
+			}
+			builder.WriteString(borderStyle.Render("│"))
+			builder.WriteString("\n")
+		}
+
+		if m.planRequest.Intent != "" {
+			writeContractLine("  ", m.planRequest.Intent, descStyle)
+		}
+
+		sections := []struct {
+			title string
+			items []string
+		}{
+			{"Boundaries", m.planRequest.Boundaries},
+			{"Preconditions", m.planRequest.Preconditions},
+			{"Postconditions", m.planRequest.Postconditions},
+			{"Invariants", m.planRequest.Invariants},
+			{"Examples", m.planRequest.Examples},
+		}
+		for _, section := range sections {
+			if len(section.items) == 0 {
+				continue
+			}
+			writeContractLine("  ", section.title+":", infoStyle)
+			for _, item := range section.items {
+				writeContractLine("    ", "• "+item, stepTitleStyle)
+			}
+		}
 	}
 
 	// Empty line before options
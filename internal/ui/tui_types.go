@@ -18,6 +18,7 @@ const (
 	StateShortcutsOverlay
 	StateCommandPalette
 	StateDiffPreview
+	StateMultiDiffPreview
 	StateSearchResults
 	StateGitStatus
 	StateFileBrowser
@@ -112,6 +113,7 @@ type (
 		Args      map[string]any
 		RiskLevel string
 		Reason    string
+		Scope     string // Rule "always" grants, when narrower than the tool
 	}
 	// PermissionResponseMsg carries the user's permission decision.
 	PermissionResponseMsg struct {
@@ -133,11 +135,13 @@ type (
 		Description string
 		Steps       []PlanStepInfo
 		// Contract fields (optional, shown when ContractName is non-empty)
-		ContractName string
-		Intent       string
-		Boundaries   []string // Pre-formatted
-		Invariants   []string
-		Examples     []string
+		ContractName   string
+		Intent         string
+		Boundaries     []string // Pre-formatted
+		Preconditions  []string
+		Postconditions []string
+		Invariants     []string
+		Examples       []string
 	}
 	// PlanStepInfo contains info about a plan step for display.
 	PlanStepInfo struct {
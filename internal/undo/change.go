@@ -3,6 +3,7 @@ package undo
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"fmt"
 	"time"
 )
 
@@ -15,6 +16,10 @@ type FileChange struct {
 	OldContent []byte    `json:"old_content"` // nil for new files
 	NewContent []byte    `json:"new_content"`
 	WasNew     bool      `json:"was_new"` // file was created (didn't exist before)
+
+	// Files holds the changes of a multi-file transaction, which is undone
+	// and redone as a single step. FilePath is empty for transactions.
+	Files []FileChange `json:"files,omitempty"`
 }
 
 // NewFileChange creates a new FileChange with a generated ID.
@@ -30,6 +35,28 @@ func NewFileChange(filePath, tool string, oldContent, newContent []byte, wasNew
 	}
 }
 
+// NewTransaction groups changes to several files into one undoable change.
+func NewTransaction(tool string, changes []FileChange) *FileChange {
+	return &FileChange{
+		ID:        generateID(),
+		Tool:      tool,
+		Timestamp: time.Now(),
+		Files:     changes,
+	}
+}
+
+// Paths returns the files affected by the change.
+func (c *FileChange) Paths() []string {
+	if len(c.Files) == 0 {
+		return []string{c.FilePath}
+	}
+	paths := make([]string, len(c.Files))
+	for i, f := range c.Files {
+		paths[i] = f.FilePath
+	}
+	return paths
+}
+
 // generateID creates a unique identifier for a change.
 func generateID() string {
 	b := make([]byte, 8)
@@ -39,6 +66,9 @@ func generateID() string {
 
 // Summary returns a human-readable summary of the change.
 func (c *FileChange) Summary() string {
+	if len(c.Files) > 0 {
+		return fmt.Sprintf("modified %d files", len(c.Files))
+	}
 	if c.WasNew {
 		return "created " + c.FilePath
 	}
@@ -47,5 +77,9 @@ func (c *FileChange) Summary() string {
 
 // SizeChange returns the size difference in bytes.
 func (c *FileChange) SizeChange() int {
-	return len(c.NewContent) - len(c.OldContent)
+	size := len(c.NewContent) - len(c.OldContent)
+	for i := range c.Files {
+		size += c.Files[i].SizeChange()
+	}
+	return size
 }
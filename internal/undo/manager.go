@@ -170,6 +170,19 @@ func (m *Manager) SetRedoStack(stack []FileChange) {
 
 // revertChange reverts a file change to its previous state.
 func (m *Manager) revertChange(change *FileChange) error {
+	if len(change.Files) > 0 {
+		// Revert newest first; on failure restore what was already reverted
+		for i := len(change.Files) - 1; i >= 0; i-- {
+			if err := m.revertChange(&change.Files[i]); err != nil {
+				for j := i + 1; j < len(change.Files); j++ {
+					_ = m.applyChange(&change.Files[j])
+				}
+				return fmt.Errorf("%s: %w", change.Files[i].FilePath, err)
+			}
+		}
+		return nil
+	}
+
 	if change.WasNew {
 		// File was created - delete it
 		if err := os.Remove(change.FilePath); err != nil && !os.IsNotExist(err) {
@@ -189,6 +202,18 @@ func (m *Manager) revertChange(change *FileChange) error {
 
 // applyChange applies a file change (for redo).
 func (m *Manager) applyChange(change *FileChange) error {
+	if len(change.Files) > 0 {
+		for i := range change.Files {
+			if err := m.applyChange(&change.Files[i]); err != nil {
+				for j := i - 1; j >= 0; j-- {
+					_ = m.revertChange(&change.Files[j])
+				}
+				return fmt.Errorf("%s: %w", change.Files[i].FilePath, err)
+			}
+		}
+		return nil
+	}
+
 	dir := filepath.Dir(change.FilePath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err